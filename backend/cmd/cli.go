@@ -0,0 +1,187 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"reconya-ai/db"
+	"reconya-ai/internal/config"
+	"reconya-ai/internal/device"
+	"reconya-ai/internal/eventlog"
+	"reconya-ai/internal/network"
+)
+
+// command is a CLI subcommand that runs once and returns an exit status
+type command struct {
+	summary string
+	run     func(args []string) int
+}
+
+var commands = map[string]command{
+	"scan":     {"one-shot ping sweep and port scan of a CIDR", runScanCommand},
+	"devices":  {"list, show, export or wake devices", runDevicesCommand},
+	"networks": {"add, list or remove networks", runNetworksCommand},
+	"db":       {"migrate, back up or vacuum the database", runDBCommand},
+	"user":     {"add or list login users", runUserCommand},
+	"import":   {"import devices from scanner output and lease files", runImportCommand},
+	"oui":      {"look up MAC vendors or update the offline vendor database", runOUICommand},
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: reconya [command] [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "  serve\tstart the web server (default)\n")
+	for _, name := range names {
+		fmt.Fprintf(tw, "  %s\t%s\n", name, commands[name].summary)
+	}
+	tw.Flush()
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'reconya <command> -h' for the flags of a command.")
+}
+
+// cliApp holds the database and services shared by the CLI commands
+type cliApp struct {
+	cfg             *config.Config
+	db              *sql.DB
+	repoFactory     *db.RepositoryFactory
+	networkService  *network.NetworkService
+	deviceService   *device.DeviceService
+	eventLogService *eventlog.EventLogService
+}
+
+// openDatabase loads the configuration and opens the database without
+// touching its schema
+func openDatabase() (*config.Config, *sql.DB, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load configuration: %v", err)
+	}
+
+	database, err := connectDatabase(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cfg, database, nil
+}
+
+// connectDatabase opens the database selected by DATABASE_TYPE
+func connectDatabase(cfg *config.Config) (*sql.DB, error) {
+	if cfg.DatabaseType == config.Postgres {
+		database, err := db.ConnectToPostgres(cfg.PostgresURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to PostgreSQL: %v", err)
+		}
+		return database, nil
+	}
+
+	database, err := db.ConnectToSQLite(cfg.SQLitePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to SQLite: %v", err)
+	}
+	return database, nil
+}
+
+// newRepositoryFactory creates the repository factory for the configured database
+func newRepositoryFactory(cfg *config.Config, database *sql.DB) *db.RepositoryFactory {
+	if cfg.DatabaseType == config.Postgres {
+		return db.NewPostgresRepositoryFactory(database, cfg.DatabaseName)
+	}
+	return db.NewRepositoryFactory(database, cfg.DatabaseName)
+}
+
+// openApp opens the database, applies pending migrations and wires the core
+// services the same way the server does
+func openApp() (*cliApp, error) {
+	cfg, database, err := openDatabase()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.InitializeSchema(database); err != nil {
+		database.Close()
+		return nil, fmt.Errorf("failed to initialize database schema: %v", err)
+	}
+
+	repoFactory := newRepositoryFactory(cfg, database)
+
+	// Vendor lookup is optional; commands work without the OUI database
+	ouiService, err := loadOUIService(ouiDataPath(cfg))
+	if err != nil {
+		ouiService = nil
+	}
+
+	networkService := network.NewNetworkService(repoFactory.NewNetworkRepository(), cfg)
+	deviceService := device.NewDeviceService(repoFactory.NewDeviceRepository(), networkService, cfg, ouiService)
+	eventLogService := eventlog.NewEventLogService(repoFactory.NewEventLogRepository(), deviceService)
+
+	return &cliApp{
+		cfg:             cfg,
+		db:              database,
+		repoFactory:     repoFactory,
+		networkService:  networkService,
+		deviceService:   deviceService,
+		eventLogService: eventLogService,
+	}, nil
+}
+
+func (a *cliApp) Close() {
+	a.db.Close()
+}
+
+// openAppOrFail opens the app and reports failures on stderr
+func openAppOrFail() (*cliApp, bool) {
+	app, err := openApp()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return nil, false
+	}
+	return app, true
+}
+
+func printJSON(v interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// runSubcommands dispatches "reconya <group> <action>" to one of the actions
+func runSubcommands(group string, args []string, actions map[string]func([]string) int) int {
+	names := make([]string, 0, len(actions))
+	for name := range actions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		fmt.Fprintf(os.Stderr, "Usage: reconya %s <%s> [arguments]\n", group, strings.Join(names, "|"))
+		if len(args) == 0 {
+			return 2
+		}
+		return 0
+	}
+
+	action, ok := actions[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "reconya %s: unknown command %q (want %s)\n", group, args[0], strings.Join(names, ", "))
+		return 2
+	}
+	return action(args[1:])
+}
+
+func newTable() *tabwriter.Writer {
+	return tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+}
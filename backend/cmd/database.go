@@ -0,0 +1,319 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"reconya-ai/db"
+	"reconya-ai/internal/backup"
+	"reconya-ai/internal/config"
+	"reconya-ai/internal/retention"
+)
+
+// runDBCommand handles "reconya db migrate|status|backup|backups|restore|prune|vacuum"
+func runDBCommand(args []string) int {
+	return runSubcommands("db", args, map[string]func([]string) int{
+		"migrate": runDBMigrate,
+		"status":  runDBStatus,
+		"backup":  runDBBackup,
+		"backups": runDBBackups,
+		"restore": runDBRestore,
+		"prune":   runDBPrune,
+		"vacuum":  runDBVacuum,
+	})
+}
+
+// reconya db migrate [-to VERSION]
+func runDBMigrate(args []string) int {
+	flags := flag.NewFlagSet("db migrate", flag.ContinueOnError)
+	to := flags.Int("to", -1, "migrate up or down to this schema version (default: latest)")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg, database, err := openDatabase()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer database.Close()
+
+	migrator := db.NewMigrator(database)
+	target := *to
+	if target < 0 {
+		target = migrator.Latest()
+	}
+
+	count, err := migrator.MigrateTo(target)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	version, err := migrator.Version()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Printf("Database %s is at schema version %d (%d migrations run)\n", cfg.SQLitePath, version, count)
+	return 0
+}
+
+// reconya db status [-format table|json]
+func runDBStatus(args []string) int {
+	flags := flag.NewFlagSet("db status", flag.ContinueOnError)
+	format := flags.String("format", "table", "output format: table or json")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	_, database, err := openDatabase()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer database.Close()
+
+	statuses, err := db.NewMigrator(database).Status()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if *format == "json" {
+		if err := printJSON(statuses); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		return 0
+	}
+
+	tw := newTable()
+	fmt.Fprintln(tw, "VERSION\tNAME\tAPPLIED")
+	for _, status := range statuses {
+		applied := "pending"
+		if status.AppliedAt != nil {
+			applied = status.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\n", status.Version, status.Name, applied)
+	}
+	tw.Flush()
+	return 0
+}
+
+// reconya db backup [FILE]
+//
+// Without FILE the backup goes to the backup directory, encrypted when
+// BACKUP_PASSPHRASE is set, and the retention policy is applied.
+func runDBBackup(args []string) int {
+	flags := flag.NewFlagSet("db backup", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: reconya db backup [FILE]")
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return 2
+	}
+
+	app, ok := openAppOrFail()
+	if !ok {
+		return 1
+	}
+	defer app.Close()
+
+	if flags.NArg() == 1 {
+		path := flags.Arg(0)
+		if err := db.BackupTo(app.db, path); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Printf("Backed up %s to %s\n", app.cfg.SQLitePath, path)
+		return 0
+	}
+
+	service := backup.NewBackupService(app.db, db.NewMigrator(app.db), app.cfg)
+	created, err := service.Create()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Printf("Backed up %s to %s\n", app.cfg.SQLitePath, filepath.Join(app.cfg.BackupDir, created.Name))
+
+	removed, err := service.Prune()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	for _, name := range removed {
+		fmt.Printf("Removed %s\n", name)
+	}
+	return 0
+}
+
+// reconya db backups [-format table|json]
+func runDBBackups(args []string) int {
+	flags := flag.NewFlagSet("db backups", flag.ContinueOnError)
+	format := flags.String("format", "table", "output format: table or json")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg, database, err := openDatabase()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer database.Close()
+
+	backups, err := backup.NewBackupService(database, db.NewMigrator(database), cfg).List()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if *format == "json" {
+		if err := printJSON(backups); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		return 0
+	}
+
+	tw := newTable()
+	fmt.Fprintln(tw, "NAME\tCREATED\tSIZE\tENCRYPTED")
+	for _, b := range backups {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%t\n", b.Name, b.CreatedAt.Format("2006-01-02 15:04:05"), b.Size, b.Encrypted)
+	}
+	tw.Flush()
+	return 0
+}
+
+// reconya db restore FILE|NAME
+func runDBRestore(args []string) int {
+	flags := flag.NewFlagSet("db restore", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: reconya db restore FILE|NAME")
+		fmt.Fprintln(flags.Output(), "NAME refers to a backup in the backup directory (see \"reconya db backups\").")
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	app, ok := openAppOrFail()
+	if !ok {
+		return 1
+	}
+	defer app.Close()
+
+	service := backup.NewBackupService(app.db, db.NewMigrator(app.db), app.cfg)
+	path := flags.Arg(0)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if stored, pathErr := service.Path(path); pathErr == nil {
+			path = stored
+		}
+	}
+
+	result, err := service.Restore(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Printf("Restored %s from %s (backup schema version %d, now %d)\n", app.cfg.SQLitePath, path, result.BackupVersion, result.SchemaVersion)
+	fmt.Printf("The previous database was saved as %s\n", result.SafetyBackup)
+	return 0
+}
+
+// reconya db prune [-dry-run] [-format table|json]
+func runDBPrune(args []string) int {
+	flags := flag.NewFlagSet("db prune", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "only count the rows that would be deleted")
+	format := flags.String("format", "table", "output format: table or json")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	app, ok := openAppOrFail()
+	if !ok {
+		return 1
+	}
+	defer app.Close()
+
+	report, err := retention.NewRetentionService(app.repoFactory.NewRetentionRepository(), app.cfg).Run(*dryRun)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if *format == "json" {
+		if err := printJSON(report); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		return 0
+	}
+
+	tw := newTable()
+	fmt.Fprintln(tw, "TABLE\tTYPE\tOLDER THAN\tDELETED")
+	for _, result := range report.Results {
+		eventType := result.Type
+		if eventType == "" {
+			eventType = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\n", result.Table, eventType, result.Before.Format("2006-01-02 15:04:05"), result.Deleted)
+	}
+	tw.Flush()
+
+	verb := "Deleted"
+	if *dryRun {
+		verb = "Would delete"
+	}
+	fmt.Printf("%s %d rows\n", verb, report.Deleted)
+	return 0
+}
+
+// reconya db vacuum
+func runDBVacuum(args []string) int {
+	flags := flag.NewFlagSet("db vacuum", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	app, ok := openAppOrFail()
+	if !ok {
+		return 1
+	}
+	defer app.Close()
+
+	if app.cfg.DatabaseType == config.Postgres {
+		if err := db.Vacuum(app.db); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Println("Vacuumed PostgreSQL database")
+		return 0
+	}
+
+	before := fileSize(app.cfg.SQLitePath)
+	if err := db.Vacuum(app.db); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Printf("Vacuumed %s: %d -> %d bytes\n", app.cfg.SQLitePath, before, fileSize(app.cfg.SQLitePath))
+	return 0
+}
+
+func fileSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
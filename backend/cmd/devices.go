@@ -0,0 +1,304 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"reconya-ai/internal/exclusion"
+	"reconya-ai/internal/export"
+	"reconya-ai/internal/wol"
+	"reconya-ai/models"
+)
+
+// runDevicesCommand handles "reconya devices list|show|export|wake"
+func runDevicesCommand(args []string) int {
+	return runSubcommands("devices", args, map[string]func([]string) int{
+		"list":   runDevicesList,
+		"show":   runDevicesShow,
+		"export": runDevicesExport,
+		"wake":   runDevicesWake,
+	})
+}
+
+// deviceFilterFlags registers the filter flags shared by list and export
+func deviceFilterFlags(flags *flag.FlagSet) func(service *export.ExportService) (export.Filter, error) {
+	networkValue := flags.String("network", "", "only devices in this network (ID or CIDR)")
+	tag := flags.String("tag", "", "only devices with this tag")
+	status := flags.String("status", "", "only devices with this status (online, idle, offline, unknown)")
+
+	return func(service *export.ExportService) (export.Filter, error) {
+		networkID, err := service.ResolveNetwork(*networkValue)
+		if err != nil {
+			return export.Filter{}, err
+		}
+		return export.Filter{
+			NetworkID: networkID,
+			Tag:       *tag,
+			Status:    models.DeviceStatus(*status),
+		}, nil
+	}
+}
+
+// reconya devices list [-network N] [-tag T] [-status S] [-format table|json]
+func runDevicesList(args []string) int {
+	flags := flag.NewFlagSet("devices list", flag.ContinueOnError)
+	filterFromFlags := deviceFilterFlags(flags)
+	format := flags.String("format", "table", "output format: table or json")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *format != "table" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "unknown output format %q\n", *format)
+		return 2
+	}
+
+	app, ok := openAppOrFail()
+	if !ok {
+		return 1
+	}
+	defer app.Close()
+
+	exportService := export.NewExportService(app.deviceService, app.networkService)
+	filter, err := filterFromFlags(exportService)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	devices, err := exportService.Devices(filter)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if *format == "json" {
+		if err := printJSON(devices); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		return 0
+	}
+
+	tw := newTable()
+	fmt.Fprintln(tw, "ID\tIP\tMAC\tNAME\tHOSTNAME\tSTATUS\tLAST SEEN")
+	for _, d := range devices {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			d.ID, d.IPv4, valueOrDash(d.MAC), valueOrDash(&d.Name), valueOrDash(d.Hostname),
+			d.Status, formatLastSeen(d.LastSeenOnlineAt))
+	}
+	tw.Flush()
+	return 0
+}
+
+// reconya devices show [-format table|json] ID|IP
+func runDevicesShow(args []string) int {
+	flags := flag.NewFlagSet("devices show", flag.ContinueOnError)
+	format := flags.String("format", "table", "output format: table or json")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: reconya devices show [flags] ID|IP")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	app, ok := openAppOrFail()
+	if !ok {
+		return 1
+	}
+	defer app.Close()
+
+	d, ok := findDevice(app, flags.Arg(0))
+	if !ok {
+		return 1
+	}
+
+	if *format == "json" {
+		if err := printJSON(d); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		return 0
+	}
+
+	tw := newTable()
+	fmt.Fprintf(tw, "ID\t%s\n", d.ID)
+	fmt.Fprintf(tw, "Name\t%s\n", valueOrDash(&d.Name))
+	fmt.Fprintf(tw, "IPv4\t%s\n", d.IPv4)
+	if len(d.IPv6Addresses) > 0 {
+		fmt.Fprintf(tw, "IPv6\t%s\n", strings.Join(d.IPv6Addresses, ", "))
+	}
+	fmt.Fprintf(tw, "MAC\t%s\n", valueOrDash(d.MAC))
+	fmt.Fprintf(tw, "Vendor\t%s\n", valueOrDash(d.Vendor))
+	fmt.Fprintf(tw, "Hostname\t%s\n", valueOrDash(d.Hostname))
+	fmt.Fprintf(tw, "Type\t%s\n", d.DeviceType)
+	if d.OS != nil && d.OS.Name != "" {
+		fmt.Fprintf(tw, "OS\t%s %s\n", d.OS.Name, d.OS.Version)
+	}
+	fmt.Fprintf(tw, "Status\t%s\n", d.Status)
+	fmt.Fprintf(tw, "Last seen\t%s\n", formatLastSeen(d.LastSeenOnlineAt))
+	if len(d.Tags) > 0 {
+		fmt.Fprintf(tw, "Tags\t%s\n", strings.Join(d.Tags, ", "))
+	}
+	fmt.Fprintf(tw, "Open ports\t%s\n", formatOpenPorts(d.Ports))
+	for _, ws := range d.WebServices {
+		fmt.Fprintf(tw, "Web service\t%s\n", ws.URL)
+	}
+	if d.Comment != nil && *d.Comment != "" {
+		fmt.Fprintf(tw, "Comment\t%s\n", *d.Comment)
+	}
+	tw.Flush()
+	return 0
+}
+
+// reconya devices wake [-confirm] [-timeout D] ID|IP
+func runDevicesWake(args []string) int {
+	flags := flag.NewFlagSet("devices wake", flag.ContinueOnError)
+	confirm := flags.Bool("confirm", false, "wait for the device to answer a ping")
+	timeout := flags.Duration("timeout", 0, "how long -confirm waits (default WOL_CONFIRM_TIMEOUT)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: reconya devices wake [flags] ID|IP")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	app, ok := openAppOrFail()
+	if !ok {
+		return 1
+	}
+	defer app.Close()
+
+	d, ok := findDevice(app, flags.Arg(0))
+	if !ok {
+		return 1
+	}
+
+	wakeService := wol.NewWakeService(app.deviceService, app.networkService, app.eventLogService, app.cfg)
+	wakeService.Exclusions = exclusion.NewExclusionService(app.repoFactory.NewExclusionRepository())
+	result, err := wakeService.Wake(d, wol.WakeOptions{Confirm: *confirm, Timeout: *timeout})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	via := ""
+	if result.Interface != "" {
+		via = " via " + result.Interface
+	}
+	fmt.Printf("Magic packet for %s sent to %s port %d%s\n", result.MAC, strings.Join(result.Broadcasts, ", "), result.Port, via)
+	if result.Confirmed == nil {
+		return 0
+	}
+	if !*result.Confirmed {
+		fmt.Fprintf(os.Stderr, "%s did not come online\n", d.IPv4)
+		return 1
+	}
+	fmt.Printf("%s came online after %.0fs\n", d.IPv4, *result.OnlineAfterSeconds)
+	return 0
+}
+
+// findDevice looks a device up by ID or IPv4 address and reports failures on
+// stderr
+func findDevice(app *cliApp, value string) (*models.Device, bool) {
+	var d *models.Device
+	var err error
+	if net.ParseIP(value) != nil {
+		d, err = app.deviceService.FindByIPv4(value)
+	} else {
+		d, err = app.deviceService.FindByID(value)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return nil, false
+	}
+	if d == nil {
+		fmt.Fprintf(os.Stderr, "device %q not found\n", value)
+		return nil, false
+	}
+	return d, true
+}
+
+// reconya devices export [-format csv] [-rows devices] [-o FILE] [filters]
+func runDevicesExport(args []string) int {
+	flags := flag.NewFlagSet("devices export", flag.ContinueOnError)
+	filterFromFlags := deviceFilterFlags(flags)
+	formatName := flags.String("format", "csv", "export format: csv, json, ndjson, xlsx or cyclonedx")
+	rowsName := flags.String("rows", "devices", "CSV rows: devices, ports or web_services")
+	output := flags.String("o", "", "write to this file instead of stdout")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	format, err := export.ParseFormat(*formatName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	rows, err := export.ParseRows(*rowsName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	app, ok := openAppOrFail()
+	if !ok {
+		return 1
+	}
+	defer app.Close()
+
+	exportService := export.NewExportService(app.deviceService, app.networkService)
+	filter, err := filterFromFlags(exportService)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	devices, err := exportService.Devices(filter)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	networks, err := exportService.Networks()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		defer file.Close()
+		w = file
+	}
+
+	opts := export.Options{Rows: rows, GeneratedAt: time.Now(), Networks: networks}
+	if err := export.Write(w, format, devices, opts); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write %s export: %v\n", format, err)
+		return 1
+	}
+	return 0
+}
+
+func formatLastSeen(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return "never"
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"reconya-ai/internal/importer"
+)
+
+// runImportCommand imports devices from files into the configured database:
+//
+//	reconya import [-format auto] [-network CIDR] [-dry-run] [-json] FILE...
+func runImportCommand(args []string) int {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	formatName := flags.String("format", "auto", fmt.Sprintf("file format: auto, %v", importer.Formats))
+	networkCIDR := flags.String("network", "", "network CIDR for devices that don't name one")
+	dryRun := flags.Bool("dry-run", false, "report what would change without writing")
+	jsonOutput := flags.Bool("json", false, "print the result as JSON")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: reconya import [flags] FILE...")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	format, err := importer.ParseFormat(*formatName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	app, ok := openAppOrFail()
+	if !ok {
+		return 1
+	}
+	defer app.Close()
+
+	importService := importer.NewImportService(app.deviceService, app.networkService)
+
+	opts := importer.Options{Format: format, Network: *networkCIDR, DryRun: *dryRun}
+
+	status := 0
+	for _, path := range flags.Args() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+			status = 1
+			continue
+		}
+
+		result, err := importService.ImportFile(path, data, opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+			status = 1
+			continue
+		}
+
+		if *jsonOutput {
+			printJSON(result)
+			continue
+		}
+		printImportResult(path, result)
+	}
+	return status
+}
+
+func printImportResult(path string, result *importer.Result) {
+	prefix := ""
+	if result.DryRun {
+		prefix = "[dry run] "
+	}
+	fmt.Printf("%s%s (%s): %d records, %d created, %d updated, %d unchanged, %d skipped\n",
+		prefix, path, result.Format, result.Records, result.Created, result.Updated, result.Unchanged, result.Skipped)
+	for _, cidr := range result.NetworksCreated {
+		fmt.Printf("  + network %s\n", cidr)
+	}
+
+	for _, d := range result.Devices {
+		switch d.Action {
+		case importer.ActionCreate:
+			fmt.Printf("  + %s %s\n", d.IPv4, d.MAC)
+		case importer.ActionUpdate:
+			fmt.Printf("  ~ %s %s\n", d.IPv4, d.MAC)
+		case importer.ActionSkip:
+			fmt.Printf("  ! %s: %s\n", d.Source, d.Reason)
+			continue
+		default:
+			continue
+		}
+		for _, change := range d.Changes {
+			fmt.Printf("      %s: %q -> %q\n", change.Field, change.From, change.To)
+		}
+	}
+}
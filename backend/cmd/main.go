@@ -3,35 +3,54 @@ package main
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"io"
 	"log"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
-	"path/filepath"
 	"runtime"
 	"runtime/debug"
 	"syscall"
 	"time"
 
 	"reconya-ai/db"
+	"reconya-ai/internal/availability"
+	"reconya-ai/internal/backup"
 	"reconya-ai/internal/config"
+	"reconya-ai/internal/conflict"
 	"reconya-ai/internal/device"
+	"reconya-ai/internal/devicestatus"
 	"reconya-ai/internal/eventlog"
+	"reconya-ai/internal/exclusion"
+	"reconya-ai/internal/export"
+	"reconya-ai/internal/importer"
+	"reconya-ai/internal/infraserver"
 	"reconya-ai/internal/ipv6monitor"
+	"reconya-ai/internal/latency"
+	"reconya-ai/internal/neighbor"
 	"reconya-ai/internal/network"
 	"reconya-ai/internal/nicidentifier"
 	"reconya-ai/internal/oui"
 	"reconya-ai/internal/pingsweep"
 	"reconya-ai/internal/portscan"
+	"reconya-ai/internal/ratelimit"
+	"reconya-ai/internal/report"
+	"reconya-ai/internal/retention"
 	"reconya-ai/internal/scan"
+	"reconya-ai/internal/scanpolicy"
 	"reconya-ai/internal/settings"
 	"reconya-ai/internal/systemstatus"
+	"reconya-ai/internal/traceroute"
+	"reconya-ai/internal/user"
 	"reconya-ai/internal/web"
+	"reconya-ai/internal/wol"
 	"reconya-ai/middleware"
+	"reconya-ai/models"
 )
 
-func runDeviceUpdater(service *device.DeviceService, done <-chan bool) {
+func runDeviceUpdater(engine *devicestatus.StatusEngine, eventLogService *eventlog.EventLogService, done <-chan bool) {
 	defer func() {
 		if r := recover(); r != nil {
 			errorLogger.Printf("Device updater panic recovered: %v", r)
@@ -57,17 +76,128 @@ func runDeviceUpdater(service *device.DeviceService, done <-chan bool) {
 						errorLogger.Printf("UpdateDeviceStatuses stack: %s", debug.Stack())
 					}
 				}()
-				err := service.UpdateDeviceStatuses()
+				transitions, err := engine.Evaluate()
 				if err != nil {
 					infoLogger.Printf("Failed to update device statuses: %v", err)
 					// Add a delay after an error to allow other operations to complete
 					time.Sleep(1 * time.Second)
+					return
+				}
+
+				for _, transition := range transitions {
+					eventType := models.DeviceOffline
+					if transition.To == models.DeviceStatusIdle {
+						eventType = models.DeviceIdle
+					}
+					payload := &models.DeviceStatusPayload{PreviousStatus: transition.From, Status: transition.To}
+					if err := eventLogService.Log(eventType, "", transition.DeviceID, payload); err != nil {
+						infoLogger.Printf("Failed to log status change for device %s: %v", transition.DeviceID, err)
+					}
 				}
 			}()
 		}
 	}
 }
 
+// runNeighborTable keeps the neighbor table current from kernel notifications
+func runNeighborTable(table *neighbor.Table, done <-chan bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			errorLogger.Printf("Neighbor table panic recovered: %v", r)
+			errorLogger.Printf("Neighbor table stack trace: %s", debug.Stack())
+		}
+		infoLogger.Println("Neighbor table watcher stopped")
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-done
+		cancel()
+	}()
+
+	infoLogger.Println("Neighbor table watcher started")
+	if err := table.Run(ctx); err != nil {
+		errorLogger.Printf("Neighbor table watcher failed: %v", err)
+	}
+}
+
+// runNeighborPresence marks known IPv4 devices online as soon as they appear
+// in the neighbor table, without waiting for the next sweep
+func runNeighborPresence(table *neighbor.Table, deviceService *device.DeviceService, eventLogService *eventlog.EventLogService, done <-chan bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			errorLogger.Printf("Neighbor presence panic recovered: %v", r)
+			errorLogger.Printf("Neighbor presence stack trace: %s", debug.Stack())
+		}
+		infoLogger.Println("Neighbor presence service stopped")
+	}()
+
+	events, unsubscribe := table.Subscribe(256)
+	defer unsubscribe()
+
+	infoLogger.Println("Neighbor presence service started")
+	for {
+		select {
+		case <-done:
+			infoLogger.Println("Neighbor presence received shutdown signal")
+			return
+		case event := <-events:
+			if event.Type != neighbor.EventAdded || event.Entry.IsIPv6() {
+				continue
+			}
+
+			ip := event.Entry.IP.String()
+			device, previous, err := deviceService.MarkPresent(ip, event.Entry.MAC)
+			if err != nil {
+				infoLogger.Printf("Failed to mark neighbor %s present: %v", ip, err)
+				continue
+			}
+			if device == nil || previous == models.DeviceStatusOnline {
+				continue
+			}
+
+			payload := &models.DeviceStatusPayload{IPv4: ip, PreviousStatus: previous, Status: models.DeviceStatusOnline}
+			if err := eventLogService.Log(models.DeviceOnline, "", device.ID, payload); err != nil {
+				infoLogger.Printf("Failed to log device %s online: %v", device.ID, err)
+			}
+		}
+	}
+}
+
+// runConflictDetector checks every IPv4 neighbor that appears or changes its
+// MAC address for ARP spoofing and duplicate addresses
+func runConflictDetector(table *neighbor.Table, service *conflict.ConflictService, done <-chan bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			errorLogger.Printf("Conflict detector panic recovered: %v", r)
+			errorLogger.Printf("Conflict detector stack trace: %s", debug.Stack())
+		}
+		infoLogger.Println("Conflict detector stopped")
+	}()
+
+	events, unsubscribe := table.Subscribe(256)
+	defer unsubscribe()
+
+	infoLogger.Println("Conflict detector started")
+	for {
+		select {
+		case <-done:
+			infoLogger.Println("Conflict detector received shutdown signal")
+			return
+		case event := <-events:
+			if event.Type != neighbor.EventAdded || event.Entry.IsIPv6() {
+				continue
+			}
+
+			ip := event.Entry.IP.String()
+			if err := service.Observe("", ip, event.Entry.MAC, models.BindingSourceNeighbor); err != nil {
+				infoLogger.Printf("Failed to check neighbor %s for address conflicts: %v", ip, err)
+			}
+		}
+	}
+}
+
 func runGeolocationCacheCleanup(repo *db.GeolocationRepository, done <-chan bool) {
 	defer func() {
 		if r := recover(); r != nil {
@@ -110,6 +240,46 @@ func runGeolocationCacheCleanup(repo *db.GeolocationRepository, done <-chan bool
 	}
 }
 
+func runRetentionPruner(service *retention.RetentionService, interval time.Duration, done <-chan bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			errorLogger.Printf("Retention pruner panic recovered: %v", r)
+			errorLogger.Printf("Retention pruner stack trace: %s", debug.Stack())
+		}
+		infoLogger.Println("Retention pruner stopped")
+	}()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	infoLogger.Printf("Retention pruner started (interval %s)", interval)
+
+	// Run initial pruning
+	if err := service.RunScheduled(); err != nil {
+		errorLogger.Printf("Initial retention pruning failed: %v", err)
+	}
+
+	for {
+		select {
+		case <-done:
+			infoLogger.Println("Retention pruner received shutdown signal")
+			return
+		case <-ticker.C:
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						errorLogger.Printf("Retention pruning panic: %v", r)
+					}
+				}()
+
+				if err := service.RunScheduled(); err != nil {
+					errorLogger.Printf("Retention pruning failed: %v", err)
+				}
+			}()
+		}
+	}
+}
+
 func runNetworkDetection(nicService *nicidentifier.NicIdentifierService, done <-chan bool) {
 	defer func() {
 		if r := recover(); r != nil {
@@ -145,6 +315,206 @@ func runNetworkDetection(nicService *nicidentifier.NicIdentifierService, done <-
 	}
 }
 
+func runTracerouteMonitor(service *traceroute.TracerouteService, interval time.Duration, done <-chan bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			errorLogger.Printf("Traceroute monitor panic recovered: %v", r)
+			errorLogger.Printf("Traceroute monitor stack trace: %s", debug.Stack())
+		}
+		infoLogger.Println("Traceroute monitor stopped")
+	}()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	infoLogger.Printf("Traceroute monitor started (interval %s)", interval)
+
+	for {
+		select {
+		case <-done:
+			infoLogger.Println("Traceroute monitor received shutdown signal")
+			return
+		case <-ticker.C:
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						errorLogger.Printf("Traceroute iteration panic: %v", r)
+					}
+				}()
+
+				service.RunScheduled()
+			}()
+		}
+	}
+}
+
+func runLatencyMonitor(service *latency.LatencyService, interval time.Duration, done <-chan bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			errorLogger.Printf("Latency monitor panic recovered: %v", r)
+			errorLogger.Printf("Latency monitor stack trace: %s", debug.Stack())
+		}
+		infoLogger.Println("Latency monitor stopped")
+	}()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// Older samples are rolled up into hourly and daily ones
+	rollupTicker := time.NewTicker(1 * time.Hour)
+	defer rollupTicker.Stop()
+
+	infoLogger.Printf("Latency monitor started (interval %s)", interval)
+
+	for {
+		select {
+		case <-done:
+			infoLogger.Println("Latency monitor received shutdown signal")
+			return
+		case <-ticker.C:
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						errorLogger.Printf("Latency probe panic: %v", r)
+					}
+				}()
+
+				service.RunProbes()
+			}()
+		case <-rollupTicker.C:
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						errorLogger.Printf("Latency rollup panic: %v", r)
+					}
+				}()
+
+				service.Downsample()
+			}()
+		}
+	}
+}
+
+// runInfraServerWatcher records the router advertisements heard on the local
+// links and, when interval is set, probes for routers and DHCP servers
+func runInfraServerWatcher(watcher *infraserver.Watcher, interval time.Duration, done <-chan bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			errorLogger.Printf("Infra server watcher panic recovered: %v", r)
+			errorLogger.Printf("Infra server watcher stack trace: %s", debug.Stack())
+		}
+		infoLogger.Println("Infra server watcher stopped")
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		if err := watcher.ListenRouterAdvertisements(ctx); err != nil {
+			errorLogger.Printf("Router advertisement listener failed: %v", err)
+		}
+	}()
+
+	infoLogger.Printf("Infra server watcher started (probe interval %s)", interval)
+
+	if interval <= 0 {
+		<-done
+		return
+	}
+
+	watcher.Probe()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			infoLogger.Println("Infra server watcher received shutdown signal")
+			return
+		case <-ticker.C:
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						errorLogger.Printf("Infra server probe panic: %v", r)
+					}
+				}()
+
+				watcher.Probe()
+			}()
+		}
+	}
+}
+
+func runReportScheduler(service *report.ReportService, interval time.Duration, done <-chan bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			errorLogger.Printf("Report scheduler panic recovered: %v", r)
+			errorLogger.Printf("Report scheduler stack trace: %s", debug.Stack())
+		}
+		infoLogger.Println("Report scheduler stopped")
+	}()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	infoLogger.Printf("Report scheduler started (interval %s)", interval)
+
+	for {
+		select {
+		case <-done:
+			infoLogger.Println("Report scheduler received shutdown signal")
+			return
+		case <-ticker.C:
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						errorLogger.Printf("Scheduled report panic: %v", r)
+					}
+				}()
+
+				if err := service.RunScheduled(); err != nil {
+					errorLogger.Printf("Scheduled report failed: %v", err)
+				}
+			}()
+		}
+	}
+}
+
+func runBackupScheduler(service *backup.BackupService, interval time.Duration, done <-chan bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			errorLogger.Printf("Backup scheduler panic recovered: %v", r)
+			errorLogger.Printf("Backup scheduler stack trace: %s", debug.Stack())
+		}
+		infoLogger.Println("Backup scheduler stopped")
+	}()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	infoLogger.Printf("Backup scheduler started (interval %s)", interval)
+
+	for {
+		select {
+		case <-done:
+			infoLogger.Println("Backup scheduler received shutdown signal")
+			return
+		case <-ticker.C:
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						errorLogger.Printf("Scheduled backup panic: %v", r)
+					}
+				}()
+
+				if err := service.RunScheduled(); err != nil {
+					errorLogger.Printf("Scheduled backup failed: %v", err)
+				}
+			}()
+		}
+	}
+}
+
 // Global loggers for different output streams
 var (
 	infoLogger  = log.New(os.Stdout, "", log.LstdFlags)
@@ -152,18 +522,53 @@ var (
 )
 
 func main() {
+	args := os.Args[1:]
+
+	// Service logging is noisy on a terminal; -v keeps it for debugging
+	verbose := false
+	if len(args) > 0 && (args[0] == "-v" || args[0] == "--verbose") {
+		verbose = true
+		args = args[1:]
+	}
+
+	if len(args) == 0 || args[0] == "serve" {
+		serve()
+		return
+	}
+
+	switch args[0] {
+	case "help", "-h", "--help":
+		printUsage(os.Stdout)
+		return
+	}
+
+	// Subcommands run once and exit instead of starting the server
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "reconya: unknown command %q\n\n", args[0])
+		printUsage(os.Stderr)
+		os.Exit(2)
+	}
+	if !verbose {
+		log.SetOutput(io.Discard)
+	}
+	os.Exit(cmd.run(args[1:]))
+}
+
+// serve runs the web server and background services until interrupted
+func serve() {
 	// Ignore common termination signals to prevent external kills
 	signal.Ignore(syscall.SIGTERM, syscall.SIGQUIT)
 
 	// Set up global panic recovery with restart
 	defer func() {
 		if r := recover(); r != nil {
-			errorLogger.Printf("FATAL PANIC in main(): %v", r)
+			errorLogger.Printf("FATAL PANIC in serve(): %v", r)
 			errorLogger.Printf("Stack trace: %s", debug.Stack())
 			errorLogger.Printf("RESTARTING BACKEND IN 1 SECOND...")
 			time.Sleep(1 * time.Second)
-			// Restart the main function
-			main()
+			// Restart the server
+			serve()
 		}
 	}()
 
@@ -176,40 +581,45 @@ func main() {
 		infoLogger.Printf("Failed to load configuration: %v", err)
 		infoLogger.Printf("CRITICAL ERROR - RESTARTING IN 2 SECONDS...")
 		time.Sleep(2 * time.Second)
-		main() // Restart instead of fatal exit
+		serve() // Restart instead of fatal exit
 		return
 	}
 
 	// Create repositories factory
 	var repoFactory *db.RepositoryFactory
-	var sqliteDB *sql.DB
+	var database *sql.DB
 
-	infoLogger.Println("Using SQLite database")
-	sqliteDB, err = db.ConnectToSQLite(cfg.SQLitePath)
+	if cfg.DatabaseType == config.Postgres {
+		infoLogger.Println("Using PostgreSQL database")
+	} else {
+		infoLogger.Println("Using SQLite database")
+	}
+	database, err = connectDatabase(cfg)
 	if err != nil {
-		infoLogger.Printf("Failed to connect to SQLite: %v", err)
+		infoLogger.Printf("%v", err)
 		infoLogger.Printf("DATABASE ERROR - RESTARTING IN 3 SECONDS...")
 		time.Sleep(3 * time.Second)
-		main() // Restart instead of fatal exit
+		serve() // Restart instead of fatal exit
 		return
 	}
 
 	// Initialize database schema
-	if err := db.InitializeSchema(sqliteDB); err != nil {
+	if err := db.InitializeSchema(database); err != nil {
 		infoLogger.Printf("Failed to initialize database schema: %v", err)
 		infoLogger.Printf("SCHEMA ERROR - RESTARTING IN 3 SECONDS...")
 		time.Sleep(3 * time.Second)
-		main() // Restart instead of fatal exit
+		serve() // Restart instead of fatal exit
 		return
 	}
 
 	// Reset port scan cooldowns for development
 	infoLogger.Println("Resetting port scan cooldowns for development...")
-	if err := db.ResetPortScanCooldowns(sqliteDB); err != nil {
+	if err := db.ResetPortScanCooldowns(database); err != nil {
 		infoLogger.Printf("Warning: Failed to reset port scan cooldowns: %v", err)
 	}
 
-	repoFactory = db.NewRepositoryFactory(sqliteDB, cfg.DatabaseName)
+	repoFactory = newRepositoryFactory(cfg, database)
+	migrator := db.NewMigrator(database)
 
 	// Create repositories
 	networkRepo := repoFactory.NewNetworkRepository()
@@ -218,13 +628,14 @@ func main() {
 	systemStatusRepo := repoFactory.NewSystemStatusRepository()
 	geolocationRepo := repoFactory.NewGeolocationRepository()
 	settingsRepo := repoFactory.NewSettingsRepository()
-
-	// Create database manager for concurrent access control
-	dbManager := db.NewDBManager()
+	tracerouteRepo := repoFactory.NewTracerouteRepository()
+	latencyRepo := repoFactory.NewLatencyRepository()
+	availabilityRepo := repoFactory.NewAvailabilityRepository()
+	statusThresholdRepo := repoFactory.NewStatusThresholdRepository()
+	userRepo := repoFactory.NewUserRepository()
 
 	// Initialize OUI service for MAC address vendor lookup
-	ouiDataPath := filepath.Join(filepath.Dir(cfg.SQLitePath), "oui")
-	ouiService := oui.NewOUIService(ouiDataPath)
+	ouiService := oui.NewOUIService(ouiDataPath(cfg))
 	infoLogger.Println("Initializing OUI service...")
 	if err := ouiService.Initialize(); err != nil {
 		infoLogger.Printf("Warning: Failed to initialize OUI service: %v", err)
@@ -232,24 +643,97 @@ func main() {
 		ouiService = nil
 	} else {
 		stats := ouiService.GetStatistics()
-		infoLogger.Printf("OUI service initialized successfully - %v entries loaded, last updated: %v",
-			stats["total_entries"], stats["last_updated"])
+		infoLogger.Printf("OUI service initialized successfully - %v entries loaded from %v, last updated: %v",
+			stats["total_entries"], stats["source"], stats["last_updated"])
 	}
 
 	// Initialize services with repositories
-	networkService := network.NewNetworkService(networkRepo, cfg, dbManager)
-	deviceService := device.NewDeviceService(deviceRepo, networkService, cfg, dbManager, ouiService)
-	eventLogService := eventlog.NewEventLogService(eventLogRepo, deviceService, dbManager)
+	networkService := network.NewNetworkService(networkRepo, cfg)
+	deviceService := device.NewDeviceService(deviceRepo, networkService, cfg, ouiService)
+	eventLogService := eventlog.NewEventLogService(eventLogRepo, deviceService)
 	systemStatusService := systemstatus.NewSystemStatusService(systemStatusRepo)
 	settingsService := settings.NewSettingsService(settingsRepo)
+	userService := user.NewUserService(userRepo, cfg)
 	portScanService := portscan.NewPortScanService(deviceService, eventLogService)
+
+	// Per network, device type, tag and device scan settings
+	scanPolicyService := scanpolicy.NewScanPolicyService(repoFactory.NewScanPolicyRepository(), deviceService)
+	portScanService.Policies = scanPolicyService
+
+	// Addresses, ranges and MAC addresses that are never scanned
+	exclusionService := exclusion.NewExclusionService(repoFactory.NewExclusionRepository())
+	scanPolicyService.Exclusions = exclusionService
+
+	// Global and per network packet rates and scan windows
+	scanLimits, err := ratelimit.NewManager(cfg, networkService)
+	if err != nil {
+		infoLogger.Printf("Failed to set up scan rate limits: %v", err)
+		infoLogger.Printf("CRITICAL ERROR - RESTARTING IN 2 SECONDS...")
+		time.Sleep(2 * time.Second)
+		serve() // Restart instead of fatal exit
+		return
+	}
+	portScanService.Limits = scanLimits
 	pingSweepService := pingsweep.NewPingSweepService(cfg, deviceService, eventLogService, networkService, portScanService)
 
+	// Neighbor table (ARP and NDP) from kernel notifications; where netlink is
+	// unavailable the scanners fall back to the system's tools
+	var neighborTable *neighbor.Table
+	if neighborSource, err := neighbor.NewSystemSource(); err != nil {
+		infoLogger.Printf("Neighbor table notifications unavailable: %v", err)
+	} else {
+		neighborTable = neighbor.NewTable(neighborSource)
+		pingSweepService.Neighbors = neighborTable
+	}
+
 	// Initialize IPv6 monitoring service
-	ipv6MonitorService := ipv6monitor.NewIPv6MonitorService(deviceService, networkService, infoLogger)
+	ipv6MonitorService := ipv6monitor.NewIPv6MonitorService(deviceService, networkService, neighborTable, infoLogger)
+
+	// ARP spoofing and address conflict detection from sweeps and neighbors
+	conflictService := conflict.NewConflictService(repoFactory.NewIPMACBindingRepository(), networkService, eventLogService, cfg)
 
 	// Initialize scan manager to control scanning
 	scanManager := scan.NewScanManager(pingSweepService, networkService, ipv6MonitorService)
+	scanManager.Conflicts = conflictService
+	scanManager.Policies = scanPolicyService
+	scanManager.Limits = scanLimits
+
+	// Traceroute path discovery for networks and selected devices
+	tracerouteService := traceroute.NewTracerouteService(tracerouteRepo, networkService, deviceService, eventLogService, cfg)
+	tracerouteService.Exclusions = exclusionService
+
+	// Latency and packet loss monitoring for selected devices
+	latencyService := latency.NewLatencyService(latencyRepo, deviceService, eventLogService, cfg)
+	latencyService.Exclusions = exclusionService
+
+	// Status engine applying idle and offline thresholds
+	statusEngine := devicestatus.NewStatusEngine(statusThresholdRepo, deviceService, networkService, cfg)
+
+	// Availability reporting from recorded device status intervals
+	availabilityService := availability.NewAvailabilityService(availabilityRepo, deviceService)
+
+	// Scheduled and on-demand reports
+	reportService := report.NewReportService(deviceService, availabilityService, cfg)
+
+	// Inventory export for external systems
+	exportService := export.NewExportService(deviceService, networkService)
+
+	// Device and network import from scanner output and lease files
+	importService := importer.NewImportService(deviceService, networkService)
+
+	// Online database backups with retention
+	backupService := backup.NewBackupService(database, migrator, cfg)
+
+	// Pruning of old events and history
+	retentionService := retention.NewRetentionService(repoFactory.NewRetentionRepository(), cfg)
+
+	// Detection of rogue IPv6 routers and DHCP servers
+	infraServerService := infraserver.NewInfraServerService(repoFactory.NewInfraServerRepository(), networkService, eventLogService, cfg)
+	infraWatcher := infraserver.NewWatcher(infraServerService, neighborTable)
+
+	// Wake-on-LAN from the device page and API
+	wakeService := wol.NewWakeService(deviceService, networkService, eventLogService, cfg)
+	wakeService.Exclusions = exclusionService
 
 	// NIC identification for network detection and suggestions
 	nicService := nicidentifier.NewNicIdentifierService(networkService, systemStatusService, eventLogService, deviceService, cfg)
@@ -261,7 +745,14 @@ func main() {
 	nicService.Identify()
 
 	// Remove automatic ping sweep - now controlled by scan manager
-	go runDeviceUpdater(deviceService, done)
+	go runDeviceUpdater(statusEngine, eventLogService, done)
+
+	// Watch the neighbor table for devices coming and going
+	if neighborTable != nil {
+		go runNeighborTable(neighborTable, done)
+		go runNeighborPresence(neighborTable, deviceService, eventLogService, done)
+		go runConflictDetector(neighborTable, conflictService, done)
+	}
 
 	// Start periodic network detection
 	go runNetworkDetection(nicService, done)
@@ -269,9 +760,37 @@ func main() {
 	// Start geolocation cache cleanup routine
 	go runGeolocationCacheCleanup(geolocationRepo, done)
 
+	// Start pruning of old events and history
+	if cfg.RetentionInterval > 0 {
+		go runRetentionPruner(retentionService, cfg.RetentionInterval, done)
+	}
+
+	// Watch for routers and DHCP servers that are not on the allow-list
+	go runInfraServerWatcher(infraWatcher, cfg.InfraProbeInterval, done)
+
+	// Start periodic traceroute path discovery
+	if cfg.TracerouteInterval > 0 {
+		go runTracerouteMonitor(tracerouteService, cfg.TracerouteInterval, done)
+	}
+
+	// Start periodic latency probing
+	if cfg.LatencyProbeInterval > 0 {
+		go runLatencyMonitor(latencyService, cfg.LatencyProbeInterval, done)
+	}
+
+	// Start scheduled reports
+	if cfg.ReportInterval > 0 {
+		go runReportScheduler(reportService, cfg.ReportInterval, done)
+	}
+
+	// Start scheduled database backups; PostgreSQL is backed up with pg_dump
+	if cfg.BackupInterval > 0 && cfg.DatabaseType == config.SQLite {
+		go runBackupScheduler(backupService, cfg.BackupInterval, done)
+	}
+
 	// Initialize web handlers for HTMX frontend
 	sessionSecret := "your-secret-key-here-replace-in-production"
-	webHandler := web.NewWebHandler(deviceService, eventLogService, networkService, systemStatusService, scanManager, geolocationRepo, settingsService, nicService, cfg, sessionSecret)
+	webHandler := web.NewWebHandler(deviceService, eventLogService, networkService, systemStatusService, scanManager, geolocationRepo, settingsService, nicService, tracerouteService, latencyService, availabilityService, statusEngine, reportService, exportService, importService, userService, migrator, backupService, retentionService, infraServerService, wakeService, scanPolicyService, exclusionService, cfg, sessionSecret)
 	router := webHandler.SetupRoutes()
 	loggedRouter := middleware.LoggingMiddleware(router)
 
@@ -311,7 +830,7 @@ func main() {
 			}
 			infoLogger.Printf("SERVER ERROR - RESTARTING IN 2 SECONDS...")
 			time.Sleep(2 * time.Second)
-			main() // Restart instead of fatal exit
+			serve() // Restart instead of fatal exit
 			return
 		}
 		infoLogger.Println("Server ListenAndServe has exited normally")
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"os"
+
+	"reconya-ai/internal/ratelimit"
+	"reconya-ai/models"
+)
+
+// runNetworksCommand handles "reconya networks add|list|remove"
+func runNetworksCommand(args []string) int {
+	return runSubcommands("networks", args, map[string]func([]string) int{
+		"add":    runNetworksAdd,
+		"list":   runNetworksList,
+		"remove": runNetworksRemove,
+	})
+}
+
+// reconya networks add [-name NAME] [-description TEXT] [-rate-limit PPS] [-scan-windows SPEC] CIDR
+func runNetworksAdd(args []string) int {
+	flags := flag.NewFlagSet("networks add", flag.ContinueOnError)
+	name := flags.String("name", "", "network name")
+	description := flags.String("description", "", "network description")
+	rateLimit := flags.Int("rate-limit", 0, "packets per second for this network (default: SCAN_RATE_LIMIT)")
+	scanWindows := flags.String("scan-windows", "", `when the network may be scanned, e.g. "Mon-Fri 22:00-06:00" (default: SCAN_WINDOWS)`)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: reconya networks add [flags] CIDR")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	_, ipNet, err := net.ParseCIDR(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid CIDR %q: %v\n", flags.Arg(0), err)
+		return 2
+	}
+	cidr := ipNet.String()
+	if *rateLimit < 0 {
+		fmt.Fprintln(os.Stderr, "rate limit must be a positive number of packets per second")
+		return 2
+	}
+	if _, err := ratelimit.ParseSchedule(*scanWindows); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	app, ok := openAppOrFail()
+	if !ok {
+		return 1
+	}
+	defer app.Close()
+
+	existing, err := app.networkService.FindByCIDR(cidr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if existing != nil {
+		fmt.Fprintf(os.Stderr, "network %s already exists (%s)\n", cidr, existing.ID)
+		return 1
+	}
+
+	network, err := app.networkService.Create(*name, cidr, *description)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create network: %v\n", err)
+		return 1
+	}
+	if *rateLimit > 0 || *scanWindows != "" {
+		var limit *int
+		if *rateLimit > 0 {
+			limit = rateLimit
+		}
+		if network, err = app.networkService.SetScanLimits(network.ID, limit, *scanWindows); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to set scan limits: %v\n", err)
+			return 1
+		}
+	}
+	fmt.Printf("Added network %s (%s)\n", network.CIDR, network.ID)
+	return 0
+}
+
+// reconya networks list [-format table|json]
+func runNetworksList(args []string) int {
+	flags := flag.NewFlagSet("networks list", flag.ContinueOnError)
+	format := flags.String("format", "table", "output format: table or json")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	app, ok := openAppOrFail()
+	if !ok {
+		return 1
+	}
+	defer app.Close()
+
+	networks, err := app.networkService.FindAll()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	for i := range networks {
+		if count, err := app.networkService.GetDeviceCount(networks[i].ID); err == nil {
+			networks[i].DeviceCount = count
+		}
+	}
+
+	if *format == "json" {
+		if networks == nil {
+			networks = []models.Network{}
+		}
+		if err := printJSON(networks); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		return 0
+	}
+
+	tw := newTable()
+	fmt.Fprintln(tw, "ID\tCIDR\tNAME\tDEVICES\tLAST SCANNED")
+	for _, n := range networks {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\n", n.ID, n.CIDR, valueOrDash(&n.Name), n.DeviceCount, formatLastSeen(n.LastScannedAt))
+	}
+	tw.Flush()
+	return 0
+}
+
+// reconya networks remove [-devices] ID|CIDR
+func runNetworksRemove(args []string) int {
+	flags := flag.NewFlagSet("networks remove", flag.ContinueOnError)
+	withDevices := flags.Bool("devices", false, "also delete the devices in the network")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: reconya networks remove [flags] ID|CIDR")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	app, ok := openAppOrFail()
+	if !ok {
+		return 1
+	}
+	defer app.Close()
+
+	value := flags.Arg(0)
+	var network *models.Network
+	var err error
+	if _, ipNet, cidrErr := net.ParseCIDR(value); cidrErr == nil {
+		network, err = app.networkService.FindByCIDR(ipNet.String())
+	} else {
+		network, err = app.networkService.FindByID(value)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if network == nil {
+		fmt.Fprintf(os.Stderr, "network %q not found\n", value)
+		return 1
+	}
+
+	deviceCount, err := app.networkService.GetDeviceCount(network.ID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to check network devices: %v\n", err)
+		return 1
+	}
+	if deviceCount > 0 {
+		if !*withDevices {
+			fmt.Fprintf(os.Stderr, "network %s still has %d devices; pass -devices to delete them too\n", network.CIDR, deviceCount)
+			return 1
+		}
+		if err := app.deviceService.DeleteByNetworkID(network.ID); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to delete devices: %v\n", err)
+			return 1
+		}
+	}
+
+	if err := app.networkService.Delete(network.ID); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to delete network: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Removed network %s\n", network.CIDR)
+	return 0
+}
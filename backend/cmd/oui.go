@@ -0,0 +1,129 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"reconya-ai/internal/config"
+	"reconya-ai/internal/oui"
+	"reconya-ai/models"
+)
+
+// runOUICommand handles "reconya oui update|lookup"
+func runOUICommand(args []string) int {
+	return runSubcommands("oui", args, map[string]func([]string) int{
+		"update": runOUIUpdate,
+		"lookup": runOUILookup,
+	})
+}
+
+// ouiDataPath returns the directory the updated vendor database is kept in
+func ouiDataPath(cfg *config.Config) string {
+	return filepath.Join(filepath.Dir(cfg.SQLitePath), "oui")
+}
+
+// loadOUIService loads the vendor database the server would use, or only the
+// bundled snapshot when dataPath is empty
+func loadOUIService(dataPath string) (*oui.OUIService, error) {
+	ouiService := oui.NewOUIService(dataPath)
+	if err := ouiService.Initialize(); err != nil {
+		return nil, err
+	}
+	return ouiService, nil
+}
+
+// reconya oui update [-replace] [-o FILE] FILE...
+func runOUIUpdate(args []string) int {
+	flags := flag.NewFlagSet("oui update", flag.ContinueOnError)
+	replace := flags.Bool("replace", false, "replace the database instead of adding to it")
+	output := flags.String("o", "", "write the database to this file instead of the data directory, starting from the bundled snapshot")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: reconya oui update [flags] FILE...")
+		fmt.Fprintln(flags.Output(), "FILE is an IEEE MA-L, MA-M, MA-S or IAB registry (oui.txt, mam.csv, oui36.csv, iab.txt, ...), optionally gzip compressed.")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	dataPath := ""
+	if *output == "" {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to load configuration: %v\n", err)
+			return 1
+		}
+		dataPath = ouiDataPath(cfg)
+	}
+
+	ouiService, err := loadOUIService(dataPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	var count int
+	if *output != "" {
+		count, err = ouiService.Import(flags.Args(), *replace)
+		if err == nil {
+			err = ouiService.Save(*output)
+		}
+	} else {
+		count, err = ouiService.Update(flags.Args(), *replace)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to update vendor database: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("Vendor database %s has %d entries\n", ouiService.GetStatistics()["source"], count)
+	return 0
+}
+
+// reconya oui lookup MAC...
+func runOUILookup(args []string) int {
+	flags := flag.NewFlagSet("oui lookup", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: reconya oui lookup MAC...")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	// The updated database is used when the configuration can be loaded
+	dataPath := ""
+	if cfg, err := config.LoadConfig(); err == nil {
+		dataPath = ouiDataPath(cfg)
+	}
+	ouiService, err := loadOUIService(dataPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	tw := newTable()
+	fmt.Fprintln(tw, "MAC\tVENDOR")
+	for _, mac := range flags.Args() {
+		vendor := ouiService.LookupVendor(mac)
+		switch {
+		case models.IsPrivateMAC(mac):
+			vendor = "(private address)"
+		case vendor == "":
+			vendor = "(unknown)"
+		}
+		fmt.Fprintf(tw, "%s\t%s\n", mac, vendor)
+	}
+	tw.Flush()
+	return 0
+}
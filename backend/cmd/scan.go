@@ -0,0 +1,252 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"reconya-ai/internal/exclusion"
+	"reconya-ai/internal/pingsweep"
+	"reconya-ai/internal/portscan"
+	"reconya-ai/internal/ratelimit"
+	"reconya-ai/internal/scanpolicy"
+	"reconya-ai/models"
+)
+
+// runScanCommand sweeps a network once and prints what it found:
+//
+//	reconya scan [-ports=false] [-workers 4] [-save] [-format table|json] CIDR
+func runScanCommand(args []string) int {
+	flags := flag.NewFlagSet("scan", flag.ContinueOnError)
+	scanPorts := flags.Bool("ports", true, "port scan the hosts found by the sweep")
+	workers := flags.Int("workers", 4, "number of hosts to port scan in parallel")
+	save := flags.Bool("save", false, "store the results in the database like a scheduled scan")
+	format := flags.String("format", "table", "output format: table or json")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: reconya scan [flags] CIDR")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+	if *format != "table" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "unknown output format %q\n", *format)
+		return 2
+	}
+	if *workers < 1 {
+		*workers = 1
+	}
+
+	_, ipNet, err := net.ParseCIDR(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid CIDR %q: %v\n", flags.Arg(0), err)
+		return 2
+	}
+	cidr := ipNet.String()
+
+	app, ok := openAppOrFail()
+	if !ok {
+		return 1
+	}
+	defer app.Close()
+
+	portScanService := portscan.NewPortScanService(app.deviceService, app.eventLogService)
+	portScanService.Policies = scanpolicy.NewScanPolicyService(app.repoFactory.NewScanPolicyRepository(), app.deviceService)
+	portScanService.Policies.Exclusions = exclusion.NewExclusionService(app.repoFactory.NewExclusionRepository())
+	pingSweepService := pingsweep.NewPingSweepService(app.cfg, app.deviceService, app.eventLogService, app.networkService, portScanService)
+
+	// Known networks and devices keep their exclusions and scan settings;
+	// other networks get the global exclusions
+	target := &models.Network{CIDR: cidr}
+	if known, err := app.networkService.FindByCIDR(cidr); err == nil && known != nil {
+		target = known
+	}
+	networkID := target.ID
+	policy, err := portScanService.Policies.SweepPolicy(target)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if policy.Skip {
+		fmt.Fprintf(os.Stderr, "Network %s is excluded from scanning by its scan policy or an exclusion list\n", cidr)
+		return 1
+	}
+
+	// The CLI honours the same rate limits and scan windows as the server
+	limits, err := ratelimit.NewManager(app.cfg, app.networkService)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	limiter := limits.For(networkID)
+	if !limiter.Open() {
+		if next, ok := limiter.NextOpen(); ok {
+			fmt.Fprintf(os.Stderr, "Outside the scan windows of %s, waiting until %s\n", cidr, next.Format("Mon 15:04"))
+		}
+	}
+
+	sweepStartedAt := time.Now()
+	devices, err := pingSweepService.ExecuteSweepScanCommand(cidr, policy, limiter)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Sweep of %s failed: %v\n", cidr, err)
+		return 1
+	}
+
+	settingsFor := func(d *models.Device) scanpolicy.Settings {
+		if known, err := app.deviceService.FindByIPv4(d.IPv4); err == nil && known != nil {
+			return portScanService.Settings(known)
+		}
+		found := *d
+		found.NetworkID = networkID
+		return portScanService.Settings(&found)
+	}
+
+	if *scanPorts {
+		portScanDevices(portScanService, settingsFor, limiter, devices, *workers)
+	}
+
+	status := 0
+	if *save {
+		if err := saveScanResults(app, settingsFor, limiter, cidr, devices, sweepStartedAt, *scanPorts); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 1
+		}
+	}
+
+	if *format == "json" {
+		if devices == nil {
+			devices = []models.Device{}
+		}
+		if err := printJSON(devices); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		return status
+	}
+	printScanTable(devices, *scanPorts)
+	return status
+}
+
+// portScanDevices fills in the open ports of each device, a few hosts at a
+// time, skipping the ones their scan policy keeps to pings
+func portScanDevices(service *portscan.PortScanService, settingsFor func(*models.Device) scanpolicy.Settings, limiter *ratelimit.Limiter, devices []models.Device, workers int) {
+	var wg sync.WaitGroup
+	queue := make(chan int)
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				d := &devices[i]
+				settings := settingsFor(d)
+				if settings.Exclude || settings.PingOnly {
+					continue
+				}
+				ports, vendor, hostname, err := service.ExecutePortScan(d.IPv4, settings, limiter)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Port scan of %s failed: %v\n", d.IPv4, err)
+					continue
+				}
+				now := time.Now()
+				d.Ports = ports
+				d.PortScanEndedAt = &now
+				if vendor != "" && d.Vendor == nil {
+					d.Vendor = &vendor
+				}
+				if hostname != "" && d.Hostname == nil {
+					d.Hostname = &hostname
+				}
+			}
+		}()
+	}
+
+	for i := range devices {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+}
+
+func saveScanResults(app *cliApp, settingsFor func(*models.Device) scanpolicy.Settings, limiter *ratelimit.Limiter, cidr string, devices []models.Device, startedAt time.Time, portsScanned bool) error {
+	network, err := app.networkService.FindOrCreate(cidr)
+	if err != nil {
+		return fmt.Errorf("failed to find or create network %s: %v", cidr, err)
+	}
+
+	found := make([]*models.Device, len(devices))
+	for i := range devices {
+		d := &devices[i]
+		if portsScanned && d.PortScanEndedAt != nil {
+			app.deviceService.PerformDeviceFingerprinting(d, settingsFor(d).OSDetection, limiter)
+		}
+		found[i] = d
+	}
+
+	saved, err := app.deviceService.SaveSweep(network.ID, found)
+	if err != nil {
+		return fmt.Errorf("failed to save devices: %v", err)
+	}
+
+	events := make([]*models.EventLog, len(saved))
+	for i, d := range saved {
+		events[i] = models.DeviceOnlineEvent(d)
+	}
+	if err := app.eventLogService.CreateMany(events); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to log devices: %v\n", err)
+	}
+
+	return app.networkService.MarkScanned(network.ID, startedAt)
+}
+
+func printScanTable(devices []models.Device, showPorts bool) {
+	tw := newTable()
+	if showPorts {
+		fmt.Fprintln(tw, "IP\tMAC\tVENDOR\tHOSTNAME\tOPEN PORTS")
+	} else {
+		fmt.Fprintln(tw, "IP\tMAC\tVENDOR\tHOSTNAME")
+	}
+
+	for _, d := range devices {
+		row := []string{d.IPv4, valueOrDash(d.MAC), valueOrDash(d.Vendor), valueOrDash(d.Hostname)}
+		if showPorts {
+			row = append(row, formatOpenPorts(d.Ports))
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	tw.Flush()
+	fmt.Printf("\n%d hosts up\n", len(devices))
+}
+
+func formatOpenPorts(ports []models.Port) string {
+	var open []string
+	for _, p := range ports {
+		if p.State != "" && p.State != "open" {
+			continue
+		}
+		label := p.Number + "/" + p.Protocol
+		if p.Service != "" {
+			label += " " + p.Service
+		}
+		open = append(open, label)
+	}
+	if len(open) == 0 {
+		return "-"
+	}
+	return strings.Join(open, ", ")
+}
+
+func valueOrDash(s *string) string {
+	if s == nil || *s == "" {
+		return "-"
+	}
+	return *s
+}
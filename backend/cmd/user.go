@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"reconya-ai/internal/user"
+
+	"golang.org/x/term"
+)
+
+// runUserCommand handles "reconya user add|list"
+func runUserCommand(args []string) int {
+	return runSubcommands("user", args, map[string]func([]string) int{
+		"add":  runUserAdd,
+		"list": runUserList,
+	})
+}
+
+// reconya user add [-password-stdin] USERNAME
+func runUserAdd(args []string) int {
+	flags := flag.NewFlagSet("user add", flag.ContinueOnError)
+	passwordStdin := flags.Bool("password-stdin", false, "read the password from the first line of stdin")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: reconya user add [flags] USERNAME")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	password, err := readPassword(*passwordStdin)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	app, ok := openAppOrFail()
+	if !ok {
+		return 1
+	}
+	defer app.Close()
+
+	userService := user.NewUserService(app.repoFactory.NewUserRepository(), app.cfg)
+	created, err := userService.Create(flags.Arg(0), password)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to add user: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Added user %s\n", created.Username)
+	return 0
+}
+
+// reconya user list
+func runUserList(args []string) int {
+	flags := flag.NewFlagSet("user list", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	app, ok := openAppOrFail()
+	if !ok {
+		return 1
+	}
+	defer app.Close()
+
+	userService := user.NewUserService(app.repoFactory.NewUserRepository(), app.cfg)
+	users, err := userService.FindAll()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	tw := newTable()
+	fmt.Fprintln(tw, "USERNAME\tCREATED")
+	fmt.Fprintf(tw, "%s\t(LOGIN_USERNAME)\n", app.cfg.Username)
+	for _, u := range users {
+		fmt.Fprintf(tw, "%s\t%s\n", u.Username, u.CreatedAt.Format("2006-01-02 15:04:05"))
+	}
+	tw.Flush()
+	return 0
+}
+
+// readPassword reads a password from stdin, prompting twice on a terminal
+func readPassword(fromStdin bool) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if fromStdin || !term.IsTerminal(fd) {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", fmt.Errorf("failed to read password: %v", err)
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+
+	fmt.Fprint(os.Stderr, "Password: ")
+	password, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read password: %v", err)
+	}
+	fmt.Fprint(os.Stderr, "Repeat password: ")
+	repeated, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read password: %v", err)
+	}
+	if string(password) != string(repeated) {
+		return "", fmt.Errorf("passwords do not match")
+	}
+	return string(password), nil
+}
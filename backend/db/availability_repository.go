@@ -0,0 +1,74 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reconya-ai/models"
+	"time"
+)
+
+// SQLiteAvailabilityRepository implements the AvailabilityRepository interface for SQLite.
+// Intervals are written by the device repository as statuses change.
+type SQLiteAvailabilityRepository struct {
+	db *sql.DB
+}
+
+// NewSQLiteAvailabilityRepository creates a new SQLiteAvailabilityRepository
+func NewSQLiteAvailabilityRepository(db *sql.DB) *SQLiteAvailabilityRepository {
+	return &SQLiteAvailabilityRepository{db: db}
+}
+
+// Close closes the repository (satisfies Repository interface)
+func (r *SQLiteAvailabilityRepository) Close() error {
+	// SQLite connection is managed by the main DB instance
+	return nil
+}
+
+// FindIntervals returns the status intervals of a device that were open at or after since, oldest first
+func (r *SQLiteAvailabilityRepository) FindIntervals(ctx context.Context, deviceID string, since time.Time) ([]*models.DeviceStatusInterval, error) {
+	query := `SELECT device_id, status, started_at, ended_at FROM device_status_intervals
+			  WHERE device_id = ? AND (ended_at IS NULL OR ended_at >= ?)
+			  ORDER BY started_at, id`
+
+	rows, err := r.db.QueryContext(ctx, query, deviceID, since)
+	if err != nil {
+		return nil, fmt.Errorf("error querying status intervals: %w", err)
+	}
+	defer rows.Close()
+
+	return scanStatusIntervals(rows)
+}
+
+// FindAllIntervals returns the status intervals of all devices that were open at or after since
+func (r *SQLiteAvailabilityRepository) FindAllIntervals(ctx context.Context, since time.Time) ([]*models.DeviceStatusInterval, error) {
+	query := `SELECT device_id, status, started_at, ended_at FROM device_status_intervals
+			  WHERE ended_at IS NULL OR ended_at >= ?
+			  ORDER BY device_id, started_at, id`
+
+	rows, err := r.db.QueryContext(ctx, query, since)
+	if err != nil {
+		return nil, fmt.Errorf("error querying status intervals: %w", err)
+	}
+	defer rows.Close()
+
+	return scanStatusIntervals(rows)
+}
+
+func scanStatusIntervals(rows *sql.Rows) ([]*models.DeviceStatusInterval, error) {
+	var intervals []*models.DeviceStatusInterval
+	for rows.Next() {
+		var interval models.DeviceStatusInterval
+		var endedAt sql.NullTime
+
+		if err := rows.Scan(&interval.DeviceID, &interval.Status, &interval.StartedAt, &endedAt); err != nil {
+			return nil, fmt.Errorf("error scanning status interval: %w", err)
+		}
+		if endedAt.Valid {
+			interval.EndedAt = &endedAt.Time
+		}
+		intervals = append(intervals, &interval)
+	}
+
+	return intervals, rows.Err()
+}
@@ -0,0 +1,155 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Full-text search on event descriptions uses an external content FTS5 table
+// kept in sync by triggers. go-sqlite3 only includes FTS5 when built with the
+// sqlite_fts5 tag; other builds search with LIKE instead and drop the
+// triggers, since writing to the table would fail without the module. The
+// first build with FTS5 recreates them and rebuilds the index.
+
+const eventSearchTrigger = "event_logs_fts_insert"
+
+// syncEventSearch installs or removes the search triggers to match the FTS5
+// support of this build
+func syncEventSearch(tx *sql.Tx) error {
+	available, err := fts5Available(tx)
+	if err != nil {
+		return err
+	}
+	installed, err := triggerExists(tx, eventSearchTrigger)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case available && !installed:
+		return execAll(tx, `
+		CREATE VIRTUAL TABLE IF NOT EXISTS event_logs_fts USING fts5(
+			description, content='event_logs', content_rowid='id'
+		)`, `
+		CREATE TRIGGER event_logs_fts_insert AFTER INSERT ON event_logs BEGIN
+			INSERT INTO event_logs_fts (rowid, description) VALUES (new.id, new.description);
+		END`, `
+		CREATE TRIGGER event_logs_fts_delete AFTER DELETE ON event_logs BEGIN
+			INSERT INTO event_logs_fts (event_logs_fts, rowid, description) VALUES ('delete', old.id, old.description);
+		END`, `
+		CREATE TRIGGER event_logs_fts_update AFTER UPDATE OF description ON event_logs BEGIN
+			INSERT INTO event_logs_fts (event_logs_fts, rowid, description) VALUES ('delete', old.id, old.description);
+			INSERT INTO event_logs_fts (rowid, description) VALUES (new.id, new.description);
+		END`,
+			`INSERT INTO event_logs_fts (event_logs_fts) VALUES ('rebuild')`)
+	case !available && installed:
+		return dropEventSearchTriggers(tx)
+	}
+	return nil
+}
+
+func fts5Available(tx *sql.Tx) (bool, error) {
+	var available bool
+	if err := tx.QueryRow(`SELECT sqlite_compileoption_used('ENABLE_FTS5')`).Scan(&available); err != nil {
+		return false, fmt.Errorf("error checking for FTS5: %w", err)
+	}
+	return available, nil
+}
+
+func dropEventSearchTriggers(tx *sql.Tx) error {
+	return execAll(tx,
+		`DROP TRIGGER IF EXISTS event_logs_fts_update`,
+		`DROP TRIGGER IF EXISTS event_logs_fts_delete`,
+		`DROP TRIGGER IF EXISTS event_logs_fts_insert`,
+	)
+}
+
+func triggerExists(tx *sql.Tx, name string) (bool, error) {
+	var count int
+	err := tx.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger' AND name = ?`, name).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("error checking trigger %s: %w", name, err)
+	}
+	return count > 0, nil
+}
+
+// SyncEventSearch brings the event search index in line with this build; see
+// syncEventSearch. It does nothing on PostgreSQL.
+func SyncEventSearch(db *sql.DB) error {
+	if IsPostgres(db) {
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := syncEventSearch(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// searchWords splits a search query into words and each word into the
+// letters and digits the FTS tokenizer indexes, so "10.0.0.1" becomes the
+// phrase 10 0 0 1
+func searchWords(query string) [][]string {
+	var words [][]string
+	for _, field := range strings.Fields(query) {
+		tokens := strings.FieldsFunc(field, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+		})
+		if len(tokens) > 0 {
+			words = append(words, tokens)
+		}
+	}
+	return words
+}
+
+// sqliteTextSearch matches every word of query as a prefix phrase in the FTS
+// index, or as a substring when the index is not installed
+func sqliteTextSearch(ctx context.Context, db *sql.DB, query string) (string, []interface{}, error) {
+	words := searchWords(query)
+	if len(words) == 0 {
+		return "", nil, nil
+	}
+
+	var count int
+	err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger' AND name = ?`, eventSearchTrigger).Scan(&count)
+	if err != nil {
+		return "", nil, fmt.Errorf("error checking event search index: %w", err)
+	}
+
+	if count > 0 {
+		phrases := make([]string, len(words))
+		for i, tokens := range words {
+			phrases[i] = `"` + strings.Join(tokens, " ") + `"*`
+		}
+		return "e.id IN (SELECT rowid FROM event_logs_fts WHERE event_logs_fts MATCH ?)", []interface{}{strings.Join(phrases, " ")}, nil
+	}
+
+	conditions := make([]string, 0, len(words))
+	args := make([]interface{}, 0, len(words))
+	for _, field := range strings.Fields(query) {
+		conditions = append(conditions, `e.description LIKE ? ESCAPE '\'`)
+		args = append(args, "%"+escapeLike(field)+"%")
+	}
+	return strings.Join(conditions, " AND "), args, nil
+}
+
+// postgresTextSearch matches query against the tsvector index of migration 10
+func postgresTextSearch(ctx context.Context, db *sql.DB, query string) (string, []interface{}, error) {
+	if len(searchWords(query)) == 0 {
+		return "", nil, nil
+	}
+	return "to_tsvector('simple', e.description) @@ plainto_tsquery('simple', ?)", []interface{}{query}, nil
+}
+
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
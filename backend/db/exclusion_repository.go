@@ -0,0 +1,87 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reconya-ai/models"
+	"time"
+)
+
+// SQLiteExclusionRepository implements the ExclusionRepository interface for SQLite
+type SQLiteExclusionRepository struct {
+	db *sql.DB
+}
+
+// NewSQLiteExclusionRepository creates a new SQLiteExclusionRepository
+func NewSQLiteExclusionRepository(db *sql.DB) *SQLiteExclusionRepository {
+	return &SQLiteExclusionRepository{db: db}
+}
+
+// Close closes the repository (satisfies Repository interface)
+func (r *SQLiteExclusionRepository) Close() error {
+	// SQLite connection is managed by the main DB instance
+	return nil
+}
+
+// FindAll returns every exclusion, the global ones first
+func (r *SQLiteExclusionRepository) FindAll(ctx context.Context) ([]*models.Exclusion, error) {
+	query := `SELECT id, network_id, kind, value, comment, created_at
+			  FROM exclusions ORDER BY network_id, kind, value`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("error querying exclusions: %w", err)
+	}
+	defer rows.Close()
+
+	var exclusions []*models.Exclusion
+	for rows.Next() {
+		var exclusion models.Exclusion
+		var comment sql.NullString
+
+		err := rows.Scan(&exclusion.ID, &exclusion.NetworkID, &exclusion.Kind, &exclusion.Value, &comment, &exclusion.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning exclusion: %w", err)
+		}
+		if comment.Valid {
+			exclusion.Comment = comment.String
+		}
+		exclusions = append(exclusions, &exclusion)
+	}
+
+	return exclusions, rows.Err()
+}
+
+// Create stores a new exclusion
+func (r *SQLiteExclusionRepository) Create(ctx context.Context, exclusion *models.Exclusion) error {
+	if exclusion.ID == "" {
+		exclusion.ID = GenerateID()
+	}
+	exclusion.CreatedAt = time.Now()
+
+	query := `INSERT INTO exclusions (id, network_id, kind, value, comment, created_at) VALUES (?, ?, ?, ?, ?, ?)`
+	_, err := r.db.ExecContext(ctx, query, exclusion.ID, exclusion.NetworkID, exclusion.Kind, exclusion.Value,
+		nullableString(&exclusion.Comment), exclusion.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error creating exclusion: %w", err)
+	}
+	return nil
+}
+
+// Delete removes an exclusion
+func (r *SQLiteExclusionRepository) Delete(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM exclusions WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("error deleting exclusion: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error checking deleted exclusion: %w", err)
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reconya-ai/models"
+)
+
+// SQLiteInfraServerRepository implements the InfraServerRepository interface for SQLite
+type SQLiteInfraServerRepository struct {
+	db *sql.DB
+}
+
+// NewSQLiteInfraServerRepository creates a new SQLiteInfraServerRepository
+func NewSQLiteInfraServerRepository(db *sql.DB) *SQLiteInfraServerRepository {
+	return &SQLiteInfraServerRepository{db: db}
+}
+
+// Close closes the repository (satisfies Repository interface)
+func (r *SQLiteInfraServerRepository) Close() error {
+	// SQLite connection is managed by the main DB instance
+	return nil
+}
+
+const infraServerColumns = `id, network_id, kind, ip, mac, interface, prefixes, flags, router_lifetime_seconds,
+	offered_address, dns_servers, allowed, first_seen_at, last_seen_at`
+
+// FindAll returns the servers seen on a network, or on every network when
+// networkID is empty
+func (r *SQLiteInfraServerRepository) FindAll(ctx context.Context, networkID string) ([]*models.InfraServer, error) {
+	query := `SELECT ` + infraServerColumns + ` FROM infra_servers`
+	var args []interface{}
+	if networkID != "" {
+		query += ` WHERE network_id = ?`
+		args = append(args, networkID)
+	}
+	query += ` ORDER BY kind, first_seen_at`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying infra servers: %w", err)
+	}
+	defer rows.Close()
+
+	var servers []*models.InfraServer
+	for rows.Next() {
+		server, err := scanInfraServer(rows)
+		if err != nil {
+			return nil, err
+		}
+		servers = append(servers, server)
+	}
+
+	return servers, rows.Err()
+}
+
+// FindByID returns a server by its ID
+func (r *SQLiteInfraServerRepository) FindByID(ctx context.Context, id string) (*models.InfraServer, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+infraServerColumns+` FROM infra_servers WHERE id = ?`, id)
+	server, err := scanInfraServer(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return server, err
+}
+
+// Save creates or updates a server
+func (r *SQLiteInfraServerRepository) Save(ctx context.Context, server *models.InfraServer) error {
+	if server.ID == "" {
+		server.ID = GenerateID()
+	}
+
+	query := `
+		INSERT INTO infra_servers (` + infraServerColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			mac = excluded.mac,
+			interface = excluded.interface,
+			prefixes = excluded.prefixes,
+			flags = excluded.flags,
+			router_lifetime_seconds = excluded.router_lifetime_seconds,
+			offered_address = excluded.offered_address,
+			dns_servers = excluded.dns_servers,
+			allowed = excluded.allowed,
+			last_seen_at = excluded.last_seen_at`
+
+	_, err := r.db.ExecContext(ctx, query,
+		server.ID, server.NetworkID, server.Kind, server.IP, server.MAC,
+		nullableString(&server.Interface), listJSON(server.Prefixes), listJSON(server.Flags),
+		nullableInt(server.RouterLifetimeSeconds), nullableString(&server.OfferedAddress), listJSON(server.DNSServers),
+		server.Allowed, server.FirstSeenAt, server.LastSeenAt,
+	)
+	if err != nil {
+		return fmt.Errorf("error saving infra server: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a server, so that it raises an alert when seen again
+func (r *SQLiteInfraServerRepository) Delete(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM infra_servers WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("error deleting infra server: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error checking deleted infra server: %w", err)
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// listJSON stores a list as a JSON array, or NULL when it is empty
+func listJSON(list []string) sql.NullString {
+	if len(list) == 0 {
+		return sql.NullString{}
+	}
+	data, err := json.Marshal(list)
+	if err != nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: string(data), Valid: true}
+}
+
+// decodeList reads a list stored by listJSON
+func decodeList(column sql.NullString) []string {
+	var list []string
+	if column.Valid {
+		json.Unmarshal([]byte(column.String), &list)
+	}
+	return list
+}
+
+// rowScanner is a *sql.Row or *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanInfraServer(row rowScanner) (*models.InfraServer, error) {
+	var server models.InfraServer
+	var iface, prefixes, flags, offeredAddress, dnsServers sql.NullString
+	var routerLifetime sql.NullInt64
+
+	err := row.Scan(&server.ID, &server.NetworkID, &server.Kind, &server.IP, &server.MAC,
+		&iface, &prefixes, &flags, &routerLifetime, &offeredAddress, &dnsServers,
+		&server.Allowed, &server.FirstSeenAt, &server.LastSeenAt)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error scanning infra server: %w", err)
+	}
+
+	server.Interface = iface.String
+	server.OfferedAddress = offeredAddress.String
+	server.RouterLifetimeSeconds = intPtr(routerLifetime)
+	server.Prefixes = decodeList(prefixes)
+	server.Flags = decodeList(flags)
+	server.DNSServers = decodeList(dnsServers)
+
+	return &server, nil
+}
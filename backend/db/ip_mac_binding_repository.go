@@ -0,0 +1,77 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reconya-ai/models"
+	"time"
+)
+
+// SQLiteIPMACBindingRepository implements the IPMACBindingRepository interface for SQLite
+type SQLiteIPMACBindingRepository struct {
+	db *sql.DB
+}
+
+// NewSQLiteIPMACBindingRepository creates a new SQLiteIPMACBindingRepository
+func NewSQLiteIPMACBindingRepository(db *sql.DB) *SQLiteIPMACBindingRepository {
+	return &SQLiteIPMACBindingRepository{db: db}
+}
+
+// Close closes the repository (satisfies Repository interface)
+func (r *SQLiteIPMACBindingRepository) Close() error {
+	// SQLite connection is managed by the main DB instance
+	return nil
+}
+
+// Record adds a binding or extends a known one to its LastSeenAt
+func (r *SQLiteIPMACBindingRepository) Record(ctx context.Context, binding *models.IPMACBinding) error {
+	query := `
+		INSERT INTO ip_mac_bindings (ip, mac, network_id, source, first_seen_at, last_seen_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(ip, mac) DO UPDATE SET
+			network_id = CASE WHEN excluded.network_id = '' THEN ip_mac_bindings.network_id ELSE excluded.network_id END,
+			source = excluded.source,
+			last_seen_at = excluded.last_seen_at`
+
+	_, err := r.db.ExecContext(ctx, query,
+		binding.IP, binding.MAC, binding.NetworkID, binding.Source, binding.FirstSeenAt, binding.LastSeenAt)
+	if err != nil {
+		return fmt.Errorf("error recording IP MAC binding: %w", err)
+	}
+	return nil
+}
+
+// FindByIP returns the bindings of an address seen since the given time,
+// most recent first
+func (r *SQLiteIPMACBindingRepository) FindByIP(ctx context.Context, ip string, since time.Time) ([]*models.IPMACBinding, error) {
+	return r.find(ctx, `ip = ?`, ip, since)
+}
+
+// FindByMAC returns the bindings of a MAC address seen since the given time,
+// most recent first
+func (r *SQLiteIPMACBindingRepository) FindByMAC(ctx context.Context, mac string, since time.Time) ([]*models.IPMACBinding, error) {
+	return r.find(ctx, `mac = ?`, mac, since)
+}
+
+func (r *SQLiteIPMACBindingRepository) find(ctx context.Context, where, value string, since time.Time) ([]*models.IPMACBinding, error) {
+	query := `SELECT ip, mac, network_id, source, first_seen_at, last_seen_at FROM ip_mac_bindings
+		WHERE ` + where + ` AND last_seen_at >= ? ORDER BY last_seen_at DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, value, since)
+	if err != nil {
+		return nil, fmt.Errorf("error querying IP MAC bindings: %w", err)
+	}
+	defer rows.Close()
+
+	var bindings []*models.IPMACBinding
+	for rows.Next() {
+		var binding models.IPMACBinding
+		if err := rows.Scan(&binding.IP, &binding.MAC, &binding.NetworkID, &binding.Source, &binding.FirstSeenAt, &binding.LastSeenAt); err != nil {
+			return nil, fmt.Errorf("error scanning IP MAC binding: %w", err)
+		}
+		bindings = append(bindings, &binding)
+	}
+
+	return bindings, rows.Err()
+}
@@ -0,0 +1,300 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reconya-ai/models"
+	"sort"
+	"time"
+)
+
+// SQLiteLatencyRepository implements the LatencyRepository interface for SQLite
+type SQLiteLatencyRepository struct {
+	db *sql.DB
+}
+
+// NewSQLiteLatencyRepository creates a new SQLiteLatencyRepository
+func NewSQLiteLatencyRepository(db *sql.DB) *SQLiteLatencyRepository {
+	return &SQLiteLatencyRepository{db: db}
+}
+
+// Close closes the repository (satisfies Repository interface)
+func (r *SQLiteLatencyRepository) Close() error {
+	// SQLite connection is managed by the main DB instance
+	return nil
+}
+
+// CreateSample stores a latency sample
+func (r *SQLiteLatencyRepository) CreateSample(ctx context.Context, sample *models.LatencySample) error {
+	_, err := r.db.ExecContext(ctx, insertLatencySampleQuery,
+		sample.DeviceID, sample.Resolution, sample.Timestamp,
+		nullableFloat64(sample.RTTMinMs), nullableFloat64(sample.RTTAvgMs), nullableFloat64(sample.RTTMaxMs),
+		nullableFloat64(sample.JitterMs), sample.LossPercent, sample.Sent, sample.Received,
+	)
+	if err != nil {
+		return fmt.Errorf("error inserting latency sample: %w", err)
+	}
+	return nil
+}
+
+const insertLatencySampleQuery = `
+	INSERT INTO latency_samples (device_id, resolution, sampled_at, rtt_min_ms, rtt_avg_ms, rtt_max_ms, jitter_ms, loss_percent, sent, received)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+// FindSamples returns the samples of all resolutions for a device since the given time, oldest first
+func (r *SQLiteLatencyRepository) FindSamples(ctx context.Context, deviceID string, since time.Time) ([]*models.LatencySample, error) {
+	query := `SELECT device_id, resolution, sampled_at, rtt_min_ms, rtt_avg_ms, rtt_max_ms, jitter_ms, loss_percent, sent, received
+			  FROM latency_samples WHERE device_id = ? AND sampled_at >= ?
+			  ORDER BY sampled_at`
+
+	rows, err := r.db.QueryContext(ctx, query, deviceID, since)
+	if err != nil {
+		return nil, fmt.Errorf("error querying latency samples: %w", err)
+	}
+	defer rows.Close()
+
+	return scanLatencySamples(rows)
+}
+
+// Rollup aggregates samples of one resolution older than before into buckets
+// of the next resolution and removes the source samples
+func (r *SQLiteLatencyRepository) Rollup(ctx context.Context, from, to models.LatencyResolution, bucket time.Duration, before time.Time) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT device_id, resolution, sampled_at, rtt_min_ms, rtt_avg_ms, rtt_max_ms, jitter_ms, loss_percent, sent, received
+		FROM latency_samples WHERE resolution = ? AND sampled_at < ?
+		ORDER BY device_id, sampled_at`, from, before)
+	if err != nil {
+		return 0, fmt.Errorf("error querying latency samples for rollup: %w", err)
+	}
+	samples, err := scanLatencySamples(rows)
+	rows.Close()
+	if err != nil {
+		return 0, err
+	}
+	if len(samples) == 0 {
+		return 0, nil
+	}
+
+	for _, aggregate := range aggregateLatencySamples(samples, to, bucket) {
+		_, err = tx.ExecContext(ctx, insertLatencySampleQuery,
+			aggregate.DeviceID, aggregate.Resolution, aggregate.Timestamp,
+			nullableFloat64(aggregate.RTTMinMs), nullableFloat64(aggregate.RTTAvgMs), nullableFloat64(aggregate.RTTMaxMs),
+			nullableFloat64(aggregate.JitterMs), aggregate.LossPercent, aggregate.Sent, aggregate.Received,
+		)
+		if err != nil {
+			return 0, fmt.Errorf("error inserting latency rollup: %w", err)
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM latency_samples WHERE resolution = ? AND sampled_at < ?`, from, before)
+	if err != nil {
+		return 0, fmt.Errorf("error deleting rolled up latency samples: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return len(samples), nil
+}
+
+// FindMonitor returns the latency monitor of a device
+func (r *SQLiteLatencyRepository) FindMonitor(ctx context.Context, deviceID string) (*models.LatencyMonitor, error) {
+	query := `SELECT device_id, rtt_threshold_ms, loss_threshold_percent, degraded, created_at, updated_at
+			  FROM latency_monitors WHERE device_id = ?`
+
+	var monitor models.LatencyMonitor
+	err := r.db.QueryRowContext(ctx, query, deviceID).Scan(
+		&monitor.DeviceID, &monitor.RTTThresholdMs, &monitor.LossThresholdPercent,
+		&monitor.Degraded, &monitor.CreatedAt, &monitor.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error scanning latency monitor: %w", err)
+	}
+
+	return &monitor, nil
+}
+
+// FindMonitors returns all latency monitors
+func (r *SQLiteLatencyRepository) FindMonitors(ctx context.Context) ([]*models.LatencyMonitor, error) {
+	query := `SELECT device_id, rtt_threshold_ms, loss_threshold_percent, degraded, created_at, updated_at
+			  FROM latency_monitors ORDER BY created_at`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("error querying latency monitors: %w", err)
+	}
+	defer rows.Close()
+
+	var monitors []*models.LatencyMonitor
+	for rows.Next() {
+		var monitor models.LatencyMonitor
+		err := rows.Scan(&monitor.DeviceID, &monitor.RTTThresholdMs, &monitor.LossThresholdPercent,
+			&monitor.Degraded, &monitor.CreatedAt, &monitor.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning latency monitor: %w", err)
+		}
+		monitors = append(monitors, &monitor)
+	}
+
+	return monitors, rows.Err()
+}
+
+// SaveMonitor creates or updates a latency monitor
+func (r *SQLiteLatencyRepository) SaveMonitor(ctx context.Context, monitor *models.LatencyMonitor) error {
+	now := time.Now()
+	if monitor.CreatedAt.IsZero() {
+		monitor.CreatedAt = now
+	}
+	monitor.UpdatedAt = now
+
+	query := `
+		INSERT INTO latency_monitors (device_id, rtt_threshold_ms, loss_threshold_percent, degraded, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(device_id) DO UPDATE SET
+			rtt_threshold_ms = excluded.rtt_threshold_ms,
+			loss_threshold_percent = excluded.loss_threshold_percent,
+			degraded = excluded.degraded,
+			updated_at = excluded.updated_at`
+
+	_, err := r.db.ExecContext(ctx, query,
+		monitor.DeviceID, monitor.RTTThresholdMs, monitor.LossThresholdPercent,
+		monitor.Degraded, monitor.CreatedAt, monitor.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("error saving latency monitor: %w", err)
+	}
+	return nil
+}
+
+// DeleteMonitor stops latency monitoring for a device
+func (r *SQLiteLatencyRepository) DeleteMonitor(ctx context.Context, deviceID string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM latency_monitors WHERE device_id = ?`, deviceID)
+	if err != nil {
+		return fmt.Errorf("error deleting latency monitor: %w", err)
+	}
+	return nil
+}
+
+func scanLatencySamples(rows *sql.Rows) ([]*models.LatencySample, error) {
+	var samples []*models.LatencySample
+	for rows.Next() {
+		var sample models.LatencySample
+		var rttMin, rttAvg, rttMax, jitter sql.NullFloat64
+
+		err := rows.Scan(&sample.DeviceID, &sample.Resolution, &sample.Timestamp,
+			&rttMin, &rttAvg, &rttMax, &jitter, &sample.LossPercent, &sample.Sent, &sample.Received)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning latency sample: %w", err)
+		}
+
+		sample.RTTMinMs = float64Ptr(rttMin)
+		sample.RTTAvgMs = float64Ptr(rttAvg)
+		sample.RTTMaxMs = float64Ptr(rttMax)
+		sample.JitterMs = float64Ptr(jitter)
+		samples = append(samples, &sample)
+	}
+
+	return samples, rows.Err()
+}
+
+// aggregateLatencySamples merges samples into one sample per device and bucket
+func aggregateLatencySamples(samples []*models.LatencySample, resolution models.LatencyResolution, bucket time.Duration) []*models.LatencySample {
+	type key struct {
+		deviceID string
+		start    time.Time
+	}
+	type accumulator struct {
+		sample      *models.LatencySample
+		rttWeighted float64
+		jitterSum   float64
+		jitterCount int
+	}
+
+	buckets := make(map[key]*accumulator)
+	var order []key
+	for _, s := range samples {
+		k := key{deviceID: s.DeviceID, start: s.Timestamp.UTC().Truncate(bucket)}
+		acc, ok := buckets[k]
+		if !ok {
+			acc = &accumulator{sample: &models.LatencySample{
+				DeviceID:   s.DeviceID,
+				Resolution: resolution,
+				Timestamp:  k.start,
+			}}
+			buckets[k] = acc
+			order = append(order, k)
+		}
+
+		agg := acc.sample
+		agg.Sent += s.Sent
+		agg.Received += s.Received
+		if s.RTTMinMs != nil && (agg.RTTMinMs == nil || *s.RTTMinMs < *agg.RTTMinMs) {
+			v := *s.RTTMinMs
+			agg.RTTMinMs = &v
+		}
+		if s.RTTMaxMs != nil && (agg.RTTMaxMs == nil || *s.RTTMaxMs > *agg.RTTMaxMs) {
+			v := *s.RTTMaxMs
+			agg.RTTMaxMs = &v
+		}
+		if s.RTTAvgMs != nil {
+			acc.rttWeighted += *s.RTTAvgMs * float64(s.Received)
+		}
+		if s.JitterMs != nil {
+			acc.jitterSum += *s.JitterMs
+			acc.jitterCount++
+		}
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		if order[i].deviceID != order[j].deviceID {
+			return order[i].deviceID < order[j].deviceID
+		}
+		return order[i].start.Before(order[j].start)
+	})
+
+	result := make([]*models.LatencySample, 0, len(order))
+	for _, k := range order {
+		acc := buckets[k]
+		agg := acc.sample
+		if agg.Received > 0 && acc.rttWeighted > 0 {
+			avg := acc.rttWeighted / float64(agg.Received)
+			agg.RTTAvgMs = &avg
+		}
+		if acc.jitterCount > 0 {
+			jitter := acc.jitterSum / float64(acc.jitterCount)
+			agg.JitterMs = &jitter
+		}
+		if agg.Sent > 0 {
+			agg.LossPercent = float64(agg.Sent-agg.Received) / float64(agg.Sent) * 100
+		}
+		result = append(result, agg)
+	}
+
+	return result
+}
+
+func nullableFloat64(f *float64) sql.NullFloat64 {
+	if f == nil {
+		return sql.NullFloat64{}
+	}
+	return sql.NullFloat64{Float64: *f, Valid: true}
+}
+
+func float64Ptr(f sql.NullFloat64) *float64 {
+	if !f.Valid {
+		return nil
+	}
+	v := f.Float64
+	return &v
+}
@@ -0,0 +1,113 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// ErrSQLiteOnly is returned by maintenance operations that work on the SQLite
+// file; PostgreSQL databases are backed up and checked with its own tools
+var ErrSQLiteOnly = errors.New("only supported for SQLite databases, use pg_dump and pg_restore for PostgreSQL")
+
+// BackupTo writes a consistent copy of the database to path with VACUUM INTO.
+// It runs while other connections keep reading and writing.
+func BackupTo(db *sql.DB, path string) error {
+	if IsPostgres(db) {
+		return ErrSQLiteOnly
+	}
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("backup file %s already exists", path)
+	}
+	if _, err := db.Exec(`VACUUM INTO ?`, path); err != nil {
+		return fmt.Errorf("error backing up database: %w", err)
+	}
+	return nil
+}
+
+// RestoreFrom replaces the contents of the database with the SQLite file at
+// path using the online backup API, so open connections stay valid
+func RestoreFrom(db *sql.DB, path string) error {
+	if IsPostgres(db) {
+		return ErrSQLiteOnly
+	}
+	ctx := context.Background()
+
+	source, err := sql.Open("sqlite3", path+"?mode=ro")
+	if err != nil {
+		return fmt.Errorf("error opening backup: %w", err)
+	}
+	defer source.Close()
+
+	sourceConn, err := source.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("error opening backup: %w", err)
+	}
+	defer sourceConn.Close()
+
+	targetConn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("error opening database: %w", err)
+	}
+	defer targetConn.Close()
+
+	return targetConn.Raw(func(target interface{}) error {
+		return sourceConn.Raw(func(src interface{}) error {
+			targetSQLite, ok := target.(*sqlite3.SQLiteConn)
+			if !ok {
+				return fmt.Errorf("database is not SQLite")
+			}
+			sourceSQLite, ok := src.(*sqlite3.SQLiteConn)
+			if !ok {
+				return fmt.Errorf("backup is not SQLite")
+			}
+
+			backup, err := targetSQLite.Backup("main", sourceSQLite, "main")
+			if err != nil {
+				return fmt.Errorf("error starting restore: %w", err)
+			}
+			if _, err := backup.Step(-1); err != nil {
+				backup.Finish()
+				return fmt.Errorf("error restoring database: %w", err)
+			}
+			if err := backup.Finish(); err != nil {
+				return fmt.Errorf("error finishing restore: %w", err)
+			}
+			return nil
+		})
+	})
+}
+
+// CheckIntegrity runs SQLite's integrity check on the database
+func CheckIntegrity(db *sql.DB) error {
+	if IsPostgres(db) {
+		return ErrSQLiteOnly
+	}
+	var result string
+	if err := db.QueryRow(`PRAGMA integrity_check`).Scan(&result); err != nil {
+		return fmt.Errorf("error checking database integrity: %w", err)
+	}
+	if result != "ok" {
+		return fmt.Errorf("database integrity check failed: %s", result)
+	}
+	return nil
+}
+
+// Vacuum rebuilds the database file to reclaim unused pages. On PostgreSQL
+// it runs a plain VACUUM, which does not lock out other instances.
+func Vacuum(db *sql.DB) error {
+	if _, err := db.Exec(`VACUUM`); err != nil {
+		return fmt.Errorf("error vacuuming database: %w", err)
+	}
+	if IsPostgres(db) {
+		return nil
+	}
+	if _, err := db.Exec(`PRAGMA wal_checkpoint(TRUNCATE)`); err != nil {
+		return fmt.Errorf("error checkpointing database: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,284 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+)
+
+// Migration is a numbered schema change. Up and Down run inside a transaction
+// together with the bookkeeping in schema_migrations.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *sql.Tx) error
+	Down    func(tx *sql.Tx) error
+}
+
+// MigrationStatus reports whether a migration has been applied
+type MigrationStatus struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+// Migrator applies and rolls back schema migrations
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// NewMigrator creates a migrator for the reconya schema, picking the
+// migrations for the database the connection was opened for
+func NewMigrator(db *sql.DB) *Migrator {
+	if IsPostgres(db) {
+		return newMigrator(db, postgresMigrations)
+	}
+	return newMigrator(db, migrations)
+}
+
+func newMigrator(db *sql.DB, list []Migration) *Migrator {
+	sorted := make([]Migration, len(list))
+	copy(sorted, list)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Version < sorted[j].Version
+	})
+	return &Migrator{db: db, migrations: sorted}
+}
+
+// Latest returns the version of the newest known migration
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version returns the highest applied migration, 0 for an unmigrated database
+func (m *Migrator) Version() (int, error) {
+	if err := m.ensureTable(); err != nil {
+		return 0, err
+	}
+
+	var version sql.NullInt64
+	if err := m.db.QueryRow(`SELECT MAX(version) FROM schema_migrations`).Scan(&version); err != nil {
+		return 0, fmt.Errorf("error reading schema version: %w", err)
+	}
+	return int(version.Int64), nil
+}
+
+// Status lists every known migration and when it was applied
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if appliedAt, ok := applied[migration.Version]; ok {
+			at := appliedAt
+			status.Applied = true
+			status.AppliedAt = &at
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Migrate applies all pending migrations and returns how many ran
+func (m *Migrator) Migrate() (int, error) {
+	return m.migrateUp(m.Latest())
+}
+
+// MigrateTo moves the schema up or down to the given version
+func (m *Migrator) MigrateTo(version int) (int, error) {
+	if version < 0 || version > m.Latest() {
+		return 0, fmt.Errorf("unknown schema version %d (latest is %d)", version, m.Latest())
+	}
+
+	current, err := m.Version()
+	if err != nil {
+		return 0, err
+	}
+	if version >= current {
+		return m.migrateUp(version)
+	}
+	return m.migrateDown(version)
+}
+
+func (m *Migrator) migrateUp(target int) (int, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return 0, err
+	}
+	if err := m.checkKnown(applied); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, migration := range m.migrations {
+		if migration.Version > target {
+			break
+		}
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+
+		log.Printf("Applying migration %d (%s)", migration.Version, migration.Name)
+		err := m.inTx(migration.Up, func(tx *sql.Tx) error {
+			_, err := tx.Exec(`INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`,
+				migration.Version, migration.Name, time.Now())
+			return err
+		})
+		if err != nil {
+			return count, fmt.Errorf("migration %d (%s) failed: %w", migration.Version, migration.Name, err)
+		}
+		count++
+	}
+	return count, nil
+}
+
+func (m *Migrator) migrateDown(target int) (int, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return 0, err
+	}
+	if err := m.checkKnown(applied); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if migration.Version <= target {
+			break
+		}
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		if migration.Down == nil {
+			return count, fmt.Errorf("migration %d (%s) cannot be rolled back", migration.Version, migration.Name)
+		}
+
+		log.Printf("Rolling back migration %d (%s)", migration.Version, migration.Name)
+		err := m.inTx(migration.Down, func(tx *sql.Tx) error {
+			_, err := tx.Exec(`DELETE FROM schema_migrations WHERE version = ?`, migration.Version)
+			return err
+		})
+		if err != nil {
+			return count, fmt.Errorf("rollback of migration %d (%s) failed: %w", migration.Version, migration.Name, err)
+		}
+		count++
+	}
+	return count, nil
+}
+
+// checkKnown refuses to touch a database migrated by a newer build
+func (m *Migrator) checkKnown(applied map[int]time.Time) error {
+	for version := range applied {
+		if version > m.Latest() {
+			return fmt.Errorf("database schema version %d is newer than this build supports (%d)", version, m.Latest())
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) inTx(steps ...func(tx *sql.Tx) error) error {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+	for _, step := range steps {
+		if err := step(tx); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (m *Migrator) ensureTable() error {
+	_, err := m.db.Exec(`
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMP NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+	return nil
+}
+
+func (m *Migrator) applied() (map[int]time.Time, error) {
+	if err := m.ensureTable(); err != nil {
+		return nil, err
+	}
+
+	rows, err := m.db.Query(`SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("error querying schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("error scanning schema_migrations: %w", err)
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+// execAll runs statements in order, stopping at the first error
+func execAll(tx *sql.Tx, statements ...string) error {
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addColumnIfMissing adds a column unless it exists. Databases created before
+// versioned migrations may already have any subset of the columns.
+func addColumnIfMissing(tx *sql.Tx, table, column, definition string) error {
+	exists, err := columnExists(tx, table, column)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+	_, err = tx.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s`, table, column, definition))
+	return err
+}
+
+func columnExists(tx *sql.Tx, table, column string) (bool, error) {
+	rows, err := tx.Query(fmt.Sprintf(`PRAGMA table_info(%s)`, table))
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, columnType string
+		var defaultValue sql.NullString
+		if err := rows.Scan(&cid, &name, &columnType, &notNull, &defaultValue, &pk); err != nil {
+			return false, err
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
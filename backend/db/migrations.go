@@ -0,0 +1,506 @@
+package db
+
+import "database/sql"
+
+// migrations is the ordered history of the schema. Append new migrations with
+// the next version number; never edit one that has been released.
+//
+// Databases created before versioned migrations already contain some of the
+// tables and columns of versions 1 to 7, so those tolerate existing objects.
+// Later migrations can rely on the exact schema of the previous version.
+// PostgreSQL has its own list in migrations_postgres.go; add schema changes to both.
+var migrations = []Migration{
+	{Version: 1, Name: "initial_schema", Up: migrateInitialSchemaUp, Down: migrateInitialSchemaDown},
+	{Version: 2, Name: "traceroute", Up: migrateTracerouteUp, Down: migrateTracerouteDown},
+	{Version: 3, Name: "latency", Up: migrateLatencyUp, Down: migrateLatencyDown},
+	{Version: 4, Name: "device_status_intervals", Up: migrateStatusIntervalsUp, Down: migrateStatusIntervalsDown},
+	{Version: 5, Name: "status_thresholds", Up: migrateStatusThresholdsUp, Down: migrateStatusThresholdsDown},
+	{Version: 6, Name: "device_tags", Up: migrateDeviceTagsUp, Down: migrateDeviceTagsDown},
+	{Version: 7, Name: "users", Up: migrateUsersUp, Down: migrateUsersDown},
+	{Version: 8, Name: "event_retention", Up: migrateEventRetentionUp, Down: migrateEventRetentionDown},
+	{Version: 9, Name: "device_child_indexes", Up: migrateDeviceChildIndexesUp, Down: migrateDeviceChildIndexesDown},
+	{Version: 10, Name: "event_log_search", Up: migrateEventLogSearchUp, Down: migrateEventLogSearchDown},
+	{Version: 11, Name: "event_log_payloads", Up: migrateEventLogPayloadsUp, Down: migrateEventLogPayloadsDown},
+	{Version: 12, Name: "ipv6_only_devices", Up: migrateIPv6OnlyDevicesUp, Down: migrateIPv6OnlyDevicesDown},
+	{Version: 13, Name: "infra_servers", Up: migrateInfraServersUp, Down: migrateInfraServersDown},
+	{Version: 14, Name: "ip_mac_bindings", Up: migrateIPMACBindingsUp, Down: migrateIPMACBindingsDown},
+	{Version: 15, Name: "scan_policies", Up: migrateScanPoliciesUp, Down: migrateScanPoliciesDown},
+	{Version: 16, Name: "network_scan_limits", Up: migrateNetworkScanLimitsUp, Down: migrateNetworkScanLimitsDown},
+	{Version: 17, Name: "exclusions", Up: migrateExclusionsUp, Down: migrateExclusionsDown},
+}
+
+func migrateInitialSchemaUp(tx *sql.Tx) error {
+	err := execAll(tx, `
+	CREATE TABLE IF NOT EXISTS networks (
+		id TEXT PRIMARY KEY,
+		cidr TEXT NOT NULL
+	)`, `
+	CREATE TABLE IF NOT EXISTS devices (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		ipv4 TEXT NOT NULL,
+		mac TEXT,
+		vendor TEXT,
+		status TEXT NOT NULL,
+		network_id TEXT,
+		hostname TEXT,
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL,
+		last_seen_online_at TIMESTAMP,
+		port_scan_started_at TIMESTAMP,
+		port_scan_ended_at TIMESTAMP,
+		FOREIGN KEY (network_id) REFERENCES networks(id)
+	)`, `
+	CREATE TABLE IF NOT EXISTS ports (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		device_id TEXT NOT NULL,
+		number TEXT NOT NULL,
+		protocol TEXT NOT NULL,
+		state TEXT NOT NULL,
+		service TEXT NOT NULL,
+		FOREIGN KEY (device_id) REFERENCES devices(id)
+	)`, `
+	CREATE TABLE IF NOT EXISTS event_logs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		type TEXT NOT NULL,
+		description TEXT NOT NULL,
+		device_id TEXT,
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL
+	)`, `
+	CREATE TABLE IF NOT EXISTS system_status (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		network_id TEXT,
+		public_ip TEXT,
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL,
+		FOREIGN KEY (network_id) REFERENCES networks(id)
+	)`, `
+	CREATE TABLE IF NOT EXISTS local_devices (
+		system_status_id INTEGER NOT NULL,
+		name TEXT NOT NULL,
+		ipv4 TEXT NOT NULL,
+		mac TEXT,
+		vendor TEXT,
+		status TEXT NOT NULL,
+		hostname TEXT,
+		PRIMARY KEY (system_status_id),
+		FOREIGN KEY (system_status_id) REFERENCES system_status(id)
+	)`)
+	if err != nil {
+		return err
+	}
+
+	// Columns that older releases added one by one
+	columns := []struct{ table, column, definition string }{
+		{"devices", "web_scan_ended_at", "TIMESTAMP"},
+		{"devices", "device_type", "TEXT"},
+		{"devices", "os_name", "TEXT"},
+		{"devices", "os_version", "TEXT"},
+		{"devices", "os_family", "TEXT"},
+		{"devices", "os_confidence", "INTEGER"},
+		{"devices", "comment", "TEXT"},
+		{"devices", "ipv6_link_local", "TEXT"},
+		{"devices", "ipv6_unique_local", "TEXT"},
+		{"devices", "ipv6_global", "TEXT"},
+		{"devices", "ipv6_addresses", "TEXT"},
+		{"networks", "name", "TEXT"},
+		{"networks", "description", "TEXT"},
+		{"networks", "status", "TEXT DEFAULT 'active'"},
+		{"networks", "last_scanned_at", "TIMESTAMP"},
+		{"networks", "device_count", "INTEGER DEFAULT 0"},
+		{"networks", "created_at", "TIMESTAMP"},
+		{"networks", "updated_at", "TIMESTAMP"},
+		{"networks", "ipv6_prefix", "TEXT"},
+		{"networks", "address_family", "TEXT DEFAULT 'ipv4'"},
+	}
+	for _, c := range columns {
+		if err := addColumnIfMissing(tx, c.table, c.column, c.definition); err != nil {
+			return err
+		}
+	}
+
+	return execAll(tx,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_devices_ipv4 ON devices(ipv4)`,
+		`CREATE INDEX IF NOT EXISTS idx_devices_mac ON devices(mac)`,
+		`CREATE INDEX IF NOT EXISTS idx_devices_network_id ON devices(network_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_devices_ipv6_link_local ON devices(ipv6_link_local)`,
+		`CREATE INDEX IF NOT EXISTS idx_devices_ipv6_unique_local ON devices(ipv6_unique_local)`,
+		`CREATE INDEX IF NOT EXISTS idx_devices_ipv6_global ON devices(ipv6_global)`, `
+	CREATE TABLE IF NOT EXISTS web_services (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		device_id TEXT NOT NULL,
+		url TEXT NOT NULL,
+		title TEXT,
+		server TEXT,
+		status_code INTEGER NOT NULL,
+		content_type TEXT,
+		size INTEGER,
+		screenshot TEXT,
+		port INTEGER NOT NULL,
+		protocol TEXT NOT NULL,
+		scanned_at TIMESTAMP NOT NULL,
+		FOREIGN KEY (device_id) REFERENCES devices(id)
+	)`,
+		`CREATE INDEX IF NOT EXISTS idx_web_services_device_id ON web_services(device_id)`, `
+	CREATE TABLE IF NOT EXISTS geolocation_cache (
+		id TEXT PRIMARY KEY,
+		ip TEXT NOT NULL UNIQUE,
+		city TEXT,
+		region TEXT,
+		country TEXT,
+		country_code TEXT,
+		latitude REAL,
+		longitude REAL,
+		timezone TEXT,
+		isp TEXT,
+		source TEXT NOT NULL DEFAULT 'api',
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL,
+		expires_at TIMESTAMP NOT NULL
+	)`,
+		`CREATE INDEX IF NOT EXISTS idx_geolocation_cache_ip ON geolocation_cache(ip)`,
+		`CREATE INDEX IF NOT EXISTS idx_geolocation_cache_expires_at ON geolocation_cache(expires_at)`, `
+	CREATE TABLE IF NOT EXISTS settings (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL,
+		screenshots_enabled BOOLEAN NOT NULL DEFAULT 1,
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL,
+		UNIQUE(user_id)
+	)`,
+		`CREATE INDEX IF NOT EXISTS idx_settings_user_id ON settings(user_id)`,
+	)
+}
+
+func migrateInitialSchemaDown(tx *sql.Tx) error {
+	return execAll(tx,
+		`DROP TABLE IF EXISTS settings`,
+		`DROP TABLE IF EXISTS geolocation_cache`,
+		`DROP TABLE IF EXISTS web_services`,
+		`DROP TABLE IF EXISTS local_devices`,
+		`DROP TABLE IF EXISTS system_status`,
+		`DROP TABLE IF EXISTS event_logs`,
+		`DROP TABLE IF EXISTS ports`,
+		`DROP TABLE IF EXISTS devices`,
+		`DROP TABLE IF EXISTS networks`,
+	)
+}
+
+func migrateTracerouteUp(tx *sql.Tx) error {
+	return execAll(tx, `
+	CREATE TABLE IF NOT EXISTS traceroute_runs (
+		id TEXT PRIMARY KEY,
+		network_id TEXT,
+		device_id TEXT,
+		target TEXT NOT NULL,
+		method TEXT NOT NULL,
+		reached BOOLEAN NOT NULL DEFAULT 0,
+		path_changed BOOLEAN NOT NULL DEFAULT 0,
+		started_at TIMESTAMP NOT NULL,
+		completed_at TIMESTAMP NOT NULL
+	)`,
+		`CREATE INDEX IF NOT EXISTS idx_traceroute_runs_network_id ON traceroute_runs(network_id, started_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_traceroute_runs_device_id ON traceroute_runs(device_id, started_at)`, `
+	CREATE TABLE IF NOT EXISTS traceroute_hops (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		run_id TEXT NOT NULL,
+		ttl INTEGER NOT NULL,
+		ip TEXT,
+		hostname TEXT,
+		rtt_ms REAL,
+		FOREIGN KEY (run_id) REFERENCES traceroute_runs(id) ON DELETE CASCADE
+	)`,
+		`CREATE INDEX IF NOT EXISTS idx_traceroute_hops_run_id ON traceroute_hops(run_id)`, `
+	CREATE TABLE IF NOT EXISTS traceroute_targets (
+		device_id TEXT PRIMARY KEY,
+		created_at TIMESTAMP NOT NULL
+	)`)
+}
+
+func migrateTracerouteDown(tx *sql.Tx) error {
+	return execAll(tx,
+		`DROP TABLE IF EXISTS traceroute_targets`,
+		`DROP TABLE IF EXISTS traceroute_hops`,
+		`DROP TABLE IF EXISTS traceroute_runs`,
+	)
+}
+
+func migrateLatencyUp(tx *sql.Tx) error {
+	return execAll(tx, `
+	CREATE TABLE IF NOT EXISTS latency_samples (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		device_id TEXT NOT NULL,
+		resolution TEXT NOT NULL,
+		sampled_at TIMESTAMP NOT NULL,
+		rtt_min_ms REAL,
+		rtt_avg_ms REAL,
+		rtt_max_ms REAL,
+		jitter_ms REAL,
+		loss_percent REAL NOT NULL,
+		sent INTEGER NOT NULL,
+		received INTEGER NOT NULL
+	)`,
+		`CREATE INDEX IF NOT EXISTS idx_latency_samples_device ON latency_samples(device_id, resolution, sampled_at)`, `
+	CREATE TABLE IF NOT EXISTS latency_monitors (
+		device_id TEXT PRIMARY KEY,
+		rtt_threshold_ms REAL NOT NULL,
+		loss_threshold_percent REAL NOT NULL,
+		degraded BOOLEAN NOT NULL DEFAULT 0,
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL
+	)`)
+}
+
+func migrateLatencyDown(tx *sql.Tx) error {
+	return execAll(tx,
+		`DROP TABLE IF EXISTS latency_monitors`,
+		`DROP TABLE IF EXISTS latency_samples`,
+	)
+}
+
+func migrateStatusIntervalsUp(tx *sql.Tx) error {
+	return execAll(tx, `
+	CREATE TABLE IF NOT EXISTS device_status_intervals (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		device_id TEXT NOT NULL,
+		status TEXT NOT NULL,
+		started_at TIMESTAMP NOT NULL,
+		ended_at TIMESTAMP
+	)`,
+		`CREATE INDEX IF NOT EXISTS idx_device_status_intervals_device ON device_status_intervals(device_id, started_at)`,
+		// Open an interval for devices that predate availability tracking
+		`
+	INSERT INTO device_status_intervals (device_id, status, started_at)
+	SELECT id, status, updated_at FROM devices
+	WHERE NOT EXISTS (SELECT 1 FROM device_status_intervals WHERE device_id = devices.id)`)
+}
+
+func migrateStatusIntervalsDown(tx *sql.Tx) error {
+	return execAll(tx, `DROP TABLE IF EXISTS device_status_intervals`)
+}
+
+func migrateStatusThresholdsUp(tx *sql.Tx) error {
+	return execAll(tx, `
+	CREATE TABLE IF NOT EXISTS status_thresholds (
+		scope TEXT NOT NULL,
+		scope_id TEXT NOT NULL,
+		idle_after_seconds INTEGER,
+		offline_after_seconds INTEGER,
+		updated_at TIMESTAMP NOT NULL,
+		PRIMARY KEY (scope, scope_id)
+	)`)
+}
+
+func migrateStatusThresholdsDown(tx *sql.Tx) error {
+	return execAll(tx, `DROP TABLE IF EXISTS status_thresholds`)
+}
+
+func migrateDeviceTagsUp(tx *sql.Tx) error {
+	// JSON array of device tags
+	return addColumnIfMissing(tx, "devices", "tags", "TEXT")
+}
+
+func migrateDeviceTagsDown(tx *sql.Tx) error {
+	return execAll(tx, `ALTER TABLE devices DROP COLUMN tags`)
+}
+
+func migrateUsersUp(tx *sql.Tx) error {
+	return execAll(tx, `
+	CREATE TABLE IF NOT EXISTS users (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		username TEXT NOT NULL UNIQUE,
+		password_hash TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL
+	)`)
+}
+
+func migrateUsersDown(tx *sql.Tx) error {
+	return execAll(tx, `DROP TABLE IF EXISTS users`)
+}
+
+func migrateEventRetentionUp(tx *sql.Tx) error {
+	return execAll(tx, `
+	CREATE TABLE IF NOT EXISTS event_log_rollups (
+		day TEXT NOT NULL,
+		type TEXT NOT NULL,
+		device_id TEXT NOT NULL DEFAULT '',
+		count INTEGER NOT NULL,
+		PRIMARY KEY (day, type, device_id)
+	)`,
+		`CREATE INDEX IF NOT EXISTS idx_event_logs_created_at ON event_logs(created_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_event_logs_type ON event_logs(type, created_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_system_status_created_at ON system_status(created_at)`)
+}
+
+func migrateEventRetentionDown(tx *sql.Tx) error {
+	return execAll(tx,
+		`DROP INDEX IF EXISTS idx_system_status_created_at`,
+		`DROP INDEX IF EXISTS idx_event_logs_type`,
+		`DROP INDEX IF EXISTS idx_event_logs_created_at`,
+		`DROP TABLE IF EXISTS event_log_rollups`,
+	)
+}
+
+// Loading or replacing a device's ports and events scanned the whole table
+func migrateDeviceChildIndexesUp(tx *sql.Tx) error {
+	return execAll(tx,
+		`CREATE INDEX IF NOT EXISTS idx_ports_device_id ON ports(device_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_event_logs_device_id ON event_logs(device_id, created_at)`,
+	)
+}
+
+func migrateDeviceChildIndexesDown(tx *sql.Tx) error {
+	return execAll(tx,
+		`DROP INDEX IF EXISTS idx_event_logs_device_id`,
+		`DROP INDEX IF EXISTS idx_ports_device_id`,
+	)
+}
+
+// The FTS5 table is only created by builds that include FTS5; see event_log_search.go
+func migrateEventLogSearchUp(tx *sql.Tx) error {
+	return syncEventSearch(tx)
+}
+
+func migrateEventLogSearchDown(tx *sql.Tx) error {
+	if err := dropEventSearchTriggers(tx); err != nil {
+		return err
+	}
+	available, err := fts5Available(tx)
+	if err != nil || !available {
+		// Dropping a virtual table needs its module
+		return err
+	}
+	return execAll(tx, `DROP TABLE IF EXISTS event_logs_fts`)
+}
+
+func migrateEventLogPayloadsUp(tx *sql.Tx) error {
+	// JSON object whose schema depends on the event type
+	return execAll(tx, `ALTER TABLE event_logs ADD COLUMN payload TEXT`)
+}
+
+func migrateEventLogPayloadsDown(tx *sql.Tx) error {
+	return execAll(tx, `ALTER TABLE event_logs DROP COLUMN payload`)
+}
+
+// IPv6-only devices have no IPv4 address, so only set addresses are unique
+func migrateIPv6OnlyDevicesUp(tx *sql.Tx) error {
+	return execAll(tx,
+		`DROP INDEX IF EXISTS idx_devices_ipv4`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_devices_ipv4 ON devices(ipv4) WHERE ipv4 <> ''`,
+	)
+}
+
+// Fails while more than one IPv6-only device is stored
+func migrateIPv6OnlyDevicesDown(tx *sql.Tx) error {
+	return execAll(tx,
+		`DROP INDEX IF EXISTS idx_devices_ipv4`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_devices_ipv4 ON devices(ipv4)`,
+	)
+}
+
+// Routers and DHCP servers seen on each network; list columns hold JSON arrays
+func migrateInfraServersUp(tx *sql.Tx) error {
+	return execAll(tx, `
+	CREATE TABLE IF NOT EXISTS infra_servers (
+		id TEXT PRIMARY KEY,
+		network_id TEXT NOT NULL DEFAULT '',
+		kind TEXT NOT NULL,
+		ip TEXT NOT NULL,
+		mac TEXT NOT NULL DEFAULT '',
+		interface TEXT,
+		prefixes TEXT,
+		flags TEXT,
+		router_lifetime_seconds INTEGER,
+		offered_address TEXT,
+		dns_servers TEXT,
+		allowed BOOLEAN NOT NULL DEFAULT 0,
+		first_seen_at TIMESTAMP NOT NULL,
+		last_seen_at TIMESTAMP NOT NULL,
+		UNIQUE (kind, network_id, ip, mac)
+	)`)
+}
+
+func migrateInfraServersDown(tx *sql.Tx) error {
+	return execAll(tx, `DROP TABLE IF EXISTS infra_servers`)
+}
+
+// Recent IPv4 to MAC address bindings for ARP spoofing and address conflict
+// detection
+func migrateIPMACBindingsUp(tx *sql.Tx) error {
+	return execAll(tx, `
+	CREATE TABLE IF NOT EXISTS ip_mac_bindings (
+		ip TEXT NOT NULL,
+		mac TEXT NOT NULL,
+		network_id TEXT NOT NULL DEFAULT '',
+		source TEXT NOT NULL,
+		first_seen_at TIMESTAMP NOT NULL,
+		last_seen_at TIMESTAMP NOT NULL,
+		PRIMARY KEY (ip, mac)
+	)`,
+		`CREATE INDEX IF NOT EXISTS idx_ip_mac_bindings_mac ON ip_mac_bindings(mac, last_seen_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_ip_mac_bindings_last_seen ON ip_mac_bindings(last_seen_at)`,
+	)
+}
+
+func migrateIPMACBindingsDown(tx *sql.Tx) error {
+	return execAll(tx, `DROP TABLE IF EXISTS ip_mac_bindings`)
+}
+
+// Scan settings attached to networks, device types, tags and devices; NULL
+// columns inherit from the broader scope
+func migrateScanPoliciesUp(tx *sql.Tx) error {
+	return execAll(tx, `
+	CREATE TABLE IF NOT EXISTS scan_policies (
+		scope TEXT NOT NULL,
+		scope_id TEXT NOT NULL,
+		exclude BOOLEAN,
+		ping_only BOOLEAN,
+		ports TEXT,
+		top_ports INTEGER,
+		udp BOOLEAN,
+		os_detection BOOLEAN,
+		screenshots BOOLEAN,
+		max_rate INTEGER,
+		updated_at TIMESTAMP NOT NULL,
+		PRIMARY KEY (scope, scope_id)
+	)`)
+}
+
+func migrateScanPoliciesDown(tx *sql.Tx) error {
+	return execAll(tx, `DROP TABLE IF EXISTS scan_policies`)
+}
+
+// Per network packet rate and windows in which scanning is allowed
+func migrateNetworkScanLimitsUp(tx *sql.Tx) error {
+	return execAll(tx,
+		`ALTER TABLE networks ADD COLUMN rate_limit INTEGER`,
+		`ALTER TABLE networks ADD COLUMN scan_windows TEXT`,
+	)
+}
+
+func migrateNetworkScanLimitsDown(tx *sql.Tx) error {
+	return execAll(tx,
+		`ALTER TABLE networks DROP COLUMN scan_windows`,
+		`ALTER TABLE networks DROP COLUMN rate_limit`,
+	)
+}
+
+// Addresses, ranges and MAC addresses that are never scanned; an empty
+// network_id applies to all networks
+func migrateExclusionsUp(tx *sql.Tx) error {
+	return execAll(tx, `
+	CREATE TABLE IF NOT EXISTS exclusions (
+		id TEXT PRIMARY KEY,
+		network_id TEXT NOT NULL DEFAULT '',
+		kind TEXT NOT NULL,
+		value TEXT NOT NULL,
+		comment TEXT,
+		created_at TIMESTAMP NOT NULL,
+		UNIQUE (network_id, value)
+	)`)
+}
+
+func migrateExclusionsDown(tx *sql.Tx) error {
+	return execAll(tx, `DROP TABLE IF EXISTS exclusions`)
+}
@@ -0,0 +1,316 @@
+package db
+
+import "database/sql"
+
+// postgresMigrations is the schema history for PostgreSQL. Versions and names
+// match migrations so schema versions mean the same on both databases.
+var postgresMigrations = []Migration{
+	{Version: 1, Name: "initial_schema", Up: migratePostgresInitialSchemaUp, Down: migrateInitialSchemaDown},
+	{Version: 2, Name: "traceroute", Up: migratePostgresTracerouteUp, Down: migrateTracerouteDown},
+	{Version: 3, Name: "latency", Up: migratePostgresLatencyUp, Down: migrateLatencyDown},
+	{Version: 4, Name: "device_status_intervals", Up: migratePostgresStatusIntervalsUp, Down: migrateStatusIntervalsDown},
+	{Version: 5, Name: "status_thresholds", Up: migratePostgresStatusThresholdsUp, Down: migrateStatusThresholdsDown},
+	{Version: 6, Name: "device_tags", Up: migratePostgresDeviceTagsUp, Down: migrateDeviceTagsDown},
+	{Version: 7, Name: "users", Up: migratePostgresUsersUp, Down: migrateUsersDown},
+	{Version: 8, Name: "event_retention", Up: migrateEventRetentionUp, Down: migrateEventRetentionDown},
+	{Version: 9, Name: "device_child_indexes", Up: migrateDeviceChildIndexesUp, Down: migrateDeviceChildIndexesDown},
+	{Version: 10, Name: "event_log_search", Up: migratePostgresEventLogSearchUp, Down: migratePostgresEventLogSearchDown},
+	{Version: 11, Name: "event_log_payloads", Up: migrateEventLogPayloadsUp, Down: migrateEventLogPayloadsDown},
+	{Version: 12, Name: "ipv6_only_devices", Up: migrateIPv6OnlyDevicesUp, Down: migrateIPv6OnlyDevicesDown},
+	{Version: 13, Name: "infra_servers", Up: migratePostgresInfraServersUp, Down: migrateInfraServersDown},
+	{Version: 14, Name: "ip_mac_bindings", Up: migratePostgresIPMACBindingsUp, Down: migrateIPMACBindingsDown},
+	{Version: 15, Name: "scan_policies", Up: migratePostgresScanPoliciesUp, Down: migrateScanPoliciesDown},
+	{Version: 16, Name: "network_scan_limits", Up: migrateNetworkScanLimitsUp, Down: migrateNetworkScanLimitsDown},
+	{Version: 17, Name: "exclusions", Up: migratePostgresExclusionsUp, Down: migrateExclusionsDown},
+}
+
+func migratePostgresInitialSchemaUp(tx *sql.Tx) error {
+	return execAll(tx, `
+	CREATE TABLE IF NOT EXISTS networks (
+		id TEXT PRIMARY KEY,
+		cidr TEXT NOT NULL,
+		name TEXT,
+		description TEXT,
+		status TEXT DEFAULT 'active',
+		last_scanned_at TIMESTAMPTZ,
+		device_count INTEGER DEFAULT 0,
+		created_at TIMESTAMPTZ,
+		updated_at TIMESTAMPTZ,
+		ipv6_prefix TEXT,
+		address_family TEXT DEFAULT 'ipv4'
+	)`, `
+	CREATE TABLE IF NOT EXISTS devices (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		comment TEXT,
+		ipv4 TEXT NOT NULL,
+		ipv6_link_local TEXT,
+		ipv6_unique_local TEXT,
+		ipv6_global TEXT,
+		ipv6_addresses TEXT,
+		mac TEXT,
+		vendor TEXT,
+		device_type TEXT,
+		os_name TEXT,
+		os_version TEXT,
+		os_family TEXT,
+		os_confidence INTEGER,
+		status TEXT NOT NULL,
+		network_id TEXT REFERENCES networks(id),
+		hostname TEXT,
+		created_at TIMESTAMPTZ NOT NULL,
+		updated_at TIMESTAMPTZ NOT NULL,
+		last_seen_online_at TIMESTAMPTZ,
+		port_scan_started_at TIMESTAMPTZ,
+		port_scan_ended_at TIMESTAMPTZ,
+		web_scan_ended_at TIMESTAMPTZ
+	)`, `
+	CREATE TABLE IF NOT EXISTS ports (
+		id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+		device_id TEXT NOT NULL REFERENCES devices(id),
+		number TEXT NOT NULL,
+		protocol TEXT NOT NULL,
+		state TEXT NOT NULL,
+		service TEXT NOT NULL
+	)`, `
+	CREATE TABLE IF NOT EXISTS event_logs (
+		id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+		type TEXT NOT NULL,
+		description TEXT NOT NULL,
+		device_id TEXT,
+		created_at TIMESTAMPTZ NOT NULL,
+		updated_at TIMESTAMPTZ NOT NULL
+	)`, `
+	CREATE TABLE IF NOT EXISTS system_status (
+		id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+		network_id TEXT REFERENCES networks(id),
+		public_ip TEXT,
+		created_at TIMESTAMPTZ NOT NULL,
+		updated_at TIMESTAMPTZ NOT NULL
+	)`, `
+	CREATE TABLE IF NOT EXISTS local_devices (
+		system_status_id BIGINT PRIMARY KEY REFERENCES system_status(id),
+		name TEXT NOT NULL,
+		ipv4 TEXT NOT NULL,
+		mac TEXT,
+		vendor TEXT,
+		status TEXT NOT NULL,
+		hostname TEXT
+	)`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_devices_ipv4 ON devices(ipv4)`,
+		`CREATE INDEX IF NOT EXISTS idx_devices_mac ON devices(mac)`,
+		`CREATE INDEX IF NOT EXISTS idx_devices_network_id ON devices(network_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_devices_ipv6_link_local ON devices(ipv6_link_local)`,
+		`CREATE INDEX IF NOT EXISTS idx_devices_ipv6_unique_local ON devices(ipv6_unique_local)`,
+		`CREATE INDEX IF NOT EXISTS idx_devices_ipv6_global ON devices(ipv6_global)`, `
+	CREATE TABLE IF NOT EXISTS web_services (
+		id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+		device_id TEXT NOT NULL REFERENCES devices(id),
+		url TEXT NOT NULL,
+		title TEXT,
+		server TEXT,
+		status_code INTEGER NOT NULL,
+		content_type TEXT,
+		size BIGINT,
+		screenshot TEXT,
+		port INTEGER NOT NULL,
+		protocol TEXT NOT NULL,
+		scanned_at TIMESTAMPTZ NOT NULL
+	)`,
+		`CREATE INDEX IF NOT EXISTS idx_web_services_device_id ON web_services(device_id)`, `
+	CREATE TABLE IF NOT EXISTS geolocation_cache (
+		id TEXT PRIMARY KEY,
+		ip TEXT NOT NULL UNIQUE,
+		city TEXT,
+		region TEXT,
+		country TEXT,
+		country_code TEXT,
+		latitude DOUBLE PRECISION,
+		longitude DOUBLE PRECISION,
+		timezone TEXT,
+		isp TEXT,
+		source TEXT NOT NULL DEFAULT 'api',
+		created_at TIMESTAMPTZ NOT NULL,
+		updated_at TIMESTAMPTZ NOT NULL,
+		expires_at TIMESTAMPTZ NOT NULL
+	)`,
+		`CREATE INDEX IF NOT EXISTS idx_geolocation_cache_expires_at ON geolocation_cache(expires_at)`, `
+	CREATE TABLE IF NOT EXISTS settings (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL UNIQUE,
+		screenshots_enabled BOOLEAN NOT NULL DEFAULT TRUE,
+		created_at TIMESTAMPTZ NOT NULL,
+		updated_at TIMESTAMPTZ NOT NULL
+	)`)
+}
+
+func migratePostgresTracerouteUp(tx *sql.Tx) error {
+	return execAll(tx, `
+	CREATE TABLE IF NOT EXISTS traceroute_runs (
+		id TEXT PRIMARY KEY,
+		network_id TEXT,
+		device_id TEXT,
+		target TEXT NOT NULL,
+		method TEXT NOT NULL,
+		reached BOOLEAN NOT NULL DEFAULT FALSE,
+		path_changed BOOLEAN NOT NULL DEFAULT FALSE,
+		started_at TIMESTAMPTZ NOT NULL,
+		completed_at TIMESTAMPTZ NOT NULL
+	)`,
+		`CREATE INDEX IF NOT EXISTS idx_traceroute_runs_network_id ON traceroute_runs(network_id, started_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_traceroute_runs_device_id ON traceroute_runs(device_id, started_at)`, `
+	CREATE TABLE IF NOT EXISTS traceroute_hops (
+		id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+		run_id TEXT NOT NULL REFERENCES traceroute_runs(id) ON DELETE CASCADE,
+		ttl INTEGER NOT NULL,
+		ip TEXT,
+		hostname TEXT,
+		rtt_ms DOUBLE PRECISION
+	)`,
+		`CREATE INDEX IF NOT EXISTS idx_traceroute_hops_run_id ON traceroute_hops(run_id)`, `
+	CREATE TABLE IF NOT EXISTS traceroute_targets (
+		device_id TEXT PRIMARY KEY,
+		created_at TIMESTAMPTZ NOT NULL
+	)`)
+}
+
+func migratePostgresLatencyUp(tx *sql.Tx) error {
+	return execAll(tx, `
+	CREATE TABLE IF NOT EXISTS latency_samples (
+		id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+		device_id TEXT NOT NULL,
+		resolution TEXT NOT NULL,
+		sampled_at TIMESTAMPTZ NOT NULL,
+		rtt_min_ms DOUBLE PRECISION,
+		rtt_avg_ms DOUBLE PRECISION,
+		rtt_max_ms DOUBLE PRECISION,
+		jitter_ms DOUBLE PRECISION,
+		loss_percent DOUBLE PRECISION NOT NULL,
+		sent INTEGER NOT NULL,
+		received INTEGER NOT NULL
+	)`,
+		`CREATE INDEX IF NOT EXISTS idx_latency_samples_device ON latency_samples(device_id, resolution, sampled_at)`, `
+	CREATE TABLE IF NOT EXISTS latency_monitors (
+		device_id TEXT PRIMARY KEY,
+		rtt_threshold_ms DOUBLE PRECISION NOT NULL,
+		loss_threshold_percent DOUBLE PRECISION NOT NULL,
+		degraded BOOLEAN NOT NULL DEFAULT FALSE,
+		created_at TIMESTAMPTZ NOT NULL,
+		updated_at TIMESTAMPTZ NOT NULL
+	)`)
+}
+
+func migratePostgresStatusIntervalsUp(tx *sql.Tx) error {
+	return execAll(tx, `
+	CREATE TABLE IF NOT EXISTS device_status_intervals (
+		id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+		device_id TEXT NOT NULL,
+		status TEXT NOT NULL,
+		started_at TIMESTAMPTZ NOT NULL,
+		ended_at TIMESTAMPTZ
+	)`,
+		`CREATE INDEX IF NOT EXISTS idx_device_status_intervals_device ON device_status_intervals(device_id, started_at)`)
+}
+
+func migratePostgresStatusThresholdsUp(tx *sql.Tx) error {
+	return execAll(tx, `
+	CREATE TABLE IF NOT EXISTS status_thresholds (
+		scope TEXT NOT NULL,
+		scope_id TEXT NOT NULL,
+		idle_after_seconds INTEGER,
+		offline_after_seconds INTEGER,
+		updated_at TIMESTAMPTZ NOT NULL,
+		PRIMARY KEY (scope, scope_id)
+	)`)
+}
+
+func migratePostgresDeviceTagsUp(tx *sql.Tx) error {
+	// JSON array of device tags
+	return execAll(tx, `ALTER TABLE devices ADD COLUMN IF NOT EXISTS tags TEXT`)
+}
+
+func migratePostgresUsersUp(tx *sql.Tx) error {
+	return execAll(tx, `
+	CREATE TABLE IF NOT EXISTS users (
+		id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+		username TEXT NOT NULL UNIQUE,
+		password_hash TEXT NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL
+	)`)
+}
+
+func migratePostgresEventLogSearchUp(tx *sql.Tx) error {
+	return execAll(tx, `CREATE INDEX IF NOT EXISTS idx_event_logs_description_search ON event_logs USING GIN (to_tsvector('simple', description))`)
+}
+
+func migratePostgresEventLogSearchDown(tx *sql.Tx) error {
+	return execAll(tx, `DROP INDEX IF EXISTS idx_event_logs_description_search`)
+}
+
+func migratePostgresInfraServersUp(tx *sql.Tx) error {
+	return execAll(tx, `
+	CREATE TABLE IF NOT EXISTS infra_servers (
+		id TEXT PRIMARY KEY,
+		network_id TEXT NOT NULL DEFAULT '',
+		kind TEXT NOT NULL,
+		ip TEXT NOT NULL,
+		mac TEXT NOT NULL DEFAULT '',
+		interface TEXT,
+		prefixes TEXT,
+		flags TEXT,
+		router_lifetime_seconds INTEGER,
+		offered_address TEXT,
+		dns_servers TEXT,
+		allowed BOOLEAN NOT NULL DEFAULT FALSE,
+		first_seen_at TIMESTAMPTZ NOT NULL,
+		last_seen_at TIMESTAMPTZ NOT NULL,
+		UNIQUE (kind, network_id, ip, mac)
+	)`)
+}
+
+func migratePostgresIPMACBindingsUp(tx *sql.Tx) error {
+	return execAll(tx, `
+	CREATE TABLE IF NOT EXISTS ip_mac_bindings (
+		ip TEXT NOT NULL,
+		mac TEXT NOT NULL,
+		network_id TEXT NOT NULL DEFAULT '',
+		source TEXT NOT NULL,
+		first_seen_at TIMESTAMPTZ NOT NULL,
+		last_seen_at TIMESTAMPTZ NOT NULL,
+		PRIMARY KEY (ip, mac)
+	)`,
+		`CREATE INDEX IF NOT EXISTS idx_ip_mac_bindings_mac ON ip_mac_bindings(mac, last_seen_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_ip_mac_bindings_last_seen ON ip_mac_bindings(last_seen_at)`,
+	)
+}
+
+func migratePostgresScanPoliciesUp(tx *sql.Tx) error {
+	return execAll(tx, `
+	CREATE TABLE IF NOT EXISTS scan_policies (
+		scope TEXT NOT NULL,
+		scope_id TEXT NOT NULL,
+		exclude BOOLEAN,
+		ping_only BOOLEAN,
+		ports TEXT,
+		top_ports INTEGER,
+		udp BOOLEAN,
+		os_detection BOOLEAN,
+		screenshots BOOLEAN,
+		max_rate INTEGER,
+		updated_at TIMESTAMPTZ NOT NULL,
+		PRIMARY KEY (scope, scope_id)
+	)`)
+}
+
+func migratePostgresExclusionsUp(tx *sql.Tx) error {
+	return execAll(tx, `
+	CREATE TABLE IF NOT EXISTS exclusions (
+		id TEXT PRIMARY KEY,
+		network_id TEXT NOT NULL DEFAULT '',
+		kind TEXT NOT NULL,
+		value TEXT NOT NULL,
+		comment TEXT,
+		created_at TIMESTAMPTZ NOT NULL,
+		UNIQUE (network_id, value)
+	)`)
+}
@@ -0,0 +1,123 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// ConnectToPostgres initializes and returns a PostgreSQL connection. The
+// repositories write SQLite style "?" placeholders; connections opened here
+// rewrite them to "$1", "$2", ... so the same queries run on both databases.
+func ConnectToPostgres(dsn string) (*sql.DB, error) {
+	connConfig, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid PostgreSQL connection string: %w", err)
+	}
+
+	db := sql.OpenDB(&postgresConnector{connector: stdlib.GetConnector(*connConfig)})
+
+	// Several reconya instances may share the database; keep the pool modest
+	db.SetMaxOpenConns(15)
+	db.SetMaxIdleConns(5)
+	db.SetConnMaxLifetime(30 * time.Minute)
+
+	if err = db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping PostgreSQL database: %w", err)
+	}
+
+	log.Printf("Connected to PostgreSQL database %s on %s", connConfig.Database, connConfig.Host)
+	return db, nil
+}
+
+// IsPostgres reports whether db was opened by ConnectToPostgres
+func IsPostgres(db *sql.DB) bool {
+	_, ok := db.Driver().(postgresDriver)
+	return ok
+}
+
+type postgresConnector struct {
+	connector driver.Connector
+}
+
+func (c *postgresConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &postgresConn{Conn: conn.(*stdlib.Conn)}, nil
+}
+
+func (c *postgresConnector) Driver() driver.Driver {
+	return postgresDriver{}
+}
+
+type postgresDriver struct{}
+
+func (postgresDriver) Open(name string) (driver.Conn, error) {
+	conn, err := stdlib.GetDefaultDriver().Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &postgresConn{Conn: conn.(*stdlib.Conn)}, nil
+}
+
+// postgresConn rewrites placeholders before handing queries to pgx
+type postgresConn struct {
+	*stdlib.Conn
+}
+
+func (c *postgresConn) Prepare(query string) (driver.Stmt, error) {
+	return c.Conn.Prepare(rebindPostgres(query))
+}
+
+func (c *postgresConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	return c.Conn.PrepareContext(ctx, rebindPostgres(query))
+}
+
+func (c *postgresConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return c.Conn.ExecContext(ctx, rebindPostgres(query), args)
+}
+
+func (c *postgresConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return c.Conn.QueryContext(ctx, rebindPostgres(query), args)
+}
+
+// rebindPostgres numbers the "?" placeholders of a query, leaving quoted
+// strings and identifiers alone
+func rebindPostgres(query string) string {
+	if !strings.Contains(query, "?") {
+		return query
+	}
+
+	var b strings.Builder
+	b.Grow(len(query) + 8)
+	n := 0
+	var quote rune
+	for _, r := range query {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '?':
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
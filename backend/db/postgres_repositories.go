@@ -0,0 +1,160 @@
+package db
+
+import "database/sql"
+
+// The PostgreSQL repositories reuse the SQLite ones: their queries are
+// portable once ConnectToPostgres has rewritten the placeholders, so only
+// dialect differences are overridden here.
+
+// PostgresNetworkRepository implements the NetworkRepository interface for PostgreSQL
+type PostgresNetworkRepository struct {
+	*SQLiteNetworkRepository
+}
+
+// NewPostgresNetworkRepository creates a new PostgresNetworkRepository
+func NewPostgresNetworkRepository(db *sql.DB) *PostgresNetworkRepository {
+	return &PostgresNetworkRepository{NewSQLiteNetworkRepository(db)}
+}
+
+// PostgresDeviceRepository implements the DeviceRepository interface for PostgreSQL
+type PostgresDeviceRepository struct {
+	*SQLiteDeviceRepository
+}
+
+// NewPostgresDeviceRepository creates a new PostgresDeviceRepository
+func NewPostgresDeviceRepository(db *sql.DB) *PostgresDeviceRepository {
+	return &PostgresDeviceRepository{NewSQLiteDeviceRepository(db)}
+}
+
+// PostgresEventLogRepository implements the EventLogRepository interface for PostgreSQL
+type PostgresEventLogRepository struct {
+	*SQLiteEventLogRepository
+}
+
+// NewPostgresEventLogRepository creates a new PostgresEventLogRepository
+func NewPostgresEventLogRepository(db *sql.DB) *PostgresEventLogRepository {
+	return &PostgresEventLogRepository{&SQLiteEventLogRepository{db: db, textSearch: postgresTextSearch}}
+}
+
+// PostgresSystemStatusRepository implements the SystemStatusRepository interface for PostgreSQL
+type PostgresSystemStatusRepository struct {
+	*SQLiteSystemStatusRepository
+}
+
+// NewPostgresSystemStatusRepository creates a new PostgresSystemStatusRepository
+func NewPostgresSystemStatusRepository(db *sql.DB) *PostgresSystemStatusRepository {
+	return &PostgresSystemStatusRepository{NewSQLiteSystemStatusRepository(db)}
+}
+
+// PostgresSettingsRepository implements the SettingsRepository interface for PostgreSQL
+type PostgresSettingsRepository struct {
+	*SQLiteSettingsRepository
+}
+
+// NewPostgresSettingsRepository creates a new PostgresSettingsRepository
+func NewPostgresSettingsRepository(db *sql.DB) *PostgresSettingsRepository {
+	return &PostgresSettingsRepository{NewSQLiteSettingsRepository(db)}
+}
+
+// PostgresTracerouteRepository implements the TracerouteRepository interface for PostgreSQL
+type PostgresTracerouteRepository struct {
+	*SQLiteTracerouteRepository
+}
+
+// NewPostgresTracerouteRepository creates a new PostgresTracerouteRepository
+func NewPostgresTracerouteRepository(db *sql.DB) *PostgresTracerouteRepository {
+	return &PostgresTracerouteRepository{NewSQLiteTracerouteRepository(db)}
+}
+
+// PostgresLatencyRepository implements the LatencyRepository interface for PostgreSQL
+type PostgresLatencyRepository struct {
+	*SQLiteLatencyRepository
+}
+
+// NewPostgresLatencyRepository creates a new PostgresLatencyRepository
+func NewPostgresLatencyRepository(db *sql.DB) *PostgresLatencyRepository {
+	return &PostgresLatencyRepository{NewSQLiteLatencyRepository(db)}
+}
+
+// PostgresAvailabilityRepository implements the AvailabilityRepository interface for PostgreSQL
+type PostgresAvailabilityRepository struct {
+	*SQLiteAvailabilityRepository
+}
+
+// NewPostgresAvailabilityRepository creates a new PostgresAvailabilityRepository
+func NewPostgresAvailabilityRepository(db *sql.DB) *PostgresAvailabilityRepository {
+	return &PostgresAvailabilityRepository{NewSQLiteAvailabilityRepository(db)}
+}
+
+// PostgresStatusThresholdRepository implements the StatusThresholdRepository interface for PostgreSQL
+type PostgresStatusThresholdRepository struct {
+	*SQLiteStatusThresholdRepository
+}
+
+// NewPostgresStatusThresholdRepository creates a new PostgresStatusThresholdRepository
+func NewPostgresStatusThresholdRepository(db *sql.DB) *PostgresStatusThresholdRepository {
+	return &PostgresStatusThresholdRepository{NewSQLiteStatusThresholdRepository(db)}
+}
+
+// PostgresScanPolicyRepository implements the ScanPolicyRepository interface for PostgreSQL
+type PostgresScanPolicyRepository struct {
+	*SQLiteScanPolicyRepository
+}
+
+// NewPostgresScanPolicyRepository creates a new PostgresScanPolicyRepository
+func NewPostgresScanPolicyRepository(db *sql.DB) *PostgresScanPolicyRepository {
+	return &PostgresScanPolicyRepository{NewSQLiteScanPolicyRepository(db)}
+}
+
+// PostgresExclusionRepository implements the ExclusionRepository interface for PostgreSQL
+type PostgresExclusionRepository struct {
+	*SQLiteExclusionRepository
+}
+
+// NewPostgresExclusionRepository creates a new PostgresExclusionRepository
+func NewPostgresExclusionRepository(db *sql.DB) *PostgresExclusionRepository {
+	return &PostgresExclusionRepository{NewSQLiteExclusionRepository(db)}
+}
+
+// PostgresInfraServerRepository implements the InfraServerRepository interface for PostgreSQL
+type PostgresInfraServerRepository struct {
+	*SQLiteInfraServerRepository
+}
+
+// NewPostgresInfraServerRepository creates a new PostgresInfraServerRepository
+func NewPostgresInfraServerRepository(db *sql.DB) *PostgresInfraServerRepository {
+	return &PostgresInfraServerRepository{NewSQLiteInfraServerRepository(db)}
+}
+
+// PostgresIPMACBindingRepository implements the IPMACBindingRepository interface for PostgreSQL
+type PostgresIPMACBindingRepository struct {
+	*SQLiteIPMACBindingRepository
+}
+
+// NewPostgresIPMACBindingRepository creates a new PostgresIPMACBindingRepository
+func NewPostgresIPMACBindingRepository(db *sql.DB) *PostgresIPMACBindingRepository {
+	return &PostgresIPMACBindingRepository{NewSQLiteIPMACBindingRepository(db)}
+}
+
+// PostgresUserRepository implements the UserRepository interface for PostgreSQL
+type PostgresUserRepository struct {
+	*SQLiteUserRepository
+}
+
+// NewPostgresUserRepository creates a new PostgresUserRepository
+func NewPostgresUserRepository(db *sql.DB) *PostgresUserRepository {
+	return &PostgresUserRepository{NewSQLiteUserRepository(db)}
+}
+
+// PostgresRetentionRepository implements the RetentionRepository interface for PostgreSQL
+type PostgresRetentionRepository struct {
+	*SQLiteRetentionRepository
+}
+
+// NewPostgresRetentionRepository creates a new PostgresRetentionRepository
+func NewPostgresRetentionRepository(db *sql.DB) *PostgresRetentionRepository {
+	return &PostgresRetentionRepository{&SQLiteRetentionRepository{
+		db:            db,
+		dayExpression: "to_char(created_at, 'YYYY-MM-DD')",
+	}}
+}
@@ -0,0 +1,25 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRebindPostgres(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{`SELECT 1`, `SELECT 1`},
+		{`SELECT * FROM devices WHERE id = ?`, `SELECT * FROM devices WHERE id = $1`},
+		{`INSERT INTO t (a, b, c) VALUES (?, ?, ?)`, `INSERT INTO t (a, b, c) VALUES ($1, $2, $3)`},
+		{`SELECT '?' FROM t WHERE a = ? AND b = 'x?y'`, `SELECT '?' FROM t WHERE a = $1 AND b = 'x?y'`},
+		{`SELECT "odd?name" FROM t WHERE a IN (?, ?)`, `SELECT "odd?name" FROM t WHERE a IN ($1, $2)`},
+		{`SELECT 'it''s' FROM t WHERE a = ?`, `SELECT 'it''s' FROM t WHERE a = $1`},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, rebindPostgres(tt.query))
+	}
+}
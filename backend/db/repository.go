@@ -28,6 +28,7 @@ type NetworkRepository interface {
 	CreateOrUpdate(ctx context.Context, network *models.Network) (*models.Network, error)
 	Delete(ctx context.Context, id string) error
 	GetDeviceCount(ctx context.Context, networkID string) (int, error)
+	UpdateLastScannedAt(ctx context.Context, id string, scannedAt time.Time) error
 }
 
 // DeviceRepository defines the interface for device operations
@@ -37,7 +38,9 @@ type DeviceRepository interface {
 	FindByIP(ctx context.Context, ip string) (*models.Device, error)
 	FindAll(ctx context.Context) ([]*models.Device, error)
 	CreateOrUpdate(ctx context.Context, device *models.Device) (*models.Device, error)
-	UpdateDeviceStatuses(ctx context.Context, timeout time.Duration) error
+	SaveSweepBatch(ctx context.Context, devices []*models.Device) error
+	FindActivity(ctx context.Context) ([]*models.DeviceActivity, error)
+	ApplyStatusTransitions(ctx context.Context, transitions []*models.DeviceStatusTransition) ([]*models.DeviceStatusTransition, error)
 	DeleteByID(ctx context.Context, id string) error
 }
 
@@ -45,8 +48,10 @@ type DeviceRepository interface {
 type EventLogRepository interface {
 	Repository
 	Create(ctx context.Context, eventLog *models.EventLog) error
+	CreateBatch(ctx context.Context, eventLogs []*models.EventLog) error
 	FindLatest(ctx context.Context, limit int) ([]*models.EventLog, error)
 	FindAllByDeviceID(ctx context.Context, deviceID string) ([]*models.EventLog, error)
+	Search(ctx context.Context, filter models.EventLogFilter, after *models.EventLogCursor, limit int) ([]*models.EventLog, error)
 }
 
 // SystemStatusRepository defines the interface for system status operations
@@ -75,10 +80,104 @@ type SettingsRepository interface {
 	Update(settings *models.Settings) error
 }
 
-// RepositoryFactory creates repositories
+// TracerouteRepository defines the interface for traceroute history operations
+type TracerouteRepository interface {
+	Repository
+	Create(ctx context.Context, run *models.TracerouteRun) error
+	FindLatestByNetworkID(ctx context.Context, networkID string) (*models.TracerouteRun, error)
+	FindLatestByDeviceID(ctx context.Context, deviceID string) (*models.TracerouteRun, error)
+	FindByNetworkID(ctx context.Context, networkID string, limit int) ([]*models.TracerouteRun, error)
+	FindByDeviceID(ctx context.Context, deviceID string, limit int) ([]*models.TracerouteRun, error)
+	AddTarget(ctx context.Context, deviceID string) error
+	RemoveTarget(ctx context.Context, deviceID string) error
+	FindTargets(ctx context.Context) ([]string, error)
+}
+
+// LatencyRepository defines the interface for latency monitoring operations
+type LatencyRepository interface {
+	Repository
+	CreateSample(ctx context.Context, sample *models.LatencySample) error
+	FindSamples(ctx context.Context, deviceID string, since time.Time) ([]*models.LatencySample, error)
+	Rollup(ctx context.Context, from, to models.LatencyResolution, bucket time.Duration, before time.Time) (int, error)
+	FindMonitor(ctx context.Context, deviceID string) (*models.LatencyMonitor, error)
+	FindMonitors(ctx context.Context) ([]*models.LatencyMonitor, error)
+	SaveMonitor(ctx context.Context, monitor *models.LatencyMonitor) error
+	DeleteMonitor(ctx context.Context, deviceID string) error
+}
+
+// AvailabilityRepository defines the interface for device status history operations
+type AvailabilityRepository interface {
+	Repository
+	FindIntervals(ctx context.Context, deviceID string, since time.Time) ([]*models.DeviceStatusInterval, error)
+	FindAllIntervals(ctx context.Context, since time.Time) ([]*models.DeviceStatusInterval, error)
+}
+
+// StatusThresholdRepository defines the interface for idle and offline threshold operations
+type StatusThresholdRepository interface {
+	Repository
+	FindAll(ctx context.Context) ([]*models.StatusThreshold, error)
+	Save(ctx context.Context, threshold *models.StatusThreshold) error
+	Delete(ctx context.Context, scope models.ThresholdScope, scopeID string) error
+}
+
+// ScanPolicyRepository defines the interface for scan policy operations
+type ScanPolicyRepository interface {
+	Repository
+	FindAll(ctx context.Context) ([]*models.ScanPolicy, error)
+	Save(ctx context.Context, policy *models.ScanPolicy) error
+	Delete(ctx context.Context, scope models.ScanPolicyScope, scopeID string) error
+}
+
+// ExclusionRepository defines the interface for exclusion list operations
+type ExclusionRepository interface {
+	Repository
+	FindAll(ctx context.Context) ([]*models.Exclusion, error)
+	Create(ctx context.Context, exclusion *models.Exclusion) error
+	Delete(ctx context.Context, id string) error
+}
+
+// InfraServerRepository defines the interface for router and DHCP server operations
+type InfraServerRepository interface {
+	Repository
+	FindAll(ctx context.Context, networkID string) ([]*models.InfraServer, error)
+	FindByID(ctx context.Context, id string) (*models.InfraServer, error)
+	Save(ctx context.Context, server *models.InfraServer) error
+	Delete(ctx context.Context, id string) error
+}
+
+// IPMACBindingRepository defines the interface for IP to MAC address binding history
+type IPMACBindingRepository interface {
+	Repository
+	Record(ctx context.Context, binding *models.IPMACBinding) error
+	FindByIP(ctx context.Context, ip string, since time.Time) ([]*models.IPMACBinding, error)
+	FindByMAC(ctx context.Context, mac string, since time.Time) ([]*models.IPMACBinding, error)
+}
+
+// UserRepository defines the interface for user account operations
+type UserRepository interface {
+	Repository
+	Create(ctx context.Context, user *models.User) error
+	FindByUsername(ctx context.Context, username string) (*models.User, error)
+	FindAll(ctx context.Context) ([]*models.User, error)
+}
+
+// RetentionRepository defines the interface for pruning history tables
+type RetentionRepository interface {
+	Repository
+	PruneEventLogs(ctx context.Context, before time.Time, types, excludeTypes []models.EEventLogType, rollup, dryRun bool) (int64, error)
+	PruneTracerouteRuns(ctx context.Context, before time.Time, dryRun bool) (int64, error)
+	PruneLatencySamples(ctx context.Context, before time.Time, dryRun bool) (int64, error)
+	PruneStatusIntervals(ctx context.Context, before time.Time, dryRun bool) (int64, error)
+	PruneSystemStatus(ctx context.Context, before time.Time, dryRun bool) (int64, error)
+	PruneIPMACBindings(ctx context.Context, before time.Time, dryRun bool) (int64, error)
+	FindEventRollups(ctx context.Context, since time.Time) ([]*models.EventLogRollup, error)
+}
+
+// RepositoryFactory creates repositories for the configured database
 type RepositoryFactory struct {
-	SQLiteDB *sql.DB
-	DBName   string
+	SQLiteDB   *sql.DB
+	PostgresDB *sql.DB
+	DBName     string
 }
 
 // NewRepositoryFactory creates a new repository factory
@@ -89,36 +188,147 @@ func NewRepositoryFactory(sqliteDB *sql.DB, dbName string) *RepositoryFactory {
 	}
 }
 
+// NewPostgresRepositoryFactory creates a repository factory for PostgreSQL
+func NewPostgresRepositoryFactory(postgresDB *sql.DB, dbName string) *RepositoryFactory {
+	return &RepositoryFactory{
+		PostgresDB: postgresDB,
+		DBName:     dbName,
+	}
+}
+
 // NewNetworkRepository creates a new network repository
 func (f *RepositoryFactory) NewNetworkRepository() NetworkRepository {
+	if f.PostgresDB != nil {
+		return NewPostgresNetworkRepository(f.PostgresDB)
+	}
 	return NewSQLiteNetworkRepository(f.SQLiteDB)
 }
 
 // NewDeviceRepository creates a new device repository
 func (f *RepositoryFactory) NewDeviceRepository() DeviceRepository {
+	if f.PostgresDB != nil {
+		return NewPostgresDeviceRepository(f.PostgresDB)
+	}
 	return NewSQLiteDeviceRepository(f.SQLiteDB)
 }
 
 // NewEventLogRepository creates a new event log repository
 func (f *RepositoryFactory) NewEventLogRepository() EventLogRepository {
+	if f.PostgresDB != nil {
+		return NewPostgresEventLogRepository(f.PostgresDB)
+	}
 	return NewSQLiteEventLogRepository(f.SQLiteDB)
 }
 
 // NewSystemStatusRepository creates a new system status repository
 func (f *RepositoryFactory) NewSystemStatusRepository() SystemStatusRepository {
+	if f.PostgresDB != nil {
+		return NewPostgresSystemStatusRepository(f.PostgresDB)
+	}
 	return NewSQLiteSystemStatusRepository(f.SQLiteDB)
 }
 
 // NewGeolocationRepository creates a new geolocation repository
 func (f *RepositoryFactory) NewGeolocationRepository() *GeolocationRepository {
-	return NewGeolocationRepository(f.SQLiteDB)
+	return NewGeolocationRepository(f.DB())
+}
+
+// DB returns the connection repositories are created for
+func (f *RepositoryFactory) DB() *sql.DB {
+	if f.PostgresDB != nil {
+		return f.PostgresDB
+	}
+	return f.SQLiteDB
 }
 
 // NewSettingsRepository creates a new settings repository
 func (f *RepositoryFactory) NewSettingsRepository() SettingsRepository {
+	if f.PostgresDB != nil {
+		return NewPostgresSettingsRepository(f.PostgresDB)
+	}
 	return NewSQLiteSettingsRepository(f.SQLiteDB)
 }
 
+// NewTracerouteRepository creates a new traceroute repository
+func (f *RepositoryFactory) NewTracerouteRepository() TracerouteRepository {
+	if f.PostgresDB != nil {
+		return NewPostgresTracerouteRepository(f.PostgresDB)
+	}
+	return NewSQLiteTracerouteRepository(f.SQLiteDB)
+}
+
+// NewLatencyRepository creates a new latency repository
+func (f *RepositoryFactory) NewLatencyRepository() LatencyRepository {
+	if f.PostgresDB != nil {
+		return NewPostgresLatencyRepository(f.PostgresDB)
+	}
+	return NewSQLiteLatencyRepository(f.SQLiteDB)
+}
+
+// NewAvailabilityRepository creates a new availability repository
+func (f *RepositoryFactory) NewAvailabilityRepository() AvailabilityRepository {
+	if f.PostgresDB != nil {
+		return NewPostgresAvailabilityRepository(f.PostgresDB)
+	}
+	return NewSQLiteAvailabilityRepository(f.SQLiteDB)
+}
+
+// NewStatusThresholdRepository creates a new status threshold repository
+func (f *RepositoryFactory) NewStatusThresholdRepository() StatusThresholdRepository {
+	if f.PostgresDB != nil {
+		return NewPostgresStatusThresholdRepository(f.PostgresDB)
+	}
+	return NewSQLiteStatusThresholdRepository(f.SQLiteDB)
+}
+
+// NewScanPolicyRepository creates a new scan policy repository
+func (f *RepositoryFactory) NewScanPolicyRepository() ScanPolicyRepository {
+	if f.PostgresDB != nil {
+		return NewPostgresScanPolicyRepository(f.PostgresDB)
+	}
+	return NewSQLiteScanPolicyRepository(f.SQLiteDB)
+}
+
+// NewExclusionRepository creates a new exclusion list repository
+func (f *RepositoryFactory) NewExclusionRepository() ExclusionRepository {
+	if f.PostgresDB != nil {
+		return NewPostgresExclusionRepository(f.PostgresDB)
+	}
+	return NewSQLiteExclusionRepository(f.SQLiteDB)
+}
+
+// NewInfraServerRepository creates a new router and DHCP server repository
+func (f *RepositoryFactory) NewInfraServerRepository() InfraServerRepository {
+	if f.PostgresDB != nil {
+		return NewPostgresInfraServerRepository(f.PostgresDB)
+	}
+	return NewSQLiteInfraServerRepository(f.SQLiteDB)
+}
+
+// NewIPMACBindingRepository creates a new IP to MAC address binding repository
+func (f *RepositoryFactory) NewIPMACBindingRepository() IPMACBindingRepository {
+	if f.PostgresDB != nil {
+		return NewPostgresIPMACBindingRepository(f.PostgresDB)
+	}
+	return NewSQLiteIPMACBindingRepository(f.SQLiteDB)
+}
+
+// NewUserRepository creates a new user repository
+func (f *RepositoryFactory) NewUserRepository() UserRepository {
+	if f.PostgresDB != nil {
+		return NewPostgresUserRepository(f.PostgresDB)
+	}
+	return NewSQLiteUserRepository(f.SQLiteDB)
+}
+
+// NewRetentionRepository creates a new retention repository
+func (f *RepositoryFactory) NewRetentionRepository() RetentionRepository {
+	if f.PostgresDB != nil {
+		return NewPostgresRetentionRepository(f.PostgresDB)
+	}
+	return NewSQLiteRetentionRepository(f.SQLiteDB)
+}
+
 // GenerateID generates a unique ID for a record
 func GenerateID() string {
 	return uuid.New().String()
@@ -0,0 +1,206 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reconya-ai/models"
+	"strings"
+	"time"
+)
+
+// SQLiteRetentionRepository implements the RetentionRepository interface for SQLite.
+// Every prune method only counts the matching rows when dryRun is set.
+type SQLiteRetentionRepository struct {
+	db *sql.DB
+	// SQL expression for the local day of an event's created_at
+	dayExpression string
+}
+
+// NewSQLiteRetentionRepository creates a new SQLiteRetentionRepository
+func NewSQLiteRetentionRepository(db *sql.DB) *SQLiteRetentionRepository {
+	// created_at is stored as local time text, so the first ten characters are the day
+	return &SQLiteRetentionRepository{db: db, dayExpression: "substr(created_at, 1, 10)"}
+}
+
+// Close closes the repository (satisfies Repository interface)
+func (r *SQLiteRetentionRepository) Close() error {
+	// SQLite connection is managed by the main DB instance
+	return nil
+}
+
+// PruneEventLogs removes events created before the cutoff. A non-empty types
+// list limits pruning to those types, excludeTypes skips types with their own
+// rule. With rollup set, daily counts of the removed events are kept.
+func (r *SQLiteRetentionRepository) PruneEventLogs(ctx context.Context, before time.Time, types, excludeTypes []models.EEventLogType, rollup, dryRun bool) (int64, error) {
+	where := "created_at < ?"
+	args := []interface{}{before}
+	if len(types) > 0 {
+		where += " AND type IN (" + placeholders(len(types)) + ")"
+		for _, eventType := range types {
+			args = append(args, eventType)
+		}
+	}
+	if len(excludeTypes) > 0 {
+		where += " AND type NOT IN (" + placeholders(len(excludeTypes)) + ")"
+		for _, eventType := range excludeTypes {
+			args = append(args, eventType)
+		}
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if rollup && !dryRun {
+		query := `INSERT INTO event_log_rollups (day, type, device_id, count)
+				  SELECT ` + r.dayExpression + `, type, COALESCE(device_id, ''), COUNT(*)
+				  FROM event_logs WHERE ` + where + `
+				  GROUP BY ` + r.dayExpression + `, type, COALESCE(device_id, '')
+				  ON CONFLICT (day, type, device_id) DO UPDATE SET count = event_log_rollups.count + excluded.count`
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return 0, fmt.Errorf("error rolling up event logs: %w", err)
+		}
+	}
+
+	deleted, err := pruneRows(ctx, tx, "event_logs", where, dryRun, args...)
+	if err != nil {
+		return 0, err
+	}
+	return deleted, commitUnlessDryRun(tx, dryRun)
+}
+
+// PruneTracerouteRuns removes traceroute runs, and their hops, started before the cutoff
+func (r *SQLiteRetentionRepository) PruneTracerouteRuns(ctx context.Context, before time.Time, dryRun bool) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if !dryRun {
+		_, err := tx.ExecContext(ctx, `DELETE FROM traceroute_hops WHERE run_id IN (SELECT id FROM traceroute_runs WHERE started_at < ?)`, before)
+		if err != nil {
+			return 0, fmt.Errorf("error pruning traceroute_hops: %w", err)
+		}
+	}
+
+	deleted, err := pruneRows(ctx, tx, "traceroute_runs", "started_at < ?", dryRun, before)
+	if err != nil {
+		return 0, err
+	}
+	return deleted, commitUnlessDryRun(tx, dryRun)
+}
+
+// PruneLatencySamples removes latency samples of any resolution taken before the cutoff
+func (r *SQLiteRetentionRepository) PruneLatencySamples(ctx context.Context, before time.Time, dryRun bool) (int64, error) {
+	return r.pruneTable(ctx, "latency_samples", "sampled_at < ?", dryRun, before)
+}
+
+// PruneStatusIntervals removes device status intervals that ended before the cutoff
+func (r *SQLiteRetentionRepository) PruneStatusIntervals(ctx context.Context, before time.Time, dryRun bool) (int64, error) {
+	return r.pruneTable(ctx, "device_status_intervals", "ended_at IS NOT NULL AND ended_at < ?", dryRun, before)
+}
+
+// PruneIPMACBindings removes IP to MAC address bindings last seen before the cutoff
+func (r *SQLiteRetentionRepository) PruneIPMACBindings(ctx context.Context, before time.Time, dryRun bool) (int64, error) {
+	return r.pruneTable(ctx, "ip_mac_bindings", "last_seen_at < ?", dryRun, before)
+}
+
+// PruneSystemStatus removes system status snapshots taken before the cutoff,
+// always keeping the latest one
+func (r *SQLiteRetentionRepository) PruneSystemStatus(ctx context.Context, before time.Time, dryRun bool) (int64, error) {
+	where := "created_at < ? AND id <> (SELECT MAX(id) FROM system_status)"
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if !dryRun {
+		_, err := tx.ExecContext(ctx, `DELETE FROM local_devices WHERE system_status_id IN (SELECT id FROM system_status WHERE `+where+`)`, before)
+		if err != nil {
+			return 0, fmt.Errorf("error pruning local_devices: %w", err)
+		}
+	}
+
+	deleted, err := pruneRows(ctx, tx, "system_status", where, dryRun, before)
+	if err != nil {
+		return 0, err
+	}
+	return deleted, commitUnlessDryRun(tx, dryRun)
+}
+
+// FindEventRollups returns the daily event counts since the given day, oldest first
+func (r *SQLiteRetentionRepository) FindEventRollups(ctx context.Context, since time.Time) ([]*models.EventLogRollup, error) {
+	query := `SELECT day, type, device_id, count FROM event_log_rollups
+			  WHERE day >= ? ORDER BY day, type, device_id`
+
+	rows, err := r.db.QueryContext(ctx, query, since.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("error querying event log rollups: %w", err)
+	}
+	defer rows.Close()
+
+	rollups := []*models.EventLogRollup{}
+	for rows.Next() {
+		var rollup models.EventLogRollup
+		var deviceID string
+		if err := rows.Scan(&rollup.Day, &rollup.Type, &deviceID, &rollup.Count); err != nil {
+			return nil, fmt.Errorf("error scanning event log rollup: %w", err)
+		}
+		if deviceID != "" {
+			rollup.DeviceID = &deviceID
+		}
+		rollups = append(rollups, &rollup)
+	}
+	return rollups, rows.Err()
+}
+
+func (r *SQLiteRetentionRepository) pruneTable(ctx context.Context, table, where string, dryRun bool, args ...interface{}) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	deleted, err := pruneRows(ctx, tx, table, where, dryRun, args...)
+	if err != nil {
+		return 0, err
+	}
+	return deleted, commitUnlessDryRun(tx, dryRun)
+}
+
+// pruneRows deletes the rows of table matching where, or counts them on a dry run
+func pruneRows(ctx context.Context, tx *sql.Tx, table, where string, dryRun bool, args ...interface{}) (int64, error) {
+	if dryRun {
+		var count int64
+		if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM `+table+` WHERE `+where, args...).Scan(&count); err != nil {
+			return 0, fmt.Errorf("error counting %s: %w", table, err)
+		}
+		return count, nil
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE `+where, args...)
+	if err != nil {
+		return 0, fmt.Errorf("error pruning %s: %w", table, err)
+	}
+	return result.RowsAffected()
+}
+
+func commitUnlessDryRun(tx *sql.Tx, dryRun bool) error {
+	if dryRun {
+		return nil
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+	return nil
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
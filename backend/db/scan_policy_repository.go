@@ -0,0 +1,130 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reconya-ai/models"
+	"time"
+)
+
+// SQLiteScanPolicyRepository implements the ScanPolicyRepository interface for SQLite
+type SQLiteScanPolicyRepository struct {
+	db *sql.DB
+}
+
+// NewSQLiteScanPolicyRepository creates a new SQLiteScanPolicyRepository
+func NewSQLiteScanPolicyRepository(db *sql.DB) *SQLiteScanPolicyRepository {
+	return &SQLiteScanPolicyRepository{db: db}
+}
+
+// Close closes the repository (satisfies Repository interface)
+func (r *SQLiteScanPolicyRepository) Close() error {
+	// SQLite connection is managed by the main DB instance
+	return nil
+}
+
+// FindAll returns every configured scan policy
+func (r *SQLiteScanPolicyRepository) FindAll(ctx context.Context) ([]*models.ScanPolicy, error) {
+	query := `SELECT scope, scope_id, exclude, ping_only, ports, top_ports, udp, os_detection,
+			  screenshots, max_rate, updated_at
+			  FROM scan_policies ORDER BY scope, scope_id`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("error querying scan policies: %w", err)
+	}
+	defer rows.Close()
+
+	var policies []*models.ScanPolicy
+	for rows.Next() {
+		var policy models.ScanPolicy
+		var exclude, pingOnly, udp, osDetection, screenshots sql.NullBool
+		var ports sql.NullString
+		var topPorts, maxRate sql.NullInt64
+
+		err := rows.Scan(&policy.Scope, &policy.ScopeID, &exclude, &pingOnly, &ports, &topPorts, &udp,
+			&osDetection, &screenshots, &maxRate, &policy.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning scan policy: %w", err)
+		}
+
+		policy.Exclude = boolPtr(exclude)
+		policy.PingOnly = boolPtr(pingOnly)
+		if ports.Valid {
+			policy.Ports = &ports.String
+		}
+		policy.TopPorts = intPtr(topPorts)
+		policy.UDP = boolPtr(udp)
+		policy.OSDetection = boolPtr(osDetection)
+		policy.Screenshots = boolPtr(screenshots)
+		policy.MaxRate = intPtr(maxRate)
+		policies = append(policies, &policy)
+	}
+
+	return policies, rows.Err()
+}
+
+// Save creates or replaces the scan policy of a scope
+func (r *SQLiteScanPolicyRepository) Save(ctx context.Context, policy *models.ScanPolicy) error {
+	policy.UpdatedAt = time.Now()
+
+	query := `
+		INSERT INTO scan_policies (scope, scope_id, exclude, ping_only, ports, top_ports, udp, os_detection,
+			screenshots, max_rate, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(scope, scope_id) DO UPDATE SET
+			exclude = excluded.exclude,
+			ping_only = excluded.ping_only,
+			ports = excluded.ports,
+			top_ports = excluded.top_ports,
+			udp = excluded.udp,
+			os_detection = excluded.os_detection,
+			screenshots = excluded.screenshots,
+			max_rate = excluded.max_rate,
+			updated_at = excluded.updated_at`
+
+	_, err := r.db.ExecContext(ctx, query,
+		policy.Scope, policy.ScopeID,
+		nullableBool(policy.Exclude), nullableBool(policy.PingOnly),
+		nullableString(policy.Ports), nullableInt(policy.TopPorts),
+		nullableBool(policy.UDP), nullableBool(policy.OSDetection), nullableBool(policy.Screenshots),
+		nullableInt(policy.MaxRate), policy.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("error saving scan policy: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the scan policy of a scope
+func (r *SQLiteScanPolicyRepository) Delete(ctx context.Context, scope models.ScanPolicyScope, scopeID string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM scan_policies WHERE scope = ? AND scope_id = ?`, scope, scopeID)
+	if err != nil {
+		return fmt.Errorf("error deleting scan policy: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error checking deleted scan policy: %w", err)
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func nullableBool(b *bool) sql.NullBool {
+	if b == nil {
+		return sql.NullBool{}
+	}
+	return sql.NullBool{Bool: *b, Valid: true}
+}
+
+func boolPtr(b sql.NullBool) *bool {
+	if !b.Valid {
+		return nil
+	}
+	v := b.Bool
+	return &v
+}
@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"reconya-ai/models"
+	"strings"
 	"time"
 )
 
@@ -27,15 +28,16 @@ func (r *SQLiteNetworkRepository) Close() error {
 
 // FindByID finds a network by ID
 func (r *SQLiteNetworkRepository) FindByID(ctx context.Context, id string) (*models.Network, error) {
-	query := `SELECT id, name, cidr, description, status, last_scanned_at, device_count, created_at, updated_at FROM networks WHERE id = ?`
+	query := `SELECT id, name, cidr, ipv6_prefix, address_family, description, status, last_scanned_at, device_count, rate_limit, scan_windows, created_at, updated_at FROM networks WHERE id = ?`
 	row := r.db.QueryRowContext(ctx, query, id)
 
 	var network models.Network
-	var name, description, status sql.NullString
+	var name, ipv6Prefix, addressFamily, description, status sql.NullString
 	var lastScannedAt, createdAt, updatedAt sql.NullTime
-	var deviceCount sql.NullInt64
+	var deviceCount, rateLimit sql.NullInt64
+	var scanWindows sql.NullString
 
-	err := row.Scan(&network.ID, &name, &network.CIDR, &description, &status, &lastScannedAt, &deviceCount, &createdAt, &updatedAt)
+	err := row.Scan(&network.ID, &name, &network.CIDR, &ipv6Prefix, &addressFamily, &description, &status, &lastScannedAt, &deviceCount, &rateLimit, &scanWindows, &createdAt, &updatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, ErrNotFound
@@ -46,6 +48,8 @@ func (r *SQLiteNetworkRepository) FindByID(ctx context.Context, id string) (*mod
 	if name.Valid {
 		network.Name = name.String
 	}
+	setNetworkAddressing(&network, ipv6Prefix, addressFamily)
+	setNetworkScanLimits(&network, rateLimit, scanWindows)
 	if description.Valid {
 		network.Description = description.String
 	}
@@ -70,15 +74,16 @@ func (r *SQLiteNetworkRepository) FindByID(ctx context.Context, id string) (*mod
 
 // FindByCIDR finds a network by CIDR
 func (r *SQLiteNetworkRepository) FindByCIDR(ctx context.Context, cidr string) (*models.Network, error) {
-	query := `SELECT id, name, cidr, description, status, last_scanned_at, device_count, created_at, updated_at FROM networks WHERE cidr = ?`
+	query := `SELECT id, name, cidr, ipv6_prefix, address_family, description, status, last_scanned_at, device_count, rate_limit, scan_windows, created_at, updated_at FROM networks WHERE cidr = ?`
 	row := r.db.QueryRowContext(ctx, query, cidr)
 
 	var network models.Network
-	var name, description, status sql.NullString
+	var name, ipv6Prefix, addressFamily, description, status sql.NullString
 	var lastScannedAt, createdAt, updatedAt sql.NullTime
-	var deviceCount sql.NullInt64
+	var deviceCount, rateLimit sql.NullInt64
+	var scanWindows sql.NullString
 
-	err := row.Scan(&network.ID, &name, &network.CIDR, &description, &status, &lastScannedAt, &deviceCount, &createdAt, &updatedAt)
+	err := row.Scan(&network.ID, &name, &network.CIDR, &ipv6Prefix, &addressFamily, &description, &status, &lastScannedAt, &deviceCount, &rateLimit, &scanWindows, &createdAt, &updatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, ErrNotFound
@@ -89,6 +94,8 @@ func (r *SQLiteNetworkRepository) FindByCIDR(ctx context.Context, cidr string) (
 	if name.Valid {
 		network.Name = name.String
 	}
+	setNetworkAddressing(&network, ipv6Prefix, addressFamily)
+	setNetworkScanLimits(&network, rateLimit, scanWindows)
 	if description.Valid {
 		network.Description = description.String
 	}
@@ -116,12 +123,16 @@ func (r *SQLiteNetworkRepository) FindAll(ctx context.Context) ([]*models.Networ
 	query := `SELECT id, 
 		COALESCE(name, '') as name, 
 		cidr, 
+		ipv6_prefix,
+		address_family,
 		COALESCE(description, '') as description, 
 		COALESCE(status, 'active') as status, 
 		last_scanned_at, 
 		COALESCE(device_count, 0) as device_count, 
-		COALESCE(created_at, datetime('now')) as created_at, 
-		COALESCE(updated_at, datetime('now')) as updated_at 
+		rate_limit,
+		scan_windows,
+		created_at, 
+		updated_at 
 	FROM networks ORDER BY created_at DESC`
 	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
@@ -132,39 +143,29 @@ func (r *SQLiteNetworkRepository) FindAll(ctx context.Context) ([]*models.Networ
 	var networks []*models.Network
 	for rows.Next() {
 		var network models.Network
-		var lastScannedAt sql.NullTime
-		var createdAtStr, updatedAtStr string
+		var ipv6Prefix, addressFamily, scanWindows sql.NullString
+		var lastScannedAt, createdAt, updatedAt sql.NullTime
+		var rateLimit sql.NullInt64
 
-		err := rows.Scan(&network.ID, &network.Name, &network.CIDR, &network.Description, &network.Status, &lastScannedAt, &network.DeviceCount, &createdAtStr, &updatedAtStr)
+		err := rows.Scan(&network.ID, &network.Name, &network.CIDR, &ipv6Prefix, &addressFamily, &network.Description, &network.Status, &lastScannedAt, &network.DeviceCount, &rateLimit, &scanWindows, &createdAt, &updatedAt)
 		if err != nil {
 			return nil, fmt.Errorf("error scanning network: %w", err)
 		}
+		setNetworkAddressing(&network, ipv6Prefix, addressFamily)
+		setNetworkScanLimits(&network, rateLimit, scanWindows)
 
 		if lastScannedAt.Valid {
 			network.LastScannedAt = &lastScannedAt.Time
 		}
 
-		// Parse datetime strings
-		if createdAtStr != "" {
-			if t, err := time.Parse("2006-01-02 15:04:05", createdAtStr); err == nil {
-				network.CreatedAt = t
-			} else {
-				// If parsing fails, use current time
-				network.CreatedAt = time.Now()
-			}
-		} else {
-			network.CreatedAt = time.Now()
+		// Networks from older releases may lack timestamps
+		network.CreatedAt = time.Now()
+		if createdAt.Valid {
+			network.CreatedAt = createdAt.Time
 		}
-
-		if updatedAtStr != "" {
-			if t, err := time.Parse("2006-01-02 15:04:05", updatedAtStr); err == nil {
-				network.UpdatedAt = t
-			} else {
-				// If parsing fails, use current time
-				network.UpdatedAt = time.Now()
-			}
-		} else {
-			network.UpdatedAt = time.Now()
+		network.UpdatedAt = time.Now()
+		if updatedAt.Valid {
+			network.UpdatedAt = updatedAt.Time
 		}
 
 		networks = append(networks, &network)
@@ -185,14 +186,14 @@ func (r *SQLiteNetworkRepository) CreateOrUpdate(ctx context.Context, network *m
 	}
 
 	if err == ErrNotFound {
-		query := `INSERT INTO networks (id, name, cidr, description, status, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)`
-		_, err := r.db.ExecContext(ctx, query, network.ID, network.Name, network.CIDR, network.Description, network.Status, network.CreatedAt, network.UpdatedAt)
+		query := `INSERT INTO networks (id, name, cidr, ipv6_prefix, address_family, description, status, rate_limit, scan_windows, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+		_, err := r.db.ExecContext(ctx, query, network.ID, network.Name, network.CIDR, nullableString(network.IPv6Prefix), networkAddressFamily(network), network.Description, network.Status, nullableInt(network.RateLimit), network.ScanWindows, network.CreatedAt, network.UpdatedAt)
 		if err != nil {
 			return nil, fmt.Errorf("error inserting network: %w", err)
 		}
 	} else {
-		query := `UPDATE networks SET name = ?, cidr = ?, description = ?, status = ?, updated_at = ? WHERE id = ?`
-		_, err := r.db.ExecContext(ctx, query, network.Name, network.CIDR, network.Description, network.Status, network.UpdatedAt, network.ID)
+		query := `UPDATE networks SET name = ?, cidr = ?, ipv6_prefix = ?, address_family = ?, description = ?, status = ?, rate_limit = ?, scan_windows = ?, updated_at = ? WHERE id = ?`
+		_, err := r.db.ExecContext(ctx, query, network.Name, network.CIDR, nullableString(network.IPv6Prefix), networkAddressFamily(network), network.Description, network.Status, nullableInt(network.RateLimit), network.ScanWindows, network.UpdatedAt, network.ID)
 		if err != nil {
 			return nil, fmt.Errorf("error updating network: %w", err)
 		}
@@ -201,6 +202,42 @@ func (r *SQLiteNetworkRepository) CreateOrUpdate(ctx context.Context, network *m
 	return network, nil
 }
 
+// setNetworkAddressing reads the IPv6 columns of a network; networks saved
+// before they were written are IPv4 networks
+func setNetworkAddressing(network *models.Network, ipv6Prefix, addressFamily sql.NullString) {
+	if ipv6Prefix.Valid && ipv6Prefix.String != "" {
+		network.IPv6Prefix = &ipv6Prefix.String
+	}
+	network.AddressFamily = models.AddressFamilyIPv4
+	if addressFamily.Valid && addressFamily.String != "" {
+		network.AddressFamily = models.AddressFamily(addressFamily.String)
+	}
+}
+
+// setNetworkScanLimits reads the rate limit and scan windows of a network
+func setNetworkScanLimits(network *models.Network, rateLimit sql.NullInt64, scanWindows sql.NullString) {
+	network.RateLimit = intPtr(rateLimit)
+	if scanWindows.Valid {
+		network.ScanWindows = scanWindows.String
+	}
+}
+
+func networkAddressFamily(network *models.Network) string {
+	if network.AddressFamily == "" {
+		return string(models.AddressFamilyIPv4)
+	}
+	return string(network.AddressFamily)
+}
+
+// UpdateLastScannedAt records when a sweep of the network started
+func (r *SQLiteNetworkRepository) UpdateLastScannedAt(ctx context.Context, id string, scannedAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE networks SET last_scanned_at = ? WHERE id = ?`, scannedAt, id)
+	if err != nil {
+		return fmt.Errorf("error updating network last scanned time: %w", err)
+	}
+	return nil
+}
+
 // Delete deletes a network by ID
 func (r *SQLiteNetworkRepository) Delete(ctx context.Context, id string) error {
 	query := `DELETE FROM networks WHERE id = ?`
@@ -242,20 +279,14 @@ func (r *SQLiteDeviceRepository) Close() error {
 
 // FindByID finds a device by ID
 func (r *SQLiteDeviceRepository) FindByID(ctx context.Context, id string) (*models.Device, error) {
-	tx, err := r.db.BeginTx(ctx, nil)
-	if err != nil {
-		return nil, fmt.Errorf("error beginning transaction: %w", err)
-	}
-	defer tx.Rollback()
-
 	query := `
 	SELECT id, name, comment, ipv4, ipv6_link_local, ipv6_unique_local, ipv6_global, ipv6_addresses,
 	       mac, vendor, device_type, os_name, os_version, os_family, os_confidence,
 	       status, network_id, hostname, created_at, updated_at, last_seen_online_at, 
-	       port_scan_started_at, port_scan_ended_at, web_scan_ended_at
+	       port_scan_started_at, port_scan_ended_at, web_scan_ended_at, tags
 	FROM devices WHERE id = ?`
 
-	row := tx.QueryRowContext(ctx, query, id)
+	row := r.db.QueryRowContext(ctx, query, id)
 
 	var device models.Device
 	// Initialize slices to prevent any nil slice issues
@@ -264,19 +295,20 @@ func (r *SQLiteDeviceRepository) FindByID(ctx context.Context, id string) (*mode
 	device.IPv6Addresses = make([]string, 0)
 	var mac, vendor, hostname, comment sql.NullString
 	var ipv6LinkLocal, ipv6UniqueLocal, ipv6Global, ipv6Addresses sql.NullString
+	var tags sql.NullString
 	var deviceType sql.NullString
 	var osName, osVersion, osFamily sql.NullString
 	var osConfidence sql.NullInt64
 	var networkID sql.NullString
 	var lastSeenOnlineAt, portScanStartedAt, portScanEndedAt, webScanEndedAt sql.NullTime
 
-	err = row.Scan(
+	err := row.Scan(
 		&device.ID, &device.Name, &comment, &device.IPv4,
 		&ipv6LinkLocal, &ipv6UniqueLocal, &ipv6Global, &ipv6Addresses,
 		&mac, &vendor, &deviceType,
 		&osName, &osVersion, &osFamily, &osConfidence,
 		&device.Status, &networkID, &hostname, &device.CreatedAt, &device.UpdatedAt,
-		&lastSeenOnlineAt, &portScanStartedAt, &portScanEndedAt, &webScanEndedAt,
+		&lastSeenOnlineAt, &portScanStartedAt, &portScanEndedAt, &webScanEndedAt, &tags,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -292,6 +324,7 @@ func (r *SQLiteDeviceRepository) FindByID(ctx context.Context, id string) (*mode
 
 	if mac.Valid {
 		device.MAC = &mac.String
+		device.PrivateMAC = models.IsPrivateMAC(mac.String)
 	}
 	if vendor.Valid {
 		device.Vendor = &vendor.String
@@ -317,6 +350,12 @@ func (r *SQLiteDeviceRepository) FindByID(ctx context.Context, id string) (*mode
 			device.IPv6Addresses = addresses
 		}
 	}
+	if tags.Valid && tags.String != "" {
+		var deviceTags []string
+		if err := json.Unmarshal([]byte(tags.String), &deviceTags); err == nil {
+			device.Tags = deviceTags
+		}
+	}
 	if deviceType.Valid {
 		device.DeviceType = models.DeviceType(deviceType.String)
 	}
@@ -357,7 +396,7 @@ func (r *SQLiteDeviceRepository) FindByID(ctx context.Context, id string) (*mode
 	SELECT number, protocol, state, service
 	FROM ports WHERE device_id = ?`
 
-	portRows, err := tx.QueryContext(ctx, portsQuery, device.ID)
+	portRows, err := r.db.QueryContext(ctx, portsQuery, device.ID)
 	if err != nil {
 		return nil, fmt.Errorf("error querying device ports: %w", err)
 	}
@@ -381,7 +420,7 @@ func (r *SQLiteDeviceRepository) FindByID(ctx context.Context, id string) (*mode
 	SELECT url, title, server, status_code, content_type, size, screenshot, port, protocol, scanned_at
 	FROM web_services WHERE device_id = ?`
 
-	webServiceRows, err := tx.QueryContext(ctx, webServicesQuery, device.ID)
+	webServiceRows, err := r.db.QueryContext(ctx, webServicesQuery, device.ID)
 	if err != nil {
 		return nil, fmt.Errorf("error querying device web services: %w", err)
 	}
@@ -414,15 +453,16 @@ func (r *SQLiteDeviceRepository) FindByID(ctx context.Context, id string) (*mode
 		device.WebServices = append(device.WebServices, ws)
 	}
 
-	if err = tx.Commit(); err != nil {
-		return nil, fmt.Errorf("error committing transaction: %w", err)
-	}
-
 	return &device, nil
 }
 
 // FindByIP finds a device by IP address
 func (r *SQLiteDeviceRepository) FindByIP(ctx context.Context, ip string) (*models.Device, error) {
+	// IPv6-only devices share the empty IPv4 address
+	if ip == "" {
+		return nil, ErrNotFound
+	}
+
 	query := `SELECT id FROM devices WHERE ipv4 = ?`
 	row := r.db.QueryRowContext(ctx, query, ip)
 
@@ -472,20 +512,152 @@ func (r *SQLiteDeviceRepository) CreateOrUpdate(ctx context.Context, device *mod
 	}
 	defer tx.Rollback()
 
+	if err = saveDevice(ctx, tx, device, time.Now()); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return device, nil
+}
+
+// SaveSweepBatch stores the devices found by a sweep in a single
+// transaction, so a sweep pays for one commit instead of one per device.
+// Either all devices are saved or none are. New devices are created in full;
+// known ones only get the columns a sweep owns, see saveSighting.
+func (r *SQLiteDeviceRepository) SaveSweepBatch(ctx context.Context, devices []*models.Device) error {
+	if len(devices) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmts := newTxStatements(tx)
+	defer stmts.Close()
+
 	now := time.Now()
+	for _, device := range devices {
+		if err = saveSighting(ctx, stmts, device, now); err != nil {
+			return fmt.Errorf("error saving device %s: %w", device.IPv4, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return nil
+}
+
+// saveSighting stores a device found by a sweep inside a transaction. A
+// known device only gets what a sweep observes: its addresses, vendor,
+// hostname, network and status. Its name, comment, tags, OS, ports and scan
+// times are left alone, so that a rename or port scan saved after the sweep
+// loaded the device is not overwritten with the stale copy.
+func saveSighting(ctx context.Context, tx queryExecer, device *models.Device, now time.Time) error {
+	existingID, exists, err := findDeviceID(ctx, tx, device)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return saveDevice(ctx, tx, device, now)
+	}
+
+	device.ID = existingID
 	device.UpdatedAt = now
+	device.PrivateMAC = device.MAC != nil && models.IsPrivateMAC(*device.MAC)
 
-	// Convert strings to *string
-	networkIDPtr := stringToPtr(device.NetworkID)
+	var ipv6AddressesJSON sql.NullString
+	if len(device.IPv6Addresses) > 0 {
+		if jsonBytes, err := json.Marshal(device.IPv6Addresses); err == nil {
+			ipv6AddressesJSON = sql.NullString{String: string(jsonBytes), Valid: true}
+		}
+	}
+
+	// Empty values mean the sweep didn't see the field, which keeps what is
+	// stored; a device type is only filled in when there is none yet. All
+	// IPv6 addresses are replaced by those of a device with IPv6 addresses,
+	// which come from a discovery of the whole prefix, so that addresses no
+	// longer seen expire.
+	hasIPv6 := device.HasIPv6()
+	query := `
+	UPDATE devices SET ipv4 = COALESCE(NULLIF(?, ''), ipv4), mac = COALESCE(?, mac), vendor = COALESCE(?, vendor),
+		device_type = COALESCE(NULLIF(device_type, ''), ?),
+		status = ?, network_id = COALESCE(?, network_id), hostname = COALESCE(?, hostname),
+		updated_at = ?, last_seen_online_at = COALESCE(?, last_seen_online_at),
+		ipv6_link_local = CASE WHEN ? THEN ? ELSE ipv6_link_local END,
+		ipv6_unique_local = CASE WHEN ? THEN ? ELSE ipv6_unique_local END,
+		ipv6_global = CASE WHEN ? THEN ? ELSE ipv6_global END,
+		ipv6_addresses = CASE WHEN ? THEN ? ELSE ipv6_addresses END
+	WHERE id = ?`
+
+	_, err = tx.ExecContext(ctx, query,
+		device.IPv4, nullableString(device.MAC), nullableString(device.Vendor),
+		nullableString((*string)(&device.DeviceType)),
+		device.Status, nullableString(&device.NetworkID), nullableString(device.Hostname),
+		device.UpdatedAt, nullableTime(device.LastSeenOnlineAt),
+		hasIPv6, nullableString(device.IPv6LinkLocal),
+		hasIPv6, nullableString(device.IPv6UniqueLocal),
+		hasIPv6, nullableString(device.IPv6Global),
+		hasIPv6, ipv6AddressesJSON,
+		device.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("error updating device: %w", err)
+	}
 
-	// Check if a device with this IP address already exists
+	return recordStatusChange(ctx, tx, device.ID, device.Status, now)
+}
+
+// findDeviceID returns the ID of the stored device with the IP address of
+// device, or else with its ID, which a device matched by MAC address keeps
+// when its IP address changes. IPv6-only devices have no IP address and are
+// matched by ID.
+func findDeviceID(ctx context.Context, tx queryExecer, device *models.Device) (string, bool, error) {
 	var existingID string
-	err = tx.QueryRowContext(ctx, "SELECT id FROM devices WHERE ipv4 = ?", device.IPv4).Scan(&existingID)
+	err := sql.ErrNoRows
+	if device.IPv4 != "" {
+		err = tx.QueryRowContext(ctx, "SELECT id FROM devices WHERE ipv4 = ?", device.IPv4).Scan(&existingID)
+	}
 	if err != nil && err != sql.ErrNoRows {
-		return nil, fmt.Errorf("error checking if device with IP exists: %w", err)
+		return "", false, fmt.Errorf("error checking if device with IP exists: %w", err)
+	}
+	if err == nil {
+		return existingID, true, nil
 	}
 
-	deviceExists := err != sql.ErrNoRows
+	if device.ID == "" {
+		return "", false, nil
+	}
+	err = tx.QueryRowContext(ctx, "SELECT id FROM devices WHERE id = ?", device.ID).Scan(&existingID)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("error checking if device exists: %w", err)
+	}
+	return existingID, true, nil
+}
+
+// saveDevice creates or updates a device with its ports and web services
+// inside a transaction
+func saveDevice(ctx context.Context, tx queryExecer, device *models.Device, now time.Time) error {
+	device.UpdatedAt = now
+	device.PrivateMAC = device.MAC != nil && models.IsPrivateMAC(*device.MAC)
+
+	// Convert strings to *string
+	networkIDPtr := stringToPtr(device.NetworkID)
+
+	existingID, deviceExists, err := findDeviceID(ctx, tx, device)
+	if err != nil {
+		return err
+	}
 
 	if deviceExists {
 		// Update existing device with the same IP address
@@ -501,7 +673,7 @@ func (r *SQLiteDeviceRepository) CreateOrUpdate(ctx context.Context, device *mod
 			"SELECT created_at, device_type, os_name, os_version, os_family, os_confidence FROM devices WHERE id = ?",
 			device.ID).Scan(&createdAt, &existingDeviceType, &existingOsName, &existingOsVersion, &existingOsFamily, &existingOsConfidence)
 		if err != nil {
-			return nil, fmt.Errorf("error getting existing device data: %w", err)
+			return fmt.Errorf("error getting existing device data: %w", err)
 		}
 		device.CreatedAt = createdAt
 
@@ -528,11 +700,11 @@ func (r *SQLiteDeviceRepository) CreateOrUpdate(ctx context.Context, device *mod
 		}
 
 		query := `
-		UPDATE devices SET name = ?, comment = ?, mac = ?, vendor = ?, device_type = ?, 
+		UPDATE devices SET name = ?, comment = ?, ipv4 = ?, mac = ?, vendor = ?, device_type = ?, 
 			os_name = ?, os_version = ?, os_family = ?, os_confidence = ?,
 			status = ?, network_id = ?, hostname = ?, updated_at = ?, last_seen_online_at = ?, 
 			port_scan_started_at = ?, port_scan_ended_at = ?, web_scan_ended_at = ?,
-			ipv6_link_local = ?, ipv6_unique_local = ?, ipv6_global = ?, ipv6_addresses = ?
+			ipv6_link_local = ?, ipv6_unique_local = ?, ipv6_global = ?, ipv6_addresses = ?, tags = ?
 		WHERE id = ?`
 
 		// Prepare OS fields
@@ -562,23 +734,23 @@ func (r *SQLiteDeviceRepository) CreateOrUpdate(ctx context.Context, device *mod
 		}
 
 		_, err = tx.ExecContext(ctx, query,
-			device.Name, nullableString(device.Comment), nullableString(device.MAC), nullableString(device.Vendor),
+			device.Name, nullableString(device.Comment), device.IPv4, nullableString(device.MAC), nullableString(device.Vendor),
 			string(device.DeviceType), osName, osVersion, osFamily, osConfidence,
 			device.Status, networkIDPtr, nullableString(device.Hostname),
 			device.UpdatedAt, nullableTime(device.LastSeenOnlineAt),
 			nullableTime(device.PortScanStartedAt), nullableTime(device.PortScanEndedAt), nullableTime(device.WebScanEndedAt),
 			nullableString(device.IPv6LinkLocal), nullableString(device.IPv6UniqueLocal), nullableString(device.IPv6Global), ipv6AddressesJSON,
-			device.ID,
+			tagsJSON(device.Tags), device.ID,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("error updating device: %w", err)
+			return fmt.Errorf("error updating device: %w", err)
 		}
 
 		// Only delete existing ports if new ports are being provided
 		if len(device.Ports) > 0 {
 			_, err = tx.ExecContext(ctx, "DELETE FROM ports WHERE device_id = ?", device.ID)
 			if err != nil {
-				return nil, fmt.Errorf("error deleting device ports: %w", err)
+				return fmt.Errorf("error deleting device ports: %w", err)
 			}
 		}
 
@@ -586,7 +758,7 @@ func (r *SQLiteDeviceRepository) CreateOrUpdate(ctx context.Context, device *mod
 		if len(device.WebServices) > 0 {
 			_, err = tx.ExecContext(ctx, "DELETE FROM web_services WHERE device_id = ?", device.ID)
 			if err != nil {
-				return nil, fmt.Errorf("error deleting device web services: %w", err)
+				return fmt.Errorf("error deleting device web services: %w", err)
 			}
 		}
 	} else {
@@ -601,8 +773,8 @@ func (r *SQLiteDeviceRepository) CreateOrUpdate(ctx context.Context, device *mod
 			os_name, os_version, os_family, os_confidence,
 			status, network_id, hostname, created_at, updated_at, last_seen_online_at, 
 			port_scan_started_at, port_scan_ended_at, web_scan_ended_at,
-			ipv6_link_local, ipv6_unique_local, ipv6_global, ipv6_addresses)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+			ipv6_link_local, ipv6_unique_local, ipv6_global, ipv6_addresses, tags)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
 		// Prepare OS fields for insert
 		var osName, osVersion, osFamily sql.NullString
@@ -637,18 +809,23 @@ func (r *SQLiteDeviceRepository) CreateOrUpdate(ctx context.Context, device *mod
 			device.CreatedAt, device.UpdatedAt, nullableTime(device.LastSeenOnlineAt),
 			nullableTime(device.PortScanStartedAt), nullableTime(device.PortScanEndedAt), nullableTime(device.WebScanEndedAt),
 			nullableString(device.IPv6LinkLocal), nullableString(device.IPv6UniqueLocal), nullableString(device.IPv6Global), ipv6AddressesJSON,
+			tagsJSON(device.Tags),
 		)
 		if err != nil {
-			return nil, fmt.Errorf("error inserting device: %w", err)
+			return fmt.Errorf("error inserting device: %w", err)
 		}
 	}
 
+	if err = recordStatusChange(ctx, tx, device.ID, device.Status, now); err != nil {
+		return err
+	}
+
 	if len(device.Ports) > 0 {
 		portQuery := `INSERT INTO ports (device_id, number, protocol, state, service) VALUES (?, ?, ?, ?, ?)`
 		for _, port := range device.Ports {
 			_, err = tx.ExecContext(ctx, portQuery, device.ID, port.Number, port.Protocol, port.State, port.Service)
 			if err != nil {
-				return nil, fmt.Errorf("error inserting port: %w", err)
+				return fmt.Errorf("error inserting port: %w", err)
 			}
 		}
 	}
@@ -659,51 +836,116 @@ func (r *SQLiteDeviceRepository) CreateOrUpdate(ctx context.Context, device *mod
 		for _, ws := range device.WebServices {
 			_, err = tx.ExecContext(ctx, webServiceQuery, device.ID, ws.URL, nullableString(&ws.Title), nullableString(&ws.Server), ws.StatusCode, nullableString(&ws.ContentType), ws.Size, nullableString(&ws.Screenshot), ws.Port, ws.Protocol, ws.ScannedAt)
 			if err != nil {
-				return nil, fmt.Errorf("error inserting web service: %w", err)
+				return fmt.Errorf("error inserting web service: %w", err)
 			}
 		}
 	}
 
+	return nil
+}
+
+// FindActivity returns the status inputs of devices that are online or idle
+func (r *SQLiteDeviceRepository) FindActivity(ctx context.Context) ([]*models.DeviceActivity, error) {
+	query := `
+	SELECT id, status, device_type, network_id, last_seen_online_at
+	FROM devices WHERE status IN (?, ?)`
+
+	rows, err := r.db.QueryContext(ctx, query, models.DeviceStatusOnline, models.DeviceStatusIdle)
+	if err != nil {
+		return nil, fmt.Errorf("error querying device activity: %w", err)
+	}
+	defer rows.Close()
+
+	var activity []*models.DeviceActivity
+	for rows.Next() {
+		var a models.DeviceActivity
+		var deviceType, networkID sql.NullString
+		var lastSeenOnlineAt sql.NullTime
+
+		if err := rows.Scan(&a.DeviceID, &a.Status, &deviceType, &networkID, &lastSeenOnlineAt); err != nil {
+			return nil, fmt.Errorf("error scanning device activity: %w", err)
+		}
+		a.DeviceType = models.DeviceType(deviceType.String)
+		a.NetworkID = networkID.String
+		if lastSeenOnlineAt.Valid {
+			a.LastSeenOnlineAt = &lastSeenOnlineAt.Time
+		}
+		activity = append(activity, &a)
+	}
+
+	return activity, rows.Err()
+}
+
+// ApplyStatusTransitions changes device statuses and records the status
+// history. A transition is skipped if the device left its From status in the
+// meantime, e.g. because it was seen again. The applied transitions are returned.
+func (r *SQLiteDeviceRepository) ApplyStatusTransitions(ctx context.Context, transitions []*models.DeviceStatusTransition) ([]*models.DeviceStatusTransition, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var applied []*models.DeviceStatusTransition
+	for _, transition := range transitions {
+		result, err := tx.ExecContext(ctx, "UPDATE devices SET status = ?, updated_at = ? WHERE id = ? AND status = ?",
+			transition.To, transition.At, transition.DeviceID, transition.From)
+		if err != nil {
+			return nil, fmt.Errorf("error updating device status: %w", err)
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return nil, fmt.Errorf("error checking device status update: %w", err)
+		}
+		if affected == 0 {
+			continue
+		}
+
+		if err = recordStatusChange(ctx, tx, transition.DeviceID, transition.To, transition.At); err != nil {
+			return nil, err
+		}
+		applied = append(applied, transition)
+	}
+
 	if err = tx.Commit(); err != nil {
 		return nil, fmt.Errorf("error committing transaction: %w", err)
 	}
 
-	return device, nil
+	return applied, nil
 }
 
-// UpdateDeviceStatuses updates device statuses based on last seen time
-func (r *SQLiteDeviceRepository) UpdateDeviceStatuses(ctx context.Context, timeout time.Duration) error {
-	now := time.Now()
-	offlineThreshold := now.Add(-timeout)
-
-	query := `
-	UPDATE devices 
-	SET status = ?, updated_at = ?
-	WHERE status IN (?, ?) AND last_seen_online_at < ?`
+// recordStatusChange closes the open status interval of a device and opens a
+// new one if the status differs from it
+func recordStatusChange(ctx context.Context, tx queryExecer, deviceID string, status models.DeviceStatus, at time.Time) error {
+	if status == "" {
+		return nil
+	}
 
-	_, err := r.db.ExecContext(ctx, query,
-		models.DeviceStatusOffline, now,
-		models.DeviceStatusOnline, models.DeviceStatusIdle,
-		offlineThreshold,
-	)
-	if err != nil {
-		return fmt.Errorf("error updating device statuses: %w", err)
+	var openID int64
+	var openStatus string
+	err := tx.QueryRowContext(ctx,
+		"SELECT id, status FROM device_status_intervals WHERE device_id = ? AND ended_at IS NULL ORDER BY started_at DESC LIMIT 1",
+		deviceID).Scan(&openID, &openStatus)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("error querying open status interval: %w", err)
 	}
 
-	// Set devices to idle after 1 minute of inactivity
-	idleThreshold := now.Add(-1 * time.Minute)
-	query = `
-	UPDATE devices 
-	SET status = ?, updated_at = ?
-	WHERE status = ? AND last_seen_online_at < ?`
+	if err == nil {
+		if models.DeviceStatus(openStatus) == status {
+			return nil
+		}
+		_, err = tx.ExecContext(ctx, "UPDATE device_status_intervals SET ended_at = ? WHERE id = ?", at, openID)
+		if err != nil {
+			return fmt.Errorf("error closing status interval: %w", err)
+		}
+	}
 
-	_, err = r.db.ExecContext(ctx, query,
-		models.DeviceStatusIdle, now,
-		models.DeviceStatusOnline,
-		idleThreshold,
-	)
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO device_status_intervals (device_id, status, started_at) VALUES (?, ?, ?)",
+		deviceID, status, at)
 	if err != nil {
-		return fmt.Errorf("error updating device idle statuses: %w", err)
+		return fmt.Errorf("error opening status interval: %w", err)
 	}
 
 	return nil
@@ -727,6 +969,26 @@ func (r *SQLiteDeviceRepository) DeleteByID(ctx context.Context, id string) erro
 		return fmt.Errorf("error deleting device web services: %w", err)
 	}
 
+	_, err = tx.ExecContext(ctx, "DELETE FROM traceroute_targets WHERE device_id = ?", id)
+	if err != nil {
+		return fmt.Errorf("error deleting device traceroute target: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM latency_monitors WHERE device_id = ?", id)
+	if err != nil {
+		return fmt.Errorf("error deleting device latency monitor: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM latency_samples WHERE device_id = ?", id)
+	if err != nil {
+		return fmt.Errorf("error deleting device latency samples: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM device_status_intervals WHERE device_id = ?", id)
+	if err != nil {
+		return fmt.Errorf("error deleting device status history: %w", err)
+	}
+
 	_, err = tx.ExecContext(ctx, "DELETE FROM devices WHERE id = ?", id)
 	if err != nil {
 		return fmt.Errorf("error deleting device: %w", err)
@@ -742,11 +1004,13 @@ func (r *SQLiteDeviceRepository) DeleteByID(ctx context.Context, id string) erro
 // SQLiteEventLogRepository implements the EventLogRepository interface for SQLite
 type SQLiteEventLogRepository struct {
 	db *sql.DB
+	// textSearch returns the condition matching a full-text query on e.description
+	textSearch func(ctx context.Context, db *sql.DB, query string) (string, []interface{}, error)
 }
 
 // NewSQLiteEventLogRepository creates a new SQLiteEventLogRepository
 func NewSQLiteEventLogRepository(db *sql.DB) *SQLiteEventLogRepository {
-	return &SQLiteEventLogRepository{db: db}
+	return &SQLiteEventLogRepository{db: db, textSearch: sqliteTextSearch}
 }
 
 // Close closes the database connection
@@ -764,11 +1028,11 @@ func (r *SQLiteEventLogRepository) Create(ctx context.Context, eventLog *models.
 		eventLog.UpdatedAt = &now
 	}
 
-	query := `INSERT INTO event_logs (type, description, device_id, created_at, updated_at)
-			  VALUES (?, ?, ?, ?, ?)`
+	query := `INSERT INTO event_logs (type, description, device_id, payload, created_at, updated_at)
+			  VALUES (?, ?, ?, ?, ?, ?)`
 
 	_, err := r.db.ExecContext(ctx, query,
-		eventLog.Type, eventLog.Description, nullableString(eventLog.DeviceID),
+		eventLog.Type, eventLog.Description, nullableString(eventLog.DeviceID), nullablePayload(eventLog.Payload),
 		eventLog.CreatedAt, eventLog.UpdatedAt,
 	)
 	if err != nil {
@@ -778,47 +1042,67 @@ func (r *SQLiteEventLogRepository) Create(ctx context.Context, eventLog *models.
 	return nil
 }
 
-// FindLatest finds the latest event logs
-func (r *SQLiteEventLogRepository) FindLatest(ctx context.Context, limit int) ([]*models.EventLog, error) {
-	query := `SELECT type, description, device_id, created_at, updated_at
-			  FROM event_logs ORDER BY created_at DESC LIMIT ?`
+// CreateBatch creates event logs in a single transaction
+func (r *SQLiteEventLogRepository) CreateBatch(ctx context.Context, eventLogs []*models.EventLog) error {
+	if len(eventLogs) == 0 {
+		return nil
+	}
 
-	rows, err := r.db.QueryContext(ctx, query, limit)
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		return nil, fmt.Errorf("error querying event logs: %w", err)
+		return fmt.Errorf("error beginning transaction: %w", err)
 	}
-	defer rows.Close()
-
-	var logs []*models.EventLog
-	for rows.Next() {
-		var log models.EventLog
-		var deviceID sql.NullString
-		var createdAt, updatedAt sql.NullTime
+	defer tx.Rollback()
 
-		err := rows.Scan(&log.Type, &log.Description, &deviceID, &createdAt, &updatedAt)
-		if err != nil {
-			return nil, fmt.Errorf("error scanning event log: %w", err)
-		}
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO event_logs (type, description, device_id, payload, created_at, updated_at)
+			  VALUES (?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("error preparing event log insert: %w", err)
+	}
+	defer stmt.Close()
 
-		if deviceID.Valid {
-			log.DeviceID = &deviceID.String
+	now := time.Now()
+	for _, eventLog := range eventLogs {
+		if eventLog.CreatedAt == nil {
+			eventLog.CreatedAt = &now
 		}
-		if createdAt.Valid {
-			log.CreatedAt = &createdAt.Time
+		if eventLog.UpdatedAt == nil {
+			eventLog.UpdatedAt = &now
 		}
-		if updatedAt.Valid {
-			log.UpdatedAt = &updatedAt.Time
+
+		_, err = stmt.ExecContext(ctx,
+			eventLog.Type, eventLog.Description, nullableString(eventLog.DeviceID), nullablePayload(eventLog.Payload),
+			eventLog.CreatedAt, eventLog.UpdatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("error inserting event log: %w", err)
 		}
+	}
 
-		logs = append(logs, &log)
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
 	}
 
-	return logs, nil
+	return nil
+}
+
+// FindLatest finds the latest event logs
+func (r *SQLiteEventLogRepository) FindLatest(ctx context.Context, limit int) ([]*models.EventLog, error) {
+	query := `SELECT id, type, description, device_id, payload, created_at, updated_at
+			  FROM event_logs ORDER BY created_at DESC LIMIT ?`
+
+	rows, err := r.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error querying event logs: %w", err)
+	}
+	defer rows.Close()
+
+	return scanEventLogs(rows)
 }
 
 // FindAllByDeviceID finds all event logs for a device
 func (r *SQLiteEventLogRepository) FindAllByDeviceID(ctx context.Context, deviceID string) ([]*models.EventLog, error) {
-	query := `SELECT type, description, device_id, created_at, updated_at
+	query := `SELECT id, type, description, device_id, payload, created_at, updated_at
 			  FROM event_logs WHERE device_id = ? ORDER BY created_at DESC`
 
 	rows, err := r.db.QueryContext(ctx, query, deviceID)
@@ -827,16 +1111,125 @@ func (r *SQLiteEventLogRepository) FindAllByDeviceID(ctx context.Context, device
 	}
 	defer rows.Close()
 
+	return scanEventLogs(rows)
+}
+
+// Search finds up to limit event logs matching filter, newest first, starting
+// after the cursor when one is given
+func (r *SQLiteEventLogRepository) Search(ctx context.Context, filter models.EventLogFilter, after *models.EventLogCursor, limit int) ([]*models.EventLog, error) {
+	var conditions []string
+	var args []interface{}
+
+	if len(filter.Types) > 0 {
+		conditions = append(conditions, "e.type IN ("+placeholders(len(filter.Types))+")")
+		for _, eventType := range filter.Types {
+			args = append(args, eventType)
+		}
+	}
+	if len(filter.Severities) > 0 {
+		condition, severityArgs := severityCondition(filter.Severities)
+		conditions = append(conditions, condition)
+		args = append(args, severityArgs...)
+	}
+	if filter.DeviceID != "" {
+		conditions = append(conditions, "e.device_id = ?")
+		args = append(args, filter.DeviceID)
+	}
+	if filter.NetworkID != "" {
+		conditions = append(conditions, "e.device_id IN (SELECT id FROM devices WHERE network_id = ?)")
+		args = append(args, filter.NetworkID)
+	}
+	if filter.Since != nil {
+		conditions = append(conditions, "e.created_at >= ?")
+		args = append(args, *filter.Since)
+	}
+	if filter.Until != nil {
+		conditions = append(conditions, "e.created_at < ?")
+		args = append(args, *filter.Until)
+	}
+	if filter.Query != "" {
+		condition, searchArgs, err := r.textSearch(ctx, r.db, filter.Query)
+		if err != nil {
+			return nil, err
+		}
+		if condition != "" {
+			conditions = append(conditions, condition)
+			args = append(args, searchArgs...)
+		}
+	}
+	if after != nil {
+		conditions = append(conditions, "(e.created_at < ? OR (e.created_at = ? AND e.id < ?))")
+		args = append(args, after.CreatedAt, after.CreatedAt, after.ID)
+	}
+
+	query := `SELECT e.id, e.type, e.description, e.device_id, e.payload, e.created_at, e.updated_at FROM event_logs e`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY e.created_at DESC, e.id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error searching event logs: %w", err)
+	}
+	defer rows.Close()
+
+	return scanEventLogs(rows)
+}
+
+// severityCondition matches event types ranked as any of severities. Info
+// covers every type without a severity, so it is matched by exclusion.
+func severityCondition(severities []models.EventSeverity) (string, []interface{}) {
+	var ranked []models.EEventLogType
+	for _, severity := range []models.EventSeverity{models.SeverityWarning, models.SeverityCritical} {
+		ranked = append(ranked, models.TypesWithSeverity(severity)...)
+	}
+
+	var conditions []string
+	var args []interface{}
+	for _, severity := range severities {
+		types := models.TypesWithSeverity(severity)
+		if severity == models.SeverityInfo {
+			types = ranked
+		}
+		if len(types) == 0 {
+			continue
+		}
+
+		operator := "IN"
+		if severity == models.SeverityInfo {
+			operator = "NOT IN"
+		}
+		conditions = append(conditions, "e.type "+operator+" ("+placeholders(len(types))+")")
+		for _, eventType := range types {
+			args = append(args, eventType)
+		}
+	}
+	if len(conditions) == 0 {
+		return "1 = 0", nil
+	}
+	return "(" + strings.Join(conditions, " OR ") + ")", args
+}
+
+func scanEventLogs(rows *sql.Rows) ([]*models.EventLog, error) {
 	var logs []*models.EventLog
 	for rows.Next() {
 		var log models.EventLog
+		var deviceID, payload sql.NullString
 		var createdAt, updatedAt sql.NullTime
 
-		err := rows.Scan(&log.Type, &log.Description, &log.DeviceID, &createdAt, &updatedAt)
+		err := rows.Scan(&log.ID, &log.Type, &log.Description, &deviceID, &payload, &createdAt, &updatedAt)
 		if err != nil {
 			return nil, fmt.Errorf("error scanning event log: %w", err)
 		}
 
+		if deviceID.Valid {
+			log.DeviceID = &deviceID.String
+		}
+		if payload.Valid && payload.String != "" {
+			log.Payload = json.RawMessage(payload.String)
+		}
 		if createdAt.Valid {
 			log.CreatedAt = &createdAt.Time
 		}
@@ -847,6 +1240,9 @@ func (r *SQLiteEventLogRepository) FindAllByDeviceID(ctx context.Context, device
 		logs = append(logs, &log)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading event logs: %w", err)
+	}
 	return logs, nil
 }
 
@@ -877,21 +1273,17 @@ func (r *SQLiteSystemStatusRepository) Create(ctx context.Context, status *model
 	networkIDPtr := stringToPtr(status.NetworkID)
 
 	query := `INSERT INTO system_status (network_id, public_ip, created_at, updated_at)
-			  VALUES (?, ?, ?, ?)`
+			  VALUES (?, ?, ?, ?) RETURNING id`
 
-	result, err := tx.ExecContext(ctx, query,
+	var statusID int64
+	err = tx.QueryRowContext(ctx, query,
 		networkIDPtr, nullableString(status.PublicIP),
 		status.CreatedAt, status.UpdatedAt,
-	)
+	).Scan(&statusID)
 	if err != nil {
 		return fmt.Errorf("error inserting system status: %w", err)
 	}
 
-	statusID, err := result.LastInsertId()
-	if err != nil {
-		return fmt.Errorf("error getting last insert ID: %w", err)
-	}
-
 	query = `INSERT INTO local_devices (system_status_id, name, ipv4, mac, vendor, status, hostname)
 			 VALUES (?, ?, ?, ?, ?, ?, ?)`
 
@@ -913,12 +1305,6 @@ func (r *SQLiteSystemStatusRepository) Create(ctx context.Context, status *model
 
 // FindLatest finds the latest system status
 func (r *SQLiteSystemStatusRepository) FindLatest(ctx context.Context) (*models.SystemStatus, error) {
-	tx, err := r.db.BeginTx(ctx, nil)
-	if err != nil {
-		return nil, fmt.Errorf("error beginning transaction: %w", err)
-	}
-	defer tx.Rollback()
-
 	// Get the latest system status
 	query := `SELECT id, network_id, public_ip, created_at, updated_at
 			  FROM system_status ORDER BY created_at DESC LIMIT 1`
@@ -927,7 +1313,7 @@ func (r *SQLiteSystemStatusRepository) FindLatest(ctx context.Context) (*models.
 	var id int64
 	var networkID, publicIP sql.NullString
 
-	err = tx.QueryRowContext(ctx, query).Scan(
+	err := r.db.QueryRowContext(ctx, query).Scan(
 		&id, &networkID, &publicIP, &status.CreatedAt, &status.UpdatedAt,
 	)
 	if err != nil {
@@ -950,7 +1336,7 @@ func (r *SQLiteSystemStatusRepository) FindLatest(ctx context.Context) (*models.
 
 	var mac, vendor, hostname sql.NullString
 
-	err = tx.QueryRowContext(ctx, query, id).Scan(
+	err = r.db.QueryRowContext(ctx, query, id).Scan(
 		&status.LocalDevice.Name, &status.LocalDevice.IPv4,
 		&mac, &vendor, &status.LocalDevice.Status, &hostname,
 	)
@@ -968,10 +1354,6 @@ func (r *SQLiteSystemStatusRepository) FindLatest(ctx context.Context) (*models.
 		status.LocalDevice.Hostname = &hostname.String
 	}
 
-	if err = tx.Commit(); err != nil {
-		return nil, fmt.Errorf("error committing transaction: %w", err)
-	}
-
 	return &status, nil
 }
 
@@ -983,6 +1365,26 @@ func nullableString(s *string) sql.NullString {
 	return sql.NullString{String: *s, Valid: true}
 }
 
+// nullablePayload stores an event payload, or NULL when there is none
+func nullablePayload(payload json.RawMessage) sql.NullString {
+	if len(payload) == 0 {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: string(payload), Valid: true}
+}
+
+// tagsJSON stores device tags as a JSON array, or NULL when there are none
+func tagsJSON(tags []string) sql.NullString {
+	if len(tags) == 0 {
+		return sql.NullString{}
+	}
+	jsonBytes, err := json.Marshal(tags)
+	if err != nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: string(jsonBytes), Valid: true}
+}
+
 func nullableTime(t *time.Time) sql.NullTime {
 	if t == nil {
 		return sql.NullTime{}
@@ -0,0 +1,60 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+// queryExecer is the part of *sql.Tx the device write helpers use
+type queryExecer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// txStatements prepares each query once per transaction. Batches run the same
+// handful of statements for every device, and preparing them dominated the
+// cost of saving a large sweep.
+type txStatements struct {
+	tx    *sql.Tx
+	stmts map[string]*sql.Stmt
+}
+
+func newTxStatements(tx *sql.Tx) *txStatements {
+	return &txStatements{tx: tx, stmts: make(map[string]*sql.Stmt)}
+}
+
+func (s *txStatements) prepare(ctx context.Context, query string) (*sql.Stmt, error) {
+	if stmt, ok := s.stmts[query]; ok {
+		return stmt, nil
+	}
+	stmt, err := s.tx.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	s.stmts[query] = stmt
+	return stmt, nil
+}
+
+func (s *txStatements) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	stmt, err := s.prepare(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return stmt.ExecContext(ctx, args...)
+}
+
+func (s *txStatements) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	stmt, err := s.prepare(ctx, query)
+	if err != nil {
+		// Let the transaction report the error through the returned row
+		return s.tx.QueryRowContext(ctx, query, args...)
+	}
+	return stmt.QueryRowContext(ctx, args...)
+}
+
+// Close releases the prepared statements
+func (s *txStatements) Close() {
+	for _, stmt := range s.stmts {
+		stmt.Close()
+	}
+}
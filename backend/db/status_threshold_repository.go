@@ -0,0 +1,109 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reconya-ai/models"
+	"time"
+)
+
+// SQLiteStatusThresholdRepository implements the StatusThresholdRepository interface for SQLite
+type SQLiteStatusThresholdRepository struct {
+	db *sql.DB
+}
+
+// NewSQLiteStatusThresholdRepository creates a new SQLiteStatusThresholdRepository
+func NewSQLiteStatusThresholdRepository(db *sql.DB) *SQLiteStatusThresholdRepository {
+	return &SQLiteStatusThresholdRepository{db: db}
+}
+
+// Close closes the repository (satisfies Repository interface)
+func (r *SQLiteStatusThresholdRepository) Close() error {
+	// SQLite connection is managed by the main DB instance
+	return nil
+}
+
+// FindAll returns every configured threshold
+func (r *SQLiteStatusThresholdRepository) FindAll(ctx context.Context) ([]*models.StatusThreshold, error) {
+	query := `SELECT scope, scope_id, idle_after_seconds, offline_after_seconds, updated_at
+			  FROM status_thresholds ORDER BY scope, scope_id`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("error querying status thresholds: %w", err)
+	}
+	defer rows.Close()
+
+	var thresholds []*models.StatusThreshold
+	for rows.Next() {
+		var threshold models.StatusThreshold
+		var idleAfter, offlineAfter sql.NullInt64
+
+		err := rows.Scan(&threshold.Scope, &threshold.ScopeID, &idleAfter, &offlineAfter, &threshold.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning status threshold: %w", err)
+		}
+
+		threshold.IdleAfterSeconds = intPtr(idleAfter)
+		threshold.OfflineAfterSeconds = intPtr(offlineAfter)
+		thresholds = append(thresholds, &threshold)
+	}
+
+	return thresholds, rows.Err()
+}
+
+// Save creates or replaces the threshold of a scope
+func (r *SQLiteStatusThresholdRepository) Save(ctx context.Context, threshold *models.StatusThreshold) error {
+	threshold.UpdatedAt = time.Now()
+
+	query := `
+		INSERT INTO status_thresholds (scope, scope_id, idle_after_seconds, offline_after_seconds, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(scope, scope_id) DO UPDATE SET
+			idle_after_seconds = excluded.idle_after_seconds,
+			offline_after_seconds = excluded.offline_after_seconds,
+			updated_at = excluded.updated_at`
+
+	_, err := r.db.ExecContext(ctx, query,
+		threshold.Scope, threshold.ScopeID,
+		nullableInt(threshold.IdleAfterSeconds), nullableInt(threshold.OfflineAfterSeconds),
+		threshold.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("error saving status threshold: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the threshold of a scope
+func (r *SQLiteStatusThresholdRepository) Delete(ctx context.Context, scope models.ThresholdScope, scopeID string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM status_thresholds WHERE scope = ? AND scope_id = ?`, scope, scopeID)
+	if err != nil {
+		return fmt.Errorf("error deleting status threshold: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error checking deleted status threshold: %w", err)
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func nullableInt(i *int) sql.NullInt64 {
+	if i == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: int64(*i), Valid: true}
+}
+
+func intPtr(i sql.NullInt64) *int {
+	if !i.Valid {
+		return nil
+	}
+	v := int(i.Int64)
+	return &v
+}
@@ -0,0 +1,221 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reconya-ai/models"
+	"time"
+)
+
+// SQLiteTracerouteRepository implements the TracerouteRepository interface for SQLite
+type SQLiteTracerouteRepository struct {
+	db *sql.DB
+}
+
+// NewSQLiteTracerouteRepository creates a new SQLiteTracerouteRepository
+func NewSQLiteTracerouteRepository(db *sql.DB) *SQLiteTracerouteRepository {
+	return &SQLiteTracerouteRepository{db: db}
+}
+
+// Close closes the repository (satisfies Repository interface)
+func (r *SQLiteTracerouteRepository) Close() error {
+	// SQLite connection is managed by the main DB instance
+	return nil
+}
+
+// Create stores a traceroute run together with its hops
+func (r *SQLiteTracerouteRepository) Create(ctx context.Context, run *models.TracerouteRun) error {
+	if run.ID == "" {
+		run.ID = GenerateID()
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO traceroute_runs (id, network_id, device_id, target, method, reached, path_changed, started_at, completed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		run.ID, nullableString(run.NetworkID), nullableString(run.DeviceID), run.Target, run.Method,
+		run.Reached, run.PathChanged, run.StartedAt, run.CompletedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("error inserting traceroute run: %w", err)
+	}
+
+	for _, hop := range run.Hops {
+		var rtt sql.NullFloat64
+		if hop.RTTMillis != nil {
+			rtt = sql.NullFloat64{Float64: *hop.RTTMillis, Valid: true}
+		}
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO traceroute_hops (run_id, ttl, ip, hostname, rtt_ms)
+			VALUES (?, ?, ?, ?, ?)`,
+			run.ID, hop.TTL, nullableString(hop.IP), nullableString(hop.Hostname), rtt,
+		)
+		if err != nil {
+			return fmt.Errorf("error inserting traceroute hop: %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return nil
+}
+
+// FindLatestByNetworkID returns the most recent run for a network
+func (r *SQLiteTracerouteRepository) FindLatestByNetworkID(ctx context.Context, networkID string) (*models.TracerouteRun, error) {
+	runs, err := r.FindByNetworkID(ctx, networkID, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(runs) == 0 {
+		return nil, ErrNotFound
+	}
+	return runs[0], nil
+}
+
+// FindLatestByDeviceID returns the most recent run for a device
+func (r *SQLiteTracerouteRepository) FindLatestByDeviceID(ctx context.Context, deviceID string) (*models.TracerouteRun, error) {
+	runs, err := r.FindByDeviceID(ctx, deviceID, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(runs) == 0 {
+		return nil, ErrNotFound
+	}
+	return runs[0], nil
+}
+
+// FindByNetworkID returns the run history for a network, newest first
+func (r *SQLiteTracerouteRepository) FindByNetworkID(ctx context.Context, networkID string, limit int) ([]*models.TracerouteRun, error) {
+	query := `SELECT id, network_id, device_id, target, method, reached, path_changed, started_at, completed_at
+			  FROM traceroute_runs WHERE network_id = ? AND device_id IS NULL
+			  ORDER BY started_at DESC LIMIT ?`
+	return r.findRuns(ctx, query, networkID, limit)
+}
+
+// FindByDeviceID returns the run history for a device, newest first
+func (r *SQLiteTracerouteRepository) FindByDeviceID(ctx context.Context, deviceID string, limit int) ([]*models.TracerouteRun, error) {
+	query := `SELECT id, network_id, device_id, target, method, reached, path_changed, started_at, completed_at
+			  FROM traceroute_runs WHERE device_id = ?
+			  ORDER BY started_at DESC LIMIT ?`
+	return r.findRuns(ctx, query, deviceID, limit)
+}
+
+func (r *SQLiteTracerouteRepository) findRuns(ctx context.Context, query string, args ...interface{}) ([]*models.TracerouteRun, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying traceroute runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []*models.TracerouteRun
+	for rows.Next() {
+		var run models.TracerouteRun
+		var networkID, deviceID sql.NullString
+
+		err := rows.Scan(&run.ID, &networkID, &deviceID, &run.Target, &run.Method,
+			&run.Reached, &run.PathChanged, &run.StartedAt, &run.CompletedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning traceroute run: %w", err)
+		}
+		if networkID.Valid {
+			run.NetworkID = &networkID.String
+		}
+		if deviceID.Valid {
+			run.DeviceID = &deviceID.String
+		}
+		runs = append(runs, &run)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating traceroute runs: %w", err)
+	}
+
+	for _, run := range runs {
+		hops, err := r.findHops(ctx, run.ID)
+		if err != nil {
+			return nil, err
+		}
+		run.Hops = hops
+	}
+
+	return runs, nil
+}
+
+func (r *SQLiteTracerouteRepository) findHops(ctx context.Context, runID string) ([]models.TracerouteHop, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT ttl, ip, hostname, rtt_ms FROM traceroute_hops WHERE run_id = ? ORDER BY ttl`, runID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying traceroute hops: %w", err)
+	}
+	defer rows.Close()
+
+	hops := []models.TracerouteHop{}
+	for rows.Next() {
+		var hop models.TracerouteHop
+		var ip, hostname sql.NullString
+		var rtt sql.NullFloat64
+
+		if err := rows.Scan(&hop.TTL, &ip, &hostname, &rtt); err != nil {
+			return nil, fmt.Errorf("error scanning traceroute hop: %w", err)
+		}
+		if ip.Valid {
+			hop.IP = &ip.String
+		}
+		if hostname.Valid {
+			hop.Hostname = &hostname.String
+		}
+		if rtt.Valid {
+			hop.RTTMillis = &rtt.Float64
+		}
+		hops = append(hops, hop)
+	}
+
+	return hops, rows.Err()
+}
+
+// AddTarget selects a device for periodic traceroute
+func (r *SQLiteTracerouteRepository) AddTarget(ctx context.Context, deviceID string) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO traceroute_targets (device_id, created_at) VALUES (?, ?) ON CONFLICT (device_id) DO NOTHING`,
+		deviceID, time.Now())
+	if err != nil {
+		return fmt.Errorf("error adding traceroute target: %w", err)
+	}
+	return nil
+}
+
+// RemoveTarget removes a device from periodic traceroute
+func (r *SQLiteTracerouteRepository) RemoveTarget(ctx context.Context, deviceID string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM traceroute_targets WHERE device_id = ?`, deviceID)
+	if err != nil {
+		return fmt.Errorf("error removing traceroute target: %w", err)
+	}
+	return nil
+}
+
+// FindTargets returns the IDs of all devices selected for periodic traceroute
+func (r *SQLiteTracerouteRepository) FindTargets(ctx context.Context) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT device_id FROM traceroute_targets ORDER BY created_at`)
+	if err != nil {
+		return nil, fmt.Errorf("error querying traceroute targets: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("error scanning traceroute target: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
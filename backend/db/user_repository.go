@@ -0,0 +1,75 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reconya-ai/models"
+	"time"
+)
+
+// SQLiteUserRepository implements the UserRepository interface for SQLite
+type SQLiteUserRepository struct {
+	db *sql.DB
+}
+
+// NewSQLiteUserRepository creates a new SQLiteUserRepository
+func NewSQLiteUserRepository(db *sql.DB) *SQLiteUserRepository {
+	return &SQLiteUserRepository{db: db}
+}
+
+// Close closes the repository (satisfies Repository interface)
+func (r *SQLiteUserRepository) Close() error {
+	// SQLite connection is managed by the main DB instance
+	return nil
+}
+
+// Create inserts a user; Password must already hold the password hash
+func (r *SQLiteUserRepository) Create(ctx context.Context, user *models.User) error {
+	if user.CreatedAt.IsZero() {
+		user.CreatedAt = time.Now()
+	}
+
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO users (username, password_hash, created_at) VALUES (?, ?, ?) RETURNING id`,
+		user.Username, user.Password, user.CreatedAt,
+	).Scan(&user.ID)
+	if err != nil {
+		return fmt.Errorf("error creating user: %w", err)
+	}
+	return nil
+}
+
+// FindByUsername returns the user with the given username and its password hash
+func (r *SQLiteUserRepository) FindByUsername(ctx context.Context, username string) (*models.User, error) {
+	var user models.User
+	err := r.db.QueryRowContext(ctx,
+		`SELECT id, username, password_hash, created_at FROM users WHERE username = ?`, username,
+	).Scan(&user.ID, &user.Username, &user.Password, &user.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error finding user: %w", err)
+	}
+	return &user, nil
+}
+
+// FindAll returns every user ordered by username, without password hashes
+func (r *SQLiteUserRepository) FindAll(ctx context.Context) ([]*models.User, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT id, username, created_at FROM users ORDER BY username`)
+	if err != nil {
+		return nil, fmt.Errorf("error querying users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []*models.User
+	for rows.Next() {
+		var user models.User
+		if err := rows.Scan(&user.ID, &user.Username, &user.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning user: %w", err)
+		}
+		users = append(users, &user)
+	}
+	return users, rows.Err()
+}
@@ -0,0 +1,145 @@
+package availability
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"reconya-ai/db"
+	"reconya-ai/internal/device"
+	"reconya-ai/models"
+)
+
+// Windows over which availability is reported. Outages and MTBF use the longest one.
+var Windows = []struct {
+	Name     string
+	Duration time.Duration
+}{
+	{"24h", 24 * time.Hour},
+	{"7d", 7 * 24 * time.Hour},
+	{"30d", 30 * 24 * time.Hour},
+}
+
+type AvailabilityService struct {
+	repository    db.AvailabilityRepository
+	deviceService *device.DeviceService
+}
+
+func NewAvailabilityService(repository db.AvailabilityRepository, deviceService *device.DeviceService) *AvailabilityService {
+	return &AvailabilityService{
+		repository:    repository,
+		deviceService: deviceService,
+	}
+}
+
+// GetDeviceAvailability returns the availability report of a single device
+func (s *AvailabilityService) GetDeviceAvailability(deviceID string) (*models.DeviceAvailability, error) {
+	device, err := s.deviceService.FindByID(deviceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find device: %v", err)
+	}
+	if device == nil {
+		return nil, db.ErrNotFound
+	}
+
+	now := time.Now()
+	intervals, err := s.repository.FindIntervals(context.Background(), deviceID, now.Add(-longestWindow()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load status history: %v", err)
+	}
+
+	return buildReport(device, intervals, now), nil
+}
+
+// GetReport returns the availability report of every device, ordered by IP
+func (s *AvailabilityService) GetReport() ([]*models.DeviceAvailability, error) {
+	devices, err := s.deviceService.FindAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load devices: %v", err)
+	}
+
+	now := time.Now()
+	intervals, err := s.repository.FindAllIntervals(context.Background(), now.Add(-longestWindow()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load status history: %v", err)
+	}
+
+	byDevice := make(map[string][]*models.DeviceStatusInterval)
+	for _, interval := range intervals {
+		byDevice[interval.DeviceID] = append(byDevice[interval.DeviceID], interval)
+	}
+
+	reports := make([]*models.DeviceAvailability, 0, len(devices))
+	for _, d := range devices {
+		reports = append(reports, buildReport(d, byDevice[d.ID], now))
+	}
+
+	return reports, nil
+}
+
+// GetPeriodReport returns the availability of every device between from and to.
+// Each report has a single window named "period".
+func (s *AvailabilityService) GetPeriodReport(from, to time.Time) ([]*models.DeviceAvailability, error) {
+	devices, err := s.deviceService.FindAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load devices: %v", err)
+	}
+
+	intervals, err := s.repository.FindAllIntervals(context.Background(), from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load status history: %v", err)
+	}
+
+	byDevice := make(map[string][]*models.DeviceStatusInterval)
+	for _, interval := range intervals {
+		byDevice[interval.DeviceID] = append(byDevice[interval.DeviceID], interval)
+	}
+
+	reports := make([]*models.DeviceAvailability, 0, len(devices))
+	for _, d := range devices {
+		window := models.ComputeAvailability("period", byDevice[d.ID], from, to)
+		outages := models.FindOutages(byDevice[d.ID], from, to)
+		if outages == nil {
+			outages = []models.Outage{}
+		}
+		reports = append(reports, &models.DeviceAvailability{
+			DeviceID:    d.ID,
+			DeviceName:  d.Name,
+			IPv4:        d.IPv4,
+			Status:      d.Status,
+			Windows:     []models.AvailabilityWindow{window},
+			Outages:     outages,
+			MTBFSeconds: models.MeanTimeBetweenFailures(window),
+		})
+	}
+
+	return reports, nil
+}
+
+func buildReport(device *models.Device, intervals []*models.DeviceStatusInterval, now time.Time) *models.DeviceAvailability {
+	report := &models.DeviceAvailability{
+		DeviceID:   device.ID,
+		DeviceName: device.Name,
+		IPv4:       device.IPv4,
+		Status:     device.Status,
+		Windows:    make([]models.AvailabilityWindow, 0, len(Windows)),
+	}
+
+	for _, window := range Windows {
+		report.Windows = append(report.Windows,
+			models.ComputeAvailability(window.Name, intervals, now.Add(-window.Duration), now))
+	}
+
+	longest := report.Windows[len(report.Windows)-1]
+	report.Outages = models.FindOutages(intervals, now.Add(-longestWindow()), now)
+	if report.Outages == nil {
+		report.Outages = []models.Outage{}
+	}
+	report.MTBFSeconds = models.MeanTimeBetweenFailures(longest)
+
+	return report
+}
+
+func longestWindow() time.Duration {
+	return Windows[len(Windows)-1].Duration
+}
@@ -0,0 +1,119 @@
+package backup
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// Encrypted archives are a gzip-compressed database sealed with AES-256-GCM:
+//
+//	magic (8 bytes) | scrypt salt (16 bytes) | GCM nonce (12 bytes) | ciphertext
+//
+// The key is derived from the passphrase with scrypt, so an archive can be
+// shipped off the box and restored anywhere with the same passphrase.
+const archiveMagic = "RCNYBAK1"
+
+const (
+	saltSize = 16
+	keySize  = 32
+)
+
+// ErrWrongPassphrase is returned when an archive cannot be decrypted
+var ErrWrongPassphrase = errors.New("wrong passphrase or corrupted backup")
+
+// IsArchive reports whether data starts like an encrypted archive
+func IsArchive(header []byte) bool {
+	return bytes.HasPrefix(header, []byte(archiveMagic))
+}
+
+// Seal compresses and encrypts a database file
+func Seal(plain []byte, passphrase string) ([]byte, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("a passphrase is required to encrypt backups")
+	}
+
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	if _, err := zw.Write(plain); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, len(archiveMagic)+saltSize+len(nonce)+compressed.Len()+aead.Overhead())
+	out = append(out, archiveMagic...)
+	out = append(out, salt...)
+	out = append(out, nonce...)
+	return aead.Seal(out, nonce, compressed.Bytes(), []byte(archiveMagic)), nil
+}
+
+// Open decrypts and decompresses an archive created by Seal
+func Open(archive []byte, passphrase string) ([]byte, error) {
+	if !IsArchive(archive) {
+		return nil, fmt.Errorf("not an encrypted reconya backup")
+	}
+	if passphrase == "" {
+		return nil, fmt.Errorf("backup is encrypted; set BACKUP_PASSPHRASE to restore it")
+	}
+
+	rest := archive[len(archiveMagic):]
+	if len(rest) < saltSize {
+		return nil, ErrWrongPassphrase
+	}
+	salt, rest := rest[:saltSize], rest[saltSize:]
+
+	aead, err := newAEAD(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) < aead.NonceSize() {
+		return nil, ErrWrongPassphrase
+	}
+	nonce, ciphertext := rest[:aead.NonceSize()], rest[aead.NonceSize():]
+
+	compressed, err := aead.Open(nil, nonce, ciphertext, []byte(archiveMagic))
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress backup: %v", err)
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
+
+func newAEAD(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, keySize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %v", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,361 @@
+package backup
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"reconya-ai/db"
+	"reconya-ai/internal/config"
+)
+
+const (
+	filePrefix    = "reconya-backup-"
+	timeLayout    = "20060102-150405"
+	extPlain      = ".db"
+	extEncrypted  = ".db.enc"
+	sqliteHeader  = "SQLite format 3\x00"
+	backupFileMod = 0600
+)
+
+// Backup is a backup file in the backup directory
+type Backup struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+	Encrypted bool      `json:"encrypted"`
+}
+
+// RestoreResult describes a completed restore
+type RestoreResult struct {
+	// Backup taken of the replaced database, for undoing the restore
+	SafetyBackup  string `json:"safety_backup"`
+	BackupVersion int    `json:"backup_version"`
+	SchemaVersion int    `json:"schema_version"`
+}
+
+type BackupService struct {
+	db       *sql.DB
+	migrator *db.Migrator
+	config   *config.Config
+	dir      string
+	mutex    sync.Mutex
+}
+
+func NewBackupService(sqliteDB *sql.DB, migrator *db.Migrator, cfg *config.Config) *BackupService {
+	return &BackupService{
+		db:       sqliteDB,
+		migrator: migrator,
+		config:   cfg,
+		dir:      cfg.BackupDir,
+	}
+}
+
+// Create takes an online backup of the database into the backup directory,
+// encrypting it when a passphrase is configured
+func (s *BackupService) Create() (*Backup, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.create()
+}
+
+func (s *BackupService) create() (*Backup, error) {
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %v", err)
+	}
+
+	ext := extPlain
+	if s.config.BackupPassphrase != "" {
+		ext = extEncrypted
+	}
+
+	// Names have one second resolution; step past backups taken moments ago
+	createdAt := time.Now()
+	name := filePrefix + createdAt.Format(timeLayout) + ext
+	for {
+		if _, err := os.Stat(filepath.Join(s.dir, name)); os.IsNotExist(err) {
+			break
+		}
+		createdAt = createdAt.Add(time.Second)
+		name = filePrefix + createdAt.Format(timeLayout) + ext
+	}
+	path := filepath.Join(s.dir, name)
+
+	tmp := filepath.Join(s.dir, "."+name+".tmp")
+	os.Remove(tmp)
+	defer os.Remove(tmp)
+	if err := db.BackupTo(s.db, tmp); err != nil {
+		return nil, err
+	}
+
+	if s.config.BackupPassphrase != "" {
+		plain, err := os.ReadFile(tmp)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read backup: %v", err)
+		}
+		sealed, err := Seal(plain, s.config.BackupPassphrase)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt backup: %v", err)
+		}
+		if err := os.WriteFile(tmp, sealed, backupFileMod); err != nil {
+			return nil, fmt.Errorf("failed to write backup: %v", err)
+		}
+	}
+
+	if err := os.Chmod(tmp, backupFileMod); err != nil {
+		return nil, fmt.Errorf("failed to set backup permissions: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return nil, fmt.Errorf("failed to write backup: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	backup, _ := parseBackup(name, info.Size())
+	return backup, nil
+}
+
+// List returns the backups in the backup directory, newest first
+func (s *BackupService) List() ([]Backup, error) {
+	entries, err := os.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return []Backup{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	backups := []Backup{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		if backup, ok := parseBackup(entry.Name(), info.Size()); ok {
+			backups = append(backups, *backup)
+		}
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].CreatedAt.After(backups[j].CreatedAt)
+	})
+	return backups, nil
+}
+
+// Path returns the path of a backup, rejecting names outside the directory
+func (s *BackupService) Path(name string) (string, error) {
+	if name != filepath.Base(name) {
+		return "", fmt.Errorf("invalid backup name")
+	}
+	if _, ok := parseBackup(name, 0); !ok {
+		return "", fmt.Errorf("invalid backup name")
+	}
+	return filepath.Join(s.dir, name), nil
+}
+
+// Prune deletes the backups outside the retention policy and returns their names
+func (s *BackupService) Prune() ([]string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.config.BackupKeepDaily <= 0 && s.config.BackupKeepWeekly <= 0 {
+		return nil, nil
+	}
+
+	backups, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+
+	keep := retained(backups, s.config.BackupKeepDaily, s.config.BackupKeepWeekly)
+	var removed []string
+	for _, backup := range backups {
+		if keep[backup.Name] {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.dir, backup.Name)); err != nil {
+			return removed, fmt.Errorf("failed to remove %s: %v", backup.Name, err)
+		}
+		removed = append(removed, backup.Name)
+	}
+	return removed, nil
+}
+
+// Restore replaces the live database with a backup file. The backup must
+// pass an integrity check and must not be newer than this build's schema;
+// older backups are migrated forward after the restore.
+func (s *BackupService) Restore(path string) (*RestoreResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup: %v", err)
+	}
+	return s.RestoreData(filepath.Base(path), data)
+}
+
+// RestoreData restores an uploaded backup, plain or encrypted
+func (s *BackupService) RestoreData(name string, data []byte) (*RestoreResult, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var err error
+	if IsArchive(data) {
+		data, err = Open(data, s.config.BackupPassphrase)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !bytes.HasPrefix(data, []byte(sqliteHeader)) {
+		return nil, fmt.Errorf("%s is not a SQLite database or reconya backup", name)
+	}
+
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %v", err)
+	}
+	tmp, err := os.CreateTemp(s.dir, ".restore-*.db")
+	if err != nil {
+		return nil, fmt.Errorf("failed to stage backup: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to stage backup: %v", err)
+	}
+
+	backupVersion, err := s.checkBackup(tmp.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	safety, err := s.create()
+	if err != nil {
+		return nil, fmt.Errorf("failed to back up the current database before restoring: %v", err)
+	}
+
+	log.Printf("Restoring database from %s (schema version %d)", name, backupVersion)
+	if err := db.RestoreFrom(s.db, tmp.Name()); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.migrator.Migrate(); err != nil {
+		return nil, fmt.Errorf("restored backup could not be migrated: %v", err)
+	}
+	version, err := s.migrator.Version()
+	if err != nil {
+		return nil, err
+	}
+
+	return &RestoreResult{
+		SafetyBackup:  safety.Name,
+		BackupVersion: backupVersion,
+		SchemaVersion: version,
+	}, nil
+}
+
+// checkBackup verifies a staged backup and returns its schema version
+func (s *BackupService) checkBackup(path string) (int, error) {
+	staged, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open backup: %v", err)
+	}
+	defer staged.Close()
+
+	if err := db.CheckIntegrity(staged); err != nil {
+		return 0, err
+	}
+
+	version, err := db.NewMigrator(staged).Version()
+	if err != nil {
+		return 0, err
+	}
+	if version > s.migrator.Latest() {
+		return 0, fmt.Errorf("backup schema version %d is newer than this build supports (%d)", version, s.migrator.Latest())
+	}
+	return version, nil
+}
+
+// RunScheduled takes a backup and applies the retention policy
+func (s *BackupService) RunScheduled() error {
+	backup, err := s.Create()
+	if err != nil {
+		return err
+	}
+	log.Printf("Database backup written to %s", filepath.Join(s.dir, backup.Name))
+
+	removed, err := s.Prune()
+	if err != nil {
+		return err
+	}
+	if len(removed) > 0 {
+		log.Printf("Removed %d backups outside the retention policy", len(removed))
+	}
+	return nil
+}
+
+// parseBackup reads the creation time and format from a backup file name
+func parseBackup(name string, size int64) (*Backup, bool) {
+	if !strings.HasPrefix(name, filePrefix) {
+		return nil, false
+	}
+
+	stamp := strings.TrimPrefix(name, filePrefix)
+	encrypted := false
+	switch {
+	case strings.HasSuffix(stamp, extEncrypted):
+		stamp = strings.TrimSuffix(stamp, extEncrypted)
+		encrypted = true
+	case strings.HasSuffix(stamp, extPlain):
+		stamp = strings.TrimSuffix(stamp, extPlain)
+	default:
+		return nil, false
+	}
+
+	createdAt, err := time.ParseInLocation(timeLayout, stamp, time.Local)
+	if err != nil {
+		return nil, false
+	}
+	return &Backup{Name: name, Size: size, CreatedAt: createdAt, Encrypted: encrypted}, true
+}
+
+// retained picks the backups to keep from a newest-first list: the newest
+// backup of each of the last keepDaily days that have backups, and the newest
+// of each of the last keepWeekly ISO weeks. The newest backup is always kept.
+func retained(backups []Backup, keepDaily, keepWeekly int) map[string]bool {
+	keep := make(map[string]bool)
+	days := make(map[string]bool)
+	weeks := make(map[string]bool)
+
+	for i, backup := range backups {
+		if i == 0 {
+			keep[backup.Name] = true
+		}
+
+		day := backup.CreatedAt.Format("2006-01-02")
+		if !days[day] && len(days) < keepDaily {
+			days[day] = true
+			keep[backup.Name] = true
+		}
+
+		year, week := backup.CreatedAt.ISOWeek()
+		weekKey := fmt.Sprintf("%d-%02d", year, week)
+		if !weeks[weekKey] && len(weeks) < keepWeekly {
+			weeks[weekKey] = true
+			keep[backup.Name] = true
+		}
+	}
+	return keep
+}
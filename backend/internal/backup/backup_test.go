@@ -0,0 +1,77 @@
+package backup
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSealOpenRoundTrip(t *testing.T) {
+	plain := []byte("SQLite format 3\x00 pretend database contents")
+
+	sealed, err := Seal(plain, "correct horse")
+	require.NoError(t, err)
+	assert.True(t, IsArchive(sealed))
+	assert.NotContains(t, string(sealed), "pretend database")
+
+	opened, err := Open(sealed, "correct horse")
+	require.NoError(t, err)
+	assert.Equal(t, plain, opened)
+
+	_, err = Open(sealed, "wrong horse")
+	assert.ErrorIs(t, err, ErrWrongPassphrase)
+
+	_, err = Open(sealed[:20], "correct horse")
+	assert.ErrorIs(t, err, ErrWrongPassphrase)
+
+	_, err = Open(sealed, "")
+	assert.Error(t, err)
+}
+
+func TestParseBackup(t *testing.T) {
+	backup, ok := parseBackup("reconya-backup-20240601-020000.db.enc", 42)
+	require.True(t, ok)
+	assert.True(t, backup.Encrypted)
+	assert.Equal(t, int64(42), backup.Size)
+	assert.Equal(t, time.Date(2024, 6, 1, 2, 0, 0, 0, time.Local), backup.CreatedAt)
+
+	backup, ok = parseBackup("reconya-backup-20240601-020000.db", 0)
+	require.True(t, ok)
+	assert.False(t, backup.Encrypted)
+
+	for _, name := range []string{"reconya-backup-latest.db", "other-20240601-020000.db", "reconya-backup-20240601-020000.db.tmp"} {
+		_, ok := parseBackup(name, 0)
+		assert.False(t, ok, name)
+	}
+}
+
+func TestRetained(t *testing.T) {
+	// Two backups a day for 30 days, newest first
+	start := time.Date(2024, 6, 30, 22, 0, 0, 0, time.Local)
+	var backups []Backup
+	for i := 0; i < 60; i++ {
+		createdAt := start.Add(-time.Duration(i) * 12 * time.Hour)
+		backups = append(backups, Backup{Name: fmt.Sprintf("b%02d", i), CreatedAt: createdAt})
+	}
+
+	keep := retained(backups, 7, 4)
+
+	// The evening backup of each of the last 7 days
+	for i := 0; i < 14; i += 2 {
+		assert.True(t, keep[backups[i].Name], "daily %s", backups[i].CreatedAt)
+	}
+	assert.False(t, keep[backups[1].Name], "second backup of a day")
+
+	// 2024-06-30 is a Sunday: weeks 26, 25, 24 and 23 keep their newest backup
+	for _, day := range []int{30, 23, 16, 9} {
+		i := (30 - day) * 2
+		assert.True(t, keep[backups[i].Name], "weekly %s", backups[i].CreatedAt)
+	}
+	assert.Len(t, keep, 10)
+
+	assert.Len(t, retained(backups, 0, 0), 1, "the newest backup is always kept")
+	assert.Empty(t, retained(nil, 7, 4))
+}
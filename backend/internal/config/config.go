@@ -2,8 +2,12 @@ package config
 
 import (
 	"fmt"
+	"log"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -11,7 +15,8 @@ import (
 type DatabaseType string
 
 const (
-	SQLite DatabaseType = "sqlite"
+	SQLite   DatabaseType = "sqlite"
+	Postgres DatabaseType = "postgres"
 )
 
 type Config struct {
@@ -20,10 +25,66 @@ type Config struct {
 	DatabaseType DatabaseType
 	// SQLite config
 	SQLitePath string
+	// PostgreSQL config
+	PostgresURL string
 	// Common configs
 	Username     string
 	Password     string
 	DatabaseName string
+	// Traceroute config
+	TracerouteMethod   string
+	TracerouteInterval time.Duration
+	TracerouteMaxHops  int
+	// Latency monitoring config
+	LatencyProbeInterval        time.Duration
+	LatencyProbeCount           int
+	LatencyRTTThresholdMs       float64
+	LatencyLossThresholdPercent float64
+	// Device status config
+	DeviceIdleAfter            time.Duration
+	DeviceOfflineAfter         time.Duration
+	DeviceStatusScanStaleAfter time.Duration
+	// Report config
+	ReportInterval        time.Duration
+	ReportPeriod          time.Duration
+	ReportFormats         []string
+	ReportOutputDir       string
+	ReportWebhookURL      string
+	ReportCertWarningDays int
+	// Backup config
+	BackupInterval   time.Duration
+	BackupDir        string
+	BackupKeepDaily  int
+	BackupKeepWeekly int
+	BackupPassphrase string
+	// Retention config; a zero retention keeps rows forever
+	RetentionInterval      time.Duration
+	EventRetention         time.Duration
+	EventRetentionByType   map[string]time.Duration
+	EventRollups           bool
+	TracerouteRetention    time.Duration
+	LatencyRetention       time.Duration
+	StatusHistoryRetention time.Duration
+	SystemStatusRetention  time.Duration
+	IPMACBindingRetention  time.Duration
+	// Router and DHCP server detection; allow-lists hold IP or MAC addresses
+	InfraProbeInterval time.Duration
+	AllowedRouters     []string
+	AllowedDHCPServers []string
+	// ARP spoofing and address conflict detection; bindings older than the
+	// window are not compared, multi-homed hosts hold IP or MAC addresses
+	ConflictWindow       time.Duration
+	ConflictMaxIPsPerMAC int
+	MultiHomedHosts      []string
+	// Wake-on-LAN; the confirm timeout bounds how long a wake request waits
+	// for the device to come online
+	WakeOnLANPort      int
+	WakeConfirmTimeout time.Duration
+	// Scan pacing shared by all networks; networks can set a lower rate and
+	// their own windows. A zero rate is unlimited, no windows allow any time.
+	ScanRateLimit int
+	ScanRateBurst int
+	ScanWindows   string
 }
 
 func LoadConfig() (*Config, error) {
@@ -53,8 +114,14 @@ func LoadConfig() (*Config, error) {
 		port = "3008"
 	}
 
-	// Set database type to SQLite
-	dbType := string(SQLite)
+	// Default to SQLite; PostgreSQL lets several instances share one database
+	dbType := strings.ToLower(os.Getenv("DATABASE_TYPE"))
+	if dbType == "" {
+		dbType = string(SQLite)
+	}
+	if dbType != string(SQLite) && dbType != string(Postgres) {
+		return nil, fmt.Errorf("unsupported DATABASE_TYPE %q, expected %q or %q", dbType, SQLite, Postgres)
+	}
 
 	config := &Config{
 		JwtKey:       []byte(jwtSecret),
@@ -73,5 +140,205 @@ func LoadConfig() (*Config, error) {
 	}
 	config.SQLitePath = sqlitePath
 
+	// Configure PostgreSQL database
+	config.PostgresURL = os.Getenv("POSTGRES_URL")
+	if config.DatabaseType == Postgres && config.PostgresURL == "" {
+		return nil, fmt.Errorf("POSTGRES_URL environment variable is not set")
+	}
+
+	// Configure traceroute path discovery
+	config.TracerouteMethod = os.Getenv("TRACEROUTE_METHOD")
+	if config.TracerouteMethod == "" {
+		config.TracerouteMethod = "icmp"
+	}
+	config.TracerouteInterval = getEnvDuration("TRACEROUTE_INTERVAL", 15*time.Minute)
+	config.TracerouteMaxHops = getEnvInt("TRACEROUTE_MAX_HOPS", 30)
+
+	// Configure latency and packet loss monitoring
+	config.LatencyProbeInterval = getEnvDuration("LATENCY_PROBE_INTERVAL", time.Minute)
+	config.LatencyProbeCount = getEnvInt("LATENCY_PROBE_COUNT", 5)
+	config.LatencyRTTThresholdMs = getEnvFloat("LATENCY_RTT_THRESHOLD_MS", 200)
+	config.LatencyLossThresholdPercent = getEnvFloat("LATENCY_LOSS_THRESHOLD_PERCENT", 20)
+
+	// Configure global device status thresholds
+	config.DeviceIdleAfter = getEnvDuration("DEVICE_IDLE_AFTER", time.Minute)
+	config.DeviceOfflineAfter = getEnvDuration("DEVICE_OFFLINE_AFTER", 3*time.Minute)
+	config.DeviceStatusScanStaleAfter = getEnvDuration("DEVICE_STATUS_SCAN_STALE_AFTER", time.Hour)
+
+	// Configure scheduled reports (disabled unless an interval is set)
+	config.ReportInterval = getEnvDuration("REPORT_INTERVAL", 0)
+	defaultPeriod := config.ReportInterval
+	if defaultPeriod <= 0 {
+		defaultPeriod = 24 * time.Hour
+	}
+	config.ReportPeriod = getEnvDuration("REPORT_PERIOD", defaultPeriod)
+	config.ReportFormats = []string{"html", "csv", "pdf"}
+	if formats := os.Getenv("REPORT_FORMATS"); formats != "" {
+		config.ReportFormats = nil
+		for _, format := range strings.Split(formats, ",") {
+			if format = strings.TrimSpace(format); format != "" {
+				config.ReportFormats = append(config.ReportFormats, format)
+			}
+		}
+	}
+	config.ReportOutputDir = os.Getenv("REPORT_OUTPUT_DIR")
+	if config.ReportOutputDir == "" {
+		config.ReportOutputDir = filepath.Join(filepath.Dir(sqlitePath), "reports")
+	}
+	config.ReportWebhookURL = os.Getenv("REPORT_WEBHOOK_URL")
+	config.ReportCertWarningDays = getEnvInt("REPORT_CERT_WARNING_DAYS", 30)
+
+	// Configure database backups
+	config.BackupInterval = getEnvDuration("BACKUP_INTERVAL", 0)
+	config.BackupDir = os.Getenv("BACKUP_DIR")
+	if config.BackupDir == "" {
+		config.BackupDir = filepath.Join(filepath.Dir(sqlitePath), "backups")
+	}
+	config.BackupKeepDaily = getEnvInt("BACKUP_KEEP_DAILY", 7)
+	config.BackupKeepWeekly = getEnvInt("BACKUP_KEEP_WEEKLY", 4)
+	config.BackupPassphrase = os.Getenv("BACKUP_PASSPHRASE")
+
+	// Configure pruning of event logs and history tables
+	config.RetentionInterval = getEnvDuration("RETENTION_INTERVAL", 6*time.Hour)
+	config.EventRetention = getEnvRetention("EVENT_RETENTION", 90*24*time.Hour)
+	config.EventRetentionByType = map[string]time.Duration{}
+	if rules := os.Getenv("EVENT_RETENTION_BY_TYPE"); rules != "" {
+		for _, rule := range strings.Split(rules, ",") {
+			eventType, value, ok := strings.Cut(rule, "=")
+			retention, err := ParseRetention(value)
+			if !ok || err != nil || strings.TrimSpace(eventType) == "" {
+				log.Printf("Invalid EVENT_RETENTION_BY_TYPE rule %q, ignoring", rule)
+				continue
+			}
+			config.EventRetentionByType[strings.TrimSpace(eventType)] = retention
+		}
+	}
+	config.EventRollups = getEnvBool("EVENT_ROLLUPS", true)
+	config.TracerouteRetention = getEnvRetention("TRACEROUTE_RETENTION", 30*24*time.Hour)
+	config.LatencyRetention = getEnvRetention("LATENCY_RETENTION", 365*24*time.Hour)
+	config.StatusHistoryRetention = getEnvRetention("STATUS_HISTORY_RETENTION", 365*24*time.Hour)
+	config.SystemStatusRetention = getEnvRetention("SYSTEM_STATUS_RETENTION", 30*24*time.Hour)
+	config.IPMACBindingRetention = getEnvRetention("IP_MAC_BINDING_RETENTION", 7*24*time.Hour)
+
+	// Configure router and DHCP server detection
+	config.InfraProbeInterval = getEnvDuration("INFRA_PROBE_INTERVAL", 5*time.Minute)
+	config.AllowedRouters = getEnvList("ALLOWED_ROUTERS")
+	config.AllowedDHCPServers = getEnvList("ALLOWED_DHCP_SERVERS")
+
+	// Configure ARP spoofing and address conflict detection
+	config.ConflictWindow = getEnvDuration("CONFLICT_WINDOW", 15*time.Minute)
+	config.ConflictMaxIPsPerMAC = getEnvInt("CONFLICT_MAX_IPS_PER_MAC", 8)
+	config.MultiHomedHosts = getEnvList("MULTIHOMED_HOSTS")
+
+	// Configure Wake-on-LAN
+	config.WakeOnLANPort = getEnvInt("WOL_PORT", 9)
+	config.WakeConfirmTimeout = getEnvDuration("WOL_CONFIRM_TIMEOUT", 2*time.Minute)
+
+	// Configure scan rate limiting and windows
+	config.ScanRateLimit = getEnvInt("SCAN_RATE_LIMIT", 0)
+	config.ScanRateBurst = getEnvInt("SCAN_RATE_BURST", 0)
+	config.ScanWindows = os.Getenv("SCAN_WINDOWS")
+
 	return config, nil
 }
+
+// getEnvList reads a comma-separated list from the environment
+func getEnvList(name string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(name), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// getEnvDuration reads a duration such as "15m" from the environment
+func getEnvDuration(name string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return defaultValue
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Invalid %s value %q, using default %s", name, value, defaultValue)
+		return defaultValue
+	}
+	return duration
+}
+
+// ParseRetention parses a retention period given as a Go duration or a number
+// of days such as "90d". "0" keeps rows forever.
+func ParseRetention(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if value == "0" {
+		return 0, nil
+	}
+	if strings.HasSuffix(value, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(value, "d"))
+		if err == nil && days >= 0 {
+			return time.Duration(days) * 24 * time.Hour, nil
+		}
+	}
+	retention, err := time.ParseDuration(value)
+	if err != nil || retention < 0 {
+		return 0, fmt.Errorf("invalid retention %q", value)
+	}
+	return retention, nil
+}
+
+// getEnvRetention reads a retention period from the environment
+func getEnvRetention(name string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return defaultValue
+	}
+	retention, err := ParseRetention(value)
+	if err != nil {
+		log.Printf("Invalid %s value %q, using default %s", name, value, defaultValue)
+		return defaultValue
+	}
+	return retention
+}
+
+// getEnvBool reads a boolean from the environment
+func getEnvBool(name string, defaultValue bool) bool {
+	value := os.Getenv(name)
+	if value == "" {
+		return defaultValue
+	}
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Invalid %s value %q, using default %t", name, value, defaultValue)
+		return defaultValue
+	}
+	return enabled
+}
+
+// getEnvInt reads an integer from the environment
+func getEnvInt(name string, defaultValue int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return defaultValue
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Invalid %s value %q, using default %d", name, value, defaultValue)
+		return defaultValue
+	}
+	return number
+}
+
+// getEnvFloat reads a floating point number from the environment
+func getEnvFloat(name string, defaultValue float64) float64 {
+	value := os.Getenv(name)
+	if value == "" {
+		return defaultValue
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Printf("Invalid %s value %q, using default %g", name, value, defaultValue)
+		return defaultValue
+	}
+	return number
+}
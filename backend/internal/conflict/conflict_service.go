@@ -0,0 +1,211 @@
+package conflict
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"reconya-ai/db"
+	"reconya-ai/internal/config"
+	"reconya-ai/internal/eventlog"
+	"reconya-ai/internal/network"
+	"reconya-ai/models"
+)
+
+// ConflictService keeps the recent IPv4 to MAC address bindings seen by
+// sweeps and in the neighbor table and raises an Alert when an address moves
+// to another MAC address within the window, most urgently the gateway's, or
+// when one MAC address answers for too many addresses
+type ConflictService struct {
+	repository      db.IPMACBindingRepository
+	networkService  *network.NetworkService
+	eventLogService *eventlog.EventLogService
+	window          time.Duration
+	maxIPsPerMAC    int
+	// multiHomed holds the IP and MAC addresses of hosts with several
+	// interfaces, whose bindings legitimately move and multiply
+	multiHomed map[string]bool
+	// Gateways returns the IPv4 addresses of the default gateways
+	Gateways func() []string
+	// alerted holds when each conflict was last reported, so that it is
+	// reported once per window
+	alerted map[string]time.Time
+	mu      sync.Mutex
+}
+
+func NewConflictService(repository db.IPMACBindingRepository, networkService *network.NetworkService, eventLogService *eventlog.EventLogService, cfg *config.Config) *ConflictService {
+	multiHomed := make(map[string]bool)
+	for _, host := range cfg.MultiHomedHosts {
+		multiHomed[strings.ToUpper(host)] = true
+	}
+	return &ConflictService{
+		repository:      repository,
+		networkService:  networkService,
+		eventLogService: eventLogService,
+		window:          cfg.ConflictWindow,
+		maxIPsPerMAC:    cfg.ConflictMaxIPsPerMAC,
+		multiHomed:      multiHomed,
+		Gateways:        defaultGateways,
+		alerted:         make(map[string]time.Time),
+	}
+}
+
+// ObserveSweep records the bindings of the devices a sweep of a network found
+func (s *ConflictService) ObserveSweep(networkID string, devices []*models.Device) {
+	for _, device := range devices {
+		if device.IPv4 == "" || device.MAC == nil || *device.MAC == "" {
+			continue
+		}
+		if err := s.Observe(networkID, device.IPv4, *device.MAC, models.BindingSourceSweep); err != nil {
+			log.Printf("Failed to check %s for address conflicts: %v", device.IPv4, err)
+		}
+	}
+}
+
+// Observe records that ip resolved to mac and raises an Alert for the
+// conflicts this reveals. networkID may be empty, in which case it is looked
+// up from the address.
+func (s *ConflictService) Observe(networkID, ip, mac, source string) error {
+	parsed := net.ParseIP(ip)
+	if parsed == nil || parsed.To4() == nil {
+		return nil
+	}
+	mac = strings.ToUpper(mac)
+	if mac == "" || mac == "00:00:00:00:00:00" || mac == "FF:FF:FF:FF:FF:FF" {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ctx := context.Background()
+	now := time.Now()
+	since := now.Add(-s.window)
+
+	previous, err := s.repository.FindByIP(ctx, ip, since)
+	if err != nil {
+		return fmt.Errorf("failed to load bindings of %s: %v", ip, err)
+	}
+	if networkID == "" {
+		networkID = s.networkFor(ip)
+	}
+	binding := &models.IPMACBinding{IP: ip, MAC: mac, NetworkID: networkID, Source: source, FirstSeenAt: now, LastSeenAt: now}
+	if err := s.repository.Record(ctx, binding); err != nil {
+		return fmt.Errorf("failed to record binding of %s: %v", ip, err)
+	}
+
+	// Bindings are most recent first, so this is the MAC the address had
+	// before, unless it already flips back and forth
+	for _, other := range previous {
+		if other.MAC == mac {
+			continue
+		}
+		s.checkMoved(binding, other.MAC, now)
+		break
+	}
+
+	if s.maxIPsPerMAC <= 0 || s.multiHomed[mac] {
+		return nil
+	}
+	claimed, err := s.repository.FindByMAC(ctx, mac, since)
+	if err != nil {
+		return fmt.Errorf("failed to load bindings of %s: %v", mac, err)
+	}
+	if len(claimed) < s.maxIPsPerMAC {
+		return nil
+	}
+	ips := make([]string, 0, len(claimed))
+	for _, other := range claimed {
+		ips = append(ips, other.IP)
+	}
+	sort.Strings(ips)
+	if !s.shouldAlert(models.AlertSourceMACConflict+"|"+mac, now) {
+		return nil
+	}
+	payload := &models.AlertPayload{Source: models.AlertSourceMACConflict, NetworkID: networkID, MAC: mac, IPs: ips}
+	description := fmt.Sprintf("MAC address %s answered for %d IP addresses within %s, possible ARP spoofing", mac, len(ips), s.window)
+	s.alert(description, payload)
+	return nil
+}
+
+// checkMoved raises an Alert for an address that moved to another MAC
+// address, unless it belongs to a multi-homed host
+func (s *ConflictService) checkMoved(binding *models.IPMACBinding, previousMAC string, now time.Time) {
+	if s.multiHomed[binding.IP] || (s.multiHomed[binding.MAC] && s.multiHomed[previousMAC]) {
+		return
+	}
+
+	pair := []string{binding.MAC, previousMAC}
+	sort.Strings(pair)
+	source := models.AlertSourceIPConflict
+	description := fmt.Sprintf("IP address %s moved from MAC address %s to %s, duplicate address or ARP spoofing", binding.IP, previousMAC, binding.MAC)
+	if s.isGateway(binding.IP) {
+		source = models.AlertSourceGatewayMAC
+		description = fmt.Sprintf("Gateway %s changed MAC address from %s to %s, possible ARP spoofing", binding.IP, previousMAC, binding.MAC)
+	}
+	if !s.shouldAlert(source+"|"+binding.IP+"|"+strings.Join(pair, "|"), now) {
+		return
+	}
+
+	payload := &models.AlertPayload{
+		Source:      source,
+		NetworkID:   binding.NetworkID,
+		IP:          binding.IP,
+		MAC:         binding.MAC,
+		PreviousMAC: previousMAC,
+	}
+	s.alert(description, payload)
+}
+
+// shouldAlert reports whether a conflict was not reported within the window
+// and marks it reported
+func (s *ConflictService) shouldAlert(key string, now time.Time) bool {
+	if last, ok := s.alerted[key]; ok && now.Sub(last) < s.window {
+		return false
+	}
+	s.alerted[key] = now
+	for other, last := range s.alerted {
+		if now.Sub(last) >= s.window {
+			delete(s.alerted, other)
+		}
+	}
+	return true
+}
+
+func (s *ConflictService) alert(description string, payload *models.AlertPayload) {
+	log.Printf("Address conflict: %s", description)
+	if err := s.eventLogService.Log(models.Alert, description, "", payload); err != nil {
+		log.Printf("Failed to log address conflict alert: %v", err)
+	}
+}
+
+func (s *ConflictService) isGateway(ip string) bool {
+	if s.Gateways == nil {
+		return false
+	}
+	for _, gateway := range s.Gateways() {
+		if gateway == ip {
+			return true
+		}
+	}
+	return false
+}
+
+// networkFor returns the ID of the network containing ip, or ""
+func (s *ConflictService) networkFor(ip string) string {
+	networks, err := s.networkService.FindAll()
+	if err != nil {
+		return ""
+	}
+	for i := range networks {
+		if networks[i].ContainsIPv4(ip) {
+			return networks[i].ID
+		}
+	}
+	return ""
+}
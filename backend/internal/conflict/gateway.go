@@ -0,0 +1,43 @@
+package conflict
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// defaultGateways returns the gateways of the IPv4 default routes. It reads
+// the Linux routing table and returns nothing on other platforms.
+func defaultGateways() []string {
+	file, err := os.Open("/proc/net/route")
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+	return parseRouteTable(file)
+}
+
+// parseRouteTable reads /proc/net/route, where addresses are hexadecimal in
+// host byte order
+func parseRouteTable(r io.Reader) []string {
+	var gateways []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 || fields[1] != "00000000" {
+			continue
+		}
+		value, err := strconv.ParseUint(fields[2], 16, 32)
+		if err != nil || value == 0 {
+			continue
+		}
+		ip := make(net.IP, net.IPv4len)
+		binary.NativeEndian.PutUint32(ip, uint32(value))
+		gateways = append(gateways, ip.String())
+	}
+	return gateways
+}
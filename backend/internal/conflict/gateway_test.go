@@ -0,0 +1,24 @@
+package conflict
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRouteTable(t *testing.T) {
+	hex := func(ip string) string {
+		return fmt.Sprintf("%08X", binary.NativeEndian.Uint32(net.ParseIP(ip).To4()))
+	}
+	table := "Iface\tDestination\tGateway \tFlags\tRefCnt\tUse\tMetric\tMask\t\tMTU\tWindow\tIRTT\n" +
+		"eth0\t00000000\t" + hex("192.168.1.1") + "\t0003\t0\t0\t100\t00000000\t0\t0\t0\n" +
+		"eth0\t" + hex("192.168.1.0") + "\t00000000\t0001\t0\t0\t100\t00FFFFFF\t0\t0\t0\n" +
+		"wg0\t00000000\t00000000\t0001\t0\t0\t200\t00000000\t0\t0\t0\n"
+
+	assert.Equal(t, []string{"192.168.1.1"}, parseRouteTable(strings.NewReader(table)))
+	assert.Empty(t, parseRouteTable(strings.NewReader("")))
+}
@@ -12,7 +12,7 @@ import (
 	"reconya-ai/internal/fingerprint"
 	"reconya-ai/internal/network"
 	"reconya-ai/internal/oui"
-	"reconya-ai/internal/util"
+	"reconya-ai/internal/ratelimit"
 	"reconya-ai/models"
 	"sort"
 	"strings"
@@ -23,17 +23,15 @@ type DeviceService struct {
 	Config             *config.Config
 	repository         db.DeviceRepository
 	networkService     *network.NetworkService
-	dbManager          *db.DBManager
 	fingerprintService *fingerprint.FingerprintService
 	ouiService         *oui.OUIService
 }
 
-func NewDeviceService(deviceRepo db.DeviceRepository, networkService *network.NetworkService, cfg *config.Config, dbManager *db.DBManager, ouiService *oui.OUIService) *DeviceService {
+func NewDeviceService(deviceRepo db.DeviceRepository, networkService *network.NetworkService, cfg *config.Config, ouiService *oui.OUIService) *DeviceService {
 	return &DeviceService{
 		Config:             cfg,
 		repository:         deviceRepo,
 		networkService:     networkService,
-		dbManager:          dbManager,
 		fingerprintService: fingerprint.NewFingerprintService(),
 		ouiService:         ouiService,
 	}
@@ -64,32 +62,182 @@ func (s *DeviceService) CreateOrUpdate(device *models.Device) (*models.Device, e
 		return nil, fmt.Errorf("network or broadcast address not allowed: %s", device.IPv4)
 	}
 
-	// First try to find device by IP address
-	existingDevice, err := s.FindByIPv4(device.IPv4)
-	if err != nil && err != db.ErrNotFound {
+	existingDevice, err := s.FindExisting(device.IPv4, device.MAC)
+	if err != nil {
 		return nil, err
 	}
 
-	// If no device found by IP and we have a MAC address, try to find by MAC
-	// This handles cases where a device changes IP but keeps the same MAC (DHCP reassignment)
-	if existingDevice == nil && device.MAC != nil && *device.MAC != "" {
-		existingByMAC, err := s.FindDeviceByMAC(*device.MAC)
-		if err == nil && existingByMAC != nil {
-			log.Printf("Found existing device by MAC %s, updating IP from %s to %s",
-				*device.MAC, existingByMAC.IPv4, device.IPv4)
+	if existingDevice != nil && existingDevice.IPv4 != device.IPv4 {
+		log.Printf("Found existing device by MAC %s, updating IP from %s to %s",
+			*device.MAC, existingDevice.IPv4, device.IPv4)
+	}
+	s.mergeExisting(device, existingDevice, currentTime)
+
+	return s.repository.CreateOrUpdate(context.Background(), device)
+}
+
+// SaveSweep stores the devices found by a sweep of a network in a single
+// transaction. It applies the same rules as CreateOrUpdate but loads the known
+// devices once for the whole sweep instead of looking each device up. Known
+// devices only get the fields a sweep owns written back, so changes saved
+// while the sweep ran are kept.
+// Network and broadcast addresses are skipped; the saved devices are returned.
+func (s *DeviceService) SaveSweep(networkID string, devices []*models.Device) ([]*models.Device, error) {
+	network, err := s.networkService.FindByID(networkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find network: %v", err)
+	}
+	if network == nil {
+		return nil, fmt.Errorf("network not found")
+	}
+
+	ctx := context.Background()
+	known, err := s.repository.FindAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load devices: %v", err)
+	}
+	byIP := make(map[string]*models.Device, len(known))
+	byMAC := make(map[string]*models.Device, len(known))
+	for _, d := range known {
+		if d.IPv4 != "" {
+			byIP[d.IPv4] = d
+		}
+		if d.MAC != nil && *d.MAC != "" {
+			byMAC[strings.ToLower(*d.MAC)] = d
+		}
+	}
+
+	currentTime := time.Now()
+	batch := make([]*models.Device, 0, len(devices))
+	for _, device := range devices {
+		if s.isNetworkOrBroadcastAddress(device.IPv4, network.CIDR) {
+			log.Printf("Skipping network/broadcast address: %s", device.IPv4)
+			continue
+		}
+
+		device.NetworkID = network.ID
+		device.LastSeenOnlineAt = &currentTime
+
+		existingDevice := byIP[device.IPv4]
+		if existingDevice == nil && device.MAC != nil && *device.MAC != "" {
+			existingDevice = byMAC[strings.ToLower(*device.MAC)]
+		}
+		s.mergeExisting(device, existingDevice, currentTime)
+		batch = append(batch, device)
+	}
+
+	if err := s.repository.SaveSweepBatch(ctx, batch); err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
 
-			// Update the existing device's IP address and other fields
-			existingByMAC.IPv4 = device.IPv4
-			existingByMAC.Hostname = device.Hostname
-			existingByMAC.Vendor = device.Vendor
-			existingByMAC.NetworkID = device.NetworkID
-			existingByMAC.LastSeenOnlineAt = &currentTime
-			existingByMAC.Status = models.DeviceStatusOnline
+// SaveIPv6Sweep stores the hosts found by IPv6 discovery of a network in a
+// single transaction. Hosts are matched to known devices by MAC address, then
+// by any of their IPv6 addresses; known devices get the addresses found this
+// time, dropping those that weren't, and are marked online. Unmatched hosts
+// are added as IPv6-only devices, which have no IPv4 address. The saved
+// devices are returned.
+func (s *DeviceService) SaveIPv6Sweep(networkID string, devices []*models.Device) ([]*models.Device, error) {
+	network, err := s.networkService.FindByID(networkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find network: %v", err)
+	}
+	if network == nil {
+		return nil, fmt.Errorf("network not found")
+	}
 
-			existingDevice = existingByMAC
-			// Set the device ID to the existing device to ensure we update rather than create
-			device.ID = existingDevice.ID
+	ctx := context.Background()
+	known, err := s.repository.FindAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load devices: %v", err)
+	}
+	byMAC := make(map[string]*models.Device, len(known))
+	byIPv6 := make(map[string]*models.Device, len(known))
+	for _, d := range known {
+		if d.MAC != nil && *d.MAC != "" {
+			byMAC[strings.ToLower(*d.MAC)] = d
 		}
+		for _, address := range d.GetAllIPv6Addresses() {
+			byIPv6[address] = d
+		}
+	}
+
+	currentTime := time.Now()
+	saved := make(map[*models.Device]bool)
+	// seen collects the addresses found for each known device; they replace
+	// its addresses once every host has been matched
+	seen := make(map[*models.Device]*models.Device)
+	batch := make([]*models.Device, 0, len(devices))
+	for _, device := range devices {
+		var existingDevice *models.Device
+		if device.MAC != nil && *device.MAC != "" {
+			existingDevice = byMAC[strings.ToLower(*device.MAC)]
+		}
+		if existingDevice == nil {
+			for _, address := range device.GetAllIPv6Addresses() {
+				if existingDevice = byIPv6[address]; existingDevice != nil {
+					break
+				}
+			}
+		}
+
+		if existingDevice != nil {
+			if seen[existingDevice] == nil {
+				seen[existingDevice] = &models.Device{}
+			}
+			seen[existingDevice].MergeIPv6Addresses(device)
+			if existingDevice.MAC == nil && device.MAC != nil {
+				existingDevice.MAC = device.MAC
+			}
+			if existingDevice.DeviceType == "" {
+				existingDevice.DeviceType = device.DeviceType
+			}
+			existingDevice.Status = models.DeviceStatusOnline
+			existingDevice.LastSeenOnlineAt = &currentTime
+			existingDevice.UpdatedAt = currentTime
+			if !saved[existingDevice] {
+				saved[existingDevice] = true
+				batch = append(batch, existingDevice)
+			}
+			continue
+		}
+
+		device.ID = ""
+		device.IPv4 = ""
+		device.NetworkID = network.ID
+		device.LastSeenOnlineAt = &currentTime
+		s.mergeExisting(device, nil, currentTime)
+		if device.MAC != nil && device.Vendor == nil {
+			if vendor := s.LookupVendor(*device.MAC); vendor != "" {
+				device.Vendor = &vendor
+			}
+		}
+		if device.MAC != nil {
+			byMAC[strings.ToLower(*device.MAC)] = device
+		}
+		for _, address := range device.GetAllIPv6Addresses() {
+			byIPv6[address] = device
+		}
+		saved[device] = true
+		batch = append(batch, device)
+	}
+	for existingDevice, addresses := range seen {
+		existingDevice.ReplaceIPv6Addresses(addresses)
+	}
+
+	if err := s.repository.SaveSweepBatch(ctx, batch); err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+// mergeExisting carries the identity and user-set fields of the known device
+// over to an incoming one
+func (s *DeviceService) mergeExisting(device, existingDevice *models.Device, currentTime time.Time) {
+	if existingDevice != nil {
+		// Set the device ID to the existing device to ensure we update rather than create
+		device.ID = existingDevice.ID
 	}
 
 	s.setTimestamps(device, existingDevice, currentTime)
@@ -107,12 +255,36 @@ func (s *DeviceService) CreateOrUpdate(device *models.Device) (*models.Device, e
 		if (device.Comment == nil || *device.Comment == "") && existingDevice.Comment != nil && *existingDevice.Comment != "" {
 			device.Comment = existingDevice.Comment
 		}
+		if device.Tags == nil {
+			device.Tags = existingDevice.Tags
+		}
 	}
 
 	// Leave device name empty if not explicitly set
+}
+
+// FindExisting returns the known device with the given identity. Devices are
+// matched by IP address first, then by MAC address so that a device which got
+// a new IP address (DHCP reassignment) is still recognised.
+func (s *DeviceService) FindExisting(ipv4 string, mac *string) (*models.Device, error) {
+	if ipv4 != "" {
+		existingDevice, err := s.FindByIPv4(ipv4)
+		if err != nil && err != db.ErrNotFound {
+			return nil, err
+		}
+		if existingDevice != nil {
+			return existingDevice, nil
+		}
+	}
+
+	if mac != nil && *mac != "" {
+		existingByMAC, err := s.FindDeviceByMAC(*mac)
+		if err == nil && existingByMAC != nil {
+			return existingByMAC, nil
+		}
+	}
 
-	// Use DB manager to serialize database access
-	return s.dbManager.CreateOrUpdateDevice(s.repository, context.Background(), device)
+	return nil, nil
 }
 
 func (s *DeviceService) setTimestamps(device, existingDevice *models.Device, currentTime time.Time) {
@@ -266,6 +438,11 @@ func (s *DeviceService) EligibleForPortScan(device *models.Device) bool {
 		return false
 	}
 
+	// Port scans target the IPv4 address
+	if device.IPv4 == "" {
+		return false
+	}
+
 	now := time.Now()
 	if device.PortScanEndedAt != nil && device.PortScanEndedAt.Add(30*time.Second).After(now) {
 		return false
@@ -446,11 +623,7 @@ func (s *DeviceService) FindAllForNetwork(cidr string) ([]models.Device, error)
 			// This is a workaround for existing data
 			d.NetworkID = network.ID
 
-			// Use retry logic for updating the device
-			_, err := util.RetryOnLockWithResult(func() (*models.Device, error) {
-				return s.repository.CreateOrUpdate(context.Background(), d)
-			})
-
+			_, err := s.repository.CreateOrUpdate(context.Background(), d)
 			if err != nil {
 				log.Printf("Error updating device network ID: %v", err)
 			} else {
@@ -510,11 +683,7 @@ func (s *DeviceService) FindOnlineDevicesForNetwork(cidr string) ([]models.Devic
 			// If device has no network ID but belongs to the current network
 			d.NetworkID = network.ID
 
-			// Use retry logic for updating the device
-			_, err := util.RetryOnLockWithResult(func() (*models.Device, error) {
-				return s.repository.CreateOrUpdate(context.Background(), d)
-			})
-
+			_, err := s.repository.CreateOrUpdate(context.Background(), d)
 			if err != nil {
 				log.Printf("Error updating device network ID: %v", err)
 			}
@@ -564,13 +733,25 @@ func (s *DeviceService) isNetworkOrBroadcastAddress(ipStr, cidrStr string) bool
 	return false
 }
 
-func (s *DeviceService) UpdateDeviceStatuses() error {
+// FindActivity returns the status inputs of all online and idle devices
+func (s *DeviceService) FindActivity() ([]*models.DeviceActivity, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return s.repository.FindActivity(ctx)
+}
+
+// ApplyStatusTransitions stores status changes decided by the status engine
+// and returns the ones that were applied
+func (s *DeviceService) ApplyStatusTransitions(transitions []*models.DeviceStatusTransition) ([]*models.DeviceStatusTransition, error) {
+	if len(transitions) == 0 {
+		return nil, nil
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	// Use DB manager to serialize database access
-	// Device status transitions: online -> idle after 1 minute, idle/online -> offline after 3 minutes
-	return s.dbManager.UpdateDeviceStatuses(s.repository, ctx, 3*time.Minute)
+	return s.repository.ApplyStatusTransitions(ctx, transitions)
 }
 
 // PerformDeviceFingerprinting analyzes device characteristics to determine type and OS
@@ -597,9 +778,46 @@ func (s *DeviceService) UpdateDevice(deviceID string, name *string, comment *str
 	return updatedDevice, nil
 }
 
-func (s *DeviceService) PerformDeviceFingerprinting(device *models.Device) {
+// UpdateTags replaces the tags of a device
+func (s *DeviceService) UpdateTags(deviceID string, tags []string) (*models.Device, error) {
+	ctx := context.Background()
+
+	device, err := s.repository.FindByID(ctx, deviceID)
+	if err != nil {
+		return nil, fmt.Errorf("device not found: %v", err)
+	}
+
+	device.Tags = models.NormalizeTags(tags)
+
+	updatedDevice, err := s.repository.CreateOrUpdate(ctx, device)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update device tags: %v", err)
+	}
+
+	return updatedDevice, nil
+}
+
+// SaveImported stores a device read from an import file. Unlike CreateOrUpdate
+// it doesn't mark the device as seen online.
+func (s *DeviceService) SaveImported(device *models.Device) (*models.Device, error) {
+	if device.Status == "" {
+		device.Status = models.DeviceStatusUnknown
+	}
+
+	return s.repository.CreateOrUpdate(context.Background(), device)
+}
+
+// LookupVendor returns the vendor registered for a MAC address, if known
+func (s *DeviceService) LookupVendor(mac string) string {
+	if s.ouiService == nil {
+		return ""
+	}
+	return s.ouiService.LookupVendor(mac)
+}
+
+func (s *DeviceService) PerformDeviceFingerprinting(device *models.Device, osDetection bool, limiter *ratelimit.Limiter) {
 	log.Printf("Starting device fingerprinting for %s", device.IPv4)
-	s.fingerprintService.AnalyzeDevice(device)
+	s.fingerprintService.AnalyzeDevice(device, osDetection, limiter)
 }
 
 // CleanupAllDeviceNames clears the names of all devices in the database
@@ -639,7 +857,7 @@ func (s *DeviceService) FindDeviceByMAC(macAddress string) (*models.Device, erro
 	}
 
 	for _, device := range devices {
-		if device.MAC != nil && *device.MAC == macAddress {
+		if device.MAC != nil && strings.EqualFold(*device.MAC, macAddress) {
 			return device, nil
 		}
 	}
@@ -714,6 +932,41 @@ func (s *DeviceService) CreateDevice(device *models.Device) error {
 	return err
 }
 
+// presenceRefreshInterval is how often MarkPresent rewrites an online device
+const presenceRefreshInterval = 30 * time.Second
+
+// MarkPresent records that the known device with address ipv4 was just seen
+// on the local link, for example in the neighbor table. It returns the device
+// and its status before, or nil for addresses of unknown devices.
+func (s *DeviceService) MarkPresent(ipv4, mac string) (*models.Device, models.DeviceStatus, error) {
+	device, err := s.FindByIPv4(ipv4)
+	if err != nil || device == nil {
+		return nil, "", err
+	}
+
+	previous := device.Status
+	now := time.Now()
+	if previous == models.DeviceStatusOnline && device.LastSeenOnlineAt != nil && now.Sub(*device.LastSeenOnlineAt) < presenceRefreshInterval {
+		return device, previous, nil
+	}
+
+	device.Status = models.DeviceStatusOnline
+	device.LastSeenOnlineAt = &now
+	if mac != "" && (device.MAC == nil || *device.MAC == "") {
+		device.MAC = &mac
+		if device.Vendor == nil {
+			if vendor := s.LookupVendor(mac); vendor != "" {
+				device.Vendor = &vendor
+			}
+		}
+	}
+
+	if err := s.UpdateDeviceRecord(device); err != nil {
+		return nil, "", fmt.Errorf("failed to mark device present: %v", err)
+	}
+	return device, previous, nil
+}
+
 func (s *DeviceService) UpdateDeviceRecord(device *models.Device) error {
 	device.UpdatedAt = time.Now()
 	_, err := s.repository.CreateOrUpdate(context.Background(), device)
@@ -0,0 +1,221 @@
+package devicestatus
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"reconya-ai/db"
+	"reconya-ai/internal/config"
+	"reconya-ai/internal/device"
+	"reconya-ai/internal/network"
+	"reconya-ai/models"
+)
+
+// Thresholds are the effective idle and offline timeouts of a device
+type Thresholds struct {
+	IdleAfter    time.Duration
+	OfflineAfter time.Duration
+	// Scopes that supplied each value, "global" for the configured defaults
+	IdleSource    string
+	OfflineSource string
+}
+
+// StatusEngine decides when online devices become idle and offline
+type StatusEngine struct {
+	repository     db.StatusThresholdRepository
+	deviceService  *device.DeviceService
+	networkService *network.NetworkService
+	defaults       Thresholds
+	scanStaleAfter time.Duration
+}
+
+func NewStatusEngine(repository db.StatusThresholdRepository, deviceService *device.DeviceService, networkService *network.NetworkService, cfg *config.Config) *StatusEngine {
+	return &StatusEngine{
+		repository:     repository,
+		deviceService:  deviceService,
+		networkService: networkService,
+		defaults: Thresholds{
+			IdleAfter:     cfg.DeviceIdleAfter,
+			OfflineAfter:  cfg.DeviceOfflineAfter,
+			IdleSource:    "global",
+			OfflineSource: "global",
+		},
+		scanStaleAfter: cfg.DeviceStatusScanStaleAfter,
+	}
+}
+
+// Evaluate demotes devices whose thresholds have passed and returns the applied transitions
+func (e *StatusEngine) Evaluate() ([]*models.DeviceStatusTransition, error) {
+	thresholds, err := e.repository.FindAll(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load status thresholds: %v", err)
+	}
+	index := IndexThresholds(thresholds)
+
+	activity, err := e.deviceService.FindActivity()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load device activity: %v", err)
+	}
+
+	lastScans, err := e.networkService.LastScannedTimes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load network scan times: %v", err)
+	}
+
+	now := time.Now()
+	var transitions []*models.DeviceStatusTransition
+	for _, a := range activity {
+		resolved := ResolveThresholds(e.defaults, index, a.DeviceID, a.DeviceType, a.NetworkID)
+
+		var lastScan *time.Time
+		if scannedAt, ok := lastScans[a.NetworkID]; ok {
+			lastScan = &scannedAt
+		}
+
+		next := NextStatus(a, resolved, lastScan, e.scanStaleAfter, now)
+		if next != a.Status {
+			transitions = append(transitions, &models.DeviceStatusTransition{
+				DeviceID: a.DeviceID,
+				From:     a.Status,
+				To:       next,
+				At:       now,
+			})
+		}
+	}
+
+	return e.deviceService.ApplyStatusTransitions(transitions)
+}
+
+// GetThresholds returns every configured override
+func (e *StatusEngine) GetThresholds() ([]*models.StatusThreshold, error) {
+	return e.repository.FindAll(context.Background())
+}
+
+// GetDefaults returns the global thresholds
+func (e *StatusEngine) GetDefaults() Thresholds {
+	return e.defaults
+}
+
+// SetThreshold creates or replaces an override
+func (e *StatusEngine) SetThreshold(threshold *models.StatusThreshold) error {
+	if !threshold.Scope.IsValid() {
+		return fmt.Errorf("invalid scope %q", threshold.Scope)
+	}
+	if threshold.ScopeID == "" {
+		return fmt.Errorf("scope_id is required")
+	}
+	if threshold.IdleAfterSeconds == nil && threshold.OfflineAfterSeconds == nil {
+		return fmt.Errorf("idle_after_seconds or offline_after_seconds is required")
+	}
+	if threshold.IdleAfterSeconds != nil && *threshold.IdleAfterSeconds <= 0 {
+		return fmt.Errorf("idle_after_seconds must be positive")
+	}
+	if threshold.OfflineAfterSeconds != nil && *threshold.OfflineAfterSeconds <= 0 {
+		return fmt.Errorf("offline_after_seconds must be positive")
+	}
+	if threshold.IdleAfterSeconds != nil && threshold.OfflineAfterSeconds != nil &&
+		*threshold.IdleAfterSeconds > *threshold.OfflineAfterSeconds {
+		return fmt.Errorf("idle_after_seconds must not exceed offline_after_seconds")
+	}
+
+	return e.repository.Save(context.Background(), threshold)
+}
+
+// DeleteThreshold removes an override
+func (e *StatusEngine) DeleteThreshold(scope models.ThresholdScope, scopeID string) error {
+	return e.repository.Delete(context.Background(), scope, scopeID)
+}
+
+// EffectiveThresholds resolves the thresholds that currently apply to a device
+func (e *StatusEngine) EffectiveThresholds(d *models.Device) (Thresholds, error) {
+	thresholds, err := e.repository.FindAll(context.Background())
+	if err != nil {
+		return Thresholds{}, fmt.Errorf("failed to load status thresholds: %v", err)
+	}
+	return ResolveThresholds(e.defaults, IndexThresholds(thresholds), d.ID, d.DeviceType, d.NetworkID), nil
+}
+
+type thresholdKey struct {
+	scope   models.ThresholdScope
+	scopeID string
+}
+
+// ThresholdIndex looks up overrides by scope
+type ThresholdIndex map[thresholdKey]*models.StatusThreshold
+
+// IndexThresholds builds a ThresholdIndex
+func IndexThresholds(thresholds []*models.StatusThreshold) ThresholdIndex {
+	index := make(ThresholdIndex, len(thresholds))
+	for _, t := range thresholds {
+		index[thresholdKey{t.Scope, t.ScopeID}] = t
+	}
+	return index
+}
+
+// ResolveThresholds applies overrides with precedence device, device type,
+// network, global. Idle and offline are resolved independently and idle is
+// capped at offline.
+func ResolveThresholds(defaults Thresholds, index ThresholdIndex, deviceID string, deviceType models.DeviceType, networkID string) Thresholds {
+	resolved := defaults
+
+	// Broadest scope first so narrower ones win
+	scopes := []thresholdKey{
+		{models.ThresholdScopeNetwork, networkID},
+		{models.ThresholdScopeDeviceType, string(deviceType)},
+		{models.ThresholdScopeDevice, deviceID},
+	}
+	for _, key := range scopes {
+		if key.scopeID == "" {
+			continue
+		}
+		t, ok := index[key]
+		if !ok {
+			continue
+		}
+		if t.IdleAfterSeconds != nil {
+			resolved.IdleAfter = time.Duration(*t.IdleAfterSeconds) * time.Second
+			resolved.IdleSource = string(key.scope)
+		}
+		if t.OfflineAfterSeconds != nil {
+			resolved.OfflineAfter = time.Duration(*t.OfflineAfterSeconds) * time.Second
+			resolved.OfflineSource = string(key.scope)
+		}
+	}
+
+	if resolved.IdleAfter > resolved.OfflineAfter {
+		resolved.IdleAfter = resolved.OfflineAfter
+	}
+	return resolved
+}
+
+// NextStatus returns the status a device should have now. Devices are only
+// demoted here; they come back online when a scan sees them.
+//
+// Networks are swept at different cadences. When the network of a device is
+// being swept (its last sweep is more recent than scanStaleAfter), the device
+// keeps its status until a sweep that started after it was last seen missed
+// it, so slow sweeps don't make devices flap between sweeps.
+func NextStatus(a *models.DeviceActivity, thresholds Thresholds, lastScan *time.Time, scanStaleAfter time.Duration, now time.Time) models.DeviceStatus {
+	if a.LastSeenOnlineAt == nil {
+		return a.Status
+	}
+
+	unseen := now.Sub(*a.LastSeenOnlineAt)
+	next := a.Status
+	switch {
+	case unseen >= thresholds.OfflineAfter:
+		next = models.DeviceStatusOffline
+	case unseen >= thresholds.IdleAfter && a.Status == models.DeviceStatusOnline:
+		next = models.DeviceStatusIdle
+	}
+	if next == a.Status {
+		return a.Status
+	}
+
+	if lastScan != nil && now.Sub(*lastScan) < scanStaleAfter && !lastScan.After(*a.LastSeenOnlineAt) {
+		return a.Status
+	}
+
+	return next
+}
@@ -0,0 +1,93 @@
+package devicestatus
+
+import (
+	"testing"
+	"time"
+
+	"reconya-ai/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var defaults = Thresholds{
+	IdleAfter:     time.Minute,
+	OfflineAfter:  3 * time.Minute,
+	IdleSource:    "global",
+	OfflineSource: "global",
+}
+
+func seconds(n int) *int {
+	return &n
+}
+
+func TestResolveThresholds_Precedence(t *testing.T) {
+	index := IndexThresholds([]*models.StatusThreshold{
+		{Scope: models.ThresholdScopeNetwork, ScopeID: "net-1", IdleAfterSeconds: seconds(300), OfflineAfterSeconds: seconds(1800)},
+		{Scope: models.ThresholdScopeDeviceType, ScopeID: string(models.DeviceTypeMobile), OfflineAfterSeconds: seconds(7200)},
+		{Scope: models.ThresholdScopeDevice, ScopeID: "dev-1", IdleAfterSeconds: seconds(30)},
+	})
+
+	// Device override for idle, device type for offline
+	resolved := ResolveThresholds(defaults, index, "dev-1", models.DeviceTypeMobile, "net-1")
+	assert.Equal(t, 30*time.Second, resolved.IdleAfter)
+	assert.Equal(t, "device", resolved.IdleSource)
+	assert.Equal(t, 2*time.Hour, resolved.OfflineAfter)
+	assert.Equal(t, "device_type", resolved.OfflineSource)
+
+	// Network applies when nothing narrower matches
+	resolved = ResolveThresholds(defaults, index, "dev-2", models.DeviceTypeServer, "net-1")
+	assert.Equal(t, 5*time.Minute, resolved.IdleAfter)
+	assert.Equal(t, 30*time.Minute, resolved.OfflineAfter)
+	assert.Equal(t, "network", resolved.OfflineSource)
+
+	// Falls back to the global defaults
+	resolved = ResolveThresholds(defaults, index, "dev-3", models.DeviceTypeServer, "net-2")
+	assert.Equal(t, defaults, resolved)
+}
+
+func TestResolveThresholds_IdleCappedAtOffline(t *testing.T) {
+	index := IndexThresholds([]*models.StatusThreshold{
+		{Scope: models.ThresholdScopeDevice, ScopeID: "dev-1", OfflineAfterSeconds: seconds(30)},
+	})
+
+	resolved := ResolveThresholds(defaults, index, "dev-1", "", "")
+	assert.Equal(t, 30*time.Second, resolved.IdleAfter)
+	assert.Equal(t, 30*time.Second, resolved.OfflineAfter)
+}
+
+func TestNextStatus(t *testing.T) {
+	now := time.Now()
+	seenAgo := func(d time.Duration) *models.DeviceActivity {
+		lastSeen := now.Add(-d)
+		return &models.DeviceActivity{Status: models.DeviceStatusOnline, LastSeenOnlineAt: &lastSeen}
+	}
+
+	assert.Equal(t, models.DeviceStatusOnline, NextStatus(seenAgo(30*time.Second), defaults, nil, time.Hour, now))
+	assert.Equal(t, models.DeviceStatusIdle, NextStatus(seenAgo(2*time.Minute), defaults, nil, time.Hour, now))
+	assert.Equal(t, models.DeviceStatusOffline, NextStatus(seenAgo(5*time.Minute), defaults, nil, time.Hour, now))
+
+	idle := seenAgo(2 * time.Minute)
+	idle.Status = models.DeviceStatusIdle
+	assert.Equal(t, models.DeviceStatusIdle, NextStatus(idle, defaults, nil, time.Hour, now))
+
+	never := &models.DeviceActivity{Status: models.DeviceStatusOnline}
+	assert.Equal(t, models.DeviceStatusOnline, NextStatus(never, defaults, nil, time.Hour, now))
+}
+
+func TestNextStatus_ScanCadence(t *testing.T) {
+	now := time.Now()
+	lastSeen := now.Add(-10 * time.Minute)
+	activity := &models.DeviceActivity{Status: models.DeviceStatusOnline, LastSeenOnlineAt: &lastSeen}
+
+	// Network swept every 15 minutes; no sweep has missed the device yet
+	sweepBefore := lastSeen.Add(-time.Second)
+	assert.Equal(t, models.DeviceStatusOnline, NextStatus(activity, defaults, &sweepBefore, time.Hour, now))
+
+	// A later sweep did not see the device
+	sweepAfter := now.Add(-time.Minute)
+	assert.Equal(t, models.DeviceStatusOffline, NextStatus(activity, defaults, &sweepAfter, time.Hour, now))
+
+	// Sweeps stopped long ago, plain thresholds apply
+	staleSweep := now.Add(-2 * time.Hour)
+	assert.Equal(t, models.DeviceStatusOffline, NextStatus(activity, defaults, &staleSweep, time.Hour, now))
+}
@@ -0,0 +1,101 @@
+package eventlog
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"reconya-ai/internal/export"
+	"reconya-ai/models"
+)
+
+// exportRecord is one exported event; CSV columns follow the field order
+type exportRecord struct {
+	ID          int64                `json:"id"`
+	CreatedAt   string               `json:"created_at"`
+	Type        models.EEventLogType `json:"type"`
+	Severity    models.EventSeverity `json:"severity"`
+	Description string               `json:"description"`
+	DeviceID    string               `json:"device_id,omitempty"`
+	DeviceIP    string               `json:"device_ip,omitempty"`
+	Payload     json.RawMessage      `json:"payload,omitempty"`
+}
+
+var exportHeader = []string{"id", "created_at", "type", "severity", "description", "device_id", "device_ip", "payload"}
+
+// ExportFilename returns the download file name of an event log export
+func ExportFilename(format export.Format, at time.Time) string {
+	return fmt.Sprintf("reconya-events-%s.%s", at.Format("20060102-150405"), format.Extension())
+}
+
+// Export writes every event log matching filter, newest first, as CSV or
+// NDJSON. Events are read a page at a time, so large exports stream.
+func (s *EventLogService) Export(w io.Writer, format export.Format, filter models.EventLogFilter) (int, error) {
+	var write func(record exportRecord) error
+	var flush func() error
+
+	switch format {
+	case export.FormatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(exportHeader); err != nil {
+			return 0, err
+		}
+		write = func(record exportRecord) error {
+			return writer.Write([]string{
+				strconv.FormatInt(record.ID, 10), record.CreatedAt, string(record.Type), string(record.Severity),
+				record.Description, record.DeviceID, record.DeviceIP, string(record.Payload),
+			})
+		}
+		flush = func() error {
+			writer.Flush()
+			return writer.Error()
+		}
+	case export.FormatNDJSON:
+		encoder := json.NewEncoder(w)
+		write = func(record exportRecord) error {
+			return encoder.Encode(record)
+		}
+		flush = func() error { return nil }
+	default:
+		return 0, fmt.Errorf("event logs cannot be exported as %s", format)
+	}
+
+	addresses := make(map[string]string)
+	count := 0
+	cursor := ""
+	for {
+		page, err := s.Search(filter, cursor, MaxPageSize)
+		if err != nil {
+			return count, err
+		}
+
+		for _, eventLog := range page.EventLogs {
+			record := exportRecord{
+				ID:          eventLog.ID,
+				Type:        eventLog.Type,
+				Severity:    eventLog.Severity(),
+				Description: eventLog.Description,
+				Payload:     eventLog.Payload,
+			}
+			if eventLog.CreatedAt != nil {
+				record.CreatedAt = eventLog.CreatedAt.Format(time.RFC3339)
+			}
+			if eventLog.DeviceID != nil {
+				record.DeviceID = *eventLog.DeviceID
+				record.DeviceIP = s.deviceAddress(eventLog.DeviceID, addresses)
+			}
+			if err := write(record); err != nil {
+				return count, err
+			}
+			count++
+		}
+
+		if page.NextCursor == "" {
+			return count, flush()
+		}
+		cursor = page.NextCursor
+	}
+}
@@ -10,17 +10,22 @@ import (
 	"time"
 )
 
+const (
+	// DefaultPageSize is the page size of Search when none is given
+	DefaultPageSize = 100
+	// MaxPageSize caps the page size of Search
+	MaxPageSize = 1000
+)
+
 type EventLogService struct {
 	repository    db.EventLogRepository
 	DeviceService *device.DeviceService
-	dbManager     *db.DBManager
 }
 
-func NewEventLogService(repository db.EventLogRepository, deviceService *device.DeviceService, dbManager *db.DBManager) *EventLogService {
+func NewEventLogService(repository db.EventLogRepository, deviceService *device.DeviceService) *EventLogService {
 	return &EventLogService{
 		repository:    repository,
 		DeviceService: deviceService,
-		dbManager:     dbManager,
 	}
 }
 
@@ -31,30 +36,51 @@ func (s *EventLogService) GetAll(limitSize int64) ([]models.EventLog, error) {
 		return nil, err
 	}
 
+	addresses := make(map[string]string)
 	eventLogs := make([]models.EventLog, len(eventLogPtrs))
 	for i, logPtr := range eventLogPtrs {
 		eventLogs[i] = *logPtr
-		eventLogs[i].Description = s.generateDescription(eventLogs[i])
+		eventLogs[i].Description = s.generateDescription(eventLogs[i], addresses)
 	}
 
 	return eventLogs, nil
 }
 
-func (s *EventLogService) generateDescription(eventLog models.EventLog) string {
+// deviceAddress returns the IPv4 address of a device for descriptions,
+// caching lookups in addresses while describing a batch of events
+func (s *EventLogService) deviceAddress(deviceID *string, addresses map[string]string) string {
+	if deviceID == nil {
+		return ""
+	}
+	if address, ok := addresses[*deviceID]; ok {
+		return address
+	}
+
+	address := ""
+	device, err := s.DeviceService.FindByID(*deviceID)
+	if err != nil {
+		log.Printf("Error fetching device information: %v", err)
+	} else if device != nil {
+		address = device.IPv4
+	}
+	addresses[*deviceID] = address
+	return address
+}
+
+func (s *EventLogService) generateDescription(eventLog models.EventLog, addresses map[string]string) string {
 	deviceInfo := "unknown device"
-	if eventLog.DeviceID != nil {
-		device, err := s.DeviceService.FindByID(*eventLog.DeviceID)
-		if err != nil {
-			log.Printf("Error fetching device information: %v", err)
-		} else if device != nil && device.IPv4 != "" {
-			deviceInfo = device.IPv4
-		}
+	if address := s.deviceAddress(eventLog.DeviceID, addresses); address != "" {
+		deviceInfo = address
 	}
 
 	switch eventLog.Type {
 	case models.PingSweep:
-		if eventLog.DurationSeconds != nil {
-			return fmt.Sprintf("Ping sweep completed in %d seconds", int(*eventLog.DurationSeconds))
+		duration := eventLog.DurationSeconds
+		if payload, err := eventLog.DecodePayload(); err == nil && duration == nil {
+			duration = payload.(*models.ScanPayload).DurationSeconds
+		}
+		if duration != nil {
+			return fmt.Sprintf("Ping sweep completed in %d seconds", int(*duration))
 		} else {
 			return "Ping sweep performed"
 		}
@@ -82,9 +108,19 @@ func (s *EventLogService) generateDescription(eventLog models.EventLog) string {
 		return eventLog.Description // Use the custom description for scan events
 	case models.ScanStopped:
 		return eventLog.Description // Use the custom description for scan events
+	case models.PathChanged:
+		return eventLog.Description // Use the custom description for path events
+	case models.WakeOnLAN:
+		return eventLog.Description // Use the custom description for wake events
 	case models.Warning:
+		if eventLog.Description != "" {
+			return eventLog.Description
+		}
 		return "Warning event occurred"
 	case models.Alert:
+		if eventLog.Description != "" {
+			return eventLog.Description
+		}
 		return "Alert event occurred"
 	default:
 		return fmt.Sprintf("System event: %s", string(eventLog.Type))
@@ -112,16 +148,80 @@ func (s *EventLogService) GetAllByDeviceId(deviceId string, limitSize int64) ([]
 	return eventLogs, nil
 }
 
+// Search returns one page of event logs matching filter, newest first, with
+// their descriptions generated. cursor is the NextCursor of the previous page.
+func (s *EventLogService) Search(filter models.EventLogFilter, cursor string, limit int) (*models.EventLogPage, error) {
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+	if limit > MaxPageSize {
+		limit = MaxPageSize
+	}
+
+	var after *models.EventLogCursor
+	if cursor != "" {
+		var err error
+		if after, err = models.ParseEventLogCursor(cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	// One extra row tells whether there is another page
+	eventLogs, err := s.repository.Search(context.Background(), filter, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.EventLogPage{EventLogs: eventLogs}
+	if len(eventLogs) > limit {
+		page.EventLogs = eventLogs[:limit]
+		page.NextCursor = models.CursorAfter(page.EventLogs[limit-1]).String()
+	}
+
+	addresses := make(map[string]string)
+	for _, eventLog := range page.EventLogs {
+		eventLog.Description = s.generateDescription(*eventLog, addresses)
+	}
+	if page.EventLogs == nil {
+		page.EventLogs = []*models.EventLog{}
+	}
+	return page, nil
+}
+
+// CreateOne stores an event log. Events without a payload get one holding
+// the common fields, so every stored event has a severity and an actor.
 func (s *EventLogService) CreateOne(eventLog *models.EventLog) error {
 	now := time.Now()
 	eventLog.CreatedAt = &now
 	eventLog.UpdatedAt = &now
+	if len(eventLog.Payload) == 0 {
+		if err := eventLog.SetPayload(nil); err != nil {
+			return err
+		}
+	}
 
-	// Use DB manager to serialize database access
-	return s.dbManager.CreateEventLog(s.repository, context.Background(), eventLog)
+	return s.repository.Create(context.Background(), eventLog)
 }
 
-func (s *EventLogService) Log(eventType models.EEventLogType, description string, deviceID string) error {
+// CreateMany stores several event logs in one transaction
+func (s *EventLogService) CreateMany(eventLogs []*models.EventLog) error {
+	now := time.Now()
+	for _, eventLog := range eventLogs {
+		eventLog.CreatedAt = &now
+		eventLog.UpdatedAt = &now
+		if len(eventLog.Payload) == 0 {
+			if err := eventLog.SetPayload(nil); err != nil {
+				return err
+			}
+		}
+	}
+
+	return s.repository.CreateBatch(context.Background(), eventLogs)
+}
+
+// Log stores an event with its payload, which must be of the payload type of
+// eventType (see models.NewEventPayload) or nil
+func (s *EventLogService) Log(eventType models.EEventLogType, description string, deviceID string, payload models.EventPayload) error {
 	eventLog := &models.EventLog{
 		Type:        eventType,
 		Description: description,
@@ -130,6 +230,9 @@ func (s *EventLogService) Log(eventType models.EEventLogType, description string
 	if deviceID != "" {
 		eventLog.DeviceID = &deviceID
 	}
+	if err := eventLog.SetPayload(payload); err != nil {
+		return err
+	}
 
 	return s.CreateOne(eventLog)
 }
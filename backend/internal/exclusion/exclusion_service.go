@@ -0,0 +1,93 @@
+package exclusion
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"reconya-ai/db"
+	"reconya-ai/models"
+)
+
+// ErrExcluded is returned when asked to probe an excluded device or address
+var ErrExcluded = errors.New("excluded from scanning")
+
+// ExclusionService stores the exclusion lists and compiles them for scans
+type ExclusionService struct {
+	repository db.ExclusionRepository
+}
+
+func NewExclusionService(repository db.ExclusionRepository) *ExclusionService {
+	return &ExclusionService{repository: repository}
+}
+
+// GetAll returns every exclusion, global and per network
+func (s *ExclusionService) GetAll() ([]*models.Exclusion, error) {
+	return s.repository.FindAll(context.Background())
+}
+
+// Add excludes an IP address, a CIDR range or a MAC address from scanning,
+// on one network or on all of them when networkID is empty
+func (s *ExclusionService) Add(networkID, value, comment string) (*models.Exclusion, error) {
+	kind, normalized, err := models.ParseExclusion(value)
+	if err != nil {
+		return nil, err
+	}
+
+	exclusions, err := s.repository.FindAll(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load exclusions: %v", err)
+	}
+	for _, e := range exclusions {
+		if e.NetworkID == networkID && e.Value == normalized {
+			return nil, fmt.Errorf("%s is already excluded", normalized)
+		}
+	}
+
+	exclusion := &models.Exclusion{
+		NetworkID: networkID,
+		Kind:      kind,
+		Value:     normalized,
+		Comment:   strings.TrimSpace(comment),
+	}
+	if err := s.repository.Create(context.Background(), exclusion); err != nil {
+		return nil, fmt.Errorf("failed to save exclusion: %v", err)
+	}
+	return exclusion, nil
+}
+
+// Delete removes an exclusion
+func (s *ExclusionService) Delete(id string) error {
+	return s.repository.Delete(context.Background(), id)
+}
+
+// ListFor compiles the global exclusions and those of a network. A nil
+// service excludes nothing.
+func (s *ExclusionService) ListFor(networkID string) (*List, error) {
+	if s == nil {
+		return nil, nil
+	}
+	exclusions, err := s.repository.FindAll(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load exclusions: %v", err)
+	}
+
+	var applicable []*models.Exclusion
+	for _, e := range exclusions {
+		if e.NetworkID == "" || e.NetworkID == networkID {
+			applicable = append(applicable, e)
+		}
+	}
+	return NewList(applicable), nil
+}
+
+// ExcludesDevice reports whether a device is excluded on its network. A nil
+// service excludes nothing.
+func (s *ExclusionService) ExcludesDevice(device *models.Device) (bool, error) {
+	list, err := s.ListFor(device.NetworkID)
+	if err != nil {
+		return false, err
+	}
+	return list.ExcludesDevice(device), nil
+}
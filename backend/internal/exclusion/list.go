@@ -0,0 +1,135 @@
+package exclusion
+
+import (
+	"net"
+	"sort"
+	"strings"
+
+	"reconya-ai/models"
+)
+
+// List matches addresses and MAC addresses against a set of exclusions. A
+// nil List excludes nothing.
+type List struct {
+	ips    map[string]bool
+	ranges []*net.IPNet
+	macs   map[string]bool
+}
+
+// NewList compiles exclusions, skipping values that don't parse
+func NewList(exclusions []*models.Exclusion) *List {
+	l := &List{ips: make(map[string]bool), macs: make(map[string]bool)}
+	for _, e := range exclusions {
+		kind, value, err := models.ParseExclusion(e.Value)
+		if err != nil {
+			continue
+		}
+		switch kind {
+		case models.ExclusionKindIP:
+			l.ips[value] = true
+		case models.ExclusionKindCIDR:
+			_, ipNet, _ := net.ParseCIDR(value)
+			l.ranges = append(l.ranges, ipNet)
+		case models.ExclusionKindMAC:
+			l.macs[value] = true
+		}
+	}
+	return l
+}
+
+// IsEmpty reports whether the list excludes nothing
+func (l *List) IsEmpty() bool {
+	return l == nil || (len(l.ips) == 0 && len(l.ranges) == 0 && len(l.macs) == 0)
+}
+
+// ExcludesIP reports whether an IPv4 or IPv6 address is excluded
+func (l *List) ExcludesIP(address string) bool {
+	if l.IsEmpty() {
+		return false
+	}
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+	if l.ips[ip.String()] {
+		return true
+	}
+	for _, r := range l.ranges {
+		if r.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExcludesMAC reports whether a MAC address is excluded
+func (l *List) ExcludesMAC(mac string) bool {
+	if l.IsEmpty() || len(l.macs) == 0 {
+		return false
+	}
+	hw, err := net.ParseMAC(strings.TrimSpace(mac))
+	if err != nil {
+		return false
+	}
+	return l.macs[strings.ToUpper(hw.String())]
+}
+
+// ExcludesDevice reports whether any address of a device is excluded
+func (l *List) ExcludesDevice(device *models.Device) bool {
+	if l.IsEmpty() {
+		return false
+	}
+	if device.MAC != nil && l.ExcludesMAC(*device.MAC) {
+		return true
+	}
+	if device.IPv4 != "" && l.ExcludesIP(device.IPv4) {
+		return true
+	}
+	for _, address := range device.GetAllIPv6Addresses() {
+		if l.ExcludesIP(address) {
+			return true
+		}
+	}
+	return false
+}
+
+// Covers reports whether a whole network range is excluded
+func (l *List) Covers(cidr string) bool {
+	if l.IsEmpty() {
+		return false
+	}
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return false
+	}
+	ones, bits := network.Mask.Size()
+	for _, r := range l.ranges {
+		rangeOnes, rangeBits := r.Mask.Size()
+		if rangeBits == bits && rangeOnes <= ones && r.Contains(network.IP) {
+			return true
+		}
+	}
+	return ones == bits && l.ips[network.IP.String()]
+}
+
+// Targets returns the excluded IPv4 addresses and ranges in the form nmap's
+// --exclude takes them. MAC addresses can't be excluded before a host
+// answers and are left out.
+func (l *List) Targets() []string {
+	if l.IsEmpty() {
+		return nil
+	}
+	var targets []string
+	for ip := range l.ips {
+		if net.ParseIP(ip).To4() != nil {
+			targets = append(targets, ip)
+		}
+	}
+	for _, r := range l.ranges {
+		if r.IP.To4() != nil {
+			targets = append(targets, r.String())
+		}
+	}
+	sort.Strings(targets)
+	return targets
+}
@@ -0,0 +1,61 @@
+package exclusion
+
+import (
+	"testing"
+
+	"reconya-ai/models"
+)
+
+func TestList(t *testing.T) {
+	list := NewList([]*models.Exclusion{
+		{Value: "192.168.1.10"},
+		{Value: "10.0.5.0/24"},
+		{Value: "aa:bb:cc:dd:ee:ff"},
+		{Value: "2001:db8::/64"},
+		{Value: "not an address"},
+	})
+
+	tests := []struct {
+		address string
+		want    bool
+	}{
+		{"192.168.1.10", true},
+		{"192.168.1.11", false},
+		{"10.0.5.200", true},
+		{"10.0.6.1", false},
+		{"2001:db8::1", true},
+		{"2001:db8:1::1", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := list.ExcludesIP(tt.address); got != tt.want {
+			t.Errorf("ExcludesIP(%q) = %v, want %v", tt.address, got, tt.want)
+		}
+	}
+
+	if !list.ExcludesMAC("AA-BB-CC-DD-EE-FF") {
+		t.Error("expected MAC address in another notation to be excluded")
+	}
+	mac := "aa:bb:cc:dd:ee:ff"
+	if !list.ExcludesDevice(&models.Device{IPv4: "192.168.1.50", MAC: &mac}) {
+		t.Error("expected device with excluded MAC address to be excluded")
+	}
+	if list.ExcludesDevice(&models.Device{IPv4: "192.168.1.50"}) {
+		t.Error("expected device at a scanned address to be scanned")
+	}
+
+	if !list.Covers("10.0.5.128/25") || list.Covers("10.0.0.0/16") || !list.Covers("192.168.1.10/32") {
+		t.Error("unexpected range coverage")
+	}
+
+	want := []string{"10.0.5.0/24", "192.168.1.10"}
+	got := list.Targets()
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("Targets() = %v, want %v", got, want)
+	}
+
+	var empty *List
+	if empty.ExcludesIP("192.168.1.10") || empty.ExcludesMAC(mac) || empty.Targets() != nil {
+		t.Error("expected nil list to exclude nothing")
+	}
+}
@@ -0,0 +1,33 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+
+	"reconya-ai/models"
+)
+
+// writeCSV writes a single table selected by the row mode
+func writeCSV(w io.Writer, devices []*models.Device, opts Options) error {
+	var t table
+	switch opts.Rows {
+	case RowsPorts:
+		t = portsTable(devices)
+	case RowsWebServices:
+		t = webServicesTable(devices)
+	default:
+		t = devicesTable(devices, opts)
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(t.Header); err != nil {
+		return err
+	}
+	for _, row := range t.Rows {
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,157 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"reconya-ai/models"
+)
+
+// CycloneDX-style inventory: every device is a component of type "device" and
+// every web service a service referencing its device. Attributes without a
+// CycloneDX field are carried as reconya:* properties.
+
+type cdxBOM struct {
+	BOMFormat    string         `json:"bomFormat"`
+	SpecVersion  string         `json:"specVersion"`
+	SerialNumber string         `json:"serialNumber,omitempty"`
+	Version      int            `json:"version"`
+	Metadata     cdxMetadata    `json:"metadata"`
+	Components   []cdxComponent `json:"components"`
+	Services     []cdxService   `json:"services,omitempty"`
+}
+
+type cdxMetadata struct {
+	Timestamp string    `json:"timestamp"`
+	Tools     []cdxTool `json:"tools"`
+}
+
+type cdxTool struct {
+	Vendor string `json:"vendor"`
+	Name   string `json:"name"`
+}
+
+type cdxComponent struct {
+	Type         string        `json:"type"`
+	BOMRef       string        `json:"bom-ref"`
+	Name         string        `json:"name"`
+	Manufacturer *cdxEntity    `json:"manufacturer,omitempty"`
+	Description  string        `json:"description,omitempty"`
+	Properties   []cdxProperty `json:"properties,omitempty"`
+}
+
+type cdxEntity struct {
+	Name string `json:"name"`
+}
+
+type cdxService struct {
+	BOMRef     string        `json:"bom-ref"`
+	Name       string        `json:"name"`
+	Endpoints  []string      `json:"endpoints"`
+	Properties []cdxProperty `json:"properties,omitempty"`
+}
+
+type cdxProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+func writeCycloneDX(w io.Writer, devices []*models.Device, opts Options) error {
+	bom := cdxBOM{
+		BOMFormat:   "CycloneDX",
+		SpecVersion: "1.5",
+		Version:     1,
+		Metadata: cdxMetadata{
+			Timestamp: formatTime(&opts.GeneratedAt),
+			Tools:     []cdxTool{{Vendor: "reconYa", Name: "reconya"}},
+		},
+		Components: make([]cdxComponent, 0, len(devices)),
+	}
+
+	for _, d := range devices {
+		bom.Components = append(bom.Components, deviceComponent(d, opts))
+		for i, ws := range d.WebServices {
+			service := cdxService{
+				BOMRef:    fmt.Sprintf("service:%s:%d", d.ID, i),
+				Name:      ws.URL,
+				Endpoints: []string{ws.URL},
+				Properties: appendProperties(nil,
+					"reconya:device", "device:"+d.ID,
+					"reconya:title", ws.Title,
+					"reconya:server", ws.Server,
+					"reconya:status_code", fmt.Sprint(ws.StatusCode),
+				),
+			}
+			if ws.Title != "" {
+				service.Name = ws.Title
+			}
+			bom.Services = append(bom.Services, service)
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(bom)
+}
+
+func deviceComponent(d *models.Device, opts Options) cdxComponent {
+	name := d.Name
+	if name == "" {
+		name = stringValue(d.Hostname)
+	}
+	if name == "" {
+		name = d.IPv4
+	}
+
+	component := cdxComponent{
+		Type:   "device",
+		BOMRef: "device:" + d.ID,
+		Name:   name,
+	}
+	if d.Vendor != nil && *d.Vendor != "" {
+		component.Manufacturer = &cdxEntity{Name: *d.Vendor}
+	}
+	if d.Comment != nil {
+		component.Description = *d.Comment
+	}
+
+	var ports []string
+	for _, port := range openPorts(d) {
+		ports = append(ports, port.Number+"/"+port.Protocol)
+	}
+	osName := ""
+	if d.OS != nil {
+		osName = strings.TrimSpace(d.OS.Name + " " + d.OS.Version)
+	}
+
+	component.Properties = appendProperties(nil,
+		"reconya:ipv4", d.IPv4,
+		"reconya:ipv6", strings.Join(d.GetAllIPv6Addresses(), ","),
+		"reconya:mac", stringValue(d.MAC),
+		"reconya:hostname", stringValue(d.Hostname),
+		"reconya:device_type", string(d.DeviceType),
+		"reconya:os", osName,
+		"reconya:status", string(d.Status),
+		"reconya:network_id", d.NetworkID,
+		"reconya:network_cidr", networkCIDR(d, opts),
+		"reconya:open_ports", strings.Join(ports, ","),
+		"reconya:first_seen", formatTime(&d.CreatedAt),
+		"reconya:last_seen", formatTime(d.LastSeenOnlineAt),
+	)
+	for _, tag := range d.Tags {
+		component.Properties = append(component.Properties, cdxProperty{Name: "reconya:tag", Value: tag})
+	}
+	return component
+}
+
+// appendProperties adds name/value pairs, skipping empty values
+func appendProperties(properties []cdxProperty, pairs ...string) []cdxProperty {
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if pairs[i+1] != "" {
+			properties = append(properties, cdxProperty{Name: pairs[i], Value: pairs[i+1]})
+		}
+	}
+	return properties
+}
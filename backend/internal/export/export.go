@@ -0,0 +1,257 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"reconya-ai/models"
+)
+
+// Format is an inventory export format
+type Format string
+
+const (
+	FormatCSV       Format = "csv"
+	FormatJSON      Format = "json"
+	FormatNDJSON    Format = "ndjson"
+	FormatXLSX      Format = "xlsx"
+	FormatCycloneDX Format = "cyclonedx"
+)
+
+// ParseFormat validates a format name
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(strings.ToLower(strings.TrimSpace(name))); f {
+	case FormatCSV, FormatJSON, FormatNDJSON, FormatXLSX, FormatCycloneDX:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown export format %q", name)
+}
+
+// ContentType returns the MIME type of the format
+func (f Format) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatJSON:
+		return "application/json"
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case FormatCycloneDX:
+		return "application/vnd.cyclonedx+json"
+	}
+	return "application/octet-stream"
+}
+
+// Extension returns the file extension used for the format
+func (f Format) Extension() string {
+	if f == FormatCycloneDX {
+		return "cdx.json"
+	}
+	return string(f)
+}
+
+// Filename returns the download file name of an export created at the given time
+func Filename(format Format, at time.Time) string {
+	return fmt.Sprintf("reconya-inventory-%s.%s", at.Format("20060102-150405"), format.Extension())
+}
+
+// Rows selects what a row represents in the flat formats (CSV)
+type Rows string
+
+const (
+	// RowsDevices writes one row per device with ports and web services joined into a cell
+	RowsDevices Rows = "devices"
+	// RowsPorts writes one row per open port
+	RowsPorts Rows = "ports"
+	// RowsWebServices writes one row per web service
+	RowsWebServices Rows = "web_services"
+)
+
+// ParseRows validates a row mode, defaulting to one row per device
+func ParseRows(name string) (Rows, error) {
+	switch r := Rows(strings.ToLower(strings.TrimSpace(name))); r {
+	case "":
+		return RowsDevices, nil
+	case RowsDevices, RowsPorts, RowsWebServices:
+		return r, nil
+	}
+	return "", fmt.Errorf("unknown row mode %q", name)
+}
+
+// Filter narrows the exported devices
+type Filter struct {
+	NetworkID string
+	Tag       string
+	Status    models.DeviceStatus
+}
+
+// Matches reports whether a device passes the filter
+func (f Filter) Matches(d *models.Device) bool {
+	if f.NetworkID != "" && d.NetworkID != f.NetworkID {
+		return false
+	}
+	if f.Tag != "" && !d.HasTag(f.Tag) {
+		return false
+	}
+	if f.Status != "" && d.Status != f.Status {
+		return false
+	}
+	return true
+}
+
+// Options control how an export is written
+type Options struct {
+	Rows        Rows
+	GeneratedAt time.Time
+	// Network names by ID, used to label devices
+	Networks map[string]*models.Network
+}
+
+// Write writes the devices in the given format
+func Write(w io.Writer, format Format, devices []*models.Device, opts Options) error {
+	if opts.Rows == "" {
+		opts.Rows = RowsDevices
+	}
+	if opts.GeneratedAt.IsZero() {
+		opts.GeneratedAt = time.Now()
+	}
+
+	switch format {
+	case FormatCSV:
+		return writeCSV(w, devices, opts)
+	case FormatJSON:
+		return writeJSON(w, devices, opts)
+	case FormatNDJSON:
+		return writeNDJSON(w, devices, opts)
+	case FormatXLSX:
+		return writeXLSX(w, devices, opts)
+	case FormatCycloneDX:
+		return writeCycloneDX(w, devices, opts)
+	}
+	return fmt.Errorf("unknown export format %q", format)
+}
+
+// table is a header and rows shared by CSV and XLSX
+type table struct {
+	Name   string
+	Header []string
+	Rows   [][]string
+}
+
+var deviceHeader = []string{
+	"id", "name", "hostname", "ipv4", "ipv6", "mac", "vendor", "device_type", "os",
+	"status", "network_id", "network_cidr", "tags", "comment", "open_ports", "web_services",
+	"created_at", "last_seen_online_at",
+}
+
+func deviceRow(d *models.Device, opts Options) []string {
+	var ports []string
+	for _, port := range openPorts(d) {
+		ports = append(ports, port.Number+"/"+port.Protocol)
+	}
+	var services []string
+	for _, ws := range d.WebServices {
+		services = append(services, ws.URL)
+	}
+
+	osName := ""
+	if d.OS != nil {
+		osName = strings.TrimSpace(d.OS.Name + " " + d.OS.Version)
+	}
+
+	return []string{
+		d.ID,
+		d.Name,
+		stringValue(d.Hostname),
+		d.IPv4,
+		strings.Join(d.GetAllIPv6Addresses(), ";"),
+		stringValue(d.MAC),
+		stringValue(d.Vendor),
+		string(d.DeviceType),
+		osName,
+		string(d.Status),
+		d.NetworkID,
+		networkCIDR(d, opts),
+		strings.Join(d.Tags, ";"),
+		stringValue(d.Comment),
+		strings.Join(ports, ";"),
+		strings.Join(services, ";"),
+		formatTime(&d.CreatedAt),
+		formatTime(d.LastSeenOnlineAt),
+	}
+}
+
+func devicesTable(devices []*models.Device, opts Options) table {
+	t := table{Name: "Devices", Header: deviceHeader}
+	for _, d := range devices {
+		t.Rows = append(t.Rows, deviceRow(d, opts))
+	}
+	return t
+}
+
+func portsTable(devices []*models.Device) table {
+	t := table{Name: "Ports", Header: []string{"device_id", "ipv4", "name", "port", "protocol", "state", "service"}}
+	for _, d := range devices {
+		for _, port := range openPorts(d) {
+			t.Rows = append(t.Rows, []string{d.ID, d.IPv4, d.Name, port.Number, port.Protocol, port.State, port.Service})
+		}
+	}
+	return t
+}
+
+func webServicesTable(devices []*models.Device) table {
+	t := table{Name: "Web Services", Header: []string{"device_id", "ipv4", "name", "url", "port", "protocol", "status_code", "title", "server", "scanned_at"}}
+	for _, d := range devices {
+		for _, ws := range d.WebServices {
+			scannedAt := ws.ScannedAt
+			t.Rows = append(t.Rows, []string{
+				d.ID, d.IPv4, d.Name, ws.URL, fmt.Sprint(ws.Port), ws.Protocol,
+				fmt.Sprint(ws.StatusCode), ws.Title, ws.Server, formatTime(&scannedAt),
+			})
+		}
+	}
+	return t
+}
+
+// openPorts returns the open ports of a device ordered by number
+func openPorts(d *models.Device) []models.Port {
+	var ports []models.Port
+	for _, port := range d.Ports {
+		if port.State == "" || port.State == "open" {
+			ports = append(ports, port)
+		}
+	}
+	sort.SliceStable(ports, func(i, j int) bool {
+		var pi, pj int
+		fmt.Sscan(ports[i].Number, &pi)
+		fmt.Sscan(ports[j].Number, &pj)
+		return pi < pj
+	})
+	return ports
+}
+
+func networkCIDR(d *models.Device, opts Options) string {
+	if network, ok := opts.Networks[d.NetworkID]; ok && network != nil {
+		return network.CIDR
+	}
+	return ""
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
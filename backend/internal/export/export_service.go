@@ -0,0 +1,75 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"reconya-ai/internal/device"
+	"reconya-ai/internal/network"
+	"reconya-ai/models"
+)
+
+type ExportService struct {
+	deviceService  *device.DeviceService
+	networkService *network.NetworkService
+}
+
+func NewExportService(deviceService *device.DeviceService, networkService *network.NetworkService) *ExportService {
+	return &ExportService{
+		deviceService:  deviceService,
+		networkService: networkService,
+	}
+}
+
+// ResolveNetwork accepts a network ID or CIDR and returns the network ID
+func (s *ExportService) ResolveNetwork(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+
+	var found *models.Network
+	var err error
+	if _, _, cidrErr := net.ParseCIDR(value); cidrErr == nil {
+		found, err = s.networkService.FindByCIDR(value)
+	} else {
+		found, err = s.networkService.FindByID(value)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to find network: %v", err)
+	}
+	if found == nil {
+		return "", fmt.Errorf("network %q not found", value)
+	}
+	return found.ID, nil
+}
+
+// Devices returns the devices matching the filter, ordered by IP
+func (s *ExportService) Devices(filter Filter) ([]*models.Device, error) {
+	devices, err := s.deviceService.FindAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load devices: %v", err)
+	}
+
+	matched := make([]*models.Device, 0, len(devices))
+	for _, d := range devices {
+		if filter.Matches(d) {
+			matched = append(matched, d)
+		}
+	}
+	return matched, nil
+}
+
+// Networks returns all networks by ID for labelling exported devices
+func (s *ExportService) Networks() (map[string]*models.Network, error) {
+	networks, err := s.networkService.Repository.FindAll(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load networks: %v", err)
+	}
+
+	byID := make(map[string]*models.Network, len(networks))
+	for _, n := range networks {
+		byID[n.ID] = n
+	}
+	return byID, nil
+}
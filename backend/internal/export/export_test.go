@@ -0,0 +1,137 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"reconya-ai/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testDevices() []*models.Device {
+	mac := "aa:bb:cc:dd:ee:ff"
+	vendor := "Acme"
+	return []*models.Device{
+		{
+			ID: "dev-1", Name: "nas", IPv4: "192.168.1.10", MAC: &mac, Vendor: &vendor,
+			Status: models.DeviceStatusOnline, NetworkID: "net-1", Tags: []string{"storage"},
+			Ports: []models.Port{
+				{Number: "443", Protocol: "tcp", State: "open", Service: "https"},
+				{Number: "22", Protocol: "tcp", State: "open", Service: "ssh"},
+				{Number: "25", Protocol: "tcp", State: "closed"},
+			},
+			WebServices: []models.WebService{{URL: "https://192.168.1.10", Port: 443, Protocol: "https", StatusCode: 200, Title: "NAS"}},
+		},
+		{ID: "dev-2", IPv4: "192.168.2.20", Status: models.DeviceStatusOffline, NetworkID: "net-2"},
+	}
+}
+
+var testOptions = Options{
+	GeneratedAt: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC),
+	Networks:    map[string]*models.Network{"net-1": {ID: "net-1", CIDR: "192.168.1.0/24"}},
+}
+
+func TestFilter(t *testing.T) {
+	devices := testDevices()
+
+	assert.True(t, Filter{}.Matches(devices[0]))
+	assert.True(t, Filter{NetworkID: "net-1", Tag: "STORAGE"}.Matches(devices[0]))
+	assert.False(t, Filter{Tag: "storage"}.Matches(devices[1]))
+	assert.False(t, Filter{Status: models.DeviceStatusOnline}.Matches(devices[1]))
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, FormatCSV, testDevices(), testOptions))
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, deviceHeader, records[0])
+
+	row := map[string]string{}
+	for i, column := range records[0] {
+		row[column] = records[1][i]
+	}
+	assert.Equal(t, "22/tcp;443/tcp", row["open_ports"])
+	assert.Equal(t, "192.168.1.0/24", row["network_cidr"])
+	assert.Equal(t, "storage", row["tags"])
+
+	opts := testOptions
+	opts.Rows = RowsPorts
+	buf.Reset()
+	require.NoError(t, Write(&buf, FormatCSV, testDevices(), opts))
+	records, err = csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	assert.Len(t, records, 3, "header and one row per open port")
+}
+
+func TestWriteNDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, FormatNDJSON, testDevices(), testOptions))
+
+	scanner := bufio.NewScanner(&buf)
+	var lines []map[string]interface{}
+	for scanner.Scan() {
+		var line map[string]interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+		lines = append(lines, line)
+	}
+	require.Len(t, lines, 2)
+	assert.Equal(t, "192.168.1.0/24", lines[0]["network_cidr"])
+	assert.Len(t, lines[0]["ports"], 3)
+}
+
+func TestWriteJSONAndCycloneDX(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, FormatJSON, testDevices(), testOptions))
+	var document struct {
+		Devices []map[string]interface{} `json:"devices"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &document))
+	assert.Len(t, document.Devices, 2)
+
+	buf.Reset()
+	require.NoError(t, Write(&buf, FormatCycloneDX, testDevices(), testOptions))
+	var bom cdxBOM
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &bom))
+	assert.Equal(t, "CycloneDX", bom.BOMFormat)
+	require.Len(t, bom.Components, 2)
+	assert.Equal(t, "device", bom.Components[0].Type)
+	assert.Equal(t, "Acme", bom.Components[0].Manufacturer.Name)
+	require.Len(t, bom.Services, 1)
+	assert.Equal(t, "NAS", bom.Services[0].Name)
+}
+
+func TestWriteXLSX(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, FormatXLSX, testDevices(), testOptions))
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+
+	files := map[string]string{}
+	for _, f := range archive.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		require.NoError(t, err)
+		files[f.Name] = string(data)
+	}
+
+	require.Contains(t, files, "[Content_Types].xml")
+	require.Contains(t, files, "xl/workbook.xml")
+	assert.True(t, strings.Contains(files["xl/worksheets/sheet1.xml"], "192.168.1.10"))
+	assert.True(t, strings.Contains(files["xl/worksheets/sheet2.xml"], `<c r="D2" t="inlineStr"><is><t xml:space="preserve">22</t>`))
+	assert.Equal(t, "AA", columnName(26))
+}
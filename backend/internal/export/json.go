@@ -0,0 +1,52 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+
+	"reconya-ai/models"
+)
+
+// deviceRecord is a device with nested ports and web services as exported to JSON
+type deviceRecord struct {
+	*models.Device
+	NetworkCIDR string `json:"network_cidr,omitempty"`
+}
+
+func newDeviceRecord(d *models.Device, opts Options) deviceRecord {
+	return deviceRecord{Device: d, NetworkCIDR: networkCIDR(d, opts)}
+}
+
+// writeJSON streams a JSON document with the devices in a top level array
+func writeJSON(w io.Writer, devices []*models.Device, opts Options) error {
+	if _, err := io.WriteString(w, `{"generated_at":"`+opts.GeneratedAt.UTC().Format("2006-01-02T15:04:05Z07:00")+`","devices":[`); err != nil {
+		return err
+	}
+	for i, d := range devices {
+		if i > 0 {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+		data, err := json.Marshal(newDeviceRecord(d, opts))
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(w, "]}\n")
+	return err
+}
+
+// writeNDJSON writes one JSON device per line
+func writeNDJSON(w io.Writer, devices []*models.Device, opts Options) error {
+	encoder := json.NewEncoder(w)
+	for _, d := range devices {
+		if err := encoder.Encode(newDeviceRecord(d, opts)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+
+	"reconya-ai/models"
+)
+
+// writeXLSX writes a workbook with a sheet for devices, ports and web services.
+// The workbook is written directly as Office Open XML using inline strings, so
+// no spreadsheet library is needed.
+func writeXLSX(w io.Writer, devices []*models.Device, opts Options) error {
+	tables := []table{devicesTable(devices, opts), portsTable(devices), webServicesTable(devices)}
+
+	archive := zip.NewWriter(w)
+
+	files := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", xlsxContentTypes(len(tables))},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", xlsxWorkbook(tables)},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels(len(tables))},
+		{"xl/styles.xml", xlsxStyles},
+	}
+	for _, file := range files {
+		if err := writeZipFile(archive, file.name, []byte(file.content)); err != nil {
+			return err
+		}
+	}
+
+	for i, t := range tables {
+		if err := writeZipFile(archive, fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), xlsxSheet(t)); err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+func writeZipFile(archive *zip.Writer, name string, content []byte) error {
+	f, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(content)
+	return err
+}
+
+const xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`
+
+// Style 1 is a bold font used for header rows
+const xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>
+<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>
+<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>
+<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>
+<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>
+</styleSheet>`
+
+func xlsxContentTypes(sheets int) string {
+	var buf bytes.Buffer
+	buf.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>
+`)
+	for i := 1; i <= sheets; i++ {
+		fmt.Fprintf(&buf, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+`, i)
+	}
+	buf.WriteString(`</Types>`)
+	return buf.String()
+}
+
+func xlsxWorkbook(tables []table) string {
+	var buf bytes.Buffer
+	buf.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets>
+`)
+	for i, t := range tables {
+		fmt.Fprintf(&buf, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>
+`, xmlEscape(t.Name), i+1, i+1)
+	}
+	buf.WriteString(`</sheets>
+</workbook>`)
+	return buf.String()
+}
+
+func xlsxWorkbookRels(sheets int) string {
+	var buf bytes.Buffer
+	buf.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+`)
+	for i := 1; i <= sheets; i++ {
+		fmt.Fprintf(&buf, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>
+`, i, i)
+	}
+	fmt.Fprintf(&buf, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+`, sheets+1)
+	buf.WriteString(`</Relationships>`)
+	return buf.String()
+}
+
+func xlsxSheet(t table) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>
+<sheetData>
+`)
+	writeRow := func(index int, values []string, style int) {
+		fmt.Fprintf(&buf, `<row r="%d">`, index)
+		for col, value := range values {
+			fmt.Fprintf(&buf, `<c r="%s%d" t="inlineStr"`, columnName(col), index)
+			if style > 0 {
+				fmt.Fprintf(&buf, ` s="%d"`, style)
+			}
+			fmt.Fprintf(&buf, `><is><t xml:space="preserve">%s</t></is></c>`, xmlEscape(value))
+		}
+		buf.WriteString("</row>\n")
+	}
+
+	writeRow(1, t.Header, 1)
+	for i, row := range t.Rows {
+		writeRow(i+2, row, 0)
+	}
+
+	buf.WriteString(`</sheetData>
+</worksheet>`)
+	return buf.Bytes()
+}
+
+// columnName converts a zero based column index to a spreadsheet column (A, B, ..., AA)
+func columnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
+
+func xmlEscape(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
@@ -4,7 +4,7 @@ import (
 	"context"
 	"encoding/xml"
 	"log"
-	"os/exec"
+	"reconya-ai/internal/ratelimit"
 	"reconya-ai/models"
 	"regexp"
 	"strconv"
@@ -18,8 +18,9 @@ func NewFingerprintService() *FingerprintService {
 	return &FingerprintService{}
 }
 
-// AnalyzeDevice performs comprehensive device fingerprinting
-func (f *FingerprintService) AnalyzeDevice(device *models.Device) {
+// AnalyzeDevice performs comprehensive device fingerprinting. Nmap OS
+// detection only runs when osDetection is set, paced by the limiter.
+func (f *FingerprintService) AnalyzeDevice(device *models.Device, osDetection bool, limiter *ratelimit.Limiter) {
 	log.Printf("Starting device fingerprinting for %s", device.IPv4)
 
 	// 1. Vendor-based device type detection
@@ -54,7 +55,9 @@ func (f *FingerprintService) AnalyzeDevice(device *models.Device) {
 	}
 
 	// 5. Nmap OS detection (more intensive)
-	if osInfo := f.performNmapOSDetection(device.IPv4); osInfo != nil {
+	if !osDetection {
+		log.Printf("Skipping OS detection for %s", device.IPv4)
+	} else if osInfo := f.performNmapOSDetection(device.IPv4, limiter); osInfo != nil {
 		device.OS = osInfo
 		log.Printf("OS detected: %s %s (confidence: %d%%)", osInfo.Name, osInfo.Version, osInfo.Confidence)
 
@@ -293,16 +296,13 @@ func (f *FingerprintService) detectDeviceTypeFromOS(os *models.DeviceOS) models.
 }
 
 // performNmapOSDetection runs nmap OS detection
-func (f *FingerprintService) performNmapOSDetection(ipv4 string) *models.DeviceOS {
+func (f *FingerprintService) performNmapOSDetection(ipv4 string, limiter *ratelimit.Limiter) *models.DeviceOS {
 	log.Printf("Performing nmap OS detection for %s", ipv4)
 
-	// Create context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
-	defer cancel()
-
-	// Run nmap OS detection
-	cmd := exec.CommandContext(ctx, "nmap", "-O", "-sT", "--osscan-guess", "-oX", "-", ipv4)
-	output, err := cmd.CombinedOutput()
+	// Run nmap OS detection with a 2 minute timeout
+	output, err := limiter.Exec(context.Background(), 2*time.Minute, func(maxRate int) []string {
+		return ratelimit.WithMaxRate([]string{"nmap", "-O", "-sT", "--osscan-guess", "-oX", "-", ipv4}, maxRate)
+	})
 	if err != nil {
 		log.Printf("nmap OS detection failed for %s: %v", ipv4, err)
 		return nil
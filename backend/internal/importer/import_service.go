@@ -0,0 +1,403 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"sort"
+	"strings"
+
+	"reconya-ai/internal/device"
+	"reconya-ai/internal/network"
+	"reconya-ai/models"
+)
+
+// Action is what an import does with a record
+type Action string
+
+const (
+	ActionCreate    Action = "create"
+	ActionUpdate    Action = "update"
+	ActionUnchanged Action = "unchanged"
+	ActionSkip      Action = "skip"
+)
+
+// Change is a field that an import sets to a new value
+type Change struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// DeviceResult describes what happened, or would happen, to one record
+type DeviceResult struct {
+	Action   Action   `json:"action"`
+	Source   string   `json:"source"`
+	DeviceID string   `json:"device_id,omitempty"`
+	IPv4     string   `json:"ipv4,omitempty"`
+	MAC      string   `json:"mac,omitempty"`
+	Changes  []Change `json:"changes,omitempty"`
+	Reason   string   `json:"reason,omitempty"`
+}
+
+// Result summarises an import
+type Result struct {
+	DryRun          bool           `json:"dry_run"`
+	Format          Format         `json:"format"`
+	Records         int            `json:"records"`
+	Created         int            `json:"created"`
+	Updated         int            `json:"updated"`
+	Unchanged       int            `json:"unchanged"`
+	Skipped         int            `json:"skipped"`
+	NetworksCreated []string       `json:"networks_created"`
+	Devices         []DeviceResult `json:"devices"`
+}
+
+// Options control an import
+type Options struct {
+	Format Format
+	// Network CIDR for records that don't name one
+	Network string
+	DryRun  bool
+}
+
+type ImportService struct {
+	deviceService  *device.DeviceService
+	networkService *network.NetworkService
+}
+
+func NewImportService(deviceService *device.DeviceService, networkService *network.NetworkService) *ImportService {
+	return &ImportService{
+		deviceService:  deviceService,
+		networkService: networkService,
+	}
+}
+
+// ImportFile detects the format if needed, parses the file and imports it
+func (s *ImportService) ImportFile(filename string, data []byte, opts Options) (*Result, error) {
+	format := opts.Format
+	if format == "" || format == FormatAuto {
+		detected, err := DetectFormat(filename, data)
+		if err != nil {
+			return nil, err
+		}
+		format = detected
+	}
+
+	records, err := Parse(format, data)
+	if err != nil {
+		return nil, err
+	}
+
+	opts.Format = format
+	return s.Import(records, opts)
+}
+
+// Import creates or updates the devices and networks described by the records.
+// Devices are matched like scan results: by IP address, then by MAC address.
+// With DryRun set nothing is written and the result reports what would change.
+func (s *ImportService) Import(records []Record, opts Options) (*Result, error) {
+	if opts.Network != "" {
+		cidr, err := normalizeCIDR(opts.Network)
+		if err != nil {
+			return nil, err
+		}
+		opts.Network = cidr
+	}
+
+	networks, err := s.networkService.Repository.FindAll(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load networks: %v", err)
+	}
+	resolver := newNetworkResolver(networks)
+
+	result := &Result{
+		DryRun:          opts.DryRun,
+		Format:          opts.Format,
+		Records:         len(records),
+		NetworksCreated: []string{},
+		Devices:         []DeviceResult{},
+	}
+
+	for _, record := range records {
+		deviceResult := s.importRecord(record, opts, resolver)
+		switch deviceResult.Action {
+		case ActionCreate:
+			result.Created++
+		case ActionUpdate:
+			result.Updated++
+		case ActionUnchanged:
+			result.Unchanged++
+		case ActionSkip:
+			result.Skipped++
+		}
+		result.Devices = append(result.Devices, deviceResult)
+	}
+
+	result.NetworksCreated = resolver.created
+	if !opts.DryRun {
+		log.Printf("Import finished: %d created, %d updated, %d unchanged, %d skipped, %d networks created",
+			result.Created, result.Updated, result.Unchanged, result.Skipped, len(result.NetworksCreated))
+	}
+	return result, nil
+}
+
+func (s *ImportService) importRecord(record Record, opts Options, resolver *networkResolver) DeviceResult {
+	deviceResult := DeviceResult{Source: record.Source, IPv4: record.IPv4}
+	skip := func(reason string) DeviceResult {
+		deviceResult.Action = ActionSkip
+		deviceResult.Reason = reason
+		return deviceResult
+	}
+
+	if record.MAC != "" {
+		mac, err := normalizeMAC(record.MAC)
+		if err != nil {
+			return skip(err.Error())
+		}
+		record.MAC = mac
+		deviceResult.MAC = mac
+	}
+	if record.IPv4 != "" {
+		if ip := net.ParseIP(record.IPv4); ip == nil || ip.To4() == nil {
+			return skip(fmt.Sprintf("invalid IPv4 address %q", record.IPv4))
+		}
+	}
+	if record.IPv4 == "" && record.MAC == "" {
+		return skip("no IP or MAC address")
+	}
+
+	var macPtr *string
+	if record.MAC != "" {
+		macPtr = &record.MAC
+	}
+	existing, err := s.deviceService.FindExisting(record.IPv4, macPtr)
+	if err != nil {
+		return skip(fmt.Sprintf("failed to look up device: %v", err))
+	}
+	if existing == nil && record.IPv4 == "" {
+		return skip("no IP address and no known device with this MAC address")
+	}
+
+	target := &models.Device{}
+	if existing != nil {
+		copied := *existing
+		target = &copied
+		deviceResult.DeviceID = existing.ID
+	}
+
+	var changes []Change
+	set := func(field, from, to string) bool {
+		if to == "" || from == to {
+			return false
+		}
+		changes = append(changes, Change{Field: field, From: from, To: to})
+		return true
+	}
+	setPtr := func(field string, ptr **string, value string) {
+		if set(field, stringValue(*ptr), value) {
+			v := value
+			*ptr = &v
+		}
+	}
+
+	if set("ipv4", target.IPv4, record.IPv4) {
+		target.IPv4 = record.IPv4
+	}
+	deviceResult.IPv4 = target.IPv4
+
+	// Networks: explicit CIDR, then the default, then the current network, then one containing the IP
+	cidr := record.Network
+	if cidr == "" {
+		cidr = opts.Network
+	}
+	if cidr == "" && existing != nil && existing.NetworkID != "" && existing.IPv4 == target.IPv4 {
+		cidr = resolver.cidrOf(existing.NetworkID)
+	}
+	if cidr == "" {
+		cidr = resolver.containing(target.IPv4)
+	}
+	if cidr == "" {
+		cidr = defaultCIDR(target.IPv4)
+	}
+	cidr, err = normalizeCIDR(cidr)
+	if err != nil {
+		return skip(err.Error())
+	}
+	if _, ipNet, _ := net.ParseCIDR(cidr); !ipNet.Contains(net.ParseIP(target.IPv4)) {
+		return skip(fmt.Sprintf("%s is not in network %s", target.IPv4, cidr))
+	}
+	set("network", resolver.cidrOf(target.NetworkID), cidr)
+
+	setPtr("mac", &target.MAC, record.MAC)
+	setPtr("hostname", &target.Hostname, record.Hostname)
+	if set("name", target.Name, record.Name) {
+		target.Name = record.Name
+	}
+	vendor := record.Vendor
+	if vendor == "" && record.MAC != "" && target.Vendor == nil {
+		vendor = s.deviceService.LookupVendor(record.MAC)
+	}
+	setPtr("vendor", &target.Vendor, vendor)
+	setPtr("comment", &target.Comment, record.Comment)
+
+	if len(record.Tags) > 0 {
+		tags := models.NormalizeTags(append(append([]string{}, target.Tags...), record.Tags...))
+		if set("tags", strings.Join(target.Tags, ","), strings.Join(tags, ",")) {
+			target.Tags = tags
+		}
+	}
+	if len(record.Ports) > 0 && set("ports", portList(target.Ports), portList(record.Ports)) {
+		target.Ports = record.Ports
+	}
+
+	deviceResult.Changes = changes
+	switch {
+	case existing == nil:
+		deviceResult.Action = ActionCreate
+	case len(changes) > 0:
+		deviceResult.Action = ActionUpdate
+	default:
+		deviceResult.Action = ActionUnchanged
+		return deviceResult
+	}
+
+	if opts.DryRun {
+		resolver.plan(cidr)
+		return deviceResult
+	}
+
+	networkID, err := resolver.ensure(s.networkService, cidr)
+	if err != nil {
+		return skip(err.Error())
+	}
+	target.NetworkID = networkID
+
+	saved, err := s.deviceService.SaveImported(target)
+	if err != nil {
+		return skip(fmt.Sprintf("failed to save device: %v", err))
+	}
+	deviceResult.DeviceID = saved.ID
+	return deviceResult
+}
+
+// networkResolver tracks known networks and the ones an import creates
+type networkResolver struct {
+	byCIDR  map[string]*models.Network
+	byID    map[string]*models.Network
+	created []string
+}
+
+func newNetworkResolver(networks []*models.Network) *networkResolver {
+	r := &networkResolver{
+		byCIDR:  make(map[string]*models.Network),
+		byID:    make(map[string]*models.Network),
+		created: []string{},
+	}
+	for _, n := range networks {
+		r.byCIDR[n.CIDR] = n
+		r.byID[n.ID] = n
+	}
+	return r
+}
+
+func (r *networkResolver) cidrOf(id string) string {
+	if n, ok := r.byID[id]; ok {
+		return n.CIDR
+	}
+	return ""
+}
+
+// containing returns the most specific known network containing the IP
+func (r *networkResolver) containing(ipv4 string) string {
+	ip := net.ParseIP(ipv4)
+	best := ""
+	bestSize := -1
+	for cidr := range r.byCIDR {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil || !ipNet.Contains(ip) {
+			continue
+		}
+		if size, _ := ipNet.Mask.Size(); size > bestSize {
+			best, bestSize = cidr, size
+		}
+	}
+	return best
+}
+
+// plan records a network a dry run would create
+func (r *networkResolver) plan(cidr string) {
+	if _, ok := r.byCIDR[cidr]; ok {
+		return
+	}
+	r.byCIDR[cidr] = &models.Network{CIDR: cidr}
+	r.created = append(r.created, cidr)
+}
+
+// ensure returns the ID of the network, creating it if needed
+func (r *networkResolver) ensure(networkService *network.NetworkService, cidr string) (string, error) {
+	if n, ok := r.byCIDR[cidr]; ok && n.ID != "" {
+		return n.ID, nil
+	}
+	n, err := networkService.FindOrCreate(cidr)
+	if err != nil {
+		return "", fmt.Errorf("failed to create network %s: %v", cidr, err)
+	}
+	r.byCIDR[cidr] = n
+	r.byID[n.ID] = n
+	r.created = append(r.created, cidr)
+	return n.ID, nil
+}
+
+func normalizeCIDR(cidr string) (string, error) {
+	_, ipNet, err := net.ParseCIDR(strings.TrimSpace(cidr))
+	if err != nil || ipNet.IP.To4() == nil {
+		return "", fmt.Errorf("invalid IPv4 network %q", cidr)
+	}
+	return ipNet.String(), nil
+}
+
+// defaultCIDR returns the /24 network containing the address
+func defaultCIDR(ipv4 string) string {
+	ip := net.ParseIP(ipv4).To4()
+	return fmt.Sprintf("%d.%d.%d.0/24", ip[0], ip[1], ip[2])
+}
+
+// normalizeMAC formats a MAC address like nmap does (upper case, colon separated)
+func normalizeMAC(mac string) (string, error) {
+	hw, err := net.ParseMAC(strings.TrimSpace(mac))
+	if err != nil || len(hw) != 6 {
+		// dhcpd and ethers allow leading zeros to be dropped ("0:1a:...")
+		parts := strings.FieldsFunc(mac, func(r rune) bool { return r == ':' || r == '-' })
+		if len(parts) != 6 {
+			return "", fmt.Errorf("invalid MAC address %q", mac)
+		}
+		for i, part := range parts {
+			if len(part) == 1 {
+				parts[i] = "0" + part
+			}
+		}
+		hw, err = net.ParseMAC(strings.Join(parts, ":"))
+		if err != nil {
+			return "", fmt.Errorf("invalid MAC address %q", mac)
+		}
+	}
+	return strings.ToUpper(hw.String()), nil
+}
+
+func portList(ports []models.Port) string {
+	entries := make([]string, 0, len(ports))
+	for _, port := range ports {
+		entries = append(entries, port.Number+"/"+port.Protocol)
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, ",")
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
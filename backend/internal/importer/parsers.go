@@ -0,0 +1,490 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"reconya-ai/models"
+)
+
+// Format is a supported import file format
+type Format string
+
+const (
+	FormatAuto         Format = "auto"
+	FormatNmapXML      Format = "nmap-xml"
+	FormatNmapGrepable Format = "nmap-grepable"
+	FormatCSV          Format = "csv"
+	FormatDhcpd        Format = "dhcpd"
+	FormatDnsmasq      Format = "dnsmasq"
+	FormatKea          Format = "kea"
+	FormatEthers       Format = "ethers"
+)
+
+// Formats lists the formats that can be imported
+var Formats = []Format{FormatNmapXML, FormatNmapGrepable, FormatCSV, FormatDhcpd, FormatDnsmasq, FormatKea, FormatEthers}
+
+// ParseFormat validates a format name; an empty name means auto detection
+func ParseFormat(name string) (Format, error) {
+	f := Format(strings.ToLower(strings.TrimSpace(name)))
+	if f == "" || f == FormatAuto {
+		return FormatAuto, nil
+	}
+	for _, known := range Formats {
+		if f == known {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("unknown import format %q", name)
+}
+
+// Record is a device read from an import file. Empty fields are left unchanged
+// on existing devices.
+type Record struct {
+	IPv4     string
+	MAC      string
+	Hostname string
+	Name     string
+	Vendor   string
+	Comment  string
+	// Network CIDR the device belongs to
+	Network string
+	Tags    []string
+	Ports   []models.Port
+	// Line or entry the record came from, used in reports
+	Source string
+}
+
+var (
+	dhcpdLeaseLine   = regexp.MustCompile(`(?m)^\s*lease\s+[0-9.]+\s*\{`)
+	dnsmasqLeaseLine = regexp.MustCompile(`(?m)^\d+\s+([0-9a-fA-F]{2}[:-]){5}[0-9a-fA-F]{2}\s+\S+\s+\S+`)
+	ethersLine       = regexp.MustCompile(`(?m)^\s*([0-9a-fA-F]{1,2}[:-]){5}[0-9a-fA-F]{1,2}\s+\S+\s*$`)
+)
+
+// DetectFormat guesses the format from the file name and content
+func DetectFormat(filename string, data []byte) (Format, error) {
+	base := strings.ToLower(filepath.Base(filename))
+	head := string(data)
+	if len(head) > 4096 {
+		head = head[:4096]
+	}
+	trimmed := strings.TrimSpace(head)
+
+	switch {
+	case strings.HasPrefix(trimmed, "<?xml") || strings.Contains(head, "<nmaprun"):
+		return FormatNmapXML, nil
+	case strings.Contains(head, "# Nmap") && strings.Contains(head, "Host: "):
+		return FormatNmapGrepable, nil
+	case strings.HasPrefix(trimmed, "Host: "):
+		return FormatNmapGrepable, nil
+	case dhcpdLeaseLine.MatchString(head):
+		return FormatDhcpd, nil
+	case strings.HasPrefix(trimmed, "address,hwaddr,"):
+		return FormatKea, nil
+	case base == "ethers":
+		return FormatEthers, nil
+	case dnsmasqLeaseLine.MatchString(head):
+		return FormatDnsmasq, nil
+	case ethersLine.MatchString(head):
+		return FormatEthers, nil
+	case strings.HasSuffix(base, ".csv") || strings.Contains(strings.SplitN(head, "\n", 2)[0], ","):
+		return FormatCSV, nil
+	}
+	return "", fmt.Errorf("unable to detect import format, please specify one of %v", Formats)
+}
+
+// Parse reads the records of a file in the given format
+func Parse(format Format, data []byte) ([]Record, error) {
+	switch format {
+	case FormatNmapXML:
+		return parseNmapXML(data)
+	case FormatNmapGrepable:
+		return parseNmapGrepable(data)
+	case FormatCSV:
+		return parseCSV(data)
+	case FormatDhcpd:
+		return parseDhcpdLeases(data)
+	case FormatDnsmasq:
+		return parseDnsmasqLeases(data)
+	case FormatKea:
+		return parseKeaLeases(data)
+	case FormatEthers:
+		return parseEthers(data)
+	}
+	return nil, fmt.Errorf("unknown import format %q", format)
+}
+
+func parseNmapXML(data []byte) ([]Record, error) {
+	var run models.NmapXML
+	if err := xml.Unmarshal(data, &run); err != nil {
+		return nil, fmt.Errorf("invalid nmap XML: %v", err)
+	}
+
+	var records []Record
+	for i, host := range run.Hosts {
+		if host.Status.State != "" && host.Status.State != "up" {
+			continue
+		}
+
+		record := Record{Source: fmt.Sprintf("host %d", i+1)}
+		for _, address := range host.Addresses {
+			switch address.AddrType {
+			case "ipv4":
+				record.IPv4 = address.Addr
+			case "mac":
+				record.MAC = address.Addr
+				record.Vendor = address.Vendor
+			}
+		}
+		if len(host.Hostnames) > 0 {
+			record.Hostname = host.Hostnames[0].Name
+		}
+		for _, port := range host.Ports {
+			if port.State.State != "open" {
+				continue
+			}
+			record.Ports = append(record.Ports, models.Port{
+				Number:   port.PortID,
+				Protocol: port.Protocol,
+				State:    port.State.State,
+				Service:  port.Service.Name,
+			})
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+var grepableHost = regexp.MustCompile(`^Host:\s+(\S+)\s+\(([^)]*)\)`)
+
+// parseNmapGrepable reads nmap -oG output. A host appears on a status line and,
+// when ports were scanned, on a ports line.
+func parseNmapGrepable(data []byte) ([]Record, error) {
+	byIP := make(map[string]*Record)
+	var order []string
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := scanner.Text()
+		match := grepableHost.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+
+		ip := match[1]
+		record, ok := byIP[ip]
+		if !ok {
+			record = &Record{IPv4: ip, Hostname: match[2], Source: fmt.Sprintf("line %d", lineNumber)}
+			byIP[ip] = record
+			order = append(order, ip)
+		}
+
+		for _, field := range strings.Split(line, "\t") {
+			field = strings.TrimSpace(field)
+			switch {
+			case strings.HasPrefix(field, "Status: ") && !strings.HasPrefix(field, "Status: Up"):
+				record.Source = "down"
+			case strings.HasPrefix(field, "Ports: "):
+				record.Ports = append(record.Ports, parseGrepablePorts(strings.TrimPrefix(field, "Ports: "))...)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	var records []Record
+	for _, ip := range order {
+		if byIP[ip].Source == "down" {
+			continue
+		}
+		records = append(records, *byIP[ip])
+	}
+	return records, nil
+}
+
+// parseGrepablePorts reads entries such as "22/open/tcp//ssh///"
+func parseGrepablePorts(value string) []models.Port {
+	var ports []models.Port
+	for _, entry := range strings.Split(value, ",") {
+		parts := strings.Split(strings.TrimSpace(entry), "/")
+		if len(parts) < 5 || parts[1] != "open" {
+			continue
+		}
+		ports = append(ports, models.Port{Number: parts[0], Protocol: parts[2], State: parts[1], Service: parts[4]})
+	}
+	return ports
+}
+
+// csvColumns maps accepted header names to record fields
+var csvColumns = map[string]string{
+	"ip": "ipv4", "ipv4": "ipv4", "ip_address": "ipv4", "address": "ipv4",
+	"mac": "mac", "mac_address": "mac", "hwaddr": "mac",
+	"hostname": "hostname", "host": "hostname",
+	"name":    "name",
+	"vendor":  "vendor",
+	"comment": "comment", "description": "comment", "notes": "comment",
+	"network": "network", "cidr": "network", "network_cidr": "network",
+	"tags":  "tags",
+	"ports": "ports", "open_ports": "ports",
+}
+
+// parseCSV reads an inventory with a header row. The export format of
+// /api/export is accepted as well.
+func parseCSV(data []byte) ([]Record, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %v", err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		key := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if field, ok := csvColumns[key]; ok {
+			if _, exists := columns[field]; !exists {
+				columns[field] = i
+			}
+		}
+	}
+	if _, ok := columns["ipv4"]; !ok {
+		if _, ok := columns["mac"]; !ok {
+			return nil, fmt.Errorf("CSV needs an ip or mac column")
+		}
+	}
+
+	var records []Record
+	line := 1
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+
+		value := func(field string) string {
+			if i, ok := columns[field]; ok && i < len(row) {
+				return strings.TrimSpace(row[i])
+			}
+			return ""
+		}
+
+		record := Record{
+			IPv4:     value("ipv4"),
+			MAC:      value("mac"),
+			Hostname: value("hostname"),
+			Name:     value("name"),
+			Vendor:   value("vendor"),
+			Comment:  value("comment"),
+			Network:  value("network"),
+			Source:   fmt.Sprintf("line %d", line),
+		}
+		if tags := value("tags"); tags != "" {
+			record.Tags = splitList(tags)
+		}
+		for _, entry := range splitList(value("ports")) {
+			number, protocol, _ := strings.Cut(entry, "/")
+			if protocol == "" {
+				protocol = "tcp"
+			}
+			record.Ports = append(record.Ports, models.Port{Number: number, Protocol: protocol, State: "open"})
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// parseDhcpdLeases reads an ISC dhcpd leases file. Later entries for the same
+// address replace earlier ones, as dhcpd appends lease updates.
+func parseDhcpdLeases(data []byte) ([]Record, error) {
+	byIP := make(map[string]*Record)
+	var order []string
+
+	var current *Record
+	state := ""
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "lease ") && strings.HasSuffix(line, "{") {
+			fields := strings.Fields(line)
+			current = &Record{IPv4: fields[1], Source: fmt.Sprintf("line %d", lineNumber)}
+			state = ""
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		if line == "}" {
+			// Freed and expired leases no longer describe a device
+			if state == "" || state == "active" || state == "static" {
+				if _, ok := byIP[current.IPv4]; !ok {
+					order = append(order, current.IPv4)
+				}
+				byIP[current.IPv4] = current
+			}
+			current = nil
+			continue
+		}
+
+		statement := strings.TrimSuffix(line, ";")
+		switch {
+		case strings.HasPrefix(statement, "hardware ethernet "):
+			current.MAC = strings.TrimPrefix(statement, "hardware ethernet ")
+		case strings.HasPrefix(statement, "client-hostname "):
+			current.Hostname = strings.Trim(strings.TrimPrefix(statement, "client-hostname "), `"`)
+		case strings.HasPrefix(statement, "binding state "):
+			state = strings.TrimPrefix(statement, "binding state ")
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	records := make([]Record, 0, len(order))
+	for _, ip := range order {
+		records = append(records, *byIP[ip])
+	}
+	return records, nil
+}
+
+// parseDnsmasqLeases reads "expiry mac ip hostname client-id" lines
+func parseDnsmasqLeases(data []byte) ([]Record, error) {
+	var records []Record
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || net.ParseIP(fields[2]).To4() == nil {
+			continue
+		}
+		record := Record{MAC: fields[1], IPv4: fields[2], Source: fmt.Sprintf("line %d", lineNumber)}
+		if fields[3] != "*" {
+			record.Hostname = fields[3]
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
+
+// parseKeaLeases reads a Kea DHCPv4 memfile lease CSV
+func parseKeaLeases(data []byte) ([]Record, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Kea lease header: %v", err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+	for _, required := range []string{"address", "hwaddr"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("Kea lease file has no %s column", required)
+		}
+	}
+
+	byIP := make(map[string]*Record)
+	var order []string
+	line := 1
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		value := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(row) {
+				return strings.TrimSpace(row[i])
+			}
+			return ""
+		}
+
+		// State 0 is a default (active) lease; declined and expired leases are skipped
+		if state := value("state"); state != "" && state != "0" {
+			continue
+		}
+
+		record := &Record{
+			IPv4:     value("address"),
+			MAC:      value("hwaddr"),
+			Hostname: strings.TrimSuffix(value("hostname"), "."),
+			Source:   fmt.Sprintf("line %d", line),
+		}
+		if _, ok := byIP[record.IPv4]; !ok {
+			order = append(order, record.IPv4)
+		}
+		byIP[record.IPv4] = record
+	}
+
+	records := make([]Record, 0, len(order))
+	for _, ip := range order {
+		records = append(records, *byIP[ip])
+	}
+	return records, nil
+}
+
+// parseEthers reads /etc/ethers "mac hostname-or-ip" lines. Host names only
+// identify a device by MAC address.
+func parseEthers(data []byte) ([]Record, error) {
+	var records []Record
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+
+		record := Record{MAC: fields[0], Source: fmt.Sprintf("line %d", lineNumber)}
+		if ip := net.ParseIP(fields[1]); ip != nil && ip.To4() != nil {
+			record.IPv4 = fields[1]
+		} else {
+			record.Hostname = fields[1]
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.FieldsFunc(value, func(r rune) bool { return r == ';' || r == ',' }) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,141 @@
+package importer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const nmapXMLSample = `<?xml version="1.0"?>
+<nmaprun>
+<host><status state="up"/>
+<address addr="192.168.1.10" addrtype="ipv4"/>
+<address addr="AA:BB:CC:00:11:22" addrtype="mac" vendor="Acme"/>
+<hostnames><hostname name="nas.lan" type="PTR"/></hostnames>
+<ports>
+<port protocol="tcp" portid="22"><state state="open"/><service name="ssh"/></port>
+<port protocol="tcp" portid="25"><state state="closed"/><service name="smtp"/></port>
+</ports>
+</host>
+<host><status state="down"/><address addr="192.168.1.11" addrtype="ipv4"/></host>
+</nmaprun>`
+
+const nmapGrepableSample = `# Nmap 7.94 scan initiated as: nmap -oG - 192.168.1.0/24
+Host: 192.168.1.10 (nas.lan)	Status: Up
+Host: 192.168.1.10 (nas.lan)	Ports: 22/open/tcp//ssh///, 80/closed/tcp//http///, 443/open/tcp//https///
+Host: 192.168.1.11 ()	Status: Down
+# Nmap done
+`
+
+const dhcpdSample = `# The format of this file is documented in the dhcpd.leases(5) manual page.
+lease 192.168.1.50 {
+  starts 4 2025/06/01 10:00:00;
+  binding state active;
+  hardware ethernet 0:1a:2b:3c:4d:5e;
+  client-hostname "laptop";
+}
+lease 192.168.1.51 {
+  binding state free;
+  hardware ethernet 00:1a:2b:3c:4d:5f;
+}
+lease 192.168.1.50 {
+  binding state active;
+  hardware ethernet 00:1a:2b:3c:4d:5e;
+  client-hostname "laptop-renamed";
+}
+`
+
+const keaSample = `address,hwaddr,client_id,valid_lifetime,expire,subnet_id,fqdn_fwd,fqdn_rev,hostname,state,user_context
+192.168.1.60,aa:bb:cc:dd:ee:01,,3600,1717236000,1,0,0,printer.lan.,0,
+192.168.1.61,aa:bb:cc:dd:ee:02,,3600,1717236000,1,0,0,,2,
+`
+
+func TestDetectFormat(t *testing.T) {
+	cases := map[string]struct {
+		filename string
+		data     string
+	}{
+		string(FormatNmapXML):      {"scan.xml", nmapXMLSample},
+		string(FormatNmapGrepable): {"scan.gnmap", nmapGrepableSample},
+		string(FormatDhcpd):        {"dhcpd.leases", dhcpdSample},
+		string(FormatKea):          {"kea-leases4.csv", keaSample},
+		string(FormatDnsmasq):      {"dnsmasq.leases", "1717236000 aa:bb:cc:dd:ee:03 192.168.1.70 phone 01:aa:bb:cc:dd:ee:03\n"},
+		string(FormatEthers):       {"ethers", "aa:bb:cc:dd:ee:04 tv\n"},
+		string(FormatCSV):          {"inventory.csv", "ip,name\n192.168.1.80,router\n"},
+	}
+
+	for expected, c := range cases {
+		format, err := DetectFormat(c.filename, []byte(c.data))
+		require.NoError(t, err, expected)
+		assert.Equal(t, Format(expected), format)
+	}
+}
+
+func TestParseNmap(t *testing.T) {
+	records, err := Parse(FormatNmapXML, []byte(nmapXMLSample))
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "192.168.1.10", records[0].IPv4)
+	assert.Equal(t, "Acme", records[0].Vendor)
+	assert.Equal(t, "nas.lan", records[0].Hostname)
+	require.Len(t, records[0].Ports, 1)
+	assert.Equal(t, "22", records[0].Ports[0].Number)
+
+	records, err = Parse(FormatNmapGrepable, []byte(nmapGrepableSample))
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "nas.lan", records[0].Hostname)
+	require.Len(t, records[0].Ports, 2)
+	assert.Equal(t, "443", records[0].Ports[1].Number)
+}
+
+func TestParseLeases(t *testing.T) {
+	records, err := Parse(FormatDhcpd, []byte(dhcpdSample))
+	require.NoError(t, err)
+	require.Len(t, records, 1, "free leases are skipped and renewals replace earlier entries")
+	assert.Equal(t, "laptop-renamed", records[0].Hostname)
+
+	records, err = Parse(FormatKea, []byte(keaSample))
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "printer.lan", records[0].Hostname)
+
+	records, err = Parse(FormatDnsmasq, []byte("1717236000 aa:bb:cc:dd:ee:03 192.168.1.70 * *\n"))
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Empty(t, records[0].Hostname)
+
+	records, err = Parse(FormatEthers, []byte("# comment\naa:bb:cc:dd:ee:04 tv\naa:bb:cc:dd:ee:05 192.168.1.90\n"))
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, "tv", records[0].Hostname)
+	assert.Equal(t, "192.168.1.90", records[1].IPv4)
+}
+
+func TestParseCSV(t *testing.T) {
+	data := "IP,MAC,Name,Tags,Open_Ports,Network\n" +
+		"192.168.1.80,aa:bb:cc:dd:ee:06,router,core;lan,22/tcp;53/udp,192.168.1.0/24\n"
+
+	records, err := Parse(FormatCSV, []byte(data))
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "192.168.1.80", records[0].IPv4)
+	assert.Equal(t, "router", records[0].Name)
+	assert.Equal(t, []string{"core", "lan"}, records[0].Tags)
+	require.Len(t, records[0].Ports, 2)
+	assert.Equal(t, "udp", records[0].Ports[1].Protocol)
+	assert.Equal(t, "192.168.1.0/24", records[0].Network)
+
+	_, err = Parse(FormatCSV, []byte("name,comment\nrouter,core\n"))
+	assert.Error(t, err)
+}
+
+func TestNormalizeMAC(t *testing.T) {
+	mac, err := normalizeMAC("0:1a:2b:3c:4d:5e")
+	require.NoError(t, err)
+	assert.Equal(t, "00:1A:2B:3C:4D:5E", mac)
+
+	_, err = normalizeMAC("not-a-mac")
+	assert.Error(t, err)
+}
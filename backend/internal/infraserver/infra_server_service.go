@@ -0,0 +1,256 @@
+package infraserver
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"reconya-ai/db"
+	"reconya-ai/internal/config"
+	"reconya-ai/internal/eventlog"
+	"reconya-ai/internal/network"
+	"reconya-ai/models"
+)
+
+// InfraServerService tracks the routers and DHCP servers seen on each network
+// and raises an Alert when one that is not allowed appears
+type InfraServerService struct {
+	repository      db.InfraServerRepository
+	networkService  *network.NetworkService
+	eventLogService *eventlog.EventLogService
+	// allowRouters and allowDHCP hold the IP and MAC addresses of servers that
+	// are allowed when first seen
+	allowRouters []string
+	allowDHCP    []string
+	mu           sync.Mutex
+}
+
+func NewInfraServerService(repository db.InfraServerRepository, networkService *network.NetworkService, eventLogService *eventlog.EventLogService, cfg *config.Config) *InfraServerService {
+	return &InfraServerService{
+		repository:      repository,
+		networkService:  networkService,
+		eventLogService: eventLogService,
+		allowRouters:    cfg.AllowedRouters,
+		allowDHCP:       cfg.AllowedDHCPServers,
+	}
+}
+
+// Record stores a sighting of a router or DHCP server. A server seen before is
+// updated with what it announced this time; a new one is added and, unless
+// the allow-list covers it, raises an Alert. It returns the stored server and
+// whether it is new.
+func (s *InfraServerService) Record(sighting *models.InfraServer) (*models.InfraServer, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ctx := context.Background()
+	if sighting.NetworkID == "" {
+		networks, err := s.networkService.FindAll()
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to load networks: %v", err)
+		}
+		sighting.NetworkID = networkFor(sighting, networks)
+	}
+
+	known, err := s.repository.FindAll(ctx, sighting.NetworkID)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to load infra servers: %v", err)
+	}
+
+	now := time.Now()
+	if existing := findServer(known, sighting); existing != nil {
+		if existing.MAC == "" {
+			existing.MAC = sighting.MAC
+		}
+		if sighting.Interface != "" {
+			existing.Interface = sighting.Interface
+		}
+		existing.Prefixes = sighting.Prefixes
+		existing.Flags = sighting.Flags
+		existing.RouterLifetimeSeconds = sighting.RouterLifetimeSeconds
+		existing.OfferedAddress = sighting.OfferedAddress
+		existing.DNSServers = sighting.DNSServers
+		existing.LastSeenAt = now
+		if err := s.repository.Save(ctx, existing); err != nil {
+			return nil, false, fmt.Errorf("failed to update infra server: %v", err)
+		}
+		return existing, false, nil
+	}
+
+	sighting.ID = ""
+	sighting.Allowed = s.allowListed(sighting)
+	sighting.FirstSeenAt = now
+	sighting.LastSeenAt = now
+	if err := s.repository.Save(ctx, sighting); err != nil {
+		return nil, false, fmt.Errorf("failed to save infra server: %v", err)
+	}
+
+	if sighting.Allowed {
+		log.Printf("Allowed %s server %s (%s) seen", sighting.Kind, sighting.IP, sighting.MAC)
+		return sighting, true, nil
+	}
+
+	source := models.AlertSourceRogueDHCP
+	if sighting.Kind == models.InfraServerRouter {
+		source = models.AlertSourceRogueRouter
+	}
+	payload := &models.AlertPayload{
+		Source:    source,
+		NetworkID: sighting.NetworkID,
+		IP:        sighting.IP,
+		MAC:       sighting.MAC,
+		Server:    sighting,
+	}
+	if err := s.eventLogService.Log(models.Alert, describe(sighting), "", payload); err != nil {
+		log.Printf("Failed to log alert for %s server %s: %v", sighting.Kind, sighting.IP, err)
+	}
+	return sighting, true, nil
+}
+
+// FindAll returns the servers seen on a network, or on every network when
+// networkID is empty
+func (s *InfraServerService) FindAll(networkID string) ([]*models.InfraServer, error) {
+	servers, err := s.repository.FindAll(context.Background(), networkID)
+	if err != nil {
+		return nil, err
+	}
+	if servers == nil {
+		servers = []*models.InfraServer{}
+	}
+	return servers, nil
+}
+
+// SetAllowed adds a server to the allow-list or removes it
+func (s *InfraServerService) SetAllowed(id string, allowed bool) (*models.InfraServer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ctx := context.Background()
+	server, err := s.repository.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	server.Allowed = allowed
+	if err := s.repository.Save(ctx, server); err != nil {
+		return nil, err
+	}
+	return server, nil
+}
+
+// Delete forgets a server; it raises a new Alert when seen again unless the
+// allow-list covers it
+func (s *InfraServerService) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.repository.Delete(context.Background(), id)
+}
+
+// allowListed reports whether the configured allow-list covers a server
+func (s *InfraServerService) allowListed(server *models.InfraServer) bool {
+	entries := s.allowDHCP
+	if server.Kind == models.InfraServerRouter {
+		entries = s.allowRouters
+	}
+	for _, entry := range entries {
+		if strings.EqualFold(entry, server.IP) || (server.MAC != "" && strings.EqualFold(entry, server.MAC)) {
+			return true
+		}
+	}
+	return false
+}
+
+// findServer returns the known server a sighting is of. A sighting without a
+// MAC address, or of a server stored without one, matches on the address.
+func findServer(known []*models.InfraServer, sighting *models.InfraServer) *models.InfraServer {
+	for _, server := range known {
+		if server.SameServer(sighting) {
+			return server
+		}
+	}
+	for _, server := range known {
+		if server.Kind == sighting.Kind && server.IP == sighting.IP && (server.MAC == "" || sighting.MAC == "") {
+			return server
+		}
+	}
+	return nil
+}
+
+// networkFor returns the ID of the network a server serves: the network of
+// the prefixes a router advertises or the address a DHCP server offers, then
+// the network of the interface it was seen on. It is empty when none matches.
+func networkFor(server *models.InfraServer, networks []models.Network) string {
+	for i := range networks {
+		network := &networks[i]
+		switch server.Kind {
+		case models.InfraServerRouter:
+			for _, prefix := range server.Prefixes {
+				if ip, _, err := net.ParseCIDR(prefix); err == nil && network.ContainsIPv6(ip.String()) {
+					return network.ID
+				}
+			}
+		case models.InfraServerDHCPv4:
+			if network.ContainsIPv4(server.OfferedAddress) || network.ContainsIPv4(server.IP) {
+				return network.ID
+			}
+		case models.InfraServerDHCPv6:
+			if network.ContainsIPv6(server.OfferedAddress) {
+				return network.ID
+			}
+		}
+	}
+
+	if server.Interface == "" {
+		return ""
+	}
+	iface, err := net.InterfaceByName(server.Interface)
+	if err != nil {
+		return ""
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return ""
+	}
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		for i := range networks {
+			if networks[i].ContainsIPv4(ipNet.IP.String()) || networks[i].ContainsIPv6(ipNet.IP.String()) {
+				return networks[i].ID
+			}
+		}
+	}
+	return ""
+}
+
+// describe returns the description of the Alert a new server raises
+func describe(server *models.InfraServer) string {
+	var b strings.Builder
+	switch server.Kind {
+	case models.InfraServerRouter:
+		b.WriteString("Unknown IPv6 router ")
+	case models.InfraServerDHCPv4:
+		b.WriteString("Unknown DHCP server ")
+	case models.InfraServerDHCPv6:
+		b.WriteString("Unknown DHCPv6 server ")
+	}
+	b.WriteString(server.IP)
+	if server.MAC != "" {
+		fmt.Fprintf(&b, " (%s)", server.MAC)
+	}
+	if server.Interface != "" {
+		fmt.Fprintf(&b, " on %s", server.Interface)
+	}
+	if len(server.Prefixes) > 0 {
+		fmt.Fprintf(&b, " advertising %s", strings.Join(server.Prefixes, ", "))
+	}
+	if server.OfferedAddress != "" {
+		fmt.Fprintf(&b, " offered %s", server.OfferedAddress)
+	}
+	return b.String()
+}
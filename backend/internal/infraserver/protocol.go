@@ -0,0 +1,298 @@
+package infraserver
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+
+	"reconya-ai/models"
+)
+
+// ICMPv6 router advertisement layout (RFC 4861)
+const (
+	raHeaderLen       = 16
+	ndOptSourceLLAddr = 1
+	ndOptPrefixInfo   = 3
+	ndOptRDNSS        = 25 // RFC 8106
+)
+
+// parseRouterAdvertisement decodes an ICMPv6 router advertisement sent from
+// source. The MAC address comes from the source link-layer address option
+// when the router includes it.
+func parseRouterAdvertisement(source net.IP, message []byte) (*models.InfraServer, error) {
+	if len(message) < raHeaderLen || message[0] != 134 {
+		return nil, fmt.Errorf("not a router advertisement")
+	}
+
+	flags := message[5]
+	lifetime := int(binary.BigEndian.Uint16(message[6:8]))
+	server := &models.InfraServer{
+		Kind:                  models.InfraServerRouter,
+		IP:                    source.String(),
+		RouterLifetimeSeconds: &lifetime,
+	}
+	if flags&0x80 != 0 {
+		server.Flags = append(server.Flags, models.RAFlagManaged)
+	}
+	if flags&0x40 != 0 {
+		server.Flags = append(server.Flags, models.RAFlagOtherConfig)
+	}
+	if flags&0x20 != 0 {
+		server.Flags = append(server.Flags, models.RAFlagHomeAgent)
+	}
+	switch (flags >> 3) & 0x3 {
+	case 1:
+		server.Flags = append(server.Flags, models.RAPreferenceHigh)
+	case 3:
+		server.Flags = append(server.Flags, models.RAPreferenceLow)
+	}
+
+	options := message[raHeaderLen:]
+	for len(options) >= 2 {
+		length := int(options[1]) * 8
+		if length == 0 || length > len(options) {
+			return nil, fmt.Errorf("invalid router advertisement option length")
+		}
+		option := options[:length]
+
+		switch options[0] {
+		case ndOptSourceLLAddr:
+			if length >= 8 {
+				server.MAC = strings.ToUpper(net.HardwareAddr(option[2:8]).String())
+			}
+		case ndOptPrefixInfo:
+			if length == 32 {
+				prefix := &net.IPNet{IP: net.IP(append([]byte(nil), option[16:32]...)), Mask: net.CIDRMask(int(option[2]), 128)}
+				server.Prefixes = append(server.Prefixes, prefix.String())
+			}
+		case ndOptRDNSS:
+			for addresses := option[8:]; len(addresses) >= net.IPv6len; addresses = addresses[net.IPv6len:] {
+				server.DNSServers = append(server.DNSServers, net.IP(addresses[:net.IPv6len]).String())
+			}
+		}
+		options = options[length:]
+	}
+
+	return server, nil
+}
+
+// routerSolicitation returns an ICMPv6 router solicitation; the kernel fills
+// in the checksum
+func routerSolicitation() []byte {
+	return []byte{133, 0, 0, 0, 0, 0, 0, 0}
+}
+
+// DHCPv4 message layout (RFC 2131, RFC 2132)
+const (
+	dhcpv4HeaderLen   = 236
+	dhcpv4MagicCookie = 0x63825363
+
+	dhcpOptRouter       = 3
+	dhcpOptDNS          = 6
+	dhcpOptMessageType  = 53
+	dhcpOptServerID     = 54
+	dhcpOptParamRequest = 55
+	dhcpOptEnd          = 255
+
+	dhcpDiscover = 1
+	dhcpOffer    = 2
+)
+
+// dhcpv4Discover returns a DHCPDISCOVER from mac. The broadcast flag asks
+// servers to broadcast their offers, which the probe receives without an
+// address of its own.
+func dhcpv4Discover(xid uint32, mac net.HardwareAddr) []byte {
+	packet := make([]byte, dhcpv4HeaderLen, 300)
+	packet[0] = 1 // BOOTREQUEST
+	packet[1] = 1 // Ethernet
+	packet[2] = 6
+	binary.BigEndian.PutUint32(packet[4:8], xid)
+	binary.BigEndian.PutUint16(packet[10:12], 0x8000)
+	copy(packet[28:44], mac)
+
+	packet = binary.BigEndian.AppendUint32(packet, dhcpv4MagicCookie)
+	packet = append(packet,
+		dhcpOptMessageType, 1, dhcpDiscover,
+		dhcpOptParamRequest, 3, 1, dhcpOptRouter, dhcpOptDNS,
+		dhcpOptEnd,
+	)
+	// Some servers ignore requests shorter than a BOOTP packet
+	for len(packet) < 300 {
+		packet = append(packet, 0)
+	}
+	return packet
+}
+
+// parseDHCPv4Offer decodes a DHCPOFFER answering xid. source is the sender's
+// address, used when the offer has no server identifier.
+func parseDHCPv4Offer(data []byte, xid uint32, source net.IP) (*models.InfraServer, error) {
+	if len(data) < dhcpv4HeaderLen+4 || data[0] != 2 {
+		return nil, fmt.Errorf("not a DHCP reply")
+	}
+	if binary.BigEndian.Uint32(data[4:8]) != xid {
+		return nil, fmt.Errorf("DHCP reply for another transaction")
+	}
+	if binary.BigEndian.Uint32(data[dhcpv4HeaderLen:dhcpv4HeaderLen+4]) != dhcpv4MagicCookie {
+		return nil, fmt.Errorf("DHCP reply without magic cookie")
+	}
+
+	server := &models.InfraServer{
+		Kind:           models.InfraServerDHCPv4,
+		OfferedAddress: net.IP(data[16:20]).String(),
+	}
+	if source != nil {
+		server.IP = source.String()
+	}
+
+	messageType := 0
+	options := data[dhcpv4HeaderLen+4:]
+	for len(options) > 0 {
+		code := options[0]
+		if code == dhcpOptEnd {
+			break
+		}
+		if code == 0 {
+			options = options[1:]
+			continue
+		}
+		if len(options) < 2 || int(options[1])+2 > len(options) {
+			return nil, fmt.Errorf("invalid DHCP option length")
+		}
+		value := options[2 : 2+int(options[1])]
+
+		switch code {
+		case dhcpOptMessageType:
+			if len(value) == 1 {
+				messageType = int(value[0])
+			}
+		case dhcpOptServerID:
+			if len(value) == 4 {
+				server.IP = net.IP(value).String()
+			}
+		case dhcpOptDNS:
+			for ; len(value) >= 4; value = value[4:] {
+				server.DNSServers = append(server.DNSServers, net.IP(value[:4]).String())
+			}
+		}
+		options = options[2+int(options[1]):]
+	}
+
+	if messageType != dhcpOffer {
+		return nil, fmt.Errorf("DHCP message type %d is not an offer", messageType)
+	}
+	if server.IP == "" {
+		return nil, fmt.Errorf("DHCP offer without server address")
+	}
+	return server, nil
+}
+
+// DHCPv6 message layout (RFC 8415)
+const (
+	dhcpv6Solicit   = 1
+	dhcpv6Advertise = 2
+
+	dhcpv6OptClientID    = 1
+	dhcpv6OptServerID    = 2
+	dhcpv6OptIANA        = 3
+	dhcpv6OptIAAddr      = 5
+	dhcpv6OptORO         = 6
+	dhcpv6OptElapsedTime = 8
+	dhcpv6OptDNSServers  = 23
+)
+
+// dhcpv6SolicitMessage returns a Solicit for one non-temporary address,
+// identified by a link-layer DUID of mac
+func dhcpv6SolicitMessage(transactionID uint32, mac net.HardwareAddr) []byte {
+	option := func(packet []byte, code uint16, value []byte) []byte {
+		packet = binary.BigEndian.AppendUint16(packet, code)
+		packet = binary.BigEndian.AppendUint16(packet, uint16(len(value)))
+		return append(packet, value...)
+	}
+
+	packet := []byte{dhcpv6Solicit, byte(transactionID >> 16), byte(transactionID >> 8), byte(transactionID)}
+	duid := append([]byte{0, 3, 0, 1}, mac...) // DUID-LL, Ethernet
+	packet = option(packet, dhcpv6OptClientID, duid)
+	packet = option(packet, dhcpv6OptElapsedTime, []byte{0, 0})
+	packet = option(packet, dhcpv6OptORO, []byte{0, dhcpv6OptDNSServers})
+	iana := make([]byte, 12) // IAID, T1 and T2
+	copy(iana, mac[len(mac)-4:])
+	packet = option(packet, dhcpv6OptIANA, iana)
+	return packet
+}
+
+// parseDHCPv6Advertise decodes an Advertise answering transactionID sent from
+// source. The MAC address comes from the server's DUID when it is based on
+// an Ethernet address.
+func parseDHCPv6Advertise(data []byte, transactionID uint32, source net.IP) (*models.InfraServer, error) {
+	if len(data) < 4 || data[0] != dhcpv6Advertise {
+		return nil, fmt.Errorf("not a DHCPv6 advertise")
+	}
+	if uint32(data[1])<<16|uint32(data[2])<<8|uint32(data[3]) != transactionID&0xffffff {
+		return nil, fmt.Errorf("DHCPv6 advertise for another transaction")
+	}
+
+	server := &models.InfraServer{Kind: models.InfraServerDHCPv6, IP: source.String()}
+	err := walkDHCPv6Options(data[4:], func(code uint16, value []byte) error {
+		switch code {
+		case dhcpv6OptServerID:
+			server.MAC = macFromDUID(value)
+		case dhcpv6OptDNSServers:
+			for ; len(value) >= net.IPv6len; value = value[net.IPv6len:] {
+				server.DNSServers = append(server.DNSServers, net.IP(value[:net.IPv6len]).String())
+			}
+		case dhcpv6OptIANA:
+			if len(value) < 12 {
+				return nil
+			}
+			return walkDHCPv6Options(value[12:], func(code uint16, value []byte) error {
+				if code == dhcpv6OptIAAddr && len(value) >= net.IPv6len && server.OfferedAddress == "" {
+					server.OfferedAddress = net.IP(value[:net.IPv6len]).String()
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return server, nil
+}
+
+func walkDHCPv6Options(options []byte, visit func(code uint16, value []byte) error) error {
+	for len(options) >= 4 {
+		code := binary.BigEndian.Uint16(options[0:2])
+		length := int(binary.BigEndian.Uint16(options[2:4]))
+		if 4+length > len(options) {
+			return fmt.Errorf("invalid DHCPv6 option length")
+		}
+		if err := visit(code, options[4:4+length]); err != nil {
+			return err
+		}
+		options = options[4+length:]
+	}
+	return nil
+}
+
+// macFromDUID returns the Ethernet address in a DUID-LLT or DUID-LL, or ""
+func macFromDUID(duid []byte) string {
+	if len(duid) < 4 || binary.BigEndian.Uint16(duid[2:4]) != 1 {
+		return ""
+	}
+	var mac []byte
+	switch binary.BigEndian.Uint16(duid[0:2]) {
+	case 1: // DUID-LLT has a timestamp before the address
+		if len(duid) == 14 {
+			mac = duid[8:14]
+		}
+	case 3:
+		if len(duid) == 10 {
+			mac = duid[4:10]
+		}
+	}
+	if mac == nil {
+		return ""
+	}
+	return strings.ToUpper(net.HardwareAddr(mac).String())
+}
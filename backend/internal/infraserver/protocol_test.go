@@ -0,0 +1,109 @@
+package infraserver
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+
+	"reconya-ai/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRouterAdvertisement(t *testing.T) {
+	message := []byte{134, 0, 0, 0, 64, 0x80 | 0x08, 0x07, 0x08, 0, 0, 0, 0, 0, 0, 0, 0}
+	message = append(message, ndOptSourceLLAddr, 1, 0xaa, 0xbb, 0xcc, 0, 0, 0x01)
+	prefix := make([]byte, 32)
+	prefix[0], prefix[1], prefix[2] = ndOptPrefixInfo, 4, 64
+	copy(prefix[16:], net.ParseIP("2001:db8:1::"))
+	message = append(message, prefix...)
+	rdnss := make([]byte, 24)
+	rdnss[0], rdnss[1] = ndOptRDNSS, 3
+	copy(rdnss[8:], net.ParseIP("2001:db8:1::53"))
+	message = append(message, rdnss...)
+
+	server, err := parseRouterAdvertisement(net.ParseIP("fe80::1"), message)
+	require.NoError(t, err)
+	assert.Equal(t, models.InfraServerRouter, server.Kind)
+	assert.Equal(t, "fe80::1", server.IP)
+	assert.Equal(t, "AA:BB:CC:00:00:01", server.MAC)
+	assert.Equal(t, []string{models.RAFlagManaged, models.RAPreferenceHigh}, server.Flags)
+	assert.Equal(t, 1800, *server.RouterLifetimeSeconds)
+	assert.Equal(t, []string{"2001:db8:1::/64"}, server.Prefixes)
+	assert.Equal(t, []string{"2001:db8:1::53"}, server.DNSServers)
+
+	_, err = parseRouterAdvertisement(net.ParseIP("fe80::1"), append(message[:raHeaderLen:raHeaderLen], ndOptSourceLLAddr, 0))
+	assert.Error(t, err, "zero length option")
+	_, err = parseRouterAdvertisement(net.ParseIP("fe80::1"), routerSolicitation())
+	assert.Error(t, err)
+}
+
+func TestDHCPv4(t *testing.T) {
+	mac, _ := net.ParseMAC("aa:bb:cc:00:00:02")
+	discover := dhcpv4Discover(0x1234, mac)
+	require.Len(t, discover, 300)
+	assert.Equal(t, uint32(0x1234), binary.BigEndian.Uint32(discover[4:8]))
+	assert.Equal(t, []byte(mac), discover[28:34])
+	assert.Equal(t, []byte{dhcpOptMessageType, 1, dhcpDiscover}, discover[dhcpv4HeaderLen+4:dhcpv4HeaderLen+7])
+
+	offer := make([]byte, dhcpv4HeaderLen)
+	offer[0] = 2
+	binary.BigEndian.PutUint32(offer[4:8], 0x1234)
+	copy(offer[16:20], net.ParseIP("192.168.1.150").To4())
+	offer = binary.BigEndian.AppendUint32(offer, dhcpv4MagicCookie)
+	offer = append(offer, dhcpOptMessageType, 1, dhcpOffer, 0, dhcpOptServerID, 4, 192, 168, 1, 66)
+	offer = append(offer, dhcpOptDNS, 8, 1, 1, 1, 1, 8, 8, 8, 8, dhcpOptEnd)
+
+	server, err := parseDHCPv4Offer(offer, 0x1234, net.ParseIP("192.168.1.1"))
+	require.NoError(t, err)
+	assert.Equal(t, models.InfraServerDHCPv4, server.Kind)
+	assert.Equal(t, "192.168.1.66", server.IP, "server identifier over the sender")
+	assert.Equal(t, "192.168.1.150", server.OfferedAddress)
+	assert.Equal(t, []string{"1.1.1.1", "8.8.8.8"}, server.DNSServers)
+
+	_, err = parseDHCPv4Offer(offer, 0x4321, nil)
+	assert.Error(t, err, "another transaction")
+	_, err = parseDHCPv4Offer(discover, 0x1234, nil)
+	assert.Error(t, err, "a request")
+}
+
+func TestDHCPv6(t *testing.T) {
+	mac, _ := net.ParseMAC("aa:bb:cc:00:00:03")
+	solicit := dhcpv6SolicitMessage(0xabcdef, mac)
+	assert.Equal(t, []byte{dhcpv6Solicit, 0xab, 0xcd, 0xef}, solicit[:4])
+	var codes []uint16
+	require.NoError(t, walkDHCPv6Options(solicit[4:], func(code uint16, value []byte) error {
+		codes = append(codes, code)
+		if code == dhcpv6OptClientID {
+			assert.Equal(t, "AA:BB:CC:00:00:03", macFromDUID(value))
+		}
+		return nil
+	}))
+	assert.Equal(t, []uint16{dhcpv6OptClientID, dhcpv6OptElapsedTime, dhcpv6OptORO, dhcpv6OptIANA}, codes)
+
+	option := func(packet []byte, code uint16, value []byte) []byte {
+		packet = binary.BigEndian.AppendUint16(packet, code)
+		packet = binary.BigEndian.AppendUint16(packet, uint16(len(value)))
+		return append(packet, value...)
+	}
+	serverDUID := []byte{0, 1, 0, 1, 0x2a, 0, 0, 0, 0xaa, 0xbb, 0xcc, 0, 0, 0x04} // DUID-LLT
+	iaAddr := option(nil, dhcpv6OptIAAddr, append(net.ParseIP("2001:db8:1::150").To16(), make([]byte, 8)...))
+	advertise := []byte{dhcpv6Advertise, 0xab, 0xcd, 0xef}
+	advertise = option(advertise, dhcpv6OptServerID, serverDUID)
+	advertise = option(advertise, dhcpv6OptIANA, append(make([]byte, 12), iaAddr...))
+	advertise = option(advertise, dhcpv6OptDNSServers, net.ParseIP("2001:db8:1::53").To16())
+
+	server, err := parseDHCPv6Advertise(advertise, 0xabcdef, net.ParseIP("fe80::4"))
+	require.NoError(t, err)
+	assert.Equal(t, models.InfraServerDHCPv6, server.Kind)
+	assert.Equal(t, "fe80::4", server.IP)
+	assert.Equal(t, "AA:BB:CC:00:00:04", server.MAC)
+	assert.Equal(t, "2001:db8:1::150", server.OfferedAddress)
+	assert.Equal(t, []string{"2001:db8:1::53"}, server.DNSServers)
+
+	_, err = parseDHCPv6Advertise(advertise, 0x123456, net.ParseIP("fe80::4"))
+	assert.Error(t, err)
+	_, err = parseDHCPv6Advertise(advertise[:len(advertise)-2], 0xabcdef, net.ParseIP("fe80::4"))
+	assert.Error(t, err, "truncated option")
+}
@@ -0,0 +1,326 @@
+package infraserver
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	"reconya-ai/internal/neighbor"
+	"reconya-ai/models"
+
+	"golang.org/x/net/ipv6"
+)
+
+var (
+	allRouters       = net.ParseIP("ff02::2")
+	allDHCPv6Servers = net.ParseIP("ff02::1:2")
+)
+
+const (
+	defaultProbeTimeout = 3 * time.Second
+	maxMessageSize      = 1500
+	// Neighbor discovery messages are sent with this hop limit; one received
+	// with less was forwarded and is not from the link (RFC 4861)
+	ndHopLimit = 255
+)
+
+// Watcher listens for router advertisements and probes for DHCP servers,
+// recording every server it hears from with the service
+type Watcher struct {
+	service   *InfraServerService
+	neighbors *neighbor.Table
+	timeout   time.Duration
+}
+
+// NewWatcher returns a watcher; neighbors, when set, supplies the MAC
+// addresses of servers whose messages do not carry one
+func NewWatcher(service *InfraServerService, neighbors *neighbor.Table) *Watcher {
+	return &Watcher{service: service, neighbors: neighbors, timeout: defaultProbeTimeout}
+}
+
+// ListenRouterAdvertisements records the router advertisements received on
+// any interface until ctx is done. It needs a raw ICMPv6 socket.
+func (w *Watcher) ListenRouterAdvertisements(ctx context.Context) error {
+	conn, err := net.ListenPacket("ip6:ipv6-icmp", "::")
+	if err != nil {
+		return fmt.Errorf("failed to open ICMPv6 socket: %v", err)
+	}
+	defer conn.Close()
+
+	packetConn := ipv6.NewPacketConn(conn)
+	var filter ipv6.ICMPFilter
+	filter.SetAll(true)
+	filter.Accept(ipv6.ICMPTypeRouterAdvertisement)
+	if err := packetConn.SetICMPFilter(&filter); err != nil {
+		log.Printf("Failed to set ICMPv6 filter: %v", err)
+	}
+	if err := packetConn.SetControlMessage(ipv6.FlagInterface|ipv6.FlagHopLimit, true); err != nil {
+		log.Printf("Failed to request ICMPv6 interface info: %v", err)
+	}
+
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	buf := make([]byte, maxMessageSize)
+	for {
+		n, cm, peer, err := packetConn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to read router advertisement: %v", err)
+		}
+		source, ok := peer.(*net.IPAddr)
+		if !ok {
+			continue
+		}
+		if cm != nil && cm.HopLimit != 0 && cm.HopLimit != ndHopLimit {
+			continue
+		}
+
+		server, err := parseRouterAdvertisement(source.IP, buf[:n])
+		if err != nil {
+			continue
+		}
+		if cm != nil {
+			server.Interface = interfaceName(cm.IfIndex)
+		}
+		w.record(server)
+	}
+}
+
+// Probe solicits router advertisements and DHCP offers on every interface.
+// DHCP servers that answer are recorded here; routers answer with an
+// advertisement that ListenRouterAdvertisements records.
+func (w *Watcher) Probe() {
+	interfaces := probeInterfaces()
+	if len(interfaces) == 0 {
+		return
+	}
+
+	w.solicitRouters(interfaces)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		w.probeDHCPv4(interfaces)
+	}()
+	go func() {
+		defer wg.Done()
+		w.probeDHCPv6(interfaces)
+	}()
+	wg.Wait()
+}
+
+func (w *Watcher) record(server *models.InfraServer) {
+	if server.MAC == "" && w.neighbors != nil {
+		if entry, ok := w.neighbors.Lookup(server.IP); ok {
+			server.MAC = entry.MAC
+		}
+	}
+	if _, _, err := w.service.Record(server); err != nil {
+		log.Printf("Failed to record %s server %s: %v", server.Kind, server.IP, err)
+	}
+}
+
+func (w *Watcher) solicitRouters(interfaces []net.Interface) {
+	conn, err := net.ListenPacket("ip6:ipv6-icmp", "::")
+	if err != nil {
+		log.Printf("Raw ICMPv6 sockets unavailable, not soliciting router advertisements: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	packetConn := ipv6.NewPacketConn(conn)
+	for _, iface := range interfaces {
+		cm := &ipv6.ControlMessage{HopLimit: ndHopLimit, IfIndex: iface.Index}
+		if _, err := packetConn.WriteTo(routerSolicitation(), cm, &net.IPAddr{IP: allRouters, Zone: iface.Name}); err != nil {
+			log.Printf("Failed to send router solicitation on %s: %v", iface.Name, err)
+		}
+	}
+}
+
+// probeDHCPv4 sends a DHCPDISCOVER to the broadcast address of each IPv4
+// subnet and records the servers that make an offer
+func (w *Watcher) probeDHCPv4(interfaces []net.Interface) {
+	conn, err := net.ListenPacket("udp4", ":68")
+	if err != nil {
+		log.Printf("DHCP client port unavailable, not probing for DHCP servers: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	sent := make(map[uint32]string)
+	for _, iface := range interfaces {
+		broadcast := ipv4Broadcast(iface)
+		if broadcast == nil {
+			continue
+		}
+		xid := rand.Uint32()
+		sent[xid] = iface.Name
+		if _, err := conn.WriteTo(dhcpv4Discover(xid, iface.HardwareAddr), &net.UDPAddr{IP: broadcast, Port: 67}); err != nil {
+			log.Printf("Failed to send DHCP discover on %s: %v", iface.Name, err)
+		}
+	}
+	if len(sent) == 0 {
+		return
+	}
+
+	conn.SetReadDeadline(time.Now().Add(w.timeout))
+	buf := make([]byte, maxMessageSize)
+	for {
+		n, peer, err := conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		if n < 8 {
+			continue
+		}
+		xid := uint32(buf[4])<<24 | uint32(buf[5])<<16 | uint32(buf[6])<<8 | uint32(buf[7])
+		ifaceName, ok := sent[xid]
+		if !ok {
+			continue
+		}
+
+		var source net.IP
+		if addr, ok := peer.(*net.UDPAddr); ok {
+			source = addr.IP
+		}
+		server, err := parseDHCPv4Offer(buf[:n], xid, source)
+		if err != nil {
+			continue
+		}
+		server.Interface = ifaceName
+		w.record(server)
+	}
+}
+
+// probeDHCPv6 sends a Solicit to all DHCPv6 servers and relays on each link
+// and records the servers that advertise
+func (w *Watcher) probeDHCPv6(interfaces []net.Interface) {
+	conn, err := net.ListenPacket("udp6", "[::]:546")
+	if err != nil {
+		log.Printf("DHCPv6 client port unavailable, not probing for DHCPv6 servers: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	sent := make(map[uint32]string)
+	for _, iface := range interfaces {
+		if iface.Flags&net.FlagMulticast == 0 || !hasIPv6LinkLocal(iface) {
+			continue
+		}
+		transactionID := rand.Uint32() & 0xffffff
+		sent[transactionID] = iface.Name
+		target := &net.UDPAddr{IP: allDHCPv6Servers, Port: 547, Zone: iface.Name}
+		if _, err := conn.WriteTo(dhcpv6SolicitMessage(transactionID, iface.HardwareAddr), target); err != nil {
+			log.Printf("Failed to send DHCPv6 solicit on %s: %v", iface.Name, err)
+		}
+	}
+	if len(sent) == 0 {
+		return
+	}
+
+	conn.SetReadDeadline(time.Now().Add(w.timeout))
+	buf := make([]byte, maxMessageSize)
+	for {
+		n, peer, err := conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		addr, ok := peer.(*net.UDPAddr)
+		if !ok || n < 4 {
+			continue
+		}
+		transactionID := uint32(buf[1])<<16 | uint32(buf[2])<<8 | uint32(buf[3])
+		ifaceName, ok := sent[transactionID]
+		if !ok {
+			continue
+		}
+
+		server, err := parseDHCPv6Advertise(buf[:n], transactionID, addr.IP)
+		if err != nil {
+			continue
+		}
+		server.Interface = ifaceName
+		w.record(server)
+	}
+}
+
+// probeInterfaces returns the up, non-loopback Ethernet interfaces
+func probeInterfaces() []net.Interface {
+	all, err := net.Interfaces()
+	if err != nil {
+		log.Printf("Failed to list interfaces: %v", err)
+		return nil
+	}
+
+	var interfaces []net.Interface
+	for _, iface := range all {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 || len(iface.HardwareAddr) != 6 {
+			continue
+		}
+		interfaces = append(interfaces, iface)
+	}
+	return interfaces
+}
+
+// ipv4Broadcast returns the broadcast address of the first IPv4 subnet of
+// iface, or nil
+func ipv4Broadcast(iface net.Interface) net.IP {
+	if iface.Flags&net.FlagBroadcast == 0 {
+		return nil
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil
+	}
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || ipNet.IP.To4() == nil {
+			continue
+		}
+		ip := ipNet.IP.To4()
+		mask := net.IP(ipNet.Mask).To4()
+		if mask == nil {
+			continue
+		}
+		broadcast := make(net.IP, net.IPv4len)
+		for i := range broadcast {
+			broadcast[i] = ip[i] | ^mask[i]
+		}
+		return broadcast
+	}
+	return nil
+}
+
+func hasIPv6LinkLocal(iface net.Interface) bool {
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return false
+	}
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.To4() == nil && ipNet.IP.IsLinkLocalUnicast() {
+			return true
+		}
+	}
+	return false
+}
+
+func interfaceName(index int) string {
+	if index <= 0 {
+		return ""
+	}
+	iface, err := net.InterfaceByIndex(index)
+	if err != nil {
+		return ""
+	}
+	return iface.Name
+}
@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"reconya-ai/internal/device"
+	"reconya-ai/internal/neighbor"
 	"reconya-ai/internal/network"
 	"reconya-ai/models"
 )
@@ -20,6 +21,7 @@ import (
 type IPv6MonitorService struct {
 	deviceService  *device.DeviceService
 	networkService *network.NetworkService
+	neighbors      *neighbor.Table
 	logger         *log.Logger
 
 	// Monitoring state
@@ -57,12 +59,16 @@ type IPv6Address struct {
 	MAC       string `json:"mac"`
 }
 
-func NewIPv6MonitorService(deviceService *device.DeviceService, networkService *network.NetworkService, logger *log.Logger) *IPv6MonitorService {
+// NewIPv6MonitorService creates the monitor. With a neighbor table, NDP
+// entries are processed as the kernel reports them; without one (neighbors is
+// nil) the NDP table is polled with ip or ndp.
+func NewIPv6MonitorService(deviceService *device.DeviceService, networkService *network.NetworkService, neighbors *neighbor.Table, logger *log.Logger) *IPv6MonitorService {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	return &IPv6MonitorService{
 		deviceService:     deviceService,
 		networkService:    networkService,
+		neighbors:         neighbors,
 		logger:            logger,
 		ctx:               ctx,
 		cancel:            cancel,
@@ -186,6 +192,11 @@ func (s *IPv6MonitorService) detectNetworkConfiguration() error {
 func (s *IPv6MonitorService) monitorNDPTable() {
 	defer s.wg.Done()
 
+	if s.neighbors != nil {
+		s.watchNeighbors()
+		return
+	}
+
 	ticker := time.NewTicker(30 * time.Second)
 	defer ticker.Stop()
 
@@ -199,6 +210,63 @@ func (s *IPv6MonitorService) monitorNDPTable() {
 	}
 }
 
+// watchNeighbors queues the IPv6 neighbors already known and then each one
+// the neighbor table reports
+func (s *IPv6MonitorService) watchNeighbors() {
+	events, unsubscribe := s.neighbors.Subscribe(cap(s.deviceChan))
+	defer unsubscribe()
+
+	queue := func(entry neighbor.Entry) bool {
+		if !entry.IsIPv6() {
+			return true
+		}
+		select {
+		case s.deviceChan <- neighborDevice(entry):
+			return true
+		case <-s.ctx.Done():
+			return false
+		}
+	}
+
+	for _, entry := range s.neighbors.Entries() {
+		if !queue(entry) {
+			return
+		}
+	}
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case event := <-events:
+			if event.Type == neighbor.EventAdded && !queue(event.Entry) {
+				return
+			}
+		}
+	}
+}
+
+// neighborDevice converts an NDP entry of the neighbor table
+func neighborDevice(entry neighbor.Entry) IPv6Device {
+	device := IPv6Device{
+		MAC:       entry.MAC,
+		Interface: entry.Interface,
+		Timestamp: time.Now(),
+		Source:    "ndp",
+	}
+
+	address := entry.IP.String()
+	switch {
+	case entry.IP.IsLinkLocalUnicast():
+		device.LinkLocal = address
+	case isUniqueLocal(entry.IP):
+		device.UniqueLocal = address
+	default:
+		device.Global = address
+	}
+	return device
+}
+
 func (s *IPv6MonitorService) scanNDPTable() {
 	var cmd *exec.Cmd
 
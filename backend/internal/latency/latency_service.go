@@ -0,0 +1,291 @@
+package latency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"reconya-ai/db"
+	"reconya-ai/internal/config"
+	"reconya-ai/internal/device"
+	"reconya-ai/internal/eventlog"
+	"reconya-ai/internal/exclusion"
+	"reconya-ai/internal/scanner"
+	"reconya-ai/models"
+)
+
+const (
+	// Raw samples are kept for a day, hourly rollups for a month
+	rawRetention  = 24 * time.Hour
+	hourRetention = 30 * 24 * time.Hour
+
+	probeSpacing   = 200 * time.Millisecond
+	maxConcurrency = 8
+)
+
+// Pinger sends a single echo request and reports the round-trip time
+type Pinger interface {
+	Ping(ip string) (bool, time.Duration)
+}
+
+type LatencyService struct {
+	repository                  db.LatencyRepository
+	deviceService               *device.DeviceService
+	eventLogService             *eventlog.EventLogService
+	probeCount                  int
+	defaultRTTThresholdMs       float64
+	defaultLossThresholdPercent float64
+	// Pinger measures the round-trip times
+	Pinger Pinger
+	// Exclusions keeps excluded devices from being probed; nil excludes
+	// nothing
+	Exclusions *exclusion.ExclusionService
+}
+
+// ErrNoIPv4Address is returned for IPv6-only devices; probes are IPv4 echo
+// requests
+var ErrNoIPv4Address = errors.New("latency monitoring needs an IPv4 address")
+
+func NewLatencyService(repository db.LatencyRepository, deviceService *device.DeviceService, eventLogService *eventlog.EventLogService, cfg *config.Config) *LatencyService {
+	pinger := scanner.NewNativeScanner()
+	pinger.SetOptions(time.Second, 1, false, false)
+
+	probeCount := cfg.LatencyProbeCount
+	if probeCount <= 0 {
+		probeCount = 5
+	}
+
+	return &LatencyService{
+		repository:                  repository,
+		deviceService:               deviceService,
+		eventLogService:             eventLogService,
+		probeCount:                  probeCount,
+		defaultRTTThresholdMs:       cfg.LatencyRTTThresholdMs,
+		defaultLossThresholdPercent: cfg.LatencyLossThresholdPercent,
+		Pinger:                      pinger,
+	}
+}
+
+// RunProbes measures every monitored device once
+func (s *LatencyService) RunProbes() {
+	ctx := context.Background()
+	monitors, err := s.repository.FindMonitors(ctx)
+	if err != nil {
+		log.Printf("Latency: failed to load monitors: %v", err)
+		return
+	}
+
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, maxConcurrency)
+	for _, monitor := range monitors {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(monitor *models.LatencyMonitor) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			if err := s.probeDevice(monitor); err != nil {
+				log.Printf("Latency probe for device %s failed: %v", monitor.DeviceID, err)
+			}
+		}(monitor)
+	}
+	wg.Wait()
+}
+
+func (s *LatencyService) probeDevice(monitor *models.LatencyMonitor) error {
+	device, err := s.deviceService.FindByID(monitor.DeviceID)
+	if err != nil {
+		return fmt.Errorf("failed to find device: %v", err)
+	}
+	if device == nil {
+		return fmt.Errorf("device no longer exists")
+	}
+	if device.IPv4 == "" {
+		return ErrNoIPv4Address
+	}
+	excluded, err := s.Exclusions.ExcludesDevice(device)
+	if err != nil {
+		return err
+	}
+	if excluded {
+		return exclusion.ErrExcluded
+	}
+
+	var rtts []float64
+	for i := 0; i < s.probeCount; i++ {
+		if i > 0 {
+			time.Sleep(probeSpacing)
+		}
+		if ok, rtt := s.Pinger.Ping(device.IPv4); ok {
+			rtts = append(rtts, float64(rtt.Microseconds())/1000)
+		}
+	}
+
+	sample := SummarizeProbe(device.ID, s.probeCount, rtts, time.Now())
+	ctx := context.Background()
+	if err := s.repository.CreateSample(ctx, sample); err != nil {
+		return fmt.Errorf("failed to save sample: %v", err)
+	}
+
+	degraded := monitor.IsDegraded(sample)
+	if degraded == monitor.Degraded {
+		return nil
+	}
+
+	monitor.Degraded = degraded
+	if err := s.repository.SaveMonitor(ctx, monitor); err != nil {
+		return fmt.Errorf("failed to update monitor: %v", err)
+	}
+
+	if degraded {
+		description := fmt.Sprintf("Latency degraded on [%s]: %s", device.IPv4, describeSample(sample))
+		if err := s.eventLogService.Log(models.Warning, description, device.ID, degradedPayload(monitor, sample)); err != nil {
+			log.Printf("Failed to log latency warning: %v", err)
+		}
+	} else {
+		log.Printf("Latency recovered on %s: %s", device.IPv4, describeSample(sample))
+	}
+	return nil
+}
+
+// degradedPayload reports the measurement that crossed the monitor's threshold
+func degradedPayload(monitor *models.LatencyMonitor, sample *models.LatencySample) *models.ThresholdPayload {
+	if monitor.LossThresholdPercent > 0 && sample.LossPercent >= monitor.LossThresholdPercent {
+		return &models.ThresholdPayload{
+			Source: "latency", Metric: "loss", Value: &sample.LossPercent,
+			Threshold: &monitor.LossThresholdPercent, Unit: "%",
+		}
+	}
+	return &models.ThresholdPayload{
+		Source: "latency", Metric: "rtt_avg", Value: sample.RTTAvgMs,
+		Threshold: &monitor.RTTThresholdMs, Unit: "ms",
+	}
+}
+
+// Downsample rolls raw samples into hourly ones and hourly samples into daily ones
+func (s *LatencyService) Downsample() {
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	rolled, err := s.repository.Rollup(ctx, models.LatencyResolutionRaw, models.LatencyResolutionHour,
+		time.Hour, now.Add(-rawRetention).Truncate(time.Hour))
+	if err != nil {
+		log.Printf("Latency: failed to roll up raw samples: %v", err)
+	} else if rolled > 0 {
+		log.Printf("Latency: rolled %d raw samples into hourly samples", rolled)
+	}
+
+	rolled, err = s.repository.Rollup(ctx, models.LatencyResolutionHour, models.LatencyResolutionDay,
+		24*time.Hour, now.Add(-hourRetention).Truncate(24*time.Hour))
+	if err != nil {
+		log.Printf("Latency: failed to roll up hourly samples: %v", err)
+	} else if rolled > 0 {
+		log.Printf("Latency: rolled %d hourly samples into daily samples", rolled)
+	}
+}
+
+// GetSamples returns the samples of a device within the given window, oldest first
+func (s *LatencyService) GetSamples(deviceID string, window time.Duration) ([]*models.LatencySample, error) {
+	return s.repository.FindSamples(context.Background(), deviceID, time.Now().Add(-window))
+}
+
+// GetMonitor returns the monitor of a device, or nil if it isn't monitored
+func (s *LatencyService) GetMonitor(deviceID string) (*models.LatencyMonitor, error) {
+	monitor, err := s.repository.FindMonitor(context.Background(), deviceID)
+	if err == db.ErrNotFound {
+		return nil, nil
+	}
+	return monitor, err
+}
+
+// EnableMonitor starts probing a device. Thresholds of zero use the configured
+// defaults. IPv6-only devices can't be monitored.
+func (s *LatencyService) EnableMonitor(deviceID string, rttThresholdMs, lossThresholdPercent float64) (*models.LatencyMonitor, error) {
+	device, err := s.deviceService.FindByID(deviceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find device: %v", err)
+	}
+	if device == nil {
+		return nil, fmt.Errorf("device not found")
+	}
+	if device.IPv4 == "" {
+		return nil, ErrNoIPv4Address
+	}
+
+	monitor, err := s.GetMonitor(deviceID)
+	if err != nil {
+		return nil, err
+	}
+	if monitor == nil {
+		monitor = &models.LatencyMonitor{DeviceID: deviceID}
+	}
+
+	if rttThresholdMs <= 0 {
+		rttThresholdMs = s.defaultRTTThresholdMs
+	}
+	if lossThresholdPercent <= 0 {
+		lossThresholdPercent = s.defaultLossThresholdPercent
+	}
+	monitor.RTTThresholdMs = rttThresholdMs
+	monitor.LossThresholdPercent = lossThresholdPercent
+
+	if err := s.repository.SaveMonitor(context.Background(), monitor); err != nil {
+		return nil, err
+	}
+	return monitor, nil
+}
+
+// DisableMonitor stops probing a device; its history is kept
+func (s *LatencyService) DisableMonitor(deviceID string) error {
+	return s.repository.DeleteMonitor(context.Background(), deviceID)
+}
+
+// SummarizeProbe turns the RTTs (in milliseconds) of one probe round into a
+// sample. Jitter is the mean difference between consecutive replies.
+func SummarizeProbe(deviceID string, sent int, rtts []float64, at time.Time) *models.LatencySample {
+	sample := &models.LatencySample{
+		DeviceID:   deviceID,
+		Resolution: models.LatencyResolutionRaw,
+		Timestamp:  at,
+		Sent:       sent,
+		Received:   len(rtts),
+	}
+	if sent > 0 {
+		sample.LossPercent = float64(sent-len(rtts)) / float64(sent) * 100
+	}
+	if len(rtts) == 0 {
+		return sample
+	}
+
+	minRTT, maxRTT, sum := rtts[0], rtts[0], 0.0
+	for _, rtt := range rtts {
+		minRTT = math.Min(minRTT, rtt)
+		maxRTT = math.Max(maxRTT, rtt)
+		sum += rtt
+	}
+	avg := sum / float64(len(rtts))
+	sample.RTTMinMs = &minRTT
+	sample.RTTAvgMs = &avg
+	sample.RTTMaxMs = &maxRTT
+
+	if len(rtts) > 1 {
+		var diffs float64
+		for i := 1; i < len(rtts); i++ {
+			diffs += math.Abs(rtts[i] - rtts[i-1])
+		}
+		jitter := diffs / float64(len(rtts)-1)
+		sample.JitterMs = &jitter
+	}
+
+	return sample
+}
+
+func describeSample(sample *models.LatencySample) string {
+	if sample.RTTAvgMs == nil {
+		return fmt.Sprintf("no replies, %.0f%% loss", sample.LossPercent)
+	}
+	return fmt.Sprintf("avg %.1f ms, %.0f%% loss", *sample.RTTAvgMs, sample.LossPercent)
+}
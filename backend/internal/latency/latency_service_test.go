@@ -0,0 +1,51 @@
+package latency
+
+import (
+	"testing"
+	"time"
+
+	"reconya-ai/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarizeProbe(t *testing.T) {
+	now := time.Now()
+	sample := SummarizeProbe("device-1", 5, []float64{10, 14, 12, 20}, now)
+
+	assert.Equal(t, models.LatencyResolutionRaw, sample.Resolution)
+	assert.Equal(t, 5, sample.Sent)
+	assert.Equal(t, 4, sample.Received)
+	assert.InDelta(t, 20.0, sample.LossPercent, 0.001)
+	require.NotNil(t, sample.RTTMinMs)
+	require.NotNil(t, sample.RTTAvgMs)
+	require.NotNil(t, sample.RTTMaxMs)
+	require.NotNil(t, sample.JitterMs)
+	assert.InDelta(t, 10.0, *sample.RTTMinMs, 0.001)
+	assert.InDelta(t, 14.0, *sample.RTTAvgMs, 0.001)
+	assert.InDelta(t, 20.0, *sample.RTTMaxMs, 0.001)
+	// |14-10| + |12-14| + |20-12| = 14 over 3 pairs
+	assert.InDelta(t, 14.0/3, *sample.JitterMs, 0.001)
+}
+
+func TestSummarizeProbe_NoReplies(t *testing.T) {
+	sample := SummarizeProbe("device-1", 5, nil, time.Now())
+
+	assert.Equal(t, 0, sample.Received)
+	assert.InDelta(t, 100.0, sample.LossPercent, 0.001)
+	assert.Nil(t, sample.RTTAvgMs)
+	assert.Nil(t, sample.JitterMs)
+}
+
+func TestLatencyMonitor_IsDegraded(t *testing.T) {
+	monitor := &models.LatencyMonitor{RTTThresholdMs: 100, LossThresholdPercent: 20}
+
+	healthy := SummarizeProbe("device-1", 5, []float64{5, 6, 7, 5, 6}, time.Now())
+	slow := SummarizeProbe("device-1", 5, []float64{150, 160, 140, 150, 150}, time.Now())
+	lossy := SummarizeProbe("device-1", 5, []float64{5, 6}, time.Now())
+
+	assert.False(t, monitor.IsDegraded(healthy))
+	assert.True(t, monitor.IsDegraded(slow))
+	assert.True(t, monitor.IsDegraded(lossy))
+}
@@ -0,0 +1,84 @@
+package neighbor
+
+import (
+	"context"
+	"sync"
+)
+
+// FakeSource is a Source for tests. Add and Remove change its entries and
+// notify the watcher the way the kernel would: changes made while nobody is
+// subscribed are not reported.
+type FakeSource struct {
+	mu       sync.Mutex
+	entries  map[string]Entry
+	events   chan Event
+	watching bool
+}
+
+func NewFakeSource(entries ...Entry) *FakeSource {
+	source := &FakeSource{
+		entries: make(map[string]Entry),
+		events:  make(chan Event, 64),
+	}
+	for _, entry := range entries {
+		source.entries[entry.IP.String()] = entry
+	}
+	return source
+}
+
+func (f *FakeSource) List() ([]Entry, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	entries := make([]Entry, 0, len(f.entries))
+	for _, entry := range f.entries {
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func (f *FakeSource) Watch(ctx context.Context, subscribed chan<- struct{}, events chan<- Event) error {
+	f.mu.Lock()
+	f.watching = true
+	f.mu.Unlock()
+	defer func() {
+		f.mu.Lock()
+		f.watching = false
+		f.mu.Unlock()
+	}()
+	close(subscribed)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event := <-f.events:
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	}
+}
+
+// Add adds or updates entry, like an RTM_NEWNEIGH notification
+func (f *FakeSource) Add(entry Entry) {
+	f.mu.Lock()
+	f.entries[entry.IP.String()] = entry
+	f.notify(Event{Type: EventAdded, Entry: entry})
+	f.mu.Unlock()
+}
+
+// Remove deletes the entry for entry.IP, like an RTM_DELNEIGH notification
+func (f *FakeSource) Remove(entry Entry) {
+	f.mu.Lock()
+	delete(f.entries, entry.IP.String())
+	f.notify(Event{Type: EventRemoved, Entry: entry})
+	f.mu.Unlock()
+}
+
+func (f *FakeSource) notify(event Event) {
+	if f.watching {
+		f.events <- event
+	}
+}
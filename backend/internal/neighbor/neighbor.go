@@ -0,0 +1,121 @@
+// Package neighbor keeps the kernel's neighbor table, IPv4 ARP and IPv6 NDP
+// entries, in memory and reports neighbors as they appear and disappear.
+package neighbor
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+)
+
+// State is the neighbor unreachability detection state of an entry, the
+// kernel's NUD_* flags
+type State uint16
+
+const (
+	StateIncomplete State = 0x01
+	StateReachable  State = 0x02
+	StateStale      State = 0x04
+	StateDelay      State = 0x08
+	StateProbe      State = 0x10
+	StateFailed     State = 0x20
+	StateNoARP      State = 0x40
+	StatePermanent  State = 0x80
+)
+
+var stateNames = []struct {
+	state State
+	name  string
+}{
+	{StateIncomplete, "INCOMPLETE"},
+	{StateReachable, "REACHABLE"},
+	{StateStale, "STALE"},
+	{StateDelay, "DELAY"},
+	{StateProbe, "PROBE"},
+	{StateFailed, "FAILED"},
+	{StateNoARP, "NOARP"},
+	{StatePermanent, "PERMANENT"},
+}
+
+// String returns the state as ip neigh prints it
+func (s State) String() string {
+	var names []string
+	for _, n := range stateNames {
+		if s&n.state != 0 {
+			names = append(names, n.name)
+		}
+	}
+	if len(names) == 0 {
+		return "NONE"
+	}
+	return strings.Join(names, "|")
+}
+
+// Entry is one neighbor of this host
+type Entry struct {
+	IP        net.IP
+	MAC       string // upper case, colon separated; empty while unresolved
+	Interface string
+	State     State
+}
+
+// IsIPv6 reports whether the entry is an NDP entry
+func (e Entry) IsIPv6() bool {
+	return e.IP.To4() == nil
+}
+
+// Valid reports whether the entry maps a unicast neighbor to a link-layer
+// address. Unresolved, failed and NOARP entries (multicast, point-to-point
+// links) are not.
+func (e Entry) Valid() bool {
+	if e.MAC == "" || e.MAC == "00:00:00:00:00:00" {
+		return false
+	}
+	if e.State&(StateIncomplete|StateFailed|StateNoARP) != 0 || e.State == 0 {
+		return false
+	}
+	return !e.IP.IsMulticast()
+}
+
+// EventType is what happened to a neighbor
+type EventType int
+
+const (
+	// EventAdded is sent when a neighbor appears, changes its link-layer
+	// address or is confirmed reachable again
+	EventAdded EventType = iota
+	// EventRemoved is sent when a neighbor is deleted or fails to resolve
+	EventRemoved
+)
+
+func (t EventType) String() string {
+	if t == EventRemoved {
+		return "removed"
+	}
+	return "added"
+}
+
+// Event is a change of the neighbor table
+type Event struct {
+	Type  EventType
+	Entry Entry
+}
+
+// ErrUnsupported is returned by NewSystemSource where the kernel's neighbor
+// table cannot be watched
+var ErrUnsupported = errors.New("neighbor table notifications are not supported on this platform")
+
+// ErrOverflow is returned by Source.Watch when notifications were lost; the
+// table must be listed again
+var ErrOverflow = errors.New("neighbor notifications were dropped")
+
+// Source reads a neighbor table
+type Source interface {
+	// List returns every entry of the table
+	List() ([]Entry, error)
+	// Watch subscribes to the table's changes, closes subscribed once it
+	// receives them and sends every change to events until ctx is done.
+	// Removed entries carry the last state the kernel reported.
+	Watch(ctx context.Context, subscribed chan<- struct{}, events chan<- Event) error
+}
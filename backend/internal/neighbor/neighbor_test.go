@@ -0,0 +1,180 @@
+package neighbor
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// neighborMessage builds an RTM_NEWNEIGH or RTM_DELNEIGH message
+func neighborMessage(msgType uint16, family byte, ifindex int, state State, ip net.IP, mac net.HardwareAddr) []byte {
+	attr := func(attrType uint16, value []byte) []byte {
+		b := make([]byte, nlmsgAlign(rtattrHeader+len(value)))
+		binary.NativeEndian.PutUint16(b[0:2], uint16(rtattrHeader+len(value)))
+		binary.NativeEndian.PutUint16(b[2:4], attrType)
+		copy(b[rtattrHeader:], value)
+		return b
+	}
+
+	body := make([]byte, ndmsgLen)
+	body[0] = family
+	binary.NativeEndian.PutUint32(body[4:8], uint32(ifindex))
+	binary.NativeEndian.PutUint16(body[8:10], uint16(state))
+	body = append(body, attr(ndaDst, ip)...)
+	if mac != nil {
+		body = append(body, attr(ndaLLAddr, mac)...)
+	}
+
+	msg := make([]byte, nlmsgHeaderLen, nlmsgHeaderLen+len(body))
+	binary.NativeEndian.PutUint32(msg[0:4], uint32(nlmsgHeaderLen+len(body)))
+	binary.NativeEndian.PutUint16(msg[4:6], msgType)
+	return append(msg, body...)
+}
+
+func doneMessage() []byte {
+	msg := make([]byte, nlmsgHeaderLen+4)
+	binary.NativeEndian.PutUint32(msg[0:4], uint32(len(msg)))
+	binary.NativeEndian.PutUint16(msg[4:6], nlmsgDone)
+	return msg
+}
+
+func TestParseMessages(t *testing.T) {
+	mac, _ := net.ParseMAC("aa:bb:cc:00:11:22")
+	var data []byte
+	data = append(data, neighborMessage(rtmNewNeigh, afInet, 2, StateReachable, net.ParseIP("192.168.1.10").To4(), mac)...)
+	data = append(data, neighborMessage(rtmDelNeigh, afInet6, 2, StateStale, net.ParseIP("fe80::1"), mac)...)
+	data = append(data, neighborMessage(rtmNewNeigh, 7, 2, StateReachable, net.ParseIP("10.0.0.1").To4(), mac)...)
+	data = append(data, doneMessage()...)
+
+	names := map[int]string{2: "eth0"}
+	events, done, err := parseMessages(data, func(index int) string { return names[index] })
+	require.NoError(t, err)
+	assert.True(t, done)
+	require.Len(t, events, 2, "other families are skipped")
+
+	assert.Equal(t, EventAdded, events[0].Type)
+	assert.Equal(t, "192.168.1.10", events[0].Entry.IP.String())
+	assert.Equal(t, "AA:BB:CC:00:11:22", events[0].Entry.MAC)
+	assert.Equal(t, "eth0", events[0].Entry.Interface)
+	assert.Equal(t, StateReachable, events[0].Entry.State)
+	assert.False(t, events[0].Entry.IsIPv6())
+
+	assert.Equal(t, EventRemoved, events[1].Type)
+	assert.Equal(t, "fe80::1", events[1].Entry.IP.String())
+	assert.True(t, events[1].Entry.IsIPv6())
+
+	_, _, err = parseMessages(data[:nlmsgHeaderLen+4], nil)
+	assert.Error(t, err, "truncated message")
+}
+
+func TestDumpRequest(t *testing.T) {
+	request := dumpRequest(7)
+	require.Len(t, request, nlmsgHeaderLen+ndmsgLen)
+	assert.Equal(t, uint32(len(request)), binary.NativeEndian.Uint32(request[0:4]))
+	assert.Equal(t, uint16(rtmGetNeigh), binary.NativeEndian.Uint16(request[4:6]))
+	assert.Equal(t, uint16(nlmFRequest|nlmFDump), binary.NativeEndian.Uint16(request[6:8]))
+	assert.Equal(t, uint32(7), binary.NativeEndian.Uint32(request[8:12]))
+}
+
+func TestEntry_Valid(t *testing.T) {
+	ip := net.ParseIP("192.168.1.10")
+	assert.True(t, Entry{IP: ip, MAC: "AA:BB:CC:00:11:22", State: StateStale}.Valid())
+	assert.False(t, Entry{IP: ip, State: StateIncomplete}.Valid())
+	assert.False(t, Entry{IP: ip, MAC: "AA:BB:CC:00:11:22", State: StateFailed}.Valid())
+	assert.False(t, Entry{IP: net.ParseIP("ff02::1"), MAC: "33:33:00:00:00:01", State: StateNoARP}.Valid())
+	assert.Equal(t, "REACHABLE|PERMANENT", (StateReachable | StatePermanent).String())
+}
+
+func receive(t *testing.T, events <-chan Event) Event {
+	t.Helper()
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(2 * time.Second):
+		t.Fatal("no neighbor event")
+		return Event{}
+	}
+}
+
+func TestTable_Run(t *testing.T) {
+	router := Entry{IP: net.ParseIP("192.168.1.1"), MAC: "AA:BB:CC:00:00:01", Interface: "eth0", State: StatePermanent}
+	source := NewFakeSource(router)
+	table := NewTable(source)
+	events, unsubscribe := table.Subscribe(16)
+	defer unsubscribe()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+	go func() { stopped <- table.Run(ctx) }()
+
+	assert.Equal(t, Event{Type: EventAdded, Entry: router}, receive(t, events))
+	found, ok := table.Lookup("192.168.1.1")
+	require.True(t, ok)
+	assert.Equal(t, "AA:BB:CC:00:00:01", found.MAC)
+
+	// Resolution in progress is not a neighbor yet
+	phone := Entry{IP: net.ParseIP("fe80::2"), Interface: "eth0", State: StateIncomplete}
+	source.Add(phone)
+	phone.MAC, phone.State = "AA:BB:CC:00:00:02", StateReachable
+	source.Add(phone)
+	assert.Equal(t, Event{Type: EventAdded, Entry: phone}, receive(t, events))
+
+	// Aging to stale is not news, becoming reachable again is
+	stale := phone
+	stale.State = StateStale
+	source.Add(stale)
+	source.Add(phone)
+	assert.Equal(t, Event{Type: EventAdded, Entry: phone}, receive(t, events))
+
+	source.Remove(phone)
+	removed := receive(t, events)
+	assert.Equal(t, EventRemoved, removed.Type)
+	assert.Equal(t, "AA:BB:CC:00:00:02", removed.Entry.MAC)
+	_, ok = table.Lookup("fe80::2")
+	assert.False(t, ok)
+	assert.Len(t, table.Entries(), 1)
+
+	cancel()
+	select {
+	case err := <-stopped:
+		assert.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("table did not stop")
+	}
+}
+
+// changingSource is a table that changes right after it is listed
+type changingSource struct {
+	*FakeSource
+	change Entry
+	once   sync.Once
+}
+
+func (s *changingSource) List() ([]Entry, error) {
+	entries, err := s.FakeSource.List()
+	s.once.Do(func() { s.FakeSource.Add(s.change) })
+	return entries, err
+}
+
+func TestTable_RunSubscribesBeforeLoading(t *testing.T) {
+	router := Entry{IP: net.ParseIP("192.168.1.1"), MAC: "AA:BB:CC:00:00:01", Interface: "eth0", State: StatePermanent}
+	printer := Entry{IP: net.ParseIP("192.168.1.20"), MAC: "AA:BB:CC:00:00:20", Interface: "eth0", State: StateReachable}
+	table := NewTable(&changingSource{FakeSource: NewFakeSource(router), change: printer})
+	events, unsubscribe := table.Subscribe(16)
+	defer unsubscribe()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go table.Run(ctx)
+
+	assert.Equal(t, Event{Type: EventAdded, Entry: router}, receive(t, events))
+	assert.Equal(t, Event{Type: EventAdded, Entry: printer}, receive(t, events), "a change while loading is not lost")
+	_, ok := table.Lookup("192.168.1.20")
+	assert.True(t, ok)
+}
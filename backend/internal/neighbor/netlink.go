@@ -0,0 +1,133 @@
+package neighbor
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+	"syscall"
+)
+
+// rtnetlink message layout, from linux/netlink.h, linux/rtnetlink.h and
+// linux/neighbour.h. Netlink uses the host byte order.
+const (
+	nlmsgHeaderLen = 16
+	ndmsgLen       = 12
+	rtattrHeader   = 4
+
+	nlmsgError = 2
+	nlmsgDone  = 3
+
+	rtmNewNeigh = 28
+	rtmDelNeigh = 29
+	rtmGetNeigh = 30
+
+	nlmFRequest = 0x1
+	nlmFDump    = 0x300
+
+	ndaDst    = 1
+	ndaLLAddr = 2
+
+	afInet  = 2
+	afInet6 = 10
+)
+
+func nlmsgAlign(n int) int {
+	return (n + 3) &^ 3
+}
+
+// dumpRequest returns an RTM_GETNEIGH request for the whole neighbor table
+func dumpRequest(seq uint32) []byte {
+	msg := make([]byte, nlmsgHeaderLen+ndmsgLen)
+	binary.NativeEndian.PutUint32(msg[0:4], uint32(len(msg)))
+	binary.NativeEndian.PutUint16(msg[4:6], rtmGetNeigh)
+	binary.NativeEndian.PutUint16(msg[6:8], nlmFRequest|nlmFDump)
+	binary.NativeEndian.PutUint32(msg[8:12], seq)
+	// An all-zero ndmsg asks for every family and interface
+	return msg
+}
+
+// parseMessages decodes the neighbor messages in one netlink read. done is
+// set at the end of a dump. interfaceName resolves interface indexes.
+func parseMessages(data []byte, interfaceName func(index int) string) (events []Event, done bool, err error) {
+	for len(data) >= nlmsgHeaderLen {
+		length := int(binary.NativeEndian.Uint32(data[0:4]))
+		msgType := binary.NativeEndian.Uint16(data[4:6])
+		if length < nlmsgHeaderLen || length > len(data) {
+			return events, done, fmt.Errorf("invalid netlink message length %d", length)
+		}
+		body := data[nlmsgHeaderLen:length]
+
+		switch msgType {
+		case nlmsgDone:
+			done = true
+		case nlmsgError:
+			if len(body) >= 4 {
+				if errno := int32(binary.NativeEndian.Uint32(body[0:4])); errno != 0 {
+					return events, done, fmt.Errorf("netlink error: %v", syscall.Errno(-errno))
+				}
+			}
+		case rtmNewNeigh, rtmDelNeigh:
+			entry, ok := parseNeighbor(body, interfaceName)
+			if ok {
+				eventType := EventAdded
+				if msgType == rtmDelNeigh {
+					eventType = EventRemoved
+				}
+				events = append(events, Event{Type: eventType, Entry: entry})
+			}
+		}
+
+		if nlmsgAlign(length) >= len(data) {
+			break
+		}
+		data = data[nlmsgAlign(length):]
+	}
+	return events, done, nil
+}
+
+// parseNeighbor decodes an ndmsg and its attributes; ok is false for other
+// families and entries without a destination
+func parseNeighbor(body []byte, interfaceName func(index int) string) (Entry, bool) {
+	if len(body) < ndmsgLen {
+		return Entry{}, false
+	}
+	family := body[0]
+	if family != afInet && family != afInet6 {
+		return Entry{}, false
+	}
+
+	entry := Entry{State: State(binary.NativeEndian.Uint16(body[8:10]))}
+	if index := int(int32(binary.NativeEndian.Uint32(body[4:8]))); index > 0 && interfaceName != nil {
+		entry.Interface = interfaceName(index)
+	}
+
+	attrs := body[ndmsgLen:]
+	for len(attrs) >= rtattrHeader {
+		attrLen := int(binary.NativeEndian.Uint16(attrs[0:2]))
+		attrType := binary.NativeEndian.Uint16(attrs[2:4])
+		if attrLen < rtattrHeader || attrLen > len(attrs) {
+			break
+		}
+		value := attrs[rtattrHeader:attrLen]
+
+		switch attrType {
+		case ndaDst:
+			entry.IP = net.IP(append([]byte(nil), value...))
+		case ndaLLAddr:
+			if len(value) == 6 {
+				entry.MAC = strings.ToUpper(net.HardwareAddr(value).String())
+			}
+		}
+
+		if nlmsgAlign(attrLen) >= len(attrs) {
+			break
+		}
+		attrs = attrs[nlmsgAlign(attrLen):]
+	}
+
+	if entry.IP == nil {
+		return Entry{}, false
+	}
+	return entry, true
+}
@@ -0,0 +1,133 @@
+//go:build linux
+
+package neighbor
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// netlinkSource reads the kernel's neighbor table over rtnetlink
+type netlinkSource struct {
+	mu         sync.Mutex
+	interfaces map[int]string
+}
+
+// NewSystemSource returns a Source reading the kernel's neighbor table. It
+// fails where netlink sockets cannot be opened, for example in restricted
+// containers.
+func NewSystemSource() (Source, error) {
+	fd, err := openNetlink(0)
+	if err != nil {
+		return nil, err
+	}
+	unix.Close(fd)
+	return &netlinkSource{interfaces: make(map[int]string)}, nil
+}
+
+func openNetlink(groups uint32) (int, error) {
+	fd, err := unix.Socket(unix.AF_NETLINK, unix.SOCK_RAW|unix.SOCK_CLOEXEC, unix.NETLINK_ROUTE)
+	if err != nil {
+		return -1, fmt.Errorf("failed to open netlink socket: %v", err)
+	}
+	if err := unix.Bind(fd, &unix.SockaddrNetlink{Family: unix.AF_NETLINK, Groups: groups}); err != nil {
+		unix.Close(fd)
+		return -1, fmt.Errorf("failed to bind netlink socket: %v", err)
+	}
+	return fd, nil
+}
+
+func (s *netlinkSource) interfaceName(index int) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if name, ok := s.interfaces[index]; ok {
+		return name
+	}
+	iface, err := net.InterfaceByIndex(index)
+	if err != nil {
+		return ""
+	}
+	s.interfaces[index] = iface.Name
+	return iface.Name
+}
+
+func (s *netlinkSource) List() ([]Entry, error) {
+	fd, err := openNetlink(0)
+	if err != nil {
+		return nil, err
+	}
+	defer unix.Close(fd)
+
+	if err := unix.Sendto(fd, dumpRequest(1), 0, &unix.SockaddrNetlink{Family: unix.AF_NETLINK}); err != nil {
+		return nil, fmt.Errorf("failed to request neighbor table: %v", err)
+	}
+
+	var entries []Entry
+	buf := make([]byte, 1<<16)
+	for {
+		n, _, err := unix.Recvfrom(fd, buf, 0)
+		if err == unix.EINTR {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read neighbor table: %v", err)
+		}
+
+		events, done, err := parseMessages(buf[:n], s.interfaceName)
+		if err != nil {
+			return nil, err
+		}
+		for _, event := range events {
+			entries = append(entries, event.Entry)
+		}
+		if done {
+			return entries, nil
+		}
+	}
+}
+
+func (s *netlinkSource) Watch(ctx context.Context, subscribed chan<- struct{}, events chan<- Event) error {
+	fd, err := openNetlink(unix.RTMGRP_NEIGH)
+	if err != nil {
+		return err
+	}
+	defer unix.Close(fd)
+
+	// Wake up every second to notice ctx being done
+	timeout := unix.NsecToTimeval(int64(time.Second))
+	if err := unix.SetsockoptTimeval(fd, unix.SOL_SOCKET, unix.SO_RCVTIMEO, &timeout); err != nil {
+		return fmt.Errorf("failed to set netlink read timeout: %v", err)
+	}
+	close(subscribed)
+
+	buf := make([]byte, 1<<16)
+	for ctx.Err() == nil {
+		n, _, err := unix.Recvfrom(fd, buf, 0)
+		switch {
+		case err == unix.EAGAIN || err == unix.EINTR:
+			continue
+		case err == unix.ENOBUFS:
+			return ErrOverflow
+		case err != nil:
+			return fmt.Errorf("failed to read neighbor notifications: %v", err)
+		}
+
+		parsed, _, err := parseMessages(buf[:n], s.interfaceName)
+		if err != nil {
+			return err
+		}
+		for _, event := range parsed {
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package neighbor
+
+// NewSystemSource returns ErrUnsupported; only Linux reports neighbor changes
+// over netlink
+func NewSystemSource() (Source, error) {
+	return nil, ErrUnsupported
+}
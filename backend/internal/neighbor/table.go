@@ -0,0 +1,182 @@
+package neighbor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+)
+
+// Table is an in-memory copy of a neighbor table kept current from a Source.
+// Subscribers receive an event whenever a valid neighbor appears, changes or
+// goes away.
+type Table struct {
+	source Source
+
+	mu          sync.RWMutex
+	entries     map[string]Entry
+	subscribers map[chan Event]struct{}
+}
+
+func NewTable(source Source) *Table {
+	return &Table{
+		source:      source,
+		entries:     make(map[string]Entry),
+		subscribers: make(map[chan Event]struct{}),
+	}
+}
+
+// Load replaces the table with the source's entries, sending events for the
+// differences
+func (t *Table) Load() error {
+	entries, err := t.source.List()
+	if err != nil {
+		return fmt.Errorf("failed to list neighbors: %v", err)
+	}
+
+	listed := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		listed[entry.IP.String()] = true
+		t.apply(Event{Type: EventAdded, Entry: entry})
+	}
+
+	t.mu.RLock()
+	var gone []Entry
+	for key, entry := range t.entries {
+		if !listed[key] {
+			gone = append(gone, entry)
+		}
+	}
+	t.mu.RUnlock()
+	for _, entry := range gone {
+		t.apply(Event{Type: EventRemoved, Entry: entry})
+	}
+	return nil
+}
+
+// Run loads the table and applies the source's changes until ctx is done.
+// Lost notifications are recovered by loading the table again.
+func (t *Table) Run(ctx context.Context) error {
+	for {
+		err := t.watch(ctx)
+		if errors.Is(err, ErrOverflow) {
+			log.Printf("Neighbor notifications overflowed, reloading the neighbor table")
+			continue
+		}
+		return err
+	}
+}
+
+// watch subscribes to the source's changes, loads the table and applies the
+// changes until the subscription ends. Loading only once subscribed means no
+// change is lost in between; the changes made while loading are queued and
+// applied after it, in order, so the table ends up current.
+func (t *Table) watch(ctx context.Context) error {
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	subscribed := make(chan struct{})
+	events := make(chan Event, 64)
+	watchErr := make(chan error, 1)
+	go func() {
+		watchErr <- t.source.Watch(watchCtx, subscribed, events)
+		close(events)
+	}()
+
+	var err error
+	select {
+	case <-subscribed:
+		if err := t.Load(); err != nil {
+			return err
+		}
+		for event := range events {
+			t.apply(event)
+		}
+		err = <-watchErr
+	case err = <-watchErr:
+	}
+
+	if errors.Is(err, ErrOverflow) {
+		return err
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	if err == nil {
+		err = errors.New("neighbor source stopped")
+	}
+	return fmt.Errorf("failed to watch neighbors: %v", err)
+}
+
+// apply updates the table with a change reported by the source
+func (t *Table) apply(event Event) {
+	key := event.Entry.IP.String()
+
+	t.mu.Lock()
+	previous, known := t.entries[key]
+	var notify *Event
+
+	if event.Type == EventAdded && event.Entry.Valid() {
+		t.entries[key] = event.Entry
+		changed := !known || previous.MAC != event.Entry.MAC
+		reconfirmed := event.Entry.State&StateReachable != 0 && previous.State&StateReachable == 0
+		if changed || reconfirmed {
+			notify = &Event{Type: EventAdded, Entry: event.Entry}
+		}
+	} else if known {
+		delete(t.entries, key)
+		removed := previous
+		removed.State = event.Entry.State
+		notify = &Event{Type: EventRemoved, Entry: removed}
+	}
+
+	if notify != nil {
+		for subscriber := range t.subscribers {
+			select {
+			case subscriber <- *notify:
+			default:
+				log.Printf("Neighbor subscriber is full, dropping %s event for %s", notify.Type, key)
+			}
+		}
+	}
+	t.mu.Unlock()
+}
+
+// Lookup returns the valid entry for ip
+func (t *Table) Lookup(ip string) (Entry, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	entry, ok := t.entries[ip]
+	return entry, ok
+}
+
+// Entries returns every valid entry
+func (t *Table) Entries() []Entry {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	entries := make([]Entry, 0, len(t.entries))
+	for _, entry := range t.entries {
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// Subscribe returns a channel receiving the table's events and a function
+// ending the subscription. Events are dropped while the channel is full.
+func (t *Table) Subscribe(buffer int) (<-chan Event, func()) {
+	events := make(chan Event, buffer)
+	t.mu.Lock()
+	t.subscribers[events] = struct{}{}
+	t.mu.Unlock()
+
+	var once sync.Once
+	return events, func() {
+		once.Do(func() {
+			t.mu.Lock()
+			delete(t.subscribers, events)
+			t.mu.Unlock()
+			close(events)
+		})
+	}
+}
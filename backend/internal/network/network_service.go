@@ -2,9 +2,11 @@ package network
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"reconya-ai/db"
 	"reconya-ai/internal/config"
+	"reconya-ai/internal/ratelimit"
 	"reconya-ai/models"
 	"time"
 )
@@ -12,14 +14,12 @@ import (
 type NetworkService struct {
 	Config     *config.Config
 	Repository db.NetworkRepository
-	dbManager  *db.DBManager
 }
 
-func NewNetworkService(networkRepo db.NetworkRepository, cfg *config.Config, dbManager *db.DBManager) *NetworkService {
+func NewNetworkService(networkRepo db.NetworkRepository, cfg *config.Config) *NetworkService {
 	return &NetworkService{
 		Config:     cfg,
 		Repository: networkRepo,
-		dbManager:  dbManager,
 	}
 }
 
@@ -34,9 +34,9 @@ func (s *NetworkService) Create(name, cidr, description string) (*models.Network
 		UpdatedAt:   now,
 	}
 	log.Printf("NetworkService.Create: Creating network with CIDR=%s, Name=%s", cidr, name)
-	result, err := s.dbManager.CreateOrUpdateNetwork(s.Repository, context.Background(), network)
+	result, err := s.Repository.CreateOrUpdate(context.Background(), network)
 	if err != nil {
-		log.Printf("NetworkService.Create: Error from dbManager: %v", err)
+		log.Printf("NetworkService.Create: Error saving network: %v", err)
 		return nil, err
 	}
 	log.Printf("NetworkService.Create: Network saved successfully with ID=%s", result.ID)
@@ -107,7 +107,78 @@ func (s *NetworkService) Update(id, name, cidr, description string) (*models.Net
 	network.Description = description
 	network.UpdatedAt = time.Now()
 
-	return s.dbManager.CreateOrUpdateNetwork(s.Repository, context.Background(), network)
+	return s.Repository.CreateOrUpdate(context.Background(), network)
+}
+
+// SetAddressing sets the address family of a network and its IPv6 prefix,
+// which IPv6 and dual-stack networks require
+func (s *NetworkService) SetAddressing(id string, family models.AddressFamily, ipv6Prefix string) (*models.Network, error) {
+	network, err := s.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if network == nil {
+		return nil, db.ErrNotFound
+	}
+
+	network.AddressFamily = family
+	network.IPv6Prefix = nil
+	if ipv6Prefix != "" {
+		network.IPv6Prefix = &ipv6Prefix
+	}
+	if err := network.ValidateNetworkAddresses(); err != nil {
+		return nil, err
+	}
+	network.UpdatedAt = time.Now()
+
+	return s.Repository.CreateOrUpdate(context.Background(), network)
+}
+
+// SetScanLimits sets the packet rate and scan windows of a network. A nil
+// rate and empty windows fall back to the global limits.
+func (s *NetworkService) SetScanLimits(id string, rateLimit *int, scanWindows string) (*models.Network, error) {
+	if rateLimit != nil && *rateLimit <= 0 {
+		return nil, fmt.Errorf("rate limit must be a positive number of packets per second")
+	}
+	schedule, err := ratelimit.ParseSchedule(scanWindows)
+	if err != nil {
+		return nil, fmt.Errorf("invalid scan windows: %v", err)
+	}
+
+	network, err := s.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if network == nil {
+		return nil, db.ErrNotFound
+	}
+
+	network.RateLimit = rateLimit
+	network.ScanWindows = schedule.String()
+	network.UpdatedAt = time.Now()
+
+	return s.Repository.CreateOrUpdate(context.Background(), network)
+}
+
+// MarkScanned records the start time of a completed sweep of the network
+func (s *NetworkService) MarkScanned(id string, startedAt time.Time) error {
+	return s.Repository.UpdateLastScannedAt(context.Background(), id, startedAt)
+}
+
+// LastScannedTimes maps network IDs to the start of their last sweep
+func (s *NetworkService) LastScannedTimes() (map[string]time.Time, error) {
+	networks, err := s.Repository.FindAll(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	times := make(map[string]time.Time, len(networks))
+	for _, network := range networks {
+		if network.LastScannedAt != nil {
+			times[network.ID] = *network.LastScannedAt
+		}
+	}
+	return times, nil
 }
 
 func (s *NetworkService) Delete(id string) error {
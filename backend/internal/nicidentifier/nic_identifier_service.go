@@ -106,15 +106,18 @@ func (s *NicIdentifierService) Identify() {
 		return
 	}
 
-	device := savedDevice.ID
-	s.EventLogService.CreateOne(&models.EventLog{
-		Type:     models.LocalIPFound,
-		DeviceID: &device,
+	s.EventLogService.Log(models.LocalIPFound, "", savedDevice.ID, &models.LocalIPPayload{
+		IPv4:      nic.IPv4,
+		Interface: nic.Name,
 	})
 
-	s.EventLogService.CreateOne(&models.EventLog{
-		Type: models.LocalNetworkFound,
-	})
+	networkPayload := &models.NetworkPayload{}
+	if networkEntity != nil {
+		networkPayload.NetworkID = networkEntity.ID
+		networkPayload.CIDR = networkEntity.CIDR
+		networkPayload.Name = networkEntity.Name
+	}
+	s.EventLogService.Log(models.LocalNetworkFound, "", "", networkPayload)
 }
 
 func (s *NicIdentifierService) getLocalNic() models.NIC {
@@ -348,10 +351,9 @@ func (s *NicIdentifierService) checkAndSuggestNetwork(networkCIDR string) {
 
 	// Network doesn't exist - log suggestion event
 	log.Printf("New network detected: %s", baseNetworkCIDR)
-	s.EventLogService.CreateOne(&models.EventLog{
-		Type:        models.NewNetworkDetected,
-		Description: fmt.Sprintf("New network %s detected. Consider creating it for scanning.", baseNetworkCIDR),
-	})
+	s.EventLogService.Log(models.NewNetworkDetected,
+		fmt.Sprintf("New network %s detected. Consider creating it for scanning.", baseNetworkCIDR), "",
+		&models.NetworkPayload{CIDR: baseNetworkCIDR})
 }
 
 // GetDetectedNetworks returns a list of detected networks that don't exist in the database
@@ -1,23 +1,37 @@
 package oui
 
 import (
-	"bufio"
+	"bytes"
+	_ "embed"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
 	"time"
+
+	"reconya-ai/models"
 )
 
+// snapshot is the vendor database bundled with the binary, so vendors are
+// known on hosts without Internet access. It currently holds the IEEE MA-L
+// assignments (oui.csv) only; "make oui-snapshot" rebuilds it from the MA-L,
+// MA-M, MA-S and IAB registries.
+//
+//go:embed snapshot/oui.tsv.gz
+var snapshot []byte
+
+// DatabaseFile is the name of the updated database in the data directory
+const DatabaseFile = "oui.tsv.gz"
+
 // OUIService handles MAC address to vendor lookup using IEEE OUI database
 type OUIService struct {
-	ouiMap   map[string]string
-	mutex    sync.RWMutex
-	dataPath string
+	ouiMap    map[string]string
+	mutex     sync.RWMutex
+	dataPath  string
+	source    string
+	updatedAt time.Time
 }
 
 // NewOUIService creates a new OUI service instance
@@ -28,177 +42,188 @@ func NewOUIService(dataPath string) *OUIService {
 	}
 }
 
-// Initialize downloads OUI database if needed and loads it into memory
+// Initialize loads the vendor database into memory: the database last
+// written by Update when there is one, otherwise the bundled snapshot. It
+// never goes online.
 func (s *OUIService) Initialize() error {
-	// Ensure data directory exists
-	if err := os.MkdirAll(s.dataPath, 0755); err != nil {
-		return fmt.Errorf("failed to create OUI data directory: %w", err)
-	}
-
-	ouiFile := filepath.Join(s.dataPath, "oui.txt")
-
-	// Check if we need to download/update the OUI database
-	if s.shouldUpdateOUI(ouiFile) {
-		log.Println("Downloading IEEE OUI database...")
-		if err := s.downloadOUIDatabase(ouiFile); err != nil {
-			log.Printf("Failed to download OUI database: %v", err)
-			// Continue with existing file if download fails
-		} else {
-			log.Println("IEEE OUI database downloaded successfully")
+	vendors, updatedAt, err := ReadDatabase(bytes.NewReader(snapshot))
+	if err != nil {
+		return fmt.Errorf("failed to load bundled OUI snapshot: %w", err)
+	}
+	source := "bundled snapshot"
+
+	if s.dataPath != "" {
+		databaseFile := filepath.Join(s.dataPath, DatabaseFile)
+		if file, err := os.Open(databaseFile); err == nil {
+			updated, fileUpdatedAt, err := ReadDatabase(file)
+			file.Close()
+			if err != nil {
+				log.Printf("Failed to load OUI database %s, using the bundled snapshot: %v", databaseFile, err)
+			} else {
+				vendors, updatedAt, source = updated, fileUpdatedAt, databaseFile
+			}
+		} else if legacy, err := s.loadLegacyDatabase(); err == nil {
+			// oui.txt downloaded by earlier versions
+			for prefix, vendor := range legacy {
+				vendors[prefix] = vendor
+			}
+			source = filepath.Join(s.dataPath, "oui.txt")
 		}
 	}
 
-	// Load OUI database into memory
-	if err := s.loadOUIDatabase(ouiFile); err != nil {
-		return fmt.Errorf("failed to load OUI database: %w", err)
-	}
+	s.mutex.Lock()
+	s.ouiMap = vendors
+	s.source = source
+	s.updatedAt = updatedAt
+	s.mutex.Unlock()
 
-	log.Printf("Loaded %d OUI entries into memory", len(s.ouiMap))
+	log.Printf("Loaded %d OUI entries into memory from %s", len(vendors), source)
 	return nil
 }
 
-// shouldUpdateOUI checks if we need to download/update the OUI database
-func (s *OUIService) shouldUpdateOUI(ouiFile string) bool {
-	info, err := os.Stat(ouiFile)
+func (s *OUIService) loadLegacyDatabase() (map[string]string, error) {
+	file, err := os.Open(filepath.Join(s.dataPath, "oui.txt"))
 	if err != nil {
-		// File doesn't exist, need to download
-		return true
+		return nil, err
 	}
-
-	// Update if file is older than 30 days
-	return time.Since(info.ModTime()) > 30*24*time.Hour
+	defer file.Close()
+	return ParseRegistry(file)
 }
 
-// downloadOUIDatabase downloads the IEEE OUI database
-func (s *OUIService) downloadOUIDatabase(ouiFile string) error {
-	// IEEE OUI database URL
-	url := "http://standards-oui.ieee.org/oui/oui.txt"
+// Update adds the assignments in IEEE registry files to the loaded database,
+// or replaces it with them, and saves the result in the data directory for
+// the next start. It returns the number of entries.
+func (s *OUIService) Update(files []string, replace bool) (int, error) {
+	count, err := s.Import(files, replace)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(s.dataPath, 0755); err != nil {
+		return 0, fmt.Errorf("failed to create OUI data directory: %w", err)
+	}
+	databaseFile := filepath.Join(s.dataPath, DatabaseFile)
+	if err := s.Save(databaseFile); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
 
-	// Create HTTP client with timeout
-	client := &http.Client{
-		Timeout: 60 * time.Second,
+// Import adds the assignments in IEEE registry files to the loaded database,
+// or replaces it with them, without saving it. It returns the number of
+// entries.
+func (s *OUIService) Import(files []string, replace bool) (int, error) {
+	vendors := make(map[string]string)
+	if !replace {
+		s.mutex.RLock()
+		for prefix, vendor := range s.ouiMap {
+			vendors[prefix] = vendor
+		}
+		s.mutex.RUnlock()
 	}
 
-	resp, err := client.Get(url)
-	if err != nil {
-		return fmt.Errorf("failed to download OUI database: %w", err)
+	for _, path := range files {
+		registry, err := parseRegistryFile(path)
+		if err != nil {
+			return 0, err
+		}
+		if len(registry) == 0 {
+			return 0, fmt.Errorf("no assignments found in %s", path)
+		}
+		for prefix, vendor := range registry {
+			vendors[prefix] = vendor
+		}
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to download OUI database: HTTP %d", resp.StatusCode)
+	s.mutex.Lock()
+	s.ouiMap = vendors
+	s.updatedAt = time.Now()
+	s.mutex.Unlock()
+	return len(vendors), nil
+}
+
+// Save writes the loaded database to path
+func (s *OUIService) Save(path string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := SaveDatabase(path, s.ouiMap, s.updatedAt); err != nil {
+		return err
 	}
+	s.source = path
+	return nil
+}
 
-	// Create temporary file
-	tempFile := ouiFile + ".tmp"
+// SaveDatabase atomically writes vendors to path
+func SaveDatabase(path string, vendors map[string]string, updated time.Time) error {
+	tempFile := path + ".tmp"
 	file, err := os.Create(tempFile)
 	if err != nil {
 		return fmt.Errorf("failed to create temp file: %w", err)
 	}
-	defer file.Close()
-
-	// Copy response body to file
-	_, err = io.Copy(file, resp.Body)
-	if err != nil {
+	if err := WriteDatabase(file, vendors, updated); err != nil {
+		file.Close()
+		os.Remove(tempFile)
+		return fmt.Errorf("failed to write OUI database: %w", err)
+	}
+	if err := file.Close(); err != nil {
 		os.Remove(tempFile)
 		return fmt.Errorf("failed to write OUI database: %w", err)
 	}
 
 	// Atomically replace the old file with the new one
-	if err := os.Rename(tempFile, ouiFile); err != nil {
+	if err := os.Rename(tempFile, path); err != nil {
 		os.Remove(tempFile)
 		return fmt.Errorf("failed to replace OUI database: %w", err)
 	}
-
 	return nil
 }
 
-// loadOUIDatabase loads the OUI database into memory
-func (s *OUIService) loadOUIDatabase(ouiFile string) error {
-	file, err := os.Open(ouiFile)
+func parseRegistryFile(path string) (map[string]string, error) {
+	file, err := os.Open(path)
 	if err != nil {
-		return fmt.Errorf("failed to open OUI database: %w", err)
+		return nil, fmt.Errorf("failed to open registry: %w", err)
 	}
 	defer file.Close()
 
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
-
-	// Clear existing map
-	s.ouiMap = make(map[string]string)
-
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-
-		// Skip empty lines and comments
-		if len(line) == 0 || strings.HasPrefix(line, "#") {
-			continue
-		}
-
-		// Look for lines with OUI assignments
-		// Format: "00-00-00   (hex)		XEROX CORPORATION"
-		if strings.Contains(line, "(hex)") {
-			parts := strings.SplitN(line, "(hex)", 2)
-			if len(parts) == 2 {
-				oui := strings.TrimSpace(parts[0])
-				vendor := strings.TrimSpace(parts[1])
-
-				// Normalize OUI format (remove dashes, convert to uppercase)
-				oui = strings.ReplaceAll(oui, "-", "")
-				oui = strings.ToUpper(oui)
-
-				if len(oui) == 6 && vendor != "" {
-					s.ouiMap[oui] = vendor
-				}
-			}
-		}
-	}
-
-	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("failed to read OUI database: %w", err)
+	registry, err := ParseRegistry(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
 	}
-
-	return nil
+	return registry, nil
 }
 
-// LookupVendor returns the vendor name for a given MAC address
+// LookupVendor returns the vendor name for a given MAC address. The most
+// specific assignment wins, so MA-S and IAB blocks take precedence over the
+// MA-M and MA-L assignments they are carved from. Private (locally
+// administered) addresses have no vendor.
 func (s *OUIService) LookupVendor(macAddress string) string {
-	if macAddress == "" {
+	if macAddress == "" || models.IsPrivateMAC(macAddress) {
 		return ""
 	}
 
-	// Extract OUI (first 6 characters after removing separators)
-	oui := s.extractOUI(macAddress)
-	if oui == "" {
-		return ""
-	}
+	mac := normalizeMAC(macAddress)
 
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
 
-	vendor, exists := s.ouiMap[oui]
-	if !exists {
-		return ""
+	for _, length := range prefixLengths {
+		if len(mac) < length {
+			continue
+		}
+		if vendor, exists := s.ouiMap[mac[:length]]; exists {
+			return vendor
+		}
 	}
-
-	return vendor
+	return ""
 }
 
-// extractOUI extracts and normalizes the OUI from a MAC address
-func (s *OUIService) extractOUI(macAddress string) string {
+// normalizeMAC removes the separators from a MAC address and upper cases it
+func normalizeMAC(macAddress string) string {
 	// Remove common MAC address separators
 	mac := strings.ReplaceAll(macAddress, ":", "")
 	mac = strings.ReplaceAll(mac, "-", "")
 	mac = strings.ReplaceAll(mac, ".", "")
-	mac = strings.ToUpper(mac)
-
-	// Ensure we have at least 6 characters for OUI
-	if len(mac) < 6 {
-		return ""
-	}
-
-	return mac[:6]
+	return strings.ToUpper(mac)
 }
 
 // GetStatistics returns statistics about the loaded OUI database
@@ -206,18 +231,13 @@ func (s *OUIService) GetStatistics() map[string]interface{} {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
 
+	lastUpdated := "unknown"
+	if !s.updatedAt.IsZero() {
+		lastUpdated = s.updatedAt.Format("2006-01-02 15:04:05")
+	}
 	return map[string]interface{}{
 		"total_entries": len(s.ouiMap),
-		"last_updated":  s.getLastUpdated(),
-	}
-}
-
-// getLastUpdated returns the last update time of the OUI database file
-func (s *OUIService) getLastUpdated() string {
-	ouiFile := filepath.Join(s.dataPath, "oui.txt")
-	info, err := os.Stat(ouiFile)
-	if err != nil {
-		return "unknown"
+		"last_updated":  lastUpdated,
+		"source":        s.source,
 	}
-	return info.ModTime().Format("2006-01-02 15:04:05")
 }
@@ -0,0 +1,239 @@
+package oui
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math/bits"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Registries are keyed by the assigned prefix in upper case hexadecimal:
+// 6 digits for MA-L (OUI), 7 for MA-M and 9 for MA-S and IAB blocks
+var prefixLengths = []int{9, 7, 6}
+
+const databaseHeader = "# reconya vendor database"
+
+// ParseRegistry reads an IEEE registry file: the text (oui.txt, mam.txt,
+// oui36.txt, iab.txt) or CSV (oui.csv, mam.csv, oui36.csv, iab.csv) listing,
+// optionally gzip compressed
+func ParseRegistry(r io.Reader) (map[string]string, error) {
+	reader, err := decompress(r)
+	if err != nil {
+		return nil, err
+	}
+	buffered := bufio.NewReader(reader)
+	start, _ := buffered.Peek(64)
+	if bytes.HasPrefix(bytes.TrimPrefix(start, []byte("\xef\xbb\xbf")), []byte("Registry,")) {
+		return parseRegistryCSV(buffered)
+	}
+	return parseRegistryText(buffered)
+}
+
+// parseRegistryCSV reads "Registry,Assignment,Organization Name,..." rows
+func parseRegistryCSV(r io.Reader) (map[string]string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	vendors := make(map[string]string)
+	header := true
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read registry CSV: %w", err)
+		}
+		if header {
+			header = false
+			continue
+		}
+		if len(record) < 3 {
+			continue
+		}
+		prefix := strings.ToUpper(strings.TrimSpace(record[1]))
+		vendor := strings.TrimSpace(record[2])
+		if validPrefix(prefix) && vendor != "" {
+			vendors[prefix] = vendor
+		}
+	}
+	return vendors, nil
+}
+
+// parseRegistryText reads the IEEE text listing. Each assignment has a
+// "(hex)" line with the OUI and a "(base 16)" line that, for MA-M, MA-S and
+// IAB blocks, holds the range of the block within the OUI:
+//
+//	00-00-0C   (hex)		Cisco Systems, Inc
+//	00000C     (base 16)		Cisco Systems, Inc
+//
+//	70-B3-D5   (hex)		Example Ltd
+//	F2C000-F2CFFF     (base 16)		Example Ltd
+func parseRegistryText(r io.Reader) (map[string]string, error) {
+	vendors := make(map[string]string)
+	var oui string
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if before, vendor, ok := strings.Cut(line, "(hex)"); ok {
+			oui = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(before), "-", ""))
+			if len(oui) != 6 || !isHex(oui) {
+				oui = ""
+				continue
+			}
+			// MA-L entries are complete here; blocks are refined below
+			if vendor = strings.TrimSpace(vendor); vendor != "" {
+				vendors[oui] = vendor
+			}
+			continue
+		}
+
+		before, vendor, ok := strings.Cut(line, "(base 16)")
+		if !ok || oui == "" {
+			continue
+		}
+		low, high, isRange := strings.Cut(strings.TrimSpace(before), "-")
+		if !isRange {
+			continue
+		}
+		prefix, ok := blockPrefix(oui, strings.TrimSpace(low), strings.TrimSpace(high))
+		if !ok {
+			continue
+		}
+		if vendor = strings.TrimSpace(vendor); vendor == "" {
+			vendor = vendors[oui]
+		}
+		delete(vendors, oui)
+		vendors[prefix] = vendor
+		oui = ""
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read registry: %w", err)
+	}
+	return vendors, nil
+}
+
+// blockPrefix returns the prefix of the block from low to high, the lower
+// 24 bits of its first and last address, within oui
+func blockPrefix(oui, low, high string) (string, bool) {
+	if len(low) != 6 || len(high) != 6 {
+		return "", false
+	}
+	first, err := strconv.ParseUint(low, 16, 32)
+	if err != nil {
+		return "", false
+	}
+	last, err := strconv.ParseUint(high, 16, 32)
+	if err != nil || last < first {
+		return "", false
+	}
+	size := last - first + 1
+	if size&(size-1) != 0 {
+		return "", false
+	}
+	// 24 bits of OUI plus the bits fixed within it, in whole hex digits
+	fixed := 24 - (bits.Len64(size) - 1)
+	if fixed%4 != 0 {
+		return "", false
+	}
+	prefix := oui + strings.ToUpper(low)[:fixed/4]
+	return prefix, validPrefix(prefix)
+}
+
+// ReadDatabase reads a gzip compressed database written by WriteDatabase. It
+// returns the vendors and when the database was written.
+func ReadDatabase(r io.Reader) (map[string]string, time.Time, error) {
+	reader, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to open vendor database: %w", err)
+	}
+	defer reader.Close()
+
+	vendors := make(map[string]string)
+	var updated time.Time
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if value, ok := strings.CutPrefix(line, "# updated "); ok {
+			updated, _ = time.Parse(time.RFC3339, value)
+			continue
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		prefix, vendor, ok := strings.Cut(line, "\t")
+		if ok && validPrefix(prefix) {
+			vendors[prefix] = vendor
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to read vendor database: %w", err)
+	}
+	return vendors, updated, nil
+}
+
+// WriteDatabase writes vendors as gzip compressed, tab separated lines sorted
+// by prefix
+func WriteDatabase(w io.Writer, vendors map[string]string, updated time.Time) error {
+	prefixes := make([]string, 0, len(vendors))
+	for prefix := range vendors {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+
+	writer, err := gzip.NewWriterLevel(w, gzip.BestCompression)
+	if err != nil {
+		return err
+	}
+	buffered := bufio.NewWriter(writer)
+	fmt.Fprintln(buffered, databaseHeader)
+	fmt.Fprintf(buffered, "# updated %s\n", updated.UTC().Format(time.RFC3339))
+	for _, prefix := range prefixes {
+		vendor := strings.NewReplacer("\t", " ", "\n", " ", "\r", "").Replace(vendors[prefix])
+		fmt.Fprintf(buffered, "%s\t%s\n", prefix, vendor)
+	}
+	if err := buffered.Flush(); err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+func decompress(r io.Reader) (io.Reader, error) {
+	buffered := bufio.NewReader(r)
+	magic, _ := buffered.Peek(2)
+	if len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		reader, err := gzip.NewReader(buffered)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress registry: %w", err)
+		}
+		return reader, nil
+	}
+	return buffered, nil
+}
+
+func validPrefix(prefix string) bool {
+	for _, length := range prefixLengths {
+		if len(prefix) == length {
+			return isHex(prefix)
+		}
+	}
+	return false
+}
+
+func isHex(s string) bool {
+	for _, c := range s {
+		if !(c >= '0' && c <= '9' || c >= 'A' && c <= 'F') {
+			return false
+		}
+	}
+	return s != ""
+}
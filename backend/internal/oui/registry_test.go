@@ -0,0 +1,119 @@
+package oui
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const registryText = `OUI/MA-L			Organization
+company_id			Organization
+				Address
+
+00-00-0C   (hex)		Cisco Systems, Inc
+00000C     (base 16)		Cisco Systems, Inc
+				170 WEST TASMAN DRIVE
+				SAN JOSE  CA  95134
+				US
+
+70-B3-D5   (hex)		IEEE Registration Authority
+F2C000-F2CFFF     (base 16)		Example Sensors Ltd
+				Example Street
+				DE
+
+8C-1F-64   (hex)		Example Meters
+A00000-AFFFFF     (base 16)		Example Meters
+				US
+`
+
+func TestParseRegistry_Text(t *testing.T) {
+	vendors, err := ParseRegistry(strings.NewReader(registryText))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"00000C":    "Cisco Systems, Inc",
+		"70B3D5F2C": "Example Sensors Ltd",
+		"8C1F64A":   "Example Meters",
+	}, vendors)
+}
+
+func TestParseRegistry_CSV(t *testing.T) {
+	registry := "\xef\xbb\xbfRegistry,Assignment,Organization Name,Organization Address\n" +
+		"MA-L,00000C,\"Cisco Systems, Inc\",SAN JOSE CA US\n" +
+		"MA-S,70B3D5F2C,Example Sensors Ltd,DE\n" +
+		"MA-L,XYZ,Invalid,\n"
+
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	_, err := writer.Write([]byte(registry))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	vendors, err := ParseRegistry(&compressed)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"00000C":    "Cisco Systems, Inc",
+		"70B3D5F2C": "Example Sensors Ltd",
+	}, vendors)
+}
+
+func TestDatabase_RoundTrip(t *testing.T) {
+	vendors := map[string]string{"00000C": "Cisco Systems, Inc", "8C1F64A": "Example\tMeters"}
+	updated := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	var buffer bytes.Buffer
+	require.NoError(t, WriteDatabase(&buffer, vendors, updated))
+	read, readUpdated, err := ReadDatabase(&buffer)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"00000C": "Cisco Systems, Inc", "8C1F64A": "Example Meters"}, read)
+	assert.True(t, updated.Equal(readUpdated))
+}
+
+// minSnapshotEntries is well below the size of the IEEE registries, which
+// have over 50,000 assignments between them, so a snapshot rebuilt from a
+// subset of them fails
+const minSnapshotEntries = 25000
+
+func TestBundledSnapshot(t *testing.T) {
+	vendors, _, err := ReadDatabase(bytes.NewReader(snapshot))
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, len(vendors), minSnapshotEntries, "the snapshot is built from the full IEEE registries")
+
+	lengths := make(map[int]int)
+	for prefix := range vendors {
+		lengths[len(prefix)]++
+	}
+	assert.NotZero(t, lengths[6], "MA-L assignments")
+	assert.NotZero(t, lengths[7], "MA-M blocks (mam.csv)")
+	assert.NotZero(t, lengths[9], "MA-S and IAB blocks (oui36.csv, iab.csv)")
+}
+
+func TestOUIService_LookupVendor(t *testing.T) {
+	dir := t.TempDir()
+	registry := filepath.Join(dir, "oui.txt")
+	require.NoError(t, os.WriteFile(registry, []byte(registryText), 0644))
+
+	service := NewOUIService(dir)
+	require.NoError(t, service.Initialize())
+	count, err := service.Update([]string{registry}, true)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	assert.Equal(t, "Cisco Systems, Inc", service.LookupVendor("00:00:0c:12:34:56"))
+	assert.Equal(t, "Example Sensors Ltd", service.LookupVendor("70-B3-D5-F2-C1-23"))
+	assert.Equal(t, "Example Meters", service.LookupVendor("8c1f.64a1.2345"))
+	assert.Empty(t, service.LookupVendor("70:B3:D5:00:00:01"), "the rest of a carved up OUI is unassigned")
+	assert.Empty(t, service.LookupVendor("02:00:0C:12:34:56"), "private addresses have no vendor")
+
+	// The saved database is preferred over the snapshot on the next start
+	restarted := NewOUIService(dir)
+	require.NoError(t, restarted.Initialize())
+	assert.Equal(t, "Example Meters", restarted.LookupVendor("8C:1F:64:A0:00:01"))
+	assert.Equal(t, filepath.Join(dir, DatabaseFile), restarted.GetStatistics()["source"])
+}
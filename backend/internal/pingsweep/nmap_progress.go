@@ -0,0 +1,63 @@
+package pingsweep
+
+import (
+	"bytes"
+	"encoding/xml"
+	"net"
+	"strings"
+	"time"
+
+	"reconya-ai/internal/scanprogress"
+)
+
+// nmapProgress follows the <taskprogress> elements nmap writes to its XML
+// output with --stats-every and reports the ping scan's to the tracker
+type nmapProgress struct {
+	tracker *scanprogress.Tracker
+	line    []byte
+}
+
+type nmapTaskProgress struct {
+	Task      string  `xml:"task,attr"`
+	Percent   float64 `xml:"percent,attr"`
+	Remaining int     `xml:"remaining,attr"`
+}
+
+func (p *nmapProgress) Write(data []byte) (int, error) {
+	p.line = append(p.line, data...)
+	for {
+		i := bytes.IndexByte(p.line, '\n')
+		if i < 0 {
+			break
+		}
+		p.parse(bytes.TrimSpace(p.line[:i]))
+		p.line = append(p.line[:0], p.line[i+1:]...)
+	}
+	return len(data), nil
+}
+
+func (p *nmapProgress) parse(line []byte) {
+	if !bytes.HasPrefix(line, []byte("<taskprogress")) {
+		return
+	}
+	var task nmapTaskProgress
+	if err := xml.Unmarshal(line, &task); err != nil {
+		return
+	}
+	// Host discovery is "Ping Scan" or "ARP Ping Scan"; later tasks such
+	// as DNS resolution start again from 0%
+	if !strings.HasSuffix(task.Task, "Ping Scan") {
+		return
+	}
+	p.tracker.Estimate(task.Percent, time.Duration(task.Remaining)*time.Second)
+}
+
+// addressCount returns the number of addresses in an IPv4 range
+func addressCount(cidr string) int {
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return 0
+	}
+	ones, bits := ipNet.Mask.Size()
+	return 1 << (bits - ones)
+}
@@ -0,0 +1,29 @@
+package pingsweep
+
+import (
+	"testing"
+
+	"reconya-ai/internal/scanprogress"
+)
+
+func TestNmapProgress(t *testing.T) {
+	tracker := scanprogress.NewTracker(1, 1)
+	tracker.StartDiscovery("192.168.0.0/22", addressCount("192.168.0.0/22"))
+	progress := &nmapProgress{tracker: tracker}
+
+	output := "<nmaprun scanner=\"nmap\">\n" +
+		"<taskbegin task=\"Ping Scan\" time=\"1700000000\"/>\n" +
+		"<taskprogress task=\"Ping Scan\" time=\"1700000002\" percent=\"25.00\" remaining=\"6\" etc=\"1700000008\"/>\n" +
+		"<taskprogress task=\"Parallel DNS resolution of 4 hosts.\" time=\"1700000003\" percent=\"0.00\"/>\n"
+	// Lines arrive split across writes
+	progress.Write([]byte(output[:50]))
+	progress.Write([]byte(output[50:]))
+
+	s := tracker.Snapshot()
+	if s.Discovery.Total != 1024 || s.Discovery.Probed != 256 || s.Discovery.Percent != 25 {
+		t.Errorf("unexpected discovery progress %+v", s.Discovery)
+	}
+	if s.ETASeconds == nil || *s.ETASeconds != 6 {
+		t.Errorf("ETASeconds = %v, want 6", s.ETASeconds)
+	}
+}
@@ -8,10 +8,15 @@ import (
 	"reconya-ai/internal/config"
 	"reconya-ai/internal/device"
 	"reconya-ai/internal/eventlog"
+	"reconya-ai/internal/neighbor"
 	"reconya-ai/internal/network"
 	"reconya-ai/internal/portscan"
+	"reconya-ai/internal/ratelimit"
 	"reconya-ai/internal/scanner"
+	"reconya-ai/internal/scanpolicy"
+	"reconya-ai/internal/scanprogress"
 	"reconya-ai/models"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -23,10 +28,18 @@ type PingSweepService struct {
 	EventLogService *eventlog.EventLogService
 	NetworkService  *network.NetworkService
 	PortScanService *portscan.PortScanService
+	// Neighbors, when set, answers the native scanner's MAC lookups
+	Neighbors *neighbor.Table
+	// Progress follows the sweeps and the devices going through the port
+	// scan queue
+	Progress        *scanprogress.Tracker
 	portScanQueue   chan models.Device
 	portScanWorkers sync.WaitGroup
 }
 
+// portScanWorkers is the number of devices port scanned at once
+const portScanWorkers = 3
+
 func NewPingSweepService(
 	cfg *config.Config,
 	deviceService *device.DeviceService,
@@ -42,9 +55,14 @@ func NewPingSweepService(
 		PortScanService: portScanService,
 		portScanQueue:   make(chan models.Device, 100), // Buffer for 100 devices
 	}
+	service.Progress = scanprogress.NewTracker(portScanWorkers, cap(service.portScanQueue))
+	// The port scan service moves the devices the workers hand it through
+	// the later stages
+	if portScanService != nil {
+		portScanService.Progress = service.Progress
+	}
 
-	// Start 3 port scan workers
-	service.startPortScanWorkers(3)
+	service.startPortScanWorkers(portScanWorkers)
 
 	return service
 }
@@ -55,14 +73,20 @@ func (s *PingSweepService) Run() {
 	log.Println("PingSweepService.Run() is deprecated - scanning is now controlled by scan manager")
 }
 
-func (s *PingSweepService) ExecuteSweepScanCommand(network string) ([]models.Device, error) {
+// ExecuteSweepScanCommand finds the hosts of a network, leaving out the
+// addresses the sweep policy excludes. nmap is paced by the limiter and
+// paused outside its scan windows.
+func (s *PingSweepService) ExecuteSweepScanCommand(network string, policy scanpolicy.SweepPolicy, limiter *ratelimit.Limiter) ([]models.Device, error) {
 	log.Printf("Executing nmap command on network: %s", network)
+	s.Progress.StartDiscovery(network, addressCount(network))
+	defer s.Progress.FinishDiscovery()
 
 	// Try multiple scan strategies for different environments
-	devices, err := s.executeWithFallback(network)
+	devices, err := s.executeWithFallback(network, policy, limiter)
 	if err != nil {
 		return nil, err
 	}
+	devices = withoutExcluded(devices, policy)
 
 	log.Printf("nmap command succeeded. Found %d devices", len(devices))
 
@@ -81,12 +105,12 @@ func (s *PingSweepService) ExecuteSweepScanCommand(network string) ([]models.Dev
 }
 
 // executeWithFallback tries different scan strategies based on environment
-func (s *PingSweepService) executeWithFallback(network string) ([]models.Device, error) {
+func (s *PingSweepService) executeWithFallback(network string, policy scanpolicy.SweepPolicy, limiter *ratelimit.Limiter) ([]models.Device, error) {
 	// Skip native scanner - it's too slow for large networks
 	log.Printf("Skipping native Go scanner, using nmap directly")
 
 	// Strategy 1: Try sudo with IP packets (works on most systems, gets MAC/vendor)
-	devices, err := s.tryNmapCommand([]string{"nmap", "-sn", "--send-ip", "-T4", "-n", "-oX", "-", network})
+	devices, err := s.tryNmapCommand(sweepArgs(policy, "nmap", "-sn", "--send-ip", "-T4", "-n", "-oX", "-", network), limiter)
 	if err == nil && len(devices) > 0 {
 		log.Printf("Sudo IP scan successful, found %d devices", len(devices))
 		return devices, nil
@@ -94,7 +118,7 @@ func (s *PingSweepService) executeWithFallback(network string) ([]models.Device,
 	log.Printf("Sudo IP scan failed or found no devices: %v", err)
 
 	// Strategy 3: Try IP packets without sudo (may still get some MAC info)
-	devices, err = s.tryNmapCommand([]string{"nmap", "-sn", "--send-ip", "-T4", "-oX", "-", network})
+	devices, err = s.tryNmapCommand(sweepArgs(policy, "nmap", "-sn", "--send-ip", "-T4", "-oX", "-", network), limiter)
 	if err == nil && len(devices) > 0 {
 		log.Printf("IP scan without sudo successful, found %d devices", len(devices))
 		return devices, nil
@@ -102,7 +126,7 @@ func (s *PingSweepService) executeWithFallback(network string) ([]models.Device,
 	log.Printf("IP scan without sudo failed or found no devices: %v", err)
 
 	// Strategy 4: Try ARP scan with sudo (best for local networks but needs interface access)
-	devices, err = s.tryNmapCommand([]string{"nmap", "-sn", "-PR", "-T4", "-n", "-oX", "-", network})
+	devices, err = s.tryNmapCommand(sweepArgs(policy, "nmap", "-sn", "-PR", "-T4", "-n", "-oX", "-", network), limiter)
 	if err == nil && len(devices) > 0 {
 		log.Printf("Sudo ARP scan successful, found %d devices", len(devices))
 		return devices, nil
@@ -110,7 +134,7 @@ func (s *PingSweepService) executeWithFallback(network string) ([]models.Device,
 	log.Printf("Sudo ARP scan failed or found no devices: %v", err)
 
 	// Strategy 5: Try ARP scan without sudo
-	devices, err = s.tryNmapCommand([]string{"nmap", "-sn", "-PR", "-T4", "-oX", "-", network})
+	devices, err = s.tryNmapCommand(sweepArgs(policy, "nmap", "-sn", "-PR", "-T4", "-oX", "-", network), limiter)
 	if err == nil && len(devices) > 0 {
 		log.Printf("ARP scan without sudo successful, found %d devices", len(devices))
 		return devices, nil
@@ -118,7 +142,7 @@ func (s *PingSweepService) executeWithFallback(network string) ([]models.Device,
 	log.Printf("ARP scan without sudo failed or found no devices: %v", err)
 
 	// Strategy 6: Last resort - TCP SYN scan on common ports (minimal info but finds hosts)
-	devices, err = s.tryNmapCommand([]string{"nmap", "-sn", "-PS80,443,22,21,23,25,53,110,111,135,139,143,993,995", "-T4", "-oX", "-", network})
+	devices, err = s.tryNmapCommand(sweepArgs(policy, "nmap", "-sn", "-PS80,443,22,21,23,25,53,110,111,135,139,143,993,995", "-T4", "-oX", "-", network), limiter)
 	if err == nil && len(devices) > 0 {
 		log.Printf("TCP SYN probe scan successful, found %d devices", len(devices))
 		return devices, nil
@@ -128,11 +152,56 @@ func (s *PingSweepService) executeWithFallback(network string) ([]models.Device,
 	return nil, fmt.Errorf("all scan strategies failed for network %s", network)
 }
 
+// sweepArgs builds an nmap command, placing the policy's exclusions and rate
+// limit before the target network. nmap reports its progress every few
+// seconds.
+func sweepArgs(policy scanpolicy.SweepPolicy, args ...string) []string {
+	target := args[len(args)-1]
+	args = append([]string{}, args[:len(args)-1]...)
+	args = append(args, "--stats-every", "2s")
+	if len(policy.ExcludeIPs) > 0 {
+		args = append(args, "--exclude", strings.Join(policy.ExcludeIPs, ","))
+	}
+	if policy.MaxRate > 0 {
+		args = append(args, "--max-rate", strconv.Itoa(policy.MaxRate))
+	}
+	return append(args, target)
+}
+
+// withoutExcluded drops excluded hosts that a scan strategy reported anyway,
+// including ones whose MAC address is excluded
+func withoutExcluded(devices []models.Device, policy scanpolicy.SweepPolicy) []models.Device {
+	if len(policy.ExcludeIPs) == 0 && policy.Exclusions.IsEmpty() {
+		return devices
+	}
+	skip := make(map[string]bool, len(policy.ExcludeIPs))
+	for _, ip := range policy.ExcludeIPs {
+		skip[ip] = true
+	}
+
+	kept := devices[:0]
+	for i := range devices {
+		if skip[devices[i].IPv4] || policy.Exclusions.ExcludesDevice(&devices[i]) {
+			log.Printf("Dropping excluded host %s from sweep results", devices[i].IPv4)
+			continue
+		}
+		kept = append(kept, devices[i])
+	}
+	return kept
+}
+
 // tryNativeScanner uses the native Go scanner for network discovery
-func (s *PingSweepService) tryNativeScanner(network string) ([]models.Device, error) {
+func (s *PingSweepService) tryNativeScanner(network string, policy scanpolicy.SweepPolicy, limiter *ratelimit.Limiter) ([]models.Device, error) {
 	log.Printf("Trying native Go scanner on network: %s", network)
 
 	nativeScanner := scanner.NewNativeScanner()
+	nativeScanner.SetVendorLookup(s.DeviceService.LookupVendor)
+	if s.Neighbors != nil {
+		nativeScanner.SetNeighborTable(s.Neighbors)
+	}
+	nativeScanner.SetLimiter(limiter)
+	nativeScanner.SetExclusions(policy.Exclusions)
+	nativeScanner.SetProgress(s.Progress.Probed)
 	devices, err := nativeScanner.ScanNetwork(network)
 	if err != nil {
 		return nil, err
@@ -141,19 +210,63 @@ func (s *PingSweepService) tryNativeScanner(network string) ([]models.Device, er
 	return devices, nil
 }
 
+// ExecuteIPv6Discovery looks for hosts on the IPv6 prefix of a network. The
+// MAC addresses and hostnames of the network's known devices are used to
+// guess their addresses, which finds hosts that ignore multicast echoes.
+// Probes are paced by the limiter and excluded hosts are neither probed nor
+// reported.
+func (s *PingSweepService) ExecuteIPv6Discovery(network *models.Network, policy scanpolicy.SweepPolicy, limiter *ratelimit.Limiter) ([]models.Device, error) {
+	prefix := network.GetIPv6Prefix()
+	if prefix == "" {
+		return nil, fmt.Errorf("network %s has no IPv6 prefix", network.CIDR)
+	}
+	log.Printf("Executing IPv6 discovery on prefix: %s", prefix)
+	s.Progress.StartDiscovery(prefix, 0)
+	defer s.Progress.FinishDiscovery()
+
+	var candidates []scanner.IPv6Candidate
+	known, err := s.DeviceService.FindByNetworkID(network.ID)
+	if err != nil {
+		log.Printf("Failed to load devices of network %s for IPv6 discovery: %v", network.CIDR, err)
+	}
+	for _, device := range known {
+		var candidate scanner.IPv6Candidate
+		if device.MAC != nil {
+			candidate.MAC = *device.MAC
+		}
+		if device.Hostname != nil {
+			candidate.Hostname = *device.Hostname
+		}
+		if candidate.MAC != "" || candidate.Hostname != "" {
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	nativeScanner := scanner.NewNativeScanner()
+	nativeScanner.SetVendorLookup(s.DeviceService.LookupVendor)
+	if s.Neighbors != nil {
+		nativeScanner.SetNeighborTable(s.Neighbors)
+	}
+	nativeScanner.SetLimiter(limiter)
+	nativeScanner.SetExclusions(policy.Exclusions)
+	nativeScanner.SetProgress(s.Progress.Probed)
+	return nativeScanner.DiscoverIPv6(prefix, candidates)
+}
+
 // tryNmapCommand executes a specific nmap command with automatic retry on timeout
-func (s *PingSweepService) tryNmapCommand(args []string) ([]models.Device, error) {
+func (s *PingSweepService) tryNmapCommand(args []string, limiter *ratelimit.Limiter) ([]models.Device, error) {
 	log.Printf("Trying nmap command: %s", strings.Join(args, " "))
 
-	// First attempt with 20-second timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
-	defer cancel()
+	// Each strategy and retry probes the range from the start
+	s.Progress.Estimate(0, 0)
 
-	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
-	output, err := cmd.CombinedOutput()
+	// First attempt with 20-second timeout
+	output, err := limiter.ExecStreaming(context.Background(), 20*time.Second, func(maxRate int) []string {
+		return ratelimit.WithMaxRate(args, maxRate)
+	}, &nmapProgress{tracker: s.Progress})
 
 	// If timeout occurred and command doesn't already have -n flag, retry with -n
-	if err != nil && ctx.Err() == context.DeadlineExceeded {
+	if err == context.DeadlineExceeded {
 		log.Printf("nmap command timed out, checking if we can retry with -n flag")
 
 		// Check if -n flag is already present
@@ -183,14 +296,13 @@ func (s *PingSweepService) tryNmapCommand(args []string) ([]models.Device, error
 			log.Printf("Retry command: %s", strings.Join(retryArgs, " "))
 
 			// Retry with 90-second timeout for Raspberry Pi compatibility
-			retryCtx, retryCancel := context.WithTimeout(context.Background(), 90*time.Second)
-			defer retryCancel()
-
-			retryCmd := exec.CommandContext(retryCtx, retryArgs[0], retryArgs[1:]...)
-			output, err = retryCmd.CombinedOutput()
+			s.Progress.Estimate(0, 0)
+			output, err = limiter.ExecStreaming(context.Background(), 90*time.Second, func(maxRate int) []string {
+				return ratelimit.WithMaxRate(retryArgs, maxRate)
+			}, &nmapProgress{tracker: s.Progress})
 
 			if err != nil {
-				if retryCtx.Err() == context.DeadlineExceeded {
+				if err == context.DeadlineExceeded {
 					log.Printf("nmap retry also timed out after 90 seconds")
 					return nil, fmt.Errorf("nmap command timed out even with -n flag")
 				}
@@ -300,6 +412,23 @@ func (s *PingSweepService) tryDigReverseLookup(ip string) string {
 	return ""
 }
 
+// EnqueuePortScan queues a device for the port scan workers. A device already
+// queued or being scanned isn't queued twice, and when the queue is full the
+// device is left for the next sweep.
+func (s *PingSweepService) EnqueuePortScan(device models.Device) bool {
+	if !s.Progress.Enqueue(device.IPv4) {
+		return false
+	}
+	select {
+	case s.portScanQueue <- device:
+		return true
+	default:
+		s.Progress.Drop(device.IPv4)
+		log.Printf("Port scan queue full, leaving %s for the next sweep", device.IPv4)
+		return false
+	}
+}
+
 // startPortScanWorkers starts background workers for port scanning
 func (s *PingSweepService) startPortScanWorkers(numWorkers int) {
 	log.Printf("Starting %d port scan workers", numWorkers)
@@ -0,0 +1,33 @@
+package portscan
+
+import (
+	"testing"
+	"time"
+
+	"reconya-ai/internal/scanpolicy"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPortScanArgs(t *testing.T) {
+	settings := scanpolicy.DefaultSettings()
+	args, timeout := portScanArgs("192.168.1.10", settings)
+	assert.Equal(t, []string{"-sT", "-T4", "-p", defaultPorts, "-oX", "-", "192.168.1.10"}, args)
+	assert.Equal(t, 2*time.Minute, timeout)
+
+	settings.Ports = "22,80"
+	settings.MaxRate = 50
+	args, _ = portScanArgs("192.168.1.10", settings)
+	assert.Equal(t, []string{"-sT", "-T4", "-p", "22,80", "--max-rate", "50", "-oX", "-", "192.168.1.10"}, args)
+
+	settings = scanpolicy.DefaultSettings()
+	settings.TopPorts = 20
+	settings.UDP = true
+	args, timeout = portScanArgs("192.168.1.10", settings)
+	assert.Equal(t, []string{"-sT", "-T4", "-sU", "--top-ports", "20", "-oX", "-", "192.168.1.10"}, args)
+	assert.Equal(t, 5*time.Minute, timeout)
+
+	settings.TopPorts = 0
+	args, _ = portScanArgs("192.168.1.10", settings)
+	assert.Equal(t, "T:"+defaultPorts+",U:"+defaultUDPPorts, args[4])
+}
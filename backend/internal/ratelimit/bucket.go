@@ -0,0 +1,132 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Bucket is a token bucket of packets or connections per second. Processes
+// that pace themselves, such as nmap with --max-rate, reserve part of the
+// rate while they run and tokens refill at what is left.
+type Bucket struct {
+	mu       sync.Mutex
+	rate     int
+	burst    int
+	reserved int
+	tokens   float64
+	last     time.Time
+}
+
+// NewBucket creates a bucket; a rate of 0 is unlimited and a burst below 1
+// defaults to the rate
+func NewBucket(rate, burst int) *Bucket {
+	b := &Bucket{}
+	b.SetRate(rate, burst)
+	return b
+}
+
+// SetRate changes the rate and burst of the bucket
+func (b *Bucket) SetRate(rate, burst int) {
+	if rate < 0 {
+		rate = 0
+	}
+	if burst < 1 {
+		burst = rate
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.rate == rate && b.burst == burst {
+		return
+	}
+	b.rate = rate
+	b.burst = burst
+	b.tokens = float64(burst)
+	b.last = time.Now()
+}
+
+// Rate returns the rate of the bucket, 0 when unlimited
+func (b *Bucket) Rate() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.rate
+}
+
+// Wait blocks until n tokens are available and takes them
+func (b *Bucket) Wait(ctx context.Context, n int) error {
+	for {
+		wait := b.take(n, time.Now())
+		if wait == 0 {
+			return nil
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// take takes n tokens, or returns how long to wait for them
+func (b *Bucket) take(n int, now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.rate == 0 {
+		return 0
+	}
+
+	refill := float64(b.available())
+	b.tokens += now.Sub(b.last).Seconds() * refill
+	b.last = now
+	if b.tokens > float64(b.burst) {
+		b.tokens = float64(b.burst)
+	}
+
+	// Requests larger than the burst wait for a full bucket
+	need := float64(n)
+	if need > float64(b.burst) {
+		need = float64(b.burst)
+	}
+	if b.tokens >= need {
+		b.tokens -= need
+		return 0
+	}
+	return time.Duration((need - b.tokens) / refill * float64(time.Second))
+}
+
+// available is the rate not reserved by processes, at least 1
+func (b *Bucket) available() int {
+	if free := b.rate - b.reserved; free > 1 {
+		return free
+	}
+	return 1
+}
+
+// share is what a new process may reserve: half of what is left, so later
+// processes and token waiters always get some of the rate
+func (b *Bucket) share() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.rate == 0 {
+		return 0
+	}
+	if share := b.available() / 2; share > 1 {
+		return share
+	}
+	return 1
+}
+
+func (b *Bucket) reserve(rate int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.reserved += rate
+}
+
+func (b *Bucket) release(rate int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.reserved -= rate
+}
@@ -0,0 +1,232 @@
+package ratelimit
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// pollInterval is how often waiting and running work checks the schedule
+var pollInterval = time.Second
+
+// Limiter paces the scanning of one network: its packets and connections draw
+// from the global bucket and the network's own, and scanning only happens
+// within the network's schedule. A nil Limiter doesn't limit anything.
+type Limiter struct {
+	buckets []*Bucket
+
+	mu       sync.RWMutex
+	schedule Schedule
+	now      func() time.Time
+}
+
+// NewLimiter creates a limiter drawing from the buckets, skipping nil ones
+func NewLimiter(schedule Schedule, buckets ...*Bucket) *Limiter {
+	l := &Limiter{schedule: schedule, now: time.Now}
+	for _, b := range buckets {
+		if b != nil {
+			l.buckets = append(l.buckets, b)
+		}
+	}
+	return l
+}
+
+// SetSchedule replaces the windows in which scanning is allowed
+func (l *Limiter) SetSchedule(schedule Schedule) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.schedule = schedule
+}
+
+// Schedule returns the windows in which scanning is allowed
+func (l *Limiter) Schedule() Schedule {
+	if l == nil {
+		return Schedule{}
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.schedule
+}
+
+// Open reports whether scanning is allowed now
+func (l *Limiter) Open() bool {
+	if l == nil {
+		return true
+	}
+	return l.Schedule().Open(l.now())
+}
+
+// NextOpen returns when scanning is next allowed
+func (l *Limiter) NextOpen() (time.Time, bool) {
+	if l == nil {
+		return time.Now(), true
+	}
+	return l.Schedule().NextOpen(l.now())
+}
+
+// WaitOpen blocks until scanning is allowed
+func (l *Limiter) WaitOpen(ctx context.Context) error {
+	for !l.Open() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+	return nil
+}
+
+// Wait blocks until scanning is allowed and n packets or connections may be
+// sent
+func (l *Limiter) Wait(ctx context.Context, n int) error {
+	if l == nil {
+		return nil
+	}
+	if err := l.WaitOpen(ctx); err != nil {
+		return err
+	}
+	for _, b := range l.buckets {
+		if err := b.Wait(ctx, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reserve reserves a share of every bucket for a process that paces itself
+// and returns its rate, 0 when unlimited
+func (l *Limiter) reserve() int {
+	if l == nil {
+		return 0
+	}
+	rate := 0
+	for _, b := range l.buckets {
+		if share := b.share(); share > 0 && (rate == 0 || share < rate) {
+			rate = share
+		}
+	}
+	for _, b := range l.buckets {
+		b.reserve(rate)
+	}
+	return rate
+}
+
+func (l *Limiter) release(rate int) {
+	if l == nil {
+		return
+	}
+	for _, b := range l.buckets {
+		b.release(rate)
+	}
+}
+
+// Exec runs a command once scanning is allowed and returns its combined
+// output. args builds the command line from the packets per second it may
+// send, 0 when unlimited. The process is stopped while the schedule is
+// closed and continued when it opens again; only the time it runs counts
+// towards timeout, after which it is killed and context.DeadlineExceeded
+// returned.
+func (l *Limiter) Exec(ctx context.Context, timeout time.Duration, args func(maxRate int) []string) ([]byte, error) {
+	return l.ExecStreaming(ctx, timeout, args, nil)
+}
+
+// ExecStreaming is Exec that also copies the output to w as the command
+// writes it, for following its progress. A nil w is ignored.
+func (l *Limiter) ExecStreaming(ctx context.Context, timeout time.Duration, args func(maxRate int) []string, w io.Writer) ([]byte, error) {
+	if err := l.WaitOpen(ctx); err != nil {
+		return nil, err
+	}
+
+	rate := l.reserve()
+	defer l.release(rate)
+
+	argv := args(rate)
+	var output bytes.Buffer
+	var out io.Writer = &output
+	if w != nil {
+		out = io.MultiWriter(&output, w)
+	}
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Stdout = out
+	cmd.Stderr = out
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	remaining := timeout
+	resumedAt := time.Now()
+	paused := false
+	for {
+		select {
+		case err := <-done:
+			return output.Bytes(), err
+		case <-ctx.Done():
+			cmd.Process.Kill()
+			<-done
+			return output.Bytes(), ctx.Err()
+		case <-deadline.C:
+			cmd.Process.Kill()
+			<-done
+			return output.Bytes(), context.DeadlineExceeded
+		case <-ticker.C:
+			open := l.Open()
+			switch {
+			case paused && open:
+				if err := resumeProcess(cmd.Process); err != nil {
+					log.Printf("Failed to resume %s: %v", argv[0], err)
+				}
+				log.Printf("Scan window opened, resumed %s", argv[0])
+				paused = false
+				resumedAt = time.Now()
+				deadline.Reset(remaining)
+			case !paused && !open:
+				if err := pauseProcess(cmd.Process); err != nil {
+					log.Printf("Failed to pause %s, letting it finish: %v", argv[0], err)
+					continue
+				}
+				log.Printf("Scan window closed, paused %s", argv[0])
+				paused = true
+				remaining -= time.Since(resumedAt)
+				if !deadline.Stop() {
+					<-deadline.C
+				}
+			}
+		}
+	}
+}
+
+// WithMaxRate returns an nmap command line sending at most rate packets per
+// second, lowering a --max-rate already given or adding one before the
+// target. A rate of 0 leaves the command unchanged.
+func WithMaxRate(args []string, rate int) []string {
+	if rate <= 0 {
+		return args
+	}
+	limited := append([]string{}, args...)
+	for i := 0; i+1 < len(limited); i++ {
+		if limited[i] == "--max-rate" {
+			if current, err := strconv.Atoi(limited[i+1]); err != nil || current > rate {
+				limited[i+1] = strconv.Itoa(rate)
+			}
+			return limited
+		}
+	}
+	target := limited[len(limited)-1]
+	limited = append(limited[:len(limited)-1], "--max-rate", strconv.Itoa(rate))
+	return append(limited, target)
+}
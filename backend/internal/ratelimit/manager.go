@@ -0,0 +1,99 @@
+package ratelimit
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	"reconya-ai/internal/config"
+	"reconya-ai/models"
+)
+
+// NetworkFinder looks up the scan limits of networks
+type NetworkFinder interface {
+	FindByID(id string) (*models.Network, error)
+}
+
+// Manager hands out the limiters of networks. All of them share the global
+// bucket; networks with a rate limit add their own.
+type Manager struct {
+	networks NetworkFinder
+	global   *Bucket
+	schedule Schedule
+
+	mu       sync.Mutex
+	limiters map[string]*networkLimiter
+	fallback *Limiter
+}
+
+type networkLimiter struct {
+	limiter *Limiter
+	bucket  *Bucket
+	windows string
+}
+
+func NewManager(cfg *config.Config, networks NetworkFinder) (*Manager, error) {
+	schedule, err := ParseSchedule(cfg.ScanWindows)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SCAN_WINDOWS: %v", err)
+	}
+
+	global := NewBucket(cfg.ScanRateLimit, cfg.ScanRateBurst)
+	return &Manager{
+		networks: networks,
+		global:   global,
+		schedule: schedule,
+		limiters: make(map[string]*networkLimiter),
+		fallback: NewLimiter(schedule, global),
+	}, nil
+}
+
+// For returns the limiter of a network with its current settings. Unknown
+// networks only get the global limits. A nil Manager returns a nil Limiter.
+func (m *Manager) For(networkID string) *Limiter {
+	if m == nil {
+		return nil
+	}
+	if networkID == "" {
+		return m.fallback
+	}
+
+	network, err := m.networks.FindByID(networkID)
+	if err != nil || network == nil {
+		if err != nil {
+			log.Printf("Failed to load scan limits of network %s, using global limits: %v", networkID, err)
+		}
+		return m.fallback
+	}
+
+	rate := 0
+	if network.RateLimit != nil {
+		rate = *network.RateLimit
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Network buckets burst no more than a second's worth, whatever the
+	// global burst
+	entry, ok := m.limiters[networkID]
+	if !ok {
+		entry = &networkLimiter{bucket: NewBucket(rate, 0)}
+		entry.limiter = NewLimiter(m.schedule, m.global, entry.bucket)
+		m.limiters[networkID] = entry
+	}
+	entry.bucket.SetRate(rate, 0)
+
+	if network.ScanWindows != entry.windows {
+		schedule := m.schedule
+		if network.ScanWindows != "" {
+			if schedule, err = ParseSchedule(network.ScanWindows); err != nil {
+				log.Printf("Invalid scan windows of network %s, using global windows: %v", network.CIDR, err)
+				schedule = m.schedule
+			}
+		}
+		entry.limiter.SetSchedule(schedule)
+		entry.windows = network.ScanWindows
+	}
+	return entry.limiter
+}
@@ -0,0 +1,16 @@
+//go:build !windows
+
+package ratelimit
+
+import (
+	"os"
+	"syscall"
+)
+
+func pauseProcess(process *os.Process) error {
+	return process.Signal(syscall.SIGSTOP)
+}
+
+func resumeProcess(process *os.Process) error {
+	return process.Signal(syscall.SIGCONT)
+}
@@ -0,0 +1,16 @@
+//go:build windows
+
+package ratelimit
+
+import (
+	"errors"
+	"os"
+)
+
+func pauseProcess(process *os.Process) error {
+	return errors.New("pausing processes is not supported on Windows")
+}
+
+func resumeProcess(process *os.Process) error {
+	return nil
+}
@@ -0,0 +1,177 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 2024-01-05 is a Friday
+func at(day int, clock string) time.Time {
+	t, err := time.ParseInLocation("2006-01-02 15:04", fmt.Sprintf("2024-01-%02d %s", day, clock), time.Local)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestParseSchedule(t *testing.T) {
+	schedule, err := ParseSchedule("Mon-Fri 22:00-06:00, Sat-Sun 00:00-24:00")
+	require.NoError(t, err)
+	assert.Len(t, schedule.Windows, 2)
+	assert.Equal(t, "Mon-Fri 22:00-06:00, Sat-Sun 00:00-24:00", schedule.String())
+
+	schedule, err = ParseSchedule("  ")
+	require.NoError(t, err)
+	assert.True(t, schedule.IsEmpty())
+
+	for _, spec := range []string{"Mon-Fri", "22:00", "Funday 10:00-11:00", "25:00-26:00", "10:00-10:00", "Mon 10:00-11:00 extra", "Mon 1:00-2:00"} {
+		_, err := ParseSchedule(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestSchedule_Open(t *testing.T) {
+	schedule, err := ParseSchedule("Mon-Fri 22:00-06:00, Sat-Sun 00:00-24:00")
+	require.NoError(t, err)
+
+	assert.False(t, schedule.Open(at(5, "12:00")), "Friday noon")
+	assert.True(t, schedule.Open(at(5, "23:00")), "Friday night")
+	assert.True(t, schedule.Open(at(6, "12:00")), "Saturday")
+	assert.False(t, schedule.Open(at(8, "03:00")), "Monday morning, Sunday's window ends at midnight")
+	assert.True(t, schedule.Open(at(9, "05:59")), "Tuesday morning, after Monday night")
+	assert.False(t, schedule.Open(at(9, "06:00")), "window end is exclusive")
+
+	next, ok := schedule.NextOpen(at(5, "12:00"))
+	require.True(t, ok)
+	assert.Equal(t, at(5, "22:00"), next)
+
+	assert.True(t, Schedule{}.Open(at(5, "12:00")), "an empty schedule is always open")
+}
+
+func TestBucket_Take(t *testing.T) {
+	b := NewBucket(10, 0)
+	now := time.Now()
+
+	// The burst defaults to the rate and is available at once
+	assert.Zero(t, b.take(10, now))
+	assert.Equal(t, 100*time.Millisecond, b.take(1, now))
+	assert.Zero(t, b.take(1, now.Add(100*time.Millisecond)))
+
+	assert.Zero(t, NewBucket(0, 0).take(1000, now), "a rate of 0 is unlimited")
+}
+
+func TestBucket_Share(t *testing.T) {
+	b := NewBucket(100, 0)
+	assert.Equal(t, 50, b.share())
+	b.reserve(50)
+	assert.Equal(t, 25, b.share())
+	assert.Equal(t, 50, b.available())
+	b.release(50)
+	assert.Equal(t, 100, b.available())
+}
+
+func TestLimiter_Reserve(t *testing.T) {
+	global := NewBucket(1000, 0)
+	network := NewBucket(100, 0)
+	l := NewLimiter(Schedule{}, global, network, nil)
+
+	// The tightest bucket decides the rate
+	rate := l.reserve()
+	assert.Equal(t, 50, rate)
+	assert.Equal(t, 950, global.available())
+	l.release(rate)
+	assert.Equal(t, 1000, global.available())
+
+	var unlimited *Limiter
+	assert.Zero(t, unlimited.reserve())
+	assert.True(t, unlimited.Open())
+	assert.NoError(t, unlimited.Wait(context.Background(), 100))
+}
+
+func TestWithMaxRate(t *testing.T) {
+	args := []string{"nmap", "-sn", "-oX", "-", "10.0.0.0/24"}
+	assert.Equal(t, args, WithMaxRate(args, 0))
+	assert.Equal(t, []string{"nmap", "-sn", "-oX", "-", "--max-rate", "50", "10.0.0.0/24"}, WithMaxRate(args, 50))
+	assert.Equal(t, []string{"nmap", "-sn", "-oX", "-", "10.0.0.0/24"}, args, "the arguments are not modified")
+
+	limited := []string{"nmap", "--max-rate", "100", "10.0.0.1"}
+	assert.Equal(t, []string{"nmap", "--max-rate", "50", "10.0.0.1"}, WithMaxRate(limited, 50))
+	assert.Equal(t, limited, WithMaxRate(limited, 200), "a lower rate is kept")
+}
+
+// switchedLimiter returns a limiter whose schedule is open while open is set
+func switchedLimiter(open *atomic.Bool) *Limiter {
+	schedule, err := ParseSchedule("Fri 10:00-11:00")
+	if err != nil {
+		panic(err)
+	}
+	l := NewLimiter(schedule)
+	l.now = func() time.Time {
+		if open.Load() {
+			return at(5, "10:30")
+		}
+		return at(5, "12:00")
+	}
+	return l
+}
+
+func TestLimiter_ExecPausesOutsideWindows(t *testing.T) {
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("sleep not available")
+	}
+	defer func(interval time.Duration) { pollInterval = interval }(pollInterval)
+	pollInterval = 10 * time.Millisecond
+
+	var open atomic.Bool
+	open.Store(true)
+	l := switchedLimiter(&open)
+
+	// The window closes while the command runs; its pause doesn't count
+	// towards the timeout
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		open.Store(false)
+		time.Sleep(700 * time.Millisecond)
+		open.Store(true)
+	}()
+
+	started := time.Now()
+	_, err := l.Exec(context.Background(), 600*time.Millisecond, func(maxRate int) []string {
+		assert.Zero(t, maxRate)
+		return []string{"sleep", "0.3"}
+	})
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(started), 700*time.Millisecond)
+}
+
+func TestLimiter_ExecWaitsForWindow(t *testing.T) {
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("sleep not available")
+	}
+	defer func(interval time.Duration) { pollInterval = interval }(pollInterval)
+	pollInterval = 10 * time.Millisecond
+
+	var open atomic.Bool
+	l := switchedLimiter(&open)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := l.Exec(ctx, time.Second, func(int) []string {
+		t.Error("the command must not start outside the scan windows")
+		return []string{"sleep", "0"}
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	open.Store(true)
+	_, err = l.Exec(context.Background(), 50*time.Millisecond, func(int) []string {
+		return []string{"sleep", "5"}
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "the command is killed after its timeout")
+}
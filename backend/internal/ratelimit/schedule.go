@@ -0,0 +1,155 @@
+package ratelimit
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// Window is a daily period in which scanning is allowed. A window whose end
+// is before its start runs past midnight into the next day.
+type Window struct {
+	Days  [7]bool // indexed by time.Weekday; the day the window starts on
+	Start time.Duration
+	End   time.Duration
+}
+
+// Schedule holds the windows in which scanning is allowed. An empty schedule
+// allows scanning at all times.
+type Schedule struct {
+	Windows []Window
+	spec    string
+}
+
+// ParseSchedule parses comma-separated windows such as
+// "Mon-Fri 22:00-06:00, Sat-Sun 00:00-24:00". Days are a day or a range of
+// days; windows without days apply every day.
+func ParseSchedule(spec string) (Schedule, error) {
+	schedule := Schedule{spec: strings.TrimSpace(spec)}
+	for _, item := range strings.Split(spec, ",") {
+		fields := strings.Fields(item)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) > 2 {
+			return Schedule{}, fmt.Errorf("invalid scan window %q", strings.TrimSpace(item))
+		}
+
+		var window Window
+		if len(fields) == 1 {
+			for day := range window.Days {
+				window.Days[day] = true
+			}
+		} else {
+			days, err := parseDays(fields[0])
+			if err != nil {
+				return Schedule{}, err
+			}
+			window.Days = days
+		}
+
+		hours := fields[len(fields)-1]
+		from, to, ok := strings.Cut(hours, "-")
+		if !ok {
+			return Schedule{}, fmt.Errorf("invalid scan window hours %q, expected HH:MM-HH:MM", hours)
+		}
+		var err error
+		if window.Start, err = parseClock(from); err != nil {
+			return Schedule{}, err
+		}
+		if window.End, err = parseClock(to); err != nil {
+			return Schedule{}, err
+		}
+		if window.Start == window.End || window.Start == 24*time.Hour {
+			return Schedule{}, fmt.Errorf("invalid scan window hours %q", hours)
+		}
+		schedule.Windows = append(schedule.Windows, window)
+	}
+	return schedule, nil
+}
+
+func parseDays(spec string) ([7]bool, error) {
+	var days [7]bool
+	from, to, isRange := strings.Cut(strings.ToLower(spec), "-")
+	first, ok := weekdays[from]
+	if !ok {
+		return days, fmt.Errorf("invalid day %q in scan window", from)
+	}
+	last := first
+	if isRange {
+		if last, ok = weekdays[to]; !ok {
+			return days, fmt.Errorf("invalid day %q in scan window", to)
+		}
+	}
+	for day := first; ; day = (day + 1) % 7 {
+		days[day] = true
+		if day == last {
+			break
+		}
+	}
+	return days, nil
+}
+
+func parseClock(value string) (time.Duration, error) {
+	var hours, minutes int
+	if _, err := fmt.Sscanf(value, "%d:%d", &hours, &minutes); err != nil || len(value) != 5 {
+		return 0, fmt.Errorf("invalid time %q in scan window, expected HH:MM", value)
+	}
+	if hours < 0 || minutes < 0 || minutes > 59 || hours > 24 || (hours == 24 && minutes != 0) {
+		return 0, fmt.Errorf("invalid time %q in scan window", value)
+	}
+	return time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute, nil
+}
+
+// IsEmpty reports whether the schedule allows scanning at all times
+func (s Schedule) IsEmpty() bool {
+	return len(s.Windows) == 0
+}
+
+// String returns the schedule as it was written
+func (s Schedule) String() string {
+	return s.spec
+}
+
+// Open reports whether scanning is allowed at t
+func (s Schedule) Open(t time.Time) bool {
+	if s.IsEmpty() {
+		return true
+	}
+
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	offset := t.Sub(midnight)
+	yesterday := (t.Weekday() + 6) % 7
+	for _, w := range s.Windows {
+		if w.Start < w.End {
+			if w.Days[t.Weekday()] && offset >= w.Start && offset < w.End {
+				return true
+			}
+			continue
+		}
+		// Past midnight: the evening of its start day and the morning after
+		if (w.Days[t.Weekday()] && offset >= w.Start) || (w.Days[yesterday] && offset < w.End) {
+			return true
+		}
+	}
+	return false
+}
+
+// NextOpen returns when scanning is next allowed at or after t, within a week
+func (s Schedule) NextOpen(t time.Time) (time.Time, bool) {
+	if s.Open(t) {
+		return t, true
+	}
+	next := t.Truncate(time.Minute).Add(time.Minute)
+	for end := t.Add(8 * 24 * time.Hour); next.Before(end); next = next.Add(time.Minute) {
+		if s.Open(next) {
+			return next, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,95 @@
+package report
+
+import (
+	"crypto/tls"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"reconya-ai/models"
+)
+
+const certificateConcurrency = 8
+
+// collectCertificates connects to every HTTPS web service and reads the leaf
+// certificate. Services that can't be reached are reported with an error.
+func collectCertificates(devices []*models.Device, timeout time.Duration) []Certificate {
+	type target struct {
+		device  *models.Device
+		service models.WebService
+	}
+
+	var targets []target
+	for _, d := range devices {
+		for _, ws := range d.WebServices {
+			if ws.Protocol == "https" {
+				targets = append(targets, target{device: d, service: ws})
+			}
+		}
+	}
+
+	certificates := make([]Certificate, len(targets))
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, certificateConcurrency)
+	for i, t := range targets {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(i int, t target) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			certificates[i] = fetchCertificate(t.device, t.service, timeout)
+		}(i, t)
+	}
+	wg.Wait()
+
+	// Soonest expiry first, unreachable services last
+	sort.SliceStable(certificates, func(i, j int) bool {
+		if (certificates[i].Error == "") != (certificates[j].Error == "") {
+			return certificates[i].Error == ""
+		}
+		return certificates[i].NotAfter.Before(certificates[j].NotAfter)
+	})
+
+	return certificates
+}
+
+func fetchCertificate(d *models.Device, ws models.WebService, timeout time.Duration) Certificate {
+	certificate := Certificate{DeviceID: d.ID, IPv4: d.IPv4, URL: ws.URL}
+
+	port := ws.Port
+	if port == 0 {
+		port = 443
+	}
+
+	// Send the host name from the URL as SNI so virtual hosts present the right certificate
+	serverName := ""
+	if parsed, err := url.Parse(ws.URL); err == nil && net.ParseIP(parsed.Hostname()) == nil {
+		serverName = parsed.Hostname()
+	}
+
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := tls.DialWithDialer(dialer, "tcp", net.JoinHostPort(d.IPv4, strconv.Itoa(port)), &tls.Config{
+		ServerName:         serverName,
+		InsecureSkipVerify: true, // Expiry is reported for self-signed certificates too
+	})
+	if err != nil {
+		certificate.Error = err.Error()
+		return certificate
+	}
+	defer conn.Close()
+
+	peers := conn.ConnectionState().PeerCertificates
+	if len(peers) == 0 {
+		certificate.Error = "no certificate presented"
+		return certificate
+	}
+
+	leaf := peers[0]
+	certificate.Subject = leaf.Subject.CommonName
+	certificate.Issuer = leaf.Issuer.CommonName
+	certificate.NotAfter = leaf.NotAfter
+	return certificate
+}
@@ -0,0 +1,165 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Destination receives rendered reports
+type Destination interface {
+	Name() string
+	Deliver(ctx context.Context, report *Report, files []*Rendered) error
+}
+
+// DiskDestination writes reports to a directory
+type DiskDestination struct {
+	Dir string
+}
+
+func (d *DiskDestination) Name() string {
+	return "disk"
+}
+
+func (d *DiskDestination) Deliver(ctx context.Context, report *Report, files []*Rendered) error {
+	if err := os.MkdirAll(d.Dir, 0755); err != nil {
+		return fmt.Errorf("failed to create report directory: %v", err)
+	}
+
+	for _, file := range files {
+		path := filepath.Join(d.Dir, file.Filename)
+		if err := os.WriteFile(path, file.Data, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %v", path, err)
+		}
+	}
+	return nil
+}
+
+// StoredReport is a report file in the output directory
+type StoredReport struct {
+	Name      string    `json:"name"`
+	Format    Format    `json:"format"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// List returns the reports in the directory, newest first
+func (d *DiskDestination) List() ([]StoredReport, error) {
+	entries, err := os.ReadDir(d.Dir)
+	if os.IsNotExist(err) {
+		return []StoredReport{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	reports := []StoredReport{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasPrefix(entry.Name(), "reconya-report-") {
+			continue
+		}
+		format, err := ParseFormat(strings.TrimPrefix(filepath.Ext(entry.Name()), "."))
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		reports = append(reports, StoredReport{
+			Name:      entry.Name(),
+			Format:    format,
+			Size:      info.Size(),
+			CreatedAt: info.ModTime(),
+		})
+	}
+
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].CreatedAt.After(reports[j].CreatedAt)
+	})
+	return reports, nil
+}
+
+// Path returns the path of a stored report, rejecting names outside the directory
+func (d *DiskDestination) Path(name string) (string, error) {
+	if name != filepath.Base(name) || !strings.HasPrefix(name, "reconya-report-") {
+		return "", fmt.Errorf("invalid report name")
+	}
+	return filepath.Join(d.Dir, name), nil
+}
+
+// WebhookDestination posts a summary and the rendered files as JSON
+type WebhookDestination struct {
+	URL    string
+	Client *http.Client
+}
+
+type webhookAttachment struct {
+	Filename      string `json:"filename"`
+	ContentType   string `json:"content_type"`
+	ContentBase64 string `json:"content_base64"`
+}
+
+type webhookPayload struct {
+	Text        string              `json:"text"`
+	Title       string              `json:"title"`
+	From        time.Time           `json:"from"`
+	To          time.Time           `json:"to"`
+	Summary     map[string]int      `json:"summary"`
+	Attachments []webhookAttachment `json:"attachments"`
+}
+
+func (d *WebhookDestination) Name() string {
+	return "webhook"
+}
+
+func (d *WebhookDestination) Deliver(ctx context.Context, report *Report, files []*Rendered) error {
+	payload := webhookPayload{
+		Text:    report.SummaryText(),
+		Title:   report.Title,
+		From:    report.From,
+		To:      report.To,
+		Summary: report.Summary(),
+	}
+	for _, file := range files {
+		payload.Attachments = append(payload.Attachments, webhookAttachment{
+			Filename:      file.Filename,
+			ContentType:   file.Format.ContentType(),
+			ContentBase64: base64.StdEncoding.EncodeToString(file.Data),
+		})
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := d.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post report: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+)
+
+// renderCSV writes every section as a titled table separated by a blank line
+func renderCSV(r *Report) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	if err := writer.Write([]string{r.Title, "Generated " + r.GeneratedAt.Format("2006-01-02 15:04")}); err != nil {
+		return nil, err
+	}
+
+	for _, s := range r.sections() {
+		if err := writer.Write(nil); err != nil {
+			return nil, err
+		}
+		if err := writer.Write([]string{s.Title}); err != nil {
+			return nil, err
+		}
+		if err := writer.Write(s.Header); err != nil {
+			return nil, err
+		}
+		if err := writer.WriteAll(s.Rows); err != nil {
+			return nil, err
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,54 @@
+package report
+
+import (
+	"bytes"
+	"html/template"
+)
+
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; font-size: 13px; color: #222; margin: 24px; }
+h1 { font-size: 20px; margin-bottom: 4px; }
+h2 { font-size: 15px; margin-top: 28px; border-bottom: 1px solid #ccc; padding-bottom: 4px; }
+.meta { color: #666; }
+table { border-collapse: collapse; width: 100%; }
+th, td { text-align: left; padding: 4px 8px; border-bottom: 1px solid #eee; vertical-align: top; }
+th { background: #f4f4f4; }
+.empty { color: #888; font-style: italic; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<div class="meta">Generated {{.GeneratedAt.Format "2006-01-02 15:04"}}</div>
+{{range .Sections}}
+<h2>{{.Title}}</h2>
+{{if .Rows}}
+<table>
+<thead><tr>{{range .Header}}<th>{{.}}</th>{{end}}</tr></thead>
+<tbody>
+{{range .Rows}}<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{end}}</tbody>
+</table>
+{{else}}
+<p class="empty">None</p>
+{{end}}
+{{end}}
+</body>
+</html>
+`))
+
+func renderHTML(r *Report) ([]byte, error) {
+	var buf bytes.Buffer
+	err := htmlTemplate.Execute(&buf, struct {
+		*Report
+		Sections []section
+	}{r, r.sections()})
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,129 @@
+package report
+
+import (
+	"bytes"
+
+	"github.com/go-pdf/fpdf"
+)
+
+const (
+	pdfMargin     = 10.0
+	pdfRowHeight  = 6.0
+	pdfFontSize   = 8.0
+	pdfCellPad    = 1.5
+	pdfMaxColumnW = 90.0
+)
+
+// renderPDF draws every section as a table on landscape A4 pages
+func renderPDF(r *Report) ([]byte, error) {
+	pdf := fpdf.New("L", "mm", "A4", "")
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	pdf.SetAutoPageBreak(true, pdfMargin)
+	pdf.SetTitle(r.Title, true)
+	pdf.SetCreator("reconYa", true)
+	// Core fonts are not UTF-8; translate to cp1252
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	pdf.AddPage()
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(0, 10, tr(r.Title), "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 9)
+	pdf.SetTextColor(100, 100, 100)
+	pdf.CellFormat(0, 5, "Generated "+r.GeneratedAt.Format("2006-01-02 15:04"), "", 1, "L", false, 0, "")
+	pdf.SetTextColor(0, 0, 0)
+
+	pageWidth, _ := pdf.GetPageSize()
+	tableWidth := pageWidth - 2*pdfMargin
+
+	for _, s := range r.sections() {
+		pdf.Ln(4)
+		pdf.SetFont("Helvetica", "B", 11)
+		pdf.CellFormat(0, 8, tr(s.Title), "", 1, "L", false, 0, "")
+
+		if len(s.Rows) == 0 {
+			pdf.SetFont("Helvetica", "I", pdfFontSize)
+			pdf.CellFormat(0, pdfRowHeight, "None", "", 1, "L", false, 0, "")
+			continue
+		}
+
+		pdf.SetFont("Helvetica", "", pdfFontSize)
+		widths := columnWidths(pdf, tr, s, tableWidth)
+
+		drawHeader := func() {
+			pdf.SetFont("Helvetica", "B", pdfFontSize)
+			pdf.SetFillColor(235, 235, 235)
+			for i, title := range s.Header {
+				pdf.CellFormat(widths[i], pdfRowHeight, fitText(pdf, tr(title), widths[i]), "1", 0, "L", true, 0, "")
+			}
+			pdf.Ln(-1)
+			pdf.SetFont("Helvetica", "", pdfFontSize)
+		}
+		drawHeader()
+
+		_, pageHeight := pdf.GetPageSize()
+		for _, row := range s.Rows {
+			// Repeat the header when the table continues on a new page
+			if pdf.GetY()+pdfRowHeight > pageHeight-pdfMargin {
+				pdf.AddPage()
+				drawHeader()
+			}
+			for i, value := range row {
+				pdf.CellFormat(widths[i], pdfRowHeight, fitText(pdf, tr(value), widths[i]), "1", 0, "L", false, 0, "")
+			}
+			pdf.Ln(-1)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// columnWidths sizes columns by their widest value and scales them to fill the table
+func columnWidths(pdf *fpdf.Fpdf, tr func(string) string, s section, tableWidth float64) []float64 {
+	widths := make([]float64, len(s.Header))
+	measure := func(i int, text string) {
+		w := pdf.GetStringWidth(tr(text)) + 2*pdfCellPad
+		if w > pdfMaxColumnW {
+			w = pdfMaxColumnW
+		}
+		if w > widths[i] {
+			widths[i] = w
+		}
+	}
+
+	pdf.SetFont("Helvetica", "B", pdfFontSize)
+	for i, title := range s.Header {
+		measure(i, title)
+	}
+	pdf.SetFont("Helvetica", "", pdfFontSize)
+	for _, row := range s.Rows {
+		for i, value := range row {
+			measure(i, value)
+		}
+	}
+
+	total := 0.0
+	for _, w := range widths {
+		total += w
+	}
+	for i := range widths {
+		widths[i] = widths[i] / total * tableWidth
+	}
+	return widths
+}
+
+// fitText truncates text that doesn't fit in a cell
+func fitText(pdf *fpdf.Fpdf, text string, width float64) string {
+	available := width - 2*pdfCellPad
+	if pdf.GetStringWidth(text) <= available {
+		return text
+	}
+	// Text is already translated to a single byte encoding, so trim bytes
+	for len(text) > 0 && pdf.GetStringWidth(text+"...") > available {
+		text = text[:len(text)-1]
+	}
+	return text + "..."
+}
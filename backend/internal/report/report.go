@@ -0,0 +1,206 @@
+package report
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"reconya-ai/models"
+)
+
+// Format is an output format of a report
+type Format string
+
+const (
+	FormatHTML Format = "html"
+	FormatCSV  Format = "csv"
+	FormatPDF  Format = "pdf"
+)
+
+// ParseFormat validates a format name
+func ParseFormat(name string) (Format, error) {
+	switch Format(strings.ToLower(strings.TrimSpace(name))) {
+	case FormatHTML:
+		return FormatHTML, nil
+	case FormatCSV:
+		return FormatCSV, nil
+	case FormatPDF:
+		return FormatPDF, nil
+	}
+	return "", fmt.Errorf("unknown report format %q", name)
+}
+
+// ContentType returns the MIME type of the format
+func (f Format) ContentType() string {
+	switch f {
+	case FormatHTML:
+		return "text/html; charset=utf-8"
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatPDF:
+		return "application/pdf"
+	}
+	return "application/octet-stream"
+}
+
+// Report is a summary of the network over a period
+type Report struct {
+	Title       string
+	GeneratedAt time.Time
+	From        time.Time
+	To          time.Time
+
+	Inventory []*models.Device
+	// Devices first discovered during the period
+	NewDevices []*models.Device
+	// Known devices that were not seen during the period
+	MissingDevices []*models.Device
+	Exposure       []PortExposure
+	Certificates   []Certificate
+	Availability   []*models.DeviceAvailability
+
+	CertificateWarningDays int
+}
+
+// PortExposure lists the devices that have a port open
+type PortExposure struct {
+	Port     string
+	Protocol string
+	Service  string
+	Devices  []string
+}
+
+// Certificate is the TLS certificate presented by a web service
+type Certificate struct {
+	DeviceID string
+	IPv4     string
+	URL      string
+	Subject  string
+	Issuer   string
+	NotAfter time.Time
+	Error    string
+}
+
+// DaysLeft returns the whole days until the certificate expires, negative once expired
+func (c Certificate) DaysLeft(now time.Time) int {
+	return int(c.NotAfter.Sub(now).Hours() / 24)
+}
+
+// Rendered is a report rendered in one format
+type Rendered struct {
+	Filename string
+	Format   Format
+	Data     []byte
+}
+
+// Filename returns the file name used for a report in the given format
+func (r *Report) Filename(format Format) string {
+	return fmt.Sprintf("reconya-report-%s.%s", r.GeneratedAt.Format("20060102-150405"), format)
+}
+
+// Render renders the report in the given format
+func (r *Report) Render(format Format) (*Rendered, error) {
+	var data []byte
+	var err error
+
+	switch format {
+	case FormatHTML:
+		data, err = renderHTML(r)
+	case FormatCSV:
+		data, err = renderCSV(r)
+	case FormatPDF:
+		data, err = renderPDF(r)
+	default:
+		err = fmt.Errorf("unknown report format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &Rendered{Filename: r.Filename(format), Format: format, Data: data}, nil
+}
+
+func deviceLabel(d *models.Device) string {
+	if d.Name != "" {
+		return d.Name
+	}
+	if d.Hostname != nil && *d.Hostname != "" {
+		return *d.Hostname
+	}
+	return "-"
+}
+
+func stringValue(s *string) string {
+	if s == nil || *s == "" {
+		return "-"
+	}
+	return *s
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return "Never"
+	}
+	return t.Format("2006-01-02 15:04")
+}
+
+func formatPercent(window models.AvailabilityWindow) string {
+	if window.Percent == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f%%", *window.Percent)
+}
+
+func openPorts(d *models.Device) []models.Port {
+	var ports []models.Port
+	for _, port := range d.Ports {
+		if port.State == "" || port.State == "open" {
+			ports = append(ports, port)
+		}
+	}
+	return ports
+}
+
+// Summary returns the headline counts of the report
+func (r *Report) Summary() map[string]int {
+	expiring := 0
+	for _, c := range r.Certificates {
+		if c.Error == "" && c.DaysLeft(r.GeneratedAt) <= r.CertificateWarningDays {
+			expiring++
+		}
+	}
+
+	return map[string]int{
+		"devices":               len(r.Inventory),
+		"new_devices":           len(r.NewDevices),
+		"missing_devices":       len(r.MissingDevices),
+		"exposed_ports":         len(r.Exposure),
+		"expiring_certificates": expiring,
+	}
+}
+
+// SummaryText returns a one line description of the report for notifications
+func (r *Report) SummaryText() string {
+	summary := r.Summary()
+	return fmt.Sprintf("%s (%s - %s): %d devices, %d new, %d missing, %d exposed ports, %d certificates expiring within %d days",
+		r.Title, r.From.Format("2006-01-02 15:04"), r.To.Format("2006-01-02 15:04"),
+		summary["devices"], summary["new_devices"], summary["missing_devices"],
+		summary["exposed_ports"], summary["expiring_certificates"], r.CertificateWarningDays)
+}
+
+// ParsePeriod parses a report period given as a Go duration or a number of days such as "7d"
+func ParsePeriod(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if strings.HasSuffix(value, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(value, "d"))
+		if err == nil && days > 0 {
+			return time.Duration(days) * 24 * time.Hour, nil
+		}
+	}
+	period, err := time.ParseDuration(value)
+	if err != nil || period <= 0 {
+		return 0, fmt.Errorf("invalid report period %q", value)
+	}
+	return period, nil
+}
@@ -0,0 +1,183 @@
+package report
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"reconya-ai/internal/availability"
+	"reconya-ai/internal/config"
+	"reconya-ai/internal/device"
+	"reconya-ai/models"
+)
+
+const certificateTimeout = 5 * time.Second
+
+type ReportService struct {
+	deviceService       *device.DeviceService
+	availabilityService *availability.AvailabilityService
+	config              *config.Config
+	disk                *DiskDestination
+	destinations        []Destination
+}
+
+func NewReportService(deviceService *device.DeviceService, availabilityService *availability.AvailabilityService, cfg *config.Config) *ReportService {
+	disk := &DiskDestination{Dir: cfg.ReportOutputDir}
+	destinations := []Destination{disk}
+	if cfg.ReportWebhookURL != "" {
+		destinations = append(destinations, &WebhookDestination{URL: cfg.ReportWebhookURL})
+	}
+
+	return &ReportService{
+		deviceService:       deviceService,
+		availabilityService: availabilityService,
+		config:              cfg,
+		disk:                disk,
+		destinations:        destinations,
+	}
+}
+
+// Generate builds a report covering the given period up to now
+func (s *ReportService) Generate(period time.Duration) (*Report, error) {
+	now := time.Now()
+	from := now.Add(-period)
+
+	devices, err := s.deviceService.FindAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load devices: %v", err)
+	}
+
+	availabilityReport, err := s.availabilityService.GetPeriodReport(from, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load availability: %v", err)
+	}
+
+	report := &Report{
+		Title:                  "reconYa Network Report",
+		GeneratedAt:            now,
+		From:                   from,
+		To:                     now,
+		Inventory:              devices,
+		Exposure:               aggregateExposure(devices),
+		Certificates:           collectCertificates(devices, certificateTimeout),
+		Availability:           availabilityReport,
+		CertificateWarningDays: s.config.ReportCertWarningDays,
+	}
+
+	for _, d := range devices {
+		if !d.CreatedAt.Before(from) {
+			report.NewDevices = append(report.NewDevices, d)
+		} else if d.LastSeenOnlineAt == nil || d.LastSeenOnlineAt.Before(from) {
+			report.MissingDevices = append(report.MissingDevices, d)
+		}
+	}
+
+	return report, nil
+}
+
+// RenderAll renders the report in every configured format
+func (s *ReportService) RenderAll(report *Report) ([]*Rendered, error) {
+	var files []*Rendered
+	for _, name := range s.config.ReportFormats {
+		format, err := ParseFormat(name)
+		if err != nil {
+			return nil, err
+		}
+		file, err := report.Render(format)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render %s report: %v", format, err)
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// Deliver renders the report and sends it to every destination. A failing
+// destination doesn't stop delivery to the others.
+func (s *ReportService) Deliver(report *Report) error {
+	files, err := s.RenderAll(report)
+	if err != nil {
+		return err
+	}
+
+	var failed []string
+	for _, destination := range s.destinations {
+		if err := destination.Deliver(context.Background(), report, files); err != nil {
+			log.Printf("Failed to deliver report to %s: %v", destination.Name(), err)
+			failed = append(failed, destination.Name())
+			continue
+		}
+		log.Printf("Delivered report to %s", destination.Name())
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to deliver report to %v", failed)
+	}
+	return nil
+}
+
+// RunScheduled generates and delivers a report over the configured period
+func (s *ReportService) RunScheduled() error {
+	report, err := s.Generate(s.config.ReportPeriod)
+	if err != nil {
+		return err
+	}
+	return s.Deliver(report)
+}
+
+// ListStored returns the reports written to the output directory
+func (s *ReportService) ListStored() ([]StoredReport, error) {
+	return s.disk.List()
+}
+
+// StoredPath returns the path of a stored report
+func (s *ReportService) StoredPath(name string) (string, error) {
+	path, err := s.disk.Path(name)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// aggregateExposure groups open ports across devices, most exposed first
+func aggregateExposure(devices []*models.Device) []PortExposure {
+	byPort := make(map[string]*PortExposure)
+	for _, d := range devices {
+		for _, port := range openPorts(d) {
+			key := port.Number + "/" + port.Protocol
+			exposure, ok := byPort[key]
+			if !ok {
+				exposure = &PortExposure{Port: port.Number, Protocol: port.Protocol, Service: port.Service}
+				byPort[key] = exposure
+			}
+			if exposure.Service == "" {
+				exposure.Service = port.Service
+			}
+			exposure.Devices = append(exposure.Devices, d.IPv4)
+		}
+	}
+
+	exposure := make([]PortExposure, 0, len(byPort))
+	for _, e := range byPort {
+		exposure = append(exposure, *e)
+	}
+	sort.Slice(exposure, func(i, j int) bool {
+		if len(exposure[i].Devices) != len(exposure[j].Devices) {
+			return len(exposure[i].Devices) > len(exposure[j].Devices)
+		}
+		pi, _ := strconv.Atoi(exposure[i].Port)
+		pj, _ := strconv.Atoi(exposure[j].Port)
+		if pi != pj {
+			return pi < pj
+		}
+		return exposure[i].Protocol < exposure[j].Protocol
+	})
+	return exposure
+}
@@ -0,0 +1,119 @@
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+	"time"
+
+	"reconya-ai/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testReport() *Report {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	mac := "aa:bb:cc:dd:ee:ff"
+	devices := []*models.Device{
+		{
+			ID: "dev-1", Name: "gateway", IPv4: "192.168.1.1", MAC: &mac, Status: models.DeviceStatusOnline,
+			Ports: []models.Port{{Number: "443", Protocol: "tcp", State: "open", Service: "https"}, {Number: "22", Protocol: "tcp", State: "open", Service: "ssh"}},
+		},
+		{
+			ID: "dev-2", IPv4: "192.168.1.20", Status: models.DeviceStatusOnline,
+			Ports: []models.Port{{Number: "443", Protocol: "tcp", State: "open"}, {Number: "8080", Protocol: "tcp", State: "closed"}},
+		},
+	}
+	percent := 99.5
+
+	return &Report{
+		Title:          "Test Report",
+		GeneratedAt:    now,
+		From:           now.Add(-24 * time.Hour),
+		To:             now,
+		Inventory:      devices,
+		NewDevices:     devices[1:],
+		MissingDevices: nil,
+		Exposure:       aggregateExposure(devices),
+		Certificates: []Certificate{
+			{URL: "https://192.168.1.1", Subject: "gateway", NotAfter: now.Add(10 * 24 * time.Hour)},
+			{URL: "https://192.168.1.20", Error: "connection refused"},
+		},
+		Availability: []*models.DeviceAvailability{
+			{DeviceID: "dev-1", DeviceName: "gateway", IPv4: "192.168.1.1", Windows: []models.AvailabilityWindow{{Window: "period", Percent: &percent, DowntimeSeconds: 432, Outages: 1}}},
+		},
+		CertificateWarningDays: 30,
+	}
+}
+
+func TestAggregateExposure(t *testing.T) {
+	exposure := testReport().Exposure
+
+	require.Len(t, exposure, 2)
+	assert.Equal(t, "443", exposure[0].Port)
+	assert.Equal(t, "https", exposure[0].Service)
+	assert.Equal(t, []string{"192.168.1.1", "192.168.1.20"}, exposure[0].Devices)
+	assert.Equal(t, "22", exposure[1].Port)
+}
+
+func TestSummary(t *testing.T) {
+	summary := testReport().Summary()
+
+	assert.Equal(t, 2, summary["devices"])
+	assert.Equal(t, 1, summary["new_devices"])
+	assert.Equal(t, 0, summary["missing_devices"])
+	assert.Equal(t, 2, summary["exposed_ports"])
+	assert.Equal(t, 1, summary["expiring_certificates"])
+}
+
+func TestRenderCSV(t *testing.T) {
+	rendered, err := testReport().Render(FormatCSV)
+	require.NoError(t, err)
+	assert.Equal(t, "reconya-report-20250601-120000.csv", rendered.Filename)
+
+	reader := csv.NewReader(bytes.NewReader(rendered.Data))
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	require.NoError(t, err)
+
+	titles := map[string]bool{}
+	for _, record := range records {
+		if len(record) == 1 {
+			titles[record[0]] = true
+		}
+	}
+	for _, title := range []string{"Summary", "Inventory", "New Devices", "Missing Devices", "Open Port Exposure", "Certificate Expiry", "Availability"} {
+		assert.True(t, titles[title], "missing section %s", title)
+	}
+	assert.Contains(t, string(rendered.Data), "https://192.168.1.1,gateway,,2025-06-11 12:00,10,Expiring")
+	assert.Contains(t, string(rendered.Data), "192.168.1.1,gateway,99.50%,7m12s,1")
+}
+
+func TestRenderHTMLAndPDF(t *testing.T) {
+	r := testReport()
+
+	html, err := r.Render(FormatHTML)
+	require.NoError(t, err)
+	assert.Contains(t, string(html.Data), "<h2>Open Port Exposure</h2>")
+	assert.Contains(t, string(html.Data), "Error: connection refused")
+
+	pdf, err := r.Render(FormatPDF)
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(pdf.Data, []byte("%PDF-")))
+}
+
+func TestParsePeriod(t *testing.T) {
+	period, err := ParsePeriod("7d")
+	require.NoError(t, err)
+	assert.Equal(t, 7*24*time.Hour, period)
+
+	period, err = ParsePeriod("12h")
+	require.NoError(t, err)
+	assert.Equal(t, 12*time.Hour, period)
+
+	_, err = ParsePeriod("-1h")
+	assert.Error(t, err)
+	_, err = ParsePeriod("soon")
+	assert.Error(t, err)
+}
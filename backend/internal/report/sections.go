@@ -0,0 +1,147 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"reconya-ai/models"
+)
+
+// section is a titled table shared by all output formats
+type section struct {
+	Title  string
+	Header []string
+	Rows   [][]string
+}
+
+// sections lays out the report as tables in the order they are rendered
+func (r *Report) sections() []section {
+	summary := r.Summary()
+
+	return []section{
+		{
+			Title:  "Summary",
+			Header: []string{"Metric", "Value"},
+			Rows: [][]string{
+				{"Period", fmt.Sprintf("%s - %s", r.From.Format("2006-01-02 15:04"), r.To.Format("2006-01-02 15:04"))},
+				{"Devices", fmt.Sprint(summary["devices"])},
+				{"New devices", fmt.Sprint(summary["new_devices"])},
+				{"Missing devices", fmt.Sprint(summary["missing_devices"])},
+				{"Exposed ports", fmt.Sprint(summary["exposed_ports"])},
+				{fmt.Sprintf("Certificates expiring within %d days", r.CertificateWarningDays), fmt.Sprint(summary["expiring_certificates"])},
+			},
+		},
+		r.inventorySection(),
+		r.deviceListSection("New Devices", r.NewDevices),
+		r.deviceListSection("Missing Devices", r.MissingDevices),
+		r.exposureSection(),
+		r.certificateSection(),
+		r.availabilitySection(),
+	}
+}
+
+func (r *Report) inventorySection() section {
+	s := section{
+		Title:  "Inventory",
+		Header: []string{"IP Address", "Name", "MAC", "Vendor", "Type", "Status", "Open Ports", "Last Seen"},
+	}
+	for _, d := range r.Inventory {
+		s.Rows = append(s.Rows, []string{
+			d.IPv4,
+			deviceLabel(d),
+			stringValue(d.MAC),
+			stringValue(d.Vendor),
+			string(d.DeviceType),
+			string(d.Status),
+			fmt.Sprint(len(openPorts(d))),
+			formatTime(d.LastSeenOnlineAt),
+		})
+	}
+	return s
+}
+
+func (r *Report) deviceListSection(title string, devices []*models.Device) section {
+	s := section{
+		Title:  title,
+		Header: []string{"IP Address", "Name", "MAC", "Vendor", "First Seen", "Last Seen"},
+	}
+	for _, d := range devices {
+		createdAt := d.CreatedAt
+		s.Rows = append(s.Rows, []string{
+			d.IPv4,
+			deviceLabel(d),
+			stringValue(d.MAC),
+			stringValue(d.Vendor),
+			formatTime(&createdAt),
+			formatTime(d.LastSeenOnlineAt),
+		})
+	}
+	return s
+}
+
+func (r *Report) exposureSection() section {
+	s := section{
+		Title:  "Open Port Exposure",
+		Header: []string{"Port", "Protocol", "Service", "Devices", "Hosts"},
+	}
+	for _, e := range r.Exposure {
+		s.Rows = append(s.Rows, []string{
+			e.Port,
+			e.Protocol,
+			e.Service,
+			fmt.Sprint(len(e.Devices)),
+			strings.Join(e.Devices, ", "),
+		})
+	}
+	return s
+}
+
+func (r *Report) certificateSection() section {
+	s := section{
+		Title:  "Certificate Expiry",
+		Header: []string{"URL", "Subject", "Issuer", "Expires", "Days Left", "Status"},
+	}
+	for _, c := range r.Certificates {
+		if c.Error != "" {
+			s.Rows = append(s.Rows, []string{c.URL, "-", "-", "-", "-", "Error: " + c.Error})
+			continue
+		}
+
+		daysLeft := c.DaysLeft(r.GeneratedAt)
+		status := "OK"
+		if daysLeft < 0 {
+			status = "Expired"
+		} else if daysLeft <= r.CertificateWarningDays {
+			status = "Expiring"
+		}
+		notAfter := c.NotAfter
+		s.Rows = append(s.Rows, []string{c.URL, c.Subject, c.Issuer, formatTime(&notAfter), fmt.Sprint(daysLeft), status})
+	}
+	return s
+}
+
+func (r *Report) availabilitySection() section {
+	s := section{
+		Title:  "Availability",
+		Header: []string{"IP Address", "Name", "Availability", "Downtime", "Outages"},
+	}
+	for _, a := range r.Availability {
+		if len(a.Windows) == 0 {
+			continue
+		}
+		window := a.Windows[0]
+		name := a.DeviceName
+		if name == "" {
+			name = "-"
+		}
+		s.Rows = append(s.Rows, []string{
+			a.IPv4,
+			name,
+			formatPercent(window),
+			(time.Duration(window.DowntimeSeconds) * time.Second).Round(time.Second).String(),
+			fmt.Sprint(window.Outages),
+		})
+	}
+	return s
+}
@@ -0,0 +1,197 @@
+package retention
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"reconya-ai/db"
+	"reconya-ai/internal/config"
+	"reconya-ai/models"
+)
+
+// Tables covered by retention rules
+const (
+	TableEventLogs     = "event_logs"
+	TableTraceroute    = "traceroute_runs"
+	TableLatency       = "latency_samples"
+	TableStatusHistory = "device_status_intervals"
+	TableSystemStatus  = "system_status"
+	TableIPMACBindings = "ip_mac_bindings"
+)
+
+const defaultRollupWindow = 90 * 24 * time.Hour
+
+// Rule keeps the rows of a table, or of one event type, for a period. A zero
+// period keeps them forever.
+type Rule struct {
+	Table     string        `json:"table"`
+	Type      string        `json:"type,omitempty"`
+	Retention time.Duration `json:"-"`
+	KeepFor   string        `json:"keep_for"`
+}
+
+// Report describes one pruning run
+type Report struct {
+	StartedAt time.Time                `json:"started_at"`
+	Duration  string                   `json:"duration"`
+	DryRun    bool                     `json:"dry_run"`
+	Results   []models.RetentionResult `json:"results"`
+	Deleted   int64                    `json:"deleted"`
+}
+
+type RetentionService struct {
+	repository db.RetentionRepository
+	config     *config.Config
+	lastReport *Report
+	mutex      sync.Mutex
+}
+
+func NewRetentionService(repository db.RetentionRepository, cfg *config.Config) *RetentionService {
+	return &RetentionService{
+		repository: repository,
+		config:     cfg,
+	}
+}
+
+// Rules returns the configured retention policy, per-type event rules first
+func (s *RetentionService) Rules() []Rule {
+	types := make([]string, 0, len(s.config.EventRetentionByType))
+	for eventType := range s.config.EventRetentionByType {
+		types = append(types, eventType)
+	}
+	sort.Strings(types)
+
+	var rules []Rule
+	for _, eventType := range types {
+		rules = append(rules, newRule(TableEventLogs, eventType, s.config.EventRetentionByType[eventType]))
+	}
+	return append(rules,
+		newRule(TableEventLogs, "", s.config.EventRetention),
+		newRule(TableTraceroute, "", s.config.TracerouteRetention),
+		newRule(TableLatency, "", s.config.LatencyRetention),
+		newRule(TableStatusHistory, "", s.config.StatusHistoryRetention),
+		newRule(TableSystemStatus, "", s.config.SystemStatusRetention),
+		newRule(TableIPMACBindings, "", s.config.IPMACBindingRetention),
+	)
+}
+
+func newRule(table, eventType string, retention time.Duration) Rule {
+	keepFor := "forever"
+	if retention > 0 {
+		keepFor = formatRetention(retention)
+	}
+	return Rule{Table: table, Type: eventType, Retention: retention, KeepFor: keepFor}
+}
+
+// Run prunes every table according to the policy. On a dry run nothing is
+// deleted and the report counts the rows that would be.
+func (s *RetentionService) Run(dryRun bool) (*Report, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	ctx := context.Background()
+	report := &Report{StartedAt: time.Now(), DryRun: dryRun, Results: []models.RetentionResult{}}
+
+	var typed []models.EEventLogType
+	for eventType := range s.config.EventRetentionByType {
+		typed = append(typed, models.EEventLogType(eventType))
+	}
+
+	for _, rule := range s.Rules() {
+		if rule.Retention <= 0 {
+			continue
+		}
+		before := report.StartedAt.Add(-rule.Retention)
+
+		var deleted int64
+		var err error
+		switch rule.Table {
+		case TableEventLogs:
+			if rule.Type != "" {
+				deleted, err = s.repository.PruneEventLogs(ctx, before, []models.EEventLogType{models.EEventLogType(rule.Type)}, nil, s.config.EventRollups, dryRun)
+			} else {
+				deleted, err = s.repository.PruneEventLogs(ctx, before, nil, typed, s.config.EventRollups, dryRun)
+			}
+		case TableTraceroute:
+			deleted, err = s.repository.PruneTracerouteRuns(ctx, before, dryRun)
+		case TableLatency:
+			deleted, err = s.repository.PruneLatencySamples(ctx, before, dryRun)
+		case TableStatusHistory:
+			deleted, err = s.repository.PruneStatusIntervals(ctx, before, dryRun)
+		case TableSystemStatus:
+			deleted, err = s.repository.PruneSystemStatus(ctx, before, dryRun)
+		case TableIPMACBindings:
+			deleted, err = s.repository.PruneIPMACBindings(ctx, before, dryRun)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to prune %s: %v", rule.Table, err)
+		}
+
+		report.Results = append(report.Results, models.RetentionResult{
+			Table:   rule.Table,
+			Type:    rule.Type,
+			Before:  before,
+			Deleted: deleted,
+		})
+		report.Deleted += deleted
+	}
+
+	report.Duration = time.Since(report.StartedAt).Round(time.Millisecond).String()
+	if !dryRun {
+		s.lastReport = report
+	}
+	return report, nil
+}
+
+// RunScheduled prunes old rows and logs how many were removed per table
+func (s *RetentionService) RunScheduled() error {
+	report, err := s.Run(false)
+	if err != nil {
+		return err
+	}
+	if report.Deleted == 0 {
+		return nil
+	}
+
+	for _, result := range report.Results {
+		if result.Deleted == 0 {
+			continue
+		}
+		if result.Type != "" {
+			log.Printf("Retention: removed %d %q events older than %s", result.Deleted, result.Type, result.Before.Format(time.RFC3339))
+		} else {
+			log.Printf("Retention: removed %d rows from %s older than %s", result.Deleted, result.Table, result.Before.Format(time.RFC3339))
+		}
+	}
+	log.Printf("Retention: removed %d rows in %s", report.Deleted, report.Duration)
+	return nil
+}
+
+// LastReport returns the report of the last pruning run, nil before the first
+func (s *RetentionService) LastReport() *Report {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.lastReport
+}
+
+// GetEventRollups returns the daily event counts within the given window,
+// defaulting to 90 days
+func (s *RetentionService) GetEventRollups(window time.Duration) ([]*models.EventLogRollup, error) {
+	if window <= 0 {
+		window = defaultRollupWindow
+	}
+	return s.repository.FindEventRollups(context.Background(), time.Now().Add(-window))
+}
+
+// formatRetention prints whole days as "90d" and anything else as a Go duration
+func formatRetention(retention time.Duration) string {
+	day := 24 * time.Hour
+	if retention%day == 0 {
+		return fmt.Sprintf("%dd", retention/day)
+	}
+	return retention.String()
+}
@@ -3,12 +3,18 @@ package scan
 import (
 	"fmt"
 	"log"
+	"reconya-ai/internal/conflict"
 	"reconya-ai/internal/ipv6monitor"
 	"reconya-ai/internal/network"
 	"reconya-ai/internal/pingsweep"
+	"reconya-ai/internal/ratelimit"
+	"reconya-ai/internal/scanpolicy"
+	"reconya-ai/internal/scanprogress"
 	"reconya-ai/models"
 	"sync"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 // ScanState represents the current state of the scanning system
@@ -21,6 +27,10 @@ type ScanState struct {
 	LastScanTime    *time.Time      `json:"last_scan_time"`
 	ScanCount       int             `json:"scan_count"`
 	IPv6Monitoring  bool            `json:"ipv6_monitoring"`
+	// RunID identifies the events of one StartScan to StopScan run
+	RunID string `json:"run_id,omitempty"`
+	// Progress is how far each stage of the scan has got, with an ETA
+	Progress scanprogress.Snapshot `json:"progress"`
 }
 
 // ScanManager manages the network scanning state and operations
@@ -32,6 +42,15 @@ type ScanManager struct {
 	ipv6MonitorService *ipv6monitor.IPv6MonitorService
 	stopChannel        chan bool
 	done               chan bool
+	// Conflicts, when set, checks the IP to MAC address bindings of each
+	// sweep for ARP spoofing and duplicate addresses
+	Conflicts *conflict.ConflictService
+	// Policies, when set, excludes networks and devices from sweeps and
+	// limits their packet rate
+	Policies *scanpolicy.ScanPolicyService
+	// Limits, when set, paces sweeps and holds them outside the scan
+	// windows of the network
+	Limits *ratelimit.Manager
 }
 
 // NewScanManager creates a new scan manager
@@ -53,6 +72,8 @@ func (sm *ScanManager) GetState() ScanState {
 	state := sm.state
 	sm.mutex.RUnlock()
 
+	state.Progress = sm.pingSweepService.Progress.Snapshot()
+
 	// If not currently running, get last scan time from database
 	if !state.IsRunning {
 		state.ScanCount = sm.getTotalScanCount()
@@ -166,16 +187,17 @@ func (sm *ScanManager) StartScan(networkID string) error {
 	sm.state.SelectedNetwork = network // Also update selected network
 	sm.state.StartTime = &now
 	sm.state.ScanCount = 0
+	sm.state.RunID = uuid.New().String()
+	sm.pingSweepService.Progress.Reset()
 
 	// Create channels for communication
 	sm.stopChannel = make(chan bool)
 	sm.done = make(chan bool)
 
 	// Log scan started event
-	err = sm.pingSweepService.EventLogService.CreateOne(&models.EventLog{
-		Type:        models.ScanStarted,
-		Description: fmt.Sprintf("Network scan started (%s)", network.CIDR),
-	})
+	err = sm.pingSweepService.EventLogService.Log(models.ScanStarted,
+		fmt.Sprintf("Network scan started (%s)", network.CIDR), "",
+		&models.ScanPayload{RunID: sm.state.RunID, NetworkID: network.ID, CIDR: network.CIDR})
 	if err != nil {
 		log.Printf("Error creating scan started event log: %v", err)
 	}
@@ -266,64 +288,104 @@ func (sm *ScanManager) runScanLoop() {
 func (sm *ScanManager) runSingleScan() {
 	sm.mutex.Lock()
 	network := sm.state.CurrentNetwork
+	runID := sm.state.RunID
 	sm.mutex.Unlock()
 
 	if network == nil {
 		return
 	}
 
-	log.Printf("Running scan on network: %s", network.CIDR)
-
-	// Log ping sweep started event
-	err := sm.pingSweepService.EventLogService.CreateOne(&models.EventLog{
-		Type: models.PingSweep,
-	})
-	if err != nil {
-		log.Printf("Error creating ping sweep started event log: %v", err)
+	var policy scanpolicy.SweepPolicy
+	if sm.Policies != nil {
+		var err error
+		policy, err = sm.Policies.SweepPolicy(network)
+		if err != nil {
+			log.Printf("Error loading scan policy of network %s: %v", network.CIDR, err)
+		}
+		if policy.Skip {
+			log.Printf("Skipping scan of network %s, excluded by scan policy or exclusion list", network.CIDR)
+			return
+		}
 	}
 
-	// Execute the ping sweep with the current network
-	devices, err := sm.pingSweepService.ExecuteSweepScanCommand(network.CIDR)
-	if err != nil {
-		log.Printf("Error during ping sweep: %v", err)
+	// Outside the scan windows the iteration is skipped rather than counted
+	// as a sweep that found nothing
+	limiter := sm.Limits.For(network.ID)
+	if !limiter.Open() {
+		if next, ok := limiter.NextOpen(); ok {
+			log.Printf("Skipping scan of network %s, outside its scan windows until %s", network.CIDR, next.Format("Mon 15:04"))
+		} else {
+			log.Printf("Skipping scan of network %s, outside its scan windows", network.CIDR)
+		}
 		return
 	}
 
-	log.Printf("Ping sweep found %d devices from scan", len(devices))
-
-	// Process the devices (similar to the original Run method)
-	for i, device := range devices {
-		log.Printf("Processing device %d/%d: %s", i+1, len(devices), device.IPv4)
+	log.Printf("Running scan on network: %s", network.CIDR)
 
-		// Set the network ID for the device
-		device.NetworkID = network.ID
+	// Log ping sweep started event
+	err := sm.pingSweepService.EventLogService.Log(models.PingSweep, "", "",
+		&models.ScanPayload{RunID: runID, NetworkID: network.ID, CIDR: network.CIDR})
+	if err != nil {
+		log.Printf("Error creating ping sweep started event log: %v", err)
+	}
 
-		// Update device in database
-		updatedDevice, err := sm.pingSweepService.DeviceService.CreateOrUpdate(&device)
+	// Sweep the IPv4 range and discover hosts on the IPv6 prefix, as enabled
+	// for the network
+	sweepStartedAt := time.Now()
+	var savedDevices []*models.Device
+	swept := false
+	if network.IsIPv4Enabled() {
+		ipv4Devices, err := sm.sweepIPv4(network, policy, limiter)
 		if err != nil {
-			log.Printf("Error updating device %s: %v", device.IPv4, err)
-			continue
+			log.Printf("Error during ping sweep: %v", err)
+		} else {
+			savedDevices = append(savedDevices, ipv4Devices...)
+			swept = true
 		}
-		log.Printf("Successfully saved device: %s", device.IPv4)
-
-		// Create event log
-		deviceIDStr := device.ID
-		err = sm.pingSweepService.EventLogService.CreateOne(&models.EventLog{
-			Type:     models.DeviceOnline,
-			DeviceID: &deviceIDStr,
-		})
+	}
+	if network.IsIPv6Enabled() {
+		ipv6Devices, err := sm.discoverIPv6(network, policy, limiter)
 		if err != nil {
-			log.Printf("Error creating device online event log: %v", err)
+			log.Printf("Error during IPv6 discovery: %v", err)
+		} else {
+			// Dual-stack devices answered both and are reported once
+			seen := make(map[string]bool, len(savedDevices))
+			for _, device := range savedDevices {
+				seen[device.ID] = true
+			}
+			for _, device := range ipv6Devices {
+				if !seen[device.ID] {
+					savedDevices = append(savedDevices, device)
+				}
+			}
+			swept = true
 		}
+	}
+	if !swept {
+		return
+	}
 
+	onlineEvents := make([]*models.EventLog, len(savedDevices))
+	for i, device := range savedDevices {
+		onlineEvents[i] = models.DeviceOnlineEvent(device)
+	}
+	if err := sm.pingSweepService.EventLogService.CreateMany(onlineEvents); err != nil {
+		log.Printf("Error creating device online event logs: %v", err)
+	}
+
+	for _, device := range savedDevices {
 		// Add to port scan queue if eligible
-		if sm.pingSweepService.DeviceService.EligibleForPortScan(updatedDevice) {
-			// Note: We'll need to expose the port scan queue from ping sweep service
-			// For now, let's trigger port scan directly
-			go sm.pingSweepService.PortScanService.Run(*updatedDevice)
+		if sm.pingSweepService.DeviceService.EligibleForPortScan(device) {
+			sm.pingSweepService.EnqueuePortScan(*device)
 		}
 	}
 
+	// Devices not seen since the sweep started were missed by it, which the
+	// status engine uses as evidence that they went away
+	if err := sm.networkService.MarkScanned(network.ID, sweepStartedAt); err != nil {
+		log.Printf("Error updating last scan time of network %s: %v", network.CIDR, err)
+	}
+
 	// Update scan state
 	sm.mutex.Lock()
 	now := time.Now()
@@ -332,12 +394,16 @@ func (sm *ScanManager) runSingleScan() {
 	sm.mutex.Unlock()
 
 	duration := time.Since(*sm.state.StartTime)
-	log.Printf("Completed scan iteration %d for network %s. Found %d devices.", sm.state.ScanCount, network.CIDR, len(devices))
+	log.Printf("Completed scan iteration %d for network %s. Found %d devices.", sm.state.ScanCount, network.CIDR, len(savedDevices))
 
 	// Create event log for ping sweep completion
 	durationInSeconds := float64(duration.Seconds())
-	err = sm.pingSweepService.EventLogService.CreateOne(&models.EventLog{
-		Type:            models.PingSweep,
+	devicesFound := len(savedDevices)
+	err = sm.pingSweepService.EventLogService.Log(models.PingSweep, "", "", &models.ScanPayload{
+		RunID:           runID,
+		NetworkID:       network.ID,
+		CIDR:            network.CIDR,
+		DevicesFound:    &devicesFound,
 		DurationSeconds: &durationInSeconds,
 	})
 	if err != nil {
@@ -345,6 +411,53 @@ func (sm *ScanManager) runSingleScan() {
 	}
 }
 
+// sweepIPv4 runs a ping sweep of the network's IPv4 range and saves the
+// devices found
+func (sm *ScanManager) sweepIPv4(network *models.Network, policy scanpolicy.SweepPolicy, limiter *ratelimit.Limiter) ([]*models.Device, error) {
+	devices, err := sm.pingSweepService.ExecuteSweepScanCommand(network.GetIPv4CIDR(), policy, limiter)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Ping sweep found %d devices from scan", len(devices))
+
+	// Save the whole sweep in one transaction
+	found := make([]*models.Device, len(devices))
+	for i := range devices {
+		found[i] = &devices[i]
+	}
+	savedDevices, err := sm.pingSweepService.DeviceService.SaveSweep(network.ID, found)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save ping sweep results: %v", err)
+	}
+	log.Printf("Saved %d devices from ping sweep", len(savedDevices))
+
+	if sm.Conflicts != nil {
+		sm.Conflicts.ObserveSweep(network.ID, found)
+	}
+	return savedDevices, nil
+}
+
+// discoverIPv6 runs active discovery on the network's IPv6 prefix and saves
+// the hosts found
+func (sm *ScanManager) discoverIPv6(network *models.Network, policy scanpolicy.SweepPolicy, limiter *ratelimit.Limiter) ([]*models.Device, error) {
+	devices, err := sm.pingSweepService.ExecuteIPv6Discovery(network, policy, limiter)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make([]*models.Device, len(devices))
+	for i := range devices {
+		found[i] = &devices[i]
+	}
+	savedDevices, err := sm.pingSweepService.DeviceService.SaveIPv6Sweep(network.ID, found)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save IPv6 discovery results: %v", err)
+	}
+	log.Printf("Saved %d devices from IPv6 discovery", len(savedDevices))
+	return savedDevices, nil
+}
+
 // ScanErrorType represents different types of scan errors
 type ScanErrorType string
 
@@ -0,0 +1,479 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"reconya-ai/models"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv6"
+)
+
+// How an IPv6 host was found
+const (
+	IPv6SourceEcho   = "echo"   // answered the all-nodes echo request
+	IPv6SourceRouter = "router" // answered a router solicitation
+	IPv6SourceMLD    = "mld"    // answered a multicast listener query
+	IPv6SourceEUI64  = "eui64"  // answered at the EUI-64 address of a known MAC
+	IPv6SourceDNS    = "dns"    // answered at an address its hostname resolves to
+)
+
+// IPv6Candidate is a known device whose IPv6 addresses are guessed: the
+// EUI-64 addresses of its MAC address and the AAAA records of its hostname
+type IPv6Candidate struct {
+	MAC      string
+	Hostname string
+}
+
+var (
+	allNodes         = net.ParseIP("ff02::1")
+	allRouters       = net.ParseIP("ff02::2")
+	allMLDv2Routers  = net.ParseIP("ff02::16")
+	linkLocalNetwork = &net.IPNet{IP: net.ParseIP("fe80::"), Mask: net.CIDRMask(64, 128)}
+)
+
+// ipv6Host collects what discovery learned about one address
+type ipv6Host struct {
+	ip      net.IP
+	mac     string
+	sources map[string]bool
+}
+
+// ipv6Probe is the socket discovery sends on. Raw ICMPv6 sockets can send
+// router solicitations and MLD queries; unprivileged ICMP sockets only echo
+// requests.
+type ipv6Probe struct {
+	conn net.PacketConn
+	raw  *ipv6.PacketConn
+	id   int
+}
+
+// DiscoverIPv6 actively looks for hosts on the links of an IPv6 prefix. It
+// sends an echo request to all nodes (ff02::1), a router solicitation and an
+// MLD query on every interface with an address in the prefix, and echo
+// requests to the addresses guessed for candidates. Devices are returned with
+// their MAC address when it is known and their IPv6 addresses; IPv4 is empty.
+func (s *NativeScanner) DiscoverIPv6(prefix string, candidates []IPv6Candidate) ([]models.Device, error) {
+	ip, prefixNet, err := net.ParseCIDR(prefix)
+	if err != nil || ip.To4() != nil {
+		return nil, fmt.Errorf("invalid IPv6 prefix %q", prefix)
+	}
+
+	interfaces, local, err := ipv6Interfaces(prefixNet)
+	if err != nil {
+		return nil, err
+	}
+	if len(interfaces) == 0 {
+		return nil, fmt.Errorf("no IPv6 interface to discover %s on", prefix)
+	}
+
+	probe, err := openIPv6Probe()
+	if err != nil {
+		return nil, err
+	}
+	defer probe.conn.Close()
+	if probe.raw == nil {
+		log.Printf("Raw ICMPv6 sockets unavailable, IPv6 discovery of %s uses echo requests only", prefix)
+	}
+
+	hosts := make(map[string]*ipv6Host)
+	guessed := make(map[string]string)
+	guessedMAC := make(map[string]string)
+	for _, candidate := range candidates {
+		if candidate.MAC != "" && s.exclusions.ExcludesMAC(candidate.MAC) {
+			continue
+		}
+		for _, address := range candidateAddresses(candidate, prefixNet) {
+			if s.exclusions.ExcludesIP(address.ip.String()) {
+				continue
+			}
+			guessed[address.ip.String()] = address.source
+			if address.source == IPv6SourceEUI64 {
+				guessedMAC[address.ip.String()] = candidate.MAC
+			}
+		}
+	}
+
+	var targets []net.IP
+	for address := range guessed {
+		target := net.ParseIP(address)
+		if target.IsLinkLocalUnicast() || prefixNet.Contains(target) {
+			targets = append(targets, target)
+		}
+	}
+
+	// Each interface gets the link-wide probes and an echo request to every
+	// guessed address
+	probed, total := 0, len(interfaces)*(1+len(targets))
+	for _, iface := range interfaces {
+		if err := s.limiter.Wait(context.Background(), 2); err != nil {
+			return nil, err
+		}
+		probe.sendLink(iface)
+		probed++
+		s.reportProgress(probed, total)
+		for _, target := range targets {
+			if err := s.limiter.Wait(context.Background(), 1); err != nil {
+				return nil, err
+			}
+			probe.sendEcho(target, iface)
+			probed++
+			s.reportProgress(probed, total)
+		}
+	}
+
+	deadline := time.Now().Add(s.timeout)
+	probe.conn.SetReadDeadline(deadline)
+	buf := make([]byte, 1500)
+	for {
+		source, msgType, err := probe.read(buf)
+		if err != nil {
+			break
+		}
+		if source == nil || local[source.String()] {
+			continue
+		}
+
+		var how string
+		switch msgType {
+		case ipv6.ICMPTypeEchoReply:
+			how = IPv6SourceEcho
+			if guess, ok := guessed[source.String()]; ok {
+				how = guess
+			}
+		case ipv6.ICMPTypeRouterAdvertisement:
+			how = IPv6SourceRouter
+		case ipv6.ICMPTypeMulticastListenerReport, ipv6.ICMPTypeVersion2MulticastListenerReport:
+			how = IPv6SourceMLD
+		default:
+			continue
+		}
+
+		key := source.String()
+		host, ok := hosts[key]
+		if !ok {
+			host = &ipv6Host{ip: source, sources: make(map[string]bool)}
+			hosts[key] = host
+		}
+		host.sources[how] = true
+	}
+
+	for key, host := range hosts {
+		if s.neighbors != nil {
+			if entry, ok := s.neighbors.Lookup(key); ok {
+				host.mac = entry.MAC
+			}
+		}
+		if host.mac == "" {
+			host.mac = guessedMAC[key]
+		}
+		if host.mac == "" {
+			host.mac = macFromEUI64(host.ip)
+		}
+		if s.exclusions.ExcludesIP(key) || (host.mac != "" && s.exclusions.ExcludesMAC(host.mac)) {
+			delete(hosts, key)
+		}
+	}
+
+	devices := ipv6Devices(hosts, prefixNet)
+	log.Printf("IPv6 discovery of %s found %d addresses of %d devices", prefix, len(hosts), len(devices))
+	return devices, nil
+}
+
+// ipv6Interfaces returns the up, multicast-capable interfaces with an address
+// in prefix, and every local IPv6 address
+func ipv6Interfaces(prefix *net.IPNet) ([]net.Interface, map[string]bool, error) {
+	all, err := net.Interfaces()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list interfaces: %v", err)
+	}
+
+	var interfaces []net.Interface
+	local := make(map[string]bool)
+	for _, iface := range all {
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		onPrefix := false
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok || ipNet.IP.To4() != nil {
+				continue
+			}
+			local[ipNet.IP.String()] = true
+			if prefix.Contains(ipNet.IP) {
+				onPrefix = true
+			}
+		}
+		usable := iface.Flags&net.FlagUp != 0 && iface.Flags&net.FlagMulticast != 0 && iface.Flags&net.FlagLoopback == 0
+		if usable && onPrefix {
+			interfaces = append(interfaces, iface)
+		}
+	}
+	return interfaces, local, nil
+}
+
+func openIPv6Probe() (*ipv6Probe, error) {
+	id := os.Getpid() & 0xffff
+
+	if conn, err := net.ListenPacket("ip6:ipv6-icmp", "::"); err == nil {
+		raw := ipv6.NewPacketConn(conn)
+		var filter ipv6.ICMPFilter
+		filter.SetAll(true)
+		filter.Accept(ipv6.ICMPTypeEchoReply)
+		filter.Accept(ipv6.ICMPTypeRouterAdvertisement)
+		filter.Accept(ipv6.ICMPTypeMulticastListenerReport)
+		filter.Accept(ipv6.ICMPTypeVersion2MulticastListenerReport)
+		if err := raw.SetICMPFilter(&filter); err != nil {
+			log.Printf("Failed to set ICMPv6 filter: %v", err)
+		}
+		if err := raw.SetControlMessage(ipv6.FlagInterface, true); err != nil {
+			log.Printf("Failed to request ICMPv6 interface info: %v", err)
+		}
+		return &ipv6Probe{conn: conn, raw: raw, id: id}, nil
+	}
+
+	conn, err := icmp.ListenPacket("udp6", "::")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ICMPv6 socket: %v", err)
+	}
+	return &ipv6Probe{conn: conn, id: id}, nil
+}
+
+// sendLink sends the link-wide probes on iface
+func (p *ipv6Probe) sendLink(iface net.Interface) {
+	p.sendEcho(allNodes, iface)
+	if p.raw == nil {
+		return
+	}
+
+	// MLDv2 reports go to ff02::16; v1 reports to the group itself
+	if err := p.raw.JoinGroup(&iface, &net.IPAddr{IP: allMLDv2Routers}); err != nil {
+		log.Printf("Failed to join ff02::16 on %s: %v", iface.Name, err)
+	}
+
+	// Routers ignore solicitations with a hop limit below 255 (RFC 4861)
+	p.write(routerSolicitation(), allRouters, iface, 255)
+	// Listeners ignore queries that were forwarded (RFC 2710)
+	p.write(mldQuery(), allNodes, iface, 1)
+}
+
+func (p *ipv6Probe) sendEcho(target net.IP, iface net.Interface) {
+	message := &icmp.Message{
+		Type: ipv6.ICMPTypeEchoRequest,
+		Body: &icmp.Echo{ID: p.id, Seq: 1, Data: []byte("reconYa discovery")},
+	}
+	data, err := message.Marshal(nil)
+	if err != nil {
+		return
+	}
+	p.write(data, target, iface, 0)
+}
+
+// write sends data to target; link-local and multicast targets are sent out
+// of iface. A zero hopLimit keeps the default.
+func (p *ipv6Probe) write(data []byte, target net.IP, iface net.Interface, hopLimit int) {
+	zone := ""
+	if target.IsLinkLocalUnicast() || target.IsLinkLocalMulticast() {
+		zone = iface.Name
+	}
+
+	var err error
+	if p.raw != nil {
+		cm := &ipv6.ControlMessage{HopLimit: hopLimit, IfIndex: iface.Index}
+		_, err = p.raw.WriteTo(data, cm, &net.IPAddr{IP: target, Zone: zone})
+	} else {
+		_, err = p.conn.WriteTo(data, &net.UDPAddr{IP: target, Zone: zone})
+	}
+	if err != nil {
+		log.Printf("Failed to send IPv6 probe to %s on %s: %v", target, iface.Name, err)
+	}
+}
+
+// read returns the source and type of the next ICMPv6 message
+func (p *ipv6Probe) read(buf []byte) (net.IP, ipv6.ICMPType, error) {
+	for {
+		var n int
+		var peer net.Addr
+		var err error
+		if p.raw != nil {
+			n, _, peer, err = p.raw.ReadFrom(buf)
+		} else {
+			n, peer, err = p.conn.ReadFrom(buf)
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+
+		message, err := icmp.ParseMessage(58, buf[:n])
+		if err != nil {
+			continue
+		}
+		msgType, ok := message.Type.(ipv6.ICMPType)
+		if !ok {
+			continue
+		}
+		// Unprivileged sockets get their ID rewritten by the kernel, which
+		// also filters replies for them
+		if echo, isEcho := message.Body.(*icmp.Echo); isEcho && p.raw != nil && echo.ID != p.id {
+			continue
+		}
+
+		switch addr := peer.(type) {
+		case *net.IPAddr:
+			return addr.IP, msgType, nil
+		case *net.UDPAddr:
+			return addr.IP, msgType, nil
+		}
+		return nil, msgType, nil
+	}
+}
+
+// routerSolicitation returns an ICMPv6 router solicitation; the kernel fills
+// in the checksum
+func routerSolicitation() []byte {
+	message := &icmp.Message{Type: ipv6.ICMPTypeRouterSolicitation, Body: &icmp.RawBody{Data: make([]byte, 4)}}
+	data, _ := message.Marshal(nil)
+	return data
+}
+
+// mldQuery returns an MLDv1 general query asking listeners to report within
+// one second; MLDv2 listeners answer it too
+func mldQuery() []byte {
+	body := make([]byte, 20)
+	body[0], body[1] = 0x03, 0xe8 // maximum response delay, 1000 ms
+	message := &icmp.Message{Type: ipv6.ICMPTypeMulticastListenerQuery, Body: &icmp.RawBody{Data: body}}
+	data, _ := message.Marshal(nil)
+	return data
+}
+
+type guessedAddress struct {
+	ip     net.IP
+	source string
+}
+
+// candidateAddresses returns the addresses guessed for a candidate: its
+// EUI-64 addresses in prefix and on the link, and the AAAA records of its
+// hostname within prefix
+func candidateAddresses(candidate IPv6Candidate, prefix *net.IPNet) []guessedAddress {
+	var addresses []guessedAddress
+	if ip := eui64Address(prefix, candidate.MAC); ip != nil {
+		addresses = append(addresses, guessedAddress{ip, IPv6SourceEUI64})
+	}
+	if ip := eui64Address(linkLocalNetwork, candidate.MAC); ip != nil {
+		addresses = append(addresses, guessedAddress{ip, IPv6SourceEUI64})
+	}
+
+	if hostname := strings.TrimSuffix(candidate.Hostname, "."); hostname != "" {
+		ips, err := net.LookupIP(hostname)
+		if err == nil {
+			for _, ip := range ips {
+				if ip.To4() == nil && prefix.Contains(ip) {
+					addresses = append(addresses, guessedAddress{ip, IPv6SourceDNS})
+				}
+			}
+		}
+	}
+	return addresses
+}
+
+// eui64Address returns the SLAAC address of mac in the first 64 bits of
+// prefix, or nil for an invalid MAC address
+func eui64Address(prefix *net.IPNet, mac string) net.IP {
+	hw, err := net.ParseMAC(mac)
+	if err != nil || len(hw) != 6 {
+		return nil
+	}
+
+	ip := make(net.IP, net.IPv6len)
+	copy(ip, prefix.IP.To16()[:8])
+	ip[8] = hw[0] ^ 0x02 // flip the universal/local bit
+	ip[9], ip[10] = hw[1], hw[2]
+	ip[11], ip[12] = 0xff, 0xfe
+	ip[13], ip[14], ip[15] = hw[3], hw[4], hw[5]
+	return ip
+}
+
+// macFromEUI64 returns the MAC address embedded in an EUI-64 interface ID,
+// or "" for other addresses such as privacy addresses
+func macFromEUI64(ip net.IP) string {
+	ip = ip.To16()
+	if ip == nil || ip.To4() != nil || ip[11] != 0xff || ip[12] != 0xfe {
+		return ""
+	}
+	hw := net.HardwareAddr{ip[8] ^ 0x02, ip[9], ip[10], ip[13], ip[14], ip[15]}
+	return strings.ToUpper(hw.String())
+}
+
+// ipv6Devices groups the discovered addresses by MAC address into devices
+func ipv6Devices(hosts map[string]*ipv6Host, prefix *net.IPNet) []models.Device {
+	keys := make([]string, 0, len(hosts))
+	for key := range hosts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return bytes.Compare(hosts[keys[i]].ip.To16(), hosts[keys[j]].ip.To16()) < 0
+	})
+
+	var devices []models.Device
+	byMAC := make(map[string]int)
+	for _, key := range keys {
+		host := hosts[key]
+		index, ok := byMAC[host.mac]
+		if !ok || host.mac == "" {
+			device := models.Device{Status: models.DeviceStatusOnline}
+			if host.mac != "" {
+				mac := host.mac
+				device.MAC = &mac
+			}
+			devices = append(devices, device)
+			index = len(devices) - 1
+			if host.mac != "" {
+				byMAC[host.mac] = index
+			}
+		}
+
+		device := &devices[index]
+		if host.sources[IPv6SourceRouter] {
+			device.DeviceType = models.DeviceTypeRouter
+		}
+		SetIPv6Address(device, host.ip, prefix)
+	}
+	return devices
+}
+
+// SetIPv6Address stores ip in the dual-stack field for its kind of address.
+// Addresses of a kind the device already has, and global addresses outside
+// prefix, go to the additional addresses.
+func SetIPv6Address(device *models.Device, ip net.IP, prefix *net.IPNet) {
+	address := ip.String()
+	var field **string
+	switch {
+	case ip.IsLinkLocalUnicast():
+		field = &device.IPv6LinkLocal
+	case ip.IsPrivate():
+		field = &device.IPv6UniqueLocal
+	case prefix == nil || prefix.Contains(ip):
+		field = &device.IPv6Global
+	}
+
+	if field != nil && (*field == nil || **field == address) {
+		*field = &address
+		return
+	}
+	for _, existing := range device.GetAllIPv6Addresses() {
+		if existing == address {
+			return
+		}
+	}
+	device.AddIPv6Address(address)
+}
@@ -0,0 +1,80 @@
+package scanner
+
+import (
+	"net"
+	"testing"
+
+	"reconya-ai/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv6"
+)
+
+func TestEUI64Address(t *testing.T) {
+	_, prefix, _ := net.ParseCIDR("2001:db8:1:2::/64")
+	ip := eui64Address(prefix, "00:1a:2b:3c:4d:5e")
+	require.NotNil(t, ip)
+	assert.Equal(t, "2001:db8:1:2:21a:2bff:fe3c:4d5e", ip.String())
+	assert.Equal(t, "00:1A:2B:3C:4D:5E", macFromEUI64(ip))
+
+	assert.Equal(t, "fe80::21a:2bff:fe3c:4d5e", eui64Address(linkLocalNetwork, "00:1A:2B:3C:4D:5E").String())
+	assert.Nil(t, eui64Address(prefix, "not a mac"))
+	assert.Empty(t, macFromEUI64(net.ParseIP("2001:db8::1234:5678:9abc:def0")), "privacy address")
+	assert.Empty(t, macFromEUI64(net.ParseIP("192.168.1.1")))
+}
+
+func TestProbeMessages(t *testing.T) {
+	message, err := icmp.ParseMessage(58, routerSolicitation())
+	require.NoError(t, err)
+	assert.Equal(t, ipv6.ICMPTypeRouterSolicitation, message.Type)
+
+	query := mldQuery()
+	require.Len(t, query, 24)
+	message, err = icmp.ParseMessage(58, query)
+	require.NoError(t, err)
+	assert.Equal(t, ipv6.ICMPTypeMulticastListenerQuery, message.Type)
+	assert.Equal(t, []byte{0x03, 0xe8}, query[4:6], "maximum response delay")
+}
+
+func TestIPv6Devices(t *testing.T) {
+	_, prefix, _ := net.ParseCIDR("2001:db8::/64")
+	host := func(address, mac string, sources ...string) *ipv6Host {
+		h := &ipv6Host{ip: net.ParseIP(address), mac: mac, sources: make(map[string]bool)}
+		for _, source := range sources {
+			h.sources[source] = true
+		}
+		return h
+	}
+	hosts := map[string]*ipv6Host{
+		"fe80::1":          host("fe80::1", "AA:BB:CC:00:00:01", IPv6SourceRouter),
+		"2001:db8::1":      host("2001:db8::1", "AA:BB:CC:00:00:01", IPv6SourceEcho),
+		"2001:db8::2":      host("2001:db8::2", "AA:BB:CC:00:00:02", IPv6SourceEcho),
+		"2001:db8::3":      host("2001:db8::3", "AA:BB:CC:00:00:02", IPv6SourceMLD),
+		"fd00::5":          host("fd00::5", "", IPv6SourceEcho),
+		"2001:db8:ffff::9": host("2001:db8:ffff::9", "", IPv6SourceEcho),
+	}
+
+	devices := ipv6Devices(hosts, prefix)
+	require.Len(t, devices, 4)
+
+	router := devices[0]
+	require.NotNil(t, router.MAC)
+	assert.Equal(t, "AA:BB:CC:00:00:01", *router.MAC)
+	assert.Equal(t, models.DeviceTypeRouter, router.DeviceType)
+	assert.Equal(t, "fe80::1", *router.IPv6LinkLocal)
+	assert.Equal(t, "2001:db8::1", *router.IPv6Global)
+	assert.Empty(t, router.IPv4)
+
+	laptop := devices[1]
+	assert.Equal(t, "2001:db8::2", *laptop.IPv6Global)
+	assert.Equal(t, []string{"2001:db8::3"}, laptop.IPv6Addresses)
+
+	outside := devices[2]
+	assert.Nil(t, outside.IPv6Global)
+	assert.Equal(t, []string{"2001:db8:ffff::9"}, outside.IPv6Addresses)
+
+	assert.Nil(t, devices[3].MAC)
+	assert.Equal(t, "fd00::5", *devices[3].IPv6UniqueLocal)
+}
@@ -3,7 +3,6 @@ package scanner
 import (
 	"context"
 	"fmt"
-	"io"
 	"log"
 	"net"
 	"net/http"
@@ -11,10 +10,15 @@ import (
 	"os"
 	"os/exec"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"reconya-ai/internal/exclusion"
+	"reconya-ai/internal/neighbor"
+	"reconya-ai/internal/ratelimit"
 	"reconya-ai/models"
 
 	"golang.org/x/net/icmp"
@@ -22,11 +26,15 @@ import (
 )
 
 type NativeScanner struct {
-	timeout                  time.Duration
-	concurrent               int
-	enableMACLookup          bool
-	enableHostnameLookup     bool
-	enableOnlineVendorLookup bool
+	timeout              time.Duration
+	concurrent           int
+	enableMACLookup      bool
+	enableHostnameLookup bool
+	neighbors            *neighbor.Table
+	vendorLookup         func(mac string) string
+	limiter              *ratelimit.Limiter
+	exclusions           *exclusion.List
+	progress             func(probed, total int)
 }
 
 type ScanResult struct {
@@ -41,21 +49,19 @@ type ScanResult struct {
 
 func NewNativeScanner() *NativeScanner {
 	return &NativeScanner{
-		timeout:                  time.Second * 3,
-		concurrent:               50, // Concurrent goroutines for scanning
-		enableMACLookup:          true,
-		enableHostnameLookup:     true,
-		enableOnlineVendorLookup: true, // Allow online vendor lookups
+		timeout:              time.Second * 3,
+		concurrent:           50, // Concurrent goroutines for scanning
+		enableMACLookup:      true,
+		enableHostnameLookup: true,
 	}
 }
 
 // SetOptions allows configuring scanner behavior
-func (s *NativeScanner) SetOptions(timeout time.Duration, concurrent int, enableMAC, enableHostname, enableOnlineVendor bool) {
+func (s *NativeScanner) SetOptions(timeout time.Duration, concurrent int, enableMAC, enableHostname bool) {
 	s.timeout = timeout
 	s.concurrent = concurrent
 	s.enableMACLookup = enableMAC
 	s.enableHostnameLookup = enableHostname
-	s.enableOnlineVendorLookup = enableOnlineVendor
 }
 
 // ScanNetwork performs a ping sweep on the given CIDR network
@@ -68,8 +74,13 @@ func (s *NativeScanner) ScanNetwork(network string) ([]models.Device, error) {
 		return nil, fmt.Errorf("invalid CIDR: %v", err)
 	}
 
-	// Generate all IPs in the network
-	ips := s.generateIPList(ipNet)
+	// Generate all IPs in the network, leaving out excluded ones
+	var ips []string
+	for _, ip := range s.generateIPList(ipNet) {
+		if !s.exclusions.ExcludesIP(ip) {
+			ips = append(ips, ip)
+		}
+	}
 	log.Printf("Scanning %d IP addresses", len(ips))
 
 	// Create channels for work distribution
@@ -97,7 +108,10 @@ func (s *NativeScanner) ScanNetwork(network string) ([]models.Device, error) {
 
 	// Collect results
 	var devices []models.Device
+	probed := 0
 	for result := range resultChan {
+		probed++
+		s.reportProgress(probed, len(ips))
 		if result.Online {
 			device := models.Device{
 				IPv4:   result.IP,
@@ -119,6 +133,11 @@ func (s *NativeScanner) ScanNetwork(network string) ([]models.Device, error) {
 				device.Hostname = &result.Hostname
 			}
 
+			if s.exclusions.ExcludesDevice(&device) {
+				log.Printf("Dropping excluded device: %s (%s)", result.IP, result.MAC)
+				continue
+			}
+
 			devices = append(devices, device)
 			log.Printf("Found online device: %s (RTT: %v)", result.IP, result.RTT)
 		}
@@ -143,6 +162,10 @@ func (s *NativeScanner) scanIP(ip string) ScanResult {
 	result := ScanResult{IP: ip}
 
 	// Try multiple detection methods
+	if err := s.limiter.Wait(context.Background(), 1); err != nil {
+		result.Error = err
+		return result
+	}
 	online, rtt := s.tryPing(ip)
 	if !online {
 		online, rtt = s.tryTCPConnect(ip)
@@ -164,13 +187,22 @@ func (s *NativeScanner) scanIP(ip string) ScanResult {
 	return result
 }
 
+// Ping sends a single ICMP echo to ip and returns whether it answered and the
+// measured round-trip time. It falls back to a TCP connect when raw ICMP
+// sockets are not available.
+func (s *NativeScanner) Ping(ip string) (bool, time.Duration) {
+	return s.tryPing(ip)
+}
+
+// pingSequence makes every echo request unique so concurrent pings can tell
+// their replies apart on the shared raw socket
+var pingSequence uint32
+
 // tryPing attempts to ping an IP address using ICMP
 func (s *NativeScanner) tryPing(ip string) (bool, time.Duration) {
 	// Note: ICMP ping requires raw sockets on most systems (root privileges)
 	// For a more portable solution, we might want to use TCP connect instead
 
-	start := time.Now()
-
 	// Try to resolve the address first
 	addr, err := net.ResolveIPAddr("ip4", ip)
 	if err != nil {
@@ -185,13 +217,16 @@ func (s *NativeScanner) tryPing(ip string) (bool, time.Duration) {
 	}
 	defer conn.Close()
 
+	id := os.Getpid() & 0xffff
+	seq := int(atomic.AddUint32(&pingSequence, 1) & 0xffff)
+
 	// Create ICMP message
 	message := &icmp.Message{
 		Type: ipv4.ICMPTypeEcho,
 		Code: 0,
 		Body: &icmp.Echo{
-			ID:   1,
-			Seq:  1,
+			ID:   id,
+			Seq:  seq,
 			Data: []byte("reconYa ping"),
 		},
 	}
@@ -205,31 +240,35 @@ func (s *NativeScanner) tryPing(ip string) (bool, time.Duration) {
 	conn.SetDeadline(time.Now().Add(s.timeout))
 
 	// Send ICMP packet
+	start := time.Now()
 	_, err = conn.WriteTo(data, addr)
 	if err != nil {
 		return false, 0
 	}
 
-	// Read response
+	// Read responses until our echo reply arrives or the deadline passes
 	reply := make([]byte, 1500)
-	n, _, err := conn.ReadFrom(reply)
-	if err != nil {
-		return false, 0
-	}
+	for {
+		n, peer, err := conn.ReadFrom(reply)
+		if err != nil {
+			return false, 0
+		}
 
-	rtt := time.Since(start)
+		rm, err := icmp.ParseMessage(1, reply[:n])
+		if err != nil || rm.Type != ipv4.ICMPTypeEchoReply {
+			continue
+		}
 
-	// Parse ICMP reply
-	rm, err := icmp.ParseMessage(int(ipv4.ICMPTypeEchoReply), reply[:n])
-	if err != nil {
-		return false, 0
-	}
+		echo, ok := rm.Body.(*icmp.Echo)
+		if !ok || echo.ID != id || echo.Seq != seq {
+			continue
+		}
+		if peerAddr, ok := peer.(*net.IPAddr); ok && !peerAddr.IP.Equal(addr.IP) {
+			continue
+		}
 
-	if rm.Type == ipv4.ICMPTypeEchoReply {
-		return true, rtt
+		return true, time.Since(start)
 	}
-
-	return false, 0
 }
 
 // tryTCPConnect attempts to connect to common ports to detect if host is alive
@@ -239,7 +278,10 @@ func (s *NativeScanner) tryTCPConnect(ip string) (bool, time.Duration) {
 	start := time.Now()
 
 	for _, port := range commonPorts {
-		address := fmt.Sprintf("%s:%d", ip, port)
+		if err := s.limiter.Wait(context.Background(), 1); err != nil {
+			return false, 0
+		}
+		address := net.JoinHostPort(ip, strconv.Itoa(port))
 		conn, err := net.DialTimeout("tcp", address, time.Millisecond*500)
 		if err == nil {
 			conn.Close()
@@ -272,16 +314,55 @@ func (s *NativeScanner) getMACInfo(ip string) (string, string) {
 	return "", ""
 }
 
+// SetNeighborTable makes MAC lookups read the in-memory neighbor table
+// instead of the system's ARP table
+func (s *NativeScanner) SetNeighborTable(table *neighbor.Table) {
+	s.neighbors = table
+}
+
+// SetLimiter paces the scanner's probes and holds them outside the scan
+// windows of the limiter
+func (s *NativeScanner) SetLimiter(limiter *ratelimit.Limiter) {
+	s.limiter = limiter
+}
+
+// SetExclusions keeps the scanner from probing excluded addresses and from
+// reporting hosts with an excluded MAC address
+func (s *NativeScanner) SetExclusions(exclusions *exclusion.List) {
+	s.exclusions = exclusions
+}
+
+// SetProgress reports how many of the addresses to probe have been probed
+func (s *NativeScanner) SetProgress(progress func(probed, total int)) {
+	s.progress = progress
+}
+
+func (s *NativeScanner) reportProgress(probed, total int) {
+	if s.progress != nil {
+		s.progress(probed, total)
+	}
+}
+
+// SetVendorLookup sets the offline vendor database MAC addresses are looked
+// up in; without one, devices are found without a vendor
+func (s *NativeScanner) SetVendorLookup(lookup func(mac string) string) {
+	s.vendorLookup = lookup
+}
+
 // getARPInfo looks up MAC address from ARP table (cross-platform)
 func (s *NativeScanner) getARPInfo(ip string) (string, string) {
 	var mac string
 
-	switch runtime.GOOS {
-	case "linux":
+	switch {
+	case s.neighbors != nil:
+		if entry, ok := s.neighbors.Lookup(ip); ok {
+			mac = entry.MAC
+		}
+	case runtime.GOOS == "linux":
 		mac = s.getARPLinux(ip)
-	case "darwin":
+	case runtime.GOOS == "darwin":
 		mac = s.getARPMacOS(ip)
-	case "windows":
+	case runtime.GOOS == "windows":
 		mac = s.getARPWindows(ip)
 	default:
 		return "", ""
@@ -390,96 +471,10 @@ func (s *NativeScanner) scanNetworkInterface(ip string) (string, string) {
 
 // lookupVendor looks up vendor information from MAC address
 func (s *NativeScanner) lookupVendor(mac string) string {
-	if len(mac) < 8 {
+	if s.vendorLookup == nil {
 		return ""
 	}
-
-	// Extract OUI (first 3 octets)
-	oui := strings.ReplaceAll(mac[:8], ":", "")
-	oui = strings.ToUpper(oui)
-
-	// Built-in vendor database (most common vendors)
-	vendors := map[string]string{
-		"000040": "Applicon",
-		"0000FF": "Camtec Electronics",
-		"000020": "Dataindustrier Diab AB",
-		"001B63": "Apple",
-		"8C859":  "Apple",
-		"F0189":  "Apple",
-		"00226B": "Cisco Systems",
-		"0007EB": "Cisco Systems",
-		"5C5948": "Samsung Electronics",
-		"002454": "Intel Corporate",
-		"84FDD1": "Netgear",
-		"001E58": "Netgear",
-		"00095B": "Netgear",
-		"3C37E6": "Intel Corporate",
-		"7085C2": "Intel Corporate",
-		"DC85DE": "Intel Corporate",
-		"00D0C9": "Intel Corporate",
-		"E45F01": "Intel Corporate",
-		"38D547": "Apple",
-		"A4C361": "Apple",
-		"F02475": "Apple",
-		"14109F": "Apple",
-		"3451C9": "Apple",
-		"BC52B7": "Apple",
-		"E8802E": "Apple",
-		"E06267": "Apple",
-		"90B21F": "Apple",
-		"F86214": "Apple",
-		"68A86D": "Apple",
-		"7C6DF8": "Apple",
-		"DC86D8": "Apple",
-		"B065BD": "Apple",
-		"609AC1": "Apple",
-		"C82A14": "Apple",
-		"F0B479": "Apple",
-		"6C4008": "Apple",
-		"E0F847": "Apple",
-		"009EC8": "Apple",
-		"002332": "Apple",
-		"002608": "Apple",
-	}
-
-	if vendor, exists := vendors[oui]; exists {
-		return vendor
-	}
-
-	// Try online OUI lookup if local database doesn't have it and online lookup is enabled
-	if s.enableOnlineVendorLookup {
-		return s.lookupVendorOnline(oui)
-	}
-
-	return ""
-}
-
-// lookupVendorOnline attempts to lookup vendor from online OUI database
-func (s *NativeScanner) lookupVendorOnline(oui string) string {
-	// For production use, you might want to cache these lookups
-	// This is a simple implementation
-
-	client := &http.Client{Timeout: time.Second * 2}
-	url := fmt.Sprintf("https://api.macvendors.com/%s", oui)
-
-	resp, err := client.Get(url)
-	if err != nil {
-		return ""
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == 200 {
-		body, err := io.ReadAll(resp.Body)
-		if err == nil {
-			vendor := strings.TrimSpace(string(body))
-			// Clean up the vendor name
-			if vendor != "Not found" && vendor != "" {
-				return vendor
-			}
-		}
-	}
-
-	return ""
+	return s.vendorLookup(mac)
 }
 
 // getHostname attempts to resolve hostname for the IP using multiple methods
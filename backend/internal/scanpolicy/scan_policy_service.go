@@ -0,0 +1,366 @@
+package scanpolicy
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"reconya-ai/db"
+	"reconya-ai/internal/device"
+	"reconya-ai/internal/exclusion"
+	"reconya-ai/models"
+)
+
+// Settings are the effective scan settings of a device
+type Settings struct {
+	Exclude     bool   `json:"exclude"`
+	PingOnly    bool   `json:"ping_only"`
+	Ports       string `json:"ports,omitempty"`     // empty for the default port list
+	TopPorts    int    `json:"top_ports,omitempty"` // 0 for the default port list
+	UDP         bool   `json:"udp"`
+	OSDetection bool   `json:"os_detection"`
+	Screenshots bool   `json:"screenshots"`
+	MaxRate     int    `json:"max_rate,omitempty"` // 0 for no limit
+	// Scopes that supplied each value, keyed by setting; "global" for the
+	// defaults and "tag:<name>" for tags
+	Sources map[string]string `json:"sources"`
+}
+
+// SweepPolicy is what a ping sweep of a network has to honor
+type SweepPolicy struct {
+	Skip       bool     // the whole network is excluded
+	ExcludeIPs []string // excluded devices, addresses and IPv4 ranges
+	MaxRate    int      // 0 for no limit
+	// Exclusions drops hosts that answer anyway, such as ones with an
+	// excluded MAC address that no known device has
+	Exclusions *exclusion.List
+}
+
+const (
+	settingExclude     = "exclude"
+	settingPingOnly    = "ping_only"
+	settingPorts       = "ports"
+	settingUDP         = "udp"
+	settingOSDetection = "os_detection"
+	settingScreenshots = "screenshots"
+	settingMaxRate     = "max_rate"
+)
+
+// SourceExclusionList is the source of the exclude setting of devices on an
+// exclusion list
+const SourceExclusionList = "exclusion_list"
+
+// DefaultSettings scans the default port list over TCP with OS detection
+func DefaultSettings() Settings {
+	settings := Settings{OSDetection: true, Sources: make(map[string]string)}
+	for _, name := range []string{settingExclude, settingPingOnly, settingPorts, settingUDP, settingOSDetection, settingScreenshots, settingMaxRate} {
+		settings.Sources[name] = "global"
+	}
+	return settings
+}
+
+// ScanPolicyService stores scan policies and resolves them for devices
+type ScanPolicyService struct {
+	repository    db.ScanPolicyRepository
+	deviceService *device.DeviceService
+	defaults      Settings
+	// Exclusions, when set, excludes the devices and addresses on the
+	// exclusion lists whatever their policies say
+	Exclusions *exclusion.ExclusionService
+}
+
+func NewScanPolicyService(repository db.ScanPolicyRepository, deviceService *device.DeviceService) *ScanPolicyService {
+	return &ScanPolicyService{
+		repository:    repository,
+		deviceService: deviceService,
+		defaults:      DefaultSettings(),
+	}
+}
+
+// GetPolicies returns every configured policy
+func (s *ScanPolicyService) GetPolicies() ([]*models.ScanPolicy, error) {
+	return s.repository.FindAll(context.Background())
+}
+
+// GetDefaults returns the settings of devices without a policy
+func (s *ScanPolicyService) GetDefaults() Settings {
+	return s.defaults
+}
+
+// SetPolicy creates or replaces a policy
+func (s *ScanPolicyService) SetPolicy(policy *models.ScanPolicy) error {
+	if !policy.Scope.IsValid() {
+		return fmt.Errorf("invalid scope %q", policy.Scope)
+	}
+	if policy.ScopeID == "" {
+		return fmt.Errorf("scope_id is required")
+	}
+	if policy.Scope == models.ScanPolicyScopeTag {
+		tags := models.NormalizeTags([]string{policy.ScopeID})
+		if len(tags) == 0 {
+			return fmt.Errorf("scope_id is required")
+		}
+		policy.ScopeID = tags[0]
+	}
+	if policy.IsEmpty() {
+		return fmt.Errorf("at least one setting is required")
+	}
+	if policy.Ports != nil && policy.TopPorts != nil {
+		return fmt.Errorf("ports and top_ports are mutually exclusive")
+	}
+	if policy.Ports != nil {
+		ports := strings.ReplaceAll(*policy.Ports, " ", "")
+		if err := ValidatePorts(ports); err != nil {
+			return err
+		}
+		policy.Ports = &ports
+	}
+	if policy.TopPorts != nil && (*policy.TopPorts < 1 || *policy.TopPorts > 65535) {
+		return fmt.Errorf("top_ports must be between 1 and 65535")
+	}
+	if policy.MaxRate != nil && *policy.MaxRate <= 0 {
+		return fmt.Errorf("max_rate must be positive")
+	}
+
+	return s.repository.Save(context.Background(), policy)
+}
+
+// DeletePolicy removes a policy
+func (s *ScanPolicyService) DeletePolicy(scope models.ScanPolicyScope, scopeID string) error {
+	if scope == models.ScanPolicyScopeTag {
+		scopeID = strings.ToLower(strings.TrimSpace(scopeID))
+	}
+	return s.repository.Delete(context.Background(), scope, scopeID)
+}
+
+// EffectiveSettings resolves the settings that currently apply to a device
+func (s *ScanPolicyService) EffectiveSettings(d *models.Device) (Settings, error) {
+	policies, err := s.repository.FindAll(context.Background())
+	if err != nil {
+		return Settings{}, fmt.Errorf("failed to load scan policies: %v", err)
+	}
+	exclusions, err := s.Exclusions.ListFor(d.NetworkID)
+	if err != nil {
+		return Settings{}, err
+	}
+
+	settings := ResolveSettings(s.defaults, IndexPolicies(policies), d)
+	if exclusions.ExcludesDevice(d) {
+		settings.Exclude = true
+		settings.Sources[settingExclude] = SourceExclusionList
+	}
+	return settings, nil
+}
+
+// SweepPolicy returns the exclusions and rate limit of a network's ping sweep
+// Networks without an ID, such as ones only given on the command line, get
+// the global exclusions.
+func (s *ScanPolicyService) SweepPolicy(network *models.Network) (SweepPolicy, error) {
+	policies, err := s.repository.FindAll(context.Background())
+	if err != nil {
+		return SweepPolicy{}, fmt.Errorf("failed to load scan policies: %v", err)
+	}
+	exclusions, err := s.Exclusions.ListFor(network.ID)
+	if err != nil {
+		return SweepPolicy{}, err
+	}
+	if len(policies) == 0 && exclusions.IsEmpty() {
+		return SweepPolicy{}, nil
+	}
+	index := IndexPolicies(policies)
+
+	networkSettings := ResolveSettings(s.defaults, index, &models.Device{NetworkID: network.ID})
+	sweep := SweepPolicy{
+		Skip:       networkSettings.Exclude || coveredBy(network, exclusions),
+		MaxRate:    networkSettings.MaxRate,
+		Exclusions: exclusions,
+	}
+	if sweep.Skip || network.ID == "" {
+		sweep.ExcludeIPs = exclusions.Targets()
+		return sweep, nil
+	}
+
+	devices, err := s.deviceService.FindByNetworkID(network.ID)
+	if err != nil {
+		return SweepPolicy{}, fmt.Errorf("failed to load devices of network %s: %v", network.CIDR, err)
+	}
+	excluded := make(map[string]bool)
+	for _, target := range exclusions.Targets() {
+		excluded[target] = true
+	}
+	for i := range devices {
+		if devices[i].IPv4 == "" {
+			continue
+		}
+		// Devices with an excluded MAC address are left out at their last
+		// known address
+		if ResolveSettings(s.defaults, index, &devices[i]).Exclude || exclusions.ExcludesDevice(&devices[i]) {
+			excluded[devices[i].IPv4] = true
+		}
+	}
+	for target := range excluded {
+		sweep.ExcludeIPs = append(sweep.ExcludeIPs, target)
+	}
+	sort.Strings(sweep.ExcludeIPs)
+	return sweep, nil
+}
+
+// coveredBy reports whether the exclusions cover every range of a network
+func coveredBy(network *models.Network, exclusions *exclusion.List) bool {
+	if exclusions.IsEmpty() {
+		return false
+	}
+	if network.IsIPv4Enabled() && !exclusions.Covers(network.GetIPv4CIDR()) {
+		return false
+	}
+	if network.IsIPv6Enabled() && !exclusions.Covers(network.GetIPv6Prefix()) {
+		return false
+	}
+	return true
+}
+
+type policyKey struct {
+	scope   models.ScanPolicyScope
+	scopeID string
+}
+
+// PolicyIndex looks up policies by scope
+type PolicyIndex map[policyKey]*models.ScanPolicy
+
+// IndexPolicies builds a PolicyIndex
+func IndexPolicies(policies []*models.ScanPolicy) PolicyIndex {
+	index := make(PolicyIndex, len(policies))
+	for _, p := range policies {
+		index[policyKey{p.Scope, p.ScopeID}] = p
+	}
+	return index
+}
+
+// ResolveSettings applies policies with precedence device, tag, device type,
+// network, global. Each setting is resolved independently. Tags apply in
+// alphabetical order, except that among tags exclusion and ping-only win
+// over their opposite and the lowest rate limit wins.
+func ResolveSettings(defaults Settings, index PolicyIndex, d *models.Device) Settings {
+	resolved := defaults
+	resolved.Sources = make(map[string]string, len(defaults.Sources))
+	for name, source := range defaults.Sources {
+		resolved.Sources[name] = source
+	}
+
+	// Broadest scope first so narrower ones win
+	apply := func(p *models.ScanPolicy, source string) {
+		if p.Exclude != nil {
+			resolved.Exclude = *p.Exclude
+			resolved.Sources[settingExclude] = source
+		}
+		if p.PingOnly != nil {
+			resolved.PingOnly = *p.PingOnly
+			resolved.Sources[settingPingOnly] = source
+		}
+		if p.Ports != nil {
+			resolved.Ports = *p.Ports
+			resolved.TopPorts = 0
+			resolved.Sources[settingPorts] = source
+		}
+		if p.TopPorts != nil {
+			resolved.TopPorts = *p.TopPorts
+			resolved.Ports = ""
+			resolved.Sources[settingPorts] = source
+		}
+		if p.UDP != nil {
+			resolved.UDP = *p.UDP
+			resolved.Sources[settingUDP] = source
+		}
+		if p.OSDetection != nil {
+			resolved.OSDetection = *p.OSDetection
+			resolved.Sources[settingOSDetection] = source
+		}
+		if p.Screenshots != nil {
+			resolved.Screenshots = *p.Screenshots
+			resolved.Sources[settingScreenshots] = source
+		}
+		if p.MaxRate != nil {
+			resolved.MaxRate = *p.MaxRate
+			resolved.Sources[settingMaxRate] = source
+		}
+	}
+
+	if p, ok := index[policyKey{models.ScanPolicyScopeNetwork, d.NetworkID}]; ok && d.NetworkID != "" {
+		apply(p, string(models.ScanPolicyScopeNetwork))
+	}
+	if p, ok := index[policyKey{models.ScanPolicyScopeDeviceType, string(d.DeviceType)}]; ok && d.DeviceType != "" {
+		apply(p, string(models.ScanPolicyScopeDeviceType))
+	}
+
+	excludedByTag, pingOnlyByTag, tagMaxRate := "", "", 0
+	tagRateSource := ""
+	for _, tag := range models.NormalizeTags(d.Tags) {
+		p, ok := index[policyKey{models.ScanPolicyScopeTag, tag}]
+		if !ok {
+			continue
+		}
+		source := "tag:" + tag
+		apply(p, source)
+		if p.Exclude != nil && *p.Exclude && excludedByTag == "" {
+			excludedByTag = source
+		}
+		if p.PingOnly != nil && *p.PingOnly && pingOnlyByTag == "" {
+			pingOnlyByTag = source
+		}
+		if p.MaxRate != nil && (tagMaxRate == 0 || *p.MaxRate < tagMaxRate) {
+			tagMaxRate, tagRateSource = *p.MaxRate, source
+		}
+	}
+	if excludedByTag != "" {
+		resolved.Exclude = true
+		resolved.Sources[settingExclude] = excludedByTag
+	}
+	if pingOnlyByTag != "" {
+		resolved.PingOnly = true
+		resolved.Sources[settingPingOnly] = pingOnlyByTag
+	}
+	if tagMaxRate > 0 {
+		resolved.MaxRate = tagMaxRate
+		resolved.Sources[settingMaxRate] = tagRateSource
+	}
+
+	if p, ok := index[policyKey{models.ScanPolicyScopeDevice, d.ID}]; ok && d.ID != "" {
+		apply(p, string(models.ScanPolicyScopeDevice))
+	}
+	return resolved
+}
+
+// ValidatePorts checks an nmap port specification such as "22,80,8000-8100",
+// optionally prefixed by protocol as in "T:80,U:53"
+func ValidatePorts(spec string) error {
+	if spec == "" {
+		return fmt.Errorf("ports must not be empty")
+	}
+	for _, part := range strings.Split(spec, ",") {
+		if len(part) > 2 && (part[:2] == "T:" || part[:2] == "U:") {
+			part = part[2:]
+		}
+		bounds := strings.SplitN(part, "-", 2)
+		low, err := parsePort(bounds[0])
+		if err != nil {
+			return fmt.Errorf("invalid port %q in ports", part)
+		}
+		if len(bounds) == 2 {
+			high, err := parsePort(bounds[1])
+			if err != nil || high < low {
+				return fmt.Errorf("invalid port range %q in ports", part)
+			}
+		}
+	}
+	return nil
+}
+
+func parsePort(value string) (int, error) {
+	port, err := strconv.Atoi(value)
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("invalid port %q", value)
+	}
+	return port, nil
+}
@@ -0,0 +1,88 @@
+package scanpolicy
+
+import (
+	"testing"
+
+	"reconya-ai/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func boolean(b bool) *bool {
+	return &b
+}
+
+func number(n int) *int {
+	return &n
+}
+
+func text(s string) *string {
+	return &s
+}
+
+func TestResolveSettings_Precedence(t *testing.T) {
+	index := IndexPolicies([]*models.ScanPolicy{
+		{Scope: models.ScanPolicyScopeNetwork, ScopeID: "net-1", TopPorts: number(1000), MaxRate: number(500)},
+		{Scope: models.ScanPolicyScopeDeviceType, ScopeID: string(models.DeviceTypePrinter), OSDetection: boolean(false)},
+		{Scope: models.ScanPolicyScopeTag, ScopeID: "lab", UDP: boolean(true)},
+		{Scope: models.ScanPolicyScopeDevice, ScopeID: "dev-1", Ports: text("80,443,9100")},
+	})
+
+	d := &models.Device{ID: "dev-1", NetworkID: "net-1", DeviceType: models.DeviceTypePrinter, Tags: []string{"lab"}}
+	resolved := ResolveSettings(DefaultSettings(), index, d)
+	assert.Equal(t, "80,443,9100", resolved.Ports)
+	assert.Zero(t, resolved.TopPorts, "custom ports replace the inherited top ports")
+	assert.Equal(t, "device", resolved.Sources["ports"])
+	assert.True(t, resolved.UDP)
+	assert.Equal(t, "tag:lab", resolved.Sources["udp"])
+	assert.False(t, resolved.OSDetection)
+	assert.Equal(t, "device_type", resolved.Sources["os_detection"])
+	assert.Equal(t, 500, resolved.MaxRate)
+	assert.Equal(t, "network", resolved.Sources["max_rate"])
+	assert.Equal(t, "global", resolved.Sources["exclude"])
+
+	// Falls back to the global defaults
+	resolved = ResolveSettings(DefaultSettings(), index, &models.Device{ID: "dev-2", NetworkID: "net-2"})
+	assert.Equal(t, DefaultSettings(), resolved)
+}
+
+func TestResolveSettings_Tags(t *testing.T) {
+	index := IndexPolicies([]*models.ScanPolicy{
+		{Scope: models.ScanPolicyScopeDeviceType, ScopeID: string(models.DeviceTypeCamera), PingOnly: boolean(true)},
+		{Scope: models.ScanPolicyScopeTag, ScopeID: "fragile", Exclude: boolean(true), MaxRate: number(10)},
+		{Scope: models.ScanPolicyScopeTag, ScopeID: "monitored", Exclude: boolean(false), PingOnly: boolean(false), MaxRate: number(100)},
+	})
+
+	// The restrictive tag wins whatever the order
+	d := &models.Device{ID: "dev-1", DeviceType: models.DeviceTypeCamera, Tags: []string{"monitored", "fragile"}}
+	resolved := ResolveSettings(DefaultSettings(), index, d)
+	assert.True(t, resolved.Exclude)
+	assert.Equal(t, "tag:fragile", resolved.Sources["exclude"])
+	assert.Equal(t, 10, resolved.MaxRate)
+
+	// A tag overrides the device type
+	d.Tags = []string{"monitored"}
+	resolved = ResolveSettings(DefaultSettings(), index, d)
+	assert.False(t, resolved.Exclude)
+	assert.False(t, resolved.PingOnly)
+	assert.Equal(t, "tag:monitored", resolved.Sources["ping_only"])
+
+	// The device overrides its tags
+	index = IndexPolicies([]*models.ScanPolicy{
+		{Scope: models.ScanPolicyScopeTag, ScopeID: "fragile", Exclude: boolean(true)},
+		{Scope: models.ScanPolicyScopeDevice, ScopeID: "dev-1", Exclude: boolean(false)},
+	})
+	d.Tags = []string{"fragile"}
+	resolved = ResolveSettings(DefaultSettings(), index, d)
+	assert.False(t, resolved.Exclude)
+	assert.Equal(t, "device", resolved.Sources["exclude"])
+}
+
+func TestValidatePorts(t *testing.T) {
+	for _, spec := range []string{"22", "22,80,443", "8000-8100", "T:80,U:53,161-162", "1-65535"} {
+		assert.NoError(t, ValidatePorts(spec), spec)
+	}
+	for _, spec := range []string{"", "0", "65536", "80,", "100-90", "http", "U:", "1-2-3"} {
+		assert.Error(t, ValidatePorts(spec), spec)
+	}
+}
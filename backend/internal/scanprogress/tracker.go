@@ -0,0 +1,351 @@
+package scanprogress
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Stage is a step of a scan
+type Stage string
+
+const (
+	StageIdle        Stage = "idle"
+	StageDiscovery   Stage = "discovery"
+	StagePortScan    Stage = "port_scan"
+	StageFingerprint Stage = "fingerprint"
+	// StageScreenshots checks a device's web services, taking screenshots
+	// when they are enabled
+	StageScreenshots Stage = "screenshots"
+)
+
+// averageWeight is how much the latest device counts towards the average
+// time a device takes
+const averageWeight = 0.2
+
+// Snapshot is the progress of the scan at one moment
+type Snapshot struct {
+	// Stage is the earliest stage with work left
+	Stage       Stage             `json:"stage"`
+	Discovery   DiscoveryProgress `json:"discovery"`
+	PortScan    StageProgress     `json:"port_scan"`
+	Fingerprint StageProgress     `json:"fingerprint"`
+	Screenshots StageProgress     `json:"screenshots"`
+	// ETA is when the work known so far should be done; devices a running
+	// discovery is still to find aren't accounted for. It is left out until
+	// there is something to base it on.
+	ETA        *time.Time `json:"eta,omitempty"`
+	ETASeconds *int       `json:"eta_seconds,omitempty"`
+}
+
+// DiscoveryProgress is how far the sweep of a range has got
+type DiscoveryProgress struct {
+	Running bool    `json:"running"`
+	Target  string  `json:"target,omitempty"`
+	Probed  int     `json:"probed"`
+	Total   int     `json:"total"`
+	Percent float64 `json:"percent"`
+}
+
+// StageProgress counts the devices of a stage. Backlog is the devices that
+// haven't reached it yet; devices without open ports never reach the
+// screenshots stage, so its backlog is an upper bound.
+type StageProgress struct {
+	Backlog   int `json:"backlog"`
+	InFlight  int `json:"in_flight"`
+	Completed int `json:"completed"`
+	// Capacity is the size of the port-scan queue
+	Capacity int `json:"capacity,omitempty"`
+	// Devices are the IP addresses in the stage
+	Devices []string `json:"devices,omitempty"`
+}
+
+// Remaining formats the time left until the ETA
+func (s Snapshot) Remaining() string {
+	if s.ETASeconds == nil {
+		return "unknown"
+	}
+	d := time.Duration(*s.ETASeconds) * time.Second
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm %ds", int(d.Minutes()), int(d.Seconds())%60)
+	default:
+		return fmt.Sprintf("%dh %dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+}
+
+type device struct {
+	stage     Stage // empty while queued
+	startedAt time.Time
+}
+
+// Tracker follows the discovery of a network and the devices going through
+// the port-scan queue. A nil Tracker tracks nothing.
+type Tracker struct {
+	mu        sync.Mutex
+	now       func() time.Time
+	workers   int
+	capacity  int
+	discovery DiscoveryProgress
+	// discoveryStarted and discoveryLeft time the running discovery;
+	// discoveryLeft is the scanner's own estimate, 0 when it has none
+	discoveryStarted time.Time
+	discoveryLeft    time.Duration
+	devices          map[string]*device
+	completed        map[Stage]int
+	// average is the moving average of the time from leaving the queue to
+	// finishing the last stage
+	average time.Duration
+}
+
+// NewTracker creates a tracker for a queue of the given capacity worked off
+// by the given number of workers
+func NewTracker(workers, capacity int) *Tracker {
+	return &Tracker{
+		now:       time.Now,
+		workers:   workers,
+		capacity:  capacity,
+		devices:   make(map[string]*device),
+		completed: make(map[Stage]int),
+	}
+}
+
+// Reset zeroes the completed counts and the discovery for a new scan run.
+// Devices already in the queue stay tracked.
+func (t *Tracker) Reset() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.discovery = DiscoveryProgress{}
+	t.discoveryLeft = 0
+	t.completed = make(map[Stage]int)
+}
+
+// StartDiscovery starts the discovery of a range of total addresses
+func (t *Tracker) StartDiscovery(target string, total int) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.discovery = DiscoveryProgress{Running: true, Target: target, Total: total}
+	t.discoveryStarted = t.now()
+	t.discoveryLeft = 0
+}
+
+// Probed records how many of total addresses have been probed
+func (t *Tracker) Probed(probed, total int) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.discovery.Probed = probed
+	t.discovery.Total = total
+	t.discovery.Percent = 0
+	if total > 0 {
+		t.discovery.Percent = float64(probed) * 100 / float64(total)
+	}
+}
+
+// Estimate records a scanner's own progress report, which gives a percentage
+// and, when known, the time left rather than a count of addresses
+func (t *Tracker) Estimate(percent float64, left time.Duration) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.discovery.Percent = percent
+	t.discovery.Probed = int(percent * float64(t.discovery.Total) / 100)
+	t.discoveryLeft = left
+}
+
+// FinishDiscovery ends the running discovery
+func (t *Tracker) FinishDiscovery() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.discovery.Running = false
+	t.discovery.Probed = t.discovery.Total
+	t.discovery.Percent = 100
+	t.discoveryLeft = 0
+}
+
+// Enqueue records a device waiting for its port scan. It returns false when
+// the device is already queued or being scanned.
+func (t *Tracker) Enqueue(ip string) bool {
+	if t == nil {
+		return true
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.devices[ip]; ok {
+		return false
+	}
+	t.devices[ip] = &device{}
+	return true
+}
+
+// Enter moves a device to a stage, completing the stage it was in. Devices
+// scanned without going through the queue are tracked from their port scan.
+func (t *Tracker) Enter(ip string, stage Stage) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	d, ok := t.devices[ip]
+	if !ok {
+		d = &device{}
+		t.devices[ip] = d
+	}
+	if d.stage != "" {
+		t.completed[d.stage]++
+	}
+	if d.startedAt.IsZero() {
+		d.startedAt = t.now()
+	}
+	d.stage = stage
+}
+
+// Done completes the stage a device is in and stops tracking it
+func (t *Tracker) Done(ip string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	d, ok := t.devices[ip]
+	if !ok {
+		return
+	}
+	delete(t.devices, ip)
+	if d.stage == "" {
+		return
+	}
+	t.completed[d.stage]++
+
+	took := t.now().Sub(d.startedAt)
+	if t.average == 0 {
+		t.average = took
+	} else {
+		t.average = time.Duration(averageWeight*float64(took) + (1-averageWeight)*float64(t.average))
+	}
+}
+
+// Drop stops tracking a device that won't be scanned after all
+func (t *Tracker) Drop(ip string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.devices, ip)
+}
+
+// Snapshot returns the progress of every stage and the ETA
+func (t *Tracker) Snapshot() Snapshot {
+	if t == nil {
+		return Snapshot{Stage: StageIdle}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s := Snapshot{
+		Discovery:   t.discovery,
+		PortScan:    StageProgress{Completed: t.completed[StagePortScan], Capacity: t.capacity},
+		Fingerprint: StageProgress{Completed: t.completed[StageFingerprint]},
+		Screenshots: StageProgress{Completed: t.completed[StageScreenshots]},
+	}
+	stages := map[Stage]*StageProgress{
+		StagePortScan:    &s.PortScan,
+		StageFingerprint: &s.Fingerprint,
+		StageScreenshots: &s.Screenshots,
+	}
+	order := map[Stage]int{"": 0, StagePortScan: 1, StageFingerprint: 2, StageScreenshots: 3}
+	for ip, d := range t.devices {
+		if stage, ok := stages[d.stage]; ok {
+			stage.InFlight++
+			stage.Devices = append(stage.Devices, ip)
+		}
+		for later, progress := range stages {
+			if order[d.stage] < order[later] {
+				progress.Backlog++
+			}
+		}
+	}
+	for _, progress := range stages {
+		sort.Strings(progress.Devices)
+	}
+
+	switch {
+	case s.Discovery.Running:
+		s.Stage = StageDiscovery
+	case s.PortScan.Backlog+s.PortScan.InFlight > 0:
+		s.Stage = StagePortScan
+	case s.Fingerprint.InFlight > 0:
+		s.Stage = StageFingerprint
+	case s.Screenshots.InFlight > 0:
+		s.Stage = StageScreenshots
+	default:
+		s.Stage = StageIdle
+	}
+
+	if left, ok := t.left(); ok {
+		eta := t.now().Add(left)
+		seconds := int(left.Round(time.Second).Seconds())
+		s.ETA = &eta
+		s.ETASeconds = &seconds
+	}
+	return s
+}
+
+// left estimates the time until the discovery and the tracked devices are
+// done: the rest of the discovery, then the queued and in-flight devices at
+// the average time a device takes, spread over the workers
+func (t *Tracker) left() (time.Duration, bool) {
+	if !t.discovery.Running && len(t.devices) == 0 {
+		return 0, false
+	}
+
+	var left time.Duration
+	if t.discovery.Running {
+		switch {
+		case t.discoveryLeft > 0:
+			left = t.discoveryLeft
+		case t.discovery.Percent > 0:
+			elapsed := t.now().Sub(t.discoveryStarted)
+			left = time.Duration(float64(elapsed) * (100 - t.discovery.Percent) / t.discovery.Percent)
+		default:
+			return 0, false
+		}
+	}
+
+	if len(t.devices) > 0 {
+		if t.average == 0 {
+			return 0, false
+		}
+		var work time.Duration
+		for _, d := range t.devices {
+			if d.stage == "" {
+				work += t.average
+			} else if rest := t.average - t.now().Sub(d.startedAt); rest > 0 {
+				work += rest
+			}
+		}
+		workers := t.workers
+		if workers < 1 {
+			workers = 1
+		}
+		left += work / time.Duration(workers)
+	}
+	return left, true
+}
@@ -0,0 +1,114 @@
+package scanprogress
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestTracker(now *time.Time) *Tracker {
+	t := NewTracker(2, 10)
+	t.now = func() time.Time { return *now }
+	return t
+}
+
+func TestTracker_Stages(t *testing.T) {
+	now := time.Date(2025, 1, 6, 12, 0, 0, 0, time.UTC)
+	tracker := newTestTracker(&now)
+
+	if !tracker.Enqueue("192.168.1.10") || !tracker.Enqueue("192.168.1.11") || !tracker.Enqueue("192.168.1.12") {
+		t.Fatal("expected devices to be queued")
+	}
+	if tracker.Enqueue("192.168.1.10") {
+		t.Error("expected a queued device not to be queued twice")
+	}
+
+	tracker.Enter("192.168.1.10", StagePortScan)
+	tracker.Enter("192.168.1.11", StagePortScan)
+	tracker.Enter("192.168.1.11", StageFingerprint)
+
+	s := tracker.Snapshot()
+	if s.Stage != StagePortScan {
+		t.Errorf("Stage = %s, want %s", s.Stage, StagePortScan)
+	}
+	if s.PortScan.Backlog != 1 || s.PortScan.InFlight != 1 || s.PortScan.Completed != 1 || s.PortScan.Capacity != 10 {
+		t.Errorf("unexpected port scan progress %+v", s.PortScan)
+	}
+	if len(s.PortScan.Devices) != 1 || s.PortScan.Devices[0] != "192.168.1.10" {
+		t.Errorf("PortScan.Devices = %v", s.PortScan.Devices)
+	}
+	if s.Fingerprint.Backlog != 2 || s.Fingerprint.InFlight != 1 {
+		t.Errorf("unexpected fingerprint progress %+v", s.Fingerprint)
+	}
+	if s.Screenshots.Backlog != 3 || s.Screenshots.InFlight != 0 {
+		t.Errorf("unexpected screenshot progress %+v", s.Screenshots)
+	}
+	if s.ETA != nil {
+		t.Error("expected no ETA before any device is done")
+	}
+
+	now = now.Add(40 * time.Second)
+	tracker.Done("192.168.1.11")
+	tracker.Drop("192.168.1.12")
+
+	// One device in flight for 40s of a 40s average leaves nothing to wait
+	// for; a queued one would add 40s over two workers
+	s = tracker.Snapshot()
+	if s.ETASeconds == nil || *s.ETASeconds != 0 {
+		t.Errorf("ETASeconds = %v, want 0", s.ETASeconds)
+	}
+	tracker.Enqueue("192.168.1.13")
+	s = tracker.Snapshot()
+	if s.ETASeconds == nil || *s.ETASeconds != 20 {
+		t.Errorf("ETASeconds = %v, want 20", s.ETASeconds)
+	}
+	if s.Fingerprint.Completed != 1 {
+		t.Errorf("Fingerprint.Completed = %d, want 1", s.Fingerprint.Completed)
+	}
+
+	tracker.Done("192.168.1.10")
+	tracker.Drop("192.168.1.13")
+	if s := tracker.Snapshot(); s.Stage != StageIdle || s.ETA != nil {
+		t.Errorf("expected an idle tracker without ETA, got %+v", s)
+	}
+}
+
+func TestTracker_Discovery(t *testing.T) {
+	now := time.Date(2025, 1, 6, 12, 0, 0, 0, time.UTC)
+	tracker := newTestTracker(&now)
+
+	tracker.StartDiscovery("10.0.0.0/20", 4096)
+	if s := tracker.Snapshot(); s.Stage != StageDiscovery || s.ETA != nil {
+		t.Errorf("expected discovery without ETA, got %+v", s)
+	}
+
+	now = now.Add(30 * time.Second)
+	tracker.Estimate(25, 0)
+	s := tracker.Snapshot()
+	if s.Discovery.Probed != 1024 {
+		t.Errorf("Discovery.Probed = %d, want 1024", s.Discovery.Probed)
+	}
+	if s.ETASeconds == nil || *s.ETASeconds != 90 {
+		t.Errorf("ETASeconds = %v, want 90", s.ETASeconds)
+	}
+
+	tracker.Estimate(50, 10*time.Second)
+	if s := tracker.Snapshot(); s.ETASeconds == nil || *s.ETASeconds != 10 {
+		t.Errorf("ETASeconds = %v, want the scanner's 10", s.ETASeconds)
+	}
+
+	tracker.Probed(3, 4)
+	if s := tracker.Snapshot(); s.Discovery.Percent != 75 {
+		t.Errorf("Discovery.Percent = %v, want 75", s.Discovery.Percent)
+	}
+
+	tracker.FinishDiscovery()
+	s = tracker.Snapshot()
+	if s.Discovery.Running || s.Discovery.Probed != s.Discovery.Total || s.Stage != StageIdle {
+		t.Errorf("unexpected finished discovery %+v", s)
+	}
+
+	var empty *Tracker
+	if s := empty.Snapshot(); s.Stage != StageIdle || !empty.Enqueue("192.168.1.10") {
+		t.Error("expected nil tracker to track nothing")
+	}
+}
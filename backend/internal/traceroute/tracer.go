@@ -0,0 +1,363 @@
+package traceroute
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"reconya-ai/models"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+)
+
+const (
+	protocolICMP = 1
+	protocolTCP  = 6
+	protocolUDP  = 17
+
+	// Stop probing once this many consecutive hops stay silent
+	maxSilentHops = 5
+
+	// MaxHops is kept within this range; the Internet's longest paths are
+	// around 30 hops
+	minHops     = 1
+	maxHopLimit = 64
+
+	// TCP probes are sent from source ports in this range, below the usual
+	// ephemeral ports
+	minSourcePort = 20000
+	maxSourcePort = 32000
+)
+
+// Tracer discovers the route to an IPv4 host by sending probes with an
+// increasing TTL and collecting the ICMP time-exceeded replies of each router.
+// Reading those replies needs a raw ICMP socket (root or CAP_NET_RAW).
+type Tracer struct {
+	Method  models.TracerouteMethod
+	MaxHops int
+	Timeout time.Duration
+	Port    int
+	id      int
+	// sourcePort+ttl is the source port of each TCP probe of a trace, so a
+	// late reply to an earlier TTL isn't taken for the current one
+	sourcePort int
+}
+
+// NewTracer creates a tracer for the given probe method. The hop count is
+// clamped to 1..64.
+func NewTracer(method models.TracerouteMethod, maxHops int, timeout time.Duration) *Tracer {
+	if maxHops < minHops || maxHops > maxHopLimit {
+		clamped := min(max(maxHops, minHops), maxHopLimit)
+		log.Printf("Traceroute max hops %d is out of range, using %d", maxHops, clamped)
+		maxHops = clamped
+	}
+	port := 33434
+	if method == models.TracerouteMethodTCP {
+		port = 80
+	}
+	return &Tracer{
+		Method:  method,
+		MaxHops: maxHops,
+		Timeout: timeout,
+		Port:    port,
+		id:      os.Getpid() & 0xffff,
+	}
+}
+
+// Trace runs a traceroute towards target
+func (t *Tracer) Trace(ctx context.Context, target string) (*models.TracerouteRun, error) {
+	dst := net.ParseIP(target).To4()
+	if dst == nil {
+		return nil, fmt.Errorf("traceroute target %s is not an IPv4 address", target)
+	}
+
+	if t.MaxHops < minHops || t.MaxHops > maxHopLimit {
+		return nil, fmt.Errorf("traceroute max hops %d is not within %d..%d", t.MaxHops, minHops, maxHopLimit)
+	}
+
+	conn, err := icmp.ListenPacket("ip4:icmp", "0.0.0.0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ICMP listener (requires root or CAP_NET_RAW): %v", err)
+	}
+	defer conn.Close()
+
+	t.sourcePort = minSourcePort + rand.Intn(maxSourcePort-minSourcePort-t.MaxHops)
+
+	run := &models.TracerouteRun{
+		Target:    target,
+		Method:    t.Method,
+		StartedAt: time.Now(),
+		Hops:      []models.TracerouteHop{},
+	}
+
+	silent := 0
+	for ttl := 1; ttl <= t.MaxHops; ttl++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		hop, reached, err := t.probe(conn, dst, ttl)
+		if err != nil {
+			return nil, err
+		}
+		run.Hops = append(run.Hops, hop)
+
+		if reached {
+			run.Reached = true
+			break
+		}
+		if hop.IP == nil {
+			silent++
+			if silent >= maxSilentHops {
+				break
+			}
+		} else {
+			silent = 0
+		}
+	}
+
+	// Drop the trailing run of silent hops of an unreachable target
+	if !run.Reached {
+		last := len(run.Hops)
+		for last > 0 && run.Hops[last-1].IP == nil {
+			last--
+		}
+		run.Hops = run.Hops[:last]
+	}
+
+	resolveHostnames(ctx, run.Hops)
+	run.CompletedAt = time.Now()
+	return run, nil
+}
+
+// probe sends a single probe with the given TTL and waits for the matching reply
+func (t *Tracer) probe(conn *icmp.PacketConn, dst net.IP, ttl int) (models.TracerouteHop, bool, error) {
+	hop := models.TracerouteHop{TTL: ttl}
+	start := time.Now()
+	deadline := start.Add(t.Timeout)
+
+	var tcpResult chan error
+	switch t.Method {
+	case models.TracerouteMethodUDP:
+		closer, err := t.sendUDP(dst, ttl)
+		if err != nil {
+			return hop, false, err
+		}
+		defer closer.Close()
+	case models.TracerouteMethodTCP:
+		tcpResult = t.sendTCP(dst, ttl)
+	default:
+		if err := t.sendICMP(conn, dst, ttl); err != nil {
+			return hop, false, err
+		}
+	}
+
+	buf := make([]byte, 1500)
+	for {
+		if tcpResult != nil {
+			select {
+			case err := <-tcpResult:
+				// A completed or refused handshake means the target itself answered
+				if err == nil || errors.Is(err, syscall.ECONNREFUSED) {
+					return reachedHop(hop, dst, start), true, nil
+				}
+				tcpResult = nil
+			default:
+			}
+		}
+
+		readDeadline := deadline
+		if tcpResult != nil {
+			// Poll so that a handshake completing without ICMP traffic is noticed
+			if next := time.Now().Add(50 * time.Millisecond); next.Before(deadline) {
+				readDeadline = next
+			}
+		}
+		conn.SetReadDeadline(readDeadline)
+
+		n, peer, err := conn.ReadFrom(buf)
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				if time.Now().Before(deadline) {
+					continue
+				}
+				return hop, false, nil
+			}
+			return hop, false, fmt.Errorf("failed to read ICMP reply: %v", err)
+		}
+
+		msg, err := icmp.ParseMessage(protocolICMP, buf[:n])
+		if err != nil || !t.matches(msg, dst, ttl) {
+			continue
+		}
+
+		peerIP := peerAddress(peer)
+		if peerIP == nil {
+			continue
+		}
+		hop = reachedHop(hop, peerIP, start)
+		reached := peerIP.Equal(dst) &&
+			(msg.Type == ipv4.ICMPTypeEchoReply || msg.Type == ipv4.ICMPTypeDestinationUnreachable)
+		return hop, reached, nil
+	}
+}
+
+func reachedHop(hop models.TracerouteHop, ip net.IP, start time.Time) models.TracerouteHop {
+	addr := ip.String()
+	rtt := float64(time.Since(start).Microseconds()) / 1000
+	hop.IP = &addr
+	hop.RTTMillis = &rtt
+	return hop
+}
+
+func peerAddress(addr net.Addr) net.IP {
+	switch a := addr.(type) {
+	case *net.IPAddr:
+		return a.IP
+	case *net.UDPAddr:
+		return a.IP
+	}
+	return nil
+}
+
+func (t *Tracer) sendICMP(conn *icmp.PacketConn, dst net.IP, ttl int) error {
+	if err := conn.IPv4PacketConn().SetTTL(ttl); err != nil {
+		return fmt.Errorf("failed to set TTL: %v", err)
+	}
+
+	message := &icmp.Message{
+		Type: ipv4.ICMPTypeEcho,
+		Code: 0,
+		Body: &icmp.Echo{
+			ID:   t.id,
+			Seq:  ttl,
+			Data: []byte("reconYa traceroute"),
+		},
+	}
+	data, err := message.Marshal(nil)
+	if err != nil {
+		return fmt.Errorf("failed to build ICMP probe: %v", err)
+	}
+
+	if _, err := conn.WriteTo(data, &net.IPAddr{IP: dst}); err != nil {
+		return fmt.Errorf("failed to send ICMP probe: %v", err)
+	}
+	return nil
+}
+
+func (t *Tracer) sendUDP(dst net.IP, ttl int) (net.PacketConn, error) {
+	conn, err := net.ListenPacket("udp4", "0.0.0.0:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open UDP socket: %v", err)
+	}
+
+	if err := ipv4.NewPacketConn(conn).SetTTL(ttl); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to set TTL: %v", err)
+	}
+
+	// Every TTL uses its own destination port so replies can be told apart
+	addr := &net.UDPAddr{IP: dst, Port: t.Port + ttl}
+	if _, err := conn.WriteTo([]byte("reconYa traceroute"), addr); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to send UDP probe: %v", err)
+	}
+	return conn, nil
+}
+
+func (t *Tracer) sendTCP(dst net.IP, ttl int) chan error {
+	result := make(chan error, 1)
+	dialer := net.Dialer{
+		Timeout:   t.Timeout,
+		LocalAddr: &net.TCPAddr{Port: t.sourcePort + ttl},
+		Control: func(network, address string, c syscall.RawConn) error {
+			var sockErr error
+			err := c.Control(func(fd uintptr) {
+				sockErr = setSocketTTL(fd, ttl)
+			})
+			if err != nil {
+				return err
+			}
+			return sockErr
+		},
+	}
+
+	go func() {
+		conn, err := dialer.Dial("tcp4", net.JoinHostPort(dst.String(), fmt.Sprintf("%d", t.Port)))
+		if conn != nil {
+			conn.Close()
+		}
+		result <- err
+	}()
+	return result
+}
+
+// matches checks whether an ICMP message answers the probe sent with ttl
+func (t *Tracer) matches(msg *icmp.Message, dst net.IP, ttl int) bool {
+	var original []byte
+	switch body := msg.Body.(type) {
+	case *icmp.Echo:
+		return msg.Type == ipv4.ICMPTypeEchoReply && t.Method == models.TracerouteMethodICMP &&
+			body.ID == t.id && body.Seq == ttl
+	case *icmp.TimeExceeded:
+		original = body.Data
+	case *icmp.DstUnreach:
+		original = body.Data
+	default:
+		return false
+	}
+
+	// The error payload carries the original IPv4 header plus 8 bytes of the probe
+	if len(original) < 20 {
+		return false
+	}
+	headerLen := int(original[0]&0x0f) * 4
+	if len(original) < headerLen+8 {
+		return false
+	}
+	protocol := original[9]
+	if !net.IP(original[16:20]).Equal(dst) {
+		return false
+	}
+	payload := original[headerLen:]
+
+	switch t.Method {
+	case models.TracerouteMethodUDP:
+		return protocol == protocolUDP && int(binary.BigEndian.Uint16(payload[2:4])) == t.Port+ttl
+	case models.TracerouteMethodTCP:
+		return protocol == protocolTCP &&
+			int(binary.BigEndian.Uint16(payload[0:2])) == t.sourcePort+ttl &&
+			int(binary.BigEndian.Uint16(payload[2:4])) == t.Port
+	default:
+		return protocol == protocolICMP && payload[0] == byte(ipv4.ICMPTypeEcho) &&
+			int(binary.BigEndian.Uint16(payload[4:6])) == t.id &&
+			int(binary.BigEndian.Uint16(payload[6:8])) == ttl
+	}
+}
+
+// resolveHostnames fills in reverse DNS names for all responding hops
+func resolveHostnames(ctx context.Context, hops []models.TracerouteHop) {
+	for i := range hops {
+		if hops[i].IP == nil {
+			continue
+		}
+		lookupCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+		names, err := net.DefaultResolver.LookupAddr(lookupCtx, *hops[i].IP)
+		cancel()
+		if err != nil || len(names) == 0 {
+			continue
+		}
+		hostname := strings.TrimSuffix(names[0], ".")
+		hops[i].Hostname = &hostname
+	}
+}
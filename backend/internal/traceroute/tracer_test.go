@@ -0,0 +1,145 @@
+package traceroute
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"reconya-ai/models"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+)
+
+// quote builds the original IPv4 header and first 8 bytes of a probe, as
+// carried by ICMP error messages
+func quote(protocol byte, dst net.IP, first8 []byte) []byte {
+	header := make([]byte, 20)
+	header[0] = 0x45
+	header[9] = protocol
+	copy(header[12:16], net.IPv4(192, 168, 1, 2).To4())
+	copy(header[16:20], dst.To4())
+	return append(header, first8...)
+}
+
+func ports(src, dst int) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint16(b[0:2], uint16(src))
+	binary.BigEndian.PutUint16(b[2:4], uint16(dst))
+	return b
+}
+
+func echo(id, seq int) []byte {
+	b := make([]byte, 8)
+	b[0] = byte(ipv4.ICMPTypeEcho)
+	binary.BigEndian.PutUint16(b[4:6], uint16(id))
+	binary.BigEndian.PutUint16(b[6:8], uint16(seq))
+	return b
+}
+
+func timeExceeded(data []byte) *icmp.Message {
+	return &icmp.Message{Type: ipv4.ICMPTypeTimeExceeded, Body: &icmp.TimeExceeded{Data: data}}
+}
+
+func TestTracer_Matches(t *testing.T) {
+	dst := net.ParseIP("10.0.0.1")
+	other := net.ParseIP("10.0.0.2")
+	const ttl = 5
+
+	icmpTracer := NewTracer(models.TracerouteMethodICMP, 30, time.Second)
+	udpTracer := NewTracer(models.TracerouteMethodUDP, 30, time.Second)
+	tcpTracer := NewTracer(models.TracerouteMethodTCP, 30, time.Second)
+	tcpTracer.sourcePort = 21000
+	id := icmpTracer.id
+
+	tests := []struct {
+		name   string
+		tracer *Tracer
+		msg    *icmp.Message
+		want   bool
+	}{
+		{"echo reply", icmpTracer, &icmp.Message{Type: ipv4.ICMPTypeEchoReply, Body: &icmp.Echo{ID: id, Seq: ttl}}, true},
+		{"echo reply with wrong id", icmpTracer, &icmp.Message{Type: ipv4.ICMPTypeEchoReply, Body: &icmp.Echo{ID: id + 1, Seq: ttl}}, false},
+		{"echo reply with wrong seq", icmpTracer, &icmp.Message{Type: ipv4.ICMPTypeEchoReply, Body: &icmp.Echo{ID: id, Seq: ttl - 1}}, false},
+		{"echo request", icmpTracer, &icmp.Message{Type: ipv4.ICMPTypeEcho, Body: &icmp.Echo{ID: id, Seq: ttl}}, false},
+		{"echo reply to a UDP trace", udpTracer, &icmp.Message{Type: ipv4.ICMPTypeEchoReply, Body: &icmp.Echo{ID: id, Seq: ttl}}, false},
+		{"ICMP quote", icmpTracer, timeExceeded(quote(protocolICMP, dst, echo(id, ttl))), true},
+		{"ICMP quote with wrong id", icmpTracer, timeExceeded(quote(protocolICMP, dst, echo(id+1, ttl))), false},
+		{"ICMP quote with wrong seq", icmpTracer, timeExceeded(quote(protocolICMP, dst, echo(id, ttl-1))), false},
+		{"ICMP quote of another target", icmpTracer, timeExceeded(quote(protocolICMP, other, echo(id, ttl))), false},
+		{"truncated quote", icmpTracer, timeExceeded(quote(protocolICMP, dst, nil)), false},
+		{"UDP quote", udpTracer, timeExceeded(quote(protocolUDP, dst, ports(40000, udpTracer.Port+ttl))), true},
+		{"UDP unreachable", udpTracer, &icmp.Message{Type: ipv4.ICMPTypeDestinationUnreachable, Body: &icmp.DstUnreach{Data: quote(protocolUDP, dst, ports(40000, udpTracer.Port+ttl))}}, true},
+		{"UDP quote with wrong port", udpTracer, timeExceeded(quote(protocolUDP, dst, ports(40000, udpTracer.Port+ttl-1))), false},
+		{"TCP quote in a UDP trace", udpTracer, timeExceeded(quote(protocolTCP, dst, ports(40000, udpTracer.Port+ttl))), false},
+		{"TCP quote", tcpTracer, timeExceeded(quote(protocolTCP, dst, ports(21000+ttl, 80))), true},
+		{"TCP quote of the previous TTL", tcpTracer, timeExceeded(quote(protocolTCP, dst, ports(21000+ttl-1, 80))), false},
+		{"TCP quote with wrong port", tcpTracer, timeExceeded(quote(protocolTCP, dst, ports(21000+ttl, 443))), false},
+		{"UDP quote in a TCP trace", tcpTracer, timeExceeded(quote(protocolUDP, dst, ports(21000+ttl, 80))), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.tracer.matches(tt.msg, dst, ttl); got != tt.want {
+				t.Errorf("matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNetworkTarget(t *testing.T) {
+	tests := []struct {
+		cidr    string
+		want    string
+		wantErr bool
+	}{
+		{"192.168.1.0/24", "192.168.1.1", false},
+		{"192.168.1.77/24", "192.168.1.1", false},
+		{"10.0.0.0/8", "10.0.0.1", false},
+		{"10.0.0.4/31", "10.0.0.4", false},
+		{"10.0.0.9/32", "10.0.0.9", false},
+		{"2001:db8::/64", "", true},
+		{"not a network", "", true},
+	}
+	for _, tt := range tests {
+		got, err := NetworkTarget(tt.cidr)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("NetworkTarget(%q) error = %v, wantErr %v", tt.cidr, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("NetworkTarget(%q) = %q, want %q", tt.cidr, got, tt.want)
+		}
+	}
+}
+
+func TestNewTracer_MaxHops(t *testing.T) {
+	tests := []struct {
+		maxHops int
+		want    int
+	}{
+		{-1, 1},
+		{0, 1},
+		{1, 1},
+		{30, 30},
+		{64, 64},
+		{65, 64},
+		{12000, 64},
+	}
+	for _, tt := range tests {
+		tracer := NewTracer(models.TracerouteMethodTCP, tt.maxHops, time.Second)
+		if tracer.MaxHops != tt.want {
+			t.Errorf("NewTracer(%d).MaxHops = %d, want %d", tt.maxHops, tracer.MaxHops, tt.want)
+		}
+	}
+
+	// A hop count set past the constructor is rejected before any probe or
+	// source port is picked
+	for _, maxHops := range []int{0, 12000} {
+		tracer := &Tracer{Method: models.TracerouteMethodTCP, MaxHops: maxHops}
+		if _, err := tracer.Trace(context.Background(), "192.0.2.1"); err == nil {
+			t.Errorf("Trace with MaxHops %d succeeded", maxHops)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package traceroute
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"reconya-ai/db"
+	"reconya-ai/internal/config"
+	"reconya-ai/internal/device"
+	"reconya-ai/internal/eventlog"
+	"reconya-ai/internal/exclusion"
+	"reconya-ai/internal/network"
+	"reconya-ai/models"
+)
+
+type TracerouteService struct {
+	repository      db.TracerouteRepository
+	networkService  *network.NetworkService
+	deviceService   *device.DeviceService
+	eventLogService *eventlog.EventLogService
+	tracer          *Tracer
+	// Exclusions keeps excluded devices and gateways from being traced; nil
+	// excludes nothing
+	Exclusions *exclusion.ExclusionService
+	// The raw ICMP listener sees every reply on the host, so runs are serialized
+	mu sync.Mutex
+}
+
+func NewTracerouteService(
+	repository db.TracerouteRepository,
+	networkService *network.NetworkService,
+	deviceService *device.DeviceService,
+	eventLogService *eventlog.EventLogService,
+	cfg *config.Config,
+) *TracerouteService {
+	method := models.TracerouteMethod(cfg.TracerouteMethod)
+	switch method {
+	case models.TracerouteMethodICMP, models.TracerouteMethodUDP, models.TracerouteMethodTCP:
+	default:
+		log.Printf("Unknown traceroute method %q, falling back to ICMP", cfg.TracerouteMethod)
+		method = models.TracerouteMethodICMP
+	}
+
+	return &TracerouteService{
+		repository:      repository,
+		networkService:  networkService,
+		deviceService:   deviceService,
+		eventLogService: eventLogService,
+		tracer:          NewTracer(method, cfg.TracerouteMaxHops, 2*time.Second),
+	}
+}
+
+// TraceNetwork discovers the path to a network. The first host of the CIDR is
+// used as target, which is usually the gateway of a routed network.
+func (s *TracerouteService) TraceNetwork(networkID string) (*models.TracerouteRun, error) {
+	network, err := s.networkService.FindByID(networkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find network: %v", err)
+	}
+	if network == nil {
+		return nil, fmt.Errorf("network not found")
+	}
+
+	target, err := NetworkTarget(network.CIDR)
+	if err != nil {
+		return nil, err
+	}
+	exclusions, err := s.Exclusions.ListFor(network.ID)
+	if err != nil {
+		return nil, err
+	}
+	if exclusions.ExcludesIP(target) {
+		return nil, fmt.Errorf("target %s is %w", target, exclusion.ErrExcluded)
+	}
+
+	run, err := s.trace(target)
+	if err != nil {
+		return nil, err
+	}
+	run.NetworkID = &network.ID
+
+	ctx := context.Background()
+	previous, err := s.repository.FindLatestByNetworkID(ctx, network.ID)
+	if err != nil && err != db.ErrNotFound {
+		return nil, fmt.Errorf("failed to load previous traceroute: %v", err)
+	}
+
+	if err := s.record(run, previous, fmt.Sprintf("network %s", network.CIDR), ""); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// TraceDevice discovers the path to a single device
+func (s *TracerouteService) TraceDevice(deviceID string) (*models.TracerouteRun, error) {
+	device, err := s.deviceService.FindByID(deviceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find device: %v", err)
+	}
+	if device == nil {
+		return nil, fmt.Errorf("device not found")
+	}
+	excluded, err := s.Exclusions.ExcludesDevice(device)
+	if err != nil {
+		return nil, err
+	}
+	if excluded {
+		return nil, exclusion.ErrExcluded
+	}
+
+	run, err := s.trace(device.IPv4)
+	if err != nil {
+		return nil, err
+	}
+	run.DeviceID = &device.ID
+	if device.NetworkID != "" {
+		run.NetworkID = &device.NetworkID
+	}
+
+	ctx := context.Background()
+	previous, err := s.repository.FindLatestByDeviceID(ctx, device.ID)
+	if err != nil && err != db.ErrNotFound {
+		return nil, fmt.Errorf("failed to load previous traceroute: %v", err)
+	}
+
+	if err := s.record(run, previous, fmt.Sprintf("device %s", device.IPv4), device.ID); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// RunScheduled traces every network and every selected device
+func (s *TracerouteService) RunScheduled() {
+	networks, err := s.networkService.FindAll()
+	if err != nil {
+		log.Printf("Traceroute: failed to load networks: %v", err)
+	}
+	for _, network := range networks {
+		if _, err := s.TraceNetwork(network.ID); err != nil {
+			log.Printf("Traceroute to network %s failed: %v", network.CIDR, err)
+		}
+	}
+
+	targets, err := s.repository.FindTargets(context.Background())
+	if err != nil {
+		log.Printf("Traceroute: failed to load device targets: %v", err)
+		return
+	}
+	for _, deviceID := range targets {
+		if _, err := s.TraceDevice(deviceID); err != nil {
+			log.Printf("Traceroute to device %s failed: %v", deviceID, err)
+		}
+	}
+}
+
+func (s *TracerouteService) trace(target string) (*models.TracerouteRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	run, err := s.tracer.Trace(ctx, target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to trace %s: %v", target, err)
+	}
+	return run, nil
+}
+
+// record flags path changes against the previous run and stores the new one
+func (s *TracerouteService) record(run, previous *models.TracerouteRun, label, deviceID string) error {
+	if previous != nil && !run.SamePath(previous) {
+		run.PathChanged = true
+		description := fmt.Sprintf("Path to %s changed: %s (was %s)", label, run.PathString(), previous.PathString())
+		payload := &models.PathChangedPayload{
+			Target:  run.Target,
+			OldHops: previous.HopAddresses(),
+			NewHops: run.HopAddresses(),
+		}
+		if run.NetworkID != nil {
+			payload.NetworkID = *run.NetworkID
+		}
+		if err := s.eventLogService.Log(models.PathChanged, description, deviceID, payload); err != nil {
+			log.Printf("Failed to log path change: %v", err)
+		}
+	}
+
+	if err := s.repository.Create(context.Background(), run); err != nil {
+		return fmt.Errorf("failed to save traceroute: %v", err)
+	}
+	return nil
+}
+
+// GetNetworkHistory returns the latest runs for a network
+func (s *TracerouteService) GetNetworkHistory(networkID string, limit int) ([]*models.TracerouteRun, error) {
+	return s.repository.FindByNetworkID(context.Background(), networkID, limit)
+}
+
+// GetDeviceHistory returns the latest runs for a device
+func (s *TracerouteService) GetDeviceHistory(deviceID string, limit int) ([]*models.TracerouteRun, error) {
+	return s.repository.FindByDeviceID(context.Background(), deviceID, limit)
+}
+
+// SetDeviceTarget selects or deselects a device for periodic traceroute
+func (s *TracerouteService) SetDeviceTarget(deviceID string, enabled bool) error {
+	ctx := context.Background()
+	if enabled {
+		return s.repository.AddTarget(ctx, deviceID)
+	}
+	return s.repository.RemoveTarget(ctx, deviceID)
+}
+
+// IsDeviceTarget reports whether a device is traced periodically
+func (s *TracerouteService) IsDeviceTarget(deviceID string) bool {
+	targets, err := s.repository.FindTargets(context.Background())
+	if err != nil {
+		log.Printf("Failed to load traceroute targets: %v", err)
+		return false
+	}
+	for _, id := range targets {
+		if id == deviceID {
+			return true
+		}
+	}
+	return false
+}
+
+// NetworkTarget returns the first usable host address of an IPv4 CIDR
+func NetworkTarget(cidr string) (string, error) {
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return "", fmt.Errorf("invalid network CIDR %s: %v", cidr, err)
+	}
+
+	ip := ipNet.IP.To4()
+	if ip == nil {
+		return "", fmt.Errorf("network %s is not an IPv4 network", cidr)
+	}
+
+	target := make(net.IP, len(ip))
+	copy(target, ip)
+	if ones, bits := ipNet.Mask.Size(); bits-ones > 1 {
+		target[3]++
+	}
+	return target.String(), nil
+}
@@ -0,0 +1,9 @@
+//go:build !windows
+
+package traceroute
+
+import "syscall"
+
+func setSocketTTL(fd uintptr, ttl int) error {
+	return syscall.SetsockoptInt(int(fd), syscall.IPPROTO_IP, syscall.IP_TTL, ttl)
+}
@@ -0,0 +1,91 @@
+package user
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"reconya-ai/db"
+	"reconya-ai/internal/config"
+	"reconya-ai/models"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// MinPasswordLength is the shortest password accepted for new users
+const MinPasswordLength = 8
+
+// ErrUserExists is returned when creating a user whose username is taken
+var ErrUserExists = errors.New("user already exists")
+
+// UserService manages login accounts. The account from LOGIN_USERNAME and
+// LOGIN_PASSWORD always works so existing installs keep their login.
+type UserService struct {
+	repo   db.UserRepository
+	config *config.Config
+}
+
+// NewUserService creates a new user service
+func NewUserService(repo db.UserRepository, cfg *config.Config) *UserService {
+	return &UserService{
+		repo:   repo,
+		config: cfg,
+	}
+}
+
+// Create adds a user with a bcrypt hash of the password
+func (s *UserService) Create(username, password string) (*models.User, error) {
+	username = strings.TrimSpace(username)
+	if username == "" {
+		return nil, fmt.Errorf("username is required")
+	}
+	if len(password) < MinPasswordLength {
+		return nil, fmt.Errorf("password must be at least %d characters", MinPasswordLength)
+	}
+
+	existing, err := s.repo.FindByUsername(context.Background(), username)
+	if err != nil && err != db.ErrNotFound {
+		return nil, err
+	}
+	if existing != nil || (s.config != nil && username == s.config.Username) {
+		return nil, ErrUserExists
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %v", err)
+	}
+
+	user := &models.User{Username: username, Password: string(hash)}
+	if err := s.repo.Create(context.Background(), user); err != nil {
+		return nil, err
+	}
+	user.Password = ""
+	return user, nil
+}
+
+// FindAll returns the users stored in the database
+func (s *UserService) FindAll() ([]*models.User, error) {
+	return s.repo.FindAll(context.Background())
+}
+
+// Authenticate reports whether the username and password belong to a user
+func (s *UserService) Authenticate(username, password string) bool {
+	user, err := s.repo.FindByUsername(context.Background(), username)
+	if err == nil {
+		return bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) == nil
+	}
+	if err != db.ErrNotFound {
+		log.Printf("Error looking up user %s: %v", username, err)
+	}
+
+	if s.config == nil || s.config.Username == "" {
+		return false
+	}
+	usernameMatch := subtle.ConstantTimeCompare([]byte(username), []byte(s.config.Username))
+	passwordMatch := subtle.ConstantTimeCompare([]byte(password), []byte(s.config.Password))
+	return usernameMatch&passwordMatch == 1
+}
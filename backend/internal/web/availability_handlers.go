@@ -0,0 +1,151 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"reconya-ai/db"
+	"reconya-ai/internal/availability"
+	"reconya-ai/models"
+
+	"github.com/gorilla/mux"
+)
+
+// AvailabilityRow is a device row of the availability report page
+type AvailabilityRow struct {
+	DeviceID   string
+	Name       string
+	IPv4       string
+	Status     models.DeviceStatus
+	Percents   []string
+	Outages    int
+	LastOutage string
+	MTBF       string
+}
+
+func (h *WebHandler) APIDeviceAvailability(w http.ResponseWriter, r *http.Request) {
+	session, _ := h.sessionStore.Get(r, "reconya-session")
+	user := h.getUserFromSession(session)
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	deviceID := mux.Vars(r)["id"]
+	report, err := h.availabilityService.GetDeviceAvailability(deviceID)
+	if err == db.ErrNotFound {
+		http.Error(w, "Device not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to compute availability for device %s: %v", deviceID, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+func (h *WebHandler) APIAvailability(w http.ResponseWriter, r *http.Request) {
+	session, _ := h.sessionStore.Get(r, "reconya-session")
+	user := h.getUserFromSession(session)
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	reports, err := h.availabilityService.GetReport()
+	if err != nil {
+		log.Printf("Failed to compute availability report: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reports)
+}
+
+func (h *WebHandler) APIAvailabilityReport(w http.ResponseWriter, r *http.Request) {
+	session, _ := h.sessionStore.Get(r, "reconya-session")
+	user := h.getUserFromSession(session)
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	reports, err := h.availabilityService.GetReport()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	windows := make([]string, 0, len(availability.Windows))
+	for _, window := range availability.Windows {
+		windows = append(windows, window.Name)
+	}
+
+	rows := make([]AvailabilityRow, 0, len(reports))
+	for _, report := range reports {
+		row := AvailabilityRow{
+			DeviceID:   report.DeviceID,
+			Name:       report.DeviceName,
+			IPv4:       report.IPv4,
+			Status:     report.Status,
+			Outages:    len(report.Outages),
+			LastOutage: "-",
+			MTBF:       "-",
+		}
+		for _, window := range report.Windows {
+			row.Percents = append(row.Percents, formatPercent(window.Percent))
+		}
+		if len(report.Outages) > 0 {
+			row.LastOutage = report.Outages[0].StartedAt.Format("2006-01-02 15:04:05")
+		}
+		if report.MTBFSeconds != nil {
+			row.MTBF = formatSeconds(*report.MTBFSeconds)
+		}
+		rows = append(rows, row)
+	}
+
+	data := struct {
+		Windows []string
+		Rows    []AvailabilityRow
+	}{
+		Windows: windows,
+		Rows:    rows,
+	}
+
+	if err := h.templates.ExecuteTemplate(w, "components/availability-report.html", data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func formatPercent(percent *float64) string {
+	if percent == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f%%", *percent)
+}
+
+// formatSeconds renders a duration with its two most significant units
+func formatSeconds(seconds float64) string {
+	d := time.Duration(seconds) * time.Second
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+	minutes := int(d.Minutes()) % 60
+
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	case minutes > 0:
+		return fmt.Sprintf("%dm", minutes)
+	default:
+		return fmt.Sprintf("%ds", int(seconds))
+	}
+}
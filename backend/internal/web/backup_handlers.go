@@ -0,0 +1,132 @@
+package web
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"reconya-ai/internal/backup"
+
+	"github.com/gorilla/mux"
+)
+
+// maxBackupUploadSize limits uploaded backups for restore
+const maxBackupUploadSize = 1 << 30
+
+func (h *WebHandler) APIBackups(w http.ResponseWriter, r *http.Request) {
+	session, _ := h.sessionStore.Get(r, "reconya-session")
+	user := h.getUserFromSession(session)
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	backups, err := h.backupService.List()
+	if err != nil {
+		log.Printf("Failed to list backups: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(backups)
+}
+
+// APICreateBackup takes an online backup and applies the retention policy
+func (h *WebHandler) APICreateBackup(w http.ResponseWriter, r *http.Request) {
+	session, _ := h.sessionStore.Get(r, "reconya-session")
+	user := h.getUserFromSession(session)
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	backup, err := h.backupService.Create()
+	if err != nil {
+		log.Printf("Failed to create backup: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if _, err := h.backupService.Prune(); err != nil {
+		log.Printf("Failed to prune backups: %v", err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(backup)
+}
+
+// APIRestoreBackup restores the database from a stored backup named by the
+// "name" form value, or from a backup uploaded as the "file" multipart field
+func (h *WebHandler) APIRestoreBackup(w http.ResponseWriter, r *http.Request) {
+	session, _ := h.sessionStore.Get(r, "reconya-session")
+	user := h.getUserFromSession(session)
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if h.scanManager.IsRunning() {
+		http.Error(w, "Stop the running scan before restoring a backup", http.StatusConflict)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxBackupUploadSize)
+
+	var name string
+	var result *backup.RestoreResult
+	var err error
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, header, formErr := r.FormFile("file")
+		if formErr != nil {
+			http.Error(w, "Missing backup file", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		data, readErr := io.ReadAll(file)
+		if readErr != nil {
+			http.Error(w, "Failed to read backup file", http.StatusBadRequest)
+			return
+		}
+		name = filepath.Base(header.Filename)
+		result, err = h.backupService.RestoreData(name, data)
+	} else {
+		name = r.FormValue("name")
+		path, pathErr := h.backupService.Path(name)
+		if pathErr != nil {
+			http.Error(w, "Backup not found", http.StatusNotFound)
+			return
+		}
+		result, err = h.backupService.Restore(path)
+	}
+	if err != nil {
+		log.Printf("Failed to restore backup %s: %v", name, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	log.Printf("Database restored from %s; previous database saved as %s", name, result.SafetyBackup)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+func (h *WebHandler) APIBackupFile(w http.ResponseWriter, r *http.Request) {
+	session, _ := h.sessionStore.Get(r, "reconya-session")
+	user := h.getUserFromSession(session)
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	name := filepath.Base(mux.Vars(r)["name"])
+	path, err := h.backupService.Path(name)
+	if err != nil {
+		http.Error(w, "Backup not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", "attachment; filename=\""+name+"\"")
+	http.ServeFile(w, r, path)
+}
@@ -0,0 +1,180 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"reconya-ai/internal/eventlog"
+	"reconya-ai/internal/export"
+	"reconya-ai/internal/report"
+	"reconya-ai/models"
+)
+
+// parseEventLogFilter reads the event log filter from the query string:
+// type and severity (repeatable or comma separated), device (ID), network (ID
+// or CIDR), since and until (RFC 3339, a date, or an age such as "24h" or
+// "7d") and q, a full-text search on the description.
+func (h *WebHandler) parseEventLogFilter(query url.Values, now time.Time) (models.EventLogFilter, error) {
+	filter := models.EventLogFilter{
+		DeviceID: strings.TrimSpace(query.Get("device")),
+		Query:    strings.TrimSpace(query.Get("q")),
+	}
+
+	for _, value := range listParam(query, "type") {
+		filter.Types = append(filter.Types, models.EEventLogType(value))
+	}
+	for _, value := range listParam(query, "severity") {
+		severity, err := models.ParseEventSeverity(value)
+		if err != nil {
+			return filter, err
+		}
+		filter.Severities = append(filter.Severities, severity)
+	}
+
+	networkID, err := h.exportService.ResolveNetwork(strings.TrimSpace(query.Get("network")))
+	if err != nil {
+		return filter, err
+	}
+	filter.NetworkID = networkID
+
+	if filter.Since, err = parseEventTime(query.Get("since"), now); err != nil {
+		return filter, err
+	}
+	if filter.Until, err = parseEventTime(query.Get("until"), now); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+// listParam collects a parameter given several times or as a comma separated list
+func listParam(query url.Values, name string) []string {
+	var values []string
+	for _, value := range query[name] {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				values = append(values, item)
+			}
+		}
+	}
+	return values
+}
+
+// parseEventTime parses an RFC 3339 timestamp, a local date or an age
+func parseEventTime(value string, now time.Time) (*time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return &t, nil
+	}
+	if age, err := report.ParsePeriod(value); err == nil {
+		t := now.Add(-age)
+		return &t, nil
+	}
+	return nil, fmt.Errorf("invalid time %q (want RFC 3339, YYYY-MM-DD or an age such as 24h or 7d)", value)
+}
+
+// APIEventLogSearch returns one page of filtered event logs as JSON. Besides
+// the filter parameters it accepts limit and cursor, the next_cursor of the
+// previous page.
+func (h *WebHandler) APIEventLogSearch(w http.ResponseWriter, r *http.Request) {
+	session, _ := h.sessionStore.Get(r, "reconya-session")
+	user := h.getUserFromSession(session)
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	filter, err := h.parseEventLogFilter(query, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	limit := 0
+	if value := query.Get("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit <= 0 {
+			http.Error(w, fmt.Sprintf("invalid limit %q", value), http.StatusBadRequest)
+			return
+		}
+	}
+
+	page, err := h.eventLogService.Search(filter, query.Get("cursor"), limit)
+	if err == models.ErrInvalidCursor {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to search event logs: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+// APIEventLogSchemas returns the JSON Schema of each event type's payload
+func (h *WebHandler) APIEventLogSchemas(w http.ResponseWriter, r *http.Request) {
+	session, _ := h.sessionStore.Get(r, "reconya-session")
+	user := h.getUserFromSession(session)
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.EventPayloadSchemas())
+}
+
+// APIEventLogExport streams every event log matching the filter.
+// Query parameters: format (csv, ndjson) and the filter of APIEventLogSearch.
+func (h *WebHandler) APIEventLogExport(w http.ResponseWriter, r *http.Request) {
+	session, _ := h.sessionStore.Get(r, "reconya-session")
+	user := h.getUserFromSession(session)
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+
+	formatName := query.Get("format")
+	if formatName == "" {
+		formatName = string(export.FormatCSV)
+	}
+	format, err := export.ParseFormat(formatName)
+	if err == nil && format != export.FormatCSV && format != export.FormatNDJSON {
+		err = fmt.Errorf("event logs can be exported as csv or ndjson, not %s", format)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	filter, err := h.parseEventLogFilter(query, now)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", "attachment; filename=\""+eventlog.ExportFilename(format, now)+"\"")
+
+	if _, err := h.eventLogService.Export(w, format, filter); err != nil {
+		// Headers are already sent, so the client sees a truncated file
+		log.Printf("Failed to write %s event log export: %v", format, err)
+	}
+}
@@ -0,0 +1,112 @@
+package web
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"reconya-ai/db"
+	"reconya-ai/models"
+
+	"github.com/gorilla/mux"
+)
+
+// ExclusionRequest adds an exclusion; an empty network ID applies it to all
+// networks
+type ExclusionRequest struct {
+	NetworkID string `json:"network_id"`
+	Value     string `json:"value"`
+	Comment   string `json:"comment"`
+}
+
+func (h *WebHandler) APIExclusions(w http.ResponseWriter, r *http.Request) {
+	session, _ := h.sessionStore.Get(r, "reconya-session")
+	user := h.getUserFromSession(session)
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	exclusions, err := h.exclusionService.GetAll()
+	if err != nil {
+		log.Printf("Failed to load exclusions: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// ?network_id= lists the exclusions that apply to a network, global
+	// ones included
+	if r.URL.Query().Has("network_id") {
+		networkID := r.URL.Query().Get("network_id")
+		applicable := exclusions[:0]
+		for _, e := range exclusions {
+			if e.NetworkID == "" || e.NetworkID == networkID {
+				applicable = append(applicable, e)
+			}
+		}
+		exclusions = applicable
+	}
+	if exclusions == nil {
+		exclusions = []*models.Exclusion{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(exclusions)
+}
+
+func (h *WebHandler) APICreateExclusion(w http.ResponseWriter, r *http.Request) {
+	session, _ := h.sessionStore.Get(r, "reconya-session")
+	user := h.getUserFromSession(session)
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req ExclusionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	req.NetworkID = strings.TrimSpace(req.NetworkID)
+	if req.NetworkID != "" {
+		network, err := h.networkService.FindByID(req.NetworkID)
+		if err != nil || network == nil {
+			http.Error(w, "Network not found", http.StatusBadRequest)
+			return
+		}
+	}
+
+	exclusion, err := h.exclusionService.Add(req.NetworkID, req.Value, req.Comment)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(exclusion)
+}
+
+func (h *WebHandler) APIDeleteExclusion(w http.ResponseWriter, r *http.Request) {
+	session, _ := h.sessionStore.Get(r, "reconya-session")
+	user := h.getUserFromSession(session)
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	err := h.exclusionService.Delete(mux.Vars(r)["id"])
+	if err == db.ErrNotFound {
+		http.Error(w, "Exclusion not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to delete exclusion: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
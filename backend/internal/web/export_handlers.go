@@ -0,0 +1,76 @@
+package web
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"reconya-ai/internal/export"
+	"reconya-ai/models"
+)
+
+// APIExport streams the device inventory.
+// Query parameters: format (csv, json, ndjson, xlsx, cyclonedx), network (ID or CIDR),
+// tag, status and rows (devices, ports, web_services; CSV only).
+func (h *WebHandler) APIExport(w http.ResponseWriter, r *http.Request) {
+	session, _ := h.sessionStore.Get(r, "reconya-session")
+	user := h.getUserFromSession(session)
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+
+	formatName := query.Get("format")
+	if formatName == "" {
+		formatName = string(export.FormatCSV)
+	}
+	format, err := export.ParseFormat(formatName)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rows, err := export.ParseRows(query.Get("rows"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	networkID, err := h.exportService.ResolveNetwork(query.Get("network"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filter := export.Filter{
+		NetworkID: networkID,
+		Tag:       query.Get("tag"),
+		Status:    models.DeviceStatus(query.Get("status")),
+	}
+
+	devices, err := h.exportService.Devices(filter)
+	if err != nil {
+		log.Printf("Failed to load devices for export: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	networks, err := h.exportService.Networks()
+	if err != nil {
+		log.Printf("Failed to load networks for export: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", "attachment; filename=\""+export.Filename(format, now)+"\"")
+
+	opts := export.Options{Rows: rows, GeneratedAt: now, Networks: networks}
+	if err := export.Write(w, format, devices, opts); err != nil {
+		// Headers are already sent, so the client sees a truncated file
+		log.Printf("Failed to write %s export: %v", format, err)
+	}
+}
@@ -9,20 +9,37 @@ import (
 	"math"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	"reconya-ai/db"
+	"reconya-ai/internal/availability"
+	"reconya-ai/internal/backup"
 	"reconya-ai/internal/config"
 	"reconya-ai/internal/device"
+	"reconya-ai/internal/devicestatus"
 	"reconya-ai/internal/eventlog"
+	"reconya-ai/internal/exclusion"
+	"reconya-ai/internal/export"
+	"reconya-ai/internal/importer"
+	"reconya-ai/internal/infraserver"
+	"reconya-ai/internal/latency"
 	"reconya-ai/internal/network"
 	"reconya-ai/internal/nicidentifier"
+	"reconya-ai/internal/ratelimit"
+	"reconya-ai/internal/report"
+	"reconya-ai/internal/retention"
 	"reconya-ai/internal/scan"
+	"reconya-ai/internal/scanpolicy"
 	"reconya-ai/internal/settings"
 	"reconya-ai/internal/systemstatus"
+	"reconya-ai/internal/traceroute"
+	"reconya-ai/internal/user"
+	"reconya-ai/internal/wol"
 	"reconya-ai/models"
 
 	"github.com/gorilla/mux"
@@ -41,6 +58,21 @@ type WebHandler struct {
 	geolocationRepository *db.GeolocationRepository
 	settingsService       *settings.SettingsService
 	nicIdentifierService  *nicidentifier.NicIdentifierService
+	tracerouteService     *traceroute.TracerouteService
+	latencyService        *latency.LatencyService
+	availabilityService   *availability.AvailabilityService
+	statusEngine          *devicestatus.StatusEngine
+	reportService         *report.ReportService
+	exportService         *export.ExportService
+	importService         *importer.ImportService
+	userService           *user.UserService
+	migrator              *db.Migrator
+	backupService         *backup.BackupService
+	retentionService      *retention.RetentionService
+	infraServerService    *infraserver.InfraServerService
+	wakeService           *wol.WakeService
+	scanPolicyService     *scanpolicy.ScanPolicyService
+	exclusionService      *exclusion.ExclusionService
 	templates             *template.Template
 	sessionStore          *sessions.CookieStore
 	config                *config.Config
@@ -90,6 +122,21 @@ func NewWebHandler(
 	geolocationRepository *db.GeolocationRepository,
 	settingsService *settings.SettingsService,
 	nicIdentifierService *nicidentifier.NicIdentifierService,
+	tracerouteService *traceroute.TracerouteService,
+	latencyService *latency.LatencyService,
+	availabilityService *availability.AvailabilityService,
+	statusEngine *devicestatus.StatusEngine,
+	reportService *report.ReportService,
+	exportService *export.ExportService,
+	importService *importer.ImportService,
+	userService *user.UserService,
+	migrator *db.Migrator,
+	backupService *backup.BackupService,
+	retentionService *retention.RetentionService,
+	infraServerService *infraserver.InfraServerService,
+	wakeService *wol.WakeService,
+	scanPolicyService *scanpolicy.ScanPolicyService,
+	exclusionService *exclusion.ExclusionService,
 	config *config.Config,
 	sessionSecret string,
 ) *WebHandler {
@@ -421,6 +468,21 @@ func NewWebHandler(
 		geolocationRepository: geolocationRepository,
 		settingsService:       settingsService,
 		nicIdentifierService:  nicIdentifierService,
+		tracerouteService:     tracerouteService,
+		latencyService:        latencyService,
+		availabilityService:   availabilityService,
+		statusEngine:          statusEngine,
+		reportService:         reportService,
+		exportService:         exportService,
+		importService:         importService,
+		userService:           userService,
+		migrator:              migrator,
+		backupService:         backupService,
+		retentionService:      retentionService,
+		infraServerService:    infraServerService,
+		wakeService:           wakeService,
+		scanPolicyService:     scanPolicyService,
+		exclusionService:      exclusionService,
 		templates:             tmpl,
 		sessionStore:          store,
 		config:                config,
@@ -784,6 +846,16 @@ func (h *WebHandler) APIUpdateDevice(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Tags are only replaced when the form includes them
+	if _, ok := r.Form["tags"]; ok {
+		device, err = h.deviceService.UpdateTags(deviceID, strings.Split(r.FormValue("tags"), ","))
+		if err != nil {
+			log.Printf("Failed to update tags of device %s: %v", deviceID, err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
 	log.Printf("Successfully updated device %s", deviceID)
 
 	// Get user's screenshot setting to match APIDeviceModal template data structure
@@ -841,7 +913,15 @@ func (h *WebHandler) APIDeleteDevice(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Log the event
-	h.eventLogService.Log(models.DeviceDeleted, fmt.Sprintf("Device %s deleted", device.IPv4), "")
+	payload := &models.DevicePayload{
+		EventMeta: models.EventMeta{Actor: models.UserActor(user.Username)},
+		IPv4:      device.IPv4,
+		Name:      device.Name,
+	}
+	if device.MAC != nil {
+		payload.MAC = *device.MAC
+	}
+	h.eventLogService.Log(models.DeviceDeleted, fmt.Sprintf("Device %s deleted", device.IPv4), "", payload)
 
 	log.Printf("Successfully deleted device %s (%s)", device.IPv4, deviceID)
 
@@ -996,23 +1076,60 @@ func (h *WebHandler) APIEventLogsTable(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get more event logs for the table view (100 instead of 20)
-	eventLogSlice, err := h.eventLogService.GetAll(100)
+	// The table takes the filter parameters of /api/event-logs/search
+	query := r.URL.Query()
+	filter, err := h.parseEventLogFilter(query, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := h.eventLogService.Search(filter, query.Get("cursor"), eventlog.DefaultPageSize)
+	if err == models.ErrInvalidCursor {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// Convert to pointer slice
-	eventLogs := make([]*models.EventLog, len(eventLogSlice))
-	for i := range eventLogSlice {
-		eventLogs[i] = &eventLogSlice[i]
+	filterQuery := url.Values{}
+	for _, name := range []string{"q", "type", "severity", "device", "network", "since", "until"} {
+		if value := query.Get(name); value != "" {
+			filterQuery.Set(name, value)
+		}
 	}
 
 	data := struct {
 		EventLogs []*models.EventLog
+		Types     []models.EEventLogType
+		Query     string
+		Type      string
+		Severity  string
+		Since     string
+		FirstPage bool
+		NextURL   template.URL
+		CSVURL    template.URL
+		NDJSONURL template.URL
 	}{
-		EventLogs: eventLogs,
+		EventLogs: page.EventLogs,
+		Types:     models.EventLogTypes,
+		Query:     query.Get("q"),
+		Type:      query.Get("type"),
+		Severity:  query.Get("severity"),
+		Since:     query.Get("since"),
+		FirstPage: query.Get("cursor") == "",
+		CSVURL:    template.URL("/api/event-logs/export?format=csv&" + filterQuery.Encode()),
+		NDJSONURL: template.URL("/api/event-logs/export?format=ndjson&" + filterQuery.Encode()),
+	}
+	if page.NextCursor != "" {
+		next := url.Values{}
+		for name, values := range filterQuery {
+			next[name] = values
+		}
+		next.Set("cursor", page.NextCursor)
+		data.NextURL = template.URL("/api/event-logs-table?" + next.Encode())
 	}
 
 	if err := h.templates.ExecuteTemplate(w, "components/event-logs-table.html", data); err != nil {
@@ -1066,9 +1183,76 @@ func (h *WebHandler) getUserFromSession(session *sessions.Session) *models.User
 	return nil
 }
 
+// parseNetworkScanLimits reads the rate_limit and scan_windows fields of a
+// network form; an empty rate limit falls back to the global one
+func parseNetworkScanLimits(r *http.Request) (*int, string, error) {
+	windows := strings.TrimSpace(r.FormValue("scan_windows"))
+	value := strings.TrimSpace(r.FormValue("rate_limit"))
+	if value == "" {
+		return nil, windows, nil
+	}
+	rate, err := strconv.Atoi(value)
+	if err != nil || rate <= 0 {
+		return nil, windows, fmt.Errorf("Rate limit must be a positive number of packets per second")
+	}
+	return &rate, windows, nil
+}
+
+// parseNetworkAddressing reads the address_family and ipv6_prefix fields of a
+// network form and checks them with the CIDR. IPv6-only networks may leave
+// the CIDR empty; it is then set to the IPv6 prefix.
+func parseNetworkAddressing(r *http.Request, cidr *string) (models.AddressFamily, string, error) {
+	family := models.AddressFamily(strings.TrimSpace(r.FormValue("address_family")))
+	switch family {
+	case "":
+		family = models.AddressFamilyIPv4
+	case models.AddressFamilyIPv4, models.AddressFamilyIPv6, models.AddressFamilyDual:
+	default:
+		return models.AddressFamilyIPv4, "", fmt.Errorf("unknown address family %q", family)
+	}
+
+	ipv6Prefix := strings.TrimSpace(r.FormValue("ipv6_prefix"))
+	if family == models.AddressFamilyIPv4 {
+		ipv6Prefix = ""
+	} else {
+		if ipv6Prefix == "" {
+			return family, "", fmt.Errorf("IPv6 prefix is required for IPv6 and dual-stack networks")
+		}
+		ip, prefixNet, err := net.ParseCIDR(ipv6Prefix)
+		if err != nil || ip.To4() != nil {
+			return family, ipv6Prefix, fmt.Errorf("Invalid IPv6 prefix. Please use format like 2001:db8:1::/64")
+		}
+		ipv6Prefix = prefixNet.String()
+	}
+
+	if family == models.AddressFamilyIPv6 {
+		if *cidr == "" {
+			*cidr = ipv6Prefix
+		}
+		return family, ipv6Prefix, nil
+	}
+
+	if *cidr == "" {
+		return family, ipv6Prefix, fmt.Errorf("CIDR address is required")
+	}
+	if ip, _, err := net.ParseCIDR(*cidr); err != nil || ip.To4() == nil {
+		return family, ipv6Prefix, fmt.Errorf("Invalid CIDR format. Please use format like 192.168.1.0/24")
+	}
+	return family, ipv6Prefix, nil
+}
+
+// networkPayload is the payload of a network event made by user
+func networkPayload(network *models.Network, user *models.User) *models.NetworkPayload {
+	return &models.NetworkPayload{
+		EventMeta: models.EventMeta{Actor: models.UserActor(user.Username)},
+		NetworkID: network.ID,
+		CIDR:      network.CIDR,
+		Name:      network.Name,
+	}
+}
+
 func (h *WebHandler) authenticate(username, password string) bool {
-	// Simple authentication - replace with your logic
-	return username == "admin" && password == "password"
+	return h.userService.Authenticate(username, password)
 }
 
 func (h *WebHandler) buildNetworkMap(devices []*models.Device) *NetworkMapData {
@@ -1426,19 +1610,29 @@ func (h *WebHandler) APICreateNetwork(w http.ResponseWriter, r *http.Request) {
 		},
 	}
 
-	// Validate CIDR
-	if cidr == "" {
-		data.Error = "CIDR address is required"
+	// Validate CIDR and IPv6 prefix
+	family, ipv6Prefix, err := parseNetworkAddressing(r, &cidr)
+	data.Network.AddressFamily = family
+	if ipv6Prefix != "" {
+		data.Network.IPv6Prefix = &ipv6Prefix
+	}
+	if err != nil {
+		data.Error = err.Error()
 		if err := h.templates.ExecuteTemplate(w, "components/network-modal.html", data); err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 		}
 		return
 	}
 
-	// Validate CIDR format
-	_, _, err := net.ParseCIDR(cidr)
+	// Validate scan limits
+	rateLimit, scanWindows, err := parseNetworkScanLimits(r)
+	data.Network.RateLimit = rateLimit
+	data.Network.ScanWindows = scanWindows
+	if err == nil {
+		_, err = ratelimit.ParseSchedule(scanWindows)
+	}
 	if err != nil {
-		data.Error = "Invalid CIDR format. Please use format like 192.168.1.0/24"
+		data.Error = err.Error()
 		if err := h.templates.ExecuteTemplate(w, "components/network-modal.html", data); err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 		}
@@ -1458,8 +1652,25 @@ func (h *WebHandler) APICreateNetwork(w http.ResponseWriter, r *http.Request) {
 	}
 	log.Printf("APICreateNetwork: Network created successfully: ID=%s, CIDR=%s", network.ID, network.CIDR)
 
+	if family != models.AddressFamilyIPv4 {
+		network, err = h.networkService.SetAddressing(network.ID, family, ipv6Prefix)
+		if err != nil {
+			log.Printf("APICreateNetwork: Error setting IPv6 prefix: %v", err)
+			http.Error(w, fmt.Sprintf("Failed to set IPv6 prefix: %v", err), http.StatusInternalServerError)
+			return
+		}
+	}
+	if rateLimit != nil || scanWindows != "" {
+		network, err = h.networkService.SetScanLimits(network.ID, rateLimit, scanWindows)
+		if err != nil {
+			log.Printf("APICreateNetwork: Error setting scan limits: %v", err)
+			http.Error(w, fmt.Sprintf("Failed to set scan limits: %v", err), http.StatusInternalServerError)
+			return
+		}
+	}
+
 	// Log the event
-	h.eventLogService.Log(models.NetworkCreated, fmt.Sprintf("Network %s (%s) created", network.CIDR, network.Name), "")
+	h.eventLogService.Log(models.NetworkCreated, fmt.Sprintf("Network %s (%s) created", network.CIDR, network.Name), "", networkPayload(network, user))
 
 	// Return success indicator that will trigger the frontend to handle the response
 	w.Header().Set("HX-Trigger", "network-saved")
@@ -1488,28 +1699,39 @@ func (h *WebHandler) APIUpdateNetwork(w http.ResponseWriter, r *http.Request) {
 	cidr := strings.TrimSpace(r.FormValue("cidr"))
 	description := strings.TrimSpace(r.FormValue("description"))
 
-	// Validate CIDR
-	if cidr == "" {
-		http.Error(w, "CIDR address is required", http.StatusBadRequest)
+	// Validate CIDR and IPv6 prefix
+	family, ipv6Prefix, err := parseNetworkAddressing(r, &cidr)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-
-	// Validate CIDR format
-	_, _, err := net.ParseCIDR(cidr)
+	rateLimit, scanWindows, err := parseNetworkScanLimits(r)
+	if err == nil {
+		_, err = ratelimit.ParseSchedule(scanWindows)
+	}
 	if err != nil {
-		http.Error(w, "Invalid CIDR format. Please use format like 192.168.1.0/24", http.StatusBadRequest)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	// Update network
-	network, err := h.networkService.Update(networkID, name, cidr, description)
+	_, err = h.networkService.Update(networkID, name, cidr, description)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to update network: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if _, err = h.networkService.SetAddressing(networkID, family, ipv6Prefix); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to update network: %v", err), http.StatusInternalServerError)
+		return
+	}
+	network, err := h.networkService.SetScanLimits(networkID, rateLimit, scanWindows)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to update network: %v", err), http.StatusInternalServerError)
 		return
 	}
 
 	// Log the event
-	h.eventLogService.Log(models.NetworkUpdated, fmt.Sprintf("Network %s (%s) updated", network.CIDR, network.Name), "")
+	h.eventLogService.Log(models.NetworkUpdated, fmt.Sprintf("Network %s (%s) updated", network.CIDR, network.Name), "", networkPayload(network, user))
 
 	// Return success indicator that will trigger the frontend to handle the response
 	w.Header().Set("HX-Trigger", "network-saved")
@@ -1576,7 +1798,7 @@ func (h *WebHandler) APIDeleteNetwork(w http.ResponseWriter, r *http.Request) {
 
 	// Log the event
 	if network != nil {
-		h.eventLogService.Log(models.NetworkDeleted, fmt.Sprintf("Network %s (%s) deleted", network.CIDR, network.Name), "")
+		h.eventLogService.Log(models.NetworkDeleted, fmt.Sprintf("Network %s (%s) deleted", network.CIDR, network.Name), "", networkPayload(network, user))
 	}
 
 	// Return empty response to remove the table row
@@ -1711,7 +1933,9 @@ func (h *WebHandler) APIForceDeleteNetwork(w http.ResponseWriter, r *http.Reques
 		if deviceCount > 0 {
 			message += fmt.Sprintf(" along with %d device(s)", deviceCount)
 		}
-		h.eventLogService.Log(models.NetworkDeleted, message, "")
+		payload := networkPayload(network, user)
+		payload.DevicesDeleted = &deviceCount
+		h.eventLogService.Log(models.NetworkDeleted, message, "", payload)
 	}
 
 	// Return success response
@@ -1861,6 +2085,7 @@ func (h *WebHandler) APIScanStop(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	state := h.scanManager.GetState()
 	err := h.scanManager.StopScan()
 	if err != nil {
 		if scanErr, ok := err.(*scan.ScanError); ok {
@@ -1877,7 +2102,15 @@ func (h *WebHandler) APIScanStop(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Log the event
-	h.eventLogService.Log(models.ScanStopped, "Network scan stopped", "")
+	payload := &models.ScanPayload{
+		EventMeta: models.EventMeta{Actor: models.UserActor(user.Username)},
+		RunID:     state.RunID,
+	}
+	if state.CurrentNetwork != nil {
+		payload.NetworkID = state.CurrentNetwork.ID
+		payload.CIDR = state.CurrentNetwork.CIDR
+	}
+	h.eventLogService.Log(models.ScanStopped, "Network scan stopped", "", payload)
 
 	// Return updated scan control component
 	h.APIScanControl(w, r)
@@ -2384,10 +2617,18 @@ func (h *WebHandler) APISettings(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	networks, err := h.networkService.FindAll()
+	if err != nil {
+		log.Printf("Error getting networks for settings: %v", err)
+		networks = []models.Network{}
+	}
+
 	data := struct {
 		Settings *models.Settings
+		Networks []models.Network
 	}{
 		Settings: settings,
+		Networks: networks,
 	}
 
 	if err := h.templates.ExecuteTemplate(w, "components/settings.html", data); err != nil {
@@ -2484,7 +2725,7 @@ func (h *WebHandler) APINetworkSuggestion(w http.ResponseWriter, r *http.Request
 	}
 
 	// Log the event
-	h.eventLogService.Log(models.NetworkCreated, fmt.Sprintf("Network %s created from suggestion", cidr), "")
+	h.eventLogService.Log(models.NetworkCreated, fmt.Sprintf("Network %s created from suggestion", cidr), "", networkPayload(network, user))
 
 	log.Printf("Created network from suggestion: %s (ID: %s)", cidr, network.ID)
 
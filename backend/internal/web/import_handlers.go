@@ -0,0 +1,75 @@
+package web
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"reconya-ai/internal/importer"
+)
+
+// maxImportSize limits uploaded import files
+const maxImportSize = 32 << 20
+
+// APIImport imports devices from an uploaded file. The file is sent as the
+// "file" field of a multipart form, or as the raw request body. Options are
+// read from form or query values: format, network and dry_run.
+func (h *WebHandler) APIImport(w http.ResponseWriter, r *http.Request) {
+	session, _ := h.sessionStore.Get(r, "reconya-session")
+	user := h.getUserFromSession(session)
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+
+	var filename string
+	var data []byte
+	var err error
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, header, formErr := r.FormFile("file")
+		if formErr != nil {
+			http.Error(w, "Missing import file", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		filename = header.Filename
+		data, err = io.ReadAll(file)
+	} else {
+		filename = r.URL.Query().Get("filename")
+		data, err = io.ReadAll(r.Body)
+	}
+	if err != nil {
+		http.Error(w, "Failed to read import file", http.StatusBadRequest)
+		return
+	}
+	if len(data) == 0 {
+		http.Error(w, "Import file is empty", http.StatusBadRequest)
+		return
+	}
+
+	format, err := importer.ParseFormat(r.FormValue("format"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	opts := importer.Options{
+		Format:  format,
+		Network: r.FormValue("network"),
+		DryRun:  r.FormValue("dry_run") == "true",
+	}
+
+	result, err := h.importService.ImportFile(filename, data, opts)
+	if err != nil {
+		log.Printf("Import of %q failed: %v", filename, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,87 @@
+package web
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"reconya-ai/db"
+
+	"github.com/gorilla/mux"
+)
+
+// InfraServerAllowedRequest adds a router or DHCP server to the allow-list
+// or removes it
+type InfraServerAllowedRequest struct {
+	Allowed bool `json:"allowed"`
+}
+
+func (h *WebHandler) APIInfraServers(w http.ResponseWriter, r *http.Request) {
+	session, _ := h.sessionStore.Get(r, "reconya-session")
+	user := h.getUserFromSession(session)
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	servers, err := h.infraServerService.FindAll(r.URL.Query().Get("network_id"))
+	if err != nil {
+		log.Printf("Failed to load infra servers: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(servers)
+}
+
+func (h *WebHandler) APISetInfraServerAllowed(w http.ResponseWriter, r *http.Request) {
+	session, _ := h.sessionStore.Get(r, "reconya-session")
+	user := h.getUserFromSession(session)
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var request InfraServerAllowedRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	server, err := h.infraServerService.SetAllowed(mux.Vars(r)["id"], request.Allowed)
+	if err == db.ErrNotFound {
+		http.Error(w, "Server not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to update infra server: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(server)
+}
+
+func (h *WebHandler) APIDeleteInfraServer(w http.ResponseWriter, r *http.Request) {
+	session, _ := h.sessionStore.Get(r, "reconya-session")
+	user := h.getUserFromSession(session)
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	err := h.infraServerService.Delete(mux.Vars(r)["id"])
+	if err == db.ErrNotFound {
+		http.Error(w, "Server not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to delete infra server: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,117 @@
+package web
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"reconya-ai/internal/latency"
+	"reconya-ai/models"
+
+	"github.com/gorilla/mux"
+)
+
+// LatencyResponse is returned by the device latency endpoint
+type LatencyResponse struct {
+	Monitor *models.LatencyMonitor  `json:"monitor"`
+	Samples []*models.LatencySample `json:"samples"`
+}
+
+// LatencyMonitorRequest optionally overrides the default degradation thresholds
+type LatencyMonitorRequest struct {
+	RTTThresholdMs       float64 `json:"rtt_threshold_ms"`
+	LossThresholdPercent float64 `json:"loss_threshold_percent"`
+}
+
+func (h *WebHandler) APIDeviceLatency(w http.ResponseWriter, r *http.Request) {
+	session, _ := h.sessionStore.Get(r, "reconya-session")
+	user := h.getUserFromSession(session)
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	window := 24 * time.Hour
+	if value := r.URL.Query().Get("window"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			http.Error(w, "Invalid window", http.StatusBadRequest)
+			return
+		}
+		window = parsed
+	}
+
+	deviceID := mux.Vars(r)["id"]
+	monitor, err := h.latencyService.GetMonitor(deviceID)
+	if err != nil {
+		log.Printf("Failed to load latency monitor for device %s: %v", deviceID, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	samples, err := h.latencyService.GetSamples(deviceID, window)
+	if err != nil {
+		log.Printf("Failed to load latency samples for device %s: %v", deviceID, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if samples == nil {
+		samples = []*models.LatencySample{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(LatencyResponse{
+		Monitor: monitor,
+		Samples: samples,
+	})
+}
+
+// APIDeviceLatencyMonitor enables (POST) or disables (DELETE) latency probing for a device
+func (h *WebHandler) APIDeviceLatencyMonitor(w http.ResponseWriter, r *http.Request) {
+	session, _ := h.sessionStore.Get(r, "reconya-session")
+	user := h.getUserFromSession(session)
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	deviceID := mux.Vars(r)["id"]
+	device, err := h.deviceService.FindByID(deviceID)
+	if err != nil || device == nil {
+		http.Error(w, "Device not found", http.StatusNotFound)
+		return
+	}
+
+	if r.Method == http.MethodDelete {
+		if err := h.latencyService.DisableMonitor(deviceID); err != nil {
+			log.Printf("Failed to disable latency monitor for device %s: %v", deviceID, err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	var req LatencyMonitorRequest
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	monitor, err := h.latencyService.EnableMonitor(deviceID, req.RTTThresholdMs, req.LossThresholdPercent)
+	if err == latency.ErrNoIPv4Address {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to enable latency monitor for device %s: %v", deviceID, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(monitor)
+}
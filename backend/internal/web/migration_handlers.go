@@ -0,0 +1,46 @@
+package web
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"reconya-ai/db"
+)
+
+// MigrationsResponse describes the schema version of the database
+type MigrationsResponse struct {
+	Version    int                  `json:"version"`
+	Latest     int                  `json:"latest"`
+	Migrations []db.MigrationStatus `json:"migrations"`
+}
+
+// APIMigrations reports which schema migrations have been applied
+func (h *WebHandler) APIMigrations(w http.ResponseWriter, r *http.Request) {
+	session, _ := h.sessionStore.Get(r, "reconya-session")
+	user := h.getUserFromSession(session)
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	statuses, err := h.migrator.Status()
+	if err != nil {
+		log.Printf("Failed to load migration status: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	version, err := h.migrator.Version()
+	if err != nil {
+		log.Printf("Failed to load schema version: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(MigrationsResponse{
+		Version:    version,
+		Latest:     h.migrator.Latest(),
+		Migrations: statuses,
+	})
+}
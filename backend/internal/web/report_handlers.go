@@ -0,0 +1,138 @@
+package web
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"reconya-ai/internal/report"
+
+	"github.com/gorilla/mux"
+)
+
+// reportPeriod reads the ?period= parameter, defaulting to the configured report period
+func (h *WebHandler) reportPeriod(r *http.Request) (time.Duration, error) {
+	value := r.URL.Query().Get("period")
+	if value == "" {
+		return h.config.ReportPeriod, nil
+	}
+	return report.ParsePeriod(value)
+}
+
+func (h *WebHandler) APIReports(w http.ResponseWriter, r *http.Request) {
+	session, _ := h.sessionStore.Get(r, "reconya-session")
+	user := h.getUserFromSession(session)
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	reports, err := h.reportService.ListStored()
+	if err != nil {
+		log.Printf("Failed to list reports: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reports)
+}
+
+func (h *WebHandler) APIGenerateReport(w http.ResponseWriter, r *http.Request) {
+	session, _ := h.sessionStore.Get(r, "reconya-session")
+	user := h.getUserFromSession(session)
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	formatName := r.URL.Query().Get("format")
+	if formatName == "" {
+		formatName = string(report.FormatHTML)
+	}
+	format, err := report.ParseFormat(formatName)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	period, err := h.reportPeriod(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	generated, err := h.reportService.Generate(period)
+	if err != nil {
+		log.Printf("Failed to generate report: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	rendered, err := generated.Render(format)
+	if err != nil {
+		log.Printf("Failed to render report: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	if format != report.FormatHTML || r.URL.Query().Get("download") == "true" {
+		w.Header().Set("Content-Disposition", "attachment; filename=\""+rendered.Filename+"\"")
+	}
+	w.Write(rendered.Data)
+}
+
+func (h *WebHandler) APIDeliverReport(w http.ResponseWriter, r *http.Request) {
+	session, _ := h.sessionStore.Get(r, "reconya-session")
+	user := h.getUserFromSession(session)
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	period, err := h.reportPeriod(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	generated, err := h.reportService.Generate(period)
+	if err != nil {
+		log.Printf("Failed to generate report: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.reportService.Deliver(generated); err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":  "delivered",
+		"summary": generated.Summary(),
+	})
+}
+
+func (h *WebHandler) APIReportFile(w http.ResponseWriter, r *http.Request) {
+	session, _ := h.sessionStore.Get(r, "reconya-session")
+	user := h.getUserFromSession(session)
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	name := filepath.Base(mux.Vars(r)["name"])
+	path, err := h.reportService.StoredPath(name)
+	if err != nil {
+		http.Error(w, "Report not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", "attachment; filename=\""+name+"\"")
+	http.ServeFile(w, r, path)
+}
@@ -0,0 +1,91 @@
+package web
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"reconya-ai/internal/report"
+	"reconya-ai/internal/retention"
+)
+
+// RetentionResponse is the retention policy with the result of the last run
+type RetentionResponse struct {
+	Interval   string            `json:"interval"`
+	Rollups    bool              `json:"rollups"`
+	Rules      []retention.Rule  `json:"rules"`
+	LastReport *retention.Report `json:"last_report,omitempty"`
+}
+
+func (h *WebHandler) APIRetention(w http.ResponseWriter, r *http.Request) {
+	session, _ := h.sessionStore.Get(r, "reconya-session")
+	user := h.getUserFromSession(session)
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	interval := "disabled"
+	if h.config.RetentionInterval > 0 {
+		interval = h.config.RetentionInterval.String()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(RetentionResponse{
+		Interval:   interval,
+		Rollups:    h.config.EventRollups,
+		Rules:      h.retentionService.Rules(),
+		LastReport: h.retentionService.LastReport(),
+	})
+}
+
+// APIRunRetention prunes old rows now; ?dry_run=true only counts them
+func (h *WebHandler) APIRunRetention(w http.ResponseWriter, r *http.Request) {
+	session, _ := h.sessionStore.Get(r, "reconya-session")
+	user := h.getUserFromSession(session)
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	result, err := h.retentionService.Run(r.FormValue("dry_run") == "true")
+	if err != nil {
+		log.Printf("Retention run failed: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// APIEventRollups returns daily event counts; ?period= accepts a Go duration or days such as "30d"
+func (h *WebHandler) APIEventRollups(w http.ResponseWriter, r *http.Request) {
+	session, _ := h.sessionStore.Get(r, "reconya-session")
+	user := h.getUserFromSession(session)
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var period time.Duration
+	if value := r.URL.Query().Get("period"); value != "" {
+		parsed, err := report.ParsePeriod(value)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		period = parsed
+	}
+
+	rollups, err := h.retentionService.GetEventRollups(period)
+	if err != nil {
+		log.Printf("Failed to load event rollups: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rollups)
+}
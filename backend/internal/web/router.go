@@ -21,6 +21,7 @@ func (h *WebHandler) SetupRoutes() *mux.Router {
 	r.HandleFunc("/devices", h.Index).Methods("GET")
 	r.HandleFunc("/logs", h.Index).Methods("GET")
 	r.HandleFunc("/networks", h.Index).Methods("GET")
+	r.HandleFunc("/availability", h.Index).Methods("GET")
 	r.HandleFunc("/alerts", h.Index).Methods("GET")
 	r.HandleFunc("/settings", h.Index).Methods("GET")
 	r.HandleFunc("/about", h.Index).Methods("GET")
@@ -32,13 +33,27 @@ func (h *WebHandler) SetupRoutes() *mux.Router {
 	api.HandleFunc("/devices/{id:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}", h.APIUpdateDevice).Methods("PUT")
 	api.HandleFunc("/devices/{id:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}", h.APIDeleteDevice).Methods("DELETE")
 	api.HandleFunc("/devices/{id:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}/rescan", h.APIRescanDevice).Methods("POST")
+	api.HandleFunc("/devices/{id:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}/traceroute", h.APIDeviceTracerouteHistory).Methods("GET")
+	api.HandleFunc("/devices/{id:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}/traceroute", h.APIDeviceTraceroute).Methods("POST")
+	api.HandleFunc("/devices/{id:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}/traceroute/schedule", h.APIDeviceTracerouteSchedule).Methods("POST", "DELETE")
+	api.HandleFunc("/devices/{id:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}/latency", h.APIDeviceLatency).Methods("GET")
+	api.HandleFunc("/devices/{id:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}/latency/monitor", h.APIDeviceLatencyMonitor).Methods("POST", "DELETE")
+	api.HandleFunc("/devices/{id:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}/availability", h.APIDeviceAvailability).Methods("GET")
+	api.HandleFunc("/devices/{id:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}/status-thresholds", h.APIDeviceStatusThresholds).Methods("GET")
+	api.HandleFunc("/devices/{id:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}/scan-policy", h.APIDeviceScanPolicy).Methods("GET")
+	api.HandleFunc("/devices/{id:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}/wake", h.APIWakeDevice).Methods("POST")
 	api.HandleFunc("/devices/new-scan", h.APINewScan).Methods("GET")
 	api.HandleFunc("/test-ipv6", h.APITestIPv6).Methods("POST")
 	api.HandleFunc("/targets", h.APITargets).Methods("GET")
 	api.HandleFunc("/system-status", h.APISystemStatus).Methods("GET")
 	api.HandleFunc("/dashboard-metrics", h.APIDashboardMetrics).Methods("GET")
+	api.HandleFunc("/availability", h.APIAvailability).Methods("GET")
+	api.HandleFunc("/availability-report", h.APIAvailabilityReport).Methods("GET")
 	api.HandleFunc("/event-logs", h.APIEventLogs).Methods("GET")
 	api.HandleFunc("/event-logs-table", h.APIEventLogsTable).Methods("GET")
+	api.HandleFunc("/event-logs/search", h.APIEventLogSearch).Methods("GET")
+	api.HandleFunc("/event-logs/export", h.APIEventLogExport).Methods("GET")
+	api.HandleFunc("/event-logs/schemas", h.APIEventLogSchemas).Methods("GET")
 	api.HandleFunc("/network-map", h.APINetworkMap).Methods("GET")
 	api.HandleFunc("/traffic-core", h.APITrafficCore).Methods("GET")
 	api.HandleFunc("/device-list", h.APIDeviceList).Methods("GET")
@@ -51,6 +66,8 @@ func (h *WebHandler) SetupRoutes() *mux.Router {
 	api.HandleFunc("/networks/{id:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}", h.APIDeleteNetwork).Methods("DELETE")
 	api.HandleFunc("/networks/{id:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}/delete-info", h.APINetworkDeleteInfo).Methods("GET")
 	api.HandleFunc("/networks/{id:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}/force-delete", h.APIForceDeleteNetwork).Methods("DELETE")
+	api.HandleFunc("/networks/{id:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}/traceroute", h.APINetworkTracerouteHistory).Methods("GET")
+	api.HandleFunc("/networks/{id:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}/traceroute", h.APINetworkTraceroute).Methods("POST")
 	api.HandleFunc("/network-modal", h.APINetworkModal).Methods("GET")
 	api.HandleFunc("/network-modal/{id:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}", h.APINetworkModal).Methods("GET")
 	api.HandleFunc("/network-delete-modal/{id:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}", h.APINetworkDeleteModal).Methods("GET")
@@ -67,6 +84,48 @@ func (h *WebHandler) SetupRoutes() *mux.Router {
 	api.HandleFunc("/settings", h.APISettings).Methods("GET")
 	api.HandleFunc("/settings/screenshots", h.APISettingsScreenshots).Methods("POST")
 
+	// Device status threshold endpoints
+	api.HandleFunc("/status-thresholds", h.APIStatusThresholds).Methods("GET")
+	api.HandleFunc("/status-thresholds", h.APISaveStatusThreshold).Methods("PUT")
+	api.HandleFunc("/status-thresholds/{scope}/{scopeID}", h.APIDeleteStatusThreshold).Methods("DELETE")
+	api.HandleFunc("/scan-policies", h.APIScanPolicies).Methods("GET")
+	api.HandleFunc("/scan-policies", h.APISaveScanPolicy).Methods("PUT")
+	api.HandleFunc("/scan-policies/{scope}/{scopeID}", h.APIDeleteScanPolicy).Methods("DELETE")
+
+	// Exclusion lists
+	api.HandleFunc("/exclusions", h.APIExclusions).Methods("GET")
+	api.HandleFunc("/exclusions", h.APICreateExclusion).Methods("POST")
+	api.HandleFunc("/exclusions/{id}", h.APIDeleteExclusion).Methods("DELETE")
+
+	// Router and DHCP server endpoints
+	api.HandleFunc("/infra-servers", h.APIInfraServers).Methods("GET")
+	api.HandleFunc("/infra-servers/{id}/allowed", h.APISetInfraServerAllowed).Methods("PUT")
+	api.HandleFunc("/infra-servers/{id}", h.APIDeleteInfraServer).Methods("DELETE")
+
+	// Report endpoints
+	api.HandleFunc("/reports", h.APIReports).Methods("GET")
+	api.HandleFunc("/reports/generate", h.APIGenerateReport).Methods("GET")
+	api.HandleFunc("/reports/deliver", h.APIDeliverReport).Methods("POST")
+	api.HandleFunc("/reports/files/{name}", h.APIReportFile).Methods("GET")
+
+	// Inventory export and import
+	api.HandleFunc("/export", h.APIExport).Methods("GET")
+	api.HandleFunc("/import", h.APIImport).Methods("POST")
+
+	// Database schema
+	api.HandleFunc("/migrations", h.APIMigrations).Methods("GET")
+
+	// Database backups
+	api.HandleFunc("/backups", h.APIBackups).Methods("GET")
+	api.HandleFunc("/backups", h.APICreateBackup).Methods("POST")
+	api.HandleFunc("/backups/restore", h.APIRestoreBackup).Methods("POST")
+	api.HandleFunc("/backups/files/{name}", h.APIBackupFile).Methods("GET")
+
+	// Data retention
+	api.HandleFunc("/retention", h.APIRetention).Methods("GET")
+	api.HandleFunc("/retention/run", h.APIRunRetention).Methods("POST")
+	api.HandleFunc("/event-logs/rollups", h.APIEventRollups).Methods("GET")
+
 	// Network detection endpoints
 	api.HandleFunc("/detected-networks", h.APIDetectedNetworks).Methods("GET")
 	api.HandleFunc("/detected-networks-debug", h.APIDetectedNetworksDebug).Methods("GET")
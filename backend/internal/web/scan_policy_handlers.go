@@ -0,0 +1,116 @@
+package web
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"reconya-ai/db"
+	"reconya-ai/internal/scanpolicy"
+	"reconya-ai/models"
+
+	"github.com/gorilla/mux"
+)
+
+// ScanPoliciesResponse lists the global defaults and all policies
+type ScanPoliciesResponse struct {
+	Defaults scanpolicy.Settings  `json:"defaults"`
+	Policies []*models.ScanPolicy `json:"policies"`
+}
+
+func (h *WebHandler) APIScanPolicies(w http.ResponseWriter, r *http.Request) {
+	session, _ := h.sessionStore.Get(r, "reconya-session")
+	user := h.getUserFromSession(session)
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	policies, err := h.scanPolicyService.GetPolicies()
+	if err != nil {
+		log.Printf("Failed to load scan policies: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if policies == nil {
+		policies = []*models.ScanPolicy{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ScanPoliciesResponse{
+		Defaults: h.scanPolicyService.GetDefaults(),
+		Policies: policies,
+	})
+}
+
+func (h *WebHandler) APISaveScanPolicy(w http.ResponseWriter, r *http.Request) {
+	session, _ := h.sessionStore.Get(r, "reconya-session")
+	user := h.getUserFromSession(session)
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var policy models.ScanPolicy
+	if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.scanPolicyService.SetPolicy(&policy); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(policy)
+}
+
+func (h *WebHandler) APIDeleteScanPolicy(w http.ResponseWriter, r *http.Request) {
+	session, _ := h.sessionStore.Get(r, "reconya-session")
+	user := h.getUserFromSession(session)
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	err := h.scanPolicyService.DeletePolicy(models.ScanPolicyScope(vars["scope"]), vars["scopeID"])
+	if err == db.ErrNotFound {
+		http.Error(w, "Scan policy not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to delete scan policy: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *WebHandler) APIDeviceScanPolicy(w http.ResponseWriter, r *http.Request) {
+	session, _ := h.sessionStore.Get(r, "reconya-session")
+	user := h.getUserFromSession(session)
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	deviceID := mux.Vars(r)["id"]
+	device, err := h.deviceService.FindByID(deviceID)
+	if err != nil || device == nil {
+		http.Error(w, "Device not found", http.StatusNotFound)
+		return
+	}
+
+	settings, err := h.scanPolicyService.EffectiveSettings(device)
+	if err != nil {
+		log.Printf("Failed to resolve scan policy for device %s: %v", deviceID, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(settings)
+}
@@ -0,0 +1,133 @@
+package web
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"reconya-ai/db"
+	"reconya-ai/internal/devicestatus"
+	"reconya-ai/models"
+
+	"github.com/gorilla/mux"
+)
+
+// EffectiveThresholdsResponse describes the thresholds that apply to a device
+type EffectiveThresholdsResponse struct {
+	IdleAfterSeconds    int    `json:"idle_after_seconds"`
+	OfflineAfterSeconds int    `json:"offline_after_seconds"`
+	IdleSource          string `json:"idle_source"`
+	OfflineSource       string `json:"offline_source"`
+}
+
+// StatusThresholdsResponse lists the global defaults and all overrides
+type StatusThresholdsResponse struct {
+	Defaults  EffectiveThresholdsResponse `json:"defaults"`
+	Overrides []*models.StatusThreshold   `json:"overrides"`
+}
+
+func (h *WebHandler) APIStatusThresholds(w http.ResponseWriter, r *http.Request) {
+	session, _ := h.sessionStore.Get(r, "reconya-session")
+	user := h.getUserFromSession(session)
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	overrides, err := h.statusEngine.GetThresholds()
+	if err != nil {
+		log.Printf("Failed to load status thresholds: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if overrides == nil {
+		overrides = []*models.StatusThreshold{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(StatusThresholdsResponse{
+		Defaults:  thresholdsResponse(h.statusEngine.GetDefaults()),
+		Overrides: overrides,
+	})
+}
+
+func (h *WebHandler) APISaveStatusThreshold(w http.ResponseWriter, r *http.Request) {
+	session, _ := h.sessionStore.Get(r, "reconya-session")
+	user := h.getUserFromSession(session)
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var threshold models.StatusThreshold
+	if err := json.NewDecoder(r.Body).Decode(&threshold); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.statusEngine.SetThreshold(&threshold); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(threshold)
+}
+
+func (h *WebHandler) APIDeleteStatusThreshold(w http.ResponseWriter, r *http.Request) {
+	session, _ := h.sessionStore.Get(r, "reconya-session")
+	user := h.getUserFromSession(session)
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	err := h.statusEngine.DeleteThreshold(models.ThresholdScope(vars["scope"]), vars["scopeID"])
+	if err == db.ErrNotFound {
+		http.Error(w, "Threshold not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to delete status threshold: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *WebHandler) APIDeviceStatusThresholds(w http.ResponseWriter, r *http.Request) {
+	session, _ := h.sessionStore.Get(r, "reconya-session")
+	user := h.getUserFromSession(session)
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	deviceID := mux.Vars(r)["id"]
+	device, err := h.deviceService.FindByID(deviceID)
+	if err != nil || device == nil {
+		http.Error(w, "Device not found", http.StatusNotFound)
+		return
+	}
+
+	thresholds, err := h.statusEngine.EffectiveThresholds(device)
+	if err != nil {
+		log.Printf("Failed to resolve status thresholds for device %s: %v", deviceID, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(thresholdsResponse(thresholds))
+}
+
+func thresholdsResponse(t devicestatus.Thresholds) EffectiveThresholdsResponse {
+	return EffectiveThresholdsResponse{
+		IdleAfterSeconds:    int(t.IdleAfter.Seconds()),
+		OfflineAfterSeconds: int(t.OfflineAfter.Seconds()),
+		IdleSource:          t.IdleSource,
+		OfflineSource:       t.OfflineSource,
+	}
+}
@@ -0,0 +1,155 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"reconya-ai/internal/exclusion"
+	"reconya-ai/models"
+
+	"github.com/gorilla/mux"
+)
+
+// TracerouteHistoryResponse is returned by the traceroute history endpoints
+type TracerouteHistoryResponse struct {
+	Scheduled bool                    `json:"scheduled"`
+	Runs      []*models.TracerouteRun `json:"runs"`
+}
+
+func (h *WebHandler) APINetworkTracerouteHistory(w http.ResponseWriter, r *http.Request) {
+	session, _ := h.sessionStore.Get(r, "reconya-session")
+	user := h.getUserFromSession(session)
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	networkID := mux.Vars(r)["id"]
+	runs, err := h.tracerouteService.GetNetworkHistory(networkID, historyLimit(r))
+	if err != nil {
+		log.Printf("Failed to load traceroute history for network %s: %v", networkID, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(TracerouteHistoryResponse{
+		Scheduled: true,
+		Runs:      nonNilRuns(runs),
+	})
+}
+
+func (h *WebHandler) APINetworkTraceroute(w http.ResponseWriter, r *http.Request) {
+	session, _ := h.sessionStore.Get(r, "reconya-session")
+	user := h.getUserFromSession(session)
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	networkID := mux.Vars(r)["id"]
+	run, err := h.tracerouteService.TraceNetwork(networkID)
+	if errors.Is(err, exclusion.ErrExcluded) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Printf("Traceroute to network %s failed: %v", networkID, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(run)
+}
+
+func (h *WebHandler) APIDeviceTracerouteHistory(w http.ResponseWriter, r *http.Request) {
+	session, _ := h.sessionStore.Get(r, "reconya-session")
+	user := h.getUserFromSession(session)
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	deviceID := mux.Vars(r)["id"]
+	runs, err := h.tracerouteService.GetDeviceHistory(deviceID, historyLimit(r))
+	if err != nil {
+		log.Printf("Failed to load traceroute history for device %s: %v", deviceID, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(TracerouteHistoryResponse{
+		Scheduled: h.tracerouteService.IsDeviceTarget(deviceID),
+		Runs:      nonNilRuns(runs),
+	})
+}
+
+func (h *WebHandler) APIDeviceTraceroute(w http.ResponseWriter, r *http.Request) {
+	session, _ := h.sessionStore.Get(r, "reconya-session")
+	user := h.getUserFromSession(session)
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	deviceID := mux.Vars(r)["id"]
+	run, err := h.tracerouteService.TraceDevice(deviceID)
+	if errors.Is(err, exclusion.ErrExcluded) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Printf("Traceroute to device %s failed: %v", deviceID, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(run)
+}
+
+// APIDeviceTracerouteSchedule adds (POST) or removes (DELETE) a device from periodic traceroute
+func (h *WebHandler) APIDeviceTracerouteSchedule(w http.ResponseWriter, r *http.Request) {
+	session, _ := h.sessionStore.Get(r, "reconya-session")
+	user := h.getUserFromSession(session)
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	deviceID := mux.Vars(r)["id"]
+	device, err := h.deviceService.FindByID(deviceID)
+	if err != nil || device == nil {
+		http.Error(w, "Device not found", http.StatusNotFound)
+		return
+	}
+
+	enabled := r.Method == http.MethodPost
+	if err := h.tracerouteService.SetDeviceTarget(deviceID, enabled); err != nil {
+		log.Printf("Failed to update traceroute schedule for device %s: %v", deviceID, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func historyLimit(r *http.Request) int {
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 || limit > 500 {
+		return 20
+	}
+	return limit
+}
+
+func nonNilRuns(runs []*models.TracerouteRun) []*models.TracerouteRun {
+	if runs == nil {
+		return []*models.TracerouteRun{}
+	}
+	return runs
+}
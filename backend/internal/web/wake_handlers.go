@@ -0,0 +1,64 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"reconya-ai/internal/exclusion"
+	"reconya-ai/internal/wol"
+	"reconya-ai/models"
+
+	"github.com/gorilla/mux"
+)
+
+// WakeRequest asks to wait for the device to come online after waking it.
+// The body is optional; without it the packets are sent and the request
+// returns at once.
+type WakeRequest struct {
+	Confirm        bool `json:"confirm"`
+	TimeoutSeconds int  `json:"timeout_seconds"`
+}
+
+func (h *WebHandler) APIWakeDevice(w http.ResponseWriter, r *http.Request) {
+	session, _ := h.sessionStore.Get(r, "reconya-session")
+	user := h.getUserFromSession(session)
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var request WakeRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil && err != io.EOF {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	deviceID := mux.Vars(r)["id"]
+	device, err := h.deviceService.FindByID(deviceID)
+	if err != nil || device == nil {
+		http.Error(w, "Device not found", http.StatusNotFound)
+		return
+	}
+
+	result, err := h.wakeService.Wake(device, wol.WakeOptions{
+		Actor:   models.UserActor(user.Username),
+		Confirm: request.Confirm,
+		Timeout: time.Duration(request.TimeoutSeconds) * time.Second,
+	})
+	if errors.Is(err, wol.ErrNoMAC) || errors.Is(err, wol.ErrNoIPv4) || errors.Is(err, exclusion.ErrExcluded) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Printf("Wake-on-LAN of device %s failed: %v", deviceID, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
@@ -12,6 +12,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"reconya-ai/internal/ratelimit"
 	"reconya-ai/models"
 	"regexp"
 	"strconv"
@@ -53,12 +54,13 @@ func NewWebService() *WebService {
 }
 
 // ScanWebServices checks for HTTP/HTTPS services on a device and extracts web info
-func (w *WebService) ScanWebServices(device *models.Device) []WebInfo {
-	return w.ScanWebServicesWithScreenshots(device, false)
+func (w *WebService) ScanWebServices(device *models.Device, limiter *ratelimit.Limiter) []WebInfo {
+	return w.ScanWebServicesWithScreenshots(device, false, limiter)
 }
 
-// ScanWebServicesWithScreenshots checks for HTTP/HTTPS services on a device and optionally captures screenshots
-func (w *WebService) ScanWebServicesWithScreenshots(device *models.Device, captureScreenshots bool) []WebInfo {
+// ScanWebServicesWithScreenshots checks for HTTP/HTTPS services on a device and optionally captures screenshots.
+// Every request waits for the limiter.
+func (w *WebService) ScanWebServicesWithScreenshots(device *models.Device, captureScreenshots bool, limiter *ratelimit.Limiter) []WebInfo {
 	var webInfos []WebInfo
 
 	if device.Ports == nil || len(device.Ports) == 0 {
@@ -110,6 +112,13 @@ func (w *WebService) ScanWebServicesWithScreenshots(device *models.Device, captu
 
 		// Try each protocol for this port
 		for _, protocol := range protocols {
+			requests := 1
+			if captureScreenshots {
+				requests = 2
+			}
+			if err := limiter.Wait(context.Background(), requests); err != nil {
+				return webInfos
+			}
 			webInfo := w.fetchWebInfo(device.IPv4, portNum, protocol, captureScreenshots)
 			if webInfo != nil {
 				webInfos = append(webInfos, *webInfo)
@@ -0,0 +1,136 @@
+package wol
+
+import (
+	"fmt"
+	"log"
+	"net"
+)
+
+// packetCopies is how many magic packets are sent to each broadcast address;
+// they are small and unacknowledged, so a few go out in case one is dropped
+const packetCopies = 3
+
+var limitedBroadcast = net.IPv4bcast
+
+// Target is where the magic packets for a device are sent
+type Target struct {
+	// Interface is the local interface on the device's network, or empty
+	// when the network is not directly attached
+	Interface string
+	// LocalIP is the address of Interface the packets are sent from, which
+	// makes them leave through it
+	LocalIP    net.IP
+	Broadcasts []net.IP
+}
+
+// MagicPacket returns the Wake-on-LAN magic packet for mac: six 0xFF bytes
+// followed by the MAC address repeated 16 times
+func MagicPacket(mac string) ([]byte, error) {
+	hw, err := net.ParseMAC(mac)
+	if err != nil || len(hw) != 6 {
+		return nil, fmt.Errorf("invalid MAC address %q", mac)
+	}
+
+	packet := make([]byte, 0, 6+16*6)
+	for i := 0; i < 6; i++ {
+		packet = append(packet, 0xff)
+	}
+	for i := 0; i < 16; i++ {
+		packet = append(packet, hw...)
+	}
+	return packet, nil
+}
+
+// TargetFor returns where to send magic packets for a device in the IPv4
+// network cidr. On a directly attached network the packets go out of its
+// interface to the network's and the limited broadcast address; otherwise
+// only to the network's broadcast address, which routers may forward. An
+// empty cidr falls back to the limited broadcast.
+func TargetFor(cidr string) Target {
+	if cidr == "" {
+		return Target{Broadcasts: []net.IP{limitedBroadcast}}
+	}
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil || network.IP.To4() == nil {
+		return Target{Broadcasts: []net.IP{limitedBroadcast}}
+	}
+
+	target := Target{Broadcasts: []net.IP{DirectedBroadcast(network)}}
+	name, localIP := localInterface(network)
+	if localIP != nil {
+		target.Interface = name
+		target.LocalIP = localIP
+		target.Broadcasts = append(target.Broadcasts, limitedBroadcast)
+	}
+	return target
+}
+
+// DirectedBroadcast returns the broadcast address of an IPv4 network
+func DirectedBroadcast(network *net.IPNet) net.IP {
+	ip := network.IP.To4()
+	mask := net.IP(network.Mask).To4()
+	if ip == nil || mask == nil {
+		return nil
+	}
+	broadcast := make(net.IP, net.IPv4len)
+	for i := range broadcast {
+		broadcast[i] = ip[i] | ^mask[i]
+	}
+	return broadcast
+}
+
+// localInterface returns the up, non-loopback interface with an address in
+// network and that address
+func localInterface(network *net.IPNet) (string, net.IP) {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		log.Printf("Failed to list interfaces: %v", err)
+		return "", nil
+	}
+	for _, iface := range interfaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.To4() != nil && network.Contains(ipNet.IP) {
+				return iface.Name, ipNet.IP.To4()
+			}
+		}
+	}
+	return "", nil
+}
+
+// SendMagicPacket sends packet to the UDP port of every broadcast address of
+// target. It fails only when no packet could be sent.
+func SendMagicPacket(packet []byte, target Target, port int) error {
+	var local *net.UDPAddr
+	if target.LocalIP != nil {
+		local = &net.UDPAddr{IP: target.LocalIP}
+	}
+	// UDP sockets are opened with SO_BROADCAST set
+	conn, err := net.ListenUDP("udp4", local)
+	if err != nil {
+		return fmt.Errorf("failed to open UDP socket: %v", err)
+	}
+	defer conn.Close()
+
+	sent := 0
+	var lastErr error
+	for _, broadcast := range target.Broadcasts {
+		for i := 0; i < packetCopies; i++ {
+			if _, err := conn.WriteToUDP(packet, &net.UDPAddr{IP: broadcast, Port: port}); err != nil {
+				lastErr = err
+				continue
+			}
+			sent++
+		}
+	}
+	if sent == 0 {
+		return fmt.Errorf("failed to send magic packet: %v", lastErr)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package wol
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMagicPacket(t *testing.T) {
+	packet, err := MagicPacket("aa:bb:cc:00:11:22")
+	require.NoError(t, err)
+	require.Len(t, packet, 102)
+	assert.Equal(t, bytes.Repeat([]byte{0xff}, 6), packet[:6])
+	for i := 0; i < 16; i++ {
+		assert.Equal(t, []byte{0xaa, 0xbb, 0xcc, 0x00, 0x11, 0x22}, packet[6+i*6:12+i*6])
+	}
+
+	_, err = MagicPacket("not a mac")
+	assert.Error(t, err)
+	_, err = MagicPacket("00:00:00:00:fe:80:00:00:00:00:00:00:02:00:5e:10:00:00:00:01")
+	assert.Error(t, err, "only 48-bit addresses can be woken")
+}
+
+func TestTargetFor(t *testing.T) {
+	_, network, err := net.ParseCIDR("192.168.1.0/24")
+	require.NoError(t, err)
+	assert.Equal(t, "192.168.1.255", DirectedBroadcast(network).String())
+
+	// A documentation network is never attached to the test host
+	target := TargetFor("198.51.100.0/25")
+	assert.Empty(t, target.Interface)
+	assert.Nil(t, target.LocalIP)
+	assert.Equal(t, []net.IP{net.ParseIP("198.51.100.127").To4()}, target.Broadcasts)
+
+	target = TargetFor("")
+	assert.Equal(t, []net.IP{net.IPv4bcast}, target.Broadcasts)
+}
@@ -0,0 +1,240 @@
+package wol
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"reconya-ai/internal/config"
+	"reconya-ai/internal/device"
+	"reconya-ai/internal/eventlog"
+	"reconya-ai/internal/exclusion"
+	"reconya-ai/internal/network"
+	"reconya-ai/internal/scanner"
+	"reconya-ai/models"
+)
+
+// confirmInterval is how often a waking device is pinged
+const confirmInterval = 2 * time.Second
+
+var (
+	ErrNoMAC  = errors.New("device has no MAC address")
+	ErrNoIPv4 = errors.New("device has no IPv4 address to confirm it came online")
+)
+
+// Pinger sends a single echo request and reports the round-trip time
+type Pinger interface {
+	Ping(ip string) (bool, time.Duration)
+}
+
+// WakeOptions controls a wake attempt
+type WakeOptions struct {
+	// Actor is logged as the cause of the attempt; empty for the system
+	Actor string
+	// Confirm waits up to Timeout, or the configured timeout when zero, for
+	// the device to answer a ping
+	Confirm bool
+	Timeout time.Duration
+}
+
+// WakeService sends Wake-on-LAN magic packets to devices over the interface
+// of their network and optionally waits for them to come online
+type WakeService struct {
+	deviceService   *device.DeviceService
+	networkService  *network.NetworkService
+	eventLogService *eventlog.EventLogService
+	port            int
+	confirmTimeout  time.Duration
+	// Pinger confirms that a device came online
+	Pinger Pinger
+	// Exclusions keeps excluded devices from being pinged for confirmation;
+	// nil excludes nothing
+	Exclusions *exclusion.ExclusionService
+	// Send transmits a magic packet to target
+	Send func(packet []byte, target Target, port int) error
+	// Target returns where to send the magic packets for a network
+	Target func(cidr string) Target
+}
+
+func NewWakeService(deviceService *device.DeviceService, networkService *network.NetworkService, eventLogService *eventlog.EventLogService, cfg *config.Config) *WakeService {
+	pinger := scanner.NewNativeScanner()
+	pinger.SetOptions(time.Second, 1, false, false)
+
+	port := cfg.WakeOnLANPort
+	if port <= 0 {
+		port = 9
+	}
+	confirmTimeout := cfg.WakeConfirmTimeout
+	if confirmTimeout <= 0 {
+		confirmTimeout = 2 * time.Minute
+	}
+
+	return &WakeService{
+		deviceService:   deviceService,
+		networkService:  networkService,
+		eventLogService: eventLogService,
+		port:            port,
+		confirmTimeout:  confirmTimeout,
+		Pinger:          pinger,
+		Send:            SendMagicPacket,
+		Target:          TargetFor,
+	}
+}
+
+// Wake sends magic packets to device and logs the attempt. With
+// options.Confirm it then pings the device until it answers or the timeout
+// passes, marks it online when it does and logs the outcome; excluded devices
+// can be woken but not confirmed.
+func (s *WakeService) Wake(device *models.Device, options WakeOptions) (*models.WakeResult, error) {
+	if device.MAC == nil || *device.MAC == "" {
+		return nil, ErrNoMAC
+	}
+	if options.Confirm && device.IPv4 == "" {
+		return nil, ErrNoIPv4
+	}
+	if options.Confirm {
+		excluded, err := s.Exclusions.ExcludesDevice(device)
+		if err != nil {
+			return nil, err
+		}
+		if excluded {
+			return nil, fmt.Errorf("can't confirm the device came online: %w", exclusion.ErrExcluded)
+		}
+	}
+	packet, err := MagicPacket(*device.MAC)
+	if err != nil {
+		return nil, err
+	}
+
+	target := s.Target(s.networkCIDR(device))
+	if err := s.Send(packet, target, s.port); err != nil {
+		return nil, err
+	}
+
+	result := &models.WakeResult{
+		DeviceID:   device.ID,
+		MAC:        *device.MAC,
+		Interface:  target.Interface,
+		Broadcasts: make([]string, 0, len(target.Broadcasts)),
+		Port:       s.port,
+		SentAt:     time.Now(),
+	}
+	for _, broadcast := range target.Broadcasts {
+		result.Broadcasts = append(result.Broadcasts, broadcast.String())
+	}
+
+	via := ""
+	if target.Interface != "" {
+		via = fmt.Sprintf(" via %s", target.Interface)
+	}
+	description := fmt.Sprintf("Wake-on-LAN packet sent to %s (%s)%s", deviceLabel(device), result.MAC, via)
+	log.Print(description)
+	s.log(description, device, result, options.Actor)
+
+	if !options.Confirm {
+		return result, nil
+	}
+
+	timeout := options.Timeout
+	if timeout <= 0 || timeout > s.confirmTimeout {
+		timeout = s.confirmTimeout
+	}
+	confirmed := s.waitOnline(device.IPv4, result.SentAt.Add(timeout))
+	result.Confirmed = &confirmed
+	if confirmed {
+		elapsed := time.Since(result.SentAt).Seconds()
+		result.OnlineAfterSeconds = &elapsed
+		description = fmt.Sprintf("Device %s came online %.0f seconds after Wake-on-LAN", deviceLabel(device), elapsed)
+		s.markOnline(device)
+	} else {
+		description = fmt.Sprintf("Device %s did not come online within %s of Wake-on-LAN", deviceLabel(device), timeout)
+	}
+	log.Print(description)
+	s.log(description, device, result, options.Actor)
+	return result, nil
+}
+
+// waitOnline pings ip until it answers or deadline passes
+func (s *WakeService) waitOnline(ip string, deadline time.Time) bool {
+	for {
+		if ok, _ := s.Pinger.Ping(ip); ok {
+			return true
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return false
+		}
+		if remaining > confirmInterval {
+			remaining = confirmInterval
+		}
+		time.Sleep(remaining)
+	}
+}
+
+// markOnline records that a woken device answered, like a sweep finding it
+func (s *WakeService) markOnline(device *models.Device) {
+	found, previous, err := s.deviceService.MarkPresent(device.IPv4, "")
+	if err != nil {
+		log.Printf("Failed to mark device %s online: %v", device.IPv4, err)
+		return
+	}
+	if found == nil || previous == models.DeviceStatusOnline {
+		return
+	}
+	payload := &models.DeviceStatusPayload{IPv4: device.IPv4, PreviousStatus: previous, Status: models.DeviceStatusOnline}
+	if err := s.eventLogService.Log(models.DeviceOnline, "", found.ID, payload); err != nil {
+		log.Printf("Failed to log device %s online: %v", found.ID, err)
+	}
+}
+
+func (s *WakeService) log(description string, device *models.Device, result *models.WakeResult, actor string) {
+	payload := &models.WakePayload{
+		EventMeta:          models.EventMeta{Actor: actor},
+		IPv4:               device.IPv4,
+		MAC:                result.MAC,
+		Interface:          result.Interface,
+		Broadcasts:         result.Broadcasts,
+		Confirmed:          result.Confirmed,
+		OnlineAfterSeconds: result.OnlineAfterSeconds,
+	}
+	if err := s.eventLogService.Log(models.WakeOnLAN, description, device.ID, payload); err != nil {
+		log.Printf("Failed to log Wake-on-LAN of device %s: %v", device.ID, err)
+	}
+}
+
+// networkCIDR returns the IPv4 CIDR of the device's network, or of the first
+// network containing its address, or ""
+func (s *WakeService) networkCIDR(device *models.Device) string {
+	if device.NetworkID != "" {
+		if network, err := s.networkService.FindByID(device.NetworkID); err == nil && network != nil {
+			return network.GetIPv4CIDR()
+		}
+	}
+	if device.IPv4 == "" {
+		return ""
+	}
+	networks, err := s.networkService.FindAll()
+	if err != nil {
+		return ""
+	}
+	for i := range networks {
+		if networks[i].ContainsIPv4(device.IPv4) {
+			return networks[i].GetIPv4CIDR()
+		}
+	}
+	return ""
+}
+
+func deviceLabel(device *models.Device) string {
+	if device.Name != "" && device.IPv4 != "" {
+		return fmt.Sprintf("%s (%s)", device.Name, device.IPv4)
+	}
+	if device.Name != "" {
+		return device.Name
+	}
+	if device.IPv4 != "" {
+		return device.IPv4
+	}
+	return device.ID
+}
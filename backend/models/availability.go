@@ -0,0 +1,135 @@
+package models
+
+import "time"
+
+// DeviceStatusInterval is a period during which a device kept the same status.
+// The current interval of a device has no end.
+type DeviceStatusInterval struct {
+	DeviceID  string       `bson:"device_id" json:"device_id"`
+	Status    DeviceStatus `bson:"status" json:"status"`
+	StartedAt time.Time    `bson:"started_at" json:"started_at"`
+	EndedAt   *time.Time   `bson:"ended_at,omitempty" json:"ended_at,omitempty"`
+}
+
+// DeviceStatusTransition is a status change applied by the periodic status sweep
+type DeviceStatusTransition struct {
+	DeviceID string       `json:"device_id"`
+	From     DeviceStatus `json:"from"`
+	To       DeviceStatus `json:"to"`
+	At       time.Time    `json:"at"`
+}
+
+// Outage is a period during which a device was offline
+type Outage struct {
+	StartedAt       time.Time  `json:"started_at"`
+	EndedAt         *time.Time `json:"ended_at,omitempty"`
+	DurationSeconds float64    `json:"duration_seconds"`
+}
+
+// AvailabilityWindow summarizes the availability of a device over a period.
+// Percent is nil when no status was recorded in the period.
+type AvailabilityWindow struct {
+	Window          string   `json:"window"`
+	Percent         *float64 `json:"percent"`
+	UptimeSeconds   float64  `json:"uptime_seconds"`
+	DowntimeSeconds float64  `json:"downtime_seconds"`
+	Outages         int      `json:"outages"`
+}
+
+// DeviceAvailability is the availability report of a single device
+type DeviceAvailability struct {
+	DeviceID    string               `json:"device_id"`
+	DeviceName  string               `json:"device_name"`
+	IPv4        string               `json:"ipv4"`
+	Status      DeviceStatus         `json:"status"`
+	Windows     []AvailabilityWindow `json:"windows"`
+	Outages     []Outage             `json:"outages"`
+	MTBFSeconds *float64             `json:"mtbf_seconds,omitempty"`
+}
+
+// IsUp reports whether a status counts as available. Idle devices were seen
+// recently and are not considered down.
+func (s DeviceStatus) IsUp() bool {
+	return s == DeviceStatusOnline || s == DeviceStatusIdle
+}
+
+// ComputeAvailability measures up and down time of intervals clipped to
+// [from, to). Intervals with an unknown status are left out.
+func ComputeAvailability(window string, intervals []*DeviceStatusInterval, from, to time.Time) AvailabilityWindow {
+	result := AvailabilityWindow{Window: window}
+
+	for _, interval := range intervals {
+		start, end, ok := clipInterval(interval, from, to)
+		if !ok {
+			continue
+		}
+
+		seconds := end.Sub(start).Seconds()
+		switch {
+		case interval.Status.IsUp():
+			result.UptimeSeconds += seconds
+		case interval.Status == DeviceStatusOffline:
+			result.DowntimeSeconds += seconds
+			if !interval.StartedAt.Before(from) {
+				result.Outages++
+			}
+		}
+	}
+
+	if total := result.UptimeSeconds + result.DowntimeSeconds; total > 0 {
+		percent := result.UptimeSeconds / total * 100
+		result.Percent = &percent
+	}
+
+	return result
+}
+
+// FindOutages returns the offline periods overlapping [from, to), newest first
+func FindOutages(intervals []*DeviceStatusInterval, from, to time.Time) []Outage {
+	var outages []Outage
+	for i := len(intervals) - 1; i >= 0; i-- {
+		interval := intervals[i]
+		if interval.Status != DeviceStatusOffline {
+			continue
+		}
+		if _, _, ok := clipInterval(interval, from, to); !ok {
+			continue
+		}
+
+		end := to
+		if interval.EndedAt != nil {
+			end = *interval.EndedAt
+		}
+		outages = append(outages, Outage{
+			StartedAt:       interval.StartedAt,
+			EndedAt:         interval.EndedAt,
+			DurationSeconds: end.Sub(interval.StartedAt).Seconds(),
+		})
+	}
+	return outages
+}
+
+// MeanTimeBetweenFailures divides the uptime of a window by the number of
+// outages that started in it. It is nil when there were no outages.
+func MeanTimeBetweenFailures(window AvailabilityWindow) *float64 {
+	if window.Outages == 0 {
+		return nil
+	}
+	mtbf := window.UptimeSeconds / float64(window.Outages)
+	return &mtbf
+}
+
+func clipInterval(interval *DeviceStatusInterval, from, to time.Time) (time.Time, time.Time, bool) {
+	start := interval.StartedAt
+	end := to
+	if interval.EndedAt != nil && interval.EndedAt.Before(to) {
+		end = *interval.EndedAt
+	}
+	if start.Before(from) {
+		start = from
+	}
+	if !start.Before(end) {
+		return start, end, false
+	}
+	return start, end, true
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeAvailability(t *testing.T) {
+	to := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	from := to.Add(-24 * time.Hour)
+	at := func(hours int) *time.Time {
+		ts := from.Add(time.Duration(hours) * time.Hour)
+		return &ts
+	}
+
+	intervals := []*DeviceStatusInterval{
+		// Started before the window, only the part inside it counts
+		{Status: DeviceStatusOnline, StartedAt: from.Add(-10 * time.Hour), EndedAt: at(12)},
+		{Status: DeviceStatusOffline, StartedAt: *at(12), EndedAt: at(15)},
+		{Status: DeviceStatusIdle, StartedAt: *at(15), EndedAt: at(18)},
+		{Status: DeviceStatusOffline, StartedAt: *at(18)},
+	}
+
+	window := ComputeAvailability("24h", intervals, from, to)
+
+	require.NotNil(t, window.Percent)
+	assert.InDelta(t, 15.0/24*100, *window.Percent, 0.001)
+	assert.InDelta(t, (15 * time.Hour).Seconds(), window.UptimeSeconds, 0.001)
+	assert.InDelta(t, (9 * time.Hour).Seconds(), window.DowntimeSeconds, 0.001)
+	assert.Equal(t, 2, window.Outages)
+
+	mtbf := MeanTimeBetweenFailures(window)
+	require.NotNil(t, mtbf)
+	assert.InDelta(t, (7*time.Hour + 30*time.Minute).Seconds(), *mtbf, 0.001)
+
+	outages := FindOutages(intervals, from, to)
+	require.Len(t, outages, 2)
+	assert.Nil(t, outages[0].EndedAt, "newest outage is still ongoing")
+	assert.InDelta(t, (6 * time.Hour).Seconds(), outages[0].DurationSeconds, 0.001)
+	assert.InDelta(t, (3 * time.Hour).Seconds(), outages[1].DurationSeconds, 0.001)
+}
+
+func TestComputeAvailability_NoHistory(t *testing.T) {
+	to := time.Now()
+	window := ComputeAvailability("24h", nil, to.Add(-24*time.Hour), to)
+
+	assert.Nil(t, window.Percent)
+	assert.Nil(t, MeanTimeBetweenFailures(window))
+}
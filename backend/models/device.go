@@ -1,6 +1,10 @@
 package models
 
 import (
+	"net"
+	"slices"
+	"sort"
+	"strings"
 	"time"
 )
 
@@ -50,6 +54,7 @@ type Device struct {
 	IPv6Global        *string      `bson:"ipv6_global,omitempty" json:"ipv6_global,omitempty"`
 	IPv6Addresses     []string     `bson:"ipv6_addresses,omitempty" json:"ipv6_addresses,omitempty"`
 	MAC               *string      `bson:"mac,omitempty" json:"mac,omitempty"`
+	PrivateMAC        bool         `bson:"-" json:"private_mac,omitempty"` // locally administered, e.g. randomized; has no vendor
 	Vendor            *string      `bson:"vendor,omitempty" json:"vendor,omitempty"`
 	DeviceType        DeviceType   `bson:"device_type,omitempty" json:"device_type,omitempty"`
 	OS                *DeviceOS    `bson:"os,omitempty" json:"os,omitempty"`
@@ -58,6 +63,7 @@ type Device struct {
 	Ports             []Port       `bson:"ports,omitempty" json:"ports,omitempty"`
 	Hostname          *string      `bson:"hostname,omitempty" json:"hostname,omitempty"`
 	WebServices       []WebService `bson:"web_services,omitempty" json:"web_services,omitempty"`
+	Tags              []string     `bson:"tags,omitempty" json:"tags,omitempty"`
 	CreatedAt         time.Time    `bson:"created_at" json:"created_at"`
 	UpdatedAt         time.Time    `bson:"updated_at" json:"updated_at"`
 	LastSeenOnlineAt  *time.Time   `bson:"last_seen_online_at,omitempty" json:"last_seen_online_at,omitempty"`
@@ -66,6 +72,42 @@ type Device struct {
 	WebScanEndedAt    *time.Time   `bson:"web_scan_ended_at,omitempty" json:"web_scan_ended_at,omitempty"`
 }
 
+// IsPrivateMAC reports whether a unicast MAC address is locally administered
+// rather than assigned by its vendor, such as a randomized private address
+func IsPrivateMAC(mac string) bool {
+	hardwareAddr, err := net.ParseMAC(mac)
+	if err != nil || len(hardwareAddr) == 0 {
+		return false
+	}
+	return hardwareAddr[0]&0x02 != 0 && hardwareAddr[0]&0x01 == 0
+}
+
+// HasTag reports whether the device carries the tag, ignoring case
+func (d *Device) HasTag(tag string) bool {
+	for _, t := range d.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeTags trims, lowercases, deduplicates and sorts tags
+func NormalizeTags(tags []string) []string {
+	seen := make(map[string]bool)
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	sort.Strings(normalized)
+	return normalized
+}
+
 // IPv6 helper methods
 func (d *Device) HasIPv6() bool {
 	return d.IPv6LinkLocal != nil || d.IPv6UniqueLocal != nil || d.IPv6Global != nil || len(d.IPv6Addresses) > 0
@@ -120,3 +162,56 @@ func (d *Device) GetAllIPv6Addresses() []string {
 	addresses = append(addresses, d.IPv6Addresses...)
 	return addresses
 }
+
+// MergeIPv6Addresses adds the IPv6 addresses of other to d. Fields d already
+// has keep their address; a different one goes to the additional addresses.
+func (d *Device) MergeIPv6Addresses(other *Device) {
+	merge := func(field **string, address *string) {
+		if address == nil || *address == "" {
+			return
+		}
+		if *field == nil {
+			value := *address
+			*field = &value
+			return
+		}
+		if **field != *address {
+			d.AddIPv6Address(*address)
+		}
+	}
+	merge(&d.IPv6LinkLocal, other.IPv6LinkLocal)
+	merge(&d.IPv6UniqueLocal, other.IPv6UniqueLocal)
+	merge(&d.IPv6Global, other.IPv6Global)
+
+	known := d.GetAllIPv6Addresses()
+	for _, address := range other.IPv6Addresses {
+		if !slices.Contains(known, address) {
+			d.AddIPv6Address(address)
+		}
+	}
+}
+
+// ReplaceIPv6Addresses sets the IPv6 addresses of d to those of seen, the
+// addresses found by the latest discovery, so that addresses which are no
+// longer used expire instead of piling up. A field seen has no address for
+// is cleared, like an additional address that wasn't found again.
+func (d *Device) ReplaceIPv6Addresses(seen *Device) {
+	replace := func(field **string, address *string) {
+		*field = nil
+		if address != nil && *address != "" {
+			value := *address
+			*field = &value
+		}
+	}
+	replace(&d.IPv6LinkLocal, seen.IPv6LinkLocal)
+	replace(&d.IPv6UniqueLocal, seen.IPv6UniqueLocal)
+	replace(&d.IPv6Global, seen.IPv6Global)
+
+	d.IPv6Addresses = nil
+	known := d.GetAllIPv6Addresses()
+	for _, address := range seen.GetAllIPv6Addresses() {
+		if !slices.Contains(known, address) {
+			d.AddIPv6Address(address)
+		}
+	}
+}
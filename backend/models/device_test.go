@@ -58,3 +58,59 @@ func TestDevice_StatusTransitions(t *testing.T) {
 	assert.NotNil(t, device.LastSeenOnlineAt)
 	assert.True(t, device.LastSeenOnlineAt.After(time.Now().Add(-time.Minute)))
 }
+
+func TestNormalizeTags(t *testing.T) {
+	tags := NormalizeTags([]string{" Servers", "dmz", "", "servers", "DMZ "})
+	assert.Equal(t, []string{"dmz", "servers"}, tags)
+
+	device := Device{Tags: tags}
+	assert.True(t, device.HasTag("DMZ"))
+	assert.False(t, device.HasTag("lab"))
+}
+
+func TestDevice_MergeIPv6Addresses(t *testing.T) {
+	linkLocal := "fe80::1"
+	global := "2001:db8::1"
+	device := Device{IPv6LinkLocal: &linkLocal}
+
+	otherLinkLocal := "fe80::2"
+	device.MergeIPv6Addresses(&Device{
+		IPv6LinkLocal: &otherLinkLocal,
+		IPv6Global:    &global,
+		IPv6Addresses: []string{"2001:db8::1", "2001:db8::99"},
+	})
+
+	assert.Equal(t, "fe80::1", *device.IPv6LinkLocal)
+	assert.Equal(t, "2001:db8::1", *device.IPv6Global)
+	assert.Equal(t, []string{"fe80::2", "2001:db8::99"}, device.IPv6Addresses)
+	assert.Equal(t, "2001:db8::1", *device.GetPrimaryIPv6())
+}
+
+func TestDevice_ReplaceIPv6Addresses(t *testing.T) {
+	linkLocal := "fe80::1"
+	global := "2001:db8::1"
+	device := Device{
+		IPv6LinkLocal: &linkLocal,
+		IPv6Global:    &global,
+		IPv6Addresses: []string{"2001:db8::2", "2001:db8::3"},
+	}
+
+	// The temporary address rotated; the link-local address wasn't seen
+	rotated := "2001:db8::4"
+	device.ReplaceIPv6Addresses(&Device{
+		IPv6Global:    &rotated,
+		IPv6Addresses: []string{"2001:db8::3", "2001:db8::4"},
+	})
+
+	assert.Nil(t, device.IPv6LinkLocal, "a field not seen again expires")
+	assert.Equal(t, "2001:db8::4", *device.IPv6Global)
+	assert.Equal(t, []string{"2001:db8::3"}, device.IPv6Addresses, "addresses not seen again expire")
+}
+
+func TestIsPrivateMAC(t *testing.T) {
+	assert.True(t, IsPrivateMAC("DA:A1:19:00:11:22"), "randomized")
+	assert.True(t, IsPrivateMAC("02:42:ac:11:00:02"), "docker")
+	assert.False(t, IsPrivateMAC("00:50:56:C0:00:08"))
+	assert.False(t, IsPrivateMAC("03:00:00:00:00:01"), "multicast")
+	assert.False(t, IsPrivateMAC("not a mac"))
+}
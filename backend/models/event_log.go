@@ -1,14 +1,94 @@
 package models
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 )
 
 type EventLog struct {
+	ID              int64         `bson:"id,omitempty"`
 	Type            EEventLogType `bson:"type"`
 	Description     string        `bson:"description"`
 	DeviceID        *string       `bson:"device_id,omitempty"`
 	DurationSeconds *float64      `bson:"duration_seconds,omitempty"`
-	CreatedAt       *time.Time    `bson:"created_at,omitempty"`
-	UpdatedAt       *time.Time    `bson:"updated_at,omitempty"`
+	// Payload is the structured data of the event; see EventPayload
+	Payload   json.RawMessage `bson:"payload,omitempty"`
+	CreatedAt *time.Time      `bson:"created_at,omitempty"`
+	UpdatedAt *time.Time      `bson:"updated_at,omitempty"`
+}
+
+// Severity is the severity of the event's type
+func (e EventLog) Severity() EventSeverity {
+	return e.Type.Severity()
+}
+
+// EventLogFilter selects event logs; zero fields match every event
+type EventLogFilter struct {
+	Types      []EEventLogType
+	Severities []EventSeverity
+	DeviceID   string
+	// NetworkID matches events of devices in the network
+	NetworkID string
+	Since     *time.Time
+	Until     *time.Time
+	// Query is a full-text search on the description; every word must match
+	Query string
+}
+
+// EventLogCursor is the position of the last event of a page. Events are
+// ordered newest first, so the next page starts after it.
+type EventLogCursor struct {
+	CreatedAt time.Time
+	ID        int64
+}
+
+// CursorAfter returns the cursor positioned after eventLog
+func CursorAfter(eventLog *EventLog) *EventLogCursor {
+	cursor := &EventLogCursor{ID: eventLog.ID}
+	if eventLog.CreatedAt != nil {
+		cursor.CreatedAt = *eventLog.CreatedAt
+	}
+	return cursor
+}
+
+// String encodes the cursor for use in a URL
+func (c EventLogCursor) String() string {
+	raw := fmt.Sprintf("%d.%d", c.CreatedAt.UnixNano(), c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+var ErrInvalidCursor = errors.New("invalid event log cursor")
+
+// ParseEventLogCursor decodes a cursor produced by EventLogCursor.String
+func ParseEventLogCursor(value string) (*EventLogCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	nanos, id, ok := strings.Cut(string(raw), ".")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	createdAt, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	cursor := &EventLogCursor{CreatedAt: time.Unix(0, createdAt)}
+	if cursor.ID, err = strconv.ParseInt(id, 10, 64); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return cursor, nil
+}
+
+// EventLogPage is one page of filtered event logs
+type EventLogPage struct {
+	EventLogs []*EventLog `json:"event_logs"`
+	// NextCursor is empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
 }
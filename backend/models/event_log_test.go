@@ -0,0 +1,40 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventLogType_Severity(t *testing.T) {
+	assert.Equal(t, SeverityCritical, Alert.Severity())
+	assert.Equal(t, SeverityWarning, DeviceOffline.Severity())
+	assert.Equal(t, SeverityInfo, PingSweep.Severity())
+	assert.Equal(t, SeverityInfo, EEventLogType("Something new").Severity())
+
+	assert.Equal(t, []EEventLogType{Alert}, TypesWithSeverity(SeverityCritical))
+	assert.Empty(t, TypesWithSeverity(SeverityInfo))
+	for _, eventType := range TypesWithSeverity(SeverityWarning) {
+		assert.Equal(t, SeverityWarning, eventType.Severity())
+	}
+
+	_, err := ParseEventSeverity("loud")
+	assert.Error(t, err)
+}
+
+func TestEventLogCursor_RoundTrip(t *testing.T) {
+	createdAt := time.Date(2025, 3, 1, 12, 30, 0, 123456789, time.UTC)
+	cursor := CursorAfter(&EventLog{ID: 42, CreatedAt: &createdAt})
+
+	parsed, err := ParseEventLogCursor(cursor.String())
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), parsed.ID)
+	assert.True(t, createdAt.Equal(parsed.CreatedAt))
+
+	for _, value := range []string{"", "not base64!", "MTIz", "YWJjLjEy"} {
+		_, err := ParseEventLogCursor(value)
+		assert.ErrorIs(t, err, ErrInvalidCursor, value)
+	}
+}
@@ -1,5 +1,10 @@
 package models
 
+import (
+	"fmt"
+	"sort"
+)
+
 type EEventLogType string
 
 const (
@@ -18,6 +23,63 @@ const (
 	ScanStarted        EEventLogType = "Scan started"
 	ScanStopped        EEventLogType = "Scan stopped"
 	NewNetworkDetected EEventLogType = "New network detected"
+	PathChanged        EEventLogType = "Network path changed"
 	Warning            EEventLogType = "Warning"
 	Alert              EEventLogType = "Alert"
+	WakeOnLAN          EEventLogType = "Wake-on-LAN"
 )
+
+// EventLogTypes lists every event type, for filters
+var EventLogTypes = []EEventLogType{
+	PingSweep, PortScanStarted, PortScanCompleted, DeviceOnline, DeviceIdle, DeviceOffline, DeviceDeleted,
+	LocalIPFound, LocalNetworkFound, NetworkCreated, NetworkUpdated, NetworkDeleted, ScanStarted, ScanStopped,
+	NewNetworkDetected, PathChanged, Warning, Alert, WakeOnLAN,
+}
+
+// EventSeverity ranks event types for filtering
+type EventSeverity string
+
+const (
+	SeverityInfo     EventSeverity = "info"
+	SeverityWarning  EventSeverity = "warning"
+	SeverityCritical EventSeverity = "critical"
+)
+
+// eventSeverities lists the types above info; every other type is info
+var eventSeverities = map[EEventLogType]EventSeverity{
+	DeviceOffline:      SeverityWarning,
+	NewNetworkDetected: SeverityWarning,
+	PathChanged:        SeverityWarning,
+	Warning:            SeverityWarning,
+	Alert:              SeverityCritical,
+}
+
+// Severity returns the severity of events of this type
+func (t EEventLogType) Severity() EventSeverity {
+	if severity, ok := eventSeverities[t]; ok {
+		return severity
+	}
+	return SeverityInfo
+}
+
+// TypesWithSeverity returns the event types ranked as severity. It is empty
+// for info, which covers every type not listed.
+func TypesWithSeverity(severity EventSeverity) []EEventLogType {
+	var types []EEventLogType
+	for t, s := range eventSeverities {
+		if s == severity {
+			types = append(types, t)
+		}
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types
+}
+
+// ParseEventSeverity validates a severity name
+func ParseEventSeverity(value string) (EventSeverity, error) {
+	switch severity := EventSeverity(value); severity {
+	case SeverityInfo, SeverityWarning, SeverityCritical:
+		return severity, nil
+	}
+	return "", fmt.Errorf("unknown severity %q (want info, warning or critical)", value)
+}
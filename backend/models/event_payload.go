@@ -0,0 +1,329 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ActorSystem is the actor of events reconya raises on its own. Events caused
+// by a logged-in user carry UserActor(username).
+const ActorSystem = "system"
+
+// UserActor returns the actor of events caused by a user
+func UserActor(username string) string {
+	return "user:" + username
+}
+
+// EventMeta holds the payload fields common to every event type
+type EventMeta struct {
+	Severity EventSeverity `json:"severity"`
+	Actor    string        `json:"actor"`
+}
+
+func (m *EventMeta) meta() *EventMeta {
+	return m
+}
+
+// EventPayload is the structured data of an event. Every event type has one
+// payload type, listed in eventPayloads, so consumers can rely on its fields
+// instead of parsing the description.
+type EventPayload interface {
+	meta() *EventMeta
+}
+
+// ScanPayload describes a scan run: Scan started, Scan stopped and Ping sweep
+type ScanPayload struct {
+	EventMeta
+	RunID           string   `json:"run_id,omitempty"`
+	NetworkID       string   `json:"network_id,omitempty"`
+	CIDR            string   `json:"cidr,omitempty"`
+	DevicesFound    *int     `json:"devices_found,omitempty"`
+	DurationSeconds *float64 `json:"duration_seconds,omitempty"`
+}
+
+// DevicePayload identifies the device of an event
+type DevicePayload struct {
+	EventMeta
+	IPv4 string `json:"ipv4,omitempty"`
+	MAC  string `json:"mac,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+// DeviceStatusPayload records a device's status change
+type DeviceStatusPayload struct {
+	EventMeta
+	IPv4           string       `json:"ipv4,omitempty"`
+	PreviousStatus DeviceStatus `json:"previous_status,omitempty"`
+	Status         DeviceStatus `json:"status"`
+}
+
+// PortScanPayload holds the open ports found by a port scan and how they
+// differ from the previous scan. Ports are written as "number/protocol".
+type PortScanPayload struct {
+	EventMeta
+	IPv4         string   `json:"ipv4,omitempty"`
+	OpenPorts    []string `json:"open_ports"`
+	AddedPorts   []string `json:"added_ports"`
+	RemovedPorts []string `json:"removed_ports"`
+}
+
+// NetworkPayload identifies the network of an event
+type NetworkPayload struct {
+	EventMeta
+	NetworkID      string `json:"network_id,omitempty"`
+	CIDR           string `json:"cidr,omitempty"`
+	Name           string `json:"name,omitempty"`
+	DevicesDeleted *int   `json:"devices_deleted,omitempty"`
+}
+
+// LocalIPPayload records an address of this host
+type LocalIPPayload struct {
+	EventMeta
+	IPv4      string `json:"ipv4"`
+	Interface string `json:"interface,omitempty"`
+}
+
+// PathChangedPayload records a changed route to a traceroute target
+type PathChangedPayload struct {
+	EventMeta
+	Target    string   `json:"target"`
+	NetworkID string   `json:"network_id,omitempty"`
+	OldHops   []string `json:"old_hops"`
+	NewHops   []string `json:"new_hops"`
+}
+
+// ThresholdPayload records a measurement that crossed a threshold, for
+// Warning events
+type ThresholdPayload struct {
+	EventMeta
+	Source    string   `json:"source"`
+	Metric    string   `json:"metric,omitempty"`
+	Value     *float64 `json:"value,omitempty"`
+	Threshold *float64 `json:"threshold,omitempty"`
+	Unit      string   `json:"unit,omitempty"`
+}
+
+// Sources of Alert events
+const (
+	AlertSourceRogueRouter = "rogue_router"
+	AlertSourceRogueDHCP   = "rogue_dhcp_server"
+	// An IPv4 address moved to another MAC address: a duplicate address or
+	// ARP spoofing
+	AlertSourceIPConflict = "ip_conflict"
+	// The default gateway's MAC address changed, the usual sign of ARP spoofing
+	AlertSourceGatewayMAC = "gateway_mac_changed"
+	// One MAC address answered for many IPv4 addresses
+	AlertSourceMACConflict = "mac_conflict"
+)
+
+// AlertPayload describes a security finding, for Alert events. Source names
+// the kind of finding; Server is set for findings about a router or DHCP
+// server, PreviousMAC and IPs for address conflicts.
+type AlertPayload struct {
+	EventMeta
+	Source      string       `json:"source"`
+	NetworkID   string       `json:"network_id,omitempty"`
+	IP          string       `json:"ip,omitempty"`
+	MAC         string       `json:"mac,omitempty"`
+	PreviousMAC string       `json:"previous_mac,omitempty"`
+	IPs         []string     `json:"ips,omitempty"`
+	Server      *InfraServer `json:"server,omitempty"`
+}
+
+// WakePayload records a Wake-on-LAN attempt. Confirmed is set on the event
+// logged when confirmation was requested and the wait is over.
+type WakePayload struct {
+	EventMeta
+	IPv4               string   `json:"ipv4,omitempty"`
+	MAC                string   `json:"mac"`
+	Interface          string   `json:"interface,omitempty"`
+	Broadcasts         []string `json:"broadcasts,omitempty"`
+	Confirmed          *bool    `json:"confirmed,omitempty"`
+	OnlineAfterSeconds *float64 `json:"online_after_seconds,omitempty"`
+}
+
+// eventPayloads maps each event type to its payload type
+var eventPayloads = map[EEventLogType]func() EventPayload{
+	PingSweep:          func() EventPayload { return &ScanPayload{} },
+	ScanStarted:        func() EventPayload { return &ScanPayload{} },
+	ScanStopped:        func() EventPayload { return &ScanPayload{} },
+	PortScanStarted:    func() EventPayload { return &PortScanPayload{} },
+	PortScanCompleted:  func() EventPayload { return &PortScanPayload{} },
+	DeviceOnline:       func() EventPayload { return &DeviceStatusPayload{} },
+	DeviceIdle:         func() EventPayload { return &DeviceStatusPayload{} },
+	DeviceOffline:      func() EventPayload { return &DeviceStatusPayload{} },
+	DeviceDeleted:      func() EventPayload { return &DevicePayload{} },
+	LocalIPFound:       func() EventPayload { return &LocalIPPayload{} },
+	LocalNetworkFound:  func() EventPayload { return &NetworkPayload{} },
+	NetworkCreated:     func() EventPayload { return &NetworkPayload{} },
+	NetworkUpdated:     func() EventPayload { return &NetworkPayload{} },
+	NetworkDeleted:     func() EventPayload { return &NetworkPayload{} },
+	NewNetworkDetected: func() EventPayload { return &NetworkPayload{} },
+	PathChanged:        func() EventPayload { return &PathChangedPayload{} },
+	Warning:            func() EventPayload { return &ThresholdPayload{} },
+	Alert:              func() EventPayload { return &AlertPayload{} },
+	WakeOnLAN:          func() EventPayload { return &WakePayload{} },
+}
+
+// NewEventPayload returns an empty payload of the type's payload type, or nil
+// for types without one
+func NewEventPayload(eventType EEventLogType) EventPayload {
+	if newPayload, ok := eventPayloads[eventType]; ok {
+		return newPayload()
+	}
+	return nil
+}
+
+// SetPayload stores payload on the event after filling in the severity of the
+// event type and, when empty, the system actor. A nil payload stores only
+// those common fields.
+func (e *EventLog) SetPayload(payload EventPayload) error {
+	expected := NewEventPayload(e.Type)
+	switch {
+	case payload == nil && expected == nil:
+		payload = &EventMeta{}
+	case payload == nil:
+		payload = expected
+	case expected != nil && reflect.TypeOf(payload) != reflect.TypeOf(expected):
+		return fmt.Errorf("event type %q takes a %T payload, not %T", e.Type, expected, payload)
+	}
+
+	meta := payload.meta()
+	meta.Severity = e.Type.Severity()
+	if meta.Actor == "" {
+		meta.Actor = ActorSystem
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s payload: %v", e.Type, err)
+	}
+	e.Payload = data
+	return nil
+}
+
+// DecodePayload returns the event's payload as its payload type. Events
+// stored before payloads existed decode to an empty payload with the severity
+// filled in.
+func (e *EventLog) DecodePayload() (EventPayload, error) {
+	payload := NewEventPayload(e.Type)
+	if payload == nil {
+		payload = &EventMeta{}
+	}
+	if len(e.Payload) > 0 {
+		if err := json.Unmarshal(e.Payload, payload); err != nil {
+			return nil, fmt.Errorf("failed to decode %s payload: %v", e.Type, err)
+		}
+	}
+	if payload.meta().Severity == "" {
+		payload.meta().Severity = e.Type.Severity()
+	}
+	return payload, nil
+}
+
+// DeviceOnlineEvent returns the event recording that a sweep found device
+func DeviceOnlineEvent(device *Device) *EventLog {
+	deviceID := device.ID
+	eventLog := &EventLog{Type: DeviceOnline, DeviceID: &deviceID}
+	// Cannot fail, the payload has the type's payload type
+	_ = eventLog.SetPayload(&DeviceStatusPayload{IPv4: device.IPv4, Status: DeviceStatusOnline})
+	return eventLog
+}
+
+// NewPortScanPayload returns the payload of a port scan of ipv4 that found
+// current where the previous scan found previous
+func NewPortScanPayload(ipv4 string, previous, current []Port) *PortScanPayload {
+	payload := &PortScanPayload{
+		IPv4:         ipv4,
+		OpenPorts:    []string{},
+		AddedPorts:   []string{},
+		RemovedPorts: []string{},
+	}
+	seen := make(map[string]bool, len(previous))
+	for _, port := range previous {
+		seen[port.String()] = true
+	}
+	for _, port := range current {
+		key := port.String()
+		payload.OpenPorts = append(payload.OpenPorts, key)
+		if !seen[key] {
+			payload.AddedPorts = append(payload.AddedPorts, key)
+		}
+		delete(seen, key)
+	}
+	for _, port := range previous {
+		if key := port.String(); seen[key] {
+			payload.RemovedPorts = append(payload.RemovedPorts, key)
+			delete(seen, key)
+		}
+	}
+	return payload
+}
+
+// EventPayloadSchemas returns a JSON Schema of the payload of every event type
+func EventPayloadSchemas() map[EEventLogType]map[string]interface{} {
+	schemas := make(map[EEventLogType]map[string]interface{}, len(eventPayloads))
+	for eventType, newPayload := range eventPayloads {
+		schemas[eventType] = jsonSchema(reflect.TypeOf(newPayload()))
+	}
+	return schemas
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// jsonSchema describes a payload type; it covers the kinds payloads use
+func jsonSchema(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case t.Kind() == reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case t.Kind() == reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": jsonSchema(t.Elem())}
+	case t.Kind() != reflect.Struct:
+		return map[string]interface{}{}
+	}
+
+	properties := make(map[string]interface{})
+	var required []string
+	var addFields func(t reflect.Type)
+	addFields = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.Anonymous {
+				addFields(field.Type)
+				continue
+			}
+			name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "" || name == "-" {
+				continue
+			}
+			properties[name] = jsonSchema(field.Type)
+			if !strings.Contains(options, "omitempty") {
+				required = append(required, name)
+			}
+		}
+	}
+	addFields(t)
+	sort.Strings(required)
+
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventLog_SetPayload(t *testing.T) {
+	eventLog := &EventLog{Type: DeviceOffline}
+	require.NoError(t, eventLog.SetPayload(&DeviceStatusPayload{
+		EventMeta:      EventMeta{Actor: UserActor("admin")},
+		PreviousStatus: DeviceStatusIdle,
+		Status:         DeviceStatusOffline,
+	}))
+
+	var stored map[string]interface{}
+	require.NoError(t, json.Unmarshal(eventLog.Payload, &stored))
+	assert.Equal(t, "warning", stored["severity"])
+	assert.Equal(t, "user:admin", stored["actor"])
+	assert.Equal(t, string(DeviceStatusOffline), stored["status"])
+
+	payload, err := eventLog.DecodePayload()
+	require.NoError(t, err)
+	status, ok := payload.(*DeviceStatusPayload)
+	require.True(t, ok)
+	assert.Equal(t, DeviceStatusIdle, status.PreviousStatus)
+
+	err = eventLog.SetPayload(&ScanPayload{})
+	assert.Error(t, err, "a device status event takes a DeviceStatusPayload")
+}
+
+func TestEventLog_DecodePayloadWithoutPayload(t *testing.T) {
+	payload, err := (&EventLog{Type: Alert}).DecodePayload()
+	require.NoError(t, err)
+	alert, ok := payload.(*AlertPayload)
+	require.True(t, ok)
+	assert.Equal(t, SeverityCritical, alert.Severity)
+
+	eventLog := &EventLog{Type: EEventLogType("Something new")}
+	require.NoError(t, eventLog.SetPayload(nil))
+	assert.JSONEq(t, `{"severity":"info","actor":"system"}`, string(eventLog.Payload))
+}
+
+func TestNewPortScanPayload(t *testing.T) {
+	previous := []Port{{Number: "22", Protocol: "tcp"}, {Number: "53", Protocol: "udp"}}
+	current := []Port{{Number: "53", Protocol: "udp"}, {Number: "8080", Protocol: "tcp"}}
+
+	payload := NewPortScanPayload("10.0.0.5", previous, current)
+	assert.Equal(t, []string{"53/udp", "8080/tcp"}, payload.OpenPorts)
+	assert.Equal(t, []string{"8080/tcp"}, payload.AddedPorts)
+	assert.Equal(t, []string{"22/tcp"}, payload.RemovedPorts)
+
+	empty := NewPortScanPayload("10.0.0.5", nil, nil)
+	data, err := json.Marshal(empty)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"open_ports":[]`)
+}
+
+func TestEventPayloadSchemas(t *testing.T) {
+	schemas := EventPayloadSchemas()
+	for _, eventType := range EventLogTypes {
+		assert.Contains(t, schemas, eventType, "every event type has a payload schema")
+	}
+
+	schema := schemas[PathChanged]
+	assert.Equal(t, "object", schema["type"])
+	properties := schema["properties"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}}, properties["new_hops"])
+	assert.Contains(t, properties, "severity")
+	assert.Equal(t, []string{"actor", "new_hops", "old_hops", "severity", "target"}, schema["required"])
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// ExclusionKind is what an exclusion matches
+type ExclusionKind string
+
+const (
+	ExclusionKindIP   ExclusionKind = "ip"
+	ExclusionKindCIDR ExclusionKind = "cidr"
+	ExclusionKindMAC  ExclusionKind = "mac"
+)
+
+// Exclusion keeps an address, a range or a MAC address from ever being
+// scanned. Exclusions without a network apply to all networks.
+type Exclusion struct {
+	ID        string        `bson:"_id,omitempty" json:"id"`
+	NetworkID string        `bson:"network_id" json:"network_id,omitempty"`
+	Kind      ExclusionKind `bson:"kind" json:"kind"`
+	Value     string        `bson:"value" json:"value"`
+	Comment   string        `bson:"comment,omitempty" json:"comment,omitempty"`
+	CreatedAt time.Time     `bson:"created_at" json:"created_at"`
+}
+
+// ParseExclusion works out the kind of an excluded value and normalizes it:
+// addresses and ranges in canonical form, MAC addresses in upper case like
+// nmap reports them
+func ParseExclusion(value string) (ExclusionKind, string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", "", fmt.Errorf("exclusion is empty")
+	}
+	if ip := net.ParseIP(value); ip != nil {
+		return ExclusionKindIP, ip.String(), nil
+	}
+	if _, ipNet, err := net.ParseCIDR(value); err == nil {
+		return ExclusionKindCIDR, ipNet.String(), nil
+	}
+	if hw, err := net.ParseMAC(value); err == nil && len(hw) == 6 {
+		return ExclusionKindMAC, strings.ToUpper(hw.String()), nil
+	}
+	return "", "", fmt.Errorf("invalid exclusion %q, expected an IP address, a CIDR range or a MAC address", value)
+}
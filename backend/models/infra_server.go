@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// InfraServerKind is the kind of address configuration server reconya
+// watches for
+type InfraServerKind string
+
+const (
+	InfraServerRouter InfraServerKind = "router" // sends IPv6 router advertisements
+	InfraServerDHCPv4 InfraServerKind = "dhcpv4"
+	InfraServerDHCPv6 InfraServerKind = "dhcpv6"
+)
+
+// Router advertisement flags, as stored in InfraServer.Flags
+const (
+	RAFlagManaged     = "managed"      // addresses from DHCPv6
+	RAFlagOtherConfig = "other_config" // other configuration from DHCPv6
+	RAFlagHomeAgent   = "home_agent"
+	RAPreferenceHigh  = "preference_high"
+	RAPreferenceLow   = "preference_low"
+)
+
+// InfraServer is a router or DHCP server seen on a network. A server is
+// identified by its kind, network, address and MAC address, so a rogue server
+// reusing the address of a legitimate one is a different server. Servers that
+// are not allowed raise an Alert when they first appear.
+type InfraServer struct {
+	ID        string          `bson:"_id,omitempty" json:"id"`
+	NetworkID string          `bson:"network_id" json:"network_id,omitempty"`
+	Kind      InfraServerKind `bson:"kind" json:"kind"`
+	IP        string          `bson:"ip" json:"ip"`
+	MAC       string          `bson:"mac" json:"mac,omitempty"`
+	Interface string          `bson:"interface" json:"interface,omitempty"`
+	// Router advertisements
+	Prefixes              []string `bson:"prefixes,omitempty" json:"prefixes,omitempty"`
+	Flags                 []string `bson:"flags,omitempty" json:"flags,omitempty"`
+	RouterLifetimeSeconds *int     `bson:"router_lifetime_seconds,omitempty" json:"router_lifetime_seconds,omitempty"`
+	// DHCP offers
+	OfferedAddress string    `bson:"offered_address,omitempty" json:"offered_address,omitempty"`
+	DNSServers     []string  `bson:"dns_servers,omitempty" json:"dns_servers,omitempty"`
+	Allowed        bool      `bson:"allowed" json:"allowed"`
+	FirstSeenAt    time.Time `bson:"first_seen_at" json:"first_seen_at"`
+	LastSeenAt     time.Time `bson:"last_seen_at" json:"last_seen_at"`
+}
+
+// SameServer reports whether other is a sighting of the same server
+func (s *InfraServer) SameServer(other *InfraServer) bool {
+	return s.Kind == other.Kind && s.NetworkID == other.NetworkID && s.IP == other.IP && s.MAC == other.MAC
+}
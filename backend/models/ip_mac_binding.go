@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Where an IP to MAC address binding was observed
+const (
+	BindingSourceSweep    = "sweep"
+	BindingSourceNeighbor = "neighbor"
+)
+
+// IPMACBinding records that an IPv4 address resolved to a MAC address between
+// FirstSeenAt and LastSeenAt. The recent bindings of an address or a MAC
+// address show when one flips to another or claims many addresses.
+type IPMACBinding struct {
+	IP          string    `bson:"ip" json:"ip"`
+	MAC         string    `bson:"mac" json:"mac"`
+	NetworkID   string    `bson:"network_id" json:"network_id,omitempty"`
+	Source      string    `bson:"source" json:"source"`
+	FirstSeenAt time.Time `bson:"first_seen_at" json:"first_seen_at"`
+	LastSeenAt  time.Time `bson:"last_seen_at" json:"last_seen_at"`
+}
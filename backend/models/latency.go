@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// LatencyResolution is the aggregation level of a latency sample
+type LatencyResolution string
+
+const (
+	LatencyResolutionRaw  LatencyResolution = "raw"
+	LatencyResolutionHour LatencyResolution = "hour"
+	LatencyResolutionDay  LatencyResolution = "day"
+)
+
+// LatencySample holds the RTT, jitter and loss measured for a device. Raw
+// samples are one probe round; hour and day samples aggregate older rounds.
+type LatencySample struct {
+	DeviceID    string            `bson:"device_id" json:"device_id"`
+	Resolution  LatencyResolution `bson:"resolution" json:"resolution"`
+	Timestamp   time.Time         `bson:"timestamp" json:"timestamp"`
+	RTTMinMs    *float64          `bson:"rtt_min_ms,omitempty" json:"rtt_min_ms,omitempty"`
+	RTTAvgMs    *float64          `bson:"rtt_avg_ms,omitempty" json:"rtt_avg_ms,omitempty"`
+	RTTMaxMs    *float64          `bson:"rtt_max_ms,omitempty" json:"rtt_max_ms,omitempty"`
+	JitterMs    *float64          `bson:"jitter_ms,omitempty" json:"jitter_ms,omitempty"`
+	LossPercent float64           `bson:"loss_percent" json:"loss_percent"`
+	Sent        int               `bson:"sent" json:"sent"`
+	Received    int               `bson:"received" json:"received"`
+}
+
+// LatencyMonitor marks a device for periodic latency probing
+type LatencyMonitor struct {
+	DeviceID             string    `bson:"device_id" json:"device_id"`
+	RTTThresholdMs       float64   `bson:"rtt_threshold_ms" json:"rtt_threshold_ms"`
+	LossThresholdPercent float64   `bson:"loss_threshold_percent" json:"loss_threshold_percent"`
+	Degraded             bool      `bson:"degraded" json:"degraded"`
+	CreatedAt            time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt            time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// IsDegraded reports whether a sample breaches the monitor thresholds
+func (m *LatencyMonitor) IsDegraded(sample *LatencySample) bool {
+	if m.LossThresholdPercent > 0 && sample.LossPercent >= m.LossThresholdPercent {
+		return true
+	}
+	if m.RTTThresholdMs > 0 && sample.RTTAvgMs != nil && *sample.RTTAvgMs >= m.RTTThresholdMs {
+		return true
+	}
+	return false
+}
@@ -27,13 +27,17 @@ type Network struct {
 	Status        string        `bson:"status" json:"status"` // active, inactive, scanning
 	LastScannedAt *time.Time    `bson:"last_scanned_at" json:"last_scanned_at"`
 	DeviceCount   int           `bson:"device_count" json:"device_count"`
-	CreatedAt     time.Time     `bson:"created_at" json:"created_at"`
-	UpdatedAt     time.Time     `bson:"updated_at" json:"updated_at"`
+	// Scan pacing; nil and empty fall back to SCAN_RATE_LIMIT and SCAN_WINDOWS
+	RateLimit   *int      `bson:"rate_limit,omitempty" json:"rate_limit,omitempty"`     // packets per second
+	ScanWindows string    `bson:"scan_windows,omitempty" json:"scan_windows,omitempty"` // e.g. "Mon-Fri 22:00-06:00"
+	CreatedAt   time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time `bson:"updated_at" json:"updated_at"`
 }
 
-// Helper methods for dual-stack network support
+// Helper methods for dual-stack network support. Networks without an
+// address family predate dual-stack support and are IPv4 networks.
 func (n *Network) IsIPv4Enabled() bool {
-	return n.AddressFamily == AddressFamilyIPv4 || n.AddressFamily == AddressFamilyDual
+	return n.AddressFamily == "" || n.AddressFamily == AddressFamilyIPv4 || n.AddressFamily == AddressFamilyDual
 }
 
 func (n *Network) IsIPv6Enabled() bool {
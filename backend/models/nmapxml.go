@@ -23,6 +23,7 @@ type NmapXMLHostname struct {
 
 // Update NmapXMLHost to include Addresses
 type NmapXMLHost struct {
+	Status    NmapXMLState      `xml:"status"`
 	Addresses []NmapXMLAddress  `xml:"address"` // Add this line to include address information
 	Ports     []NmapXMLPort     `xml:"ports>port"`
 	Hostnames []NmapXMLHostname `xml:"hostnames>hostname"`
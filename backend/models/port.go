@@ -7,3 +7,8 @@ type Port struct {
 	State    string `bson:"state" json:"state"`       // State (e.g., "open")
 	Service  string `bson:"service" json:"service"`   // Service name (e.g., "http")
 }
+
+// String returns the port as "number/protocol"
+func (p Port) String() string {
+	return p.Number + "/" + p.Protocol
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// EventLogRollup counts the events of one type, and device, on one day. Rollups
+// are written before raw events are pruned so long-term trends survive.
+type EventLogRollup struct {
+	Day      string        `json:"day"`
+	Type     EEventLogType `json:"type"`
+	DeviceID *string       `json:"device_id,omitempty"`
+	Count    int           `json:"count"`
+}
+
+// RetentionResult reports what one retention rule removed
+type RetentionResult struct {
+	Table   string    `json:"table"`
+	Type    string    `json:"type,omitempty"`
+	Before  time.Time `json:"before"`
+	Deleted int64     `json:"deleted"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// ScanPolicyScope is what a scan policy applies to
+type ScanPolicyScope string
+
+const (
+	ScanPolicyScopeNetwork    ScanPolicyScope = "network"
+	ScanPolicyScopeDeviceType ScanPolicyScope = "device_type"
+	ScanPolicyScopeTag        ScanPolicyScope = "tag"
+	ScanPolicyScopeDevice     ScanPolicyScope = "device"
+)
+
+// IsValid reports whether the scope is known
+func (s ScanPolicyScope) IsValid() bool {
+	switch s {
+	case ScanPolicyScopeNetwork, ScanPolicyScopeDeviceType, ScanPolicyScopeTag, ScanPolicyScopeDevice:
+		return true
+	}
+	return false
+}
+
+// ScanPolicy overrides how devices are scanned. A nil value inherits from the
+// next broader scope: device, tag, device type, network, global.
+type ScanPolicy struct {
+	Scope       ScanPolicyScope `bson:"scope" json:"scope"`
+	ScopeID     string          `bson:"scope_id" json:"scope_id"`
+	Exclude     *bool           `bson:"exclude,omitempty" json:"exclude,omitempty"`           // never scanned, not even pinged
+	PingOnly    *bool           `bson:"ping_only,omitempty" json:"ping_only,omitempty"`       // discovered but never port scanned
+	Ports       *string         `bson:"ports,omitempty" json:"ports,omitempty"`               // nmap port spec, e.g. "22,80,8000-8100"
+	TopPorts    *int            `bson:"top_ports,omitempty" json:"top_ports,omitempty"`       // nmap --top-ports
+	UDP         *bool           `bson:"udp,omitempty" json:"udp,omitempty"`                   // also scan UDP ports
+	OSDetection *bool           `bson:"os_detection,omitempty" json:"os_detection,omitempty"` // run nmap OS detection
+	Screenshots *bool           `bson:"screenshots,omitempty" json:"screenshots,omitempty"`   // capture web service screenshots
+	MaxRate     *int            `bson:"max_rate,omitempty" json:"max_rate,omitempty"`         // packets per second
+	UpdatedAt   time.Time       `bson:"updated_at" json:"updated_at"`
+}
+
+// IsEmpty reports whether the policy overrides nothing
+func (p *ScanPolicy) IsEmpty() bool {
+	return p.Exclude == nil && p.PingOnly == nil && p.Ports == nil && p.TopPorts == nil &&
+		p.UDP == nil && p.OSDetection == nil && p.Screenshots == nil && p.MaxRate == nil
+}
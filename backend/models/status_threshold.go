@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// ThresholdScope is what a status threshold applies to
+type ThresholdScope string
+
+const (
+	ThresholdScopeNetwork    ThresholdScope = "network"
+	ThresholdScopeDeviceType ThresholdScope = "device_type"
+	ThresholdScopeDevice     ThresholdScope = "device"
+)
+
+// IsValid reports whether the scope is known
+func (s ThresholdScope) IsValid() bool {
+	switch s {
+	case ThresholdScopeNetwork, ThresholdScopeDeviceType, ThresholdScopeDevice:
+		return true
+	}
+	return false
+}
+
+// StatusThreshold overrides when devices become idle and offline. A nil value
+// inherits from the next broader scope: device, device type, network, global.
+type StatusThreshold struct {
+	Scope               ThresholdScope `bson:"scope" json:"scope"`
+	ScopeID             string         `bson:"scope_id" json:"scope_id"`
+	IdleAfterSeconds    *int           `bson:"idle_after_seconds,omitempty" json:"idle_after_seconds,omitempty"`
+	OfflineAfterSeconds *int           `bson:"offline_after_seconds,omitempty" json:"offline_after_seconds,omitempty"`
+	UpdatedAt           time.Time      `bson:"updated_at" json:"updated_at"`
+}
+
+// DeviceActivity is the subset of a device the status engine evaluates
+type DeviceActivity struct {
+	DeviceID         string
+	Status           DeviceStatus
+	DeviceType       DeviceType
+	NetworkID        string
+	LastSeenOnlineAt *time.Time
+}
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// TracerouteMethod is the probe type used to discover a path
+type TracerouteMethod string
+
+const (
+	TracerouteMethodICMP TracerouteMethod = "icmp"
+	TracerouteMethodUDP  TracerouteMethod = "udp"
+	TracerouteMethodTCP  TracerouteMethod = "tcp"
+)
+
+// TracerouteHop is a single TTL step of a traceroute run
+type TracerouteHop struct {
+	TTL       int      `bson:"ttl" json:"ttl"`
+	IP        *string  `bson:"ip,omitempty" json:"ip,omitempty"`
+	Hostname  *string  `bson:"hostname,omitempty" json:"hostname,omitempty"`
+	RTTMillis *float64 `bson:"rtt_ms,omitempty" json:"rtt_ms,omitempty"`
+}
+
+// TracerouteRun is a stored path discovery towards a network or device
+type TracerouteRun struct {
+	ID          string           `bson:"_id,omitempty" json:"id"`
+	NetworkID   *string          `bson:"network_id,omitempty" json:"network_id,omitempty"`
+	DeviceID    *string          `bson:"device_id,omitempty" json:"device_id,omitempty"`
+	Target      string           `bson:"target" json:"target"`
+	Method      TracerouteMethod `bson:"method" json:"method"`
+	Reached     bool             `bson:"reached" json:"reached"`
+	PathChanged bool             `bson:"path_changed" json:"path_changed"`
+	Hops        []TracerouteHop  `bson:"hops" json:"hops"`
+	StartedAt   time.Time        `bson:"started_at" json:"started_at"`
+	CompletedAt time.Time        `bson:"completed_at" json:"completed_at"`
+}
+
+// HopAddresses lists the hop addresses of a run, using "*" for silent hops
+func (r *TracerouteRun) HopAddresses() []string {
+	parts := make([]string, 0, len(r.Hops))
+	for _, hop := range r.Hops {
+		if hop.IP == nil {
+			parts = append(parts, "*")
+			continue
+		}
+		parts = append(parts, *hop.IP)
+	}
+	return parts
+}
+
+// PathString renders the hop addresses of a run
+func (r *TracerouteRun) PathString() string {
+	return strings.Join(r.HopAddresses(), " > ")
+}
+
+// SamePath reports whether two runs took the same route. Hops that did not
+// answer in either run are ignored so that rate-limited routers don't cause
+// spurious path changes.
+func (r *TracerouteRun) SamePath(other *TracerouteRun) bool {
+	if other == nil {
+		return false
+	}
+	if r.Reached != other.Reached {
+		return false
+	}
+	if r.Reached && len(r.Hops) != len(other.Hops) {
+		return false
+	}
+
+	n := len(r.Hops)
+	if len(other.Hops) < n {
+		n = len(other.Hops)
+	}
+	for i := 0; i < n; i++ {
+		a, b := r.Hops[i].IP, other.Hops[i].IP
+		if a == nil || b == nil {
+			continue
+		}
+		if *a != *b {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"testing"
+)
+
+func TestTracerouteRun_SamePath(t *testing.T) {
+	run := func(reached bool, hops ...string) *TracerouteRun {
+		r := &TracerouteRun{Reached: reached}
+		for i, hop := range hops {
+			h := TracerouteHop{TTL: i + 1}
+			if hop != "*" {
+				ip := hop
+				h.IP = &ip
+			}
+			r.Hops = append(r.Hops, h)
+		}
+		return r
+	}
+
+	tests := []struct {
+		name string
+		a, b *TracerouteRun
+		want bool
+	}{
+		{"identical", run(true, "10.0.0.1", "10.0.1.1"), run(true, "10.0.0.1", "10.0.1.1"), true},
+		{"silent hop ignored", run(true, "10.0.0.1", "*", "10.0.2.1"), run(true, "10.0.0.1", "10.0.1.1", "10.0.2.1"), true},
+		{"different hop", run(true, "10.0.0.1", "10.0.1.1"), run(true, "10.0.0.1", "10.0.9.1"), false},
+		{"reached with more hops", run(true, "10.0.0.1", "10.0.1.1"), run(true, "10.0.0.1", "10.0.5.1", "10.0.1.1"), false},
+		{"reached and unreachable", run(true, "10.0.0.1"), run(false, "10.0.0.1"), false},
+		{"unreachable prefixes", run(false, "10.0.0.1", "10.0.1.1"), run(false, "10.0.0.1"), true},
+		{"no previous run", run(true, "10.0.0.1"), nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.SamePath(tt.b); got != tt.want {
+				t.Errorf("SamePath() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
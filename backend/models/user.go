@@ -1,8 +1,11 @@
 package models
 
+import "time"
+
 // User represents a user in the system
 type User struct {
-	ID       int    `json:"id"`
-	Username string `json:"username"`
-	Password string `json:"-"` // Never serialize password
+	ID        int       `json:"id"`
+	Username  string    `json:"username"`
+	Password  string    `json:"-"` // Never serialize password
+	CreatedAt time.Time `json:"created_at,omitempty"`
 }
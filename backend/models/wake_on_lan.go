@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// WakeResult describes a Wake-on-LAN attempt: where the magic packets went
+// and, when confirmation was requested, whether the device came online
+type WakeResult struct {
+	DeviceID   string    `json:"device_id"`
+	MAC        string    `json:"mac"`
+	Interface  string    `json:"interface,omitempty"`
+	Broadcasts []string  `json:"broadcasts"`
+	Port       int       `json:"port"`
+	SentAt     time.Time `json:"sent_at"`
+	// Confirmed is nil unless confirmation was requested
+	Confirmed          *bool    `json:"confirmed,omitempty"`
+	OnlineAfterSeconds *float64 `json:"online_after_seconds,omitempty"`
+}
@@ -0,0 +1,112 @@
+package integration
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"reconya-ai/db"
+	"reconya-ai/internal/backup"
+	"reconya-ai/tests/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupService_Integration(t *testing.T) {
+	testDB, cleanup := testutils.SetupTestDatabase(t)
+	defer cleanup()
+
+	cfg := testutils.GetTestConfig()
+	cfg.BackupDir = t.TempDir()
+	cfg.BackupKeepDaily = 7
+	cfg.BackupKeepWeekly = 4
+	migrator := db.NewMigrator(testDB)
+	backupService := backup.NewBackupService(testDB, migrator, cfg)
+
+	countNetworks := func() int {
+		var count int
+		require.NoError(t, testDB.QueryRow(`SELECT COUNT(*) FROM networks`).Scan(&count))
+		return count
+	}
+
+	_, err := testDB.Exec(`INSERT INTO networks (id, cidr, name) VALUES ('n1', '10.1.0.0/24', 'before')`)
+	require.NoError(t, err)
+
+	t.Run("CreateAndRestorePlain", func(t *testing.T) {
+		created, err := backupService.Create()
+		require.NoError(t, err)
+		assert.False(t, created.Encrypted)
+		assert.Positive(t, created.Size)
+
+		_, err = testDB.Exec(`INSERT INTO networks (id, cidr, name) VALUES ('n2', '10.2.0.0/24', 'after')`)
+		require.NoError(t, err)
+		assert.Equal(t, 2, countNetworks())
+
+		path, err := backupService.Path(created.Name)
+		require.NoError(t, err)
+		result, err := backupService.Restore(path)
+		require.NoError(t, err)
+		assert.Equal(t, migrator.Latest(), result.BackupVersion)
+		assert.Equal(t, migrator.Latest(), result.SchemaVersion)
+		assert.NotEmpty(t, result.SafetyBackup)
+
+		// The connection pool keeps working and sees the restored data
+		assert.Equal(t, 1, countNetworks())
+
+		backups, err := backupService.List()
+		require.NoError(t, err)
+		assert.Len(t, backups, 2)
+	})
+
+	t.Run("EncryptedBackupNeedsPassphrase", func(t *testing.T) {
+		cfg.BackupPassphrase = "correct horse"
+		defer func() { cfg.BackupPassphrase = "" }()
+
+		created, err := backupService.Create()
+		require.NoError(t, err)
+		assert.True(t, created.Encrypted)
+
+		path, err := backupService.Path(created.Name)
+		require.NoError(t, err)
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.True(t, backup.IsArchive(data))
+
+		cfg.BackupPassphrase = "wrong horse"
+		_, err = backupService.Restore(path)
+		assert.ErrorIs(t, err, backup.ErrWrongPassphrase)
+
+		cfg.BackupPassphrase = "correct horse"
+		_, err = backupService.Restore(path)
+		require.NoError(t, err)
+		assert.Equal(t, 1, countNetworks())
+	})
+
+	t.Run("RejectsNewerSchema", func(t *testing.T) {
+		future := filepath.Join(t.TempDir(), "future.db")
+		require.NoError(t, db.BackupTo(testDB, future))
+
+		futureDB, err := db.ConnectToSQLite(future)
+		require.NoError(t, err)
+		_, err = futureDB.Exec(`INSERT INTO schema_migrations (version, name, applied_at) VALUES (9999, 'future', CURRENT_TIMESTAMP)`)
+		require.NoError(t, err)
+		require.NoError(t, db.Vacuum(futureDB))
+		futureDB.Close()
+
+		_, err = backupService.Restore(future)
+		assert.ErrorContains(t, err, "newer than this build supports")
+		assert.Equal(t, 1, countNetworks())
+	})
+
+	t.Run("RejectsOtherFiles", func(t *testing.T) {
+		junk := filepath.Join(t.TempDir(), "junk.db")
+		require.NoError(t, os.WriteFile(junk, []byte("not a database"), 0600))
+
+		_, err := backupService.Restore(junk)
+		assert.Error(t, err)
+
+		_, err = backupService.Path("../reconya-backup-20240601-020000.db")
+		assert.Error(t, err)
+	})
+}
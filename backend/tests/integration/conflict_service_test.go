@@ -0,0 +1,109 @@
+package integration
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"reconya-ai/internal/conflict"
+	"reconya-ai/internal/device"
+	"reconya-ai/internal/eventlog"
+	"reconya-ai/internal/network"
+	"reconya-ai/models"
+	"reconya-ai/tests/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConflictService_Observe(t *testing.T) {
+	factory, cleanup := testutils.SetupTestRepositoryFactory(t)
+	defer cleanup()
+
+	cfg := testutils.GetTestConfig()
+	cfg.ConflictWindow = 15 * time.Minute
+	cfg.ConflictMaxIPsPerMAC = 3
+	cfg.MultiHomedHosts = []string{"192.168.1.50", "aa:bb:cc:00:00:60", "aa:bb:cc:00:00:61"}
+	networkService := network.NewNetworkService(factory.NewNetworkRepository(), cfg)
+	deviceService := device.NewDeviceService(factory.NewDeviceRepository(), networkService, cfg, nil)
+	eventLogService := eventlog.NewEventLogService(factory.NewEventLogRepository(), deviceService)
+	service := conflict.NewConflictService(factory.NewIPMACBindingRepository(), networkService, eventLogService, cfg)
+	service.Gateways = func() []string { return []string{"192.168.1.1"} }
+
+	lan, err := networkService.Create("LAN", "192.168.1.0/24", "")
+	require.NoError(t, err)
+
+	alerts := func() []*models.AlertPayload {
+		page, err := eventLogService.Search(models.EventLogFilter{Types: []models.EEventLogType{models.Alert}}, "", 50)
+		require.NoError(t, err)
+		var payloads []*models.AlertPayload
+		for _, eventLog := range page.EventLogs {
+			payload, err := eventLog.DecodePayload()
+			require.NoError(t, err)
+			payloads = append(payloads, payload.(*models.AlertPayload))
+		}
+		return payloads
+	}
+	observe := func(ip, mac string) {
+		require.NoError(t, service.Observe("", ip, mac, models.BindingSourceNeighbor))
+	}
+
+	t.Run("IPMoved", func(t *testing.T) {
+		mac := "AA:BB:CC:00:00:10"
+		printer := &models.Device{IPv4: "192.168.1.10", MAC: &mac}
+		service.ObserveSweep(lan.ID, []*models.Device{printer})
+		observe("192.168.1.10", "aa:bb:cc:00:00:10")
+		assert.Empty(t, alerts(), "same binding")
+
+		observe("192.168.1.10", "AA:BB:CC:00:00:99")
+		observe("192.168.1.10", "AA:BB:CC:00:00:10")
+		observe("192.168.1.10", "AA:BB:CC:00:00:99")
+
+		payloads := alerts()
+		require.Len(t, payloads, 1, "a flapping address alerts once per window")
+		assert.Equal(t, models.AlertSourceIPConflict, payloads[0].Source)
+		assert.Equal(t, lan.ID, payloads[0].NetworkID)
+		assert.Equal(t, "192.168.1.10", payloads[0].IP)
+		assert.Equal(t, "AA:BB:CC:00:00:99", payloads[0].MAC)
+		assert.Equal(t, "AA:BB:CC:00:00:10", payloads[0].PreviousMAC)
+	})
+
+	t.Run("GatewayMoved", func(t *testing.T) {
+		observe("192.168.1.1", "AA:BB:CC:00:00:01")
+		observe("192.168.1.1", "AA:BB:CC:00:00:66")
+
+		payloads := alerts()
+		require.Len(t, payloads, 2)
+		assert.Equal(t, models.AlertSourceGatewayMAC, payloads[0].Source)
+		assert.Equal(t, "AA:BB:CC:00:00:01", payloads[0].PreviousMAC)
+	})
+
+	t.Run("MultiHomed", func(t *testing.T) {
+		observe("192.168.1.50", "AA:BB:CC:00:00:50")
+		observe("192.168.1.50", "AA:BB:CC:00:00:51")
+		observe("192.168.1.60", "AA:BB:CC:00:00:60")
+		observe("192.168.1.60", "AA:BB:CC:00:00:61")
+		for i := 0; i < 4; i++ {
+			observe(fmt.Sprintf("192.168.1.%d", 70+i), "AA:BB:CC:00:00:60")
+		}
+		assert.Len(t, alerts(), 2)
+	})
+
+	t.Run("MACClaimsManyIPs", func(t *testing.T) {
+		for i := 0; i < 4; i++ {
+			observe(fmt.Sprintf("192.168.1.%d", 100+i), "AA:BB:CC:00:00:77")
+		}
+
+		payloads := alerts()
+		require.Len(t, payloads, 3, "alerts once per window")
+		assert.Equal(t, models.AlertSourceMACConflict, payloads[0].Source)
+		assert.Equal(t, "AA:BB:CC:00:00:77", payloads[0].MAC)
+		assert.Equal(t, []string{"192.168.1.100", "192.168.1.101", "192.168.1.102"}, payloads[0].IPs)
+	})
+
+	t.Run("IgnoresIPv6", func(t *testing.T) {
+		observe("fe80::1", "AA:BB:CC:00:00:01")
+		observe("fe80::1", "AA:BB:CC:00:00:02")
+		assert.Len(t, alerts(), 3)
+	})
+}
@@ -6,7 +6,6 @@ import (
 	"net/http"
 	"testing"
 
-	"reconya-ai/db"
 	"reconya-ai/internal/device"
 	"reconya-ai/internal/network"
 	"reconya-ai/models"
@@ -25,11 +24,10 @@ func TestDeviceHandlers_Integration(t *testing.T) {
 	networkRepo := factory.NewNetworkRepository()
 
 	cfg := testutils.GetTestConfig()
-	dbManager := db.NewDBManager()
 
 	// Create services
-	networkService := network.NewNetworkService(networkRepo, cfg, dbManager)
-	deviceService := device.NewDeviceService(deviceRepo, networkService, cfg, dbManager, nil) // nil OUI service for tests
+	networkService := network.NewNetworkService(networkRepo, cfg)
+	deviceService := device.NewDeviceService(deviceRepo, networkService, cfg, nil) // nil OUI service for tests
 
 	// Create handlers
 	deviceHandlers := device.NewDeviceHandlers(deviceService, cfg)
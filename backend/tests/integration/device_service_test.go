@@ -96,4 +96,65 @@ func TestDeviceRepository_Integration(t *testing.T) {
 		assert.Equal(t, models.DeviceStatusOnline, updatedDevice.Status)
 		assert.NotNil(t, updatedDevice.LastSeenOnlineAt)
 	})
+
+	t.Run("StatusTransitionsRecordIntervals", func(t *testing.T) {
+		availabilityRepo := factory.NewAvailabilityRepository()
+
+		testDevice := createTestDevice("192.168.1.105", "Availability Test Device")
+		lastSeen := time.Now().Add(-5 * time.Minute)
+		testDevice.LastSeenOnlineAt = &lastSeen
+
+		savedDevice, err := deviceRepo.CreateOrUpdate(ctx, testDevice)
+		require.NoError(t, err)
+
+		activity, err := deviceRepo.FindActivity(ctx)
+		require.NoError(t, err)
+
+		var found *models.DeviceActivity
+		for _, a := range activity {
+			if a.DeviceID == savedDevice.ID {
+				found = a
+			}
+		}
+		require.NotNil(t, found)
+		assert.Equal(t, models.DeviceStatusOnline, found.Status)
+
+		transitions := []*models.DeviceStatusTransition{
+			{DeviceID: savedDevice.ID, From: models.DeviceStatusOnline, To: models.DeviceStatusOffline, At: time.Now()},
+			// Stale transition, the device is no longer idle
+			{DeviceID: savedDevice.ID, From: models.DeviceStatusIdle, To: models.DeviceStatusOffline, At: time.Now()},
+		}
+		applied, err := deviceRepo.ApplyStatusTransitions(ctx, transitions)
+		require.NoError(t, err)
+		require.Len(t, applied, 1)
+		assert.Equal(t, models.DeviceStatusOffline, applied[0].To)
+
+		intervals, err := availabilityRepo.FindIntervals(ctx, savedDevice.ID, time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		require.Len(t, intervals, 2)
+		assert.Equal(t, models.DeviceStatusOnline, intervals[0].Status)
+		assert.NotNil(t, intervals[0].EndedAt)
+		assert.Equal(t, models.DeviceStatusOffline, intervals[1].Status)
+		assert.Nil(t, intervals[1].EndedAt)
+	})
+
+	t.Run("TagsRoundTrip", func(t *testing.T) {
+		testDevice := createTestDevice("192.168.1.106", "Tagged Device")
+		testDevice.Tags = []string{"dmz", "servers"}
+
+		savedDevice, err := deviceRepo.CreateOrUpdate(ctx, testDevice)
+		require.NoError(t, err)
+
+		foundDevice, err := deviceRepo.FindByID(ctx, savedDevice.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"dmz", "servers"}, foundDevice.Tags)
+
+		foundDevice.Tags = nil
+		_, err = deviceRepo.CreateOrUpdate(ctx, foundDevice)
+		require.NoError(t, err)
+
+		foundDevice, err = deviceRepo.FindByID(ctx, savedDevice.ID)
+		require.NoError(t, err)
+		assert.Empty(t, foundDevice.Tags)
+	})
 }
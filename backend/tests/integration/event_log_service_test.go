@@ -0,0 +1,127 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"reconya-ai/internal/device"
+	"reconya-ai/internal/eventlog"
+	"reconya-ai/internal/export"
+	"reconya-ai/internal/network"
+	"reconya-ai/models"
+	"reconya-ai/tests/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventLogService_SearchAndExport(t *testing.T) {
+	factory, cleanup := testutils.SetupTestRepositoryFactory(t)
+	defer cleanup()
+
+	cfg := testutils.GetTestConfig()
+	networkService := network.NewNetworkService(factory.NewNetworkRepository(), cfg)
+	deviceRepo := factory.NewDeviceRepository()
+	deviceService := device.NewDeviceService(deviceRepo, networkService, cfg, nil)
+	service := eventlog.NewEventLogService(factory.NewEventLogRepository(), deviceService)
+
+	printer := createTestDevice("192.168.1.20", "printer")
+	_, err := deviceRepo.CreateOrUpdate(context.Background(), printer)
+	require.NoError(t, err)
+
+	start := time.Now().Add(-time.Hour)
+	var eventLogs []*models.EventLog
+	for i := 0; i < 25; i++ {
+		createdAt := start.Add(time.Duration(i) * time.Minute)
+		eventLogs = append(eventLogs, &models.EventLog{
+			Type: models.DeviceOnline, DeviceID: &printer.ID, CreatedAt: &createdAt, UpdatedAt: &createdAt,
+		})
+	}
+	createdAt := start.Add(30 * time.Minute)
+	eventLogs = append(eventLogs, &models.EventLog{
+		Type: models.Alert, Description: "Duplicate address, detected", CreatedAt: &createdAt, UpdatedAt: &createdAt,
+	})
+	require.NoError(t, factory.NewEventLogRepository().CreateBatch(context.Background(), eventLogs))
+
+	t.Run("Pages", func(t *testing.T) {
+		page, err := service.Search(models.EventLogFilter{}, "", 10)
+		require.NoError(t, err)
+		require.Len(t, page.EventLogs, 10)
+		assert.Equal(t, models.Alert, page.EventLogs[0].Type, "newest first")
+		assert.Equal(t, "Live device [192.168.1.20] found", page.EventLogs[1].Description)
+		require.NotEmpty(t, page.NextCursor)
+
+		total := len(page.EventLogs)
+		for page.NextCursor != "" {
+			page, err = service.Search(models.EventLogFilter{}, page.NextCursor, 10)
+			require.NoError(t, err)
+			total += len(page.EventLogs)
+		}
+		assert.Equal(t, 26, total)
+
+		_, err = service.Search(models.EventLogFilter{}, "bogus", 10)
+		assert.ErrorIs(t, err, models.ErrInvalidCursor)
+	})
+
+	t.Run("CSV", func(t *testing.T) {
+		var buf bytes.Buffer
+		count, err := service.Export(&buf, export.FormatCSV, models.EventLogFilter{Severities: []models.EventSeverity{models.SeverityCritical}})
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+
+		records, err := csv.NewReader(&buf).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, records, 2)
+		assert.Equal(t, []string{"id", "created_at", "type", "severity", "description", "device_id", "device_ip", "payload"}, records[0])
+		assert.Equal(t, []string{"Alert", "critical", "Duplicate address, detected"}, records[1][2:5])
+	})
+
+	t.Run("NDJSON", func(t *testing.T) {
+		var buf bytes.Buffer
+		count, err := service.Export(&buf, export.FormatNDJSON, models.EventLogFilter{DeviceID: printer.ID})
+		require.NoError(t, err)
+		assert.Equal(t, 25, count)
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		require.Len(t, lines, 25)
+		var record map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(lines[0]), &record))
+		assert.Equal(t, "192.168.1.20", record["device_ip"])
+		assert.Equal(t, "info", record["severity"])
+		assert.Equal(t, string(models.DeviceOnline), record["type"])
+	})
+
+	t.Run("Payloads", func(t *testing.T) {
+		previous := []models.Port{{Number: "22", Protocol: "tcp"}, {Number: "80", Protocol: "tcp"}}
+		current := []models.Port{{Number: "80", Protocol: "tcp"}, {Number: "443", Protocol: "tcp"}}
+		payload := models.NewPortScanPayload(printer.IPv4, previous, current)
+		require.NoError(t, service.Log(models.PortScanCompleted, "", printer.ID, payload))
+
+		page, err := service.Search(models.EventLogFilter{Types: []models.EEventLogType{models.PortScanCompleted}}, "", 10)
+		require.NoError(t, err)
+		require.Len(t, page.EventLogs, 1)
+
+		decoded, err := page.EventLogs[0].DecodePayload()
+		require.NoError(t, err)
+		portScan, ok := decoded.(*models.PortScanPayload)
+		require.True(t, ok)
+		assert.Equal(t, []string{"80/tcp", "443/tcp"}, portScan.OpenPorts)
+		assert.Equal(t, []string{"443/tcp"}, portScan.AddedPorts)
+		assert.Equal(t, []string{"22/tcp"}, portScan.RemovedPorts)
+		assert.Equal(t, models.ActorSystem, portScan.Actor)
+		assert.Equal(t, models.SeverityInfo, portScan.Severity)
+
+		err = service.Log(models.PortScanCompleted, "", printer.ID, &models.NetworkPayload{})
+		assert.Error(t, err, "payload of another type")
+	})
+
+	t.Run("UnsupportedFormat", func(t *testing.T) {
+		_, err := service.Export(&bytes.Buffer{}, export.FormatXLSX, models.EventLogFilter{})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,96 @@
+package integration
+
+import (
+	"testing"
+
+	"reconya-ai/internal/device"
+	"reconya-ai/internal/exclusion"
+	"reconya-ai/internal/network"
+	"reconya-ai/internal/scanpolicy"
+	"reconya-ai/models"
+	"reconya-ai/tests/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExclusionService(t *testing.T) {
+	factory, cleanup := testutils.SetupTestRepositoryFactory(t)
+	defer cleanup()
+
+	cfg := testutils.GetTestConfig()
+	networkService := network.NewNetworkService(factory.NewNetworkRepository(), cfg)
+	deviceService := device.NewDeviceService(factory.NewDeviceRepository(), networkService, cfg, nil)
+	service := exclusion.NewExclusionService(factory.NewExclusionRepository())
+	policies := scanpolicy.NewScanPolicyService(factory.NewScanPolicyRepository(), deviceService)
+	policies.Exclusions = service
+
+	lan, err := networkService.Create("LAN", "192.168.1.0/24", "")
+	require.NoError(t, err)
+	lab, err := networkService.Create("Lab", "10.0.5.0/24", "")
+	require.NoError(t, err)
+	mac := "aa:bb:cc:dd:ee:ff"
+	pump, err := deviceService.CreateOrUpdate(&models.Device{Name: "infusion pump", IPv4: "192.168.1.40", MAC: &mac, NetworkID: lan.ID})
+	require.NoError(t, err)
+
+	t.Run("Validation", func(t *testing.T) {
+		_, err := service.Add("", "192.168.1.300", "")
+		assert.Error(t, err)
+		_, err = service.Add("", "", "")
+		assert.Error(t, err)
+
+		added, err := service.Add("", " 192.168.1.10 ", " router ")
+		require.NoError(t, err)
+		assert.Equal(t, models.ExclusionKindIP, added.Kind)
+		assert.Equal(t, "192.168.1.10", added.Value)
+		assert.Equal(t, "router", added.Comment)
+
+		_, err = service.Add("", "192.168.1.10", "")
+		assert.Error(t, err)
+		_, err = service.Add(lan.ID, "192.168.1.10", "")
+		assert.NoError(t, err)
+	})
+
+	t.Run("Sweep", func(t *testing.T) {
+		_, err := service.Add(lan.ID, "AA-BB-CC-DD-EE-FF", "")
+		require.NoError(t, err)
+		_, err = service.Add(lab.ID, "10.0.0.0/16", "")
+		require.NoError(t, err)
+
+		sweep, err := policies.SweepPolicy(lan)
+		require.NoError(t, err)
+		assert.False(t, sweep.Skip)
+		assert.Equal(t, []string{"192.168.1.10", "192.168.1.40"}, sweep.ExcludeIPs)
+		assert.True(t, sweep.Exclusions.ExcludesMAC(mac))
+
+		sweep, err = policies.SweepPolicy(lab)
+		require.NoError(t, err)
+		assert.True(t, sweep.Skip)
+
+		// Command line targets only get the global exclusions
+		sweep, err = policies.SweepPolicy(&models.Network{CIDR: "10.0.5.0/24"})
+		require.NoError(t, err)
+		assert.False(t, sweep.Skip)
+		assert.Equal(t, []string{"192.168.1.10"}, sweep.ExcludeIPs)
+
+		settings, err := policies.EffectiveSettings(pump)
+		require.NoError(t, err)
+		assert.True(t, settings.Exclude)
+		assert.Equal(t, scanpolicy.SourceExclusionList, settings.Sources["exclude"])
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		exclusions, err := service.GetAll()
+		require.NoError(t, err)
+		require.Len(t, exclusions, 4)
+		for _, e := range exclusions {
+			require.NoError(t, service.Delete(e.ID))
+		}
+		assert.Error(t, service.Delete(exclusions[0].ID))
+
+		sweep, err := policies.SweepPolicy(lan)
+		require.NoError(t, err)
+		assert.Empty(t, sweep.ExcludeIPs)
+		assert.False(t, sweep.Skip)
+	})
+}
@@ -0,0 +1,79 @@
+package integration
+
+import (
+	"context"
+	"testing"
+
+	"reconya-ai/db"
+	"reconya-ai/internal/device"
+	"reconya-ai/internal/importer"
+	"reconya-ai/internal/network"
+	"reconya-ai/tests/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportService_Integration(t *testing.T) {
+	factory, cleanup := testutils.SetupTestRepositoryFactory(t)
+	defer cleanup()
+
+	cfg := testutils.GetTestConfig()
+
+	networkService := network.NewNetworkService(factory.NewNetworkRepository(), cfg)
+	deviceService := device.NewDeviceService(factory.NewDeviceRepository(), networkService, cfg, nil)
+	importService := importer.NewImportService(deviceService, networkService)
+	ctx := context.Background()
+
+	leases := []byte("1717236000 aa:bb:cc:dd:ee:01 10.20.0.5 printer *\n" +
+		"1717236000 aa:bb:cc:dd:ee:02 10.20.0.6 * *\n")
+
+	t.Run("DryRunWritesNothing", func(t *testing.T) {
+		result, err := importService.ImportFile("dnsmasq.leases", leases, importer.Options{DryRun: true})
+		require.NoError(t, err)
+		assert.Equal(t, importer.FormatDnsmasq, result.Format)
+		assert.Equal(t, 2, result.Created)
+		assert.Equal(t, []string{"10.20.0.0/24"}, result.NetworksCreated)
+
+		devices, err := deviceService.FindAll()
+		require.NoError(t, err)
+		assert.Empty(t, devices)
+		_, err = factory.NewNetworkRepository().FindByCIDR(ctx, "10.20.0.0/24")
+		assert.Equal(t, db.ErrNotFound, err)
+	})
+
+	t.Run("ImportCreatesDevicesAndNetworks", func(t *testing.T) {
+		result, err := importService.ImportFile("dnsmasq.leases", leases, importer.Options{})
+		require.NoError(t, err)
+		assert.Equal(t, 2, result.Created)
+
+		printer, err := deviceService.FindByIPv4("10.20.0.5")
+		require.NoError(t, err)
+		require.NotNil(t, printer)
+		assert.Equal(t, "AA:BB:CC:DD:EE:01", *printer.MAC)
+		assert.Equal(t, "printer", *printer.Hostname)
+		assert.Nil(t, printer.LastSeenOnlineAt, "imported devices are not marked as seen")
+
+		// Importing the same file again changes nothing
+		result, err = importService.ImportFile("dnsmasq.leases", leases, importer.Options{})
+		require.NoError(t, err)
+		assert.Equal(t, 2, result.Unchanged)
+	})
+
+	t.Run("MatchesByMACWhenTheAddressChanged", func(t *testing.T) {
+		moved := []byte("1717236000 aa:bb:cc:dd:ee:01 10.20.0.50 printer *\n")
+		result, err := importService.ImportFile("dnsmasq.leases", moved, importer.Options{})
+		require.NoError(t, err)
+		require.Equal(t, 1, result.Updated)
+		assert.Equal(t, "ipv4", result.Devices[0].Changes[0].Field)
+
+		printer, err := deviceService.FindByIPv4("10.20.0.50")
+		require.NoError(t, err)
+		require.NotNil(t, printer)
+		assert.Equal(t, result.Devices[0].DeviceID, printer.ID)
+
+		old, err := deviceService.FindByIPv4("10.20.0.5")
+		require.NoError(t, err)
+		assert.Nil(t, old)
+	})
+}
@@ -0,0 +1,101 @@
+package integration
+
+import (
+	"testing"
+
+	"reconya-ai/internal/device"
+	"reconya-ai/internal/eventlog"
+	"reconya-ai/internal/infraserver"
+	"reconya-ai/internal/network"
+	"reconya-ai/models"
+	"reconya-ai/tests/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInfraServerService_Record(t *testing.T) {
+	factory, cleanup := testutils.SetupTestRepositoryFactory(t)
+	defer cleanup()
+
+	cfg := testutils.GetTestConfig()
+	cfg.AllowedRouters = []string{"aa:bb:cc:00:00:01"}
+	networkService := network.NewNetworkService(factory.NewNetworkRepository(), cfg)
+	deviceService := device.NewDeviceService(factory.NewDeviceRepository(), networkService, cfg, nil)
+	eventLogService := eventlog.NewEventLogService(factory.NewEventLogRepository(), deviceService)
+	service := infraserver.NewInfraServerService(factory.NewInfraServerRepository(), networkService, eventLogService, cfg)
+
+	lan, err := networkService.Create("LAN", "192.168.1.0/24", "")
+	require.NoError(t, err)
+	_, err = networkService.SetAddressing(lan.ID, models.AddressFamilyDual, "2001:db8:1::/64")
+	require.NoError(t, err)
+
+	alerts := func() []*models.EventLog {
+		page, err := eventLogService.Search(models.EventLogFilter{Types: []models.EEventLogType{models.Alert}}, "", 50)
+		require.NoError(t, err)
+		return page.EventLogs
+	}
+
+	t.Run("AllowListedRouter", func(t *testing.T) {
+		server, created, err := service.Record(&models.InfraServer{
+			Kind: models.InfraServerRouter, IP: "fe80::1", MAC: "AA:BB:CC:00:00:01", Prefixes: []string{"2001:db8:1::/64"},
+		})
+		require.NoError(t, err)
+		assert.True(t, created)
+		assert.True(t, server.Allowed)
+		assert.Equal(t, lan.ID, server.NetworkID)
+		assert.Empty(t, alerts())
+	})
+
+	var rogue *models.InfraServer
+	t.Run("RogueDHCPServer", func(t *testing.T) {
+		sighting := func() *models.InfraServer {
+			return &models.InfraServer{Kind: models.InfraServerDHCPv4, IP: "192.168.1.66", OfferedAddress: "192.168.1.150"}
+		}
+		server, created, err := service.Record(sighting())
+		require.NoError(t, err)
+		assert.True(t, created)
+		assert.False(t, server.Allowed)
+		assert.Equal(t, lan.ID, server.NetworkID)
+		rogue = server
+
+		events := alerts()
+		require.Len(t, events, 1)
+		assert.Contains(t, events[0].Description, "Unknown DHCP server 192.168.1.66")
+		payload, err := events[0].DecodePayload()
+		require.NoError(t, err)
+		alert, ok := payload.(*models.AlertPayload)
+		require.True(t, ok)
+		assert.Equal(t, models.AlertSourceRogueDHCP, alert.Source)
+		assert.Equal(t, "192.168.1.66", alert.IP)
+
+		// Seen again, now with a MAC from the neighbor table
+		again := sighting()
+		again.MAC = "AA:BB:CC:00:00:66"
+		server, created, err = service.Record(again)
+		require.NoError(t, err)
+		assert.False(t, created)
+		assert.Equal(t, rogue.ID, server.ID)
+		assert.Equal(t, "AA:BB:CC:00:00:66", server.MAC)
+		assert.Len(t, alerts(), 1, "a known server alerts once")
+	})
+
+	t.Run("SetAllowedAndDelete", func(t *testing.T) {
+		server, err := service.SetAllowed(rogue.ID, true)
+		require.NoError(t, err)
+		assert.True(t, server.Allowed)
+
+		servers, err := service.FindAll(lan.ID)
+		require.NoError(t, err)
+		assert.Len(t, servers, 2)
+
+		require.NoError(t, service.Delete(rogue.ID))
+		_, err = service.SetAllowed(rogue.ID, true)
+		assert.Error(t, err)
+
+		_, created, err := service.Record(&models.InfraServer{Kind: models.InfraServerDHCPv4, IP: "192.168.1.66", OfferedAddress: "192.168.1.151"})
+		require.NoError(t, err)
+		assert.True(t, created)
+		assert.Len(t, alerts(), 2, "a forgotten server alerts again")
+	})
+}
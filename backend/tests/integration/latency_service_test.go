@@ -0,0 +1,112 @@
+package integration
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"reconya-ai/internal/device"
+	"reconya-ai/internal/eventlog"
+	"reconya-ai/internal/exclusion"
+	"reconya-ai/internal/latency"
+	"reconya-ai/internal/network"
+	"reconya-ai/models"
+	"reconya-ai/tests/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingPinger answers every ping and records the pinged addresses
+type recordingPinger struct {
+	mu    sync.Mutex
+	pings []string
+}
+
+func (p *recordingPinger) Ping(ip string) (bool, time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pings = append(p.pings, ip)
+	return true, time.Millisecond
+}
+
+func (p *recordingPinger) Pinged() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.pings...)
+}
+
+func TestLatencyService_IPv6OnlyDevices(t *testing.T) {
+	factory, cleanup := testutils.SetupTestRepositoryFactory(t)
+	defer cleanup()
+
+	cfg := testutils.GetTestConfig()
+	networkService := network.NewNetworkService(factory.NewNetworkRepository(), cfg)
+	deviceService := device.NewDeviceService(factory.NewDeviceRepository(), networkService, cfg, nil)
+	eventLogService := eventlog.NewEventLogService(factory.NewEventLogRepository(), deviceService)
+	latencyRepo := factory.NewLatencyRepository()
+	service := latency.NewLatencyService(latencyRepo, deviceService, eventLogService, cfg)
+	pinger := &recordingPinger{}
+	service.Pinger = pinger
+
+	lan, err := networkService.Create("LAN", "192.168.1.0/24", "")
+	require.NoError(t, err)
+	global := "2001:db8::10"
+	saved, err := deviceService.SaveIPv6Sweep(lan.ID, []*models.Device{{IPv6Global: &global}})
+	require.NoError(t, err)
+	require.Len(t, saved, 1)
+	ipv6Only := saved[0]
+
+	_, err = service.EnableMonitor(ipv6Only.ID, 0, 0)
+	assert.ErrorIs(t, err, latency.ErrNoIPv4Address)
+	monitor, err := service.GetMonitor(ipv6Only.ID)
+	require.NoError(t, err)
+	assert.Nil(t, monitor)
+
+	// A monitor added before IPv6-only devices were rejected is not probed
+	require.NoError(t, latencyRepo.SaveMonitor(context.Background(), &models.LatencyMonitor{DeviceID: ipv6Only.ID}))
+	service.RunProbes()
+	assert.Empty(t, pinger.Pinged())
+}
+
+func TestLatencyService_SkipsExcludedDevices(t *testing.T) {
+	factory, cleanup := testutils.SetupTestRepositoryFactory(t)
+	defer cleanup()
+
+	cfg := testutils.GetTestConfig()
+	networkService := network.NewNetworkService(factory.NewNetworkRepository(), cfg)
+	deviceService := device.NewDeviceService(factory.NewDeviceRepository(), networkService, cfg, nil)
+	eventLogService := eventlog.NewEventLogService(factory.NewEventLogRepository(), deviceService)
+	exclusions := exclusion.NewExclusionService(factory.NewExclusionRepository())
+	service := latency.NewLatencyService(factory.NewLatencyRepository(), deviceService, eventLogService, cfg)
+	service.Exclusions = exclusions
+	pinger := &recordingPinger{}
+	service.Pinger = pinger
+
+	lan, err := networkService.Create("LAN", "192.168.1.0/24", "")
+	require.NoError(t, err)
+	mac := "AA:BB:CC:00:00:40"
+	pump, err := deviceService.CreateOrUpdate(&models.Device{Name: "infusion pump", IPv4: "192.168.1.40", MAC: &mac, NetworkID: lan.ID})
+	require.NoError(t, err)
+	nas, err := deviceService.CreateOrUpdate(&models.Device{Name: "nas", IPv4: "192.168.1.10", NetworkID: lan.ID})
+	require.NoError(t, err)
+	for _, d := range []*models.Device{pump, nas} {
+		_, err = service.EnableMonitor(d.ID, 0, 0)
+		require.NoError(t, err)
+	}
+
+	_, err = exclusions.Add(lan.ID, "aa:bb:cc:00:00:40", "")
+	require.NoError(t, err)
+	service.RunProbes()
+
+	pinged := pinger.Pinged()
+	assert.NotEmpty(t, pinged)
+	assert.NotContains(t, pinged, "192.168.1.40")
+	samples, err := service.GetSamples(pump.ID, time.Hour)
+	require.NoError(t, err)
+	assert.Empty(t, samples)
+	samples, err = service.GetSamples(nas.ID, time.Hour)
+	require.NoError(t, err)
+	assert.Len(t, samples, 1)
+}
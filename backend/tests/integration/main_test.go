@@ -0,0 +1,14 @@
+package integration
+
+import (
+	"os"
+	"testing"
+
+	"reconya-ai/tests/testutils"
+)
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	testutils.StopPostgres()
+	os.Exit(code)
+}
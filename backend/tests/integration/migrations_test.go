@@ -0,0 +1,139 @@
+package integration
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"reconya-ai/db"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// openLegacyDatabase loads the pre-migration fixture into a fresh database file
+func openLegacyDatabase(t *testing.T) *sql.DB {
+	fixture, err := os.ReadFile(filepath.Join("testdata", "legacy_schema.sql"))
+	require.NoError(t, err)
+
+	legacyDB, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "legacy.db")+"?_foreign_keys=on")
+	require.NoError(t, err)
+	t.Cleanup(func() { legacyDB.Close() })
+
+	_, err = legacyDB.Exec(string(fixture))
+	require.NoError(t, err)
+	return legacyDB
+}
+
+func TestMigrations_LegacyDatabase(t *testing.T) {
+	legacyDB := openLegacyDatabase(t)
+	migrator := db.NewMigrator(legacyDB)
+	ctx := context.Background()
+
+	version, err := migrator.Version()
+	require.NoError(t, err)
+	assert.Equal(t, 0, version)
+
+	t.Run("MigratesForward", func(t *testing.T) {
+		require.NoError(t, db.InitializeSchema(legacyDB))
+
+		version, err := migrator.Version()
+		require.NoError(t, err)
+		assert.Equal(t, migrator.Latest(), version)
+
+		statuses, err := migrator.Status()
+		require.NoError(t, err)
+		for _, status := range statuses {
+			assert.True(t, status.Applied, "migration %d", status.Version)
+		}
+	})
+
+	t.Run("KeepsExistingData", func(t *testing.T) {
+		deviceRepo := db.NewSQLiteDeviceRepository(legacyDB)
+		router, err := deviceRepo.FindByIP(ctx, "192.168.50.1")
+		require.NoError(t, err)
+		assert.Equal(t, "router", router.Name)
+		assert.Equal(t, "upstairs", *router.Comment)
+		assert.Nil(t, router.IPv6Global)
+		assert.Empty(t, router.Tags)
+		require.Len(t, router.Ports, 1)
+		assert.Equal(t, "443", router.Ports[0].Number)
+
+		router.Tags = []string{"core"}
+		_, err = deviceRepo.CreateOrUpdate(ctx, router)
+		require.NoError(t, err)
+
+		network, err := db.NewSQLiteNetworkRepository(legacyDB).FindByCIDR(ctx, "192.168.50.0/24")
+		require.NoError(t, err)
+		assert.Equal(t, "Home", network.Name)
+	})
+
+	t.Run("SeedsStatusIntervals", func(t *testing.T) {
+		var count int
+		require.NoError(t, legacyDB.QueryRow(`SELECT COUNT(*) FROM device_status_intervals`).Scan(&count))
+		assert.Equal(t, 2, count)
+	})
+
+	t.Run("SecondRunIsNoop", func(t *testing.T) {
+		applied, err := migrator.Migrate()
+		require.NoError(t, err)
+		assert.Equal(t, 0, applied)
+	})
+
+	t.Run("RollsBackAndForward", func(t *testing.T) {
+		_, err := migrator.MigrateTo(5)
+		require.NoError(t, err)
+
+		version, err := migrator.Version()
+		require.NoError(t, err)
+		assert.Equal(t, 5, version)
+
+		_, err = legacyDB.Exec(`SELECT tags FROM devices`)
+		assert.Error(t, err, "tags column is dropped")
+		_, err = legacyDB.Exec(`SELECT 1 FROM users`)
+		assert.Error(t, err, "users table is dropped")
+
+		applied, err := migrator.Migrate()
+		require.NoError(t, err)
+		assert.Equal(t, migrator.Latest()-5, applied)
+
+		var name string
+		require.NoError(t, legacyDB.QueryRow(`SELECT name FROM devices WHERE ipv4 = '192.168.50.1'`).Scan(&name))
+		assert.Equal(t, "router", name)
+	})
+
+	t.Run("RefusesNewerSchema", func(t *testing.T) {
+		_, err := legacyDB.Exec(`INSERT INTO schema_migrations (version, name, applied_at) VALUES (9999, 'future', CURRENT_TIMESTAMP)`)
+		require.NoError(t, err)
+
+		_, err = migrator.Migrate()
+		assert.ErrorContains(t, err, "newer than this build supports")
+	})
+}
+
+func TestMigrations_FreshDatabaseRoundTrip(t *testing.T) {
+	freshDB, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "fresh.db")+"?_foreign_keys=on")
+	require.NoError(t, err)
+	defer freshDB.Close()
+
+	migrator := db.NewMigrator(freshDB)
+	applied, err := migrator.Migrate()
+	require.NoError(t, err)
+	assert.Equal(t, migrator.Latest(), applied)
+
+	rolledBack, err := migrator.MigrateTo(0)
+	require.NoError(t, err)
+	assert.Equal(t, migrator.Latest(), rolledBack)
+
+	var tables int
+	require.NoError(t, freshDB.QueryRow(
+		`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name NOT IN ('schema_migrations', 'sqlite_sequence')`,
+	).Scan(&tables))
+	assert.Equal(t, 0, tables)
+
+	_, err = migrator.Migrate()
+	require.NoError(t, err)
+}
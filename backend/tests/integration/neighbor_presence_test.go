@@ -0,0 +1,82 @@
+package integration
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"reconya-ai/internal/device"
+	"reconya-ai/internal/neighbor"
+	"reconya-ai/internal/network"
+	"reconya-ai/models"
+	"reconya-ai/tests/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeviceService_MarkPresentFromNeighbors(t *testing.T) {
+	factory, cleanup := testutils.SetupTestRepositoryFactory(t)
+	defer cleanup()
+
+	cfg := testutils.GetTestConfig()
+	networkService := network.NewNetworkService(factory.NewNetworkRepository(), cfg)
+	deviceRepo := factory.NewDeviceRepository()
+	deviceService := device.NewDeviceService(deviceRepo, networkService, cfg, nil)
+
+	printer := createTestDevice("192.168.1.30", "printer")
+	printer.MAC = nil
+	printer.Status = models.DeviceStatusOffline
+	lastSeen := time.Now().Add(-2 * time.Hour)
+	printer.LastSeenOnlineAt = &lastSeen
+	_, err := deviceRepo.CreateOrUpdate(context.Background(), printer)
+	require.NoError(t, err)
+
+	source := neighbor.NewFakeSource()
+	table := neighbor.NewTable(source)
+	events, unsubscribe := table.Subscribe(8)
+	defer unsubscribe()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go table.Run(ctx)
+
+	source.Add(neighbor.Entry{IP: net.ParseIP("192.168.1.30"), MAC: "AA:BB:CC:00:00:30", Interface: "eth0", State: neighbor.StateReachable})
+	source.Add(neighbor.Entry{IP: net.ParseIP("192.168.1.31"), MAC: "AA:BB:CC:00:00:31", Interface: "eth0", State: neighbor.StateReachable})
+
+	// Entries added before the table's first load arrive in any order
+	seen := make(map[string]bool)
+	for len(seen) < 2 {
+		select {
+		case event := <-events:
+			ip := event.Entry.IP.String()
+			require.Contains(t, []string{"192.168.1.30", "192.168.1.31"}, ip)
+			require.False(t, seen[ip], "duplicate event for %s", ip)
+			seen[ip] = true
+			found, previous, err := deviceService.MarkPresent(ip, event.Entry.MAC)
+			require.NoError(t, err)
+			if ip == "192.168.1.31" {
+				assert.Nil(t, found, "unknown addresses are not added")
+				continue
+			}
+			require.NotNil(t, found)
+			assert.Equal(t, models.DeviceStatusOffline, previous)
+		case <-time.After(2 * time.Second):
+			t.Fatal("no neighbor event")
+		}
+	}
+
+	stored, err := deviceRepo.FindByIP(context.Background(), "192.168.1.30")
+	require.NoError(t, err)
+	assert.Equal(t, models.DeviceStatusOnline, stored.Status)
+	require.NotNil(t, stored.MAC)
+	assert.Equal(t, "AA:BB:CC:00:00:30", *stored.MAC)
+	require.NotNil(t, stored.LastSeenOnlineAt)
+	assert.True(t, stored.LastSeenOnlineAt.After(lastSeen))
+	assert.Equal(t, "printer", stored.Name)
+
+	_, previous, err := deviceService.MarkPresent("192.168.1.30", "")
+	require.NoError(t, err)
+	assert.Equal(t, models.DeviceStatusOnline, previous)
+}
@@ -0,0 +1,479 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"reconya-ai/db"
+	"reconya-ai/models"
+	"reconya-ai/tests/testutils"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRepositoryConformance runs the same checks against every storage
+// backend. PostgreSQL is skipped unless RECONYA_TEST_POSTGRES_URL is set.
+func TestRepositoryConformance(t *testing.T) {
+	backends := []struct {
+		name  string
+		setup func(t *testing.T) (*db.RepositoryFactory, func())
+	}{
+		{"SQLite", testutils.SetupTestRepositoryFactory},
+		{"Postgres", testutils.SetupTestPostgresRepositoryFactory},
+	}
+
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			factory, cleanup := backend.setup(t)
+			defer cleanup()
+			testRepositoryConformance(t, factory)
+		})
+	}
+}
+
+func testRepositoryConformance(t *testing.T, factory *db.RepositoryFactory) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+
+	networkRepo := factory.NewNetworkRepository()
+	deviceRepo := factory.NewDeviceRepository()
+
+	network := testutils.CreateTestNetwork()
+	network.Name = "Office"
+	_, err := networkRepo.CreateOrUpdate(ctx, network)
+	require.NoError(t, err)
+
+	device := createTestDevice("192.168.1.10", "printer")
+	device.NetworkID = network.ID
+	device.Tags = []string{"office", "printer"}
+	device.Ports = []models.Port{{Number: "631", Protocol: "tcp", State: "open", Service: "ipp"}}
+	_, err = deviceRepo.CreateOrUpdate(ctx, device)
+	require.NoError(t, err)
+
+	t.Run("Networks", func(t *testing.T) {
+		found, err := networkRepo.FindByCIDR(ctx, network.CIDR)
+		require.NoError(t, err)
+		assert.Equal(t, network.ID, found.ID)
+		assert.Equal(t, "Office", found.Name)
+		assert.Equal(t, models.AddressFamilyIPv4, found.AddressFamily)
+
+		prefix := "2001:db8:1::/64"
+		found.AddressFamily = models.AddressFamilyDual
+		found.IPv6Prefix = &prefix
+		_, err = networkRepo.CreateOrUpdate(ctx, found)
+		require.NoError(t, err)
+		found, err = networkRepo.FindByID(ctx, network.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.AddressFamilyDual, found.AddressFamily)
+		assert.Equal(t, prefix, found.GetIPv6Prefix())
+
+		require.NoError(t, networkRepo.UpdateLastScannedAt(ctx, network.ID, now))
+		found, err = networkRepo.FindByID(ctx, network.ID)
+		require.NoError(t, err)
+		require.NotNil(t, found.LastScannedAt)
+		assert.True(t, now.Equal(*found.LastScannedAt))
+
+		count, err := networkRepo.GetDeviceCount(ctx, network.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+
+		all, err := networkRepo.FindAll(ctx)
+		require.NoError(t, err)
+		assert.Len(t, all, 1)
+
+		_, err = networkRepo.FindByID(ctx, "missing")
+		assert.ErrorIs(t, err, db.ErrNotFound)
+	})
+
+	t.Run("Devices", func(t *testing.T) {
+		found, err := deviceRepo.FindByIP(ctx, "192.168.1.10")
+		require.NoError(t, err)
+		assert.Equal(t, device.ID, found.ID)
+		assert.Equal(t, []string{"office", "printer"}, found.Tags)
+		require.Len(t, found.Ports, 1)
+		assert.Equal(t, "631", found.Ports[0].Number)
+
+		found.Name = "laser printer"
+		_, err = deviceRepo.CreateOrUpdate(ctx, found)
+		require.NoError(t, err)
+		found, err = deviceRepo.FindByID(ctx, device.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "laser printer", found.Name)
+
+		activity, err := deviceRepo.FindActivity(ctx)
+		require.NoError(t, err)
+		require.Len(t, activity, 1)
+		assert.Equal(t, network.ID, activity[0].NetworkID)
+
+		_, err = deviceRepo.FindByIP(ctx, "192.168.1.99")
+		assert.ErrorIs(t, err, db.ErrNotFound)
+	})
+
+	t.Run("StatusHistory", func(t *testing.T) {
+		transitions := []*models.DeviceStatusTransition{
+			{DeviceID: device.ID, From: models.DeviceStatusOnline, To: models.DeviceStatusOffline, At: time.Now()},
+			{DeviceID: device.ID, From: models.DeviceStatusOnline, To: models.DeviceStatusIdle, At: time.Now()},
+		}
+		applied, err := deviceRepo.ApplyStatusTransitions(ctx, transitions)
+		require.NoError(t, err)
+		assert.Len(t, applied, 1, "the second transition no longer matches")
+
+		intervals, err := factory.NewAvailabilityRepository().FindIntervals(ctx, device.ID, now.Add(-time.Hour))
+		require.NoError(t, err)
+		require.NotEmpty(t, intervals)
+		assert.Equal(t, models.DeviceStatusOffline, intervals[len(intervals)-1].Status)
+	})
+
+	t.Run("EventLogs", func(t *testing.T) {
+		eventLogRepo := factory.NewEventLogRepository()
+		for i := 0; i < 3; i++ {
+			require.NoError(t, eventLogRepo.Create(ctx, testutils.CreateTestEventLog(device.ID)))
+		}
+
+		latest, err := eventLogRepo.FindLatest(ctx, 2)
+		require.NoError(t, err)
+		assert.Len(t, latest, 2)
+
+		byDevice, err := eventLogRepo.FindAllByDeviceID(ctx, device.ID)
+		require.NoError(t, err)
+		assert.Len(t, byDevice, 3)
+
+		addEvent := func(eventType models.EEventLogType, description string, deviceID *string, age time.Duration) {
+			createdAt := now.Add(-age)
+			require.NoError(t, eventLogRepo.Create(ctx, &models.EventLog{
+				Type: eventType, Description: description, DeviceID: deviceID, CreatedAt: &createdAt, UpdatedAt: &createdAt,
+			}))
+		}
+		addEvent(models.ScanStarted, "Scan started on Office", nil, time.Hour)
+		addEvent(models.Alert, "Rogue DHCP server 10.0.0.7 answered", &device.ID, 2*time.Hour)
+		addEvent(models.Warning, "Printer latency above threshold", &device.ID, 3*time.Hour)
+
+		search := func(filter models.EventLogFilter) []*models.EventLog {
+			found, err := eventLogRepo.Search(ctx, filter, nil, 100)
+			require.NoError(t, err)
+			return found
+		}
+		since := now.Add(-150 * time.Minute)
+
+		assert.Len(t, search(models.EventLogFilter{}), 6)
+		assert.Len(t, search(models.EventLogFilter{Types: []models.EEventLogType{models.Alert}}), 1)
+		assert.Len(t, search(models.EventLogFilter{Severities: []models.EventSeverity{models.SeverityWarning, models.SeverityCritical}}), 2)
+		assert.Len(t, search(models.EventLogFilter{Severities: []models.EventSeverity{models.SeverityInfo}}), 4)
+		assert.Len(t, search(models.EventLogFilter{DeviceID: device.ID}), 5)
+		assert.Len(t, search(models.EventLogFilter{NetworkID: network.ID}), 5)
+		assert.Len(t, search(models.EventLogFilter{Since: &since}), 5)
+		assert.Len(t, search(models.EventLogFilter{Until: &since}), 1)
+
+		found := search(models.EventLogFilter{Query: "rogue dhcp"})
+		require.Len(t, found, 1)
+		assert.Equal(t, models.Alert, found[0].Type)
+		assert.Len(t, search(models.EventLogFilter{Query: "10.0.0.7"}), 1)
+		assert.Len(t, search(models.EventLogFilter{Query: "printer"}), 1)
+		assert.Empty(t, search(models.EventLogFilter{Query: "rogue printer"}), "every word must match")
+		assert.Len(t, search(models.EventLogFilter{Query: "latency", Severities: []models.EventSeverity{models.SeverityWarning}}), 1)
+
+		// Paging through two at a time returns every event once, newest first
+		var paged []*models.EventLog
+		var cursor *models.EventLogCursor
+		for {
+			page, err := eventLogRepo.Search(ctx, models.EventLogFilter{}, cursor, 2)
+			require.NoError(t, err)
+			paged = append(paged, page...)
+			if len(page) < 2 {
+				break
+			}
+			cursor = models.CursorAfter(page[len(page)-1])
+		}
+		require.Len(t, paged, 6)
+		seen := make(map[int64]bool)
+		for i, eventLog := range paged {
+			assert.False(t, seen[eventLog.ID], "event %d returned twice", eventLog.ID)
+			seen[eventLog.ID] = true
+			if i > 0 {
+				assert.False(t, eventLog.CreatedAt.After(*paged[i-1].CreatedAt))
+			}
+		}
+	})
+
+	t.Run("SystemStatus", func(t *testing.T) {
+		systemStatusRepo := factory.NewSystemStatusRepository()
+		status := testutils.CreateTestSystemStatus()
+		status.NetworkID = network.ID
+		require.NoError(t, systemStatusRepo.Create(ctx, status))
+
+		latest, err := systemStatusRepo.FindLatest(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, network.ID, latest.NetworkID)
+		assert.Equal(t, "203.0.113.1", *latest.PublicIP)
+	})
+
+	t.Run("Settings", func(t *testing.T) {
+		settingsRepo := factory.NewSettingsRepository()
+		settings := &models.Settings{ID: uuid.New().String(), UserID: "admin", ScreenshotsEnabled: true, CreatedAt: &now, UpdatedAt: &now}
+		require.NoError(t, settingsRepo.Create(settings))
+
+		settings.ScreenshotsEnabled = false
+		require.NoError(t, settingsRepo.Update(settings))
+
+		found, err := settingsRepo.FindByUserID("admin")
+		require.NoError(t, err)
+		require.NotNil(t, found)
+		assert.False(t, found.ScreenshotsEnabled)
+	})
+
+	t.Run("Traceroute", func(t *testing.T) {
+		tracerouteRepo := factory.NewTracerouteRepository()
+		hopIP := "192.168.1.1"
+		rtt := 1.5
+		run := &models.TracerouteRun{
+			NetworkID:   &network.ID,
+			DeviceID:    &device.ID,
+			Target:      device.IPv4,
+			Method:      models.TracerouteMethodICMP,
+			Reached:     true,
+			Hops:        []models.TracerouteHop{{TTL: 1, IP: &hopIP, RTTMillis: &rtt}},
+			StartedAt:   now,
+			CompletedAt: now,
+		}
+		require.NoError(t, tracerouteRepo.Create(ctx, run))
+
+		latest, err := tracerouteRepo.FindLatestByDeviceID(ctx, device.ID)
+		require.NoError(t, err)
+		assert.True(t, latest.Reached)
+		require.Len(t, latest.Hops, 1)
+		assert.Equal(t, hopIP, *latest.Hops[0].IP)
+
+		require.NoError(t, tracerouteRepo.AddTarget(ctx, device.ID))
+		require.NoError(t, tracerouteRepo.AddTarget(ctx, device.ID), "adding a target twice is a no-op")
+		targets, err := tracerouteRepo.FindTargets(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{device.ID}, targets)
+
+		require.NoError(t, tracerouteRepo.RemoveTarget(ctx, device.ID))
+		targets, err = tracerouteRepo.FindTargets(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, targets)
+	})
+
+	t.Run("Latency", func(t *testing.T) {
+		latencyRepo := factory.NewLatencyRepository()
+		avg := 12.5
+		sample := &models.LatencySample{
+			DeviceID:   device.ID,
+			Resolution: models.LatencyResolutionRaw,
+			Timestamp:  now,
+			RTTAvgMs:   &avg,
+			Sent:       5,
+			Received:   5,
+		}
+		require.NoError(t, latencyRepo.CreateSample(ctx, sample))
+
+		samples, err := latencyRepo.FindSamples(ctx, device.ID, now.Add(-time.Minute))
+		require.NoError(t, err)
+		require.Len(t, samples, 1)
+		assert.Equal(t, avg, *samples[0].RTTAvgMs)
+
+		monitor := &models.LatencyMonitor{DeviceID: device.ID, RTTThresholdMs: 100, LossThresholdPercent: 10}
+		require.NoError(t, latencyRepo.SaveMonitor(ctx, monitor))
+		monitor.Degraded = true
+		require.NoError(t, latencyRepo.SaveMonitor(ctx, monitor))
+
+		found, err := latencyRepo.FindMonitor(ctx, device.ID)
+		require.NoError(t, err)
+		assert.True(t, found.Degraded)
+
+		require.NoError(t, latencyRepo.DeleteMonitor(ctx, device.ID))
+		_, err = latencyRepo.FindMonitor(ctx, device.ID)
+		assert.ErrorIs(t, err, db.ErrNotFound)
+	})
+
+	t.Run("StatusThresholds", func(t *testing.T) {
+		thresholdRepo := factory.NewStatusThresholdRepository()
+		idle := 300
+		threshold := &models.StatusThreshold{Scope: models.ThresholdScopeNetwork, ScopeID: network.ID, IdleAfterSeconds: &idle}
+		require.NoError(t, thresholdRepo.Save(ctx, threshold))
+
+		thresholds, err := thresholdRepo.FindAll(ctx)
+		require.NoError(t, err)
+		require.Len(t, thresholds, 1)
+		assert.Equal(t, idle, *thresholds[0].IdleAfterSeconds)
+		assert.Nil(t, thresholds[0].OfflineAfterSeconds)
+
+		require.NoError(t, thresholdRepo.Delete(ctx, models.ThresholdScopeNetwork, network.ID))
+		assert.ErrorIs(t, thresholdRepo.Delete(ctx, models.ThresholdScopeNetwork, network.ID), db.ErrNotFound)
+	})
+
+	t.Run("ScanPolicies", func(t *testing.T) {
+		policyRepo := factory.NewScanPolicyRepository()
+		pingOnly := true
+		ports := "22,80,8000-8100"
+		policy := &models.ScanPolicy{Scope: models.ScanPolicyScopeTag, ScopeID: "iot", PingOnly: &pingOnly, Ports: &ports}
+		require.NoError(t, policyRepo.Save(ctx, policy))
+
+		policies, err := policyRepo.FindAll(ctx)
+		require.NoError(t, err)
+		require.Len(t, policies, 1)
+		assert.True(t, *policies[0].PingOnly)
+		assert.Equal(t, ports, *policies[0].Ports)
+		assert.Nil(t, policies[0].Exclude)
+		assert.Nil(t, policies[0].MaxRate)
+
+		require.NoError(t, policyRepo.Delete(ctx, models.ScanPolicyScopeTag, "iot"))
+		assert.ErrorIs(t, policyRepo.Delete(ctx, models.ScanPolicyScopeTag, "iot"), db.ErrNotFound)
+	})
+
+	t.Run("Exclusions", func(t *testing.T) {
+		exclusionRepo := factory.NewExclusionRepository()
+		global := &models.Exclusion{Kind: models.ExclusionKindCIDR, Value: "10.0.0.0/24", Comment: "lab PLCs"}
+		require.NoError(t, exclusionRepo.Create(ctx, global))
+		scoped := &models.Exclusion{NetworkID: network.ID, Kind: models.ExclusionKindMAC, Value: "00:11:22:33:44:55"}
+		require.NoError(t, exclusionRepo.Create(ctx, scoped))
+		assert.Error(t, exclusionRepo.Create(ctx, &models.Exclusion{NetworkID: network.ID, Kind: models.ExclusionKindMAC, Value: "00:11:22:33:44:55"}))
+
+		exclusions, err := exclusionRepo.FindAll(ctx)
+		require.NoError(t, err)
+		require.Len(t, exclusions, 2)
+		assert.Equal(t, global.ID, exclusions[0].ID, "global exclusions come first")
+		assert.Equal(t, "lab PLCs", exclusions[0].Comment)
+		assert.Equal(t, network.ID, exclusions[1].NetworkID)
+		assert.Empty(t, exclusions[1].Comment)
+
+		require.NoError(t, exclusionRepo.Delete(ctx, global.ID))
+		require.NoError(t, exclusionRepo.Delete(ctx, scoped.ID))
+		assert.ErrorIs(t, exclusionRepo.Delete(ctx, scoped.ID), db.ErrNotFound)
+	})
+
+	t.Run("InfraServers", func(t *testing.T) {
+		infraRepo := factory.NewInfraServerRepository()
+		lifetime := 1800
+		server := &models.InfraServer{
+			NetworkID:             network.ID,
+			Kind:                  models.InfraServerRouter,
+			IP:                    "fe80::1",
+			MAC:                   "AA:BB:CC:00:00:01",
+			Prefixes:              []string{"2001:db8:1::/64"},
+			Flags:                 []string{models.RAFlagManaged},
+			RouterLifetimeSeconds: &lifetime,
+			FirstSeenAt:           now,
+			LastSeenAt:            now,
+		}
+		require.NoError(t, infraRepo.Save(ctx, server))
+		assert.NotEmpty(t, server.ID)
+
+		server.Allowed = true
+		require.NoError(t, infraRepo.Save(ctx, server))
+
+		servers, err := infraRepo.FindAll(ctx, network.ID)
+		require.NoError(t, err)
+		require.Len(t, servers, 1)
+		assert.True(t, servers[0].Allowed)
+		assert.Equal(t, []string{"2001:db8:1::/64"}, servers[0].Prefixes)
+		assert.Equal(t, lifetime, *servers[0].RouterLifetimeSeconds)
+		assert.Empty(t, servers[0].DNSServers)
+
+		others, err := infraRepo.FindAll(ctx, "other")
+		require.NoError(t, err)
+		assert.Empty(t, others)
+
+		require.NoError(t, infraRepo.Delete(ctx, server.ID))
+		_, err = infraRepo.FindByID(ctx, server.ID)
+		assert.ErrorIs(t, err, db.ErrNotFound)
+		assert.ErrorIs(t, infraRepo.Delete(ctx, server.ID), db.ErrNotFound)
+	})
+
+	t.Run("IPMACBindings", func(t *testing.T) {
+		bindingRepo := factory.NewIPMACBindingRepository()
+		earlier := now.Add(-time.Hour)
+		first := &models.IPMACBinding{IP: "192.168.1.10", MAC: "AA:BB:CC:00:00:10", NetworkID: network.ID, Source: models.BindingSourceSweep, FirstSeenAt: earlier, LastSeenAt: earlier}
+		require.NoError(t, bindingRepo.Record(ctx, first))
+		second := &models.IPMACBinding{IP: "192.168.1.10", MAC: "AA:BB:CC:00:00:11", Source: models.BindingSourceNeighbor, FirstSeenAt: now, LastSeenAt: now}
+		require.NoError(t, bindingRepo.Record(ctx, second))
+
+		bindings, err := bindingRepo.FindByIP(ctx, "192.168.1.10", earlier)
+		require.NoError(t, err)
+		require.Len(t, bindings, 2)
+		assert.Equal(t, "AA:BB:CC:00:00:11", bindings[0].MAC, "most recent first")
+
+		// Seen again: extended, keeping its first sighting and network
+		again := &models.IPMACBinding{IP: "192.168.1.10", MAC: "AA:BB:CC:00:00:10", Source: models.BindingSourceNeighbor, FirstSeenAt: now, LastSeenAt: now.Add(time.Minute)}
+		require.NoError(t, bindingRepo.Record(ctx, again))
+		bindings, err = bindingRepo.FindByMAC(ctx, "AA:BB:CC:00:00:10", now)
+		require.NoError(t, err)
+		require.Len(t, bindings, 1)
+		assert.Equal(t, network.ID, bindings[0].NetworkID)
+		assert.True(t, bindings[0].FirstSeenAt.Equal(earlier))
+		assert.Equal(t, models.BindingSourceNeighbor, bindings[0].Source)
+
+		deleted, err := factory.NewRetentionRepository().PruneIPMACBindings(ctx, now.Add(30*time.Second), false)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), deleted)
+	})
+
+	t.Run("Users", func(t *testing.T) {
+		userRepo := factory.NewUserRepository()
+		user := &models.User{Username: "alice", Password: "hash"}
+		require.NoError(t, userRepo.Create(ctx, user))
+		assert.NotZero(t, user.ID)
+
+		found, err := userRepo.FindByUsername(ctx, "alice")
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, found.ID)
+
+		_, err = userRepo.FindByUsername(ctx, "bob")
+		assert.ErrorIs(t, err, db.ErrNotFound)
+	})
+
+	t.Run("Geolocation", func(t *testing.T) {
+		geolocationRepo := factory.NewGeolocationRepository()
+		cache := &models.GeolocationCache{IP: "203.0.113.1", City: "Athens", Country: "Greece", Latitude: 37.98, Longitude: 23.72, Source: "api"}
+		require.NoError(t, geolocationRepo.Upsert(ctx, cache))
+
+		found, err := geolocationRepo.FindByIP(ctx, "203.0.113.1")
+		require.NoError(t, err)
+		assert.Equal(t, "Athens", found.City)
+	})
+
+	t.Run("Retention", func(t *testing.T) {
+		retentionRepo := factory.NewRetentionRepository()
+		eventLogRepo := factory.NewEventLogRepository()
+
+		old := testutils.CreateTestEventLog(device.ID)
+		createdAt := now.Add(-48 * time.Hour)
+		old.CreatedAt = &createdAt
+		old.UpdatedAt = &createdAt
+		require.NoError(t, eventLogRepo.Create(ctx, old))
+
+		before := now.Add(-24 * time.Hour)
+		deleted, err := retentionRepo.PruneEventLogs(ctx, before, nil, nil, true, true)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), deleted)
+
+		deleted, err = retentionRepo.PruneEventLogs(ctx, before, nil, nil, true, false)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), deleted)
+
+		rollups, err := retentionRepo.FindEventRollups(ctx, now.Add(-72*time.Hour))
+		require.NoError(t, err)
+		require.Len(t, rollups, 1)
+		assert.Equal(t, createdAt.Format("2006-01-02"), rollups[0].Day)
+		assert.Equal(t, 1, rollups[0].Count)
+
+		_, err = retentionRepo.PruneTracerouteRuns(ctx, before, false)
+		require.NoError(t, err)
+		_, err = retentionRepo.PruneLatencySamples(ctx, before, false)
+		require.NoError(t, err)
+		_, err = retentionRepo.PruneStatusIntervals(ctx, before, false)
+		require.NoError(t, err)
+		_, err = retentionRepo.PruneSystemStatus(ctx, before, false)
+		require.NoError(t, err)
+	})
+
+	t.Run("DeleteDevice", func(t *testing.T) {
+		require.NoError(t, deviceRepo.DeleteByID(ctx, device.ID))
+		_, err := deviceRepo.FindByID(ctx, device.ID)
+		assert.ErrorIs(t, err, db.ErrNotFound)
+	})
+}
@@ -0,0 +1,99 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"reconya-ai/internal/retention"
+	"reconya-ai/models"
+	"reconya-ai/tests/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetentionService_Integration(t *testing.T) {
+	factory, cleanup := testutils.SetupTestRepositoryFactory(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	eventLogRepo := factory.NewEventLogRepository()
+
+	createEvent := func(eventType models.EEventLogType, deviceID string, age time.Duration) {
+		event := testutils.CreateTestEventLog(deviceID)
+		event.Type = eventType
+		createdAt := time.Now().Add(-age)
+		event.CreatedAt = &createdAt
+		event.UpdatedAt = &createdAt
+		require.NoError(t, eventLogRepo.Create(ctx, event))
+	}
+
+	day := 24 * time.Hour
+	createEvent(models.PingSweep, "device-1", 10*day)
+	createEvent(models.PingSweep, "device-1", 10*day)
+	createEvent(models.PingSweep, "device-1", 2*day)
+	createEvent(models.DeviceOnline, "device-1", 40*day)
+	createEvent(models.DeviceOnline, "device-2", 10*day)
+	createEvent(models.Alert, "device-2", 40*day)
+
+	cfg := testutils.GetTestConfig()
+	cfg.EventRetention = 30 * day
+	cfg.EventRetentionByType = map[string]time.Duration{
+		string(models.PingSweep): 7 * day,
+		string(models.Alert):     0,
+	}
+	cfg.EventRollups = true
+	service := retention.NewRetentionService(factory.NewRetentionRepository(), cfg)
+
+	t.Run("DryRunCountsWithoutDeleting", func(t *testing.T) {
+		report, err := service.Run(true)
+		require.NoError(t, err)
+		assert.True(t, report.DryRun)
+		assert.Equal(t, int64(3), report.Deleted)
+		assert.Nil(t, service.LastReport())
+
+		events, err := eventLogRepo.FindLatest(ctx, 100)
+		require.NoError(t, err)
+		assert.Len(t, events, 6)
+	})
+
+	t.Run("PrunesPerTypeAndKeepsRollups", func(t *testing.T) {
+		report, err := service.Run(false)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), report.Deleted)
+
+		deleted := map[string]int64{}
+		for _, result := range report.Results {
+			if result.Table == retention.TableEventLogs {
+				deleted[result.Type] = result.Deleted
+			}
+		}
+		assert.Equal(t, int64(2), deleted[string(models.PingSweep)])
+		assert.Equal(t, int64(1), deleted[""])
+		assert.NotContains(t, deleted, string(models.Alert), "a zero retention keeps the type forever")
+
+		events, err := eventLogRepo.FindLatest(ctx, 100)
+		require.NoError(t, err)
+		assert.Len(t, events, 3)
+
+		rollups, err := service.GetEventRollups(60 * day)
+		require.NoError(t, err)
+		counts := map[models.EEventLogType]int{}
+		for _, rollup := range rollups {
+			counts[rollup.Type] += rollup.Count
+			require.NotNil(t, rollup.DeviceID)
+			assert.Equal(t, "device-1", *rollup.DeviceID)
+		}
+		assert.Equal(t, map[models.EEventLogType]int{models.PingSweep: 2, models.DeviceOnline: 1}, counts)
+
+		require.NotNil(t, service.LastReport())
+		assert.Equal(t, int64(3), service.LastReport().Deleted)
+	})
+
+	t.Run("RunningAgainDeletesNothing", func(t *testing.T) {
+		report, err := service.Run(false)
+		require.NoError(t, err)
+		assert.Zero(t, report.Deleted)
+	})
+}
@@ -0,0 +1,80 @@
+package integration
+
+import (
+	"testing"
+
+	"reconya-ai/internal/network"
+	"reconya-ai/internal/ratelimit"
+	"reconya-ai/tests/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNetworkScanLimits(t *testing.T) {
+	factory, cleanup := testutils.SetupTestRepositoryFactory(t)
+	defer cleanup()
+
+	cfg := testutils.GetTestConfig()
+	cfg.ScanRateLimit = 1000
+	networkService := network.NewNetworkService(factory.NewNetworkRepository(), cfg)
+
+	lan, err := networkService.Create("LAN", "192.168.1.0/24", "")
+	require.NoError(t, err)
+	assert.Nil(t, lan.RateLimit)
+
+	t.Run("Validation", func(t *testing.T) {
+		zero := 0
+		_, err := networkService.SetScanLimits(lan.ID, &zero, "")
+		assert.Error(t, err)
+		_, err = networkService.SetScanLimits(lan.ID, nil, "Mon-Fri 22:00")
+		assert.Error(t, err)
+		_, err = networkService.SetScanLimits("missing", nil, "")
+		assert.Error(t, err)
+	})
+
+	t.Run("Persisted", func(t *testing.T) {
+		rate := 50
+		_, err := networkService.SetScanLimits(lan.ID, &rate, "Mon-Fri 22:00-06:00")
+		require.NoError(t, err)
+
+		found, err := networkService.FindByID(lan.ID)
+		require.NoError(t, err)
+		require.NotNil(t, found.RateLimit)
+		assert.Equal(t, 50, *found.RateLimit)
+		assert.Equal(t, "Mon-Fri 22:00-06:00", found.ScanWindows)
+
+		all, err := networkService.FindAll()
+		require.NoError(t, err)
+		require.Len(t, all, 1)
+		require.NotNil(t, all[0].RateLimit)
+		assert.Equal(t, "Mon-Fri 22:00-06:00", all[0].ScanWindows)
+
+		// Renaming the network keeps its limits
+		_, err = networkService.Update(lan.ID, "Office", lan.CIDR, "")
+		require.NoError(t, err)
+		found, err = networkService.FindByCIDR(lan.CIDR)
+		require.NoError(t, err)
+		require.NotNil(t, found.RateLimit)
+		assert.Equal(t, 50, *found.RateLimit)
+	})
+
+	t.Run("Manager", func(t *testing.T) {
+		limits, err := ratelimit.NewManager(cfg, networkService)
+		require.NoError(t, err)
+
+		// The network's windows replace the global ones
+		limiter := limits.For(lan.ID)
+		assert.Equal(t, "Mon-Fri 22:00-06:00", limiter.Schedule().String())
+		assert.Same(t, limiter, limits.For(lan.ID), "networks keep their limiter")
+		assert.True(t, limits.For("").Schedule().IsEmpty())
+
+		_, err = networkService.SetScanLimits(lan.ID, nil, "")
+		require.NoError(t, err)
+		assert.True(t, limits.For(lan.ID).Schedule().IsEmpty(), "cleared windows fall back to the global ones")
+
+		cfg.ScanWindows = "Mon-Fri 25:00-26:00"
+		_, err = ratelimit.NewManager(cfg, networkService)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,85 @@
+package integration
+
+import (
+	"testing"
+
+	"reconya-ai/internal/device"
+	"reconya-ai/internal/network"
+	"reconya-ai/internal/scanpolicy"
+	"reconya-ai/models"
+	"reconya-ai/tests/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanPolicyService(t *testing.T) {
+	factory, cleanup := testutils.SetupTestRepositoryFactory(t)
+	defer cleanup()
+
+	cfg := testutils.GetTestConfig()
+	networkService := network.NewNetworkService(factory.NewNetworkRepository(), cfg)
+	deviceService := device.NewDeviceService(factory.NewDeviceRepository(), networkService, cfg, nil)
+	service := scanpolicy.NewScanPolicyService(factory.NewScanPolicyRepository(), deviceService)
+
+	lan, err := networkService.Create("LAN", "192.168.1.0/24", "")
+	require.NoError(t, err)
+	printer, err := deviceService.CreateOrUpdate(&models.Device{Name: "printer", IPv4: "192.168.1.20", NetworkID: lan.ID})
+	require.NoError(t, err)
+	camera, err := deviceService.CreateOrUpdate(&models.Device{Name: "camera", IPv4: "192.168.1.30", NetworkID: lan.ID})
+	require.NoError(t, err)
+	camera, err = deviceService.UpdateTags(camera.ID, []string{"Fragile"})
+	require.NoError(t, err)
+
+	exclude := true
+	rate := 100
+
+	t.Run("Validation", func(t *testing.T) {
+		assert.Error(t, service.SetPolicy(&models.ScanPolicy{Scope: "rack", ScopeID: "a", Exclude: &exclude}))
+		assert.Error(t, service.SetPolicy(&models.ScanPolicy{Scope: models.ScanPolicyScopeDevice, Exclude: &exclude}))
+		assert.Error(t, service.SetPolicy(&models.ScanPolicy{Scope: models.ScanPolicyScopeDevice, ScopeID: printer.ID}))
+
+		ports := "80,http"
+		assert.Error(t, service.SetPolicy(&models.ScanPolicy{Scope: models.ScanPolicyScopeDevice, ScopeID: printer.ID, Ports: &ports}))
+		ports = "80"
+		top := 10
+		assert.Error(t, service.SetPolicy(&models.ScanPolicy{Scope: models.ScanPolicyScopeDevice, ScopeID: printer.ID, Ports: &ports, TopPorts: &top}))
+		zero := 0
+		assert.Error(t, service.SetPolicy(&models.ScanPolicy{Scope: models.ScanPolicyScopeDevice, ScopeID: printer.ID, MaxRate: &zero}))
+
+		policies, err := service.GetPolicies()
+		require.NoError(t, err)
+		assert.Empty(t, policies)
+	})
+
+	t.Run("Sweep", func(t *testing.T) {
+		require.NoError(t, service.SetPolicy(&models.ScanPolicy{Scope: models.ScanPolicyScopeTag, ScopeID: " FRAGILE ", Exclude: &exclude}))
+		require.NoError(t, service.SetPolicy(&models.ScanPolicy{Scope: models.ScanPolicyScopeNetwork, ScopeID: lan.ID, MaxRate: &rate}))
+
+		sweep, err := service.SweepPolicy(lan)
+		require.NoError(t, err)
+		assert.False(t, sweep.Skip)
+		assert.Equal(t, []string{"192.168.1.30"}, sweep.ExcludeIPs)
+		assert.Equal(t, 100, sweep.MaxRate)
+
+		settings, err := service.EffectiveSettings(camera)
+		require.NoError(t, err)
+		assert.True(t, settings.Exclude)
+		assert.Equal(t, "tag:fragile", settings.Sources["exclude"])
+
+		require.NoError(t, service.SetPolicy(&models.ScanPolicy{Scope: models.ScanPolicyScopeNetwork, ScopeID: lan.ID, Exclude: &exclude}))
+		sweep, err = service.SweepPolicy(lan)
+		require.NoError(t, err)
+		assert.True(t, sweep.Skip)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		require.NoError(t, service.DeletePolicy(models.ScanPolicyScopeTag, "Fragile"))
+		require.NoError(t, service.DeletePolicy(models.ScanPolicyScopeNetwork, lan.ID))
+		assert.Error(t, service.DeletePolicy(models.ScanPolicyScopeNetwork, lan.ID))
+
+		sweep, err := service.SweepPolicy(lan)
+		require.NoError(t, err)
+		assert.Equal(t, scanpolicy.SweepPolicy{}, sweep)
+	})
+}
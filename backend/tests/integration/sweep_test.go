@@ -0,0 +1,345 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"reconya-ai/db"
+	"reconya-ai/internal/device"
+	"reconya-ai/internal/network"
+	"reconya-ai/models"
+	"reconya-ai/tests/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sweepDevices returns the devices a sweep of 10.0.0.0/16 would report for
+// the first n hosts
+func sweepDevices(n int) []*models.Device {
+	devices := make([]*models.Device, n)
+	for i := range devices {
+		host := i + 1
+		ip := fmt.Sprintf("10.0.%d.%d", host/256, host%256)
+		mac := fmt.Sprintf("02:00:00:00:%02x:%02x", host/256, host%256)
+		devices[i] = &models.Device{IPv4: ip, MAC: &mac}
+	}
+	return devices
+}
+
+// setupSweepServices returns a device service on a new sweep database
+func setupSweepServices(tb testing.TB) (*device.DeviceService, db.DeviceRepository, *models.Network) {
+	deviceRepo, networkService, net := setupSweepDatabase(tb)
+	deviceService := device.NewDeviceService(deviceRepo, networkService, testutils.GetTestConfig(), nil)
+	return deviceService, deviceRepo, net
+}
+
+// setupSweepDatabase opens a database the way the server does and creates the
+// 10.0.0.0/16 network
+func setupSweepDatabase(tb testing.TB) (db.DeviceRepository, *network.NetworkService, *models.Network) {
+	database, err := db.ConnectToSQLite(filepath.Join(tb.TempDir(), "sweep.db"))
+	require.NoError(tb, err)
+	tb.Cleanup(func() { database.Close() })
+	require.NoError(tb, db.InitializeSchema(database))
+
+	factory := db.NewRepositoryFactory(database, "reconya_test")
+	networkService := network.NewNetworkService(factory.NewNetworkRepository(), testutils.GetTestConfig())
+
+	net, err := networkService.Create("Lab", "10.0.0.0/16", "")
+	require.NoError(tb, err)
+	return factory.NewDeviceRepository(), networkService, net
+}
+
+// racingDeviceRepository runs afterFindAll once the devices are loaded, to
+// save changes while a sweep is being merged
+type racingDeviceRepository struct {
+	db.DeviceRepository
+	afterFindAll func()
+}
+
+func (r *racingDeviceRepository) FindAll(ctx context.Context) ([]*models.Device, error) {
+	devices, err := r.DeviceRepository.FindAll(ctx)
+	if err == nil && r.afterFindAll != nil {
+		r.afterFindAll()
+	}
+	return devices, err
+}
+
+func TestDeviceService_SaveSweep(t *testing.T) {
+	deviceService, deviceRepo, net := setupSweepServices(t)
+	ctx := context.Background()
+
+	t.Run("CreatesDevices", func(t *testing.T) {
+		found := sweepDevices(300)
+		found = append(found, &models.Device{IPv4: "10.0.255.255"})
+
+		saved, err := deviceService.SaveSweep(net.ID, found)
+		require.NoError(t, err)
+		assert.Len(t, saved, 300, "the broadcast address is skipped")
+
+		all, err := deviceRepo.FindAll(ctx)
+		require.NoError(t, err)
+		assert.Len(t, all, 300)
+		for _, d := range saved {
+			assert.NotEmpty(t, d.ID)
+			assert.Equal(t, net.ID, d.NetworkID)
+			assert.Equal(t, models.DeviceStatusOnline, d.Status)
+			assert.NotNil(t, d.LastSeenOnlineAt)
+		}
+	})
+
+	t.Run("KeepsUserFieldsAndIdentity", func(t *testing.T) {
+		first, err := deviceRepo.FindByIP(ctx, "10.0.0.1")
+		require.NoError(t, err)
+		first.Name = "gateway"
+		first.Tags = []string{"core"}
+		_, err = deviceRepo.CreateOrUpdate(ctx, first)
+		require.NoError(t, err)
+
+		moved, err := deviceRepo.FindByIP(ctx, "10.0.0.2")
+		require.NoError(t, err)
+
+		// The second host got a new address; its MAC still identifies it
+		found := sweepDevices(2)
+		found[1].IPv4 = "10.0.100.1"
+		_, err = deviceService.SaveSweep(net.ID, found)
+		require.NoError(t, err)
+
+		gateway, err := deviceRepo.FindByIP(ctx, "10.0.0.1")
+		require.NoError(t, err)
+		assert.Equal(t, first.ID, gateway.ID)
+		assert.Equal(t, "gateway", gateway.Name)
+		assert.Equal(t, []string{"core"}, gateway.Tags)
+		assert.True(t, gateway.CreatedAt.Equal(first.CreatedAt))
+
+		renumbered, err := deviceRepo.FindByIP(ctx, "10.0.100.1")
+		require.NoError(t, err)
+		assert.Equal(t, moved.ID, renumbered.ID)
+	})
+
+	t.Run("UnknownNetwork", func(t *testing.T) {
+		_, err := deviceService.SaveSweep("missing", sweepDevices(1))
+		assert.Error(t, err)
+	})
+}
+
+// TestDeviceService_SaveSweepKeepsConcurrentChanges saves a sweep merged
+// into devices that changed after they were loaded
+func TestDeviceService_SaveSweepKeepsConcurrentChanges(t *testing.T) {
+	deviceRepo, networkService, net := setupSweepDatabase(t)
+	ctx := context.Background()
+	strPtr := func(s string) *string { return &s }
+
+	_, err := device.NewDeviceService(deviceRepo, networkService, testutils.GetTestConfig(), nil).SaveSweep(net.ID, sweepDevices(3))
+	require.NoError(t, err)
+
+	// A port scan and a rename land after the sweep loaded the devices
+	racing := &racingDeviceRepository{DeviceRepository: deviceRepo}
+	racing.afterFindAll = func() {
+		scanned, err := deviceRepo.FindByIP(ctx, "10.0.0.3")
+		require.NoError(t, err)
+		scanned.Name = "printer"
+		scanned.Comment = strPtr("2nd floor")
+		scanned.Tags = []string{"office"}
+		scanned.Ports = []models.Port{{Number: "631", Protocol: "tcp", State: "open", Service: "ipp"}}
+		scannedAt := time.Now()
+		scanned.PortScanEndedAt = &scannedAt
+		_, err = deviceRepo.CreateOrUpdate(ctx, scanned)
+		require.NoError(t, err)
+	}
+	deviceService := device.NewDeviceService(racing, networkService, testutils.GetTestConfig(), nil)
+
+	found := sweepDevices(3)
+	found[2].Hostname = strPtr("printer.lan")
+	_, err = deviceService.SaveSweep(net.ID, found)
+	require.NoError(t, err)
+
+	printer, err := deviceRepo.FindByIP(ctx, "10.0.0.3")
+	require.NoError(t, err)
+	assert.Equal(t, "printer", printer.Name)
+	assert.Equal(t, "2nd floor", *printer.Comment)
+	assert.Equal(t, []string{"office"}, printer.Tags)
+	assert.Len(t, printer.Ports, 1)
+	assert.NotNil(t, printer.PortScanEndedAt)
+	assert.Equal(t, "printer.lan", *printer.Hostname)
+	assert.Equal(t, models.DeviceStatusOnline, printer.Status)
+}
+
+func TestDeviceService_SaveIPv6Sweep(t *testing.T) {
+	deviceService, deviceRepo, net := setupSweepServices(t)
+	ctx := context.Background()
+	strPtr := func(s string) *string { return &s }
+
+	known := sweepDevices(2)
+	_, err := deviceService.SaveSweep(net.ID, known)
+	require.NoError(t, err)
+
+	found := []*models.Device{
+		// A known dual-stack host, by MAC address
+		{MAC: strPtr("02:00:00:00:00:01"), IPv6LinkLocal: strPtr("fe80::1"), IPv6Global: strPtr("2001:db8::1")},
+		// An IPv6-only host, twice from different sources
+		{MAC: strPtr("02:00:00:00:99:01"), IPv6Global: strPtr("2001:db8::99")},
+		{IPv6Global: strPtr("2001:db8::99"), IPv6LinkLocal: strPtr("fe80::99")},
+		// Another IPv6-only host without a MAC address
+		{IPv6Global: strPtr("2001:db8::100")},
+	}
+	saved, err := deviceService.SaveIPv6Sweep(net.ID, found)
+	require.NoError(t, err)
+	assert.Len(t, saved, 3)
+
+	dual, err := deviceRepo.FindByIP(ctx, "10.0.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, "2001:db8::1", *dual.IPv6Global)
+	assert.Equal(t, "fe80::1", *dual.IPv6LinkLocal)
+
+	all, err := deviceRepo.FindAll(ctx)
+	require.NoError(t, err)
+	require.Len(t, all, 4, "IPv6-only hosts are added without an IPv4 address")
+	var ipv6Only []*models.Device
+	for _, d := range all {
+		if d.IPv4 == "" {
+			ipv6Only = append(ipv6Only, d)
+			assert.Equal(t, net.ID, d.NetworkID)
+			assert.Equal(t, models.DeviceStatusOnline, d.Status)
+		}
+	}
+	require.Len(t, ipv6Only, 2)
+
+	// The next sweep finds the IPv6-only hosts again instead of adding them
+	saved, err = deviceService.SaveIPv6Sweep(net.ID, []*models.Device{
+		{IPv6LinkLocal: strPtr("fe80::99")},
+		{IPv6Global: strPtr("2001:db8::100"), IPv6UniqueLocal: strPtr("fd00::100")},
+	})
+	require.NoError(t, err)
+	require.Len(t, saved, 2)
+	all, err = deviceRepo.FindAll(ctx)
+	require.NoError(t, err)
+	assert.Len(t, all, 4)
+
+	// Addresses that aren't found again expire
+	for _, additional := range [][]string{{"2001:db8::3", "2001:db8::4"}, nil} {
+		_, err = deviceService.SaveIPv6Sweep(net.ID, []*models.Device{
+			{MAC: strPtr("02:00:00:00:00:01"), IPv6Global: strPtr("2001:db8::2"), IPv6Addresses: additional},
+		})
+		require.NoError(t, err)
+	}
+	dual, err = deviceRepo.FindByIP(ctx, "10.0.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, "2001:db8::2", *dual.IPv6Global)
+	assert.Nil(t, dual.IPv6LinkLocal, "the link-local address wasn't found again")
+	assert.Empty(t, dual.IPv6Addresses)
+
+	_, err = deviceService.SaveIPv6Sweep("missing", found)
+	assert.Error(t, err)
+}
+
+func TestDeviceService_SaveIPv6SweepLosesGlobalAddress(t *testing.T) {
+	deviceService, deviceRepo, net := setupSweepServices(t)
+	ctx := context.Background()
+	strPtr := func(s string) *string { return &s }
+
+	_, err := deviceService.SaveSweep(net.ID, sweepDevices(1))
+	require.NoError(t, err)
+	_, err = deviceService.SaveIPv6Sweep(net.ID, []*models.Device{{
+		MAC:             strPtr("02:00:00:00:00:01"),
+		IPv6LinkLocal:   strPtr("fe80::1"),
+		IPv6UniqueLocal: strPtr("fd00::1"),
+		IPv6Global:      strPtr("2001:db8::1"),
+	}})
+	require.NoError(t, err)
+
+	// An IPv4 sweep doesn't look for IPv6 addresses and keeps them
+	_, err = deviceService.SaveSweep(net.ID, sweepDevices(1))
+	require.NoError(t, err)
+	device, err := deviceRepo.FindByIP(ctx, "10.0.0.1")
+	require.NoError(t, err)
+	require.NotNil(t, device.IPv6Global)
+	assert.Equal(t, "2001:db8::1", *device.IPv6Global)
+
+	// The prefix went away; the next discovery only finds the link-local address
+	_, err = deviceService.SaveIPv6Sweep(net.ID, []*models.Device{
+		{MAC: strPtr("02:00:00:00:00:01"), IPv6LinkLocal: strPtr("fe80::1")},
+	})
+	require.NoError(t, err)
+
+	device, err = deviceRepo.FindByIP(ctx, "10.0.0.1")
+	require.NoError(t, err)
+	require.NotNil(t, device.IPv6LinkLocal)
+	assert.Equal(t, "fe80::1", *device.IPv6LinkLocal)
+	assert.Nil(t, device.IPv6UniqueLocal)
+	assert.Nil(t, device.IPv6Global)
+	assert.Empty(t, device.IPv6Addresses)
+}
+
+// sweepSizes are result sets of a /24, a /20 and a /16
+var sweepSizes = []int{254, 4094, 65534}
+
+// BenchmarkSaveSweep measures saving a sweep in one transaction, into an empty
+// database and again when every device is already known
+func BenchmarkSaveSweep(b *testing.B) {
+	for _, size := range sweepSizes {
+		b.Run(fmt.Sprintf("new/%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				deviceService, _, net := setupSweepServices(b)
+				found := sweepDevices(size)
+				b.StartTimer()
+
+				if _, err := deviceService.SaveSweep(net.ID, found); err != nil {
+					b.Fatal(err)
+				}
+			}
+			reportDevicesPerSecond(b, size)
+		})
+
+		b.Run(fmt.Sprintf("known/%d", size), func(b *testing.B) {
+			deviceService, _, net := setupSweepServices(b)
+			if _, err := deviceService.SaveSweep(net.ID, sweepDevices(size)); err != nil {
+				b.Fatal(err)
+			}
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				found := sweepDevices(size)
+				b.StartTimer()
+
+				if _, err := deviceService.SaveSweep(net.ID, found); err != nil {
+					b.Fatal(err)
+				}
+			}
+			reportDevicesPerSecond(b, size)
+		})
+	}
+}
+
+// BenchmarkSaveSweepPerDevice is the baseline of one transaction per device
+func BenchmarkSaveSweepPerDevice(b *testing.B) {
+	ctx := context.Background()
+	for _, size := range sweepSizes {
+		b.Run(fmt.Sprintf("new/%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				_, deviceRepo, net := setupSweepServices(b)
+				found := sweepDevices(size)
+				b.StartTimer()
+
+				for _, d := range found {
+					d.NetworkID = net.ID
+					d.Status = models.DeviceStatusOnline
+					if _, err := deviceRepo.CreateOrUpdate(ctx, d); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+			reportDevicesPerSecond(b, size)
+		})
+	}
+}
+
+func reportDevicesPerSecond(b *testing.B, size int) {
+	b.ReportMetric(float64(size*b.N)/b.Elapsed().Seconds(), "devices/s")
+}
@@ -0,0 +1,84 @@
+-- Database as created by InitializeSchema before IPv6 support, device tags
+-- and versioned migrations. Used to check that old installs migrate forward.
+CREATE TABLE networks (
+	id TEXT PRIMARY KEY,
+	cidr TEXT NOT NULL,
+	name TEXT,
+	description TEXT,
+	status TEXT DEFAULT 'active',
+	last_scanned_at TIMESTAMP,
+	device_count INTEGER DEFAULT 0,
+	created_at TIMESTAMP,
+	updated_at TIMESTAMP
+);
+
+CREATE TABLE devices (
+	id TEXT PRIMARY KEY,
+	name TEXT NOT NULL,
+	ipv4 TEXT NOT NULL,
+	mac TEXT,
+	vendor TEXT,
+	status TEXT NOT NULL,
+	network_id TEXT,
+	hostname TEXT,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL,
+	last_seen_online_at TIMESTAMP,
+	port_scan_started_at TIMESTAMP,
+	port_scan_ended_at TIMESTAMP,
+	web_scan_ended_at TIMESTAMP,
+	device_type TEXT,
+	os_name TEXT,
+	os_version TEXT,
+	os_family TEXT,
+	os_confidence INTEGER,
+	comment TEXT,
+	FOREIGN KEY (network_id) REFERENCES networks(id)
+);
+
+CREATE UNIQUE INDEX idx_devices_ipv4 ON devices(ipv4);
+CREATE INDEX idx_devices_mac ON devices(mac);
+
+CREATE TABLE ports (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	device_id TEXT NOT NULL,
+	number TEXT NOT NULL,
+	protocol TEXT NOT NULL,
+	state TEXT NOT NULL,
+	service TEXT NOT NULL,
+	FOREIGN KEY (device_id) REFERENCES devices(id)
+);
+
+CREATE TABLE event_logs (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	type TEXT NOT NULL,
+	description TEXT NOT NULL,
+	device_id TEXT,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE settings (
+	id TEXT PRIMARY KEY,
+	user_id TEXT NOT NULL,
+	screenshots_enabled BOOLEAN NOT NULL DEFAULT 1,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL,
+	UNIQUE(user_id)
+);
+
+INSERT INTO networks (id, cidr, name, status, created_at, updated_at)
+VALUES ('6f1c1d2e-0000-4000-8000-000000000001', '192.168.50.0/24', 'Home', 'active', '2024-05-01 10:00:00', '2024-05-01 10:00:00');
+
+INSERT INTO devices (id, name, ipv4, mac, vendor, status, network_id, hostname, created_at, updated_at, last_seen_online_at, device_type, comment)
+VALUES
+	('6f1c1d2e-0000-4000-8000-000000000101', 'router', '192.168.50.1', 'AA:BB:CC:00:00:01', 'Acme', 'online',
+	 '6f1c1d2e-0000-4000-8000-000000000001', 'gw.lan', '2024-05-01 10:00:00', '2024-05-02 09:00:00', '2024-05-02 09:00:00', 'router', 'upstairs'),
+	('6f1c1d2e-0000-4000-8000-000000000102', 'nas', '192.168.50.20', 'AA:BB:CC:00:00:02', NULL, 'offline',
+	 '6f1c1d2e-0000-4000-8000-000000000001', NULL, '2024-05-01 10:00:00', '2024-05-01 12:00:00', NULL, NULL, NULL);
+
+INSERT INTO ports (device_id, number, protocol, state, service)
+VALUES ('6f1c1d2e-0000-4000-8000-000000000101', '443', 'tcp', 'open', 'https');
+
+INSERT INTO event_logs (type, description, device_id, created_at, updated_at)
+VALUES ('device_online', 'Device online', '6f1c1d2e-0000-4000-8000-000000000101', '2024-05-02 09:00:00', '2024-05-02 09:00:00');
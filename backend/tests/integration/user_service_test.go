@@ -0,0 +1,61 @@
+package integration
+
+import (
+	"context"
+	"testing"
+
+	"reconya-ai/internal/user"
+	"reconya-ai/tests/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserService_Integration(t *testing.T) {
+	factory, cleanup := testutils.SetupTestRepositoryFactory(t)
+	defer cleanup()
+
+	cfg := testutils.GetTestConfig()
+	userRepo := factory.NewUserRepository()
+	userService := user.NewUserService(userRepo, cfg)
+
+	t.Run("CreateStoresHash", func(t *testing.T) {
+		created, err := userService.Create("alice", "correct horse")
+		require.NoError(t, err)
+		assert.NotZero(t, created.ID)
+		assert.Empty(t, created.Password)
+
+		stored, err := userRepo.FindByUsername(context.Background(), "alice")
+		require.NoError(t, err)
+		assert.NotEqual(t, "correct horse", stored.Password)
+	})
+
+	t.Run("CreateRejectsDuplicatesAndShortPasswords", func(t *testing.T) {
+		_, err := userService.Create("alice", "another password")
+		assert.ErrorIs(t, err, user.ErrUserExists)
+
+		_, err = userService.Create(cfg.Username, "another password")
+		assert.ErrorIs(t, err, user.ErrUserExists)
+
+		_, err = userService.Create("bob", "short")
+		assert.Error(t, err)
+	})
+
+	t.Run("Authenticate", func(t *testing.T) {
+		assert.True(t, userService.Authenticate("alice", "correct horse"))
+		assert.False(t, userService.Authenticate("alice", "wrong horse"))
+		assert.False(t, userService.Authenticate("bob", "short"))
+
+		// The configured login keeps working alongside database users
+		assert.True(t, userService.Authenticate(cfg.Username, cfg.Password))
+		assert.False(t, userService.Authenticate(cfg.Username, "wrong"))
+	})
+
+	t.Run("FindAll", func(t *testing.T) {
+		users, err := userService.FindAll()
+		require.NoError(t, err)
+		require.Len(t, users, 1)
+		assert.Equal(t, "alice", users[0].Username)
+		assert.Empty(t, users[0].Password)
+	})
+}
@@ -0,0 +1,138 @@
+package integration
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"reconya-ai/internal/device"
+	"reconya-ai/internal/eventlog"
+	"reconya-ai/internal/exclusion"
+	"reconya-ai/internal/network"
+	"reconya-ai/internal/wol"
+	"reconya-ai/models"
+	"reconya-ai/tests/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePinger struct {
+	up bool
+}
+
+func (p *fakePinger) Ping(ip string) (bool, time.Duration) {
+	return p.up, time.Millisecond
+}
+
+func TestWakeService_Wake(t *testing.T) {
+	factory, cleanup := testutils.SetupTestRepositoryFactory(t)
+	defer cleanup()
+
+	cfg := testutils.GetTestConfig()
+	networkService := network.NewNetworkService(factory.NewNetworkRepository(), cfg)
+	deviceService := device.NewDeviceService(factory.NewDeviceRepository(), networkService, cfg, nil)
+	eventLogService := eventlog.NewEventLogService(factory.NewEventLogRepository(), deviceService)
+	service := wol.NewWakeService(deviceService, networkService, eventLogService, cfg)
+
+	type sent struct {
+		packet []byte
+		target wol.Target
+		port   int
+	}
+	var sends []sent
+	service.Send = func(packet []byte, target wol.Target, port int) error {
+		sends = append(sends, sent{packet, target, port})
+		return nil
+	}
+	var targets []string
+	service.Target = func(cidr string) wol.Target {
+		targets = append(targets, cidr)
+		return wol.Target{Interface: "eth0", LocalIP: net.ParseIP("192.168.1.2"), Broadcasts: []net.IP{net.ParseIP("192.168.1.255"), net.IPv4bcast}}
+	}
+	pinger := &fakePinger{}
+	service.Pinger = pinger
+
+	lan, err := networkService.Create("LAN", "192.168.1.0/24", "")
+	require.NoError(t, err)
+	mac := "AA:BB:CC:00:00:10"
+	created, err := deviceService.CreateOrUpdate(&models.Device{Name: "nas", IPv4: "192.168.1.10", MAC: &mac, NetworkID: lan.ID, Status: models.DeviceStatusOffline})
+	require.NoError(t, err)
+
+	wakeEvents := func() []*models.WakePayload {
+		page, err := eventLogService.Search(models.EventLogFilter{Types: []models.EEventLogType{models.WakeOnLAN}}, "", 50)
+		require.NoError(t, err)
+		var payloads []*models.WakePayload
+		for _, eventLog := range page.EventLogs {
+			payload, err := eventLog.DecodePayload()
+			require.NoError(t, err)
+			payloads = append(payloads, payload.(*models.WakePayload))
+		}
+		return payloads
+	}
+
+	t.Run("Send", func(t *testing.T) {
+		result, err := service.Wake(created, wol.WakeOptions{Actor: models.UserActor("admin")})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"192.168.1.0/24"}, targets)
+		require.Len(t, sends, 1)
+		assert.Len(t, sends[0].packet, 102)
+		assert.Equal(t, 9, sends[0].port)
+		assert.Equal(t, "eth0", result.Interface)
+		assert.Equal(t, []string{"192.168.1.255", "255.255.255.255"}, result.Broadcasts)
+		assert.Nil(t, result.Confirmed)
+
+		payloads := wakeEvents()
+		require.Len(t, payloads, 1)
+		assert.Equal(t, "user:admin", payloads[0].Actor)
+		assert.Equal(t, mac, payloads[0].MAC)
+		assert.Nil(t, payloads[0].Confirmed)
+	})
+
+	t.Run("NotConfirmed", func(t *testing.T) {
+		result, err := service.Wake(created, wol.WakeOptions{Confirm: true, Timeout: 10 * time.Millisecond})
+		require.NoError(t, err)
+		require.NotNil(t, result.Confirmed)
+		assert.False(t, *result.Confirmed)
+
+		payloads := wakeEvents()
+		require.Len(t, payloads, 3)
+		require.NotNil(t, payloads[0].Confirmed)
+		assert.False(t, *payloads[0].Confirmed)
+	})
+
+	t.Run("Confirmed", func(t *testing.T) {
+		pinger.up = true
+		result, err := service.Wake(created, wol.WakeOptions{Confirm: true})
+		require.NoError(t, err)
+		require.NotNil(t, result.Confirmed)
+		assert.True(t, *result.Confirmed)
+		assert.NotNil(t, result.OnlineAfterSeconds)
+
+		woken, err := deviceService.FindByID(created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.DeviceStatusOnline, woken.Status)
+
+		page, err := eventLogService.Search(models.EventLogFilter{Types: []models.EEventLogType{models.DeviceOnline}}, "", 10)
+		require.NoError(t, err)
+		assert.Len(t, page.EventLogs, 1)
+	})
+
+	t.Run("NoMAC", func(t *testing.T) {
+		_, err := service.Wake(&models.Device{IPv4: "192.168.1.20"}, wol.WakeOptions{})
+		assert.ErrorIs(t, err, wol.ErrNoMAC)
+	})
+
+	t.Run("Excluded", func(t *testing.T) {
+		exclusions := exclusion.NewExclusionService(factory.NewExclusionRepository())
+		_, err := exclusions.Add(lan.ID, "192.168.1.10", "")
+		require.NoError(t, err)
+		service.Exclusions = exclusions
+		defer func() { service.Exclusions = nil }()
+
+		_, err = service.Wake(created, wol.WakeOptions{Confirm: true})
+		assert.ErrorIs(t, err, exclusion.ErrExcluded, "excluded devices aren't pinged")
+		_, err = service.Wake(created, wol.WakeOptions{})
+		assert.NoError(t, err)
+	})
+}
@@ -2,17 +2,23 @@ package testutils
 
 import (
 	"database/sql"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"reconya-ai/db"
 	"reconya-ai/internal/config"
 
+	"github.com/google/uuid"
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/stretchr/testify/require"
 )
 
+// PostgresURLEnv names the variable pointing the tests at a PostgreSQL server
+const PostgresURLEnv = "RECONYA_TEST_POSTGRES_URL"
+
 func SetupTestDatabase(t *testing.T) (*sql.DB, func()) {
 	tempDir := t.TempDir()
 	dbPath := filepath.Join(tempDir, "test.db")
@@ -37,12 +43,64 @@ func SetupTestRepositoryFactory(t *testing.T) (*db.RepositoryFactory, func()) {
 	return factory, cleanup
 }
 
+// SetupTestPostgresDatabase migrates a fresh schema on the server named by
+// RECONYA_TEST_POSTGRES_URL, or on a PostgreSQL container when it is not set
+// and Docker is available. Without either the test is skipped, except in CI
+// where it fails. Each test gets its own schema, dropped again on cleanup.
+func SetupTestPostgresDatabase(t *testing.T) (*sql.DB, func()) {
+	url, err := postgresTestURL()
+	if err != nil {
+		if os.Getenv("CI") != "" {
+			t.Fatalf("PostgreSQL tests cannot run: %v", err)
+		}
+		t.Skipf("Skipping PostgreSQL tests: %v", err)
+	}
+
+	adminDB, err := db.ConnectToPostgres(url)
+	require.NoError(t, err)
+
+	schema := "reconya_test_" + strings.ReplaceAll(uuid.New().String(), "-", "")
+	_, err = adminDB.Exec(fmt.Sprintf(`CREATE SCHEMA %s`, schema))
+	require.NoError(t, err)
+
+	testDB, err := db.ConnectToPostgres(withSearchPath(url, schema))
+	require.NoError(t, err)
+
+	err = db.InitializeSchema(testDB)
+	require.NoError(t, err)
+
+	cleanup := func() {
+		testDB.Close()
+		adminDB.Exec(fmt.Sprintf(`DROP SCHEMA %s CASCADE`, schema))
+		adminDB.Close()
+	}
+
+	return testDB, cleanup
+}
+
+func SetupTestPostgresRepositoryFactory(t *testing.T) (*db.RepositoryFactory, func()) {
+	testDB, cleanup := SetupTestPostgresDatabase(t)
+	factory := db.NewPostgresRepositoryFactory(testDB, "reconya_test")
+	return factory, cleanup
+}
+
+// withSearchPath adds a search_path run-time parameter to a URL or
+// keyword/value connection string
+func withSearchPath(dsn, schema string) string {
+	if !strings.Contains(dsn, "://") {
+		return dsn + " search_path=" + schema
+	}
+	if strings.Contains(dsn, "?") {
+		return dsn + "&search_path=" + schema
+	}
+	return dsn + "?search_path=" + schema
+}
+
 func GetTestConfig() *config.Config {
 	return &config.Config{
 		DatabaseType: config.SQLite,
 		SQLitePath:   ":memory:",
 		DatabaseName: "reconya_test",
-		NetworkCIDR:  "192.168.1.0/24",
 		JwtKey:       []byte("test_jwt_secret_key_for_testing_only"),
 		Username:     "test_admin",
 		Password:     "test_password",
@@ -0,0 +1,85 @@
+package testutils
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"reconya-ai/db"
+)
+
+// postgresImage is the image of the throwaway server started when
+// RECONYA_TEST_POSTGRES_URL is not set
+const postgresImage = "postgres:16-alpine"
+
+var (
+	postgresOnce      sync.Once
+	postgresURL       string
+	postgresContainer string
+	postgresErr       error
+)
+
+// postgresTestURL returns RECONYA_TEST_POSTGRES_URL or, without it, the URL of
+// a PostgreSQL container started for the test binary, which StopPostgres
+// removes again
+func postgresTestURL() (string, error) {
+	if url := os.Getenv(PostgresURLEnv); url != "" {
+		return url, nil
+	}
+	postgresOnce.Do(func() {
+		postgresURL, postgresContainer, postgresErr = startPostgresContainer()
+	})
+	return postgresURL, postgresErr
+}
+
+func startPostgresContainer() (string, string, error) {
+	if _, err := exec.LookPath("docker"); err != nil {
+		return "", "", fmt.Errorf("%s is not set and docker is not available", PostgresURLEnv)
+	}
+
+	out, err := exec.Command("docker", "run", "-d", "--rm",
+		"-e", "POSTGRES_PASSWORD=reconya", "-e", "POSTGRES_DB=reconya_test",
+		"-p", "127.0.0.1::5432", postgresImage).Output()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to start %s container: %v", postgresImage, err)
+	}
+	container := strings.TrimSpace(string(out))
+
+	out, err = exec.Command("docker", "port", container, "5432/tcp").Output()
+	if err != nil {
+		removeContainer(container)
+		return "", "", fmt.Errorf("failed to find the port of the PostgreSQL container: %v", err)
+	}
+	address := strings.TrimSpace(strings.SplitN(string(out), "\n", 2)[0])
+	url := fmt.Sprintf("postgres://postgres:reconya@%s/reconya_test?sslmode=disable", address)
+
+	// The server only listens on TCP once the image has initialized it
+	deadline := time.Now().Add(time.Minute)
+	for {
+		conn, err := db.ConnectToPostgres(url)
+		if err == nil {
+			conn.Close()
+			return url, container, nil
+		}
+		if time.Now().After(deadline) {
+			removeContainer(container)
+			return "", "", fmt.Errorf("PostgreSQL container did not come up: %v", err)
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
+// StopPostgres removes the PostgreSQL container started for the tests, if
+// any. Packages using the PostgreSQL helpers call it from TestMain.
+func StopPostgres() {
+	if postgresContainer != "" {
+		removeContainer(postgresContainer)
+	}
+}
+
+func removeContainer(container string) {
+	exec.Command("docker", "rm", "-f", container).Run()
+}
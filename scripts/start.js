@@ -158,7 +158,7 @@ class ServiceManager {
       return;
     }
 
-    this.backendProcess = spawn(goPath, ['run', './cmd'], {
+    this.backendProcess = spawn(goPath, ['run', '-tags', 'sqlite_fts5', './cmd'], {
       cwd: backendPath,
       stdio: ['ignore', 'pipe', 'pipe'],
       shell: Utils.isWindows(),